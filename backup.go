@@ -0,0 +1,53 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// backupGeneration copies the files a previous run generated in dir, with their
+// manifest and the lockfile if it is there, to a directory named after the
+// current time in dir's backup directory (dir with .bak appended), outside dir so
+// that LuaLS doesn't read the backed up definitions as well. It returns the
+// directory of the backup.
+func backupGeneration(dir string, files []string) (string, error) {
+	backupDir := filepath.Join(filepath.Clean(dir)+".bak", time.Now().Format("20060102-150405"))
+	files = slices.Concat(files, []string{generatedFilesName, lockFileName})
+	for _, file := range files {
+		if !filepath.IsLocal(filepath.FromSlash(file)) {
+			continue
+		}
+		src := filepath.Join(dir, filepath.FromSlash(file))
+		if !fileExists(src) {
+			continue // Pruned by hand, or no lockfile in dir
+		}
+		if err := copyFile(src, filepath.Join(backupDir, filepath.FromSlash(file))); err != nil {
+			return "", err
+		}
+	}
+	return backupDir, nil
+}
+
+// copyFile copies a file, creating the directories of dst.
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/generator"
+)
+
+func TestBackupGeneration(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "factorio")
+	files := map[string]string{
+		"runtime.lua":                   "---@meta\n",
+		"runtime/classes/LuaEntity.lua": "---@class LuaEntity\n",
+		generatedFilesName:              "runtime.lua\nruntime/classes/LuaEntity.lua\n",
+		lockFileName:                    "{}\n",
+		"notes.md":                      "the user's\n",
+	}
+	for file, content := range files {
+		writeTestFile(t, filepath.Join(dir, filepath.FromSlash(file)), content)
+	}
+	writeTestFile(t, filepath.Join(root, "outside.lua"), "")
+
+	backupDir, err := backupGeneration(dir, []string{"runtime.lua", "runtime/classes/LuaEntity.lua", "pruned.lua", "../outside.lua"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(backupDir) != dir+".bak" {
+		t.Errorf("backed up to %s, want a directory in %s.bak", backupDir, dir)
+	}
+	for file, content := range files {
+		data, err := os.ReadFile(filepath.Join(backupDir, filepath.FromSlash(file)))
+		switch {
+		case file == "notes.md":
+			if !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("%s, which wasn't generated, was backed up", file)
+			}
+		case err != nil:
+			t.Errorf("%s wasn't backed up: %v", file, err)
+		case string(data) != content:
+			t.Errorf("backup of %s = %q, want %q", file, data, content)
+		}
+	}
+	for _, file := range []string{"pruned.lua", "outside.lua"} {
+		if fileExists(filepath.Join(backupDir, file)) {
+			t.Errorf("%s was backed up", file)
+		}
+	}
+	// The definitions themselves are left as they are.
+	if data, _ := os.ReadFile(filepath.Join(dir, "runtime.lua")); string(data) != files["runtime.lua"] {
+		t.Errorf("runtime.lua = %q after the backup", data)
+	}
+}
+
+func TestKeepUserFiles(t *testing.T) {
+	defer func(saved bool) { force = saved }(force)
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "runtime.lua"), "generated before\n")
+	writeTestFile(t, filepath.Join(dir, "prototype.lua"), "the user's\n")
+	previous := []string{"runtime.lua"}
+
+	write := func(filename string) error {
+		w, err := keepUserFiles(dir, previous, generator.DirCreator(dir))(filename)
+		if err != nil {
+			return err
+		}
+		io.WriteString(w, "generated\n")
+		return w.Close()
+	}
+
+	force = false
+	for _, filename := range []string{"runtime.lua", "builtin.lua", "runtime/classes/LuaEntity.lua"} {
+		if err := write(filename); err != nil {
+			t.Errorf("writing %s: %v", filename, err)
+		}
+	}
+	err := write("prototype.lua")
+	if err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("writing the user's prototype.lua: %v, want a refusal", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "prototype.lua")); string(data) != "the user's\n" {
+		t.Errorf("the user's prototype.lua = %q after the refusal", data)
+	}
+
+	force = true
+	if err := write("prototype.lua"); err != nil {
+		t.Errorf("writing prototype.lua with --force: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "prototype.lua")); string(data) != "generated\n" {
+		t.Errorf("prototype.lua = %q with --force", data)
+	}
+}
+
+func TestKeepUserFilesWithoutManifest(t *testing.T) {
+	defer func(saved bool) { force = saved }(force)
+	force = false
+	// Generated before the manifest was kept: the generated files are told apart
+	// from the user's by their headers.
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "runtime.lua"), "---@meta\n\n-- Auto-generated Factorio Runtime API definitions\n-- Generated from: runtime-api.json\n")
+	writeTestFile(t, filepath.Join(dir, "runtime/classes/LuaEntity.lua"), "---@meta\n\n-- Auto-generated Factorio Runtime API definitions\n")
+	writeTestFile(t, filepath.Join(dir, "runtime.d.ts"), "// Auto-generated Factorio Runtime API definitions\n")
+	writeTestFile(t, filepath.Join(dir, "prototype.lua"), "-- The user's annotations\n---@class MyPrototype\n")
+	writeTestFile(t, filepath.Join(dir, ".git/runtime.lua"), "-- Auto-generated Factorio Runtime API definitions\n")
+
+	previous, err := readGeneratedFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"runtime/classes/LuaEntity.lua", "runtime.d.ts", "runtime.lua"}; !slices.Equal(previous, want) {
+		t.Errorf("generated files without a manifest = %q, want %q", previous, want)
+	}
+
+	create := keepUserFiles(dir, previous, generator.DirCreator(dir))
+	for _, filename := range []string{"runtime.lua", "runtime/classes/LuaEntity.lua", "runtime.d.ts"} {
+		w, err := create(filename)
+		if err != nil {
+			t.Errorf("writing %s: %v", filename, err)
+			continue
+		}
+		w.Close()
+	}
+	if _, err := create("prototype.lua"); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("writing the user's prototype.lua: %v, want a refusal", err)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/apidiff"
+	"github.com/spf13/cobra"
+)
+
+var changelogCmd = &cobra.Command{
+	Use:   "changelog",
+	Short: "Render the changes between two versions of the Factorio API as a Markdown changelog",
+	Long: `Compares the APIs of the --from and --to versions like diff does (--only limits it
+to one stage), and prints the changes as Markdown: a section per stage and per kind
+of definition (classes, events, concepts, ...), with the changes of each class or
+concept under its name, breaking changes and deprecations marked. Paste it into the
+migration notes of a mod, or redirect it to a file.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Stdout carries the changelog.
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
+
+		if diffFrom == "" || diffTo == "" {
+			log.Fatalf("Fatal error: changelog needs --from and --to")
+		}
+		if only != "" && only != "runtime" && only != "prototype" {
+			log.Fatalf("Fatal error: unknown --only %q (expected %q or %q)", only, "runtime", "prototype")
+		}
+
+		oldRuntime, oldPrototype := loadAPIVersion(diffFrom)
+		newRuntime, newPrototype := loadAPIVersion(diffTo)
+		fmt.Print(apidiff.Compare(oldRuntime, newRuntime, oldPrototype, newPrototype).Markdown())
+	},
+}
+
+func init() {
+	changelogCmd.Flags().StringVar(&diffFrom, "from", "", "The old version: a game version (e.g. 2.0.40) or the URL of a directory with the API JSON files")
+	changelogCmd.Flags().StringVar(&diffTo, "to", "", "The new version, like --from")
+	rootCmd.AddCommand(changelogCmd)
+}
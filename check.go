@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// staleFiles counts the files --check found differing from the generated ones.
+var staleFiles int
+
+// checkGenerated compares the files generated for dir with those in it, logging
+// each difference like a diff summary: files that differ, with the first line
+// that does, that are missing, and that a previous run generated (per its
+// manifest) but this one doesn't. It returns the number of differences.
+func checkGenerated(dir string, files map[string]string, previous []string) int {
+	differences := 0
+	for _, file := range slices.Sorted(maps.Keys(files)) {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		data, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			log.Printf("missing: %s", path)
+		case err != nil:
+			log.Fatalf("Fatal error reading %s: %v", path, err)
+		case string(data) != files[file]:
+			log.Printf("differs: %s (%s)", path, lineDifference(string(data), files[file]))
+		default:
+			continue
+		}
+		differences++
+	}
+	for _, file := range previous {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		if _, ok := files[file]; !ok && fileExists(path) {
+			log.Printf("stale: %s (no longer generated)", path)
+			differences++
+		}
+	}
+	return differences
+}
+
+// lineDifference describes where the committed content of a file first differs
+// from the generated one.
+func lineDifference(committed string, generated string) string {
+	committedLines, generatedLines := strings.Split(committed, "\n"), strings.Split(generated, "\n")
+	line := 0
+	for line < len(committedLines) && line < len(generatedLines) && committedLines[line] == generatedLines[line] {
+		line++
+	}
+	return fmt.Sprintf("first difference at line %d; %d lines committed, %d generated", line+1, len(committedLines), len(generatedLines))
+}
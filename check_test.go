@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCheckGenerated(t *testing.T) {
+	dir := t.TempDir()
+	for file, content := range map[string]string{
+		"runtime.lua":    "---@meta\nsame\n",
+		"prototype.lua":  "---@meta\ncommitted\nend\n",
+		"old/stale.lua":  "",
+		"their-code.lua": "",
+	} {
+		writeTestFile(t, filepath.Join(dir, filepath.FromSlash(file)), content)
+	}
+	generated := map[string]string{
+		"runtime.lua":   "---@meta\nsame\n",
+		"prototype.lua": "---@meta\ngenerated\nend\n",
+		"builtin.lua":   "---@meta\n",
+	}
+	// prototype.lua differs, builtin.lua is missing and old/stale.lua is stale;
+	// their-code.lua isn't the generator's, and gone.lua was removed already.
+	if differences := checkGenerated(dir, generated, []string{"runtime.lua", "old/stale.lua", "gone.lua"}); differences != 3 {
+		t.Errorf("checkGenerated = %d differences, want 3", differences)
+	}
+	if differences := checkGenerated(dir, map[string]string{"runtime.lua": "---@meta\nsame\n"}, []string{"runtime.lua"}); differences != 0 {
+		t.Errorf("checkGenerated of up-to-date files = %d differences, want 0", differences)
+	}
+}
+
+func TestLineDifference(t *testing.T) {
+	tests := []struct {
+		committed, generated, want string
+	}{
+		{"a\nb\nc\n", "a\nx\nc\n", "first difference at line 2; 4 lines committed, 4 generated"},
+		{"a\n", "a\nb\n", "first difference at line 2; 2 lines committed, 3 generated"},
+		{"", "a", "first difference at line 1; 1 lines committed, 1 generated"},
+	}
+	for _, test := range tests {
+		if got := lineDifference(test.committed, test.generated); got != test.want {
+			t.Errorf("lineDifference(%q, %q) = %q, want %q", test.committed, test.generated, got, test.want)
+		}
+	}
+}
+
+// --check exits with 1 when the output directory doesn't hold the definitions
+// the options generate, and leaves it as it is.
+func TestCheckExitStatus(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "factorio")
+	flags := append(fixtureFlags(t), "--output", dir)
+	if code, output := runCommand(t, flags...); code != 0 {
+		t.Fatalf("generating exited with %d:\n%s", code, output)
+	}
+	if code, output := runCommand(t, slices.Concat(flags, []string{"--check"})...); code != 0 {
+		t.Errorf("--check of fresh definitions exited with %d:\n%s", code, output)
+	}
+
+	runtimeFile := filepath.Join(dir, "runtime.lua")
+	data, err := os.ReadFile(runtimeFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := strings.Replace(string(data), "---@class LuaBootstrap", "---@class LuaBootstrapped", 1)
+	if err := os.WriteFile(runtimeFile, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	code, output := runCommand(t, slices.Concat(flags, []string{"--check"})...)
+	if code != 1 {
+		t.Errorf("--check of edited definitions exited with %d, want 1:\n%s", code, output)
+	}
+	if !strings.Contains(output, "differs: "+runtimeFile) {
+		t.Errorf("--check didn't report the edited %s:\n%s", runtimeFile, output)
+	}
+	if data, _ := os.ReadFile(runtimeFile); string(data) != edited {
+		t.Error("--check rewrote the edited runtime.lua")
+	}
+
+	// A file the options no longer generate is stale.
+	if code, output := runCommand(t, slices.Concat(flags, []string{"--check", "--only", "runtime"})...); code != 1 || !strings.Contains(output, "stale: "+filepath.Join(dir, "prototype.lua")) {
+		t.Errorf("--check --only runtime exited with %d, want 1 with prototype.lua stale:\n%s", code, output)
+	}
+}
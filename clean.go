@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// generatedFilesName is the manifest of the files generated in an output
+// directory: their paths relative to it, slash-separated, one per line. --prune
+// and clean remove the files it lists that a later run doesn't generate, and
+// nothing else, so files of the user's in the output directory are safe.
+const generatedFilesName = ".factorio-api-gen-files"
+
+// cleaning is set by clean: the definitions are generated without writing them,
+// only to remove the stale files of the previous run.
+var cleaning bool
+
+var cleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove the generated files the current options no longer generate",
+	Long: `Removes the files a previous run generated in the output directory (or each
+--factorio-version directory) that a run with the given options doesn't generate,
+such as the per-class files of --split-files after going back to single files, or those
+of classes a new game version renamed. Stale files confuse LuaLS, which keeps
+reading their definitions. The definitions are generated in memory to know which
+files are current, so the generation flags must be those of the run to clean up
+after; nothing is written besides the list of generated files, which each run
+keeps in ` + generatedFilesName + `. Files the tool didn't write are never removed.
+
+--prune does the same while generating.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		switch {
+		case watch:
+			log.Fatalf("Fatal error: clean removes stale files once, without --watch")
+		case workspaceDir != "":
+			log.Fatalf("Fatal error: clean leaves the workspace as it is, without --workspace")
+		}
+		cleaning = true
+		rootCmd.Run(cmd, nil)
+	},
+}
+
+// readGeneratedFiles returns the files of the manifest in dir. Without a
+// manifest, as in directories generated before it was kept, they are the files
+// starting with a header of the generator (see headerFiles).
+func readGeneratedFiles(dir string) ([]string, error) {
+	f, err := os.Open(filepath.Join(dir, generatedFilesName))
+	if errors.Is(err, os.ErrNotExist) {
+		return headerFiles(dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var files []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, scanner.Err()
+}
+
+// generatedHeaders are the comments the generator starts its Lua, Teal,
+// TypeScript and rockspec files with. The other files it writes (JSON, Markdown,
+// HTML) have no header, and are only known to be generated from the manifest.
+var generatedHeaders = [][]byte{
+	[]byte("-- Auto-generated Factorio "),
+	[]byte("// Auto-generated Factorio "),
+	[]byte("-- Generated by factorio-api-gen "),
+}
+
+// headerFiles returns the files of dir, slash-separated and relative to it, that
+// start with one of generatedHeaders, or none if dir doesn't exist. Hidden
+// directories, such as .git or .vscode, are skipped.
+func headerFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case entry.IsDir():
+			if path != dir && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		case !entry.Type().IsRegular():
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		// The header follows the ---@meta line and a blank line at most.
+		head := make([]byte, 256)
+		n, err := io.ReadFull(f, head)
+		f.Close()
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+			return err
+		}
+		lines := bytes.SplitN(head[:n], []byte("\n"), 4)
+		for _, line := range lines[:min(len(lines), 3)] {
+			if slices.ContainsFunc(generatedHeaders, func(header []byte) bool { return bytes.HasPrefix(line, header) }) {
+				rel, _ := filepath.Rel(dir, path)
+				files = append(files, filepath.ToSlash(rel))
+				break
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return files, err
+}
+
+// writeGeneratedFiles writes the manifest of the files generated in dir.
+func writeGeneratedFiles(dir string, files []string) error {
+	files = slices.Sorted(slices.Values(files))
+	var sb strings.Builder
+	for _, file := range slices.Compact(files) {
+		sb.WriteString(file + "\n")
+	}
+	return os.WriteFile(filepath.Join(dir, generatedFilesName), []byte(sb.String()), 0644)
+}
+
+// pruneFiles removes the files of previous that aren't in current from dir, and
+// the directories of dir they leave empty, such as those of --split-files. It
+// returns the files removed, as listed. Paths leaving dir are ignored: the
+// manifest only lists files inside it.
+func pruneFiles(dir string, previous []string, current []string) []string {
+	var removed []string
+	for _, file := range previous {
+		if slices.Contains(current, file) || !filepath.IsLocal(filepath.FromSlash(file)) {
+			continue
+		}
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		if err := os.Remove(path); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				log.Printf("Warning: not removing %s: %v", path, err)
+			}
+			continue
+		}
+		removed = append(removed, file)
+		// Removing a non-empty directory fails, which ends the walk up.
+		for parent := filepath.Dir(path); parent != filepath.Clean(dir); parent = filepath.Dir(parent) {
+			if os.Remove(parent) != nil {
+				break
+			}
+		}
+	}
+	return removed
+}
+
+func init() {
+	rootCmd.AddCommand(cleanCmd)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestGeneratedFiles(t *testing.T) {
+	dir := t.TempDir()
+	if files, err := readGeneratedFiles(dir); err != nil || files != nil {
+		t.Fatalf("readGeneratedFiles without a manifest = %q, %v", files, err)
+	}
+	if err := writeGeneratedFiles(dir, []string{"runtime.lua", "builtin.lua", "runtime.lua", "runtime/classes/LuaEntity.lua"}); err != nil {
+		t.Fatal(err)
+	}
+	files, err := readGeneratedFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Sorted and without duplicates.
+	if want := []string{"builtin.lua", "runtime.lua", "runtime/classes/LuaEntity.lua"}; !slices.Equal(files, want) {
+		t.Errorf("readGeneratedFiles = %q, want %q", files, want)
+	}
+
+	// Blank lines and surrounding spaces, as left by editing the manifest by hand,
+	// are ignored.
+	if err := os.WriteFile(filepath.Join(dir, generatedFilesName), []byte("\n  runtime.lua \n\nbuiltin.lua"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := readGeneratedFiles(dir); !slices.Equal(files, []string{"runtime.lua", "builtin.lua"}) {
+		t.Errorf("readGeneratedFiles of an edited manifest = %q", files)
+	}
+}
+
+func TestPruneFiles(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "out")
+	for _, file := range []string{
+		"out/runtime.lua",
+		"out/runtime/classes/LuaEntity.lua",
+		"out/runtime/classes/LuaPlayer.lua",
+		"out/prototype/prototypes/item.lua",
+		"out/notes.md", // The user's, not in the manifest
+		"outside.lua",
+		"out-other/runtime.lua",
+	} {
+		writeTestFile(t, filepath.Join(root, file), "")
+	}
+
+	previous := []string{
+		"runtime.lua",
+		"runtime/classes/LuaEntity.lua",
+		"runtime/classes/LuaPlayer.lua",
+		"prototype/prototypes/item.lua",
+		"missing.lua",
+		// Entries that aren't local paths are never removed.
+		"../outside.lua",
+		"../out-other/runtime.lua",
+		"runtime/../../outside.lua",
+		filepath.ToSlash(filepath.Join(root, "outside.lua")),
+		"",
+	}
+	current := []string{"runtime.lua", "runtime/classes/LuaEntity.lua"}
+	removed := pruneFiles(dir, previous, current)
+	if want := []string{"runtime/classes/LuaPlayer.lua", "prototype/prototypes/item.lua"}; !slices.Equal(removed, want) {
+		t.Errorf("pruneFiles removed %q, want %q", removed, want)
+	}
+
+	for file, exists := range map[string]bool{
+		"out/runtime.lua":                   true,
+		"out/runtime/classes/LuaEntity.lua": true,
+		"out/runtime/classes/LuaPlayer.lua": false,
+		"out/prototype":                     false, // Left empty
+		"out/notes.md":                      true,
+		"outside.lua":                       true,
+		"out-other/runtime.lua":             true,
+	} {
+		if fileExists(filepath.Join(root, file)) != exists {
+			t.Errorf("%s exists: %v, want %v", file, !exists, exists)
+		}
+	}
+	if !fileExists(dir) {
+		t.Error("the output directory was removed")
+	}
+}
+
+// writeTestFile writes a file, creating its directories.
+func writeTestFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/apidiff"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffFrom   string
+	diffTo     string
+	diffReport string
+	diffFailOn string
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare two versions of the Factorio API and report the breaking changes",
+	Long: `Downloads the runtime and prototype APIs of the --from and --to versions (--only
+limits it to one stage) and lists their differences by severity: breaking changes
+(removed classes, methods and attributes, changed types, new required parameters),
+warnings (deprecations) and compatible changes (additions). A version is either a
+game version documented on lua-api.factorio.com, e.g. 2.0.45 or latest, or the URL
+of a directory holding runtime-api.json and prototype-api.json.
+
+--report writes the changes as JSON as well, for CI. With --fail-on, the command
+exits with status 1 if a change is at least that severe, e.g. to fail the build of
+a mod when the version it pins changes incompatibly.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Stdout carries the report.
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
+
+		if diffFrom == "" || diffTo == "" {
+			log.Fatalf("Fatal error: diff needs --from and --to")
+		}
+		if only != "" && only != "runtime" && only != "prototype" {
+			log.Fatalf("Fatal error: unknown --only %q (expected %q or %q)", only, "runtime", "prototype")
+		}
+		if diffFailOn != "" && !slices.Contains(apidiff.Severities, apidiff.Severity(diffFailOn)) {
+			log.Fatalf("Fatal error: unknown --fail-on %q (expected %q, %q or %q)", diffFailOn, apidiff.SeverityBreaking, apidiff.SeverityWarning, apidiff.SeverityInfo)
+		}
+
+		oldRuntime, oldPrototype := loadAPIVersion(diffFrom)
+		newRuntime, newPrototype := loadAPIVersion(diffTo)
+		report := apidiff.Compare(oldRuntime, newRuntime, oldPrototype, newPrototype)
+		fmt.Print(report.Text())
+
+		if diffReport != "" {
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				log.Fatalf("Fatal error encoding the report: %v", err)
+			}
+			if err := os.WriteFile(diffReport, append(data, '\n'), 0644); err != nil {
+				log.Fatalf("Fatal error writing the report: %v", err)
+			}
+		}
+		if max, ok := report.Max(); ok && diffFailOn != "" && max.AtLeast(apidiff.Severity(diffFailOn)) {
+			os.Exit(1)
+		}
+	},
+}
+
+// apiVersionURL returns the URL of the directory holding the API documents of a
+// version: the given URL, or the version's directory on lua-api.factorio.com.
+func apiVersionURL(version string) string {
+	if strings.Contains(version, "://") {
+		return strings.TrimSuffix(version, "/")
+	}
+	return "https://lua-api.factorio.com/" + version
+}
+
+// loadAPIVersion downloads the APIs of a version (see apiVersionURL). A stage
+// excluded by --only is left nil.
+func loadAPIVersion(version string) (*api.API, *api.API) {
+	var runtimeAPI, prototypeAPI *api.API
+	if only != "prototype" {
+		runtimeAPI = &api.API{}
+		url := apiVersionURL(version) + "/runtime-api.json"
+		if err := api.DownloadAndParseAPI(url, runtimeAPI); err != nil {
+			log.Fatalf("Fatal error downloading/parsing runtime API from %s: %v", url, err)
+		}
+	}
+	if only != "runtime" {
+		prototypeAPI = &api.API{}
+		url := apiVersionURL(version) + "/prototype-api.json"
+		if err := api.DownloadAndParseAPI(url, prototypeAPI); err != nil {
+			log.Fatalf("Fatal error downloading/parsing prototype API from %s: %v", url, err)
+		}
+	}
+	return runtimeAPI, prototypeAPI
+}
+
+func init() {
+	diffCmd.Flags().StringVar(&diffFrom, "from", "", "The old version: a game version (e.g. 2.0.40) or the URL of a directory with the API JSON files")
+	diffCmd.Flags().StringVar(&diffTo, "to", "", "The new version, like --from")
+	diffCmd.Flags().StringVar(&diffReport, "report", "", "Also write the changes as JSON to this file")
+	diffCmd.Flags().StringVar(&diffFailOn, "fail-on", "", "Exit with status 1 on changes at least this severe: 'breaking', 'warning' or 'info'")
+	rootCmd.AddCommand(diffCmd)
+}
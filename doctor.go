@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/generator"
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+var userDataDir string
+
+// diagnosis collects the results of the doctor's checks.
+type diagnosis struct {
+	problems int
+}
+
+func (d *diagnosis) ok(format string, args ...any) {
+	fmt.Printf("ok       %s\n", fmt.Sprintf(format, args...))
+}
+
+// skipped reports a check that couldn't run, which isn't a problem.
+func (d *diagnosis) skipped(format string, args ...any) {
+	fmt.Printf("skipped  %s\n", fmt.Sprintf(format, args...))
+}
+
+func (d *diagnosis) problem(fix string, format string, args ...any) {
+	d.problems++
+	fmt.Printf("PROBLEM  %s\n         fix: %s\n", fmt.Sprintf(format, args...), fix)
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose why the editor doesn't see the generated definitions",
+	Long: `Checks the setup of the --workspace directory (the current directory by default)
+for the usual reasons the language server doesn't type a mod, and prints how to fix
+each problem found:
+
+  - the definitions of --output are missing, or aren't in the library of the
+    workspace's .luarc.json or .vscode/settings.json;
+  - the definitions are for another game version than the one installed, as last
+    run with --user-data-dir (per its factorio-current.log);
+  - a lua-language-server on the PATH or a LuaLS VS Code extension is too old for
+    the syntax of the definitions;
+  - another library of the workspace also defines the Factorio API, such as the
+    definitions of another generator or extension, which conflict with them.
+
+It exits with status 1 if it found a problem.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(0)
+
+		dir := workspaceDir
+		if dir == "" {
+			dir = "."
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			log.Fatalf("Fatal error: --workspace %s is not a directory", dir)
+		}
+		output, err := filepath.Abs(outputDir)
+		if err != nil {
+			log.Fatalf("Fatal error resolving %s: %v", outputDir, err)
+		}
+		if lockPath == "" {
+			lockPath = filepath.Join(outputDir, lockFileName)
+		}
+		if userDataDir == "" {
+			userDataDir = workspace.DefaultUserDataDir()
+		}
+
+		var d diagnosis
+		generate := fmt.Sprintf("factorio-api-gen --output %s --workspace %s", outputDir, dir)
+
+		// The definitions LuaLS reads are in library/ for an addon and common/ with
+		// --stage-globals; the workspace may use a stage's directory instead.
+		library := output
+		switch {
+		case fileExists(filepath.Join(output, "config.json")):
+			library = filepath.Join(output, "library")
+		case fileExists(filepath.Join(output, generator.StageCommonDir)):
+			library = filepath.Join(output, generator.StageCommonDir)
+		}
+		if !fileExists(library) {
+			d.problem("generate them: "+generate, "no definitions in %s", output)
+		} else {
+			d.ok("definitions in %s", library)
+		}
+
+		settings, err := workspace.ReadLibraries(dir)
+		if err != nil {
+			log.Fatalf("Fatal error reading the workspace settings: %v", err)
+		}
+		configured := false
+		for _, setting := range settings {
+			for _, entry := range setting.Libraries {
+				if within(entry, output) {
+					configured = true
+					d.ok("%s has the definitions in its library", setting.File)
+				}
+			}
+		}
+		if !configured {
+			d.problem(fmt.Sprintf("run %s, or for VS Code factorio-api-gen install vscode --output %s --workspace %s", generate, outputDir, dir),
+				"neither .luarc.json nor .vscode/settings.json of %s has %s in its workspace library", dir, output)
+		}
+
+		// Other libraries declaring the API make every class ambiguous.
+		for _, setting := range settings {
+			for _, entry := range setting.Libraries {
+				if !within(entry, output) && workspace.DeclaresFactorioAPI(entry) {
+					d.problem(fmt.Sprintf("remove %s from the workspace library of %s (or disable the extension adding it)", entry, setting.File),
+						"%s also defines the Factorio API, so its definitions conflict with the generated ones", entry)
+				}
+			}
+		}
+
+		lock, err := readLockFile(lockPath)
+		if err != nil {
+			d.skipped("can't tell the game version of the definitions: %v", err)
+		} else if installed, err := workspace.InstalledGameVersion(userDataDir); err != nil {
+			d.skipped("can't tell the installed game version: %v", err)
+		} else if generated := lockedGameVersion(lock); generated != installed {
+			d.problem(fmt.Sprintf("regenerate them: %s --factorio-version %s", generate, installed),
+				"the definitions are for game version %s, but the installed game is %s", generated, installed)
+		} else {
+			d.ok("the definitions are for the installed game version %s", installed)
+		}
+
+		installs := workspace.FindLuaLS()
+		if len(installs) == 0 {
+			d.skipped("no lua-language-server on the PATH nor LuaLS VS Code extension found")
+		}
+		requirements := workspace.RequiredLuaLS(library)
+		for _, install := range installs {
+			outdated := false
+			for _, requirement := range requirements {
+				if workspace.OlderLuaLS(install.Version, requirement.Version) {
+					outdated = true
+					d.problem(fmt.Sprintf("upgrade LuaLS to %s or later, or regenerate with %s", requirement.Version, requirement.Fix),
+						"LuaLS %s (%s) doesn't read the %s of the definitions, e.g. at %s", install.Version, install.Where, requirement.Syntax, requirement.Where)
+				}
+			}
+			if !outdated {
+				d.ok("LuaLS %s (%s) reads the definitions", install.Version, install.Where)
+			}
+		}
+
+		if d.problems > 0 {
+			fmt.Printf("\n%d problem(s) found.\n", d.problems)
+			os.Exit(1)
+		}
+		fmt.Println("\nNo problems found.")
+	},
+}
+
+// fileExists reports whether a file or directory exists.
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// within reports whether path is dir or inside it.
+func within(path string, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func init() {
+	doctorCmd.Flags().StringVar(&userDataDir, "user-data-dir", "", "Factorio user data directory, whose factorio-current.log names the installed game version (default: the platform's)")
+	rootCmd.AddCommand(doctorCmd)
+}
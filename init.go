@@ -0,0 +1,62 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init <mod directory>",
+	Short: "Create a mod workspace set up with generated definitions",
+	Long: `Creates a mod in the given directory, named after it: an info.json for the game
+version of the API, and control.lua, data.lua and settings.lua to start from.
+Existing files are kept. The definitions are generated like without a subcommand
+(all generation flags apply) and the mod's .luarc.json is written to use them, as
+with --workspace, so the mod is typed from the first edit.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		log.SetOutput(os.Stdout)
+		log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
+
+		dir := args[0]
+		name := filepath.Base(dir)
+		if err := workspace.CheckModName(name); err != nil {
+			log.Fatalf("Fatal error: %v", err)
+		}
+		switch {
+		case workspaceDir != "":
+			log.Fatalf("Fatal error: init sets up the mod directory as the workspace, without --workspace")
+		case watch:
+			log.Fatalf("Fatal error: init generates the definitions once, without --watch")
+		case len(versions) > 1:
+			log.Fatalf("Fatal error: init needs a single version of the definitions, got %d", len(versions))
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			log.Fatalf("Fatal error creating %s: %v", dir, err)
+		}
+
+		// The definitions come first, since info.json names their game version.
+		workspaceDir = dir
+		rootCmd.Run(cmd, nil)
+		gameVersion := ""
+		if lock, err := readLockFile(lockPath); err == nil {
+			gameVersion = lockedGameVersion(lock)
+		}
+		created, err := workspace.ScaffoldMod(dir, name, gameVersion)
+		if err != nil {
+			log.Fatalf("Fatal error creating the mod: %v", err)
+		}
+		for _, path := range created {
+			log.Printf("Created %s", path)
+		}
+		log.Printf("Mod %s is ready in %s.", name, dir)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(initCmd)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/generator"
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+var installCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Configure an editor to use previously generated definitions",
+}
+
+var installVSCodeCmd = &cobra.Command{
+	Use:   "vscode",
+	Short: "Merge the settings for the generated definitions into .vscode/settings.json",
+	Long: `Adds the --output directory to Lua.workspace.library in the .vscode/settings.json
+of the --workspace directory (the current directory by default), sets the Lua version
+Factorio uses and declares the globals it provides outside the API. If the output has
+the require plugin (--require-plugin), Lua.runtime.plugin is set to it. If the
+workspace is a mod, the mods its info.json depends on are looked up in --mods-dir
+and added to the library too. Existing settings and comments are kept.
+
+With --launch, .vscode/launch.json gets a configuration of the Factorio Mod Debug
+extension (FMTK) running Factorio with the mod of the workspace from --mods-dir and
+its debug adapter hooked into the mod, so the mod can be debugged in game. The
+configuration is replaced on the next run; --factorio-path sets the executable,
+which is otherwise the extension's active Factorio version.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		log.SetOutput(os.Stdout)
+		log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
+
+		dir := workspaceDir
+		if dir == "" {
+			dir = "."
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			log.Fatalf("Fatal error: --workspace %s is not a directory", dir)
+		}
+		library, err := filepath.Abs(outputDir)
+		if err != nil {
+			log.Fatalf("Fatal error resolving %s: %v", outputDir, err)
+		}
+		if _, err := os.Stat(library); err != nil {
+			log.Fatalf("Fatal error: no definitions in %s, generate them first", library)
+		}
+		// Output generated with --require-plugin has the plugin at its root.
+		plugin := filepath.Join(library, generator.LuaLSPluginFile)
+		if _, err := os.Stat(plugin); err != nil {
+			plugin = ""
+		}
+		// Output generated with --format luals-addon keeps the definitions in library/.
+		if _, err := os.Stat(filepath.Join(library, "config.json")); err == nil {
+			library = filepath.Join(library, "library")
+		}
+
+		libraries := append([]string{library}, dependencyLibraries(dir)...)
+		settings, err := workspace.InstallVSCode(dir, libraries, plugin)
+		if err != nil {
+			log.Fatalf("Fatal error updating the VS Code settings: %v", err)
+		}
+		log.Printf("Updated %s to use the definitions in %s.", settings, library)
+
+		if launch {
+			info, err := workspace.ReadModInfo(dir)
+			if err != nil {
+				log.Fatalf("Fatal error: --launch needs a mod workspace: %v", err)
+			}
+			mods := modsDir
+			if mods == "" {
+				mods = workspace.DefaultModsDir()
+			}
+			if mods, err = filepath.Abs(mods); err != nil {
+				log.Fatalf("Fatal error resolving %s: %v", mods, err)
+			}
+			// Factorio only loads the mod from its mods directory, where the workspace
+			// is usually linked.
+			if matches, _ := filepath.Glob(filepath.Join(mods, info.Name+"_*")); len(matches) == 0 {
+				if _, err := os.Stat(filepath.Join(mods, info.Name)); err != nil {
+					log.Printf("Warning: %s is not in %s; link the workspace there to debug it", info.Name, mods)
+				}
+			}
+			launchFile, err := workspace.InstallLaunch(dir, workspace.LaunchConfig{Mod: info.Name, ModsDir: mods, FactorioPath: factorioPath})
+			if err != nil {
+				log.Fatalf("Fatal error updating the launch configurations: %v", err)
+			}
+			log.Printf("Added the %q configuration debugging %s to %s.", workspace.LaunchConfigName, info.Name, launchFile)
+		}
+	},
+}
+
+var (
+	launch       bool
+	factorioPath string
+)
+
+func init() {
+	installVSCodeCmd.Flags().BoolVar(&launch, "launch", false, "Also add a Factorio Mod Debug (FMTK) configuration debugging the workspace's mod to .vscode/launch.json")
+	installVSCodeCmd.Flags().StringVar(&factorioPath, "factorio-path", "", "Factorio executable of the --launch configuration (default: the extension's active version)")
+	installCmd.AddCommand(installVSCodeCmd)
+	rootCmd.AddCommand(installCmd)
+}
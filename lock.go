@@ -0,0 +1,112 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+// lockFileName is the name of the lockfile, written in the output directory
+// unless --lockfile says otherwise.
+const lockFileName = "factorio-api-gen.lock"
+
+// lockFile records the exact inputs of a generation: the API documents, by URL
+// and checksum, and the version of the generator. --frozen regenerates the
+// definitions from it, so that they are the same on every machine.
+type lockFile struct {
+	GeneratorVersion string       `json:"generator_version"`
+	Targets          []lockTarget `json:"targets"`
+}
+
+// lockTarget records the documents the definitions of a directory were
+// generated from. Output is relative to the output directory: "." or, with
+// --factorio-version, the version's subdirectory. A stage left out by --only has
+// no document.
+type lockTarget struct {
+	Output    string        `json:"output"`
+	Runtime   *lockDocument `json:"runtime,omitempty"`
+	Prototype *lockDocument `json:"prototype,omitempty"`
+}
+
+// lockDocument identifies an API document.
+type lockDocument struct {
+	URL         string `json:"url"`
+	GameVersion string `json:"game_version"`
+	APIVersion  int    `json:"api_version"`
+	SHA256      string `json:"sha256"`
+}
+
+// readLockFile reads a lockfile written by writeLockFile.
+func readLockFile(path string) (*lockFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var lock lockFile
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("invalid lockfile %s: %w", path, err)
+	}
+	if len(lock.Targets) == 0 {
+		return nil, fmt.Errorf("the lockfile %s lists no definitions", path)
+	}
+	return &lock, nil
+}
+
+// writeLockFile writes a lockfile, indented so that it diffs well in version
+// control.
+func writeLockFile(path string, lock *lockFile) error {
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// lockedTargets returns the targets of a lockfile, generated under dir, with the
+// checksums their documents must match.
+func lockedTargets(lock *lockFile, dir string) []target {
+	var targets []target
+	for _, locked := range lock.Targets {
+		t := target{dir: filepath.Join(dir, filepath.FromSlash(locked.Output)), runtimeLock: locked.Runtime, prototypeLock: locked.Prototype}
+		if locked.Runtime != nil {
+			t.runtimeURL = locked.Runtime.URL
+		}
+		if locked.Prototype != nil {
+			t.prototypeURL = locked.Prototype.URL
+		}
+		targets = append(targets, t)
+	}
+	return targets
+}
+
+// fetchDocument downloads (or reads from the cache) and parses an API document,
+// returning it with its lockfile entry. When locked isn't nil, the document must
+// have its checksum. The entry pins the URLs of the "latest" documents to the
+// version they documented, where the same document stays available.
+func fetchDocument(cache *api.Cache, url string, locked *lockDocument) (*api.API, *lockDocument, error) {
+	body, err := cache.Fetch(url)
+	if err != nil {
+		return nil, nil, err
+	}
+	checksum := fmt.Sprintf("%x", sha256.Sum256(body))
+	if locked != nil && locked.SHA256 != checksum {
+		return nil, nil, fmt.Errorf("%s has changed since the lockfile was written (sha256 %s, locked %s)", url, checksum, locked.SHA256)
+	}
+	document := &api.API{}
+	if err := api.ParseAPI(url, body, document); err != nil {
+		return nil, nil, err
+	}
+	entry := &lockDocument{URL: url, GameVersion: document.ApplicationVersion, APIVersion: document.APIVersion, SHA256: checksum}
+	if document.ApplicationVersion != "" {
+		entry.URL = strings.Replace(url, "/latest/", "/"+document.ApplicationVersion+"/", 1)
+	}
+	return document, entry, nil
+}
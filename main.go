@@ -1,19 +1,75 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
 	"log" // Import the log package
+	"maps"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
 
 	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"       // Corrected import path
 	"github.com/bry-guy/factorio-lsp-plugin/pkg/generator" // Corrected import path
-	"github.com/spf13/cobra"                               // Using Cobra for better CLI
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/workspace"
+	"github.com/spf13/cobra" // Using Cobra for better CLI
 )
 
 var (
-	runtimeURL   string
-	prototypeURL string
-	outputDir    string
+	runtimeURL    string
+	prototypeURL  string
+	outputDir     string
+	optionalStyle string
+	noExamples    bool
+	numberMode    string
+	tupleStyle    string
+	splitFiles    bool
+	only          string
+	includes      []string
+	excludes      []string
+	dataRawNames  string
+	protoClasses  string
+	protoPrefix   string
+	typePrefix    string
+	headerFile    string
+	templatesDir  string
+	dialect       string
+	formats       []string
+	workspaceDir  string
+	typeReport    bool
+	diagnostics   []string
+	jobs          int
+	reqPlugin     bool
+	prune         bool
+	checking      bool
+	runReportPath string
+	force         bool
+	backup        bool
+	outputFormat  string
+	rockSourceURL string
+	modsDir       string
+	fetchDeps     bool
+	modSettings   []string
+	customEvents  []string
+	remoteMods    []string
+	localeDirs    []string
+	localeLang    string
+	scriptContext string
+	featureFlags  []string
+	versions      []string
+	frozen        bool
+	watch         bool
+	watchInterval time.Duration
+	postHook      string
+	stageGlobals  bool
+	stageDirs     []string
+	lockPath      string
 )
 
 var rootCmd = &cobra.Command{
@@ -21,8 +77,11 @@ var rootCmd = &cobra.Command{
 	Short: "factorio-api-gen generates LuaLS definitions from Factorio API JSON",
 	Long:  `A tool to download the Factorio Runtime and Prototype API JSON files and generate Lua Language Server definition files.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		// Configure logging
+		// Configure logging; standard output may carry the definitions instead.
 		log.SetOutput(os.Stdout)
+		if outputFormat == outputToStdout {
+			log.SetOutput(os.Stderr)
+		}
 		log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
 
 		log.Println("Starting Factorio API Generator...")
@@ -30,62 +89,851 @@ var rootCmd = &cobra.Command{
 		log.Printf("Prototype API URL: %s", prototypeURL)
 		log.Printf("Output Directory: %s", outputDir)
 
-		// 1. Download and Parse Runtime API JSON
-		runtimeAPI := &api.API{}
+		// With --factorio-version, each version is generated in a subdirectory of the
+		// output, and the workspace is set up for the only one. With --frozen, the
+		// documents and directories are those of the lockfile.
+		switch outputFormat {
+		case outputToDir:
+			if lockPath == "" {
+				lockPath = filepath.Join(outputDir, lockFileName)
+			}
+		case string(generator.ArchiveZip), string(generator.ArchiveTarGz), outputToStdout:
+			switch {
+			case workspaceDir != "":
+				log.Fatalf("Fatal error: --workspace needs the definitions in a directory, without --output-format %s", outputFormat)
+			case watch || prune || cleaning || checking:
+				log.Fatalf("Fatal error: --watch, --prune, --check and clean work on a directory of definitions, without --output-format %s", outputFormat)
+			case frozen && lockPath == "":
+				log.Fatalf("Fatal error: --frozen with --output-format %s needs --lockfile", outputFormat)
+			case outputFormat == outputToStdout && (len(versions) > 1 || typeReport):
+				log.Fatalf("Fatal error: --output-format stdout writes a single version of the definitions, without --warnings-report")
+			}
+		default:
+			log.Fatalf("Fatal error: unknown --output-format %q (expected %q, %q, %q or %q)", outputFormat, outputToDir, generator.ArchiveZip, generator.ArchiveTarGz, outputToStdout)
+		}
+		lockDir := outputDir
+		targets := []target{{runtimeURL: runtimeURL, prototypeURL: prototypeURL, dir: outputDir}}
+		switch {
+		case frozen:
+			if len(versions) > 0 {
+				log.Fatalf("Fatal error: --frozen generates the versions of the lockfile, without --factorio-version")
+			}
+			lock, err := readLockFile(lockPath)
+			if err != nil {
+				log.Fatalf("Fatal error reading the lockfile: %v", err)
+			}
+			if lock.GeneratorVersion != generator.Version {
+				log.Fatalf("Fatal error: the lockfile %s was written by version %s of the generator, this is %s", lockPath, lock.GeneratorVersion, generator.Version)
+			}
+			targets = lockedTargets(lock, outputDir)
+		case len(versions) > 0:
+			var err error
+			if targets, err = versionTargets(versions, runtimeURL, prototypeURL, outputDir); err != nil {
+				log.Fatalf("Fatal error: --factorio-version: %v", err)
+			}
+		}
+		if workspaceDir != "" && len(targets) > 1 {
+			log.Fatalf("Fatal error: --workspace needs a single version of the definitions, got %d", len(targets))
+		}
+		outputDir = targets[0].dir
+		if watch {
+			switch {
+			case frozen:
+				log.Fatalf("Fatal error: --watch regenerates the definitions of new versions, which --frozen pins")
+			case len(versions) > 0:
+				log.Fatalf("Fatal error: --watch follows the latest version, without --factorio-version")
+			case watchInterval < time.Minute:
+				log.Fatalf("Fatal error: --watch-interval %s is under a minute", watchInterval)
+			}
+		} else if postHook != "" {
+			log.Fatalf("Fatal error: --post-hook runs after --watch regenerates the definitions")
+		}
+		if checking {
+			switch {
+			case cleaning:
+				log.Fatalf("Fatal error: clean removes files, which --check doesn't")
+			case watch || prune || backup || workspaceDir != "":
+				log.Fatalf("Fatal error: --check only compares the definitions, without --watch, --prune, --backup or --workspace")
+			}
+		}
+
+		// Options are validated up front, so a typo doesn't cost a download.
+		options := generator.DefaultOptions()
+		options.OptionalStyle = generator.OptionalStyle(optionalStyle)
+		if options.OptionalStyle != generator.OptionalSuffix && options.OptionalStyle != generator.OptionalNilUnion {
+			log.Fatalf("Fatal error: unknown --optional-style %q (expected %q or %q)", optionalStyle, generator.OptionalSuffix, generator.OptionalNilUnion)
+		}
+		options.IncludeExamples = !noExamples
+		options.SplitFiles = splitFiles
+		options.NumberMode = generator.NumberMode(numberMode)
+		if options.NumberMode != generator.NumbersStrict && options.NumberMode != generator.NumbersLoose {
+			log.Fatalf("Fatal error: unknown --numbers %q (expected %q or %q)", numberMode, generator.NumbersStrict, generator.NumbersLoose)
+		}
+		options.TupleStyle = generator.TupleStyle(tupleStyle)
+		if options.TupleStyle != generator.TuplesModern && options.TupleStyle != generator.TuplesTable {
+			log.Fatalf("Fatal error: unknown --tuple-style %q (expected %q or %q)", tupleStyle, generator.TuplesModern, generator.TuplesTable)
+		}
+		options.Dialect = generator.Dialect(dialect)
+		if options.Dialect != generator.DialectLuaLS && options.Dialect != generator.DialectEmmyLua {
+			log.Fatalf("Fatal error: unknown --dialect %q (expected %q or %q)", dialect, generator.DialectLuaLS, generator.DialectEmmyLua)
+		}
+		options.Formats = nil
+		for _, format := range formats {
+			switch generator.Format(format) {
+			case generator.FormatLuaLS, generator.FormatTeal, generator.FormatTypeScript, generator.FormatIR, generator.FormatMarkdown, generator.FormatHTML, generator.FormatLuaLSAddon, generator.FormatStubs, generator.FormatLuaRocks:
+			default:
+				log.Fatalf("Fatal error: unknown --format %q (expected %q, %q, %q, %q, %q, %q, %q, %q or %q)", format, generator.FormatLuaLS, generator.FormatLuaLSAddon, generator.FormatLuaRocks, generator.FormatTeal, generator.FormatTypeScript, generator.FormatIR, generator.FormatMarkdown, generator.FormatHTML, generator.FormatStubs)
+			}
+			options.Formats = append(options.Formats, generator.Format(format))
+		}
+		options.PrototypeClasses = generator.PrototypeClassMode(protoClasses)
+		if options.PrototypeClasses != generator.PrototypeClassesByType && options.PrototypeClasses != generator.PrototypeClassesByDefinition {
+			log.Fatalf("Fatal error: unknown --prototype-classes %q (expected %q or %q)", protoClasses, generator.PrototypeClassesByType, generator.PrototypeClassesByDefinition)
+		}
+		if protoPrefix != "" && !regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`).MatchString(protoPrefix) {
+			log.Fatalf("Fatal error: --prototype-class-prefix %q is not a valid Lua identifier", protoPrefix)
+		}
+		options.PrototypeClassPrefix = protoPrefix
+		// A namespace-like prefix ("Factorio.") or a plain one ("Factorio_").
+		if typePrefix != "" && !regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*\.?$`).MatchString(typePrefix) {
+			log.Fatalf("Fatal error: --type-prefix %q is not a valid type name prefix (e.g. %q)", typePrefix, "Factorio.")
+		}
+		options.TypePrefix = typePrefix
+		if jobs < 0 {
+			log.Fatalf("Fatal error: --jobs must not be negative, got %d", jobs)
+		}
+		options.Workers = jobs
+		if reqPlugin && !slices.Contains(options.Formats, generator.FormatLuaLS) && !slices.Contains(options.Formats, generator.FormatLuaLSAddon) {
+			log.Fatalf("Fatal error: --require-plugin needs the %q or %q format", generator.FormatLuaLS, generator.FormatLuaLSAddon)
+		}
+		options.RequirePlugin = reqPlugin
+		if rockSourceURL != "" && !slices.Contains(options.Formats, generator.FormatLuaRocks) {
+			log.Fatalf("Fatal error: --rock-source-url needs the %q format", generator.FormatLuaRocks)
+		}
+		options.RockSourceURL = rockSourceURL
+		if only != "" && only != "runtime" && only != "prototype" {
+			log.Fatalf("Fatal error: unknown --only %q (expected %q or %q)", only, "runtime", "prototype")
+		}
+		if stageGlobals {
+			if len(options.Formats) != 1 || options.Formats[0] != generator.FormatLuaLS {
+				log.Fatalf("Fatal error: --stage-globals needs the %q format alone", generator.FormatLuaLS)
+			}
+			if scriptContext != string(generator.ContextControl) {
+				log.Fatalf("Fatal error: --stage-globals lays out the globals of a mod's stages, without --context")
+			}
+		}
+		options.StageGlobals = stageGlobals
+		// Each --stage-dir gets its own .luarc.json, checked before generating.
+		stageLibraries := make(map[string]string)
+		for _, entry := range stageDirs {
+			stage, dir, ok := strings.Cut(entry, "=")
+			switch {
+			case !ok || dir == "":
+				log.Fatalf("Fatal error: invalid --stage-dir %q (expected stage=dir, e.g. data=prototypes)", entry)
+			case stage != generator.ControlStageDir && stage != generator.DataStageDir && stage != generator.SettingsStageDir:
+				log.Fatalf("Fatal error: unknown stage %q in --stage-dir (expected %q, %q or %q)", stage, generator.SettingsStageDir, generator.DataStageDir, generator.ControlStageDir)
+			case !stageGlobals || workspaceDir == "":
+				log.Fatalf("Fatal error: --stage-dir needs --stage-globals and --workspace")
+			}
+			stageLibraries[dir] = stage
+		}
+		options.Context = generator.Context(scriptContext)
+		switch options.Context {
+		case generator.ContextControl:
+		case generator.ContextMigrations, generator.ContextScenario:
+			if !slices.Contains(options.Formats, generator.FormatLuaLS) && !slices.Contains(options.Formats, generator.FormatLuaLSAddon) {
+				log.Fatalf("Fatal error: --context %s needs the %q or %q format", scriptContext, generator.FormatLuaLS, generator.FormatLuaLSAddon)
+			}
+			// Migrations and scenarios run in the runtime stage, so the data stage's
+			// globals are left out.
+			if only == "prototype" {
+				log.Fatalf("Fatal error: --context %s generates the runtime stage, not --only prototype", scriptContext)
+			}
+			only = "runtime"
+		default:
+			log.Fatalf("Fatal error: unknown --context %q (expected %q, %q or %q)", scriptContext, generator.ContextControl, generator.ContextMigrations, generator.ContextScenario)
+		}
+		// An empty --feature-flags stands for the base game, leaving out every
+		// expansion's members; without the flag, nothing is left out.
+		if cmd.Flags().Changed("feature-flags") {
+			options.FeatureFlags = []string{}
+			for _, flag := range featureFlags {
+				if !slices.Contains(knownFeatureFlags, flag) {
+					log.Fatalf("Fatal error: unknown --feature-flags %q (expected %s)", flag, strings.Join(knownFeatureFlags, ", "))
+				}
+				// Space Age depends on the other two expansion mods.
+				if flag == "space-age" {
+					options.FeatureFlags = append(options.FeatureFlags, "quality", "elevated_rails")
+				}
+				// The API names visibilities like mods, with underscores.
+				options.FeatureFlags = append(options.FeatureFlags, strings.ReplaceAll(flag, "-", "_"))
+			}
+		}
+		for _, pattern := range append(slices.Clone(includes), excludes...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				log.Fatalf("Fatal error: invalid --include/--exclude pattern %q: %v", pattern, err)
+			}
+		}
+		options.Include = includes
+		options.Exclude = excludes
+
+		// "default" stands for the diagnostics the definitions are known to trigger.
+		diagnosticPattern := regexp.MustCompile(`^[a-z]+(-[a-z]+)*$`)
+		for _, diagnostic := range diagnostics {
+			switch {
+			case diagnostic == "default":
+				options.DisabledDiagnostics = append(options.DisabledDiagnostics, generator.DefaultDisabledDiagnostics...)
+			case diagnosticPattern.MatchString(diagnostic):
+				options.DisabledDiagnostics = append(options.DisabledDiagnostics, diagnostic)
+			default:
+				log.Fatalf("Fatal error: invalid --disable-diagnostics name %q (expected a LuaLS diagnostic such as %q)", diagnostic, "lowercase-global")
+			}
+		}
+
+		if headerFile != "" {
+			text, err := os.ReadFile(headerFile)
+			if err != nil {
+				log.Fatalf("Fatal error reading --header-template: %v", err)
+			}
+			header, err := template.New(filepath.Base(headerFile)).Parse(string(text))
+			if err != nil {
+				log.Fatalf("Fatal error parsing --header-template: %v", err)
+			}
+			options.HeaderTemplate = header
+		}
+
+		if templatesDir != "" {
+			templates, err := generator.LoadTemplates(templatesDir)
+			if err != nil {
+				log.Fatalf("Fatal error loading --templates: %v", err)
+			}
+			options.Templates = templates
+		}
+
+		// The workspace library points at the LuaLS files, in library/ for an addon.
+		library := outputDir
+		if workspaceDir != "" {
+			if info, err := os.Stat(workspaceDir); err != nil || !info.IsDir() {
+				log.Fatalf("Fatal error: --workspace %s is not a directory", workspaceDir)
+			}
+			switch {
+			case slices.Contains(options.Formats, generator.FormatLuaLS):
+			case slices.Contains(options.Formats, generator.FormatLuaLSAddon):
+				library = filepath.Join(outputDir, "library")
+			default:
+				log.Fatalf("Fatal error: --workspace needs the %q or %q format", generator.FormatLuaLS, generator.FormatLuaLSAddon)
+			}
+		}
+
+		if typeReport && !slices.Contains(options.Formats, generator.FormatLuaLS) && !slices.Contains(options.Formats, generator.FormatLuaLSAddon) {
+			log.Fatalf("Fatal error: --warnings-report needs the %q or %q format", generator.FormatLuaLS, generator.FormatLuaLSAddon)
+		}
+
+		if dataRawNames != "" {
+			// "dump" stands for the dump of the local game.
+			if dataRawNames == "dump" {
+				dataRawNames = workspace.DefaultDataRawDump()
+			}
+			names, err := api.LoadPrototypeNames(dataRawNames)
+			if err != nil {
+				log.Fatalf("Fatal error loading --data-raw-names: %v", err)
+			}
+			options.DataRawNames = names
+		}
+
+		if len(localeDirs) > 0 {
+			if !slices.Contains(options.Formats, generator.FormatLuaLS) && !slices.Contains(options.Formats, generator.FormatLuaLSAddon) {
+				log.Fatalf("Fatal error: --locale needs the %q or %q format", generator.FormatLuaLS, generator.FormatLuaLSAddon)
+			}
+			// Later directories override earlier ones, as mods do the game.
+			locale := make(workspace.Locale)
+			for _, dir := range localeDirs {
+				dirLocale, err := workspace.ReadLocale(dir, localeLang)
+				if err != nil {
+					log.Fatalf("Fatal error reading the %q locale of %s: %v", localeLang, dir, err)
+				}
+				locale.Merge(dirLocale)
+				log.Printf("Read %d locale sections from %s", len(dirLocale), dir)
+			}
+			options.Locale = locale
+		}
+
+		for _, dir := range modSettings {
+			if !slices.Contains(options.Formats, generator.FormatLuaLS) && !slices.Contains(options.Formats, generator.FormatLuaLSAddon) {
+				log.Fatalf("Fatal error: --mod-settings needs the %q or %q format", generator.FormatLuaLS, generator.FormatLuaLSAddon)
+			}
+			settings, warnings, err := workspace.ScanSettings(dir)
+			if err != nil {
+				log.Fatalf("Fatal error reading the settings of %s: %v", dir, err)
+			}
+			for _, warning := range warnings {
+				log.Printf("Warning: %s", warning)
+			}
+			for _, setting := range settings {
+				options.ModSettings = append(options.ModSettings, generator.ModSetting{
+					Name:          setting.Name,
+					Type:          setting.Type,
+					SettingType:   setting.SettingType,
+					AllowedValues: setting.AllowedValues,
+				})
+			}
+			log.Printf("Found %d settings in %s", len(settings), dir)
+		}
+
+		for _, dir := range customEvents {
+			if !slices.Contains(options.Formats, generator.FormatLuaLS) && !slices.Contains(options.Formats, generator.FormatLuaLSAddon) {
+				log.Fatalf("Fatal error: --custom-events needs the %q or %q format", generator.FormatLuaLS, generator.FormatLuaLSAddon)
+			}
+			events, err := workspace.ScanCustomEvents(dir)
+			if err != nil {
+				log.Fatalf("Fatal error scanning the custom events of %s: %v", dir, err)
+			}
+			for _, event := range events {
+				custom := generator.CustomEvent{Name: event.Name}
+				for _, field := range event.Fields {
+					custom.Fields = append(custom.Fields, generator.CustomEventField{Name: field.Name, Type: field.Type, Optional: field.Optional})
+				}
+				options.CustomEvents = append(options.CustomEvents, custom)
+				log.Printf("Found custom event %s in %s: annotate its id with ---@type CustomEvent.%s", event.Name, event.File, event.Name)
+			}
+		}
+
+		for _, dir := range remoteMods {
+			if !slices.Contains(options.Formats, generator.FormatLuaLS) && !slices.Contains(options.Formats, generator.FormatLuaLSAddon) {
+				log.Fatalf("Fatal error: --remote-interfaces needs the %q or %q format", generator.FormatLuaLS, generator.FormatLuaLSAddon)
+			}
+			// The mod's own interfaces, then those of its dependencies.
+			for _, modDir := range append([]string{dir}, dependencyLibraries(dir)...) {
+				interfaces, err := workspace.ScanRemoteInterfaces(modDir)
+				if err != nil {
+					log.Fatalf("Fatal error scanning the remote interfaces of %s: %v", modDir, err)
+				}
+				for _, remote := range interfaces {
+					if slices.ContainsFunc(options.RemoteInterfaces, func(r generator.RemoteInterface) bool { return r.Name == remote.Name }) {
+						continue // Found through another mod
+					}
+					options.RemoteInterfaces = append(options.RemoteInterfaces, remoteInterface(remote))
+					log.Printf("Found remote interface %q with %d functions in %s", remote.Name, len(remote.Functions), remote.File)
+				}
+			}
+		}
+
+		// 1-3. Download the API documents and generate the definitions, for each
+		// version of --factorio-version.
+		cache := apiCache()
+		lock, err := generateTargets(options, targets, cache, lockDir)
+		if err != nil {
+			log.Fatalf("Fatal error %v", err)
+		}
+		if cleaning {
+			return
+		}
+		if checking {
+			if staleFiles > 0 {
+				log.Printf("%d files of %s differ from the generated definitions: regenerate them.", staleFiles, lockDir)
+				os.Exit(1)
+			}
+			log.Printf("The definitions in %s are up to date.", lockDir)
+			return
+		}
+
+		// 5. Configure the workspace
+		if workspaceDir != "" {
+			// .luarc.json is read relative to the workspace, so the library is absolute.
+			absLibrary, err := filepath.Abs(library)
+			if err != nil {
+				log.Fatalf("Fatal error resolving %s: %v", library, err)
+			}
+			plugin := ""
+			if reqPlugin {
+				if plugin, err = filepath.Abs(filepath.Join(outputDir, generator.LuaLSPluginFile)); err != nil {
+					log.Fatalf("Fatal error resolving the plugin path: %v", err)
+				}
+			}
+			dependencies := dependencyLibraries(workspaceDir)
+			libraries := append([]string{absLibrary}, dependencies...)
+			// With --stage-globals, the workspace sees the control stage's globals, and
+			// each --stage-dir those of its stage.
+			stageLibrary := func(stage string) []string {
+				return append([]string{filepath.Join(absLibrary, generator.StageCommonDir), filepath.Join(absLibrary, stage)}, dependencies...)
+			}
+			if stageGlobals {
+				libraries = stageLibrary(generator.ControlStageDir)
+			}
+			for _, dir := range slices.Sorted(maps.Keys(stageLibraries)) {
+				luarc, err := workspace.UpdateLuarc(filepath.Join(workspaceDir, dir), stageLibrary(stageLibraries[dir]), plugin)
+				if err != nil {
+					log.Fatalf("Fatal error updating the configuration of %s: %v", dir, err)
+				}
+				log.Printf("Updated %s to use the %s stage definitions.", luarc, stageLibraries[dir])
+			}
+			// Migrations are a workspace of their own, configured in the mod's
+			// migrations/ directory.
+			luarcDir := workspaceDir
+			if options.Context == generator.ContextMigrations {
+				luarcDir = filepath.Join(workspaceDir, "migrations")
+				if err := os.MkdirAll(luarcDir, 0755); err != nil {
+					log.Fatalf("Fatal error creating %s: %v", luarcDir, err)
+				}
+			}
+			luarc, err := workspace.UpdateLuarc(luarcDir, libraries, plugin)
+			if err != nil {
+				log.Fatalf("Fatal error updating the workspace configuration: %v", err)
+			}
+			log.Printf("Updated %s to use the generated definitions.", luarc)
+		} else if outputFormat == outputToDir {
+			log.Println("\nTo use these definitions with lua-language-server, configure your editor's settings to add this directory to the Lua.workspace.library setting.")
+		}
+
+		if watch {
+			watchReleases(lock, func() (*lockFile, error) { return generateTargets(options, targets, cache, lockDir) })
+		}
+	},
+}
+
+// generateTargets generates the definitions of every target and, unless frozen,
+// records their inputs in the lockfile, returning it. With --output-format, the
+// targets are written into one archive, or to standard output, instead of their
+// directories. It stops at the first target failing, without a lockfile.
+func generateTargets(options generator.Options, targets []target, cache *api.Cache, lockDir string) (*lockFile, error) {
+	lock := &lockFile{GeneratorVersion: generator.Version}
+	if runReportPath != "" && !cleaning && !checking {
+		generationReport = &runReport{GeneratorVersion: generator.Version, Started: time.Now(), Output: lockDir, OutputFormat: outputFormat, Formats: formats, Lockfile: lockPath, Targets: []reportTarget{}}
+	}
+	var archive *generator.Archive
+	var archiveFile *os.File
+	if outputFormat == string(generator.ArchiveZip) || outputFormat == string(generator.ArchiveTarGz) {
+		var err error
+		if archiveFile, err = os.Create(archivePath(lockDir)); err != nil {
+			return nil, fmt.Errorf("creating the archive: %w", err)
+		}
+		if archive, err = generator.NewArchive(archiveFile, generator.ArchiveFormat(outputFormat)); err != nil {
+			return nil, fmt.Errorf("creating the archive: %w", err)
+		}
+	}
+	for _, t := range targets {
+		// Targets are archived under their directory relative to the output.
+		output, err := filepath.Rel(lockDir, t.dir)
+		if err != nil {
+			return nil, fmt.Errorf("locating %s: %w", t.dir, err)
+		}
+		var create generator.FileCreator
+		switch {
+		case archive != nil:
+			create = archive.Creator(filepath.ToSlash(output))
+		case outputFormat == outputToStdout:
+			create = generator.StreamCreator(os.Stdout)
+		}
+		if generationReport != nil {
+			generationReport.Targets = append(generationReport.Targets, reportTarget{Output: filepath.ToSlash(output), Collisions: []string{}})
+		}
+		locked, err := generateTarget(options, t, cache, create)
+		if err != nil {
+			return nil, err
+		}
+		locked.Output = filepath.ToSlash(output)
+		lock.Targets = append(lock.Targets, locked)
+		if generationReport != nil {
+			reported := &generationReport.Targets[len(generationReport.Targets)-1]
+			reported.Runtime, reported.Prototype = locked.Runtime, locked.Prototype
+		}
+	}
+	if archive != nil {
+		err := archive.Close()
+		if closeErr := archiveFile.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("writing the archive %s: %w", archiveFile.Name(), err)
+		}
+		log.Printf("Wrote the definitions to %s", archiveFile.Name())
+		if generationReport != nil {
+			generationReport.Output = archiveFile.Name()
+		}
+	}
+	// A frozen run generated exactly what the lockfile says, and clean and --check
+	// nothing.
+	// Archives and standard output only get a lockfile given with --lockfile.
+	if !frozen && !cleaning && !checking && lockPath != "" {
+		if err := writeLockFile(lockPath, lock); err != nil {
+			return nil, fmt.Errorf("writing the lockfile %s: %w", lockPath, err)
+		}
+		log.Printf("Recorded the inputs of the definitions in %s", lockPath)
+	}
+	if generationReport != nil {
+		generationReport.Finished = time.Now()
+		if err := writeRunReport(runReportPath, generationReport); err != nil {
+			return nil, fmt.Errorf("writing the report %s: %w", runReportPath, err)
+		}
+		log.Printf("Wrote the report of the generation to %s", runReportPath)
+	}
+	return lock, nil
+}
+
+// archivePath returns where the archive of --output-format is written: the output
+// path with the archive's extension, unless it already has it.
+func archivePath(output string) string {
+	if strings.HasSuffix(output, "."+outputFormat) {
+		return output
+	}
+	return output + "." + outputFormat
+}
+
+// target is a set of definitions to generate: the API documents they're
+// generated from and the directory they're written to.
+type target struct {
+	runtimeURL   string
+	prototypeURL string
+	dir          string
+	// The lockfile entries the documents must match with --frozen.
+	runtimeLock   *lockDocument
+	prototypeLock *lockDocument
+}
+
+// versionTargets returns the targets of --factorio-version: the documents of each
+// version, whose URLs are those of the latest version with "latest" replaced by
+// the version, generated in a subdirectory of dir named after the major and minor
+// version (2.0 for 2.0.28).
+func versionTargets(versions []string, runtimeURL string, prototypeURL string, dir string) ([]target, error) {
+	versionPattern := regexp.MustCompile(`^(\d+\.\d+)\.\d+$`)
+	for _, url := range []string{runtimeURL, prototypeURL} {
+		if !strings.Contains(url, "/latest/") {
+			return nil, fmt.Errorf("the API URL %s has no /latest/ to replace with the versions", url)
+		}
+	}
+	var targets []target
+	for _, version := range versions {
+		match := versionPattern.FindStringSubmatch(version)
+		if match == nil {
+			return nil, fmt.Errorf("invalid version %q (expected a release such as %q)", version, "2.0.28")
+		}
+		versionDir := filepath.Join(dir, match[1])
+		if slices.ContainsFunc(targets, func(t target) bool { return t.dir == versionDir }) {
+			return nil, fmt.Errorf("several versions would be generated in %s", versionDir)
+		}
+		targets = append(targets, target{
+			runtimeURL:   strings.Replace(runtimeURL, "/latest/", "/"+version+"/", 1),
+			prototypeURL: strings.Replace(prototypeURL, "/latest/", "/"+version+"/", 1),
+			dir:          versionDir,
+		})
+	}
+	return targets, nil
+}
+
+// apiCache returns the cache of the downloaded API documents, or nil when the
+// platform has no cache directory.
+func apiCache() *api.Cache {
+	dir, err := api.DefaultCacheDir()
+	if err != nil {
+		log.Printf("Warning: not caching the API documents: %v", err)
+		return nil
+	}
+	return &api.Cache{Dir: dir}
+}
+
+// generateTarget downloads the API documents of a target (those of the stages
+// selected by --only, and with --frozen in the lockfile) and generates its
+// definitions, with create or, when it is nil, in the target's directory. It
+// returns the lockfile entry of the documents, without Output.
+func generateTarget(options generator.Options, t target, cache *api.Cache, create generator.FileCreator) (lockTarget, error) {
+	var locked lockTarget
+	// 1. Download and Parse Runtime API JSON
+	// A stage excluded by --only is neither downloaded nor generated (its API stays nil).
+	var runtimeAPI *api.API
+	if only != "prototype" && t.runtimeURL != "" {
 		log.Println("Initiating runtime API download and parsing...")
-		err := api.DownloadAndParseAPI(runtimeURL, runtimeAPI)
+		var err error
+		runtimeAPI, locked.Runtime, err = fetchDocument(cache, t.runtimeURL, t.runtimeLock)
 		if err != nil {
-			log.Fatalf("Fatal error downloading/parsing runtime API from %s: %v", runtimeURL, err)
+			return lockTarget{}, fmt.Errorf("downloading/parsing runtime API from %s: %w", t.runtimeURL, err)
 		}
 		log.Println("Runtime API download and parsing complete.")
+	}
 
-		// 2. Download and Parse Prototype API JSON
-		prototypeAPI := &api.API{}
+	// 2. Download and Parse Prototype API JSON
+	var prototypeAPI *api.API
+	if only != "runtime" && t.prototypeURL != "" {
 		log.Println("Initiating prototype API download and parsing...")
-		err = api.DownloadAndParseAPI(prototypeURL, prototypeAPI)
+		var err error
+		prototypeAPI, locked.Prototype, err = fetchDocument(cache, t.prototypeURL, t.prototypeLock)
 		if err != nil {
-			log.Fatalf("Fatal error downloading/parsing prototype API from %s: %v", prototypeURL, err)
+			return lockTarget{}, fmt.Errorf("downloading/parsing prototype API from %s: %w", t.prototypeURL, err)
 		}
 		log.Println("Prototype API download and parsing complete.")
+	}
 
-		// 3. Generate Lua Definitions
-		log.Println("Initiating Lua definition generation...")
-		gen := generator.NewGenerator()
-		definitions, err := gen.GenerateDefinitions(runtimeAPI, prototypeAPI)
+	// 3. Generate Lua Definitions
+	options.RuntimeURL = t.runtimeURL
+	options.PrototypeURL = t.prototypeURL
+	// The definitions go to the directory unless create writes them elsewhere
+	// (an archive or standard output), and nowhere for clean. Files are written
+	// as soon as they are generated, so the output directory must exist first.
+	toDir := create == nil
+	var previous []string
+	if toDir {
+		var err error
+		if previous, err = readGeneratedFiles(t.dir); err != nil {
+			return lockTarget{}, fmt.Errorf("reading the generated files of %s: %w", t.dir, err)
+		}
+	}
+	var checked map[string]string
+	switch {
+	case cleaning:
+		create = generator.StreamCreator(io.Discard)
+	case checking:
+		checked = make(map[string]string)
+		create = generator.MapCreator(checked)
+	case toDir:
+		if backup && len(previous) > 0 {
+			backupDir, err := backupGeneration(t.dir, previous)
+			if err != nil {
+				return lockTarget{}, fmt.Errorf("backing up %s: %w", t.dir, err)
+			}
+			log.Printf("Backed up the previous definitions to %s", backupDir)
+		}
+		log.Printf("Ensuring output directory exists: %s", t.dir)
+		err := os.MkdirAll(t.dir, 0755)
 		if err != nil {
-			log.Fatalf("Fatal error generating Lua definitions: %v", err)
+			return lockTarget{}, fmt.Errorf("creating output directory %s: %w", t.dir, err)
 		}
-		log.Println("Lua definition generation complete.")
+		log.Println("Output directory is ready.")
+		// Split output nests files in per-stage directories, which DirCreator creates.
+		create = keepUserFiles(t.dir, previous, generator.DirCreator(t.dir))
+	}
+	writing := toDir && !cleaning && !checking
+	var reported *reportTarget
+	if generationReport != nil {
+		reported = &generationReport.Targets[len(generationReport.Targets)-1]
+		create = reportCreator(create, reported)
+	}
 
-		// 4. Write Definitions to Files
-		log.Printf("Ensuring output directory exists: %s", outputDir)
-		err = os.MkdirAll(outputDir, 0755)
+	log.Println("Initiating Lua definition generation...")
+	gen := generator.NewGenerator(options)
+	// The files generated are recorded for --prune and clean, which only need
+	// their names.
+	var generated []string
+	err := gen.GenerateTo(runtimeAPI, prototypeAPI, func(filename string) (io.WriteCloser, error) {
+		if writing {
+			log.Printf("Writing file: %s", filepath.Join(t.dir, filepath.FromSlash(filename)))
+		}
+		w, err := create(filename)
+		if err == nil {
+			generated = append(generated, filename)
+		}
+		return w, err
+	})
+	// On failure, the files written so far are recorded, so the next run may
+	// overwrite them.
+	recordWritten := func() {
+		if writing {
+			writeGeneratedFiles(t.dir, append(previous, generated...))
+		}
+	}
+	if err != nil {
+		recordWritten()
+		return lockTarget{}, fmt.Errorf("generating Lua definitions: %w", err)
+	}
+	for _, collision := range gen.Collisions() {
+		log.Printf("Name collision: %s", collision)
+	}
+	report := gen.TypeReport()
+	log.Printf("Imprecise types: %d unresolved and %d downgraded to any or table, of %d (any-rate %.2f%%).", report.Unresolved, report.Downgraded, report.Types, 100*report.AnyRate)
+	log.Println("Lua definition generation complete.")
+	if reported != nil {
+		reported.Statistics.addStatistics(runtimeAPI, prototypeAPI)
+		for _, collision := range gen.Collisions() {
+			reported.Collisions = append(reported.Collisions, collision.String())
+		}
+		reported.Warnings = report
+	}
+
+	if typeReport {
+		data, err := json.MarshalIndent(report, "", "  ")
 		if err != nil {
-			log.Fatalf("Fatal error creating output directory %s: %v", outputDir, err)
+			return lockTarget{}, fmt.Errorf("encoding the warnings report: %w", err)
 		}
-		log.Println("Output directory is ready.")
+		reportPath := filepath.Join(t.dir, "warnings.json")
+		f, err := create("warnings.json")
+		if err == nil {
+			_, err = f.Write(append(data, '\n'))
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		if err != nil {
+			recordWritten()
+			return lockTarget{}, fmt.Errorf("writing the warnings report %s: %w", reportPath, err)
+		}
+		if writing {
+			log.Printf("Wrote the warnings report to %s", reportPath)
+		}
+		generated = append(generated, "warnings.json")
+	}
 
-		log.Println("Writing generated definitions to files...")
-		for filename, content := range definitions {
-			outputPath := filepath.Join(outputDir, filename)
-			log.Printf("Writing file: %s", outputPath)
-			err := os.WriteFile(outputPath, []byte(content), 0644)
-			if err != nil {
-				log.Fatalf("Fatal error writing definition file %s: %v", outputPath, err)
+	// The files of the previous run that this one didn't generate are stale.
+	if toDir && !checking {
+		var removed []string
+		if prune || cleaning {
+			removed = pruneFiles(t.dir, previous, generated)
+			for _, file := range removed {
+				log.Printf("Removed stale file: %s", filepath.Join(t.dir, filepath.FromSlash(file)))
 			}
-			log.Printf("Successfully wrote %s", outputPath)
+			log.Printf("Removed %d stale files from %s.", len(removed), t.dir)
 		}
+		// The stale files left stay listed, for a later --prune or clean.
+		files := slices.DeleteFunc(previous, func(file string) bool { return slices.Contains(removed, file) })
+		if !cleaning {
+			files = append(files, generated...)
+		}
+		if !cleaning || len(previous) > 0 {
+			if err := writeGeneratedFiles(t.dir, files); err != nil {
+				return lockTarget{}, fmt.Errorf("recording the generated files of %s: %w", t.dir, err)
+			}
+		}
+	}
+	if cleaning {
+		return locked, nil
+	}
+	if checking {
+		staleFiles += checkGenerated(t.dir, checked, previous)
+		return locked, nil
+	}
 
-		log.Println("\nFactorio Lua definitions generated successfully.")
-		log.Printf("Generated files are located in: %s", outputDir)
-		log.Println("\nTo use these definitions with lua-language-server, configure your editor's settings to add this directory to the Lua.workspace.library setting.")
-	},
+	log.Println("\nFactorio Lua definitions generated successfully.")
+	log.Printf("Generated files are located in: %s", t.dir)
+	return locked, nil
 }
 
+// keepUserFiles wraps the FileCreator of the directory dir to refuse overwriting
+// the files in it that a previous run didn't generate, per its manifest or, for
+// a directory without one, their headers (see readGeneratedFiles): those are the
+// user's, kept unless --force.
+func keepUserFiles(dir string, previous []string, create generator.FileCreator) generator.FileCreator {
+	return func(filename string) (io.WriteCloser, error) {
+		path := filepath.Join(dir, filepath.FromSlash(filename))
+		if !force && !slices.Contains(previous, filename) && fileExists(path) {
+			return nil, fmt.Errorf("not overwriting %s, which factorio-api-gen didn't generate: move it away, or pass --force to overwrite it", path)
+		}
+		return create(filename)
+	}
+}
+
+// The --output-format values besides the archive formats.
+const (
+	outputToDir    = "dir"
+	outputToStdout = "stdout"
+)
+
+// knownFeatureFlags are the values of --feature-flags: the expansions of the game.
+var knownFeatureFlags = []string{"space-age", "quality", "elevated-rails"}
+
 func init() {
 	rootCmd.PersistentFlags().StringVar(&runtimeURL, "runtime-url", "https://lua-api.factorio.com/latest/runtime-api.json", "URL for the Factorio Runtime API JSON")
 	rootCmd.PersistentFlags().StringVar(&prototypeURL, "prototype-url", "https://lua-api.factorio.com/latest/prototype-api.json", "URL for the Factorio Prototype API JSON")
+	rootCmd.PersistentFlags().StringSliceVar(&versions, "factorio-version", nil, "Game versions to generate definitions for, each in a subdirectory of the output named after its major and minor version (e.g. 1.1.110,2.0.28 writes 1.1/ and 2.0/); their API documents are cached")
+	rootCmd.PersistentFlags().BoolVar(&frozen, "frozen", false, "Generate from the API documents recorded in the lockfile, failing if they changed or the lockfile was written by another generator version")
+	rootCmd.PersistentFlags().StringVar(&lockPath, "lockfile", "", "Lockfile recording the API documents (URLs, game versions and checksums) and generator version of the definitions (default: "+lockFileName+" in the output directory)")
+	rootCmd.PersistentFlags().BoolVar(&watch, "watch", false, "Keep running after generating, polling the API for a new game version and regenerating the definitions when one is released")
+	rootCmd.PersistentFlags().DurationVar(&watchInterval, "watch-interval", time.Hour, "How often --watch polls the API")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output-format", outputToDir, "Where the definitions are written: 'dir' (the --output directory), 'zip' or 'tar.gz' (an archive of it, at --output with the extension added) or 'stdout' (the files one after the other, logging to stderr)")
+	rootCmd.PersistentFlags().BoolVar(&force, "force", false, "Overwrite files of the output directory that a previous run didn't generate, which are otherwise kept by failing")
+	rootCmd.PersistentFlags().BoolVar(&backup, "backup", false, "Copy the previous definitions to a timestamped directory next to the output directory (e.g. factorio.bak/20250101-120000/) before overwriting them")
+	rootCmd.PersistentFlags().BoolVar(&checking, "check", false, "Generate the definitions in memory and compare them with those of the output directory instead of writing them, exiting with status 1 if they differ (for CI)")
+	rootCmd.PersistentFlags().StringVar(&runReportPath, "report", "", "Write a JSON report of the generation to this file: the API documents, options, files written with their SHA-256, warnings and statistics, for programs running the generator")
+	rootCmd.PersistentFlags().BoolVar(&prune, "prune", false, "Remove the files a previous run generated in the output directory that this one doesn't, e.g. after changing --split-files (see the clean command)")
+	rootCmd.PersistentFlags().StringVar(&postHook, "post-hook", "", "Shell command run after --watch regenerates the definitions (e.g. to commit and push them), with FACTORIO_VERSION and FACTORIO_API_OUTPUT set")
 	rootCmd.PersistentFlags().StringVar(&outputDir, "output", "./output/factorio", "Output directory for generated Lua definitions")
+	rootCmd.PersistentFlags().StringSliceVar(&includes, "include", nil, "Only generate the classes, events and prototypes matching these glob patterns (e.g. 'LuaEntity*'); repeatable")
+	rootCmd.PersistentFlags().StringSliceVar(&excludes, "exclude", nil, "Skip the classes, events and prototypes matching these glob patterns (e.g. 'LuaGui*'); repeatable")
+	rootCmd.PersistentFlags().StringVar(&protoClasses, "prototype-classes", string(generator.PrototypeClassesByType), "Prototype classes: 'type' (one per prototype type, properties merged) or 'definition' (also one per prototype definition, with its exact fields)")
+	rootCmd.PersistentFlags().StringVar(&typePrefix, "type-prefix", "", "Prefix for all generated class and alias names and their references (e.g. 'Factorio.' gives Factorio.LuaEntity)")
+	rootCmd.PersistentFlags().StringVar(&protoPrefix, "prototype-class-prefix", "", "Prefix for the class names derived from prototype type names (e.g. 'Factorio' gives FactorioAssemblingMachinePrototype)")
+	rootCmd.PersistentFlags().StringVar(&headerFile, "header-template", "", "Go text/template file rendering the comment at the top of every generated file, in place of the default banner")
+	rootCmd.PersistentFlags().StringVar(&templatesDir, "templates", "", "Directory of Go text/templates (class.tmpl, method.tmpl, field.tmpl, define.tmpl) overriding how those entities are emitted")
+	rootCmd.PersistentFlags().StringVar(&dataRawNames, "data-raw-names", "", "JSON file of known prototype names per type (e.g. data-raw-dump.json from factorio --dump-data, or 'dump' for the local game's), declared as fields of the data.raw tables and offered for the ID types (ItemID, ...)")
+	rootCmd.PersistentFlags().StringVar(&only, "only", "", "Generate only one stage: 'runtime' or 'prototype' (default: both)")
+	rootCmd.PersistentFlags().StringVar(&scriptContext, "context", string(generator.ContextControl), "Scripts the runtime definitions are for: 'control' (a mod's control.lua), 'migrations' (migration scripts, whose globals go in migrations.lua; --workspace configures the mod's migrations/ directory) or 'scenario' (a scenario's or tutorial's control.lua, whose globals go in scenario.lua)")
+	rootCmd.PersistentFlags().BoolVar(&stageGlobals, "stage-globals", false, "Declare the globals of the settings, data and control stages in directories of their own (settings/, data/, control/), next to the shared definitions in common/, so a workspace only sees its stage's globals; --workspace configures the control stage")
+	rootCmd.PersistentFlags().StringSliceVar(&stageDirs, "stage-dir", nil, "With --stage-globals and --workspace, a directory of the workspace holding the code of a stage, which gets a .luarc.json of its own with that stage's globals (e.g. data=prototypes); repeatable")
+	rootCmd.PersistentFlags().StringSliceVar(&featureFlags, "feature-flags", nil, "Expansions the mod depends on: 'space-age', 'quality' and/or 'elevated-rails'; members only available with other expansions are left out (empty for the base game only; default: every member)")
+	rootCmd.PersistentFlags().StringVar(&optionalStyle, "optional-style", string(generator.OptionalSuffix), "How optional fields are annotated: 'suffix' (name? T) or 'nil-union' (name T | nil, for older LuaLS versions)")
+	rootCmd.PersistentFlags().BoolVar(&splitFiles, "split-files", false, "Emit one file per class and section (e.g. runtime/classes/LuaEntity.lua) instead of one file per stage")
+	rootCmd.PersistentFlags().BoolVar(&reqPlugin, "require-plugin", false, "Also write plugin.lua, a LuaLS plugin resolving require(\"__mod-name__/...\") paths (set up by --workspace and install vscode)")
+	rootCmd.PersistentFlags().StringVar(&rockSourceURL, "rock-source-url", "", "Source URL of the rockspec written by --format luarocks, an archive of the output to publish the rock from (default: '.', enough for luarocks make)")
+	rootCmd.PersistentFlags().IntVar(&jobs, "jobs", 0, "Number of classes and prototype types generated concurrently (0: one per CPU, 1: one at a time)")
+	rootCmd.PersistentFlags().BoolVar(&noExamples, "no-examples", false, "Omit API examples from doc comments, for smaller output files")
+	rootCmd.PersistentFlags().StringVar(&numberMode, "numbers", string(generator.NumbersStrict), "Numeric type mapping: 'strict' (integral types as integer, floating types as number) or 'loose' (everything as number)")
+	rootCmd.PersistentFlags().StringSliceVar(&diagnostics, "disable-diagnostics", nil, "LuaLS diagnostics to disable in the generated files ('default' for the ones the definitions trigger by design, e.g. lowercase-global); repeatable")
+	rootCmd.PersistentFlags().BoolVar(&typeReport, "warnings-report", false, "Write warnings.json next to the output, listing the types that were unresolved or downgraded to any/table")
+	rootCmd.PersistentFlags().StringVar(&workspaceDir, "workspace", "", "Mod workspace whose .luarc.json is written or updated to use the generated definitions")
+	rootCmd.PersistentFlags().StringVar(&modsDir, "mods-dir", "", "Factorio mods directory the dependencies in the workspace's info.json are looked up in, to add them to the workspace library (default: the platform's user mods directory)")
+	rootCmd.PersistentFlags().BoolVar(&fetchDeps, "fetch-dependencies", false, "Download the dependencies missing from --mods-dir from the mod portal into a cache, for the workspace library (needs FACTORIO_USERNAME and FACTORIO_TOKEN, or a logged in game)")
+	rootCmd.PersistentFlags().StringSliceVar(&customEvents, "custom-events", nil, "Mod directory whose Lua files are scanned for script.generate_event_name() ids and script.raise_event payloads, generating typed custom events; repeatable")
+	rootCmd.PersistentFlags().StringSliceVar(&remoteMods, "remote-interfaces", nil, "Mod directory whose Lua files, and those of its dependencies, are scanned for remote.add_interface calls, typing remote.call for their functions; repeatable")
+	rootCmd.PersistentFlags().StringSliceVar(&localeDirs, "locale", nil, "Mod directory, or the game's data directory, whose locale files give the localised names of prototypes and define values in the descriptions; repeatable, later ones overriding earlier ones")
+	rootCmd.PersistentFlags().StringVar(&localeLang, "locale-language", "en", "The language of the locale files read with --locale, e.g. 'de'")
+	rootCmd.PersistentFlags().StringSliceVar(&modSettings, "mod-settings", nil, "Mod directory whose settings.lua declares the only valid names of settings.startup, settings.global and the per-player settings; repeatable")
+	rootCmd.PersistentFlags().StringSliceVar(&formats, "format", []string{string(generator.FormatLuaLS)}, "Output formats: 'luals' (LuaLS annotations), 'luals-addon' (the annotations as a LuaLS addon), 'luarocks' (the annotations as a rock, with its rockspec), 'teal' (Teal .d.tl declarations), 'dts' (TypeScriptToLua .d.ts declarations), 'ir-json' (the normalized API as JSON), 'markdown' (an offline API reference), 'html' (the reference as a static site) and/or 'lua-stubs' (executable stubs for unit tests); repeatable")
+	rootCmd.PersistentFlags().StringVar(&dialect, "dialect", string(generator.DialectLuaLS), "Annotation dialect: 'luals' or 'emmylua' (the subset understood by older EmmyLua plugins, e.g. for IntelliJ)")
+	rootCmd.PersistentFlags().StringVar(&tupleStyle, "tuple-style", string(generator.TuplesModern), "How tuple types are written: 'modern' ([T1, T2]) or 'table' ({1: T1, 2: T2}, for older lua-language-server versions)")
+}
+
+// dependencyLibraries returns the source directories of the mods the workspace's
+// info.json depends on, found in --mods-dir, for the workspace library. A
+// workspace that isn't a mod has none. With --fetch-dependencies, the missing ones
+// are downloaded from the mod portal. Dependencies that can't be found are
+// reported, but don't stop the setup.
+func dependencyLibraries(dir string) []string {
+	if _, err := os.Stat(filepath.Join(dir, "info.json")); err != nil {
+		return nil
+	}
+	mods := modsDir
+	if mods == "" {
+		mods = workspace.DefaultModsDir()
+	}
+	var portal *workspace.ModPortal
+	if fetchDeps {
+		username, token, err := workspace.PortalCredentials(mods)
+		if err != nil {
+			log.Fatalf("Fatal error: --fetch-dependencies: %v", err)
+		}
+		cache, err := workspace.DefaultModCacheDir()
+		if err != nil {
+			log.Fatalf("Fatal error: --fetch-dependencies: %v", err)
+		}
+		portal = &workspace.ModPortal{URL: workspace.DefaultModPortalURL, CacheDir: cache, Username: username, Token: token}
+	}
+	resolved, missing, err := workspace.ResolveDependencies(dir, mods, portal)
+	if err != nil {
+		log.Printf("Warning: not adding the mod's dependencies to the library: %v", err)
+		return nil
+	}
+	var libraries []string
+	for _, mod := range resolved {
+		library, err := filepath.Abs(mod.Dir)
+		if err != nil {
+			log.Fatalf("Fatal error resolving %s: %v", mod.Dir, err)
+		}
+		log.Printf("Adding dependency %s %s from %s", mod.Name, mod.Version, library)
+		libraries = append(libraries, library)
+	}
+	for _, dep := range missing {
+		log.Printf("Warning: dependency %q not added to the library: %v", dep.Name, dep.Err)
+	}
+	return libraries
+}
+
+// remoteInterface converts a scanned remote interface for the generator.
+func remoteInterface(remote workspace.RemoteInterface) generator.RemoteInterface {
+	converted := generator.RemoteInterface{Name: remote.Name}
+	for _, function := range remote.Functions {
+		f := generator.RemoteFunction{Name: function.Name, Returns: function.Returns}
+		for _, param := range function.Parameters {
+			f.Parameters = append(f.Parameters, generator.RemoteParameter{Name: param.Name, Type: param.Type, Optional: param.Optional})
+		}
+		converted.Functions = append(converted.Functions, f)
+	}
+	return converted
 }
 
 func main() {
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestMain runs the command instead of the tests when runCommand starts the
+// test binary, so tests can check how it exits.
+func TestMain(m *testing.M) {
+	if os.Getenv("FACTORIO_API_GEN_RUN_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// fixtureFlags serves the API documents of the generator's 2.0.45 fixtures for
+// the duration of the test, and returns the flags generating from them. The
+// headers of the generated files name the URLs, so a test comparing runs uses
+// the same flags for all of them.
+func fixtureFlags(t *testing.T) []string {
+	t.Helper()
+	server := httptest.NewServer(http.FileServer(http.Dir(filepath.Join("pkg", "generator", "testdata", "fixtures", "2.0.45"))))
+	t.Cleanup(server.Close)
+	return []string{"--runtime-url", server.URL + "/runtime-api.json", "--prototype-url", server.URL + "/prototype-api.json"}
+}
+
+// runCommand runs the command with args and returns its exit code and output.
+func runCommand(t *testing.T, args ...string) (int, string) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	home := t.TempDir()
+	cmd.Env = append(os.Environ(), "FACTORIO_API_GEN_RUN_MAIN=1", "HOME="+home, "XDG_CACHE_HOME="+filepath.Join(home, ".cache"))
+	output, err := cmd.CombinedOutput()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode(), string(output)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return 0, string(output)
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// versionedDocument matches the URLs of the API documents of a given game
+// version, e.g. https://lua-api.factorio.com/2.0.28/runtime-api.json, which never
+// change once published.
+var versionedDocument = regexp.MustCompile(`/\d+\.\d+\.\d+/[^/]+\.json$`)
+
+// Cache keeps downloaded API documents on disk, so that each version's documents
+// are downloaded once, whether for several versions in one run or across runs.
+// Only the documents of a version are cached: those of "latest" change with
+// every release.
+type Cache struct {
+	Dir string // Documents are stored as <Dir>/<sha256 of the URL>.json
+}
+
+// DefaultCacheDir returns where API documents are cached by default.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "factorio-api-gen", "api"), nil
+}
+
+// DownloadAndParseAPI is DownloadAndParseAPI reading the document through the
+// cache, see Fetch.
+func (c *Cache) DownloadAndParseAPI(url string, v interface{}) error {
+	body, err := c.Fetch(url)
+	if err != nil {
+		return err
+	}
+	return ParseAPI(url, body, v)
+}
+
+// Fetch returns a document, read from the cache when it holds it, and downloaded
+// and added to it otherwise. A nil cache caches nothing, and a cache that can't
+// be written to only logs a warning.
+func (c *Cache) Fetch(url string) ([]byte, error) {
+	if c == nil {
+		return download(url)
+	}
+	client := defaultClient()
+	client.CacheDir = c.Dir
+	return client.Fetch(context.Background(), url)
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestCacheDownloadsVersionsOnce(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		io.WriteString(w, `{"application": "factorio", "application_version": "2.0.28", "stage": "runtime"}`)
+	}))
+	defer server.Close()
+
+	cache := &Cache{Dir: t.TempDir()}
+	for _, c := range []struct {
+		url      string
+		requests int
+	}{
+		{server.URL + "/2.0.28/runtime-api.json", 1},
+		{server.URL + "/2.0.28/runtime-api.json", 1}, // Read from the cache
+		{server.URL + "/latest/runtime-api.json", 2},
+		{server.URL + "/latest/runtime-api.json", 3}, // Never cached
+	} {
+		var parsed API
+		if err := cache.DownloadAndParseAPI(c.url, &parsed); err != nil {
+			t.Fatalf("%s: %v", c.url, err)
+		}
+		if parsed.ApplicationVersion != "2.0.28" {
+			t.Errorf("%s: parsed version %q", c.url, parsed.ApplicationVersion)
+		}
+		if requests != c.requests {
+			t.Errorf("%s: %d requests, want %d", c.url, requests, c.requests)
+		}
+	}
+}
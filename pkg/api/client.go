@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// Client downloads and parses API documents for programs embedding the
+// generator: it uses the HTTP client and logger it is given, and stops when its
+// context is canceled. The zero Client uses http.DefaultClient, logs nothing and
+// caches nothing.
+//
+// DownloadAndParseAPI, LoadPrototypeNames and Cache are Clients logging to the
+// standard logger.
+type Client struct {
+	HTTPClient *http.Client // http.DefaultClient if nil
+	Logger     *log.Logger  // Progress messages; nil logs nothing
+	// Where the documents of a game version are cached, see Cache. Empty caches
+	// nothing.
+	CacheDir string
+}
+
+// Load downloads (or reads from the cache) and parses a document.
+func (c *Client) Load(ctx context.Context, url string) (*API, error) {
+	body, err := c.Fetch(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return c.Parse(url, body)
+}
+
+// Parse parses a document downloaded from url.
+func (c *Client) Parse(url string, body []byte) (*API, error) {
+	document := &API{}
+	if err := c.parse(url, body, document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+// Fetch returns a document, read from the cache when it holds it, and downloaded
+// and added to it otherwise. Only the documents of a game version are cached:
+// those of "latest" change with every release. A cache that can't be written to
+// only logs a warning.
+func (c *Client) Fetch(ctx context.Context, url string) ([]byte, error) {
+	if c.CacheDir == "" || !versionedDocument.MatchString(url) {
+		return c.download(ctx, url)
+	}
+	path := filepath.Join(c.CacheDir, fmt.Sprintf("%x.json", sha256.Sum256([]byte(url))))
+	if body, err := os.ReadFile(path); err == nil && json.Valid(body) {
+		c.logf("Using the cached copy of %s: %s", url, path)
+		return body, nil
+	}
+	// A missing or corrupted copy is downloaded again.
+	body, err := c.download(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.store(path, body); err != nil {
+		c.logf("Warning: not caching %s: %v", url, err)
+	}
+	return body, nil
+}
+
+// download returns the body of a document fetched with a GET request.
+func (c *Client) download(ctx context.Context, url string) ([]byte, error) {
+	c.logf("Attempting to download API from: %s", url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download API from %s: %w", url, err)
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		c.logf("Failed to download API from %s: %v", url, err)
+		return nil, fmt.Errorf("failed to download API from %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	c.logf("Download successful from %s, status code: %d", url, resp.StatusCode)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download API from %s: received status code %d", url, resp.StatusCode)
+	}
+
+	c.logf("Reading response body from %s", url)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.logf("Failed to read response body from %s: %v", url, err)
+		return nil, fmt.Errorf("failed to read response body from %s: %w", url, err)
+	}
+	c.logf("Successfully read %d bytes from %s", len(body), url)
+	return body, nil
+}
+
+// parse unmarshals a document downloaded from url into v.
+func (c *Client) parse(url string, body []byte, v interface{}) error {
+	c.logf("Attempting to parse JSON from %s", url)
+	err := json.Unmarshal(body, v)
+	if err != nil {
+		c.logf("Failed to parse JSON from %s: %v", url, err)
+		return fmt.Errorf("failed to parse JSON from %s: %w", url, err)
+	}
+	c.logf("Successfully parsed JSON from %s", url)
+	return nil
+}
+
+// store writes a document to the cache. It is written to a temporary file first,
+// so that an interrupted run doesn't leave a partial copy behind.
+func (c *Client) store(path string, body []byte) error {
+	if err := os.MkdirAll(c.CacheDir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.CacheDir, "download-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (c *Client) logf(format string, args ...any) {
+	if c.Logger != nil {
+		// Report the line logging the message rather than this one.
+		c.Logger.Output(2, fmt.Sprintf(format, args...))
+	}
+}
@@ -1,43 +1,75 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log" // Import the log package
-	"net/http"
+	"os"
+	"slices"
 )
 
 // DownloadAndParseAPI downloads JSON from the given URL and unmarshals it into the provided interface.
 func DownloadAndParseAPI(url string, v interface{}) error {
-	log.Printf("Attempting to download API from: %s", url)
-	resp, err := http.Get(url)
+	body, err := download(url)
 	if err != nil {
-		log.Printf("Failed to download API from %s: %v", url, err)
-		return fmt.Errorf("failed to download API from %s: %w", url, err)
+		return err
 	}
-	defer resp.Body.Close()
-	log.Printf("Download successful from %s, status code: %d", url, resp.StatusCode)
+	return ParseAPI(url, body, v)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to download API from %s: received status code %d", url, resp.StatusCode)
-	}
+// defaultClient is the Client of the package functions, logging to the standard
+// logger.
+func defaultClient() *Client {
+	return &Client{Logger: log.Default()}
+}
 
-	log.Printf("Reading response body from %s", url)
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("Failed to read response body from %s: %v", url, err)
-		return fmt.Errorf("failed to read response body from %s: %w", url, err)
-	}
-	log.Printf("Successfully read %d bytes from %s", len(body), url)
+// download returns the body of a document fetched with a GET request.
+func download(url string) ([]byte, error) {
+	return defaultClient().download(context.Background(), url)
+}
 
-	log.Printf("Attempting to parse JSON from %s", url)
-	err = json.Unmarshal(body, v)
+// ParseAPI unmarshals a document downloaded from url into v.
+func ParseAPI(url string, body []byte, v interface{}) error {
+	return defaultClient().parse(url, body, v)
+}
+
+// LoadPrototypeNames reads the known prototype names per prototype type from a JSON
+// file. Each type maps either to an array of names, {"item": ["iron-plate"]}, or to
+// an object keyed by name, as in the data-raw-dump.json written by
+// `factorio --dump-data`. Names are returned sorted.
+func LoadPrototypeNames(path string) (map[string][]string, error) {
+	return defaultClient().LoadPrototypeNames(path)
+}
+
+// LoadPrototypeNames is the package's LoadPrototypeNames, logging to the
+// Client's logger.
+func (c *Client) LoadPrototypeNames(path string) (map[string][]string, error) {
+	c.logf("Reading prototype names from: %s", path)
+	data, err := os.ReadFile(path)
 	if err != nil {
-		log.Printf("Failed to parse JSON from %s: %v", url, err)
-		return fmt.Errorf("failed to parse JSON from %s: %w", url, err)
+		return nil, fmt.Errorf("failed to read prototype names from %s: %w", path, err)
 	}
-	log.Printf("Successfully parsed JSON from %s", url)
 
-	return nil
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse prototype names from %s: %w", path, err)
+	}
+	names := make(map[string][]string, len(raw))
+	for typeName, value := range raw {
+		var list []string
+		if err := json.Unmarshal(value, &list); err != nil {
+			var byName map[string]json.RawMessage
+			if err := json.Unmarshal(value, &byName); err != nil {
+				return nil, fmt.Errorf("failed to parse prototype names of type %s in %s: expected an array or an object", typeName, path)
+			}
+			for name := range byName {
+				list = append(list, name)
+			}
+		}
+		slices.Sort(list)
+		names[typeName] = list
+	}
+	c.logf("Read names for %d prototype types from %s", len(names), path)
+	return names, nil
 }
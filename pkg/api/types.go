@@ -3,8 +3,9 @@ package api
 import (
 	"bytes" // Import the bytes package
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log" // Import the log package
+	"slices"
 )
 
 // API represents the overall structure of the Factorio API JSON files.
@@ -12,6 +13,11 @@ import (
 // including common top-level keys.
 // Note: Top-level collections are arrays in the JSON, hence the use of slices here.
 type API struct {
+	Application        string `json:"application,omitempty"`         // Always "factorio"
+	ApplicationVersion string `json:"application_version,omitempty"` // Game version the API was generated from, e.g. "2.0.45"
+	APIVersion         int    `json:"api_version,omitempty"`         // Version of the JSON format
+	Stage              string `json:"stage,omitempty"`               // "runtime" or "prototype"
+
 	Classes       []Class        `json:"classes,omitempty"`
 	Events        []Event        `json:"events,omitempty"`
 	Defines       []Define       `json:"defines,omitempty"`
@@ -19,6 +25,7 @@ type API struct {
 	Concepts      []Concept      `json:"concepts,omitempty"`      // Found in both APIs, often custom types
 	Prototypes    []Prototype    `json:"prototypes,omitempty"`    // Specific to prototype-api.json
 	BuiltinTypes  []Type         `json:"builtin_types,omitempty"` // Documented built-in types
+	Types         []Concept      `json:"types,omitempty"`         // prototype-api.json name for its concepts
 	// Add other top-level fields if needed after a full analysis
 }
 
@@ -29,25 +36,78 @@ type BasicMember struct {
 	Description string   `json:"description"`
 	Lists       []string `json:"lists,omitempty"`    // Additional markdown lists
 	Examples    []string `json:"examples,omitempty"` // Code examples
+	Deprecated  bool     `json:"deprecated,omitempty"`
+	// The expansions the member is only available with, e.g. "space_age"; empty
+	// for members of the base game. See VisibleWith.
+	Visibility []string `json:"visibility,omitempty"`
 	// Images []Image `json:"images,omitempty"` // If you need to parse image info
 	// Note: 'Notes' field also exists on some members
 }
 
+// SortKey returns the documented order and name of the member. Sorting by
+// order first (then by name) reproduces the order used on the API website.
+func (b BasicMember) SortKey() (int, string) {
+	return b.Order, b.Name
+}
+
+// VisibleWith reports whether the member is available when the given expansions
+// (visibility names, e.g. "space_age") are enabled: members of the base game
+// always are, the others when one of the expansions they're visible with is.
+func (b BasicMember) VisibleWith(expansions []string) bool {
+	if len(b.Visibility) == 0 {
+		return true
+	}
+	for _, visibility := range b.Visibility {
+		if slices.Contains(expansions, visibility) {
+			return true
+		}
+	}
+	return false
+}
+
 // Class represents a Factorio Lua API class.
 // Methods and Properties are arrays in the JSON, not maps keyed by name.
 type Class struct {
 	BasicMember
 	Methods    []Method   `json:"methods,omitempty"`    // Corrected to slice
 	Properties []Property `json:"properties,omitempty"` // Corrected to slice
-	Parent     string     `json:"parent,omitempty"`     // Inherited class name
+	Attributes []Property `json:"attributes,omitempty"` // Runtime API name for properties (uses read_type/write_type)
+	Operators  []Operator `json:"operators,omitempty"`  // Operators supported by instances of the class
+	Parent     ParentList `json:"parent,omitempty"`     // Inherited class name(s)
 	Abstract   bool       `json:"abstract,omitempty"`
 	// Add other class-specific fields
 }
 
+// ParentList holds the parent class names of a class. The JSON gives a single
+// name for most classes, but 2.0 may list several parents, so both a string and
+// an array of strings are accepted.
+type ParentList []string
+
+// UnmarshalJSON accepts either a single parent name or a list of names.
+func (p *ParentList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		if single == "" {
+			*p = nil
+		} else {
+			*p = ParentList{single}
+		}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("failed to unmarshal parent list: %w", err)
+	}
+	*p = ParentList(list)
+	return nil
+}
+
 // Event represents a Factorio Lua API event.
 type Event struct {
 	BasicMember
 	Data []Parameter `json:"data,omitempty"` // Parameters passed to the event handler
+	// Filter names the event filter concept accepted when registering the event, if any.
+	Filter string `json:"filter,omitempty"`
 	// Add other event-specific fields
 }
 
@@ -77,6 +137,13 @@ type Concept struct {
 	BasicMember
 	Category string `json:"category"` // e.g., "type", "concept"
 	Type     Type   `json:"type"`     // The underlying type definition
+
+	// Prototype types of complex_type "struct" define their fields as properties,
+	// optionally inheriting those of a parent type.
+	Parent     ParentList `json:"parent,omitempty"`
+	Abstract   bool       `json:"abstract,omitempty"`
+	Inline     bool       `json:"inline,omitempty"`
+	Properties []Property `json:"properties,omitempty"`
 	// Add other concept-specific fields
 }
 
@@ -93,12 +160,40 @@ type Prototype struct {
 // Method represents a method of a class.
 type Method struct {
 	BasicMember
-	Parameters  []Parameter  `json:"parameters,omitempty"`
-	ReturnTypes []ReturnType `json:"return_types,omitempty"` // Can return multiple values
-	Variadic    bool         `json:"variadic,omitempty"`     // If it accepts variable arguments
+	Parameters        []Parameter        `json:"parameters,omitempty"`
+	ReturnValues      []ReturnType       `json:"return_values,omitempty"`      // Can return multiple values
+	VariadicParameter *VariadicParameter `json:"variadic_parameter,omitempty"` // Set if it accepts variable arguments
+	Format            MethodFormat       `json:"format"`                       // How the parameters are passed
+
+	// Variant parameter groups add parameters depending on the value of another
+	// parameter (usually `type`), e.g. the element-specific arguments of LuaGuiElement.add.
+	VariantParameterGroups      []ParameterGroup `json:"variant_parameter_groups,omitempty"`
+	VariantParameterDescription string           `json:"variant_parameter_description,omitempty"`
 	// Add other method-specific fields
 }
 
+// ParameterGroup is a named group of additional parameters used by one variant
+// of a table-taking method or table concept.
+type ParameterGroup struct {
+	BasicMember
+	Parameters []Parameter `json:"parameters,omitempty"`
+}
+
+// MethodFormat describes how a method receives its parameters.
+type MethodFormat struct {
+	TakesTable    bool `json:"takes_table"`              // Parameters are passed as a single table of named arguments
+	TableOptional bool `json:"table_optional,omitempty"` // The parameter table itself may be omitted
+}
+
+// Operator represents an operator supported by a class: "call", "index" or "length".
+// The call operator has the shape of a method; index and length have the shape of
+// an attribute, with the resulting type in ReadType.
+type Operator struct {
+	Method
+	ReadType *Type `json:"read_type,omitempty"`
+	Optional bool  `json:"optional,omitempty"`
+}
+
 // Property represents a property of a class or prototype.
 type Property struct {
 	BasicMember
@@ -110,9 +205,39 @@ type Property struct {
 	Overload bool        `json:"overload,omitempty"` // If it overrides a parent property
 	AltName  string      `json:"alt_name,omitempty"` // Alternative name
 	Default  interface{} `json:"default,omitempty"`  // Default value
+
+	// Runtime attributes carry separate read and write types instead of Type.
+	// A missing read_type means the attribute is write-only, and vice versa.
+	ReadType  *Type `json:"read_type,omitempty"`
+	WriteType *Type `json:"write_type,omitempty"`
 	// Add other property-specific fields
 }
 
+// ValueType returns the type of the property as seen by Lua code. For runtime
+// attributes this is the read type, falling back to the write type for
+// write-only attributes; prototype properties use Type directly.
+func (p Property) ValueType() Type {
+	if p.ReadType != nil {
+		return *p.ReadType
+	}
+	if p.WriteType != nil {
+		return *p.WriteType
+	}
+	return p.Type
+}
+
+// IsReadable reports whether the property can be read, using read_type for
+// runtime attributes and the read flag otherwise.
+func (p Property) IsReadable() bool {
+	return p.Read || p.ReadType != nil
+}
+
+// IsWritable reports whether the property can be written, using write_type for
+// runtime attributes and the write flag otherwise.
+func (p Property) IsWritable() bool {
+	return p.Write || p.WriteType != nil
+}
+
 // Parameter represents a parameter of a method or event.
 type Parameter struct {
 	Name        string `json:"name"`
@@ -124,6 +249,17 @@ type Parameter struct {
 	// Add other parameter-specific fields
 }
 
+// SortKey returns the documented order and name of the parameter.
+func (p Parameter) SortKey() (int, string) {
+	return p.Order, p.Name
+}
+
+// VariadicParameter describes the trailing variable arguments of a method.
+type VariadicParameter struct {
+	Type        Type   `json:"type"`
+	Description string `json:"description"`
+}
+
 // ReturnType represents a return value of a method.
 type ReturnType struct {
 	Type        Type   `json:"type"`
@@ -133,6 +269,12 @@ type ReturnType struct {
 	Order       int    `json:"order"` // Order of the return value
 }
 
+// SortKey returns the documented order of the return value. Return values
+// are unnamed, so the name component is always empty.
+func (r ReturnType) SortKey() (int, string) {
+	return r.Order, ""
+}
+
 // Type represents a data type in the Factorio API. This struct and its
 // UnmarshalJSON method are designed to handle the various ways types
 // are defined in the JSON (simple name, complex structure, unions, etc.).
@@ -146,13 +288,21 @@ type Type struct {
 	ComplexType string `json:"complex_type,omitempty"` // e.g., "array", "dictionary", "union", "literal", "type", "struct", "tuple"
 
 	// Details for specific complex types:
-	Value *Type `json:"value,omitempty"` // For "array" (element type) or "type" (actual type)
-	Key   *Type `json:"key,omitempty"`   // For "dictionary" (key type)
-	// Value field is also used for "dictionary" (value type)
+	Value *Type `json:"value,omitempty"` // For "array" (element type), "type" (actual type) or "LuaLazyLoadedValue" (loaded type)
+	Key   *Type `json:"key,omitempty"`   // For "dictionary" and "LuaCustomTable" (key type)
+	// Value field is also used for "dictionary" and "LuaCustomTable" (value type)
 
 	Values []Type `json:"values,omitempty"` // For "tuple" (element elements) or "union" (possible types)
 
-	LiteralValue interface{} `json:"value,omitempty"` // For "literal" (the literal value)
+	Parameters []Type `json:"parameters,omitempty"` // For "function" (the types of the function's arguments)
+
+	// For "table" (named fields, stored under "parameters", and variant field groups).
+	Fields                 []Parameter      `json:"-"`
+	VariantParameterGroups []ParameterGroup `json:"-"`
+
+	Attributes []Property `json:"attributes,omitempty"` // For "LuaStruct" (the struct's attributes)
+
+	LiteralValue interface{} `json:"-"` // For "literal" (the literal value, stored under the "value" key and set by UnmarshalJSON)
 
 	FullFormat bool `json:"full_format,omitempty"` // For "union" (if options have descriptions)
 
@@ -161,21 +311,35 @@ type Type struct {
 	BasicMember
 }
 
+// ComplexTypes lists the complex_types Type.UnmarshalJSON parses; it rejects the
+// others.
+var ComplexTypes = []string{"array", "dictionary", "LuaCustomTable", "union", "literal", "type", "struct", "table", "LuaLazyLoadedValue", "LuaStruct", "tuple", "function", "builtin"}
+
 // UnmarshalJSON is a custom unmarshaler for the Type struct to handle
 // the varied structure of type definitions in the Factorio API JSON.
 // It first attempts to unmarshal into a temporary struct to capture
 // the complex_type and name, then uses json.RawMessage to handle
 // nested structures based on the complex_type.
+//
+// Shapes it can't represent faithfully, such as an unknown complex_type or an
+// array without an element type, are errors rather than partially filled Types.
 func (t *Type) UnmarshalJSON(data []byte) error {
-	log.Printf("UnmarshalJSON for Type: Raw data size %d, Data: %s", len(data), string(data))
+
+	// The API never leaves a type out as null, so a null is a malformed document
+	// rather than an absent type.
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return errors.New("type is null")
+	}
 
 	// First, check if the data is a simple string.
 	var stringValue string
 	if err := json.Unmarshal(data, &stringValue); err == nil {
+		if stringValue == "" {
+			return errors.New("type has an empty name")
+		}
 		// If it's a string, set the Name field and return.
 		t.Name = stringValue
 		t.ComplexType = "" // Ensure complex type is empty for simple types
-		log.Printf("UnmarshalJSON: Unmarshaled as simple string: '%s'", t.Name)
 		return nil
 	}
 
@@ -192,15 +356,21 @@ func (t *Type) UnmarshalJSON(data []byte) error {
 		ValueRaw  json.RawMessage `json:"value,omitempty"`
 		KeyRaw    json.RawMessage `json:"key,omitempty"`
 		ValuesRaw json.RawMessage `json:"values,omitempty"`
+		// Unions list their members under "options" rather than "values".
+		OptionsRaw json.RawMessage `json:"options,omitempty"`
+		ParamsRaw  json.RawMessage `json:"parameters,omitempty"`
+		// Tables may split their fields into variant groups.
+		VariantGroupsRaw json.RawMessage `json:"variant_parameter_groups,omitempty"`
+		AttributesRaw    json.RawMessage `json:"attributes,omitempty"`
 
 		// BasicMember fields might be present for some complex types (union, literal, type, tuple)
 		// Unmarshal these into a separate struct first.
 		BasicMemberRaw json.RawMessage `json:",inline"` // Use inline to capture top-level BasicMember fields
+		// Union options (and full-format literals) carry their own description.
+		Description string `json:"description,omitempty"`
 	}{}
 
-	log.Println("UnmarshalJSON: Data is not a string, attempting complex unmarshalling.")
 	if err := json.Unmarshal(data, &temp); err != nil {
-		log.Printf("Error initial complex unmarshal of Type struct: %v", err)
 		return fmt.Errorf("failed initial complex unmarshal of Type struct: %w", err)
 	}
 
@@ -208,8 +378,7 @@ func (t *Type) UnmarshalJSON(data []byte) error {
 	t.Name = temp.Name
 	t.ComplexType = temp.ComplexType
 	t.FullFormat = temp.FullFormat
-
-	log.Printf("UnmarshalJSON (Complex): Name='%s', ComplexType='%s'", t.Name, t.ComplexType)
+	t.Description = temp.Description
 
 	// Unmarshal BasicMember fields if they were present
 	if len(temp.BasicMemberRaw) > 0 {
@@ -220,12 +389,9 @@ func (t *Type) UnmarshalJSON(data []byte) error {
 		// and BasicMember only has simple fields or fields handled by default unmarshalling.
 		// Check if the raw data is not null or an empty object before attempting to unmarshal BasicMember
 		if !bytes.Equal(temp.BasicMemberRaw, []byte("null")) && !bytes.Equal(temp.BasicMemberRaw, []byte("{}")) {
-			if err := json.Unmarshal(temp.BasicMemberRaw, &bm); err != nil {
-				log.Printf("Warning: Failed to unmarshal BasicMember within Type: %v", err)
-				// Continue without BasicMember data if it fails
-			} else {
+			// Continue without BasicMember data if it fails
+			if err := json.Unmarshal(temp.BasicMemberRaw, &bm); err == nil {
 				t.BasicMember = bm
-				log.Printf("UnmarshalJSON (Complex): Unmarshaled BasicMember - Name='%s', Description='%s'", bm.Name, bm.Description)
 			}
 		}
 	}
@@ -233,73 +399,78 @@ func (t *Type) UnmarshalJSON(data []byte) error {
 	// Now, based on ComplexType, unmarshal the raw fields into the correct Type fields
 	switch t.ComplexType {
 	case "array":
-		log.Println("UnmarshalJSON (Complex): Handling complex_type 'array'")
-		if len(temp.ValueRaw) > 0 {
-			t.Value = &Type{} // Initialize nested Type
-			if err := json.Unmarshal(temp.ValueRaw, t.Value); err != nil {
-				log.Printf("Error unmarshalling array value type: %v", err)
-				return fmt.Errorf("failed to unmarshal array value type: %w", err)
-			}
-			log.Printf("UnmarshalJSON (Complex): Unmarshaled array value type")
+		if len(temp.ValueRaw) == 0 {
+			return errors.New("array type without a value type")
+		}
+		t.Value = &Type{} // Initialize nested Type
+		if err := json.Unmarshal(temp.ValueRaw, t.Value); err != nil {
+			return fmt.Errorf("failed to unmarshal array value type: %w", err)
+		}
+	case "dictionary", "LuaCustomTable":
+		// LuaCustomTable has the same key/value shape as a dictionary.
+		if len(temp.KeyRaw) == 0 || len(temp.ValueRaw) == 0 {
+			return fmt.Errorf("%s type without a key or value type", t.ComplexType)
 		}
-	case "dictionary":
-		log.Println("UnmarshalJSON (Complex): Handling complex_type 'dictionary'")
 		if len(temp.KeyRaw) > 0 {
 			t.Key = &Type{} // Initialize nested Type
 			if err := json.Unmarshal(temp.KeyRaw, t.Key); err != nil {
-				log.Printf("Error unmarshalling dictionary key type: %v", err)
 				return fmt.Errorf("failed to unmarshal dictionary key type: %w", err)
 			}
-			log.Printf("UnmarshalJSON (Complex): Unmarshaled dictionary key type")
 		}
 		if len(temp.ValueRaw) > 0 { // Note: Dictionary value also uses the "value" key
 			t.Value = &Type{} // Initialize nested Type
 			if err := json.Unmarshal(temp.ValueRaw, t.Value); err != nil {
-				log.Printf("Error unmarshalling dictionary value type: %v", err)
 				return fmt.Errorf("failed to unmarshal dictionary value type: %w", err)
 			}
-			log.Printf("UnmarshalJSON (Complex): Unmarshaled dictionary value type")
 		}
 	case "union":
-		log.Println("UnmarshalJSON (Complex): Handling complex_type 'union'")
-		if len(temp.ValuesRaw) > 0 {
-			if err := json.Unmarshal(temp.ValuesRaw, &t.Values); err != nil {
-				log.Printf("Error unmarshalling union values: %v", err)
+		// The API stores union members under "options"; "values" is accepted as a fallback.
+		optionsRaw := temp.OptionsRaw
+		if len(optionsRaw) == 0 {
+			optionsRaw = temp.ValuesRaw
+		}
+		if len(optionsRaw) > 0 {
+			if err := json.Unmarshal(optionsRaw, &t.Values); err != nil {
 				return fmt.Errorf("failed to unmarshal union values: %w", err)
 			}
-			log.Printf("UnmarshalJSON (Complex): Unmarshaled %d union values", len(t.Values))
+		}
+		if len(t.Values) == 0 {
+			return errors.New("union type without options")
 		}
 		// BasicMember fields (like Description) are handled by the BasicMemberRaw unmarshalling
 		// FullFormat is handled by the initial unmarshalling
 	case "literal":
-		log.Println("UnmarshalJSON (Complex): Handling complex_type 'literal'")
 		// Literal value can be string, number, or boolean. Unmarshal RawMessage directly.
 		// The key for the literal value is also "value".
 		if len(temp.ValueRaw) > 0 {
 			// Try unmarshalling into an interface{} to keep the original type
 			var val interface{}
 			if err := json.Unmarshal(temp.ValueRaw, &val); err != nil {
-				log.Printf("Error unmarshalling literal value: %v", err)
 				return fmt.Errorf("failed to unmarshal literal value: %w", err)
 			}
+			// Literals are strings, numbers or booleans.
+			switch val.(type) {
+			case string, float64, bool:
+			default:
+				return fmt.Errorf("literal type with a %T value", val)
+			}
 			t.LiteralValue = val
-			log.Printf("UnmarshalJSON (Complex): Unmarshaled literal value: %v (Type: %T)", val, val)
+		}
+		if t.LiteralValue == nil {
+			return errors.New("literal type without a value")
 		}
 		// BasicMember fields (like Description) are handled by the BasicMemberRaw unmarshalling
 	case "type":
-		log.Println("UnmarshalJSON (Complex): Handling complex_type 'type'")
 		// This complex type wraps another type, using the "value" key
-		if len(temp.ValueRaw) > 0 {
-			t.Value = &Type{} // Initialize nested Type
-			if err := json.Unmarshal(temp.ValueRaw, t.Value); err != nil {
-				log.Printf("Error unmarshalling wrapped type value: %v", err)
-				return fmt.Errorf("failed to unmarshal wrapped type value: %w", err)
-			}
-			log.Printf("UnmarshalJSON (Complex): Unmarshaled wrapped type value")
+		if len(temp.ValueRaw) == 0 {
+			return errors.New("type wrapper without a type")
+		}
+		t.Value = &Type{} // Initialize nested Type
+		if err := json.Unmarshal(temp.ValueRaw, t.Value); err != nil {
+			return fmt.Errorf("failed to unmarshal wrapped type value: %w", err)
 		}
 		// BasicMember fields (like Description) are handled by the BasicMemberRaw unmarshalling
 	case "struct":
-		log.Println("UnmarshalJSON (Complex): Handling complex_type 'struct'")
 		// 'struct' often just has a name and description, or might imply fields
 		// defined elsewhere. The BasicMember fields handle name/description.
 		// If there were inline field definitions, they would need to be handled here.
@@ -307,48 +478,71 @@ func (t *Type) UnmarshalJSON(data []byte) error {
 		// for named concepts or types that are essentially tables/structs.
 		// No additional unmarshalling is needed for the basic 'struct' case as defined.
 		// BasicMember fields (like Description) are handled by the BasicMemberRaw unmarshalling
+	case "table":
+		// Unlike functions, tables list named fields under "parameters".
+		if len(temp.ParamsRaw) > 0 {
+			if err := json.Unmarshal(temp.ParamsRaw, &t.Fields); err != nil {
+				return fmt.Errorf("failed to unmarshal table fields: %w", err)
+			}
+		}
+		if len(temp.VariantGroupsRaw) > 0 {
+			if err := json.Unmarshal(temp.VariantGroupsRaw, &t.VariantParameterGroups); err != nil {
+				return fmt.Errorf("failed to unmarshal table variant groups: %w", err)
+			}
+		}
+	case "LuaLazyLoadedValue":
+		// A LuaLazyLoadedValue of the type under "value", e.g. the entities of
+		// on_entity_damaged's cause.
+		if len(temp.ValueRaw) == 0 {
+			return errors.New("LuaLazyLoadedValue type without a value type")
+		}
+		t.Value = &Type{}
+		if err := json.Unmarshal(temp.ValueRaw, t.Value); err != nil {
+			return fmt.Errorf("failed to unmarshal lazily loaded value type: %w", err)
+		}
+	case "LuaStruct":
+		if len(temp.AttributesRaw) > 0 {
+			if err := json.Unmarshal(temp.AttributesRaw, &t.Attributes); err != nil {
+				return fmt.Errorf("failed to unmarshal struct attributes: %w", err)
+			}
+		}
 	case "tuple":
-		log.Println("UnmarshalJSON (Complex): Handling complex_type 'tuple'")
 		if len(temp.ValuesRaw) > 0 {
 			if err := json.Unmarshal(temp.ValuesRaw, &t.Values); err != nil {
-				log.Printf("Error unmarshalling tuple values: %v", err)
 				return fmt.Errorf("failed to unmarshal tuple values: %w", err)
 			}
-			log.Printf("UnmarshalJSON (Complex): Unmarshaled %d tuple values", len(t.Values))
+		}
+		if len(t.Values) == 0 {
+			return errors.New("tuple type without values")
 		}
 		// BasicMember fields (like Description) are handled by the BasicMemberRaw unmarshalling
 
+	case "function":
+		// Function types list their argument types (unnamed) under "parameters".
+		if len(temp.ParamsRaw) > 0 {
+			if err := json.Unmarshal(temp.ParamsRaw, &t.Parameters); err != nil {
+				return fmt.Errorf("failed to unmarshal function parameters: %w", err)
+			}
+		}
+
 	case "builtin":
-		log.Println("UnmarshalJSON (Complex): Handling complex_type 'builtin'")
 		// The log shows {"complex_type":"builtin"} which implies no name or value here.
-		// The name for builtin types might be the key in the BuiltinTypes map at the top level.
-		// No further unmarshalling is needed for this structure based on the log.
-		// The Name field would be populated if this Type struct was part of a map/slice
-		// where the name is the key/part of the surrounding structure.
-		// If this "builtin" type appears in a context where it needs a name (e.g., a parameter type),
-		// the name should come from the surrounding structure, not the Type object itself.
-		// We'll return "any" or a specific Lua primitive if the context implies it.
-		// Based on the log, it seems these "builtin" markers appear where a type is expected.
-		// Returning "any" is a safe fallback, or we could try to infer from context if possible.
-		// For now, returning "any" for the marker itself. The actual builtin types (like "boolean")
-		// are handled by the IsSimple() case.
-		return "any"
+		// The name for builtin types comes from the surrounding structure (the concept
+		// or builtin type entry that owns this marker), so there is nothing further
+		// to unmarshal. Translation of the marker itself is left to the generator.
 
-	default:
-		// If ComplexType is empty or unknown, it might be a simple type with just a Name.
-		// The initial unmarshalling into temp.Name handles the Name field.
-		// If Name is also empty and ComplexType is empty, it might be an error in the JSON
-		// or a type we haven't accounted for.
+	case "":
+		// An object with just a name is a reference to a named type.
 		if t.Name == "" {
-			// This case might indicate an issue with the JSON or an unhandled type structure.
-			// Log a warning or return an error if strict parsing is needed.
-			log.Printf("Warning: UnmarshalJSON (Complex): Encountered type with no Name and no ComplexType: %s", string(data))
-		} else {
-			log.Printf("UnmarshalJSON (Complex): Handling simple type or unknown complex type with Name='%s'", t.Name)
+			return fmt.Errorf("type has neither a name nor a complex_type: %s", string(data))
 		}
+
+	default:
+		// A complex type this parser doesn't know, e.g. from a newer API version:
+		// parsing it as anything would misrepresent it.
+		return fmt.Errorf("unknown complex_type %q", t.ComplexType)
 	}
 
-	log.Println("UnmarshalJSON: Finished Type unmarshalling")
 	return nil
 }
 
@@ -387,7 +581,7 @@ func (t Type) IsLiteral() bool {
 // structural type like "array" or "dictionary", it might be a named complex type.
 // Or, if it has a Name and ComplexType is "struct", it's likely a named struct concept.
 func (t Type) IsNamedComplex() bool {
-	return t.Name != "" && t.ComplexType != "" && t.ComplexType != "array" && t.ComplexType != "dictionary" && t.ComplexType != "union" && t.ComplexType != "literal" && t.ComplexType != "type" && t.ComplexType != "tuple" && t.ComplexType != "struct" && t.ComplexType != "builtin" // Added struct and builtin here
+	return t.Name != "" && t.ComplexType != "" && t.ComplexType != "array" && t.ComplexType != "dictionary" && t.ComplexType != "union" && t.ComplexType != "literal" && t.ComplexType != "type" && t.ComplexType != "tuple" && t.ComplexType != "struct" && t.ComplexType != "builtin" && t.ComplexType != "function" // Added struct, builtin and function here
 }
 
 // Helper to check if a type is a tuple
@@ -395,6 +589,11 @@ func (t Type) IsTuple() bool {
 	return t.ComplexType == "tuple" && len(t.Values) > 0
 }
 
+// Helper to check if a type is a function (callback) type
+func (t Type) IsFunction() bool {
+	return t.ComplexType == "function"
+}
+
 // Helper to check if a type is a builtin type marker
 func (t Type) IsBuiltinMarker() bool {
 	return t.ComplexType == "builtin" && t.Name == "" && t.Value == nil && t.Key == nil && len(t.Values) == 0 && t.LiteralValue == nil
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// typeSeeds are type snippets of the API documents, one per shape UnmarshalJSON
+// handles.
+var typeSeeds = []string{
+	`"LuaEntity"`,
+	`{"complex_type":"array","value":"LuaPlayer"}`,
+	`{"complex_type":"dictionary","key":"string","value":"ModSetting"}`,
+	`{"complex_type":"LuaCustomTable","key":"uint","value":"LuaPlayer"}`,
+	`{"complex_type":"union","options":["string",{"complex_type":"literal","value":"all"}],"full_format":false}`,
+	`{"complex_type":"union","options":[{"complex_type":"literal","value":0,"description":"Quick"},{"complex_type":"literal","value":true}],"full_format":true}`,
+	`{"complex_type":"type","value":"uint","description":"The tick."}`,
+	`{"complex_type":"tuple","values":["double","double"]}`,
+	`{"complex_type":"function","parameters":["CustomCommandData"]}`,
+	`{"complex_type":"table","parameters":[{"name":"x","order":0,"description":"","type":"double","optional":false}],"variant_parameter_groups":[{"name":"Other","order":0,"description":"","parameters":[{"name":"y","order":0,"description":"","type":"double","optional":true}]}],"variant_parameter_description":""}`,
+	`{"complex_type":"LuaStruct","attributes":[{"name":"r","order":0,"description":"","read_type":"float","optional":true}]}`,
+	`{"complex_type":"LuaLazyLoadedValue","value":{"complex_type":"dictionary","key":"uint","value":"LuaEntity"}}`,
+	`{"complex_type":"struct"}`,
+	`{"complex_type":"builtin"}`,
+}
+
+// FuzzTypeUnmarshalJSON feeds UnmarshalJSON arbitrary JSON, seeded with the
+// snippets above and every type of the recorded fixtures. Whatever the input, it
+// must either return an error or a Type that is complete for its complex_type:
+//
+//	go test ./pkg/api -run '^$' -fuzz FuzzTypeUnmarshalJSON
+func FuzzTypeUnmarshalJSON(f *testing.F) {
+	// UnmarshalJSON logs every type it parses.
+	log.SetOutput(io.Discard)
+	f.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	for _, seed := range typeSeeds {
+		f.Add([]byte(seed))
+	}
+	for _, seed := range fixtureTypes(f) {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var parsed Type
+		if err := json.Unmarshal(data, &parsed); err != nil {
+			return
+		}
+		if problem := incompleteType(parsed); problem != "" {
+			t.Errorf("%s parsed without an error, but %s", data, problem)
+		}
+	})
+}
+
+// TestTypeUnmarshalJSONErrors checks that malformed and unknown shapes are
+// rejected.
+func TestTypeUnmarshalJSONErrors(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	for _, data := range []string{
+		`null`,
+		`""`,
+		`42`,
+		`["string"]`,
+		`{}`,
+		`{"description":"No name"}`,
+		`{"complex_type":"array"}`,
+		`{"complex_type":"array","value":null}`,
+		`{"complex_type":"dictionary","value":"string"}`,
+		`{"complex_type":"union","options":[]}`,
+		`{"complex_type":"union","options":["string",null]}`,
+		`{"complex_type":"literal"}`,
+		`{"complex_type":"literal","value":{"a":1}}`,
+		`{"complex_type":"type","value":{}}`,
+		`{"complex_type":"tuple","values":[]}`,
+		`{"complex_type":"LuaLazyLoadedValue"}`,
+		`{"complex_type":"LuaNewThing","value":"string"}`,
+	} {
+		var parsed Type
+		if err := json.Unmarshal([]byte(data), &parsed); err == nil {
+			t.Errorf("%s: parsed as %+v, want an error", data, parsed)
+		}
+	}
+	for _, data := range typeSeeds {
+		var parsed Type
+		if err := json.Unmarshal([]byte(data), &parsed); err != nil {
+			t.Errorf("%s: %v", data, err)
+		}
+	}
+}
+
+// incompleteType describes what a parsed type lacks for its complex_type, or
+// returns "" if it is complete. Nested types are checked too.
+func incompleteType(t Type) string {
+	nested := []*Type{t.Key, t.Value}
+	for i := range t.Values {
+		nested = append(nested, &t.Values[i])
+	}
+	for i := range t.Parameters {
+		nested = append(nested, &t.Parameters[i])
+	}
+	for _, n := range nested {
+		if n == nil {
+			continue
+		}
+		if problem := incompleteType(*n); problem != "" {
+			return problem
+		}
+	}
+
+	switch t.ComplexType {
+	case "":
+		if t.Name == "" {
+			return "it has neither a name nor a complex_type"
+		}
+	case "array", "type", "LuaLazyLoadedValue":
+		if t.Value == nil {
+			return fmt.Sprintf("the %s has no value type", t.ComplexType)
+		}
+	case "dictionary", "LuaCustomTable":
+		if t.Key == nil || t.Value == nil {
+			return fmt.Sprintf("the %s has no key or value type", t.ComplexType)
+		}
+	case "union", "tuple":
+		if len(t.Values) == 0 {
+			return fmt.Sprintf("the %s is empty", t.ComplexType)
+		}
+	case "literal":
+		switch t.LiteralValue.(type) {
+		case string, float64, bool:
+		default:
+			return fmt.Sprintf("the literal's value is a %T", t.LiteralValue)
+		}
+	case "table", "LuaStruct", "function", "struct", "builtin":
+	default:
+		return fmt.Sprintf("its complex_type %q is unknown", t.ComplexType)
+	}
+	return ""
+}
+
+// fixtureTypes collects the types of the API documents recorded for the golden
+// tests of the generator: the values of every "type", "read_type", "write_type"
+// and "return_type" key.
+func fixtureTypes(tb testing.TB) [][]byte {
+	paths, err := filepath.Glob(filepath.Join("..", "generator", "testdata", "fixtures", "*", "*-api.json"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	var types [][]byte
+	seen := map[string]bool{}
+	var walk func(value any)
+	walk = func(value any) {
+		switch value := value.(type) {
+		case map[string]any:
+			for key, v := range value {
+				switch key {
+				case "type", "read_type", "write_type", "return_type":
+					data, err := json.Marshal(v)
+					if err != nil {
+						tb.Fatal(err)
+					}
+					if !seen[string(data)] {
+						seen[string(data)] = true
+						types = append(types, data)
+					}
+				}
+				walk(v)
+			}
+		case []any:
+			for _, v := range value {
+				walk(v)
+			}
+		}
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		var document any
+		if err := json.Unmarshal(data, &document); err != nil {
+			tb.Fatal(err)
+		}
+		walk(document)
+	}
+	return types
+}
@@ -0,0 +1,93 @@
+package apidiff
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// changelogSections are the sections of a changelog, per stage, in order: the
+// categories of changes and their titles.
+var changelogSections = []struct {
+	stage, title string
+	categories   []struct{ category, title string }
+}{
+	{"runtime", "Runtime stage", []struct{ category, title string }{
+		{"class", "Classes"}, {"event", "Events"}, {"concept", "Concepts"}, {"global", "Global objects"}, {"define", "Defines"},
+	}},
+	{"prototype", "Prototype stage", []struct{ category, title string }{
+		{"prototype", "Prototypes"}, {"type", "Types"}, {"define", "Defines"},
+	}},
+}
+
+// Markdown renders the report as a changelog for mod migration notes: a section
+// per stage and category (classes, events, ...), with the changes of each class,
+// concept, ... under its name. Breaking changes and deprecations are marked and
+// listed before the additions.
+func (r *Report) Markdown() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Factorio API changes from %s to %s\n\n", r.From, r.To))
+	if len(r.Changes) == 0 {
+		sb.WriteString("No changes to the documented API.\n")
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("Breaking changes: %d, warnings: %d, compatible changes: %d.\n",
+		r.Summary[string(SeverityBreaking)], r.Summary[string(SeverityWarning)], r.Summary[string(SeverityInfo)]))
+
+	for _, section := range changelogSections {
+		stageWritten := false
+		for _, category := range section.categories {
+			var changes []Change
+			for _, change := range r.Changes {
+				if change.Stage == section.stage && change.Category == category.category {
+					changes = append(changes, change)
+				}
+			}
+			if len(changes) == 0 {
+				continue
+			}
+			if !stageWritten {
+				sb.WriteString(fmt.Sprintf("\n## %s\n", section.title))
+				stageWritten = true
+			}
+			sb.WriteString(fmt.Sprintf("\n### %s\n", category.title))
+			writeChangelogGroups(&sb, changes)
+		}
+	}
+	return sb.String()
+}
+
+// writeChangelogGroups writes the changes of a category grouped by owner, in
+// alphabetical order.
+func writeChangelogGroups(sb *strings.Builder, changes []Change) {
+	// A stable sort keeps the order the changes were found in within a severity.
+	slices.SortStableFunc(changes, func(a, b Change) int {
+		return cmp.Or(strings.Compare(a.Owner, b.Owner), slices.Index(Severities, a.Severity)-slices.Index(Severities, b.Severity))
+	})
+	for i, change := range changes {
+		if i == 0 || changes[i-1].Owner != change.Owner {
+			sb.WriteString(fmt.Sprintf("\n#### `%s`\n\n", change.Owner))
+		}
+		if change.Kind == KindDeprecated {
+			if change.Member == "" {
+				sb.WriteString("- **Deprecated**\n")
+			} else {
+				sb.WriteString(fmt.Sprintf("- **Deprecated:** `%s`\n", change.Member))
+			}
+			continue
+		}
+		sb.WriteString("- ")
+		if change.Severity == SeverityBreaking {
+			sb.WriteString("**Breaking:** ")
+		}
+		if change.Member != "" {
+			sb.WriteString(fmt.Sprintf("`%s`: ", change.Member))
+		}
+		message := change.Message
+		if change.From != "" {
+			message = strings.Replace(message, fmt.Sprintf("from %s to %s", change.From, change.To), fmt.Sprintf("from `%s` to `%s`", change.From, change.To), 1)
+		}
+		sb.WriteString(message + "\n")
+	}
+}
@@ -0,0 +1,435 @@
+// Package apidiff compares two versions of the Factorio API documents and
+// classifies the differences by how they affect mods written against the older
+// one: a removed method breaks the mods calling it, a new optional parameter
+// doesn't. The comparison is of the documented surface only (names, types,
+// optionality and deprecation), not of descriptions or behavior.
+package apidiff
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/generator"
+)
+
+// Kind is the kind of a change.
+type Kind string
+
+const (
+	KindRemoved              Kind = "removed"
+	KindAdded                Kind = "added"
+	KindTypeChanged          Kind = "type-changed"
+	KindNewRequiredParameter Kind = "new-required-parameter" // Or an optional one became required
+	KindDeprecated           Kind = "deprecated"
+	KindReadOnly             Kind = "read-only" // An attribute can't be written anymore
+)
+
+// Severity is how a change affects the mods written against the older version.
+type Severity string
+
+const (
+	// SeverityBreaking changes make valid code invalid: removals, changed
+	// types, new required parameters.
+	SeverityBreaking Severity = "breaking"
+	// SeverityWarning changes keep code working for now, e.g. deprecations.
+	SeverityWarning Severity = "warning"
+	// SeverityInfo changes are compatible additions.
+	SeverityInfo Severity = "info"
+)
+
+// Severities lists the severities from the most to the least severe.
+var Severities = []Severity{SeverityBreaking, SeverityWarning, SeverityInfo}
+
+// AtLeast reports whether s is as severe as other.
+func (s Severity) AtLeast(other Severity) bool {
+	return slices.Index(Severities, s) <= slices.Index(Severities, other)
+}
+
+// Change is one difference between the two versions.
+type Change struct {
+	Stage    string   `json:"stage"`    // "runtime" or "prototype"
+	Category string   `json:"category"` // "class", "concept", "event", "define", "global", "prototype" or "type"
+	Owner    string   `json:"owner"`    // The class, concept, ... changed, e.g. "LuaEntity"
+	Member   string   `json:"member,omitempty"`
+	Kind     Kind     `json:"kind"`
+	Severity Severity `json:"severity"`
+	From     string   `json:"from,omitempty"` // The old type, for type changes
+	To       string   `json:"to,omitempty"`   // The new type
+	Message  string   `json:"message"`
+}
+
+// Path returns the changed definition, e.g. "LuaEntity.get_inventory".
+func (c Change) Path() string {
+	if c.Member == "" {
+		return c.Owner
+	}
+	return c.Owner + "." + c.Member
+}
+
+// Report is the result of comparing two versions.
+type Report struct {
+	From    string         `json:"from"` // The versions compared, e.g. "2.0.40"
+	To      string         `json:"to"`
+	Summary map[string]int `json:"summary"` // Changes per severity
+	Changes []Change       `json:"changes"`
+}
+
+// Max returns the highest severity of the report's changes, and false if there
+// are none.
+func (r *Report) Max() (Severity, bool) {
+	for _, severity := range Severities {
+		if r.Summary[string(severity)] > 0 {
+			return severity, true
+		}
+	}
+	return "", false
+}
+
+// differ accumulates the changes of one stage.
+type differ struct {
+	stage   string
+	types   *generator.Generator // Spells types as the definitions do
+	changes []Change
+}
+
+// Compare compares the old and new versions of the runtime and prototype APIs.
+// Either stage may be nil in both, in which case it isn't compared.
+func Compare(oldRuntime, newRuntime, oldPrototype, newPrototype *api.API) *Report {
+	report := &Report{Summary: make(map[string]int), Changes: []Change{}}
+	types := generator.NewGenerator(generator.DefaultOptions())
+	if oldRuntime != nil && newRuntime != nil {
+		d := &differ{stage: "runtime", types: types}
+		d.runtime(oldRuntime, newRuntime)
+		report.Changes = append(report.Changes, d.changes...)
+		report.From, report.To = oldRuntime.ApplicationVersion, newRuntime.ApplicationVersion
+	}
+	if oldPrototype != nil && newPrototype != nil {
+		d := &differ{stage: "prototype", types: types}
+		// Both APIs document the defines; they are compared once.
+		d.prototype(oldPrototype, newPrototype, oldRuntime == nil || newRuntime == nil)
+		report.Changes = append(report.Changes, d.changes...)
+		report.From, report.To = oldPrototype.ApplicationVersion, newPrototype.ApplicationVersion
+	}
+	for _, severity := range Severities {
+		report.Summary[string(severity)] = 0
+	}
+	for _, change := range report.Changes {
+		report.Summary[string(change.Severity)]++
+	}
+	return report
+}
+
+func (d *differ) add(category, owner, member string, kind Kind, severity Severity, message string) *Change {
+	d.changes = append(d.changes, Change{Stage: d.stage, Category: category, Owner: owner, Member: member, Kind: kind, Severity: severity, Message: message})
+	return &d.changes[len(d.changes)-1]
+}
+
+// typeChanged records a type change. Inputs (parameters, writable attributes,
+// prototype properties) only widened by new union options accept what they did
+// before, so that is compatible; anything else breaks.
+func (d *differ) typeChanged(category, owner, member, what string, from, to string, input bool) {
+	severity := SeverityBreaking
+	if input && widened(from, to) {
+		severity = SeverityInfo
+	}
+	change := d.add(category, owner, member, KindTypeChanged, severity, fmt.Sprintf("%s changed from %s to %s", what, from, to))
+	change.From, change.To = from, to
+}
+
+// widened reports whether the union type to has every option of from.
+func widened(from, to string) bool {
+	options := strings.Split(to, " | ")
+	for _, option := range strings.Split(from, " | ") {
+		if !slices.Contains(options, option) {
+			return false
+		}
+	}
+	return true
+}
+
+// deprecation records a definition deprecated by the new version.
+func (d *differ) deprecation(category, owner, member string, was, is bool) {
+	if is && !was {
+		d.add(category, owner, member, KindDeprecated, SeverityWarning, "deprecated")
+	}
+}
+
+// byName indexes a list of definitions by name.
+func byName[T any](items []T, name func(T) string) map[string]T {
+	m := make(map[string]T, len(items))
+	for _, item := range items {
+		m[name(item)] = item
+	}
+	return m
+}
+
+// compareNamed calls removed, added and both for the definitions of the old and
+// new lists, in the order of the old list followed by the additions.
+func compareNamed[T any](old, new []T, name func(T) string, removed func(T), added func(T), both func(T, T)) {
+	newByName := byName(new, name)
+	oldByName := byName(old, name)
+	for _, o := range old {
+		if n, ok := newByName[name(o)]; ok {
+			both(o, n)
+		} else {
+			removed(o)
+		}
+	}
+	for _, n := range new {
+		if _, ok := oldByName[name(n)]; !ok {
+			added(n)
+		}
+	}
+}
+
+// field is a named, typed member compared the same way wherever it occurs:
+// parameters, event data, table concept fields and prototype properties.
+type field struct {
+	name       string
+	luaType    string
+	optional   bool
+	deprecated bool
+}
+
+// compareFields compares the fields of a definition. A new field that isn't
+// optional breaks code passing the definition (inputs); it doesn't for outputs,
+// such as event data, which only gain a value to read.
+func (d *differ) compareFields(category, owner, prefix, what string, old, new []field, input bool) {
+	compareNamed(old, new, func(f field) string { return f.name },
+		func(f field) {
+			d.add(category, owner, prefix+f.name, KindRemoved, SeverityBreaking, what+" removed")
+		},
+		func(f field) {
+			if input && !f.optional {
+				d.add(category, owner, prefix+f.name, KindNewRequiredParameter, SeverityBreaking, "new required "+what)
+			} else {
+				d.add(category, owner, prefix+f.name, KindAdded, SeverityInfo, what+" added")
+			}
+		},
+		func(o, n field) {
+			if o.luaType != n.luaType {
+				d.typeChanged(category, owner, prefix+n.name, what, o.luaType, n.luaType, input)
+			}
+			if input && o.optional && !n.optional {
+				d.add(category, owner, prefix+n.name, KindNewRequiredParameter, SeverityBreaking, what+" is now required")
+			}
+			if !input && !o.optional && n.optional {
+				d.add(category, owner, prefix+n.name, KindTypeChanged, SeverityBreaking, what+" may now be absent")
+			}
+			d.deprecation(category, owner, prefix+n.name, o.deprecated, n.deprecated)
+		})
+}
+
+func (d *differ) parameterFields(params []api.Parameter) []field {
+	var fields []field
+	for _, param := range params {
+		fields = append(fields, field{name: param.Name, luaType: d.types.LuaLSType(param.Type), optional: param.Optional || param.Nullable})
+	}
+	return fields
+}
+
+func (d *differ) propertyFields(properties []api.Property) []field {
+	var fields []field
+	for _, property := range properties {
+		fields = append(fields, field{name: property.Name, luaType: d.types.LuaLSType(property.ValueType()), optional: property.Optional, deprecated: property.Deprecated})
+	}
+	return fields
+}
+
+// runtime compares the runtime APIs.
+func (d *differ) runtime(old, new *api.API) {
+	compareNamed(old.Classes, new.Classes, func(c api.Class) string { return c.Name },
+		func(c api.Class) { d.add("class", c.Name, "", KindRemoved, SeverityBreaking, "class removed") },
+		func(c api.Class) { d.add("class", c.Name, "", KindAdded, SeverityInfo, "class added") },
+		d.class)
+	compareNamed(old.Events, new.Events, func(e api.Event) string { return e.Name },
+		func(e api.Event) { d.add("event", e.Name, "", KindRemoved, SeverityBreaking, "event removed") },
+		func(e api.Event) { d.add("event", e.Name, "", KindAdded, SeverityInfo, "event added") },
+		func(o, n api.Event) {
+			d.deprecation("event", n.Name, "", o.Deprecated, n.Deprecated)
+			d.compareFields("event", n.Name, "", "event data field", d.parameterFields(o.Data), d.parameterFields(n.Data), false)
+		})
+	compareNamed(old.GlobalObjects, new.GlobalObjects, func(g api.GlobalObject) string { return g.Name },
+		func(g api.GlobalObject) {
+			d.add("global", g.Name, "", KindRemoved, SeverityBreaking, "global object removed")
+		},
+		func(g api.GlobalObject) { d.add("global", g.Name, "", KindAdded, SeverityInfo, "global object added") },
+		func(o, n api.GlobalObject) {
+			if from, to := d.types.LuaLSType(o.Type), d.types.LuaLSType(n.Type); from != to {
+				d.typeChanged("global", n.Name, "", "type", from, to, false)
+			}
+		})
+	d.concepts("concept", old.Concepts, new.Concepts)
+	d.defines(old.Defines, new.Defines)
+}
+
+// class compares the methods and attributes of a class.
+func (d *differ) class(old, new api.Class) {
+	d.deprecation("class", new.Name, "", old.Deprecated, new.Deprecated)
+	compareNamed(old.Methods, new.Methods, func(m api.Method) string { return m.Name },
+		func(m api.Method) { d.add("class", new.Name, m.Name, KindRemoved, SeverityBreaking, "method removed") },
+		func(m api.Method) { d.add("class", new.Name, m.Name, KindAdded, SeverityInfo, "method added") },
+		func(o, n api.Method) { d.method(new.Name, o, n) })
+
+	attributes := func(c api.Class) []api.Property { return append(slices.Clone(c.Attributes), c.Properties...) }
+	compareNamed(attributes(old), attributes(new), func(p api.Property) string { return p.Name },
+		func(p api.Property) {
+			d.add("class", new.Name, p.Name, KindRemoved, SeverityBreaking, "attribute removed")
+		},
+		func(p api.Property) { d.add("class", new.Name, p.Name, KindAdded, SeverityInfo, "attribute added") },
+		func(o, n api.Property) {
+			if from, to := d.types.LuaLSType(o.ValueType()), d.types.LuaLSType(n.ValueType()); from != to {
+				d.typeChanged("class", new.Name, n.Name, "attribute type", from, to, false)
+			}
+			if o.IsWritable() && !n.IsWritable() {
+				d.add("class", new.Name, n.Name, KindReadOnly, SeverityBreaking, "attribute is now read-only")
+			}
+			d.deprecation("class", new.Name, n.Name, o.Deprecated, n.Deprecated)
+		})
+}
+
+// method compares the signature of a method.
+func (d *differ) method(owner string, old, new api.Method) {
+	d.deprecation("class", owner, new.Name, old.Deprecated, new.Deprecated)
+	d.compareFields("class", owner, new.Name+".", "parameter", d.parameterFields(old.Parameters), d.parameterFields(new.Parameters), true)
+	if old.VariadicParameter != nil && new.VariadicParameter == nil {
+		d.add("class", owner, new.Name, KindRemoved, SeverityBreaking, "variadic parameter removed")
+	}
+	if from, to := d.returns(old), d.returns(new); from != to {
+		d.typeChanged("class", owner, new.Name, "return type", from, to, false)
+	}
+}
+
+// returns spells the return values of a method, e.g. "LuaEntity?, string".
+func (d *differ) returns(method api.Method) string {
+	var returns []string
+	for _, ret := range method.ReturnValues {
+		luaType := d.types.LuaLSType(ret.Type)
+		if ret.Optional || ret.Nullable {
+			luaType += "?"
+		}
+		returns = append(returns, luaType)
+	}
+	if len(returns) == 0 {
+		return "nothing"
+	}
+	return strings.Join(returns, ", ")
+}
+
+// concepts compares concepts, or the types of the prototype API. Table concepts
+// and the prototype types with properties are compared field by field, since they
+// are typically passed to the API (inputs); others by their whole type.
+func (d *differ) concepts(category string, old, new []api.Concept) {
+	compareNamed(old, new, func(c api.Concept) string { return c.Name },
+		func(c api.Concept) { d.add(category, c.Name, "", KindRemoved, SeverityBreaking, category+" removed") },
+		func(c api.Concept) { d.add(category, c.Name, "", KindAdded, SeverityInfo, category+" added") },
+		func(o, n api.Concept) {
+			d.deprecation(category, n.Name, "", o.Deprecated, n.Deprecated)
+			oldFields, oldOK := d.conceptFields(o)
+			newFields, newOK := d.conceptFields(n)
+			if oldOK && newOK {
+				d.compareFields(category, n.Name, "", "field", oldFields, newFields, true)
+				return
+			}
+			if from, to := d.types.LuaLSType(o.Type), d.types.LuaLSType(n.Type); from != to {
+				d.typeChanged(category, n.Name, "", "type", from, to, true)
+			}
+		})
+}
+
+// conceptFields returns the fields of a table concept, a LuaStruct or a prototype
+// type with properties.
+func (d *differ) conceptFields(concept api.Concept) ([]field, bool) {
+	switch {
+	case len(concept.Properties) > 0:
+		return d.propertyFields(concept.Properties), true
+	case concept.Type.ComplexType == "table":
+		return d.parameterFields(concept.Type.Fields), true
+	case concept.Type.ComplexType == "LuaStruct":
+		return d.propertyFields(concept.Type.Attributes), true
+	}
+	return nil, false
+}
+
+// defines compares the values of the defines, flattened to their full names
+// (defines.events.on_tick).
+func (d *differ) defines(old, new []api.Define) {
+	flatten := func(defines []api.Define) []string {
+		var names []string
+		var walk func(prefix string, defines []api.Define)
+		walk = func(prefix string, defines []api.Define) {
+			for _, define := range defines {
+				name := prefix + "." + define.Name
+				names = append(names, name)
+				for _, value := range define.Values {
+					names = append(names, name+"."+value.Name)
+				}
+				walk(name, define.Subkeys)
+			}
+		}
+		walk("defines", defines)
+		return names
+	}
+	identity := func(name string) string { return name }
+	compareNamed(flatten(old), flatten(new), identity,
+		func(name string) { d.define(name, KindRemoved, SeverityBreaking, "define removed") },
+		func(name string) { d.define(name, KindAdded, SeverityInfo, "define added") },
+		func(string, string) {})
+}
+
+// define records a change of a define, owned by its enclosing define, e.g.
+// defines.events for defines.events.on_tick.
+func (d *differ) define(name string, kind Kind, severity Severity, message string) {
+	i := strings.LastIndex(name, ".")
+	d.add("define", name[:i], name[i+1:], kind, severity, message)
+}
+
+// prototype compares the prototype APIs.
+func (d *differ) prototype(old, new *api.API, defines bool) {
+	compareNamed(old.Prototypes, new.Prototypes, func(p api.Prototype) string { return p.Name },
+		func(p api.Prototype) {
+			d.add("prototype", p.Name, "", KindRemoved, SeverityBreaking, "prototype removed")
+		},
+		func(p api.Prototype) { d.add("prototype", p.Name, "", KindAdded, SeverityInfo, "prototype added") },
+		func(o, n api.Prototype) {
+			d.deprecation("prototype", n.Name, "", o.Deprecated, n.Deprecated)
+			if o.TypeName != n.TypeName {
+				change := d.add("prototype", n.Name, "", KindTypeChanged, SeverityBreaking, fmt.Sprintf("typename changed from %q to %q", o.TypeName, n.TypeName))
+				change.From, change.To = o.TypeName, n.TypeName
+			}
+			// Mods define prototypes, so their properties are inputs.
+			d.compareFields("prototype", n.Name, "", "property", d.propertyFields(o.Properties), d.propertyFields(n.Properties), true)
+		})
+	d.concepts("type", old.Types, new.Types)
+	if defines {
+		d.defines(old.Defines, new.Defines)
+	}
+}
+
+// Text renders the report for people: the changes by severity, most severe
+// first, one per line.
+func (r *Report) Text() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Changes from %s to %s (breaking: %d, warnings: %d, compatible: %d)\n", r.From, r.To,
+		r.Summary[string(SeverityBreaking)], r.Summary[string(SeverityWarning)], r.Summary[string(SeverityInfo)]))
+	for _, severity := range Severities {
+		if r.Summary[string(severity)] == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\n%s:\n", severityTitles[severity]))
+		for _, change := range r.Changes {
+			if change.Severity == severity {
+				sb.WriteString(fmt.Sprintf("  %-9s %s: %s\n", change.Stage, change.Path(), change.Message))
+			}
+		}
+	}
+	return sb.String()
+}
+
+var severityTitles = map[Severity]string{
+	SeverityBreaking: "Breaking changes",
+	SeverityWarning:  "Warnings",
+	SeverityInfo:     "Compatible changes",
+}
@@ -0,0 +1,170 @@
+package apidiff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+func named(name string) api.Type { return api.Type{Name: name} }
+
+func union(names ...string) api.Type {
+	t := api.Type{ComplexType: "union"}
+	for _, name := range names {
+		t.Values = append(t.Values, named(name))
+	}
+	return t
+}
+
+func member(name string) api.BasicMember { return api.BasicMember{Name: name} }
+
+func runtimeAPI(version string, classes []api.Class, events []api.Event, concepts []api.Concept, defines []api.Define) *api.API {
+	return &api.API{ApplicationVersion: version, Classes: classes, Events: events, Concepts: concepts, Defines: defines}
+}
+
+func TestCompareRuntime(t *testing.T) {
+	str, num := named("string"), named("uint32")
+	oldRuntime := runtimeAPI("2.0.40",
+		[]api.Class{
+			{BasicMember: member("LuaEntity"),
+				Methods: []api.Method{
+					{BasicMember: member("destroy")},
+					{BasicMember: member("get_inventory"), Parameters: []api.Parameter{{Name: "index", Type: num}}, ReturnValues: []api.ReturnType{{Type: named("LuaInventory"), Optional: true}}},
+					{BasicMember: member("teleport"), Parameters: []api.Parameter{{Name: "position", Type: named("MapPosition")}, {Name: "surface", Type: str, Optional: true}}},
+				},
+				Attributes: []api.Property{
+					{BasicMember: member("name"), ReadType: &str},
+					{BasicMember: member("health"), ReadType: &num, WriteType: &num},
+					{BasicMember: member("active"), ReadType: &str, WriteType: &str},
+				}},
+			{BasicMember: member("LuaRemoved")},
+		},
+		[]api.Event{{BasicMember: member("on_tick"), Data: []api.Parameter{{Name: "tick", Type: num}}}},
+		[]api.Concept{{BasicMember: member("Target"), Type: union("LuaEntity", "string")}},
+		[]api.Define{{BasicMember: member("events"), Values: []api.DefineValue{{BasicMember: member("on_tick")}, {BasicMember: member("on_removed")}}}},
+	)
+	newRuntime := runtimeAPI("2.0.41",
+		[]api.Class{
+			{BasicMember: member("LuaEntity"),
+				Methods: []api.Method{
+					{BasicMember: api.BasicMember{Name: "destroy", Deprecated: true}},
+					{BasicMember: member("get_inventory"), Parameters: []api.Parameter{{Name: "index", Type: num}, {Name: "player", Type: str}}, ReturnValues: []api.ReturnType{{Type: named("LuaInventory")}}},
+					{BasicMember: member("teleport"), Parameters: []api.Parameter{{Name: "position", Type: union("MapPosition", "LuaEntity")}, {Name: "surface", Type: str}, {Name: "raise", Type: named("boolean"), Optional: true}}},
+					{BasicMember: member("clone")},
+				},
+				Attributes: []api.Property{
+					{BasicMember: member("name"), ReadType: &num},
+					{BasicMember: member("health"), ReadType: &num},
+				}},
+			{BasicMember: member("LuaAdded")},
+		},
+		[]api.Event{{BasicMember: member("on_tick"), Data: []api.Parameter{{Name: "tick", Type: num}, {Name: "name", Type: str}}}},
+		[]api.Concept{{BasicMember: member("Target"), Type: union("LuaEntity", "string", "uint32")}},
+		[]api.Define{{BasicMember: member("events"), Values: []api.DefineValue{{BasicMember: member("on_tick")}, {BasicMember: member("on_added")}}}},
+	)
+
+	report := Compare(oldRuntime, newRuntime, nil, nil)
+	tests := []struct {
+		path     string
+		kind     Kind
+		severity Severity
+	}{
+		{"LuaEntity.destroy", KindDeprecated, SeverityWarning},
+		{"LuaEntity.get_inventory.player", KindNewRequiredParameter, SeverityBreaking},
+		{"LuaEntity.get_inventory", KindTypeChanged, SeverityBreaking}, // The return value is no longer optional
+		{"LuaEntity.teleport.position", KindTypeChanged, SeverityInfo}, // A widened input
+		{"LuaEntity.teleport.surface", KindNewRequiredParameter, SeverityBreaking},
+		{"LuaEntity.teleport.raise", KindAdded, SeverityInfo},
+		{"LuaEntity.clone", KindAdded, SeverityInfo},
+		{"LuaEntity.name", KindTypeChanged, SeverityBreaking}, // A changed output
+		{"LuaEntity.health", KindReadOnly, SeverityBreaking},
+		{"LuaEntity.active", KindRemoved, SeverityBreaking},
+		{"LuaRemoved", KindRemoved, SeverityBreaking},
+		{"LuaAdded", KindAdded, SeverityInfo},
+		{"on_tick.name", KindAdded, SeverityInfo}, // Event data is an output
+		{"Target", KindTypeChanged, SeverityInfo},
+		{"defines.events.on_removed", KindRemoved, SeverityBreaking},
+		{"defines.events.on_added", KindAdded, SeverityInfo},
+	}
+	for _, test := range tests {
+		found := false
+		for _, change := range report.Changes {
+			if change.Path() == test.path && change.Kind == test.kind {
+				found = true
+				if change.Severity != test.severity {
+					t.Errorf("%s %s is %s, want %s", test.path, test.kind, change.Severity, test.severity)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("no %s change of %s", test.kind, test.path)
+		}
+	}
+	if len(report.Changes) != len(tests) {
+		for _, change := range report.Changes {
+			t.Logf("%s: %s %s", change.Path(), change.Kind, change.Severity)
+		}
+		t.Errorf("got %d changes, want %d", len(report.Changes), len(tests))
+	}
+
+	if report.From != "2.0.40" || report.To != "2.0.41" {
+		t.Errorf("report compares %s to %s", report.From, report.To)
+	}
+	if report.Summary["breaking"] != 8 || report.Summary["warning"] != 1 || report.Summary["info"] != 7 {
+		t.Errorf("summary = %v", report.Summary)
+	}
+	if severity, ok := report.Max(); !ok || severity != SeverityBreaking {
+		t.Errorf("Max() = %s, %v", severity, ok)
+	}
+}
+
+func TestComparePrototype(t *testing.T) {
+	oldPrototype := &api.API{ApplicationVersion: "2.0.40",
+		Prototypes: []api.Prototype{{BasicMember: member("ItemPrototype"), TypeName: "item", Properties: []api.Property{{BasicMember: member("stack_size"), Type: named("uint32")}}}},
+	}
+	newPrototype := &api.API{ApplicationVersion: "2.0.41",
+		Prototypes: []api.Prototype{{BasicMember: member("ItemPrototype"), TypeName: "item", Properties: []api.Property{
+			{BasicMember: member("stack_size"), Type: named("uint32")},
+			{BasicMember: member("weight"), Type: named("double"), Optional: true},
+			{BasicMember: member("icon"), Type: named("FileName")},
+		}}},
+	}
+	report := Compare(nil, nil, oldPrototype, newPrototype)
+	want := []string{"ItemPrototype.weight added info", "ItemPrototype.icon new-required-parameter breaking"}
+	var got []string
+	for _, change := range report.Changes {
+		got = append(got, strings.Join([]string{change.Path(), string(change.Kind), string(change.Severity)}, " "))
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("changes = %q, want %q", got, want)
+	}
+
+	if _, ok := Compare(nil, nil, oldPrototype, oldPrototype).Max(); ok {
+		t.Error("comparing a version to itself found changes")
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	report := &Report{From: "2.0.40", To: "2.0.41", Summary: map[string]int{"breaking": 2, "warning": 1, "info": 1}, Changes: []Change{
+		{Stage: "runtime", Category: "class", Owner: "LuaEntity", Member: "clone", Kind: KindAdded, Severity: SeverityInfo, Message: "method added"},
+		{Stage: "runtime", Category: "class", Owner: "LuaEntity", Member: "name", Kind: KindTypeChanged, Severity: SeverityBreaking, From: "string", To: "uint32", Message: "attribute type changed from string to uint32"},
+		{Stage: "runtime", Category: "class", Owner: "LuaAlpha", Kind: KindDeprecated, Severity: SeverityWarning, Message: "deprecated"},
+		{Stage: "prototype", Category: "prototype", Owner: "ItemPrototype", Kind: KindRemoved, Severity: SeverityBreaking, Message: "prototype removed"},
+	}}
+	want := "# Factorio API changes from 2.0.40 to 2.0.41\n\n" +
+		"Breaking changes: 2, warnings: 1, compatible changes: 1.\n" +
+		"\n## Runtime stage\n\n### Classes\n" +
+		"\n#### `LuaAlpha`\n\n- **Deprecated**\n" +
+		"\n#### `LuaEntity`\n\n- **Breaking:** `name`: attribute type changed from `string` to `uint32`\n- `clone`: method added\n" +
+		"\n## Prototype stage\n\n### Prototypes\n" +
+		"\n#### `ItemPrototype`\n\n- **Breaking:** prototype removed\n"
+	if got := report.Markdown(); got != want {
+		t.Errorf("Markdown() =\n%s\nwant\n%s", got, want)
+	}
+
+	empty := &Report{From: "2.0.40", To: "2.0.40", Summary: map[string]int{}}
+	if got := empty.Markdown(); !strings.HasSuffix(got, "No changes to the documented API.\n") {
+		t.Errorf("Markdown() of no changes = %q", got)
+	}
+}
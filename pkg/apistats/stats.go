@@ -0,0 +1,281 @@
+// Package apistats summarizes a Factorio API document without generating
+// anything from it: how many classes, methods, prototypes... it documents, how
+// complex its types are, what is deprecated, and the complex_types the api
+// package doesn't parse. It reads the document as plain JSON, so it describes
+// the documents of new game releases that the generator can't parse yet, which
+// is when the drift of the schema matters.
+package apistats
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+// memberKinds are the arrays of documented members counted, by their key in the
+// document, with how they are named in the text summary.
+var memberKinds = []struct{ key, label string }{
+	{"classes", "classes"},
+	{"methods", "methods"},
+	{"attributes", "attributes"},
+	{"operators", "operators"},
+	{"parameters", "parameters"},
+	{"return_values", "return values"},
+	{"events", "events"},
+	{"data", "event data fields"},
+	{"defines", "defines"},
+	{"subkeys", "nested defines"},
+	{"values", "define values"},
+	{"global_objects", "global objects"},
+	{"global_functions", "global functions"},
+	{"concepts", "concepts"},
+	{"prototypes", "prototypes"},
+	{"types", "types"},
+	{"properties", "properties"},
+}
+
+// MaxDepth is the depth from which types are counted together in
+// Stats.TypeDepths.
+const MaxDepth = 4
+
+// Stats summarizes an API document.
+type Stats struct {
+	Stage       string `json:"stage"`        // "runtime" or "prototype"
+	GameVersion string `json:"game_version"` // e.g. "2.0.45"
+	APIVersion  int    `json:"api_version"`  // The version of the JSON format
+
+	// The documented members by key in the document (see memberKinds), and how
+	// many of them are deprecated.
+	Members    map[string]int `json:"members"`
+	Deprecated map[string]int `json:"deprecated"`
+
+	// Types counts the types of the document (those of members, concepts,
+	// properties...) by complexity: simple named types have depth 0, and a complex
+	// type one more than its deepest component, so Array[Dict[string, X]] has 2.
+	// Types as deep as MaxDepth or deeper are counted under MaxDepth.
+	Types      int         `json:"types"`
+	TypeDepths map[int]int `json:"type_depths"`
+	Deepest    string      `json:"deepest"` // Where the deepest type is
+	// The complex_types found, nested ones included, with their occurrences.
+	ComplexTypes map[string]int `json:"complex_types"`
+	// The complex_types api.Type doesn't parse, with where they are.
+	Unknown []UnknownType `json:"unknown"`
+	// Why the api package fails to parse the document, if it does.
+	ParseError string `json:"parse_error,omitempty"`
+}
+
+// UnknownType is an occurrence of a complex_type the api package doesn't parse.
+type UnknownType struct {
+	ComplexType string `json:"complex_type"`
+	Path        string `json:"path"` // e.g. "LuaEntity.get_inventory"
+}
+
+// Analyze summarizes an API document. It only fails if the document isn't a
+// JSON object.
+func Analyze(document []byte) (*Stats, error) {
+	var root map[string]any
+	if err := json.Unmarshal(document, &root); err != nil {
+		return nil, err
+	}
+	s := &Stats{
+		Members:      make(map[string]int),
+		Deprecated:   make(map[string]int),
+		TypeDepths:   make(map[int]int),
+		ComplexTypes: make(map[string]int),
+		Unknown:      []UnknownType{},
+	}
+	s.Stage, _ = root["stage"].(string)
+	s.GameVersion, _ = root["application_version"].(string)
+	if version, ok := root["api_version"].(float64); ok {
+		s.APIVersion = int(version)
+	}
+	deepest := -1
+	s.walk(root, "", "", &deepest)
+
+	var parsed api.API
+	if err := json.Unmarshal(document, &parsed); err != nil {
+		s.ParseError = err.Error()
+	}
+	return s, nil
+}
+
+// walk visits the members of the document, counting the members of arrays under
+// key and the types it finds. path names the enclosing members.
+func (s *Stats) walk(value any, key string, path string, deepest *int) {
+	switch value := value.(type) {
+	case []any:
+		for _, element := range value {
+			if member, ok := element.(map[string]any); ok && isMemberKind(key) {
+				s.Members[key]++
+				if deprecated, _ := member["deprecated"].(bool); deprecated {
+					s.Deprecated[key]++
+				}
+			}
+			s.walk(element, key, path, deepest)
+		}
+	case map[string]any:
+		if name, ok := value["name"].(string); ok {
+			path = joinPath(path, name)
+		}
+		for _, field := range sortedKeys(value) {
+			switch field {
+			case "type", "read_type", "write_type":
+				s.Types++
+				depth := s.typeDepth(value[field], path)
+				s.TypeDepths[min(depth, MaxDepth)]++
+				if depth > *deepest {
+					*deepest = depth
+					s.Deepest = path
+				}
+			default:
+				s.walk(value[field], field, path, deepest)
+			}
+		}
+	}
+}
+
+// typeDepth returns the depth of a type, counting its complex_types on the way.
+func (s *Stats) typeDepth(value any, path string) int {
+	t, ok := value.(map[string]any)
+	if !ok {
+		return 0 // A named type
+	}
+	complexType, _ := t["complex_type"].(string)
+	if complexType == "" {
+		return 0
+	}
+	s.ComplexTypes[complexType]++
+	if !slices.Contains(api.ComplexTypes, complexType) {
+		s.Unknown = append(s.Unknown, UnknownType{ComplexType: complexType, Path: path})
+	}
+
+	// The components are under the keys of the known complex_types, which an
+	// unknown one likely reuses: types under value, key, options, values and
+	// (for functions) parameters, and typed fields under parameters (for tables),
+	// variant_parameter_groups and attributes.
+	var components []any
+	for _, field := range []string{"value", "key"} {
+		if component, ok := t[field]; ok && complexType != "literal" {
+			components = append(components, component)
+		}
+	}
+	for _, field := range []string{"options", "values"} {
+		if list, ok := t[field].([]any); ok {
+			components = append(components, list...)
+		}
+	}
+	fields := func(list any) {
+		for _, field := range asList(list) {
+			if field, ok := field.(map[string]any); ok && field["complex_type"] == nil {
+				for _, key := range []string{"type", "read_type", "write_type"} {
+					if component, ok := field[key]; ok {
+						components = append(components, component)
+					}
+				}
+			} else {
+				components = append(components, field)
+			}
+		}
+	}
+	fields(t["parameters"])
+	fields(t["attributes"])
+	for _, group := range asList(t["variant_parameter_groups"]) {
+		if group, ok := group.(map[string]any); ok {
+			fields(group["parameters"])
+		}
+	}
+
+	depth := 0
+	for _, component := range components {
+		depth = max(depth, s.typeDepth(component, path))
+	}
+	return depth + 1
+}
+
+// Text renders the summary for reading.
+func (s *Stats) Text() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s API of Factorio %s (API version %d)\n", s.Stage, s.GameVersion, s.APIVersion))
+	if s.ParseError != "" {
+		sb.WriteString(fmt.Sprintf("  Not parsed by the generator: %s\n", s.ParseError))
+	}
+
+	sb.WriteString("\nMembers:\n")
+	for _, kind := range memberKinds {
+		if count := s.Members[kind.key]; count > 0 {
+			sb.WriteString(fmt.Sprintf("  %-18s %6d", kind.label, count))
+			if deprecated := s.Deprecated[kind.key]; deprecated > 0 {
+				sb.WriteString(fmt.Sprintf("  (%d deprecated)", deprecated))
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("\nTypes by depth (%d types):\n", s.Types))
+	for depth := 0; depth <= MaxDepth; depth++ {
+		label := fmt.Sprintf("%d", depth)
+		switch depth {
+		case 0:
+			label = "0 (named)"
+		case MaxDepth:
+			label = fmt.Sprintf("%d or more", MaxDepth)
+		}
+		sb.WriteString(fmt.Sprintf("  %-18s %6d\n", label, s.TypeDepths[depth]))
+	}
+	if s.Deepest != "" {
+		sb.WriteString(fmt.Sprintf("  The deepest type is at %s.\n", s.Deepest))
+	}
+
+	sb.WriteString("\nComplex types:\n")
+	for _, complexType := range sortedKeys(s.ComplexTypes) {
+		sb.WriteString(fmt.Sprintf("  %-18s %6d", complexType, s.ComplexTypes[complexType]))
+		if !slices.Contains(api.ComplexTypes, complexType) {
+			sb.WriteString("  (unknown)")
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(s.Unknown) == 0 {
+		sb.WriteString("\nNo unknown complex types.\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("\nUnknown complex types (%d):\n", len(s.Unknown)))
+		for _, unknown := range s.Unknown {
+			sb.WriteString(fmt.Sprintf("  %s at %s\n", unknown.ComplexType, unknown.Path))
+		}
+	}
+	return sb.String()
+}
+
+func isMemberKind(key string) bool {
+	for _, kind := range memberKinds {
+		if kind.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func asList(value any) []any {
+	list, _ := value.([]any)
+	return list
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,49 @@
+package apistats
+
+import (
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	document := `{
+		"application": "factorio", "application_version": "2.1.0", "api_version": 7, "stage": "runtime",
+		"classes": [{
+			"name": "LuaEntity",
+			"methods": [
+				{"name": "die", "deprecated": true, "parameters": [{"name": "force", "type": "ForceID"}]},
+				{"name": "get_things", "return_values": [{"type": {"complex_type": "array", "value": {"complex_type": "dictionary", "key": "string", "value": "uint"}}}]}
+			],
+			"attributes": [{"name": "shape", "read_type": {"complex_type": "polygon", "values": ["MapPosition"]}}]
+		}]
+	}`
+	stats, err := Analyze([]byte(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.GameVersion != "2.1.0" || stats.APIVersion != 7 || stats.Stage != "runtime" {
+		t.Errorf("document = %s %s %d", stats.Stage, stats.GameVersion, stats.APIVersion)
+	}
+	if want := map[string]int{"classes": 1, "methods": 2, "attributes": 1, "parameters": 1, "return_values": 1}; !maps.Equal(stats.Members, want) {
+		t.Errorf("members = %v, want %v", stats.Members, want)
+	}
+	if want := map[string]int{"methods": 1}; !maps.Equal(stats.Deprecated, want) {
+		t.Errorf("deprecated = %v, want %v", stats.Deprecated, want)
+	}
+	if want := map[int]int{0: 1, 1: 1, 2: 1}; !maps.Equal(stats.TypeDepths, want) {
+		t.Errorf("type depths = %v, want %v", stats.TypeDepths, want)
+	}
+	if stats.Deepest != "LuaEntity.get_things" {
+		t.Errorf("deepest = %q", stats.Deepest)
+	}
+	if len(stats.Unknown) != 1 || stats.Unknown[0] != (UnknownType{"polygon", "LuaEntity.shape"}) {
+		t.Errorf("unknown = %v", stats.Unknown)
+	}
+	if !strings.Contains(stats.ParseError, `unknown complex_type "polygon"`) {
+		t.Errorf("parse error = %q", stats.ParseError)
+	}
+	if text := stats.Text(); !strings.Contains(text, "polygon at LuaEntity.shape") || !strings.Contains(text, "(1 deprecated)") {
+		t.Errorf("text:\n%s", text)
+	}
+}
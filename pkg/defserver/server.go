@@ -0,0 +1,151 @@
+// Package defserver serves generated definitions and documentation over HTTP, a
+// tree of files per game version.
+package defserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// GenerateFunc generates the files of a game version, by slash-separated path.
+type GenerateFunc func(version string) (map[string]string, error)
+
+// Server is an http.Handler serving the files of the game versions it allows,
+// under /<version>/ (e.g. /2.0.45/runtime.lua, /2.0.45/site/index.html). Each
+// version is generated on its first request and kept in memory, so "latest"
+// stays the version it was when first requested.
+//
+// The root lists the versions served so far, and /<version>/ the files of a
+// version, both as JSON.
+type Server struct {
+	generate GenerateFunc
+	allowed  func(version string) bool
+
+	mu       sync.Mutex
+	versions map[string]*version
+}
+
+// version is a game version being or having been generated.
+type version struct {
+	once  sync.Once
+	files map[string]string
+	err   error
+}
+
+// NewServer returns a server generating the versions allowed with generate.
+func NewServer(generate GenerateFunc, allowed func(version string) bool) *Server {
+	return &Server{generate: generate, allowed: allowed, versions: make(map[string]*version)}
+}
+
+// Index is the document served at the root.
+type Index struct {
+	Versions []string `json:"versions"` // The versions generated so far
+}
+
+// VersionIndex is the document served at /<version>/.
+type VersionIndex struct {
+	Version string   `json:"version"`
+	Files   []string `json:"files"`
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name, file, _ := strings.Cut(strings.TrimPrefix(path.Clean(r.URL.Path), "/"), "/")
+	if name == "" {
+		s.mu.Lock()
+		index := Index{Versions: []string{}}
+		for name, v := range s.versions {
+			if v.files != nil {
+				index.Versions = append(index.Versions, name)
+			}
+		}
+		s.mu.Unlock()
+		slices.Sort(index.Versions)
+		writeJSON(w, index)
+		return
+	}
+	if !s.allowed(name) {
+		http.Error(w, fmt.Sprintf("version %s is not served", name), http.StatusNotFound)
+		return
+	}
+
+	files, err := s.files(name)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to generate version %s: %v", name, err), http.StatusBadGateway)
+		return
+	}
+	if file == "" {
+		index := VersionIndex{Version: name, Files: make([]string, 0, len(files))}
+		for filename := range files {
+			index.Files = append(index.Files, filename)
+		}
+		slices.Sort(index.Files)
+		writeJSON(w, index)
+		return
+	}
+	content, ok := files[file]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", contentType(file))
+	w.Write([]byte(content))
+}
+
+// files returns the files of a version, generating them on its first request.
+// Requests for a version being generated wait for it, and a version that failed
+// to generate is tried again on the next request (the download may succeed).
+func (s *Server) files(name string) (map[string]string, error) {
+	s.mu.Lock()
+	v, ok := s.versions[name]
+	if !ok {
+		v = &version{}
+		s.versions[name] = v
+	}
+	s.mu.Unlock()
+
+	v.once.Do(func() {
+		files, err := s.generate(name)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		v.files, v.err = files, err
+		if err != nil {
+			delete(s.versions, name)
+		}
+	})
+	return v.files, v.err
+}
+
+// contentType returns the media type of a generated file.
+func contentType(filename string) string {
+	switch path.Ext(filename) {
+	case ".lua", ".tl", ".ts", ".rockspec":
+		return "text/plain; charset=utf-8"
+	case ".md":
+		return "text/markdown; charset=utf-8"
+	}
+	if t := mime.TypeByExtension(path.Ext(filename)); t != "" {
+		return t
+	}
+	return "text/plain; charset=utf-8"
+}
+
+func writeJSON(w http.ResponseWriter, document any) {
+	data, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(data, '\n'))
+}
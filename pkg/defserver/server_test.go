@@ -0,0 +1,56 @@
+package defserver
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestServerGeneratesEachVersionOnce(t *testing.T) {
+	generated := map[string]int{}
+	server := httptest.NewServer(NewServer(func(version string) (map[string]string, error) {
+		generated[version]++
+		if version == "1.0.0" {
+			return nil, errors.New("no such version")
+		}
+		return map[string]string{"runtime.lua": "-- " + version + "\n"}, nil
+	}, func(version string) bool { return version != "2.1.0" }))
+	defer server.Close()
+
+	get := func(path string) (int, string) {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+	for range 2 {
+		if code, body := get("/2.0.45/runtime.lua"); code != http.StatusOK || body != "-- 2.0.45\n" {
+			t.Errorf("/2.0.45/runtime.lua = %d %q", code, body)
+		}
+	}
+	if generated["2.0.45"] != 1 {
+		t.Errorf("2.0.45 generated %d times", generated["2.0.45"])
+	}
+	if code, _ := get("/2.0.45/missing.lua"); code != http.StatusNotFound {
+		t.Errorf("/2.0.45/missing.lua = %d", code)
+	}
+	if code, _ := get("/2.1.0/runtime.lua"); code != http.StatusNotFound {
+		t.Errorf("/2.1.0/runtime.lua = %d", code)
+	}
+	for range 2 {
+		if code, _ := get("/1.0.0/"); code != http.StatusBadGateway {
+			t.Errorf("/1.0.0/ = %d", code)
+		}
+	}
+	if generated["1.0.0"] != 2 {
+		t.Errorf("the failed version was generated %d times, want a retry", generated["1.0.0"])
+	}
+	if _, body := get("/"); body != "{\n  \"versions\": [\n    \"2.0.45\"\n  ]\n}\n" {
+		t.Errorf("/ = %q", body)
+	}
+}
@@ -0,0 +1,41 @@
+package generator
+
+import "encoding/json"
+
+// luaLSAddonConfig is the config.json of a LuaLS addon. The addon manager suggests
+// the addon for workspaces containing one of the files or words (both Lua
+// patterns) and applies the settings when it is enabled.
+type luaLSAddonConfig struct {
+	Name     string         `json:"name"`
+	Words    []string       `json:"words"`
+	Files    []string       `json:"files"`
+	Settings map[string]any `json:"settings"`
+}
+
+// luaLSAddonLibrary is the directory of a LuaLS addon holding its definitions,
+// which LuaLS adds to the workspace library by itself.
+const luaLSAddonLibrary = "library/"
+
+// luaLSAddonConfigFile returns the config.json of the addon laying out the LuaLS
+// definitions for the LuaLS addon manager: it detects Factorio mods and configures
+// the runtime Factorio runs them in (Lua 5.2, without the io and os libraries).
+// The definitions go in luaLSAddonLibrary next to it.
+func luaLSAddonConfigFile() (string, error) {
+	config := luaLSAddonConfig{
+		Name:  "Factorio",
+		Words: []string{`data:extend%s*%(`, `script%.on_event%s*%(`, `script%.on_init%s*%(`, `defines%.`},
+		Files: []string{`info%.json`},
+		Settings: map[string]any{
+			"Lua.runtime.version": "Lua 5.2",
+			"Lua.runtime.builtin": map[string]string{
+				"io": "disable",
+				"os": "disable",
+			},
+		},
+	}
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
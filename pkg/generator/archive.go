@@ -0,0 +1,105 @@
+package generator
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"time"
+)
+
+// ArchiveFormat selects the kind of archive an Archive writes.
+type ArchiveFormat string
+
+const (
+	ArchiveZip   ArchiveFormat = "zip"
+	ArchiveTarGz ArchiveFormat = "tar.gz"
+)
+
+// archiveTime is the modification time of every archived file, so that the same
+// definitions make the same archive.
+var archiveTime = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Archive writes generated files into a zip or gzipped tar archive, as a single
+// artifact for release pipelines. Files are added in the order they are closed.
+type Archive struct {
+	zip *zip.Writer
+	tar *tar.Writer
+	gz  *gzip.Writer
+}
+
+// NewArchive returns an archive writing to w. Close it to complete the archive.
+func NewArchive(w io.Writer, format ArchiveFormat) (*Archive, error) {
+	switch format {
+	case ArchiveZip:
+		return &Archive{zip: zip.NewWriter(w)}, nil
+	case ArchiveTarGz:
+		gz := gzip.NewWriter(w)
+		return &Archive{tar: tar.NewWriter(gz), gz: gz}, nil
+	default:
+		return nil, fmt.Errorf("unknown archive format %q", format)
+	}
+}
+
+// Creator returns a FileCreator adding the files to the archive under dir, a
+// slash-separated path ("." for the root). Tar entries need their size up front,
+// so each file is kept in memory until it is closed.
+func (a *Archive) Creator(dir string) FileCreator {
+	return func(filename string) (io.WriteCloser, error) {
+		return &archiveFile{archive: a, name: path.Join(dir, filename)}, nil
+	}
+}
+
+// Close completes the archive, without closing the underlying writer.
+func (a *Archive) Close() error {
+	if a.zip != nil {
+		return a.zip.Close()
+	}
+	if err := a.tar.Close(); err != nil {
+		return err
+	}
+	return a.gz.Close()
+}
+
+func (a *Archive) add(name string, content []byte) error {
+	if a.zip != nil {
+		w, err := a.zip.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: archiveTime})
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(content)
+		return err
+	}
+	if err := a.tar.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), ModTime: archiveTime, Format: tar.FormatPAX}); err != nil {
+		return err
+	}
+	_, err := a.tar.Write(content)
+	return err
+}
+
+// archiveFile is a file of an Archive, added to it when closed.
+type archiveFile struct {
+	bytes.Buffer
+	archive *Archive
+	name    string
+}
+
+func (f *archiveFile) Close() error {
+	return f.archive.add(f.name, f.Bytes())
+}
+
+// StreamCreator returns a FileCreator writing the files to w one after the other,
+// e.g. to standard output for piping into other tools. The LuaLS files, each
+// starting with its ---@meta line, make a single definitions file this way.
+func StreamCreator(w io.Writer) FileCreator {
+	return func(filename string) (io.WriteCloser, error) {
+		return nopCloser{w}, nil
+	}
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,72 @@
+package generator_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/generator"
+)
+
+func TestArchive(t *testing.T) {
+	files := []struct{ name, content string }{{"runtime.lua", "---@meta\n"}, {"runtime/classes/LuaEntity.lua", "---@class LuaEntity\n"}}
+	for _, format := range []generator.ArchiveFormat{generator.ArchiveZip, generator.ArchiveTarGz} {
+		var buf bytes.Buffer
+		archive, err := generator.NewArchive(&buf, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		create := archive.Creator("2.0")
+		for _, file := range files {
+			w, err := create(file.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			io.WriteString(w, file.content)
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := archive.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		got := make(map[string]string)
+		if format == generator.ArchiveZip {
+			r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, f := range r.File {
+				rc, _ := f.Open()
+				data, _ := io.ReadAll(rc)
+				got[f.Name] = string(data)
+			}
+		} else {
+			gz, err := gzip.NewReader(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := tar.NewReader(gz)
+			for {
+				header, err := r.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				data, _ := io.ReadAll(r)
+				got[header.Name] = string(data)
+			}
+		}
+		for _, file := range files {
+			if got["2.0/"+file.name] != file.content {
+				t.Errorf("%s archive: 2.0/%s = %q, want %q (archived %v)", format, file.name, got["2.0/"+file.name], file.content, got)
+			}
+		}
+	}
+}
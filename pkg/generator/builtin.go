@@ -0,0 +1,87 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+// integerBuiltins are the builtin types that only hold integral values.
+var integerBuiltins = map[string]bool{
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+}
+
+// floatBuiltins are the builtin types that hold floating point values.
+var floatBuiltins = map[string]bool{
+	"float":  true,
+	"double": true,
+}
+
+// nativeBuiltins are builtin types that LuaLS already knows under the same
+// name, so aliasing them would only create a self-reference.
+var nativeBuiltins = map[string]bool{
+	"boolean": true,
+	"string":  true,
+	"number":  true,
+	"table":   true,
+	"nil":     true,
+}
+
+// builtinAliasTargets maps the remaining non-numeric builtins to a LuaLS type.
+var builtinAliasTargets = map[string]string{
+	"LuaObject":        "any",
+	"DataExtendMethod": "function",
+}
+
+// isBuiltinConcept reports whether a concept documents a builtin type. The runtime
+// API marks these with {"complex_type": "builtin"}, the prototype API with the
+// plain type name "builtin".
+func isBuiltinConcept(concept api.Concept) bool {
+	return concept.Type.ComplexType == "builtin" || (concept.Type.IsSimple() && concept.Type.Name == "builtin")
+}
+
+// generateBuiltinAliases generates `---@alias` definitions for the documented
+// builtin types of both APIs (uint8, double, ...), carrying their documented
+// ranges, so references resolve to a precise type instead of collapsing to number.
+// Builtins documented by both APIs are emitted once, using the runtime description.
+func (g *Generator) generateBuiltinAliases(runtimeAPI *api.API, prototypeAPI *api.API, header string) string {
+	var builtins []api.Concept
+	seen := make(map[string]bool)
+	collect := func(concepts []api.Concept) {
+		for _, concept := range sortedByOrder(concepts) {
+			if !isBuiltinConcept(concept) || seen[concept.Name] || nativeBuiltins[concept.Name] {
+				continue
+			}
+			seen[concept.Name] = true
+			builtins = append(builtins, concept)
+		}
+	}
+	// Either API may be nil when only one stage is generated.
+	if runtimeAPI != nil {
+		collect(runtimeAPI.Concepts)
+	}
+	if prototypeAPI != nil {
+		collect(prototypeAPI.Types)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(g.metaPreamble())
+	sb.WriteString(header)
+	for _, builtin := range builtins {
+		target, ok := builtinAliasTargets[builtin.Name]
+		switch {
+		case ok:
+		case integerBuiltins[builtin.Name]:
+			target = g.integerType()
+		case floatBuiltins[builtin.Name]:
+			target = "number"
+		default:
+			target = "any"
+		}
+		g.writeDocComment(&sb, "", builtin.Description)
+		sb.WriteString(fmt.Sprintf("---@alias %s %s\n\n", builtin.Name, target))
+	}
+	return sb.String()
+}
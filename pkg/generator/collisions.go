@@ -0,0 +1,209 @@
+package generator
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+// CollisionResolution is how a name declared by two definitions was resolved.
+type CollisionResolution string
+
+const (
+	// CollisionMerged means the definitions are equivalent, or one is a fallback
+	// for the other, and the name is declared once.
+	CollisionMerged CollisionResolution = "merged"
+	// CollisionRenamed means the second definition is declared under another name,
+	// which the references of its stage use.
+	CollisionRenamed CollisionResolution = "renamed"
+)
+
+// Collision is a name that two definitions of the LuaLS output would declare,
+// such as a concept documented by both the runtime and the prototype API. LuaLS
+// would pick one of them arbitrarily, so the generator merges or renames them.
+type Collision struct {
+	Name       string
+	Kept       string // The definition keeping the name, e.g. "runtime concept"
+	Other      string // The definition it collided with, e.g. "prototype concept"
+	Resolution CollisionResolution
+	NewName    string // The name Other is declared under, when renamed
+}
+
+func (c Collision) String() string {
+	if c.Resolution == CollisionRenamed {
+		return fmt.Sprintf("%s: the %s keeps the name, the %s is declared as %s", c.Name, c.Kept, c.Other, c.NewName)
+	}
+	return fmt.Sprintf("%s: the %s and the %s are merged into the %s", c.Name, c.Kept, c.Other, c.Kept)
+}
+
+// Collisions returns the name collisions found by the last GenerateDefinitions
+// call and how they were resolved, in name order.
+func (g *Generator) Collisions() []Collision {
+	return slices.SortedStableFunc(slices.Values(g.collisions), func(a, b Collision) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+}
+
+// addCollision records a resolved collision.
+func (g *Generator) addCollision(c Collision) {
+	g.collisions = append(g.collisions, c)
+}
+
+// resolveCollisions decides what happens to the prototype concepts sharing their
+// name with a runtime concept or class. Equivalent concepts (the same type,
+// ignoring descriptions) are merged: only the runtime stage declares them. The
+// others are renamed into the data namespace (Color becomes data.Color), which
+// keeps the runtime name for control scripts while the prototype stage refers to
+// its own definition. A concept equivalent to its runtime namesake but referring
+// to a renamed one is renamed too, since its references differ in the output.
+//
+// The results are kept in runtimeNames, mergedConcepts and prototypeRenames for
+// generatePrototype.
+func (g *Generator) resolveCollisions(runtimeAPI *api.API, prototypeAPI *api.API) {
+	g.runtimeNames = make(map[string]bool)
+	g.mergedConcepts = make(map[string]bool)
+	g.prototypeRenames = make(map[string]string)
+	if runtimeAPI == nil || prototypeAPI == nil {
+		return
+	}
+
+	runtimeConcepts := make(map[string]api.Concept)
+	for _, concept := range runtimeAPI.Concepts {
+		if !isBuiltinConcept(concept) {
+			runtimeConcepts[concept.Name] = concept
+		}
+	}
+	runtimeClasses := make(map[string]bool)
+	for _, class := range runtimeAPI.Classes {
+		runtimeClasses[class.Name] = true
+	}
+	for name := range runtimeConcepts {
+		g.runtimeNames[name] = true
+	}
+	maps.Copy(g.runtimeNames, runtimeClasses)
+
+	colliding := make(map[string]api.Concept)
+	for _, concept := range append(slices.Clone(prototypeAPI.Concepts), prototypeAPI.Types...) {
+		if isBuiltinConcept(concept) {
+			continue
+		}
+		if runtime, ok := runtimeConcepts[concept.Name]; ok {
+			colliding[concept.Name] = concept
+			if sameConcept(runtime, concept) {
+				g.mergedConcepts[concept.Name] = true
+			} else {
+				g.prototypeRenames[concept.Name] = "data." + concept.Name
+			}
+		} else if runtimeClasses[concept.Name] {
+			colliding[concept.Name] = concept
+			g.prototypeRenames[concept.Name] = "data." + concept.Name
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for name := range g.mergedConcepts {
+			if slices.ContainsFunc(conceptReferences(colliding[name]), func(ref string) bool { return g.prototypeRenames[ref] != "" }) {
+				delete(g.mergedConcepts, name)
+				g.prototypeRenames[name] = "data." + name
+				changed = true
+			}
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(colliding)) {
+		kept := "runtime concept"
+		if runtimeClasses[name] {
+			kept = "runtime class"
+		}
+		if g.mergedConcepts[name] {
+			g.addCollision(Collision{Name: name, Kept: kept, Other: "prototype concept", Resolution: CollisionMerged})
+		} else {
+			g.addCollision(Collision{Name: name, Kept: kept, Other: "prototype concept", Resolution: CollisionRenamed, NewName: g.prototypeRenames[name]})
+		}
+	}
+}
+
+// documentationKeys are the JSON keys ignored when comparing definitions.
+var documentationKeys = []string{"description", "order", "examples", "lists", "images"}
+
+// sameConcept reports whether two concepts define the same type, ignoring their
+// documentation.
+func sameConcept(a api.Concept, b api.Concept) bool {
+	return reflect.DeepEqual(conceptShape(a), conceptShape(b))
+}
+
+// conceptShape returns the definition of a concept, without its name and
+// documentation, as decoded JSON.
+func conceptShape(concept api.Concept) any {
+	data, _ := json.Marshal(map[string]any{
+		"type":       concept.Type,
+		"parent":     concept.Parent,
+		"properties": concept.Properties,
+	})
+	var shape any
+	_ = json.Unmarshal(data, &shape)
+	return stripDocumentation(shape)
+}
+
+func stripDocumentation(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for _, key := range documentationKeys {
+			delete(value, key)
+		}
+		for key, v := range value {
+			value[key] = stripDocumentation(v)
+		}
+	case []any:
+		for i, v := range value {
+			value[i] = stripDocumentation(v)
+		}
+	}
+	return value
+}
+
+// conceptReferences returns the names a concept's definition refers to. Field
+// names are included, which errs on the side of renaming.
+func conceptReferences(concept api.Concept) []string {
+	refs := slices.Clone(concept.Parent)
+	var walk func(value any)
+	walk = func(value any) {
+		switch value := value.(type) {
+		case map[string]any:
+			for key, v := range value {
+				if name, ok := v.(string); ok && key == "name" {
+					refs = append(refs, name)
+				}
+				walk(v)
+			}
+		case []any:
+			for _, v := range value {
+				walk(v)
+			}
+		}
+	}
+	walk(conceptShape(concept))
+	return refs
+}
+
+// renamedPrototypeConcept returns a prototype concept under the name it is
+// declared as, with renamed parents.
+func (g *Generator) renamedPrototypeConcept(concept api.Concept) api.Concept {
+	if name, ok := g.prototypeRenames[concept.Name]; ok {
+		concept.Name = name
+	}
+	if len(concept.Parent) > 0 {
+		parents := make(api.ParentList, len(concept.Parent))
+		for i, parent := range concept.Parent {
+			parents[i] = cmp.Or(g.prototypeRenames[parent], parent)
+		}
+		concept.Parent = parents
+	}
+	return concept
+}
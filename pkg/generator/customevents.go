@@ -0,0 +1,53 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CustomEvent is a custom event raised by a mod (see Options.CustomEvents): an
+// id from script.generate_event_name(), raised with script.raise_event.
+type CustomEvent struct {
+	Name   string // e.g. "on_thing_done"
+	Fields []CustomEventField
+}
+
+// CustomEventField is a field of the payload of a custom event.
+type CustomEventField struct {
+	Name     string
+	Type     string // A LuaLS type, e.g. "string" or "integer | string"
+	Optional bool
+}
+
+// customEventOverloads returns one signature of LuaBootstrap.on_event per custom
+// event, like eventOverloads does for the documented ones. Custom events have no
+// filters.
+func (g *Generator) customEventOverloads() []string {
+	var overloads []string
+	for _, event := range g.options.CustomEvents {
+		overloads = append(overloads, fmt.Sprintf("fun(event: CustomEvent.%s, handler: fun(event: CustomEventData.%s) | nil)", event.Name, event.Name))
+	}
+	return overloads
+}
+
+// generateCustomEvents generates two classes per custom event: its id, a
+// defines.events so it can be passed wherever an event id is expected, and its
+// payload, an EventData with the fields it is raised with. The id returned by
+// script.generate_event_name() is only a number to LuaLS, so the variable holding
+// it has to be annotated with the id class to select the event's on_event
+// overload. Neither class has a table, since no global of that name exists.
+func (g *Generator) generateCustomEvents() string {
+	var sb strings.Builder
+	for _, event := range g.options.CustomEvents {
+		sb.WriteString(fmt.Sprintf("---Id of the custom event %s, from script.generate_event_name(). Annotate the variable holding it with `---@type %sCustomEvent.%s` to type the handlers registered for it.\n", event.Name, g.options.TypePrefix, event.Name))
+		sb.WriteString(fmt.Sprintf("---@class CustomEvent.%s : defines.events\n\n", event.Name))
+		sb.WriteString(fmt.Sprintf("---Payload of the custom event %s, as raised with script.raise_event.\n", event.Name))
+		sb.WriteString(fmt.Sprintf("---@class CustomEventData.%s : EventData\n", event.Name))
+		for _, field := range event.Fields {
+			name, luaLSType := g.optionalField(field.Name, field.Type, field.Optional)
+			sb.WriteString(fmt.Sprintf("---@field %s %s\n", name, luaLSType))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
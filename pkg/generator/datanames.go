@@ -0,0 +1,63 @@
+package generator
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+// prototypeIDNames returns the known prototype names (Options.DataRawNames) that
+// each ID concept accepts. An ID concept names a prototype of a base prototype
+// and its descendants, e.g. ItemID the ItemPrototype, AmmoItemPrototype, ...
+// Both stages document them (ItemID), so they get the names of every typename
+// below the base prototype. Without the prototype API, which documents the
+// hierarchy, there are none.
+func prototypeIDNames(prototypeAPI *api.API, dataRawNames map[string][]string) map[string][]string {
+	if prototypeAPI == nil || len(dataRawNames) == 0 {
+		return nil
+	}
+	parents := make(map[string]string, len(prototypeAPI.Prototypes))
+	for _, prototype := range prototypeAPI.Prototypes {
+		parents[prototype.Name] = prototype.Parent
+	}
+	names := make(map[string][]string)
+	for _, prototype := range prototypeAPI.Prototypes {
+		if prototype.TypeName == "" || len(dataRawNames[prototype.TypeName]) == 0 {
+			continue
+		}
+		// The names count for the ID of every ancestor, e.g. "ammo" for ItemID.
+		seen := make(map[string]bool)
+		for name := prototype.Name; name != "" && !seen[name]; name = parents[name] {
+			seen[name] = true
+			if base, ok := strings.CutSuffix(name, "Prototype"); ok {
+				names[base+"ID"] = append(names[base+"ID"], dataRawNames[prototype.TypeName]...)
+			}
+		}
+	}
+	for id, list := range names {
+		slices.Sort(list)
+		names[id] = slices.Compact(list)
+	}
+	return names
+}
+
+// withPrototypeNames adds the known prototype names an ID concept accepts to its
+// type as string literals, so completion offers them where an ItemID or an
+// EntityID is expected. The type still accepts any string, for the prototypes
+// added by mods that weren't loaded when the names were dumped.
+func (g *Generator) withPrototypeNames(concept api.Concept) api.Concept {
+	names := g.idNames[concept.Name]
+	if len(names) == 0 {
+		return concept
+	}
+	options := []api.Type{concept.Type}
+	if concept.Type.IsUnion() {
+		options = slices.Clone(concept.Type.Values)
+	}
+	for _, name := range names {
+		options = append(options, api.Type{ComplexType: "literal", LiteralValue: name})
+	}
+	concept.Type = api.Type{ComplexType: "union", Values: options}
+	return concept
+}
@@ -0,0 +1,69 @@
+package generator
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+func TestDictionaryKeyType(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string // The key type, as in the API documents
+		want string
+	}{
+		{"named", `"string"`, "string"},
+		{"concept", `"ItemID"`, "ItemID"},
+		{"class", `"LuaEntity"`, "LuaEntity"},
+		{"string literal", `{"complex_type": "literal", "value": "left"}`, "string"},
+		{"integer literal", `{"complex_type": "literal", "value": 3}`, "integer"},
+		{"fractional literal", `{"complex_type": "literal", "value": 0.5}`, "number"},
+		{"boolean literal", `{"complex_type": "literal", "value": true}`, "boolean"},
+		{"union of string literals", `{"complex_type": "union", "options": [
+			{"complex_type": "literal", "value": "left"}, {"complex_type": "literal", "value": "right"}]}`, `"left" | "right"`},
+		{"union of mixed literals", `{"complex_type": "union", "options": [
+			{"complex_type": "literal", "value": "left"}, {"complex_type": "literal", "value": 1}]}`, "string | integer"},
+		{"union of literals of a type", `{"complex_type": "union", "options": [
+			{"complex_type": "literal", "value": 1}, {"complex_type": "literal", "value": 2}]}`, "integer"},
+		{"union of a literal and named types", `{"complex_type": "union", "options": [
+			{"complex_type": "literal", "value": "all"}, "ItemID", "uint"]}`, "string | ItemID | uint"},
+		{"union with a table", `{"complex_type": "union", "options": [
+			"ItemID", {"complex_type": "array", "value": "ItemID"}]}`, "any"},
+		{"table", `{"complex_type": "array", "value": "string"}`, "any"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var key api.Type
+			if err := json.Unmarshal([]byte(test.key), &key); err != nil {
+				t.Fatal(err)
+			}
+			g := NewGenerator(DefaultOptions())
+			if got := g.dictionaryKeyType(key); got != test.want {
+				t.Errorf("dictionaryKeyType(%s) = %q, want %q", test.key, got, test.want)
+			}
+		})
+	}
+}
+
+func TestLiteralBaseType(t *testing.T) {
+	tests := []struct {
+		value      interface{}
+		numberMode NumberMode
+		want       string
+	}{
+		{"left", NumbersStrict, "string"},
+		{true, NumbersStrict, "boolean"},
+		{float64(3), NumbersStrict, "integer"},
+		{float64(3), NumbersLoose, "number"},
+		{-0.25, NumbersStrict, "number"},
+		{nil, NumbersStrict, "any"},
+	}
+	for _, test := range tests {
+		options := DefaultOptions()
+		options.NumberMode = test.numberMode
+		if got := NewGenerator(options).literalBaseType(test.value); got != test.want {
+			t.Errorf("literalBaseType(%v) with %s numbers = %q, want %q", test.value, test.numberMode, got, test.want)
+		}
+	}
+}
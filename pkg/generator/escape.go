@@ -0,0 +1,57 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// luaString quotes a string as a double-quoted Lua string literal, which is also
+// how LuaLS and Teal read string literal types. It is used for every string the
+// Lua outputs quote: literal types, define values and quoted field names.
+//
+// Go's %q and strconv.Quote are not suitable: their \u and \x escapes don't
+// exist in Lua 5.2 (Factorio's Lua), which would read "é" as "u00e9". Here
+// only the characters Lua requires are escaped, by their short escapes where
+// Lua has one; other control characters and invalid UTF-8 bytes use decimal
+// escapes (\ddd), and any other character, ASCII or not, is kept as is.
+func luaString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == '\\':
+			sb.WriteString(`\\`)
+		case r == '"':
+			sb.WriteString(`\"`)
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case r == '\r':
+			sb.WriteString(`\r`)
+		case r == '\t':
+			sb.WriteString(`\t`)
+		case r == utf8.RuneError && size == 1, r < 0x20, r == 0x7f:
+			// Three digits, so a following digit isn't read as part of the escape.
+			fmt.Fprintf(&sb, `\%03d`, s[i])
+		default:
+			sb.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// jsString quotes a string as a JavaScript string literal, for the TypeScript
+// output. JSON strings are valid JavaScript strings; HTML characters are kept
+// as is rather than escaped as JSON encoding does by default.
+func jsString(s string) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(s) // Strings always encode
+	return strings.TrimSuffix(buf.String(), "\n")
+}
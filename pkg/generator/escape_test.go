@@ -0,0 +1,56 @@
+package generator
+
+import "testing"
+
+func TestLuaString(t *testing.T) {
+	tests := []struct {
+		s, want string
+	}{
+		{"iron-plate", `"iron-plate"`},
+		{"", `""`},
+		{`say "hi"`, `"say \"hi\""`},
+		{"it's", `"it's"`},
+		{`C:\mods\`, `"C:\\mods\\"`},
+		{"line\nbreak", `"line\nbreak"`},
+		{"a\r\n\tb", `"a\r\n\tb"`},
+		{"bell\a", `"bell\007"`},
+		{"nul\x00", `"nul\000"`},
+		{"del\x7f", `"del\127"`},
+		// A digit after a decimal escape isn't read as part of it.
+		{"\x01" + "23", `"\00123"`},
+		{"\x1b" + "9", `"\0279"`},
+		{"é and ✓", `"é and ✓"`},
+		{"bad\xff\xfe", `"bad\255\254"`},
+		{"cut\xe2\x9c", `"cut\226\156"`},
+		{"\xff1", `"\2551"`},
+		{"\uFFFD", `"` + "\uFFFD" + `"`},
+	}
+	for _, test := range tests {
+		if got := luaString(test.s); got != test.want {
+			t.Errorf("luaString(%q) = %s, want %s", test.s, got, test.want)
+		}
+	}
+}
+
+func TestJSString(t *testing.T) {
+	tests := []struct {
+		s, want string
+	}{
+		{"iron-plate", `"iron-plate"`},
+		{"", `""`},
+		{`say "hi"`, `"say \"hi\""`},
+		{`C:\mods\`, `"C:\\mods\\"`},
+		{"line\nbreak\r\t", `"line\nbreak\r\t"`},
+		{"bell\a1", `"bell\u00071"`},
+		{"<b>&</b>", `"<b>&</b>"`},
+		{"é and ✓", `"é and ✓"`},
+		{"\u2028", `"\u2028"`},
+		// Invalid UTF-8 becomes the replacement character.
+		{"bad\xff", `"bad` + "\uFFFD" + `"`},
+	}
+	for _, test := range tests {
+		if got := jsString(test.s); got != test.want {
+			t.Errorf("jsString(%q) = %s, want %s", test.s, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package generator
+
+import (
+	"path"
+	"slices"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+// included reports whether an entity passes the configured include and exclude
+// globs (path.Match syntax, e.g. "LuaGui*"). Any of the names may match; prototypes
+// are matched by both their name and their typename. With no include patterns
+// everything is included, and exclude patterns win over include patterns.
+func (o Options) included(names ...string) bool {
+	matchesAny := func(patterns []string) bool {
+		for _, pattern := range patterns {
+			for _, name := range names {
+				// Patterns are validated up front, so errors can't occur here.
+				if ok, _ := path.Match(pattern, name); ok {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	if len(o.Include) > 0 && !matchesAny(o.Include) {
+		return false
+	}
+	return !matchesAny(o.Exclude)
+}
+
+// filterAPI returns a copy of a parsed API with the classes, events and prototypes
+// that don't pass the include/exclude filters removed, after filterFeatures. The original is left
+// untouched; a nil API stays nil.
+func (g *Generator) filterAPI(a *api.API) *api.API {
+	a = g.filterFeatures(a)
+	if a == nil || (len(g.options.Include) == 0 && len(g.options.Exclude) == 0) {
+		return a
+	}
+	filtered := *a
+	filtered.Classes = nil
+	for _, class := range a.Classes {
+		if g.options.included(class.Name) {
+			filtered.Classes = append(filtered.Classes, class)
+		}
+	}
+	filtered.Events = nil
+	for _, event := range a.Events {
+		if g.options.included(event.Name) {
+			filtered.Events = append(filtered.Events, event)
+		}
+	}
+	filtered.Prototypes = nil
+	for _, prototype := range a.Prototypes {
+		if g.options.included(prototype.Name, prototype.TypeName) {
+			filtered.Prototypes = append(filtered.Prototypes, prototype)
+		}
+	}
+	return &filtered
+}
+
+// filterFeatures returns a copy of a parsed API without the members that aren't
+// visible with Options.FeatureFlags: classes and their methods and attributes,
+// events, concepts and prototypes and their properties, and the options of unions
+// naming the concepts and prototypes left out. Without feature flags,
+// or for a nil API, the API is returned as is.
+func (g *Generator) filterFeatures(a *api.API) *api.API {
+	if a == nil || g.options.FeatureFlags == nil {
+		return a
+	}
+	flags := g.options.FeatureFlags
+	filtered := *a
+	filtered.Classes = visibleWith(a.Classes, flags)
+	for i, class := range filtered.Classes {
+		filtered.Classes[i].Methods = visibleWith(class.Methods, flags)
+		filtered.Classes[i].Attributes = visibleWith(class.Attributes, flags)
+		filtered.Classes[i].Properties = visibleWith(class.Properties, flags)
+	}
+	filtered.Events = visibleWith(a.Events, flags)
+	filtered.Concepts = visibleWith(a.Concepts, flags)
+	for i, concept := range filtered.Concepts {
+		filtered.Concepts[i].Properties = visibleWith(concept.Properties, flags)
+	}
+	filtered.Types = visibleWith(a.Types, flags)
+	for i, concept := range filtered.Types {
+		filtered.Types[i].Properties = visibleWith(concept.Properties, flags)
+	}
+	filtered.Prototypes = visibleWith(a.Prototypes, flags)
+	for i, prototype := range filtered.Prototypes {
+		filtered.Prototypes[i].Properties = visibleWith(prototype.Properties, flags)
+	}
+
+	// Unions listing the definitions left out (AnyPrototype lists every
+	// prototype) lose those options, rather than naming undefined types.
+	removed := make(map[string]bool)
+	for _, concept := range slices.Concat(a.Concepts, a.Types) {
+		removed[concept.Name] = !concept.VisibleWith(flags)
+	}
+	for _, prototype := range a.Prototypes {
+		removed[prototype.Name] = !prototype.VisibleWith(flags)
+	}
+	for _, concepts := range [][]api.Concept{filtered.Concepts, filtered.Types} {
+		for i, concept := range concepts {
+			if concept.Type.ComplexType == "union" {
+				concepts[i].Type.Values = slices.DeleteFunc(slices.Clone(concept.Type.Values), func(option api.Type) bool {
+					// Options with a description are wrapped in a "type" type.
+					if option.ComplexType == "type" && option.Value != nil {
+						option = *option.Value
+					}
+					return removed[option.Name]
+				})
+			}
+		}
+	}
+	return &filtered
+}
+
+// visibleWith returns a copy of members without those that aren't visible with
+// the expansions.
+func visibleWith[T interface{ VisibleWith([]string) bool }](members []T, expansions []string) []T {
+	return slices.DeleteFunc(slices.Clone(members), func(member T) bool { return !member.VisibleWith(expansions) })
+}
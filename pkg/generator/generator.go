@@ -1,115 +1,647 @@
 package generator
 
 import (
+	"cmp"
 	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"text/template"
 
 	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/luasyntax"
 )
 
+// OptionalStyle selects how optional fields and parameters are annotated.
+type OptionalStyle string
+
+const (
+	// OptionalSuffix uses the canonical LuaLS form: `---@field name? T`.
+	OptionalSuffix OptionalStyle = "suffix"
+	// OptionalNilUnion uses `---@field name T | nil`, for older LuaLS versions.
+	OptionalNilUnion OptionalStyle = "nil-union"
+)
+
+// NumberMode selects how Factorio's numeric types map to LuaLS types.
+type NumberMode string
+
+const (
+	// NumbersStrict maps integral types (int, uint, uint64, ...) to integer and
+	// floating point types to number.
+	NumbersStrict NumberMode = "strict"
+	// NumbersLoose maps every numeric type to number.
+	NumbersLoose NumberMode = "loose"
+)
+
+// TupleStyle selects how tuple types are written.
+type TupleStyle string
+
+const (
+	// TuplesModern uses the LuaLS tuple syntax: `[T1, T2]`.
+	TuplesModern TupleStyle = "modern"
+	// TuplesTable uses an inline table type with numeric keys, `{1: T1, 2: T2}`,
+	// as emitted by earlier versions of the generator.
+	TuplesTable TupleStyle = "table"
+)
+
+// Format selects an output format. Several formats can be generated at once.
+type Format string
+
+const (
+	// FormatLuaLS generates LuaLS annotation files (runtime.lua, prototype.lua, builtin.lua).
+	FormatLuaLS Format = "luals"
+	// FormatTeal generates Teal declaration files (runtime.d.tl, prototype.d.tl).
+	FormatTeal Format = "teal"
+	// FormatTypeScript generates TypeScript declaration files (runtime.d.ts,
+	// prototype.d.ts) for TypeScriptToLua.
+	FormatTypeScript Format = "dts"
+	// FormatIR dumps the normalized API model as JSON (ir.json) for other tools.
+	FormatIR Format = "ir-json"
+	// FormatMarkdown generates an offline API reference in Markdown (docs/).
+	FormatMarkdown Format = "markdown"
+	// FormatHTML generates a static HTML site of the API reference (site/).
+	FormatHTML Format = "html"
+	// FormatLuaLSAddon generates the LuaLS annotation files laid out as a LuaLS
+	// addon (config.json and library/), for the addon manager.
+	FormatLuaLSAddon Format = "luals-addon"
+	// FormatStubs generates executable Lua stubs of the API (stubs/) for unit
+	// tests outside the game.
+	FormatStubs Format = "lua-stubs"
+	// FormatLuaRocks generates the LuaLS annotation files laid out as a LuaLS
+	// rock (a rockspec and library/), for LuaRocks.
+	FormatLuaRocks Format = "luarocks"
+)
+
+// Dialect selects the annotation syntax the output targets.
+type Dialect string
+
+const (
+	// DialectLuaLS uses the full annotation syntax of lua-language-server.
+	DialectLuaLS Dialect = "luals"
+	// DialectEmmyLua restricts the output to the subset understood by the older
+	// EmmyLua plugins (e.g. for IntelliJ): optional members become `T | nil`,
+	// defines become classes instead of @enum tables, tuples become arrays, and
+	// generic classes, @operator annotations and indexed fields are left out.
+	DialectEmmyLua Dialect = "emmylua"
+)
+
+// Context selects the scripts the runtime definitions are for, which differ in
+// the globals available to them.
+type Context string
+
+const (
+	// ContextControl is control.lua and the files it requires.
+	ContextControl Context = "control"
+	// ContextMigrations is the mod's migration scripts (migrations/*.lua). The
+	// runtime globals are left out of the runtime definitions, and MigrationsFile
+	// declares those of migrations instead. The prototype stage isn't generated.
+	ContextMigrations Context = "migrations"
+	// ContextScenario is the control.lua of a scenario or tutorial. As with
+	// ContextMigrations, ScenarioFile declares the globals, which include the
+	// classes of the event_handler library scenarios are built with, and the
+	// prototype stage isn't generated, scenarios having no data stage.
+	ContextScenario Context = "scenario"
+)
+
+// PrototypeClassMode selects how prototype definitions map to classes.
+type PrototypeClassMode string
+
+const (
+	// PrototypeClassesByType generates one class per prototype type, with the
+	// properties of all definitions of the type merged.
+	PrototypeClassesByType PrototypeClassMode = "type"
+	// PrototypeClassesByDefinition additionally generates one class per prototype
+	// definition, deriving from its type's class, with exact field sets.
+	PrototypeClassesByDefinition PrototypeClassMode = "definition"
+)
+
+// Options configures the output of a Generator.
+type Options struct {
+	OptionalStyle   OptionalStyle // How optional fields and parameters are annotated
+	SplitFiles      bool          // Emit one file per section and class instead of one per stage
+	IncludeExamples bool          // Append API examples to doc comments as fenced code blocks
+	NumberMode      NumberMode    // Whether integral types map to integer or number
+	TupleStyle      TupleStyle    // How tuple types are written
+	Dialect         Dialect       // Annotation syntax the output targets
+	Formats         []Format      // Output formats to generate
+	Context         Context       // Scripts the runtime globals are declared for
+	// Declare the globals of each stage in a directory of their own, see
+	// StageCommonDir.
+	StageGlobals bool
+	// How prototype definitions map to classes
+	PrototypeClasses PrototypeClassMode
+	// Prepended to the class names derived from prototype typenames. Optional.
+	PrototypeClassPrefix string
+	// Prepended to the names of all generated classes and aliases and to the
+	// references to them, e.g. "Factorio." for Factorio.LuaEntity. Optional.
+	TypePrefix string
+	// The expansions the definitions are for, by their visibility names in the
+	// API (e.g. "space_age"). Unless nil, the members only visible with other
+	// expansions are left out, an empty list keeping those of the base game.
+	FeatureFlags []string
+	// Glob patterns selecting the classes, events and prototypes to generate.
+	Include []string
+	Exclude []string
+	// Known prototype names per type (e.g. "item" -> "iron-plate"), declared as
+	// fields of the data.raw tables and added to the ID concepts (ItemID, ...)
+	// so they get completion. Optional.
+	DataRawNames map[string][]string
+	// Localised strings by locale section and key (e.g. "item-name" ->
+	// "iron-plate" -> "Iron plate"), from the locale files of the game and mods.
+	// The names of prototypes are shown on the data.raw fields of DataRawNames,
+	// and those of define values on the values. Optional.
+	Locale map[string]map[string]string
+	// Settings declared by the mods the definitions are for, typing the tables of
+	// the settings global (settings.startup, ...) so that only their names are
+	// valid. Optional.
+	ModSettings []ModSetting
+	// Custom events raised by the mods the definitions are for, generated as
+	// event ids and payloads with on_event overloads. Optional.
+	CustomEvents []CustomEvent
+	// Remote interfaces registered by the mods the definitions are for or their
+	// dependencies, declared in RemoteInterfaces and typing remote.call. Optional.
+	RemoteInterfaces []RemoteInterface
+	// LuaLS diagnostics disabled in the generated LuaLS files with a
+	// `---@diagnostic disable` line, e.g. DefaultDisabledDiagnostics. Optional.
+	DisabledDiagnostics []string
+	// Where the API documents were read from, named in the file headers.
+	RuntimeURL   string
+	PrototypeURL string
+	// Renders the header comment of the generated files from HeaderData, in place
+	// of the default two-line comment. Optional.
+	HeaderTemplate *template.Template
+	// Overrides of the emission of classes, methods, fields and defines, see
+	// LoadTemplates. Optional.
+	Templates *template.Template
+	// Write LuaLSPluginFile with the LuaLS output, a LuaLS plugin resolving
+	// Factorio's require("__mod-name__/...") paths.
+	RequirePlugin bool
+	// The source URL of the rockspec of FormatLuaRocks: an archive of the output
+	// for publishing the rock. Optional; luarocks make doesn't need one.
+	RockSourceURL string
+	// Where Run writes the generated files; nil returns them in Result.Files.
+	Output FileCreator
+	// How Run downloads the API documents: the HTTP client (http.DefaultClient if
+	// nil), the logger of its progress (nil logs nothing) and the directory
+	// caching the documents of game versions (empty caches nothing, see
+	// api.Client).
+	HTTPClient *http.Client
+	Logger     *log.Logger
+	CacheDir   string
+	// Number of classes and prototype types generated concurrently. Zero uses
+	// every CPU (GOMAXPROCS); one generates them one by one. The output is the
+	// same either way.
+	Workers int
+}
+
+// DefaultOptions returns the options used when nothing is configured.
+func DefaultOptions() Options {
+	return Options{
+		OptionalStyle:    OptionalSuffix,
+		IncludeExamples:  true,
+		NumberMode:       NumbersStrict,
+		TupleStyle:       TuplesModern,
+		Dialect:          DialectLuaLS,
+		Formats:          []Format{FormatLuaLS},
+		Context:          ContextControl,
+		PrototypeClasses: PrototypeClassesByType,
+		RuntimeURL:       "https://lua-api.factorio.com/latest/runtime-api.json",
+		PrototypeURL:     "https://lua-api.factorio.com/latest/prototype-api.json",
+	}
+}
+
 // Generator holds the logic for converting API data to LuaLS definitions.
 type Generator struct {
-	// Add any necessary configuration or state here
+	options  Options
+	renderer *descriptionRenderer // Set per GenerateDefinitions call
+	// Per-event signatures of LuaBootstrap.on_event, set per GenerateDefinitions call.
+	eventOverloads []string
+	// Per-function signatures of LuaRemote.call, set per GenerateDefinitions call.
+	remoteOverloads []string
+	templateErr     error // First error rendering Options.Templates, see override
+	// Name collisions between definitions and their resolution, set per
+	// GenerateDefinitions call, see resolveCollisions.
+	collisions       []Collision
+	runtimeNames     map[string]bool   // Runtime concepts and classes
+	mergedConcepts   map[string]bool   // Prototype concepts declared by the runtime stage
+	prototypeRenames map[string]string // Prototype concepts declared under another name
+	// The first parameter class generated for each shape of named arguments, keyed
+	// by its declaration with the class name left out; see generateMethodStub.
+	paramShapes map[string]string
+	// The known prototype names of each ID concept, set per GenerateDefinitions
+	// call; see prototypeIDNames.
+	idNames map[string][]string
+	// The locale sections naming the prototypes of each typename; see
+	// prototypeLocaleSections.
+	localeSections map[string][]string
+	// The parameter classes of a worker, declared when it is merged; see
+	// generateConcurrently.
+	pendingParams []paramClass
+	// The class to declare the next inline table type as, set per attribute, and
+	// the classes declared so far for the current class; see luaLSSyntax.table.
+	tableName string
+	tables    []paramClass
+	// Names translated by luaLSSyntax.named, set while a stage is generated.
+	renames map[string]string
+	// Records the imprecise types while the LuaLS output is generated, and their
+	// report once it is; see TypeReport.
+	types      *typeLog
+	typeReport TypeReport
+	hooks      []Hook // Registered with AddHook
+	// Serializes the rendering of Options.Templates by the generator and its
+	// forks: override sets the template functions of the generator rendering it,
+	// which text/template doesn't allow while the template is executed by another.
+	templateMu *sync.Mutex
 }
 
-// NewGenerator creates a new instance of the Generator.
-func NewGenerator() *Generator {
-	return &Generator{}
+// NewGenerator creates a new instance of the Generator with the given options.
+// Options.Templates is cloned, so generators given the same templates can run
+// concurrently.
+func NewGenerator(options Options) *Generator {
+	if options.Templates != nil {
+		if templates, err := options.Templates.Clone(); err == nil {
+			options.Templates = templates
+		}
+	}
+	return &Generator{options: options, templateMu: new(sync.Mutex)}
 }
 
 // GenerateDefinitions takes the parsed API data and returns a map of filenames
-// to their generated Lua definition content.
+// to their generated Lua definition content. GenerateTo writes the same files
+// without keeping them all in memory.
 func (g *Generator) GenerateDefinitions(runtimeAPI *api.API, prototypeAPI *api.API) (map[string]string, error) {
 	definitions := make(map[string]string)
+	err := g.GenerateTo(runtimeAPI, prototypeAPI, func(filename string) (io.WriteCloser, error) {
+		return &mapFile{name: filename, files: definitions}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return definitions, nil
+}
 
-	// --- Runtime API ---
-	var runtimeSB strings.Builder
-	runtimeSB.WriteString("---@meta\n\n")
-	runtimeSB.WriteString("-- Auto-generated Factorio Runtime API definitions\n")
-	runtimeSB.WriteString("-- Generated from: https://lua-api.factorio.com/latest/runtime-api.json\n\n")
+// GenerateTo generates the definitions like GenerateDefinitions, writing every
+// file through create as soon as it is complete. With SplitFiles, the LuaLS
+// output is then written class by class rather than built up in memory, as are
+// the other formats' files once their format is generated. The LuaLS files are
+// only kept until the end when TypePrefix is set, since prefixing needs every
+// name they declare.
+//
+// Files are created in the order they are generated. When an error is returned,
+// the files written so far are incomplete output.
+func (g *Generator) GenerateTo(runtimeAPI *api.API, prototypeAPI *api.API, create FileCreator) error {
+	// Migrations and scenarios run in the runtime stage, where the data stage's
+	// globals don't exist.
+	if g.options.Context == ContextMigrations || g.options.Context == ContextScenario {
+		prototypeAPI = nil
+	}
+	headers, err := g.stageHeaders(runtimeAPI, prototypeAPI)
+	if err != nil {
+		return err
+	}
+	runtimeAPI = g.transformAPI(g.filterAPI(runtimeAPI))
+	prototypeAPI = g.transformAPI(g.filterAPI(prototypeAPI))
+	g.renderer = newDescriptionRenderer(runtimeAPI, prototypeAPI)
+	g.eventOverloads = append(eventOverloads(runtimeAPI), g.customEventOverloads()...)
+	g.remoteOverloads = g.remoteCallOverloads()
+	g.templateErr = nil
+	g.collisions = nil
+	g.paramShapes = make(map[string]string)
+	g.idNames = prototypeIDNames(prototypeAPI, g.options.DataRawNames)
+	g.localeSections = prototypeLocaleSections(prototypeAPI)
+	g.typeReport = TypeReport{Warnings: []TypeWarning{}}
+
+	write := func(filename string, content string) error {
+		// A template failing leaves its definitions incomplete.
+		if g.templateErr != nil {
+			return g.templateErr
+		}
+		content = g.rewriteOutput(filename, content)
+		// An escaping bug in a description or a hook breaking the output is caught
+		// here rather than as an error in the users' editors. Rockspecs are Lua too.
+		if strings.HasSuffix(filename, ".lua") || strings.HasSuffix(filename, ".rockspec") {
+			if err := luasyntax.Check(content); err != nil {
+				return fmt.Errorf("generated %s is not valid Lua: %w", filename, err)
+			}
+		}
+		w, err := create(filename)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, content); err != nil {
+			w.Close()
+			return err
+		}
+		return w.Close()
+	}
+	writeAll := func(files map[string]string) error {
+		for _, filename := range sortedKeys(files) {
+			if err := write(filename, files[filename]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if slices.Contains(g.options.Formats, FormatLuaLS) || slices.Contains(g.options.Formats, FormatLuaLSAddon) || slices.Contains(g.options.Formats, FormatLuaRocks) {
+		// Each LuaLS file is written to every layout requested. The addon and the
+		// rock share library/.
+		writeLuaLS := func(filename string, content string) error {
+			if g.options.StageGlobals {
+				filename = stagePath(filename)
+			}
+			if slices.Contains(g.options.Formats, FormatLuaLS) {
+				if err := write(filename, content); err != nil {
+					return err
+				}
+			}
+			if slices.Contains(g.options.Formats, FormatLuaLSAddon) || slices.Contains(g.options.Formats, FormatLuaRocks) {
+				return write(luaLSAddonLibrary+filename, content)
+			}
+			return nil
+		}
+		emit := writeLuaLS
+		var unprefixed map[string]string
+		if g.options.TypePrefix != "" {
+			unprefixed = make(map[string]string)
+			emit = func(filename string, content string) error {
+				unprefixed[filename] = content
+				return nil
+			}
+		}
+		if slices.Contains(g.options.Formats, FormatLuaLSAddon) {
+			config, err := luaLSAddonConfigFile()
+			if err != nil {
+				return fmt.Errorf("failed to encode the addon config: %w", err)
+			}
+			if err := write("config.json", config); err != nil {
+				return err
+			}
+		}
+
+		if slices.Contains(g.options.Formats, FormatLuaRocks) {
+			if err := write(g.luaRocksRockspec(runtimeAPI, prototypeAPI)); err != nil {
+				return err
+			}
+		}
+
+		// The plugin goes at the root of the output, which keeps it out of the
+		// addon's library/ since it isn't a definition file.
+		if g.options.RequirePlugin {
+			if err := write(LuaLSPluginFile, luaLSPlugin); err != nil {
+				return err
+			}
+		}
 
+		defs := newDefinitionSet(g.options.SplitFiles, g.metaPreamble(), headers, emit)
+		g.resolveCollisions(runtimeAPI, prototypeAPI)
+		g.types = newTypeLog(runtimeAPI, prototypeAPI)
+		for _, table := range modSettingTables {
+			g.types.known[table.className] = true
+		}
+		for _, event := range g.options.CustomEvents {
+			g.types.known["CustomEvent."+event.Name] = true
+			g.types.known["CustomEventData."+event.Name] = true
+		}
+		g.renderer.prototypeRenames = g.prototypeRenames
+		// Either stage may be left out (see --only), in which case its API is nil.
+		var runtimeDefines map[string]bool
+		if runtimeAPI != nil {
+			runtimeDefines = g.generateRuntime(defs, g.withModSettingTables(runtimeAPI))
+			if err := defs.finish(); err != nil {
+				return err
+			}
+		}
+		if prototypeAPI != nil {
+			if err := g.generatePrototype(defs, prototypeAPI, runtimeDefines); err != nil {
+				return err
+			}
+			if err := defs.finish(); err != nil {
+				return err
+			}
+		}
+		if g.templateErr != nil {
+			return g.templateErr
+		}
+
+		// --- Builtin types ---
+		// Shared by both stages, so they live in their own file to avoid duplicate aliases.
+		if err := emit("builtin.lua", g.generateBuiltinAliases(runtimeAPI, prototypeAPI, headers["builtin"])); err != nil {
+			return err
+		}
+		// The other formats may use LuaLS types too (Markdown does), which aren't
+		// part of the report.
+		g.typeReport = g.types.report()
+		g.types = nil
+		if unprefixed != nil {
+			prefixTypeNames(unprefixed, g.options.TypePrefix)
+			for _, filename := range sortedKeys(unprefixed) {
+				if err := writeLuaLS(filename, unprefixed[filename]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if slices.Contains(g.options.Formats, FormatTeal) {
+		if err := writeAll(g.generateTeal(runtimeAPI, prototypeAPI, headers)); err != nil {
+			return err
+		}
+	}
+	if slices.Contains(g.options.Formats, FormatTypeScript) {
+		if err := writeAll(g.generateTypeScript(runtimeAPI, prototypeAPI, headers)); err != nil {
+			return err
+		}
+	}
+	if slices.Contains(g.options.Formats, FormatStubs) {
+		if err := writeAll(g.generateStubs(runtimeAPI, prototypeAPI, headers)); err != nil {
+			return err
+		}
+	}
+	if slices.Contains(g.options.Formats, FormatIR) {
+		ir, err := g.generateIR(runtimeAPI, prototypeAPI)
+		if err != nil {
+			return fmt.Errorf("failed to encode the IR: %w", err)
+		}
+		if err := writeAll(ir); err != nil {
+			return err
+		}
+	}
+	if slices.Contains(g.options.Formats, FormatMarkdown) {
+		if err := writeAll(g.generateMarkdown(runtimeAPI, prototypeAPI)); err != nil {
+			return err
+		}
+	}
+	if slices.Contains(g.options.Formats, FormatHTML) {
+		site, err := g.generateHTML(runtimeAPI, prototypeAPI)
+		if err != nil {
+			return fmt.Errorf("failed to generate the HTML site: %w", err)
+		}
+		if err := writeAll(site); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeDefinesRoot declares the global `defines` table. Every define hangs off it,
+// so it must exist before the first one.
+func writeDefinesRoot(sb *strings.Builder) {
+	sb.WriteString("---Constants used throughout the API, such as `defines.direction.north`.\n")
+	sb.WriteString("---@class defines\n")
+	sb.WriteString("defines = {}\n\n")
+}
+
+// generateRuntime generates the runtime stage definitions and returns the names
+// of the defines it emitted.
+func (g *Generator) generateRuntime(defs *definitionSet, runtimeAPI *api.API) map[string]bool {
+	// --- Runtime API ---
 	// Generate Defines
 	// Factorio defines are often nested, so we need a recursive approach.
-	runtimeSB.WriteString("-- Defines\n\n")
+	runtimeSB := defs.section("runtime", "Defines", "defines.lua")
+	writeDefinesRoot(runtimeSB)
+	runtimeDefines := make(map[string]bool)
 	// Iterate over the slice and pass the Define struct directly
-	for _, define := range runtimeAPI.Defines {
-		g.generateDefine(&runtimeSB, define, "") // Pass the struct, start recursion with empty prefix
+	for _, define := range sortedByOrder(runtimeAPI.Defines) {
+		runtimeDefines[define.Name] = true
+		g.generateDefine(runtimeSB, define, "defines.") // Pass the struct, start recursion under the root table
 		runtimeSB.WriteString("\n")
 	}
 
 	// Generate Concepts (Runtime)
-	runtimeSB.WriteString("-- Concepts (Runtime)\n\n")
+	runtimeSB = defs.section("runtime", "Concepts (Runtime)", "concepts.lua")
 	// Iterate over the slice and pass the Concept struct directly
-	for _, concept := range runtimeAPI.Concepts {
+	for _, concept := range sortedByOrder(runtimeAPI.Concepts) {
+		// Builtin types are emitted as aliases in builtin.lua.
+		if isBuiltinConcept(concept) {
+			continue
+		}
 		// Concepts can be aliases or complex types, need to handle based on Category and Type structure
-		runtimeSB.WriteString(g.generateConcept(concept)) // Pass the struct
+		runtimeSB.WriteString(g.generateConcept(g.withPrototypeNames(concept))) // Pass the struct
 		runtimeSB.WriteString("\n")
 	}
 
+	if len(g.options.ModSettings) > 0 {
+		defs.section("runtime", "Mod Settings", "mod-settings.lua").WriteString(g.generateModSettingTables())
+	}
+
 	// Generate Classes
-	runtimeSB.WriteString("-- Classes\n\n")
 	// Iterate over the slice and pass the Class struct directly
-	for _, class := range runtimeAPI.Classes {
-		runtimeSB.WriteString(g.generateClass(class)) // Pass the struct
+	// Classes don't depend on each other, so they are generated concurrently.
+	classes := sortedByOrder(runtimeAPI.Classes)
+	classOutputs := g.generateConcurrently(len(classes), func(w *Generator, i int) string {
+		return w.generateClass(classes[i])
+	})
+	for i, class := range classes {
+		runtimeSB = defs.section("runtime", "Classes", "classes/"+class.Name+".lua")
+		runtimeSB.WriteString(classOutputs[i])
 		runtimeSB.WriteString("\n")
+		if class.Name == "LuaRemote" {
+			runtimeSB.WriteString(remoteInterfacesClass)
+			runtimeSB.WriteString(g.generateRemoteInterfaceFields())
+			runtimeSB.WriteString("\n")
+		}
 	}
 
 	// Generate Global Objects
-	runtimeSB.WriteString("-- Global Objects\n\n")
-	// Iterate over the slice and pass the GlobalObject struct directly
-	for _, global := range runtimeAPI.GlobalObjects {
-		runtimeSB.WriteString(g.generateGlobalObject(global)) // Pass the struct
+	// Migration and scenario scripts get theirs in a file of their own.
+	switch g.options.Context {
+	case ContextMigrations:
+		defs.file(MigrationsFile, defs.headers["runtime"]).WriteString(g.generateMigrationGlobals(runtimeAPI))
+	case ContextScenario:
+		defs.file(ScenarioFile, defs.headers["runtime"]).WriteString(g.generateScenarioGlobals(runtimeAPI))
+	default:
+		if g.options.StageGlobals {
+			runtimeSB = defs.file(ControlStageDir+"/"+stageGlobalsFile, defs.headers["runtime"])
+			runtimeSB.WriteString("-- Control stage (control.lua and the files it requires)\n\n")
+		} else {
+			runtimeSB = defs.section("runtime", "Global Objects", "globals.lua")
+		}
+		for _, global := range runtimeGlobals(runtimeAPI) {
+			runtimeSB.WriteString(g.generateGlobalObject(global)) // Pass the struct
+			runtimeSB.WriteString("\n")
+		}
+		runtimeSB.WriteString(g.persistentDataGlobals(runtimeAPI))
 		runtimeSB.WriteString("\n")
 	}
 
 	// Generate Events
 	// Events are typically handled by defining types for event data payloads
 	// and potentially documenting the script.on_event function.
-	runtimeSB.WriteString("-- Events\n\n")
-	runtimeSB.WriteString("---@class EventData\n") // Base class for all event data
-	runtimeSB.WriteString("EventData = {}\n\n")
+	runtimeSB = defs.section("runtime", "Events", "events.lua")
+	// Base class for all event data. Newer APIs document it as a concept, which then
+	// replaces this fallback.
+	if slices.ContainsFunc(runtimeAPI.Concepts, func(c api.Concept) bool { return c.Name == "EventData" }) {
+		g.addCollision(Collision{Name: "EventData", Kept: "runtime concept", Other: "event data base class", Resolution: CollisionMerged})
+	} else {
+		runtimeSB.WriteString(g.generateStructClass(eventDataConcept.Name, eventDataConcept, eventDataConcept.Type))
+		runtimeSB.WriteString("EventData = {}\n\n")
+	}
 
 	// Iterate over the slice and pass the Event struct directly
-	for _, event := range runtimeAPI.Events {
+	for _, event := range sortedByOrder(runtimeAPI.Events) {
 		runtimeSB.WriteString(g.generateEventDataClass(event)) // Pass the struct
 		runtimeSB.WriteString("\n")
 	}
+	runtimeSB.WriteString(g.generateCustomEvents())
 
 	// You might also want to document script.on_event with overloads
 	// for better type checking when registering handlers. This is more complex
 	// and depends on LuaLS capabilities for function overloads with specific
 	// literal string arguments. For now, we focus on the data types.
 
-	definitions["runtime.lua"] = runtimeSB.String()
+	return runtimeDefines
+}
+
+// generatePrototype generates the prototype stage definitions. Defines already
+// emitted by the runtime stage (runtimeDefines, nil when it wasn't generated) are
+// skipped, since both APIs document the same defines. It fails if two prototype
+// types can't be given distinct class names.
+func (g *Generator) generatePrototype(defs *definitionSet, prototypeAPI *api.API, runtimeDefines map[string]bool) error {
+	// Prototype concepts colliding with runtime ones are declared under their new
+	// names, which every reference of this stage uses.
+	g.renames = g.prototypeRenames
+	defer func() { g.renames = nil }()
 
 	// --- Prototype API ---
 	// The Prototype API structure might be slightly different, requiring
 	// separate parsing and generation logic. Assuming a similar top-level
 	// structure for now, but you might need a separate api.PrototypeAPI struct.
-	var prototypeSB strings.Builder
-	prototypeSB.WriteString("---@meta\n\n")
-	prototypeSB.WriteString("-- Auto-generated Factorio Prototype API definitions\n")
-	prototypeSB.WriteString("-- Generated from: https://lua-api.factorio.com/latest/prototype-api.json\n\n")
-
 	// Prototypes API also has Concepts and Defines, potentially with different content
 	// Generate Defines (Prototype)
-	prototypeSB.WriteString("-- Defines (Prototype)\n\n")
+	prototypeSB := defs.section("prototype", "Defines (Prototype)", "defines.lua")
+	if runtimeDefines == nil && len(prototypeAPI.Defines) > 0 {
+		writeDefinesRoot(prototypeSB)
+	}
 	// Assuming prototypeAPI has a Defines field like runtimeAPI
 	if prototypeAPI.Defines != nil {
-		// Iterate over the slice and pass the Define struct directly
-		for _, define := range prototypeAPI.Defines {
-			g.generateDefine(&prototypeSB, define, "") // Pass the struct
+		// Iterate over the slice and pass the Define struct directly. Both APIs document
+		// the same defines, so only those missing from the runtime stage are emitted here.
+		for _, define := range sortedByOrder(prototypeAPI.Defines) {
+			if runtimeDefines[define.Name] {
+				continue
+			}
+			g.generateDefine(prototypeSB, define, "defines.") // Pass the struct
 			prototypeSB.WriteString("\n")
 		}
 	}
 
 	// Generate Concepts (Prototype)
-	prototypeSB.WriteString("-- Concepts (Prototype)\n\n")
-	// Assuming prototypeAPI has a Concepts field
-	if prototypeAPI.Concepts != nil {
+	prototypeSB = defs.section("prototype", "Concepts (Prototype)", "concepts.lua")
+	// The prototype API documents its concepts under "types"; Concepts is kept for
+	// older formats. Without them, references such as AmbientSoundType would dangle.
+	prototypeConcepts := append(slices.Clone(prototypeAPI.Concepts), prototypeAPI.Types...)
+	if len(prototypeConcepts) > 0 {
 		// Iterate over the slice and pass the Concept struct directly
-		for _, concept := range prototypeAPI.Concepts {
-			prototypeSB.WriteString(g.generateConcept(concept)) // Pass the struct
+		for _, concept := range sortedByOrder(prototypeConcepts) {
+			if isBuiltinConcept(concept) || g.mergedConcepts[concept.Name] {
+				continue
+			}
+			prototypeSB.WriteString(g.generateConcept(g.renamedPrototypeConcept(g.withPrototypeNames(concept)))) // Pass the struct
 			prototypeSB.WriteString("\n")
 		}
 	}
@@ -117,95 +649,408 @@ func (g *Generator) GenerateDefinitions(runtimeAPI *api.API, prototypeAPI *api.A
 	// Generate Prototypes
 	// Prototypes themselves are definitions, not runtime objects.
 	// You might define types representing each prototype type (e.g., "item", "recipe").
-	prototypeSB.WriteString("-- Prototypes\n\n")
+	prototypeSB = defs.section("prototype", "Prototypes", "prototypes.lua")
 	// Assuming prototypeAPI has a Prototypes field
 	if prototypeAPI.Prototypes != nil {
-		// First, define a base class for all prototypes
-		prototypeSB.WriteString("---@class Prototype\n")
-		prototypeSB.WriteString("Prototype = {}\n\n")
+		// First, define a base class for all prototypes, unless the API documents it
+		// (as the abstract Prototype, itself deriving from PrototypeBase).
+		if !slices.ContainsFunc(prototypeAPI.Prototypes, func(p api.Prototype) bool { return p.Name == "Prototype" }) {
+			prototypeSB.WriteString("---@class Prototype\n")
+			prototypeSB.WriteString("Prototype = {}\n\n")
+		}
 
 		// Then, define a class for each specific prototype type (e.g., ItemPrototype, RecipePrototype)
 		// and a class for each individual prototype instance (e.g., data.raw.item.iron_plate)
 		// This requires iterating through prototypes and grouping them by typename.
-		prototypesByTypeName := make(map[string]map[string]api.Prototype)
-		for _, prototype := range prototypeAPI.Prototypes { // Iterate over the slice
-			if prototypesByTypeName[prototype.TypeName] == nil {
-				prototypesByTypeName[prototype.TypeName] = make(map[string]api.Prototype)
+		// Prototypes are sorted before grouping so each group keeps documentation order,
+		// and the groups themselves are emitted in typename order for stable output.
+		prototypesByTypeName := make(map[string][]api.Prototype)
+		for _, prototype := range sortedByOrder(prototypeAPI.Prototypes) {
+			if prototype.TypeName == "" {
+				continue
+			}
+			prototypesByTypeName[prototype.TypeName] = append(prototypesByTypeName[prototype.TypeName], prototype)
+		}
+
+		// Type classes are named after their typename ("assembling-machine" gives
+		// AssemblingMachinePrototype). The documented definition names are reserved
+		// first, so a typename whose name is taken by another prototype (the type
+		// "loader" and the abstract LoaderPrototype) falls back to its definition name.
+		namer := newClassNamer(g.options.PrototypeClassPrefix)
+		for _, prototype := range prototypeAPI.Prototypes {
+			namer.reserve(prototype.Name, cmp.Or(prototype.TypeName, prototype.Name))
+		}
+		for _, setting := range settingPrototypes {
+			namer.reserve(setting.className, setting.typeName)
+		}
+		typeClassNames := make(map[string]string)
+		for _, typeName := range sortedKeys(prototypesByTypeName) {
+			className, err := namer.name(typeName, pascalCase(typeName)+"Prototype", prototypesByTypeName[typeName][0].Name)
+			if err != nil {
+				return err
+			}
+			typeClassNames[typeName] = className
+		}
+
+		// Every prototype derives from its documented parent, so inherited properties
+		// are declared once, on the class that introduces them. Concrete prototypes
+		// are generated under their type class name, which parents must then use.
+		// A definition named like a runtime concept or class (MapSettings) goes by its
+		// type class name either way.
+		classNames := make(map[string]string)
+		for _, prototype := range prototypeAPI.Prototypes {
+			classNames[prototype.Name] = prototype.Name
+			if prototype.TypeName == "" {
+				continue
+			}
+			if g.options.PrototypeClasses == PrototypeClassesByType {
+				classNames[prototype.Name] = typeClassNames[prototype.TypeName]
+			} else if g.runtimeNames[prototype.Name] {
+				classNames[prototype.Name] = typeClassNames[prototype.TypeName]
+				g.addCollision(Collision{Name: prototype.Name, Kept: "runtime definition", Other: "prototype", Resolution: CollisionRenamed, NewName: typeClassNames[prototype.TypeName]})
+			}
+		}
+		parentClass := func(prototype api.Prototype) string {
+			if name, ok := classNames[prototype.Parent]; ok {
+				return name
+			}
+			return prototype.Parent // Empty for roots; a filtered-out parent keeps its name
+		}
+
+		// Abstract prototypes have no typename and never appear in data.raw; they are
+		// emitted once here, as the bases of the concrete prototype classes.
+		for _, prototype := range sortedByOrder(prototypeAPI.Prototypes) {
+			if prototype.TypeName == "" {
+				prototypeSB.WriteString(g.generatePrototypeClass(prototype.Name, parentClass(prototype), prototype))
+				prototypeSB.WriteString("\n")
 			}
-			prototypesByTypeName[prototype.TypeName][prototype.Name] = prototype // Use prototype.Name as key
 		}
 
-		for typeName, prototypes := range prototypesByTypeName {
+		// Like runtime classes, the classes of each type are generated concurrently.
+		typeNames := sortedKeys(prototypesByTypeName)
+		typeOutputs := g.generateConcurrently(len(typeNames), func(w *Generator, i int) string {
+			typeName := typeNames[i]
+			prototypes := prototypesByTypeName[typeName]
 			// Define a class for the type name (e.g., ItemPrototype)
-			typeClassName := strings.Title(typeName) + "Prototype" // Capitalize first letter
-			// Pass the map of prototypes for this type, not an individual prototype
-			prototypeSB.WriteString(g.generatePrototypeTypeClass(typeClassName, typeName, prototypes))
+			typeClassName := typeClassNames[typeName]
+			// Typenames map to a single definition, whose parent the type class takes.
+			parent := parentClass(prototypes[0])
+			if g.options.PrototypeClasses == PrototypeClassesByDefinition && classNames[prototypes[0].Name] != typeClassName {
+				return w.generatePrototypeDefinitionClasses(typeClassName, parent, typeName, prototypes)
+			}
+			// Pass the prototypes for this type, not an individual prototype
+			return w.generatePrototypeTypeClass(typeClassName, parent, typeName, prototypes)
+		})
+		// data.raw gets one field per type, declared once all type classes are known,
+		// and data.PrototypeUnion one member per type class.
+		var rawFields strings.Builder
+		var unionMembers []string
+		for i, typeName := range typeNames {
+			typeClassName := typeClassNames[typeName]
+			prototypeSB = defs.section("prototype", "Prototypes", "prototypes/"+typeName+".lua")
+			prototypeSB.WriteString(typeOutputs[i])
 			prototypeSB.WriteString("\n")
+			unionMembers = append(unionMembers, typeClassName)
+
+			// Type names such as "assembling-machine" aren't identifiers, so they are quoted.
+			rawType := fmt.Sprintf("table<string, %s>", typeClassName)
+			if names := g.options.DataRawNames[typeName]; len(names) > 0 {
+				// Known prototype names become fields of a class for the type's table,
+				// giving completion for data.raw.item["iron-plate"] and the like.
+				rawType = "data.raw." + strings.ReplaceAll(typeName, "-", "_")
+				prototypeSB.WriteString(fmt.Sprintf("---@class %s\n", rawType))
+				for _, name := range names {
+					// The localised name shows when hovering data.raw.item["iron-plate"].
+					localised := g.inlineDescription(g.localisedName(g.localeSections[typeName], name))
+					prototypeSB.WriteString(fieldAnnotation(luaFieldName(name), typeClassName, localised) + "\n")
+				}
+				if g.options.Dialect != DialectEmmyLua {
+					prototypeSB.WriteString(fmt.Sprintf("---@field [string] %s\n", typeClassName))
+				}
+				prototypeSB.WriteString("\n")
+			}
+			rawFields.WriteString(fmt.Sprintf("---@field %s %s Table of %s prototypes by name.\n", luaFieldName(typeName), rawType, typeName))
+		}
 
-			// Define a global table for data.raw.<typename>
-			prototypeSB.WriteString(fmt.Sprintf("---@type table<string, %s> Table of %s prototypes by name.\n", typeClassName, typeName))
-			prototypeSB.WriteString(fmt.Sprintf("data.raw.%s = {}\n\n", typeName))
+		settingClasses, settingRawFields := g.generateSettingPrototypes()
+		defs.section("prototype", "Prototypes", "settings.lua").WriteString(settingClasses)
+		rawFields.WriteString(settingRawFields)
+		for _, setting := range settingPrototypes {
+			unionMembers = append(unionMembers, setting.className)
+		}
 
-			// Optionally, define individual fields on data.raw.<typename> for specific prototypes
-			// This can make the definition file very large, but provides direct autocompletion
-			// for known prototype names (e.g., data.raw.item.iron_plate).
-			// for protoName, prototype := range prototypes { // Iterate over the map
-			// 	prototypeSB.WriteString(fmt.Sprintf("---@field %s %s %s\n", protoName, typeClassName, prototype.Description))
-			// }
-			// prototypeSB.WriteString(fmt.Sprintf("data.raw.%s = {}\n\n", typeName)) // Redefine the table with fields
+		// Newer APIs document FeatureFlags as a type, which replaces the fallback class.
+		documentedFlags := slices.ContainsFunc(prototypeConcepts, func(c api.Concept) bool { return c.Name == "FeatureFlags" })
+		if documentedFlags {
+			g.addCollision(Collision{Name: "FeatureFlags", Kept: "prototype concept", Other: "feature_flags class", Resolution: CollisionMerged})
+		}
+		var stageGlobals *strings.Builder
+		if g.options.StageGlobals {
+			stageGlobals = &strings.Builder{}
+		}
+		defs.section("prototype", "Prototypes", "data.lua").WriteString(generateDataStageGlobals(rawFields.String(), unionMembers, documentedFlags, stageGlobals))
+		if stageGlobals != nil {
+			g.generateStageGlobals(defs, stageGlobals.String(), runtimeDefines != nil)
 		}
 	}
+	return nil
+}
 
-	definitions["prototype.lua"] = prototypeSB.String()
+// featureFlags are the flags of the `feature_flags` global, each enabled by a mod
+// (such as Space Age) declaring it in its info.json.
+var featureFlags = []string{
+	"quality", "rail_bridges", "space_travel", "spoiling", "freezing", "segmented_units", "expansion_shaders",
+}
 
-	return definitions, nil
+// generateDataStageGlobals generates the globals of the data stage: the `data`
+// table with its typed `raw` field and extend method, `mods`, and `feature_flags`.
+// rawFields holds the `---@field` lines of data.raw, one per prototype type, and
+// unionMembers the classes of data.PrototypeUnion. Every class carries a literal
+// `type` field, so LuaLS narrows each table passed to data:extend by its type.
+// documentedFlags leaves out the FeatureFlags class, for APIs documenting it.
+//
+// Unless globals is nil, the globals (data with its class, extend method, mods
+// and feature_flags) are written to it instead, for a file of their own (see
+// Options.StageGlobals).
+func generateDataStageGlobals(rawFields string, unionMembers []string, documentedFlags bool, globals *strings.Builder) string {
+	var sb strings.Builder
+	if globals == nil {
+		globals = &sb
+	}
+	sb.WriteString("-- Data stage\n\n")
+
+	sb.WriteString("---Every prototype defined so far, indexed by type and then by name.\n")
+	sb.WriteString("---@class data.raw\n")
+	sb.WriteString(rawFields)
+	sb.WriteString("\n")
+
+	globals.WriteString("---The table prototypes are defined in, available in the data stage (data.lua, data-updates.lua and data-final-fixes.lua).\n")
+	globals.WriteString("---@class data\n")
+	globals.WriteString("---@field raw data.raw Every prototype defined so far, indexed by type and then by name.\n")
+	globals.WriteString("---@field is_demo boolean Whether the game is the demo version.\n")
+	globals.WriteString("data = {}\n\n")
+
+	sb.WriteString("---Any prototype definition, discriminated by its `type` field.\n")
+	sb.WriteString("---@alias data.PrototypeUnion\n")
+	for _, member := range unionMembers {
+		sb.WriteString(fmt.Sprintf("---| %s\n", member))
+	}
+	sb.WriteString("\n")
+
+	globals.WriteString("---Adds the given prototypes to data.raw, replacing any prototype of the same type and name.\n")
+	globals.WriteString("---@param prototypes data.PrototypeUnion[]\n")
+	globals.WriteString("function data:extend(prototypes) end\n\n")
+
+	globals.WriteString("---The active mods, mapped to their version.\n")
+	globals.WriteString("---@type table<string, string>\n")
+	globals.WriteString("mods = {}\n\n")
+
+	if !documentedFlags {
+		sb.WriteString("---@class FeatureFlags\n")
+		for _, flag := range featureFlags {
+			sb.WriteString(fmt.Sprintf("---@field %s boolean\n", luaFieldName(flag)))
+		}
+		sb.WriteString("\n")
+	}
+	globals.WriteString("---The feature flags enabled by the active mods.\n")
+	globals.WriteString("---@type FeatureFlags\n")
+	globals.WriteString("feature_flags = {}\n")
+	return sb.String()
 }
 
 // generateDefine recursively generates LuaLS annotations for Defines.
-// Now accepts the Define struct directly.
+// Defines whose values all carry a concrete value are emitted as `---@enum` tables
+// with the literal values, so LuaLS can validate uses such as defines.direction.north.
+// When the JSON omits the values, the define falls back to a class whose fields are
+// typed as the define itself, which keeps the values opaque but still distinct.
+// defines.events is special-cased so that every event id is its own type.
 func (g *Generator) generateDefine(sb *strings.Builder, define api.Define, prefix string) {
 	fullName := prefix + define.Name // Use the Name field from the struct
-	sb.WriteString(fmt.Sprintf("---@class %s %s\n", fullName, define.Description))
-	sb.WriteString(fmt.Sprintf("%s = {}\n", fullName))
+	var own strings.Builder
+	g.writeDefine(&own, define, fullName)
+	sb.WriteString(g.override("define", DefineTemplateData{FullName: fullName, Define: define, Default: own.String()}, own.String()))
 
-	// Generate values (enum fields)
+	// defines.prototypes declares its subkeys as fields (see writeDefine).
+	if fullName == "defines.prototypes" {
+		return
+	}
+	// Recurse into subkeys (nested defines)
 	// Iterate over the slice
-	for _, value := range define.Values {
-		// LuaLS often represents enum values as fields on the enum table
-		// The type might be inferred or explicitly set if known (e.g., number, string)
-		valType := "any" // Default type
-		if value.Value != nil {
-			// Attempt to infer type from value.Value
-			switch value.Value.(type) {
-			case int, float64:
-				valType = "number"
-			case string:
-				valType = "string"
-			case bool:
-				valType = "boolean"
-				// Add other types as needed
+	for _, subDefine := range sortedByOrder(define.Subkeys) {
+		g.generateDefine(sb, subDefine, fullName+".") // Pass the subDefine struct
+	}
+}
+
+// writeDefine writes the annotations of a single define, without its subkeys.
+func (g *Generator) writeDefine(sb *strings.Builder, define api.Define, fullName string) {
+	values := sortedByOrder(define.Values)
+
+	if fullName == "defines.prototypes" {
+		// defines.prototypes maps each top-level prototype type to its subtypes, all with
+		// the value 0. Subtype names contain dashes, so each type is keyed by a union of
+		// its subtype names rather than declaring one field per subtype.
+		g.writeDocComment(sb, "", define.Description)
+		sb.WriteString(fmt.Sprintf("---@class %s\n", fullName))
+		for _, subDefine := range sortedByOrder(define.Subkeys) {
+			var subtypes []string
+			for _, value := range sortedByOrder(subDefine.Values) {
+				subtypes = append(subtypes, luaString(value.Name))
 			}
+			sb.WriteString(fmt.Sprintf("---@field %s table<%s, 0>\n", luaFieldName(subDefine.Name), joinUnion(subtypes...)))
 		}
-		sb.WriteString(fmt.Sprintf("---@field %s %s %s\n", value.Name, valType, value.Description)) // Use value.Name
+		sb.WriteString(fmt.Sprintf("%s = {}\n", fullName))
+		return
 	}
 
-	// Recurse into subkeys (nested defines)
-	// Iterate over the slice
-	for _, subDefine := range define.Subkeys {
-		g.generateDefine(sb, subDefine, fullName+".") // Pass the subDefine struct
+	if fullName == "defines.events" {
+		// Event ids get one distinct type per event (e.g. `events.on_tick`), all deriving
+		// from the define itself. Handler signatures can then be narrowed on the id, and
+		// passing an unrelated define value (such as a direction) is flagged.
+		g.writeDocComment(sb, "", define.Description)
+		sb.WriteString(fmt.Sprintf("---@class %s\n", fullName))
+		for _, value := range values {
+			sb.WriteString(fieldAnnotation(luaFieldName(value.Name), fullName+"."+value.Name, g.inlineDescription(value.Description)) + "\n")
+		}
+		sb.WriteString(fmt.Sprintf("%s = {}\n", fullName))
+		for _, value := range values {
+			sb.WriteString(fmt.Sprintf("---@class %s.%s : %s\n", fullName, value.Name, fullName))
+		}
+	} else if len(values) > 0 && defineHasLiteralValues(values) && g.options.Dialect != DialectEmmyLua {
+		g.writeDocComment(sb, "", define.Description)
+		sb.WriteString(fmt.Sprintf("---@enum %s\n", fullName))
+		sb.WriteString(fmt.Sprintf("%s = {\n", fullName))
+		for _, value := range values {
+			g.writeDocComment(sb, "\t", g.withLocalisedName(fullName, value))
+			if value.Deprecated {
+				sb.WriteString("\t---@deprecated\n")
+			}
+			sb.WriteString(fmt.Sprintf("\t%s = %s,\n", luaFieldName(value.Name), defineLiteral(value.Value)))
+		}
+		sb.WriteString("}\n")
+	} else {
+		g.writeDocComment(sb, "", define.Description)
+		sb.WriteString(fmt.Sprintf("---@class %s\n", fullName))
+		// Opaque enum fields: the runtime value is unknown, but typing each field as
+		// the define itself still lets parameters typed as the define reject other values.
+		var deprecatedValues []api.DefineValue
+		for _, value := range values {
+			if value.Deprecated {
+				deprecatedValues = append(deprecatedValues, value)
+				continue
+			}
+			sb.WriteString(fieldAnnotation(luaFieldName(value.Name), fullName, g.inlineDescription(g.withLocalisedName(fullName, value))) + "\n")
+		}
+		sb.WriteString(fmt.Sprintf("%s = {}\n", fullName))
+		// Deprecated values are assigned after the table, where @deprecated can apply to them.
+		for _, value := range deprecatedValues {
+			g.writeDocComment(sb, "", g.withLocalisedName(fullName, value))
+			sb.WriteString("---@deprecated\n")
+			sb.WriteString(fmt.Sprintf("---@type %s\n", fullName))
+			sb.WriteString(fmt.Sprintf("%s = nil\n", luaIndex(fullName, value.Name)))
+		}
+	}
+}
+
+// defineHasLiteralValues reports whether every define value has a value that can be
+// written as a Lua literal. A partially valued define is treated as opaque.
+func defineHasLiteralValues(values []api.DefineValue) bool {
+	for _, value := range values {
+		switch value.Value.(type) {
+		case int, float64, string, bool:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// defineLiteral formats a define value as a Lua literal.
+func defineLiteral(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	case string:
+		return luaString(v)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return "nil"
+	}
+}
+
+// writeDocComment writes a (possibly multi-line) description as `---` doc comment
+// lines, so that newlines in descriptions don't break out of the annotation block.
+// The indent is prepended to every line, for comments on table constructor fields.
+func (g *Generator) writeDocComment(sb *strings.Builder, indent string, description string) {
+	if description == "" {
+		return
+	}
+	for _, line := range strings.Split(g.renderer.render(description), "\n") {
+		sb.WriteString(indent + "---" + line + "\n")
 	}
 }
 
 // generateConcept generates LuaLS annotations for Concepts.
 // Now accepts the Concept struct directly.
 func (g *Generator) generateConcept(concept api.Concept) string {
+	g.types.at("concept " + concept.Name)
 	var sb strings.Builder
 	// Concepts are often aliases or specific table structures.
 	// If the concept has a complex type defined directly, generate an alias.
 	// If it's just a named concept with a category like "type", it might be
 	// a reference handled by translateFactorioTypeToLuaLS.
-	if concept.Type.IsComplex() || concept.Type.IsSimple() { // Check if the nested Type has definition details
-		sb.WriteString(fmt.Sprintf("---@alias %s %s %s\n", concept.Name, g.translateFactorioTypeToLuaLS(concept.Type), concept.Description)) // Use concept.Name
+	if isStringLiteralUnion(concept.Type) {
+		// Enumerations of strings become an alias listing every valid string, so
+		// completion offers them. Options with descriptions get one line each.
+		g.writeDocComment(&sb, "", concept.Description)
+		g.writeExamples(&sb, concept.Examples)
+		g.writeSeeAlso(&sb, concept.Name, concept.Description)
+		if !hasOptionDescriptions(concept.Type) {
+			sb.WriteString(fmt.Sprintf("---@alias %s %s\n", concept.Name, g.translateFactorioTypeToLuaLS(concept.Type)))
+			return sb.String()
+		}
+		sb.WriteString(fmt.Sprintf("---@alias %s\n", concept.Name))
+		for _, option := range concept.Type.Values {
+			line := "---| " + g.translateFactorioTypeToLuaLS(option)
+			if desc := g.inlineDescription(option.Description); desc != "" {
+				line += " # " + desc
+			}
+			sb.WriteString(line + "\n")
+		}
+	} else if structType, ok := structShape(concept.Type); ok {
+		// Struct-shaped concepts become classes so their fields get completion and
+		// hover docs. When the struct is one form of a union (such as MapPosition's
+		// {x, y} alongside its tuple shorthand), it gets its own class and the
+		// concept becomes an alias over all forms.
+		if !concept.Type.IsUnion() {
+			g.writeDocComment(&sb, "", concept.Description)
+			g.writeExamples(&sb, concept.Examples)
+			g.writeSeeAlso(&sb, concept.Name, concept.Description)
+			sb.WriteString(g.generateStructClass(concept.Name, concept, structType))
+			return sb.String()
+		}
+		className := concept.Name + ".struct"
+		sb.WriteString(g.generateStructClass(className, concept, structType))
+		var options []string
+		for _, option := range concept.Type.Values {
+			if isStructType(option) {
+				options = append(options, className)
+			} else {
+				options = append(options, g.translateFactorioTypeToLuaLS(option))
+			}
+		}
+		sb.WriteString("\n")
+		g.writeDocComment(&sb, "", concept.Description)
+		g.writeExamples(&sb, concept.Examples)
+		g.writeSeeAlso(&sb, concept.Name, concept.Description)
+		sb.WriteString(fmt.Sprintf("---@alias %s %s\n", concept.Name, joinUnion(options...)))
+	} else if concept.Type.IsComplex() || concept.Type.IsSimple() { // Check if the nested Type has definition details
+		g.writeDocComment(&sb, "", concept.Description)
+		g.writeExamples(&sb, concept.Examples)
+		g.writeSeeAlso(&sb, concept.Name, concept.Description)
+		sb.WriteString(fmt.Sprintf("---@alias %s %s\n", concept.Name, g.translateFactorioTypeToLuaLS(concept.Type)))
 	} else {
 		// If the nested type is just a name without complex details here,
 		// it's likely already handled as a direct type reference.
@@ -213,71 +1058,452 @@ func (g *Generator) generateConcept(concept api.Concept) string {
 		// For now, we'll generate an alias if the type has a name, assuming it
 		// refers to a defined type elsewhere.
 		if concept.Type.Name != "" {
-			sb.WriteString(fmt.Sprintf("---@alias %s %s %s\n", concept.Name, concept.Type.Name, concept.Description)) // Use concept.Name
+			g.writeDocComment(&sb, "", concept.Description)
+			sb.WriteString(fmt.Sprintf("---@alias %s %s\n", concept.Name, concept.Type.Name))
 		} else {
 			// If the concept has no type name or complex type, it's hard to define.
 			// Add a comment indicating this.
-			sb.WriteString(fmt.Sprintf("-- Undefined concept: %s %s\n", concept.Name, concept.Description)) // Use concept.Name
+			sb.WriteString(fmt.Sprintf("-- Undefined concept: %s %s\n", concept.Name, g.inlineDescription(concept.Description)))
+		}
+	}
+
+	return sb.String()
+}
+
+// isStringLiteralUnion reports whether a type is a union made up only of string
+// literals, i.e. an enumeration of valid strings.
+func isStringLiteralUnion(t api.Type) bool {
+	if !t.IsUnion() {
+		return false
+	}
+	for _, option := range t.Values {
+		if _, ok := option.LiteralValue.(string); !option.IsLiteral() || !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// isStructType reports whether a type describes a table with named fields: runtime
+// "table" and "LuaStruct" types, and prototype "struct" types.
+func isStructType(t api.Type) bool {
+	return t.ComplexType == "table" || t.ComplexType == "LuaStruct" || t.ComplexType == "struct"
+}
+
+// structShape returns the struct type defining a concept's fields: the concept's
+// own type, or the single struct option of a union.
+func structShape(t api.Type) (api.Type, bool) {
+	if isStructType(t) {
+		return t, true
+	}
+	if !t.IsUnion() {
+		return api.Type{}, false
+	}
+	var shape api.Type
+	count := 0
+	for _, option := range t.Values {
+		if isStructType(option) {
+			shape = option
+			count++
+		}
+	}
+	return shape, count == 1
+}
+
+// generateStructClass generates the class for a struct-shaped concept. Runtime
+// tables reuse the named-argument classes (including variant groups); LuaStructs
+// list their attributes, and prototype structs their properties and parent.
+func (g *Generator) generateStructClass(className string, concept api.Concept, structType api.Type) string {
+	if structType.ComplexType == "table" {
+		// Concepts are always declared in full; parameter classes and inline tables
+		// with the same fields become aliases of them.
+		param := g.generateParamTypes(className, structType.Fields, structType.VariantParameterGroups)
+		if _, ok := g.paramShapes[param.shape]; !ok {
+			g.paramShapes[param.shape] = className
 		}
+		return param.text
 	}
 
+	var sb strings.Builder
+	properties := concept.Properties
+	if structType.ComplexType == "LuaStruct" {
+		properties = structType.Attributes
+	}
+	if len(concept.Parent) > 0 {
+		sb.WriteString(fmt.Sprintf("---@class %s : %s\n", className, strings.Join(concept.Parent, ", ")))
+	} else {
+		sb.WriteString(fmt.Sprintf("---@class %s\n", className))
+	}
+	for _, property := range sortedByOrder(properties) {
+		sb.WriteString(g.generatePropertyAnnotation(property.Name, property) + "\n")
+	}
 	return sb.String()
 }
 
+// hasOptionDescriptions reports whether any option of a union is documented.
+func hasOptionDescriptions(t api.Type) bool {
+	for _, option := range t.Values {
+		if option.Description != "" {
+			return true
+		}
+	}
+	return false
+}
+
 // generateClass generates LuaLS annotations for a Class.
 // Now accepts the Class struct directly.
+// Fields must precede the table declaration to attach to the class, and methods
+// follow it as function stubs so LuaLS sees them as callable members.
 func (g *Generator) generateClass(class api.Class) string {
+	g.types.at("class " + class.Name)
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("---@class %s %s\n", class.Name, class.Description)) // Use class.Name
-	if class.Parent != "" {
-		sb.WriteString(fmt.Sprintf("---@field __parent %s\n", class.Parent)) // Indicate parent class
+	// Parents use LuaLS inheritance syntax so inherited members show up in completion.
+	className := class.Name
+	generic, isGeneric := genericClasses[class.Name]
+	isGeneric = isGeneric && g.options.Dialect != DialectEmmyLua
+	if isGeneric {
+		className = fmt.Sprintf("%s<%s>", class.Name, generic.params)
+	}
+	if len(class.Parent) > 0 {
+		className = fmt.Sprintf("%s : %s", class.Name, strings.Join(class.Parent, ", "))
+	}
+	if class.Deprecated {
+		sb.WriteString("---@deprecated\n")
 	}
-	sb.WriteString(fmt.Sprintf("%s = {}\n", class.Name)) // Use class.Name // Classes are typically represented as tables in Lua
+	g.writeDocComment(&sb, "", class.Description)
+	g.writeExamples(&sb, class.Examples)
+	g.writeSeeAlso(&sb, class.Name, class.Description)
+	sb.WriteString(fmt.Sprintf("---@class %s\n", className))
+	if isGeneric {
+		sb.WriteString(generic.field + "\n")
+	}
+
+	sb.WriteString(g.generateOperatorAnnotations(class))
 
 	// Generate Properties
-	// Iterate over the slice
-	for _, prop := range class.Properties {
+	// The runtime API calls these attributes; the older format used properties.
+	// LuaLS can't deprecate a single @field, so deprecated properties are declared
+	// as assignments on the class table after it instead.
+	properties := append(sortedByOrder(class.Attributes), sortedByOrder(class.Properties)...)
+	var deprecatedProperties []api.Property
+	for _, prop := range properties {
+		if prop.Deprecated {
+			deprecatedProperties = append(deprecatedProperties, prop)
+			continue
+		}
+		g.types.at("class " + class.Name + "." + prop.Name)
+		g.tableName = class.Name + "." + prop.Name + "_table"
 		sb.WriteString(g.generatePropertyAnnotation(prop.Name, prop)) // Use prop.Name
 		sb.WriteString("\n")
 	}
+	sb.WriteString(fmt.Sprintf("%s = {}\n\n", class.Name)) // Classes are typically represented as tables in Lua
+
+	for _, prop := range deprecatedProperties {
+		g.types.at("class " + class.Name + "." + prop.Name)
+		g.tableName = class.Name + "." + prop.Name + "_table"
+		luaLSType, desc := g.propertyTypeAndDescription(prop)
+		g.writeDocComment(&sb, "", desc)
+		sb.WriteString("---@deprecated\n")
+		sb.WriteString(fmt.Sprintf("---@type %s\n", luaLSType))
+		sb.WriteString(fmt.Sprintf("%s.%s = nil\n\n", class.Name, prop.Name))
+	}
+	g.tableName = ""
+
+	// The attributes' inline table types, aliased like parameter classes when
+	// another table has the same fields (logistic_parameters of LuaEntityPrototype
+	// and LuaEquipmentPrototype).
+	for _, table := range g.tables {
+		g.writeDeclaration(&sb, table)
+		sb.WriteString("\n")
+	}
+	g.tables = nil
 
 	// Generate Methods
 	// Iterate over the slice
-	for _, method := range class.Methods {
-		sb.WriteString(g.generateMethodAnnotation(method.Name, method)) // Use method.Name
+	for _, method := range sortedByOrder(class.Methods) {
+		g.types.at("class " + class.Name + "." + method.Name)
+		sb.WriteString(g.generateMethodStub(class.Name, method))
 		sb.WriteString("\n")
 	}
 
+	return g.override("class", ClassTemplateData{Class: class, Default: sb.String()}, sb.String())
+}
+
+// genericClasses lists the classes emitted as LuaLS generics, with their type
+// parameters and the keyed field through which elements are accessed. References
+// to them instantiate the generic (see translateFactorioTypeToLuaLS).
+var genericClasses = map[string]struct{ params, field string }{
+	"LuaCustomTable": {params: "K, V", field: "---@field [K] V"},
+}
+
+// variantDiscriminators are the parameter names that select a variant parameter
+// group, in order of preference. The group names are the values of that parameter.
+var variantDiscriminators = []string{"type", "filter"}
+
+// generateParamTypes generates the types for a table of named arguments. Without
+// variant groups this is a single class. With variant groups, the common parameters
+// go into a `_base` class, each group gets a class deriving from it (with the
+// discriminator field narrowed to the group's literal), and typeName becomes an
+// alias over all variants, so LuaLS can narrow the available fields by discriminator.
+//
+// The returned shape leaves out the descriptions, so tables with the same fields
+// share one declaration (see declareParamClass) however each is documented.
+func (g *Generator) generateParamTypes(typeName string, params []api.Parameter, groups []api.ParameterGroup) paramClass {
+	var text, shape strings.Builder
+	if len(groups) == 0 {
+		g.writeParamClass(&text, &shape, typeName, params)
+		return newParamClass(typeName, text.String(), shape.String())
+	}
+
+	baseName := typeName + "_base"
+	g.writeParamClass(&text, &shape, baseName, params)
+
+	discriminator := ""
+	for _, candidate := range variantDiscriminators {
+		for _, param := range params {
+			if param.Name == candidate {
+				discriminator = candidate
+				break
+			}
+		}
+		if discriminator != "" {
+			break
+		}
+	}
+
+	var variantNames []string
+	for _, group := range sortedByOrder(groups) {
+		variantName := typeName + "." + strings.ReplaceAll(group.Name, "-", "_")
+		variantNames = append(variantNames, variantName)
+
+		text.WriteString("\n")
+		g.writeDocComment(&text, "", group.Description)
+		header := fmt.Sprintf("---@class %s : %s\n", variantName, baseName)
+		// Group names such as "OtherTypes" or "defines.command.attack" aren't string
+		// values of the discriminator, so only plain names narrow the field.
+		if discriminator != "" && !strings.Contains(group.Name, ".") && group.Name != "OtherTypes" {
+			header += fmt.Sprintf("---@field %s %s\n", discriminator, luaString(group.Name))
+		}
+		text.WriteString(header)
+		shape.WriteString(header)
+		for _, param := range sortedByOrder(group.Parameters) {
+			g.writeParamField(&text, &shape, param)
+		}
+	}
+
+	alias := fmt.Sprintf("---@alias %s %s\n", typeName, strings.Join(variantNames, " | "))
+	text.WriteString("\n" + alias)
+	shape.WriteString(alias)
+	return newParamClass(typeName, text.String(), shape.String())
+}
+
+// writeParamClass writes a class describing a table of named arguments, with one
+// field per parameter, and its shape. Optional parameters may be left out of the
+// table, so their fields are annotated as optional.
+func (g *Generator) writeParamClass(text, shape *strings.Builder, paramClassName string, params []api.Parameter) {
+	header := fmt.Sprintf("---@class %s\n", paramClassName)
+	text.WriteString(header)
+	shape.WriteString(header)
+	for _, param := range sortedByOrder(params) {
+		g.writeParamField(text, shape, param)
+	}
+}
+
+// writeParamField writes the field annotation for one named argument, and the
+// field without its description to the shape.
+func (g *Generator) writeParamField(text, shape *strings.Builder, param api.Parameter) {
+	luaLSType := g.translateFactorioTypeToLuaLS(param.Type)
+	if param.Nullable {
+		luaLSType = withNil(luaLSType)
+	}
+	name, luaLSType := g.optionalField(param.Name, luaLSType, param.Optional)
+	text.WriteString(fieldAnnotation(name, luaLSType, g.inlineDescription(param.Description)) + "\n")
+	shape.WriteString(fieldAnnotation(name, luaLSType, "") + "\n")
+}
+
+// methodParamTypes overrides the types of method parameters whose documented type
+// is too narrow to be useful, keyed by "Class.method" and then parameter name.
+var methodParamTypes = map[string]map[string]string{
+	// Documented as functions without arguments, which rejects real interface functions.
+	"LuaRemote.add_interface": {"functions": "table<string, function>"},
+	// Lifecycle handlers get named, precise signatures; unregistering with nil is
+	// covered by the overloads in methodOverloads.
+	"LuaBootstrap.on_init":                  {"handler": "fun()"},
+	"LuaBootstrap.on_load":                  {"handler": "fun()"},
+	"LuaBootstrap.on_configuration_changed": {"handler": "fun(data: ConfigurationChangedData)"},
+	"LuaBootstrap.on_nth_tick":              {"tick": "uint | uint[]", "handler": "fun(event: NthTickEventData)"},
+}
+
+// eventOverloads returns one signature of LuaBootstrap.on_event per event, so the
+// handler is typed with the event's payload and, for events that support filters,
+// the filters are typed with the event's filter concept. Events without an id in
+// defines.events (such as CustomInputEvent) are registered differently and skipped.
+func eventOverloads(runtimeAPI *api.API) []string {
+	if runtimeAPI == nil {
+		return nil
+	}
+	eventIDs := make(map[string]bool)
+	for _, define := range runtimeAPI.Defines {
+		if define.Name != "events" {
+			continue
+		}
+		for _, value := range define.Values {
+			eventIDs[value.Name] = true
+		}
+	}
+
+	var overloads []string
+	for _, event := range sortedByOrder(runtimeAPI.Events) {
+		if !eventIDs[event.Name] {
+			continue
+		}
+		overload := fmt.Sprintf("fun(event: defines.events.%s, handler: fun(event: EventData.%s) | nil", event.Name, event.Name)
+		if event.Filter != "" {
+			overload += fmt.Sprintf(", filters?: %s[]", event.Filter)
+		}
+		overloads = append(overloads, overload+")")
+	}
+	return overloads
+}
+
+// methodOverloads are additional call signatures of methods, keyed by "Class.method".
+var methodOverloads = map[string][]string{
+	"LuaBootstrap.on_init":                  {"fun(handler: nil)"},
+	"LuaBootstrap.on_load":                  {"fun(handler: nil)"},
+	"LuaBootstrap.on_configuration_changed": {"fun(handler: nil)"},
+	// nil as the only argument unregisters every nth-tick handler.
+	"LuaBootstrap.on_nth_tick": {"fun(tick: uint | uint[], handler: nil)", "fun(tick: nil)"},
+	// Custom inputs (keybindings) are registered by the name of their prototype.
+	"LuaBootstrap.on_event": {"fun(event: string | LuaCustomInputPrototype, handler: fun(event: EventData.CustomInputEvent) | nil)"},
+}
+
+// remoteInterfacesClass is the extension point for typing remote interfaces. The
+// generator can't know which interfaces mods provide, so the class is open: users
+// declare one field per interface, and the interfaces found by scanning the mods
+// (Options.RemoteInterfaces) are declared after it.
+const remoteInterfacesClass = `---The remote interfaces known to this workspace, by interface name. Extend it to type
+---the interfaces your mod calls:
+---
+---` + "```lua" + `
+------@class RemoteInterfaces
+------@field my_mod { get_value: fun(name: string): number }
+---` + "```" + `
+---@class RemoteInterfaces
+---@field [string] table<string, function>
+`
+
+// operatorIndexKeys maps classes to the key type of their index operator. The JSON
+// only documents the result type, so classes indexed by something other than an
+// integer are listed here.
+var operatorIndexKeys = map[string]string{
+	"LuaGuiElement": "string",
+}
+
+// generateOperatorAnnotations generates `---@operator` annotations for the
+// operators a class supports, so that `#inventory` and calls on callable objects
+// (e.g. LuaRandomGenerator) type-check. LuaLS has no index operator; bracket
+// indexing resolves through an indexed field instead, so `inventory[1]` is a
+// LuaItemStack.
+func (g *Generator) generateOperatorAnnotations(class api.Class) string {
+	var sb strings.Builder
+	if g.options.Dialect == DialectEmmyLua {
+		return "" // EmmyLua has no @operator, nor indexed fields
+	}
+	for _, operator := range sortedByOrder(class.Operators) {
+		switch operator.Name {
+		case "call":
+			params := sortedByOrder(operator.Parameters)
+			var results []string
+			for _, ret := range sortedByOrder(operator.ReturnValues) {
+				results = append(results, g.translateFactorioTypeToLuaLS(ret.Type))
+			}
+			result := strings.Join(results, ", ")
+			if result == "" {
+				result = "nil"
+			}
+			switch len(params) {
+			case 0:
+				sb.WriteString(fmt.Sprintf("---@operator call: %s\n", result))
+			case 1:
+				sb.WriteString(fmt.Sprintf("---@operator call(%s): %s\n", g.translateFactorioTypeToLuaLS(params[0].Type), result))
+			default:
+				// @operator only takes a single input type, so multi-argument calls are
+				// described with an overload carrying the full signature instead.
+				var args []string
+				for _, param := range params {
+					optional := ""
+					if param.Optional {
+						optional = "?"
+					}
+					args = append(args, fmt.Sprintf("%s%s: %s", luaParamName(param.Name), optional, g.translateFactorioTypeToLuaLS(param.Type)))
+				}
+				sb.WriteString(fmt.Sprintf("---@overload fun(%s): %s\n", strings.Join(args, ", "), result))
+			}
+		case "index":
+			// Generic classes expose their elements through a keyed field instead.
+			if _, isGeneric := genericClasses[class.Name]; isGeneric {
+				continue
+			}
+			keyType, ok := operatorIndexKeys[class.Name]
+			if !ok {
+				keyType = "integer"
+			}
+			valueType := "any"
+			if operator.ReadType != nil {
+				valueType = g.translateFactorioTypeToLuaLS(*operator.ReadType)
+			}
+			if operator.Optional {
+				valueType = withNil(valueType)
+			}
+			// Declared fields take precedence over the indexed one, so LuaGuiElement
+			// children are looked up by name without hiding its properties.
+			sb.WriteString(fmt.Sprintf("---@field [%s] %s\n", keyType, valueType))
+		case "length":
+			lengthType := "integer"
+			if operator.ReadType != nil {
+				lengthType = g.translateFactorioTypeToLuaLS(*operator.ReadType)
+			}
+			sb.WriteString(fmt.Sprintf("---@operator len: %s\n", lengthType))
+		}
+	}
 	return sb.String()
 }
 
 // generatePropertyAnnotation generates the LuaLS annotation for a property.
+// Properties whose type is a function are translated to `fun(...)` types, so
+// callable properties can be invoked with checked arguments.
 func (g *Generator) generatePropertyAnnotation(name string, property api.Property) string {
-	luaLSType := g.translateFactorioTypeToLuaLS(property.Type)
-	// LuaLS handles optionality often within the type string (e.g., Type | nil)
-	// The [opt] tag is more for parameters.
+	luaLSType, desc := g.propertyTypeAndDescription(property)
+	fieldName, luaLSType := g.optionalField(name, luaLSType, property.Optional)
+	field := fieldAnnotation(fieldName, luaLSType, desc)
+	return g.override("field", FieldTemplateData{Name: name, Property: property, Type: luaLSType, Default: field}, field)
+}
 
-	// Combine type and nullability
-	if property.Nullable && !strings.Contains(luaLSType, "| nil") {
-		luaLSType = luaLSType + " | nil"
+// propertyTypeAndDescription returns the LuaLS type of a property and its
+// single-line description, annotated with the property's read/write access.
+func (g *Generator) propertyTypeAndDescription(property api.Property) (string, string) {
+	luaLSType := g.translateFactorioTypeToLuaLS(property.ValueType())
+	// Nullability is part of the type; optionality is applied to the field name by
+	// the caller (see optionalMember), since it depends on the configured style.
+	if property.Nullable {
+		luaLSType = withNil(luaLSType)
 	}
-	// Also consider optional properties as potentially nil if not explicitly nullable
-	// This might be a matter of preference or how LuaLS interprets optional fields.
-	// If property.Optional && !property.Nullable && !strings.Contains(luaLSType, "| nil") {
-	//      luaLSType = luaLSType + " | nil"
-	// }
 
-	// Indicate read/write status in description or a custom tag if LuaLS supports it
+	// LuaLS has no annotation for read-only or write-only fields, nor a diagnostic
+	// for assigning to one, so the access can only be shown in the description;
+	// `serve --read-only-diagnostics` reports the assignments instead. The
+	// TypeScript output declares read-only properties readonly, and the IR records
+	// both (see IRField).
 	access := ""
-	if property.Read && property.Write {
+	if property.IsReadable() && property.IsWritable() {
 		access = "(Read/Write)"
-	} else if property.Read {
+	} else if property.IsReadable() {
 		access = "(Read-only)"
-	} else if property.Write {
+	} else if property.IsWritable() {
 		access = "(Write-only)"
 	}
 
-	desc := property.Description
+	desc := g.inlineDescription(property.Description)
 	if access != "" {
 		if desc != "" {
 			desc = desc + " " + access
@@ -286,259 +1512,702 @@ func (g *Generator) generatePropertyAnnotation(name string, property api.Propert
 		}
 	}
 
-	return fmt.Sprintf("---@field %s %s %s", name, luaLSType, desc)
+	return luaLSType, desc
 }
 
-// generateMethodAnnotation generates the LuaLS annotation for a method.
-func (g *Generator) generateMethodAnnotation(name string, method api.Method) string {
+// generateMethodStub generates an annotated function stub for a method, e.g.
+//
+//	---@param area BoundingBox
+//	---@return LuaEntity[]
+//	function LuaSurface.find_entities(area) end
+//
+// Factorio methods are called with dot syntax, so the stub is declared with a dot.
+func (g *Generator) generateMethodStub(className string, method api.Method) string {
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("---@method %s\n", name))
 
-	// Add parameter annotations
-	for _, param := range method.Parameters {
-		luaLSType := g.translateFactorioTypeToLuaLS(param.Type)
-		optional := ""
-		if param.Optional {
-			optional = " [opt]" // [opt] is common for parameters
-		}
-		// Handle nullability within the type string for parameters too if needed
-		if param.Nullable && !strings.Contains(luaLSType, "| nil") {
-			luaLSType = luaLSType + " | nil"
-		}
+	// Methods taking a table of named arguments get a dedicated parameter class,
+	// emitted ahead of the stub, and are typed as taking that single table.
+	var paramNames []string
+	if method.Format.TakesTable {
+		// Methods often take the same arguments (LuaItemCommon and LuaRecord share
+		// their blueprint methods, find_* and destroy_* their filters, and
+		// set_gui_arrow a GuiArrowSpecification). Only the first of the tables with
+		// the same fields is declared; the others become aliases of it, so each name
+		// stays usable in annotations.
+		paramClassName := fmt.Sprintf("%s.%s_param", className, method.Name)
+		g.writeDeclaration(&sb, g.generateParamTypes(paramClassName, method.Parameters, method.VariantParameterGroups))
+		sb.WriteString("\n")
 
-		sb.WriteString(fmt.Sprintf("---@param %s%s %s %s\n", param.Name, optional, luaLSType, param.Description))
+		g.writeDocComment(&sb, "", method.Description)
+		g.writeExamples(&sb, method.Examples)
+		name, luaLSType := g.optionalMember("param", paramClassName, method.Format.TableOptional)
+		sb.WriteString(fmt.Sprintf("---@param %s %s\n", name, luaLSType))
+		paramNames = append(paramNames, "param")
+	} else {
+		g.writeDocComment(&sb, "", method.Description)
+		g.writeExamples(&sb, method.Examples)
+
+		// Add parameter annotations
+		for _, param := range sortedByOrder(method.Parameters) {
+			luaLSType := g.translateFactorioTypeToLuaLS(param.Type)
+			// Handle nullability within the type string for parameters too if needed
+			if param.Nullable {
+				luaLSType = withNil(luaLSType)
+			}
+			if override, ok := methodParamTypes[className+"."+method.Name][param.Name]; ok {
+				luaLSType = override
+			}
+
+			paramName := luaParamName(param.Name)
+			annotatedName, luaLSType := g.optionalMember(paramName, luaLSType, param.Optional)
+			sb.WriteString(fmt.Sprintf("---@param %s %s %s\n", annotatedName, luaLSType, g.inlineDescription(param.Description)))
+			paramNames = append(paramNames, paramName)
+		}
+	}
+	// Variadic methods take their trailing arguments as `...`.
+	if method.VariadicParameter != nil {
+		luaLSType := g.translateFactorioTypeToLuaLS(method.VariadicParameter.Type)
+		sb.WriteString(fmt.Sprintf("---@param ... %s %s\n", luaLSType, g.inlineDescription(method.VariadicParameter.Description)))
+		paramNames = append(paramNames, "...")
 	}
 
 	// Add return type annotations
 	// Handle multiple return values - LuaLS supports this with multiple @return tags
-	for _, ret := range method.ReturnTypes {
+	for _, ret := range sortedByOrder(method.ReturnValues) {
 		luaLSType := g.translateFactorioTypeToLuaLS(ret.Type)
-		if ret.Nullable && !strings.Contains(luaLSType, "| nil") {
-			luaLSType = luaLSType + " | nil"
+		// An optional return value may be nil; return types have no `?` form in either style.
+		if ret.Nullable || ret.Optional {
+			luaLSType = withNil(luaLSType)
 		}
-		sb.WriteString(fmt.Sprintf("---@return %s %s\n", luaLSType, ret.Description))
+		sb.WriteString(fmt.Sprintf("---@return %s %s\n", luaLSType, g.inlineDescription(ret.Description)))
 	}
 
-	// Add method description
-	sb.WriteString(fmt.Sprintf("%s: %s\n", name, method.Description))
+	overloads := methodOverloads[className+"."+method.Name]
+	if className == "LuaBootstrap" && method.Name == "on_event" {
+		overloads = append(slices.Clone(g.eventOverloads), overloads...)
+	}
+	if className == "LuaRemote" && method.Name == "call" {
+		overloads = append(slices.Clone(g.remoteOverloads), overloads...)
+	}
+	for _, overload := range overloads {
+		sb.WriteString(fmt.Sprintf("---@overload %s\n", overload))
+	}
+	g.writeSeeAlso(&sb, className+"."+method.Name, method.Description)
+	if method.Deprecated {
+		sb.WriteString("---@deprecated\n")
+	}
+	sb.WriteString(fmt.Sprintf("function %s.%s(%s) end\n", className, method.Name, strings.Join(paramNames, ", ")))
 
-	return sb.String()
+	return g.override("method", MethodTemplateData{ClassName: className, Method: method, Default: sb.String()}, sb.String())
+}
+
+// integerType returns the LuaLS type used for integral values in the configured number mode.
+func (g *Generator) integerType() string {
+	if g.options.NumberMode == NumbersLoose {
+		return "number"
+	}
+	return "integer"
+}
+
+// optionalMember returns the name and type to annotate an optional field or
+// parameter with, using the configured style: `name? T` or `name T | nil`.
+// Required members are returned unchanged.
+func (g *Generator) optionalMember(name string, luaLSType string, optional bool) (string, string) {
+	if !optional {
+		return name, luaLSType
+	}
+	if g.options.OptionalStyle == OptionalNilUnion || g.options.Dialect == DialectEmmyLua {
+		return name, withNil(luaLSType)
+	}
+	return name + "?", luaLSType
+}
+
+// optionalField is optionalMember for the names of `---@field` annotations,
+// which are quoted when they aren't identifiers (see luaFieldName). LuaLS has no
+// optional form of quoted names, so optional ones admit nil instead.
+func (g *Generator) optionalField(name string, luaLSType string, optional bool) (string, string) {
+	fieldName := luaFieldName(name)
+	if fieldName == name {
+		return g.optionalMember(name, luaLSType, optional)
+	}
+	if optional {
+		luaLSType = withNil(luaLSType)
+	}
+	return fieldName, luaLSType
+}
+
+// withNil adds nil to a type, unless the type already admits nil.
+func withNil(luaLSType string) string {
+	return joinUnion(luaLSType, "nil")
+}
+
+// fieldAnnotation returns a `---@field` annotation, without its newline. The
+// description is separated by a space only when there is one, so fields without
+// a description don't end in trailing whitespace.
+func fieldAnnotation(name string, luaLSType string, description string) string {
+	if description == "" {
+		return fmt.Sprintf("---@field %s %s", name, luaLSType)
+	}
+	return fmt.Sprintf("---@field %s %s %s", name, luaLSType, description)
+}
+
+// luaKeywords are the reserved words of Lua 5.2, which cannot be used as parameter names.
+var luaKeywords = map[string]bool{
+	"and": true, "break": true, "do": true, "else": true, "elseif": true, "end": true,
+	"false": true, "for": true, "function": true, "goto": true, "if": true, "in": true,
+	"local": true, "nil": true, "not": true, "or": true, "repeat": true, "return": true,
+	"then": true, "true": true, "until": true, "while": true,
+}
+
+// luaIdentifierPattern matches names that are valid Lua identifiers (keywords aside).
+var luaIdentifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// luaFieldName returns a name as used in a `---@field` annotation: identifiers as
+// they are, anything else (such as "iron-plate") in LuaLS's quoted ["..."] form.
+func luaFieldName(name string) string {
+	if luaIdentifierPattern.MatchString(name) && !luaKeywords[name] {
+		return name
+	}
+	return "[" + luaString(name) + "]"
+}
+
+// luaIndex returns the expression indexing a table with a field name: table.name
+// for identifiers, table["name"] otherwise.
+func luaIndex(table string, name string) string {
+	if field := luaFieldName(name); field != name {
+		return table + field
+	}
+	return table + "." + name
+}
+
+// luaParamName returns a parameter name that is valid in a Lua function signature,
+// suffixing reserved words (e.g. the `function` parameter of add_command) with an underscore.
+func luaParamName(name string) string {
+	if luaKeywords[name] {
+		return name + "_"
+	}
+	return name
+}
+
+// writeExamples appends the examples of an entity to its doc comment as ```lua
+// fenced code blocks, unless examples are disabled. The JSON examples are already
+// fenced, but without a language; examples without a fence are wrapped in one.
+func (g *Generator) writeExamples(sb *strings.Builder, examples []string) {
+	if !g.options.IncludeExamples {
+		return
+	}
+	for _, example := range examples {
+		example = strings.TrimSpace(example)
+		if strings.HasPrefix(example, "```") {
+			example = "```lua" + strings.TrimPrefix(example, "```")
+		} else {
+			example = "```lua\n" + example + "\n```"
+		}
+		sb.WriteString("---\n")
+		// Examples are code, so they're written verbatim rather than through the renderer.
+		for _, line := range strings.Split(example, "\n") {
+			sb.WriteString("---" + line + "\n")
+		}
+	}
+}
+
+// writeSeeAlso writes a `---@see` line for every API member referenced from the
+// description, except the annotated entity itself, so hovers link to related APIs.
+func (g *Generator) writeSeeAlso(sb *strings.Builder, self string, description string) {
+	for _, ref := range g.renderer.references(description) {
+		if ref == self {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("---@see %s\n", ref))
+	}
+}
+
+// inlineDescription flattens a description onto a single line, for annotations
+// such as @param and @field where the description must follow the type inline.
+func (g *Generator) inlineDescription(description string) string {
+	return strings.Join(strings.Fields(g.renderer.render(description)), " ")
 }
 
 // translateFactorioTypeToLuaLS translates a Factorio API Type struct to a LuaLS annotation type string.
 // This function is crucial and requires careful implementation to handle all Factorio type variations.
 func (g *Generator) translateFactorioTypeToLuaLS(t api.Type) string {
-	// Handle simple types
-	if t.IsSimple() {
-		// Map common Factorio types to LuaLS equivalents
-		switch t.Name {
-		case "string":
-			return "string"
-		case "int", "uint", "long", "ulong", "float", "double", "number": // Added "number" explicitly
-			return "number" // Lua has a single number type
-		case "boolean":
-			return "boolean"
-		case "table":
-			return "table" // Generic table, might need more specific type if possible
-		case "nil":
-			return "nil" // Represents the nil value/type
-		case "object":
-			return "any" // Generic object, use 'any' or a more specific base class if defined
-		case "LuaObject":
-			return "LuaObject" // Base class for Lua objects
-		case "void":
-			return "nil" // Methods returning nothing might be 'void' in JSON, map to nil
-		// Add other common simple types or built-in types if needed
-		default:
-			// Assume it's a reference to a defined class, concept, or simple type
-			return t.Name
-		}
-	}
-
-	// Handle complex types based on ComplexType field
-	switch t.ComplexType {
-	case "array":
-		if t.Value != nil {
-			// Array of a specific type: Type[] or table<integer, Type>
-			// LuaLS supports both, Type[] is often cleaner.
-			return g.translateFactorioTypeToLuaLS(*t.Value) + "[]"
-		}
-		return "table" // Generic array if element type is unknown
-
-	case "dictionary":
-		if t.Key != nil && t.Value != nil {
-			// Dictionary with specific key and value types: table<KeyType, ValueType>
-			keyType := g.translateFactorioTypeToLuaLS(*t.Key)
-			valueType := g.translateFactorioTypeToLuaLS(*t.Value)
-			return fmt.Sprintf("table<%s, %s>", keyType, valueType)
-		}
-		return "table" // Generic dictionary if types are unknown
-
-	case "union":
-		if len(t.Values) > 0 {
-			// Union of types: Type1 | Type2 | ...
-			var options []string
-			for _, optionType := range t.Values {
-				options = append(options, g.translateFactorioTypeToLuaLS(optionType))
-			}
-			return strings.Join(options, " | ")
-		}
-		return "any" // Union with no options? Shouldn't happen based on docs.
-
-	case "literal":
-		// Literal value type. LuaLS might represent this as a union of literal values
-		// or a specific type depending on context. For simplicity, return the inferred type.
-		if t.LiteralValue != nil {
-			switch val := t.LiteralValue.(type) {
-			case int, float64:
-				return fmt.Sprintf("%v", val) // Represent literal numbers directly
-			case string:
-				// Escape string literals for Lua
-				escapedString := strings.ReplaceAll(val, `"`, `\"`)
-				escapedString = strings.ReplaceAll(escapedString, `\`, `\\`)   // Escape backslashes
-				escapedString = strings.ReplaceAll(escapedString, "\n", "\\n") // Escape newlines
-				escapedString = strings.ReplaceAll(escapedString, "\r", "\\r") // Escape carriage returns
-				escapedString = strings.ReplaceAll(escapedString, "\t", "\\t") // Escape tabs
-				return fmt.Sprintf(`"%s"`, escapedString)                      // Represent literal strings directly
-			case bool:
-				return fmt.Sprintf("%v", val) // Represent literal booleans directly (true or false)
-			default:
-				return "any" // Unknown literal type
-			}
-		}
-		return "any" // Literal with no value?
-
-	case "type":
-		// This seems to be a wrapper around another type, possibly with a description.
-		// Just return the translation of the wrapped type.
-		if t.Value != nil {
-			return g.translateFactorioTypeToLuaLS(*t.Value)
-		}
-		return "any" // Type wrapper with no inner type?
-
-	case "struct":
-		// 'struct' often just has a name and description, or might imply fields
-		// defined elsewhere. The BasicMember fields handle name/description.
-		// If there were inline field definitions, they would need to be handled here.
-		// Based on the Factorio JSON docs, 'struct' often appears as a complex_type
-		// for named concepts or types that are essentially tables/structs.
-		// If t.Name is present, it's likely a reference to a defined concept/type.
-		if t.Name != "" {
-			return t.Name
-		}
-		return "table" // Generic struct/table if no name or fields are defined here
-
-	case "tuple":
-		if len(t.Values) > 0 {
-			// Tuple of types: {Type1, Type2, ...} or LuaLS specific tuple syntax if available/preferred
-			// LuaLS often represents tuples as tables with specific field types or ordered elements.
-			// A common representation is a table type with numeric keys: table<integer, Type1|Type2|...>
-			// Or if the order/specific types are crucial, an inline table type: {1: Type1, 2: Type2, ...}
-			// Let's use the inline table type for stricter tuple representation.
-			var fields []string
-			for i, elementType := range t.Values {
-				fields = append(fields, fmt.Sprintf("%d: %s", i+1, g.translateFactorioTypeToLuaLS(elementType)))
-			}
-			return fmt.Sprintf("{%s}", strings.Join(fields, ", "))
-		}
-		return "table" // Generic table if tuple elements are unknown
-
-	case "builtin":
-		// The log shows {"complex_type":"builtin"} which implies no name or value here.
-		// The name for builtin types might be the key in the BuiltinTypes map at the top level.
-		// No further unmarshalling is needed for this structure based on the log.
-		// The Name field would be populated if this Type struct was part of a map/slice
-		// where the name is the key/part of the surrounding structure.
-		// If this "builtin" type appears in a context where it needs a name (e.g., a parameter type),
-		// the name should come from the surrounding structure, not the Type object itself.
-		// We'll return "any" or a specific Lua primitive if the context implies it.
-		// Based on the log, it seems these "builtin" markers appear where a type is expected.
-		// Returning "any" is a safe fallback, or we could try to infer from context if possible.
-		// For now, returning "any" for the marker itself. The actual builtin types (like "boolean")
-		// are handled by the IsSimple() case.
+	if g.types != nil {
+		g.types.types++
+	}
+	return translateType(t, luaLSSyntax{g})
+}
+
+// LuaLSType returns a type as the LuaLS definitions write it, e.g. "LuaEntity[]",
+// so other tools can present the API in the same terms (see the lsp package).
+func (g *Generator) LuaLSType(t api.Type) string {
+	return g.translateFactorioTypeToLuaLS(t)
+}
+
+// luaLSSyntax spells types in LuaLS annotation syntax, honoring the configured
+// number mode, tuple style and dialect.
+type luaLSSyntax struct{ g *Generator }
+
+func (s luaLSSyntax) named(name string) string {
+	// Map common Factorio types to LuaLS equivalents
+	switch name {
+	case "long", "ulong": // Legacy integral names without a documented alias
+		return s.g.integerType()
+	case "object":
+		return "any" // Generic object, use 'any' or a more specific base class if defined
+	case "void":
+		return "nil" // Methods returning nothing might be 'void' in JSON, map to nil
+	default:
+		// Documented builtins (int, double, ...) resolve to the aliases in builtin.lua;
+		// anything else is a reference to a defined class, concept, or simple type.
+		if s.g.types != nil && !s.g.types.known[name] {
+			s.g.types.add(TypeUnresolved, api.Type{Name: name}, name, "no definition declares the name")
+		}
+		if renamed, ok := s.g.renames[name]; ok {
+			return renamed
+		}
+		return name
+	}
+}
+
+func (s luaLSSyntax) array(element string, elementType api.Type) string {
+	// Array of a specific type: Type[] or table<integer, Type>
+	// LuaLS supports both, Type[] is often cleaner. Element types that are
+	// written with their own operators or brackets (unions, functions, tuples)
+	// are parenthesized so the [] suffix applies to the whole element.
+	if elementType.IsUnion() || elementType.IsFunction() || elementType.IsTuple() {
+		element = "(" + element + ")"
+	}
+	return element + "[]"
+}
+
+func (s luaLSSyntax) dictionary(key string, value string) string {
+	return fmt.Sprintf("table<%s, %s>", key, value)
+}
+
+func (s luaLSSyntax) customTable(key string, value string) string {
+	// Instantiate the generic LuaCustomTable<K, V> class.
+	if s.g.options.Dialect == DialectEmmyLua {
+		return fmt.Sprintf("table<%s, %s>", key, value) // No generic classes
+	}
+	return fmt.Sprintf("LuaCustomTable<%s, %s>", key, value)
+}
+
+func (s luaLSSyntax) key(t api.Type) string {
+	return s.g.dictionaryKeyType(t)
+}
+
+func (s luaLSSyntax) union(members []string) string {
+	return joinUnion(members...)
+}
+
+func (s luaLSSyntax) literal(value interface{}) string {
+	switch val := value.(type) {
+	case int, float64:
+		return fmt.Sprintf("%v", val) // Represent literal numbers directly
+	case string:
+		return luaString(val) // Represent literal strings directly
+	case bool:
+		return fmt.Sprintf("%v", val) // Represent literal booleans directly (true or false)
+	default:
+		return "any" // Unknown literal type
+	}
+}
+
+func (s luaLSSyntax) tuple(elements []string) string {
+	// Tuple of types: [Type1, Type2, ...] in LuaLS tuple syntax, or the older
+	// inline table type with numeric keys {1: Type1, 2: Type2, ...}, which some
+	// LuaLS versions accept only inconsistently.
+	if s.g.options.Dialect == DialectEmmyLua {
+		// No tuple types: an array of the element types is the closest match.
+		element := joinUnion(elements...)
+		if len(splitUnion(element)) > 1 {
+			element = "(" + element + ")"
+		}
+		return element + "[]"
+	}
+	if s.g.options.TupleStyle == TuplesTable {
+		var numbered []string
+		for i, element := range elements {
+			numbered = append(numbered, fmt.Sprintf("%d: %s", i+1, element))
+		}
+		return fmt.Sprintf("{%s}", strings.Join(numbered, ", "))
+	}
+	return fmt.Sprintf("[%s]", strings.Join(elements, ", "))
+}
+
+func (s luaLSSyntax) function(params []string) string {
+	// Callback types only list their argument types, so synthesize positional names.
+	var named []string
+	for i, param := range params {
+		named = append(named, fmt.Sprintf("arg%d: %s", i+1, param))
+	}
+	return fmt.Sprintf("fun(%s)", strings.Join(named, ", "))
+}
+
+// table declares an inline table type (LuaControl.walking_state) as a class
+// named after the attribute, emitted after the class the attribute belongs to.
+// Other inline tables, such as a second one in the same type, are spelled as
+// LuaLS table literals.
+func (s luaLSSyntax) table(t api.Type) string {
+	if name := s.g.tableName; name != "" {
+		s.g.tableName = ""
+		s.g.tables = append(s.g.tables, s.g.generateParamTypes(name, t.Fields, t.VariantParameterGroups))
+		return name
+	}
+	params := slices.Clone(t.Fields)
+	for _, group := range t.VariantParameterGroups {
+		params = append(params, group.Parameters...)
+	}
+	var fields []string
+	seen := make(map[string]bool)
+	for _, param := range sortedByOrder(params) {
+		if seen[param.Name] {
+			continue // Repeated by several variant groups
+		}
+		seen[param.Name] = true
+		luaLSType := s.g.translateFactorioTypeToLuaLS(param.Type)
+		if param.Nullable {
+			luaLSType = withNil(luaLSType)
+		}
+		name, luaLSType := s.g.optionalMember(param.Name, luaLSType, param.Optional)
+		fields = append(fields, name+": "+luaLSType)
+	}
+	return "{" + strings.Join(fields, ", ") + "}"
+}
+
+func (s luaLSSyntax) any() string {
+	return "any"
+}
+
+func (s luaLSSyntax) downgraded(t api.Type, output string, reason string) {
+	s.g.types.add(TypeDowngraded, t, output, reason)
+}
+
+// dictionaryKeyType translates the key type of a dictionary or LuaCustomTable so
+// that it is valid inside table<K, V>. String-literal unions are kept, so completion
+// offers the valid keys; any other literal resolves to its base type, and members
+// that can't be a table key on their own (nested tables, functions) become any.
+func (g *Generator) dictionaryKeyType(key api.Type) string {
+	switch {
+	case isStringLiteralUnion(key):
+		return g.translateFactorioTypeToLuaLS(key)
+	case key.IsLiteral():
+		return g.literalBaseType(key.LiteralValue)
+	case key.IsUnion():
+		var members []string
+		for _, option := range key.Values {
+			member := g.dictionaryKeyType(option)
+			if member == "any" {
+				return "any"
+			}
+			members = append(members, member)
+		}
+		return joinUnion(members...)
+	case key.IsSimple():
+		return g.translateFactorioTypeToLuaLS(key)
+	default:
+		g.types.add(TypeDowngraded, key, "any", "dictionary key that can't be a table key")
 		return "any"
+	}
+}
 
+// literalBaseType returns the LuaLS type of a literal value, e.g. string for "left".
+func (g *Generator) literalBaseType(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return g.integerType()
+		}
+		return "number"
 	default:
-		// If ComplexType is empty or unknown, it might be a simple type with just a Name.
-		// The initial unmarshalling into temp.Name handles the Name field.
-		// If Name is also empty and ComplexType is empty, it might be an error in the JSON
-		// or a type we haven't accounted for.
-		if t.Name != "" {
-			return t.Name // Assume it's a reference to a defined type/concept
+		return "any"
+	}
+}
+
+// eventDataConcept is the base of every event's data, declared in place of the
+// EventData concept of newer APIs by those that don't document it. Every payload
+// carries the event id and the tick it was raised on; mod_name is only set when
+// the event was raised by a mod. The tick is a "long", which every format spells
+// as its integer type, since the uint alias may not be documented either.
+var eventDataConcept = api.Concept{
+	BasicMember: api.BasicMember{Name: "EventData"},
+	Type: api.Type{ComplexType: "table", Fields: []api.Parameter{
+		{Name: "name", Description: "Identifier of the event", Type: api.Type{Name: "defines.events"}, Order: 0},
+		{Name: "tick", Description: "Tick the event was generated.", Type: api.Type{Name: "long"}, Order: 1},
+		{Name: "mod_name", Description: "The name of the mod that raised the event if it was raised using [LuaBootstrap::raise_event](runtime:LuaBootstrap::raise_event).", Type: api.Type{Name: "string"}, Optional: true, Order: 2},
+	}},
+}
+
+// documentedEventData returns the EventData concept of an API, or
+// eventDataConcept if it doesn't document one.
+func documentedEventData(runtimeAPI *api.API) api.Concept {
+	if i := slices.IndexFunc(runtimeAPI.Concepts, func(c api.Concept) bool { return c.Name == "EventData" }); i >= 0 {
+		return runtimeAPI.Concepts[i]
+	}
+	return eventDataConcept
+}
+
+// controlStageGlobals are the global objects available to control.lua, declared
+// when the runtime JSON doesn't document them.
+var controlStageGlobals = []api.GlobalObject{
+	{BasicMember: api.BasicMember{Name: "game", Description: "The main scripting interface through which most of the API is accessed."}, Type: api.Type{Name: "LuaGameScript"}},
+	{BasicMember: api.BasicMember{Name: "script", Description: "Provides an interface for registering game event handlers."}, Type: api.Type{Name: "LuaBootstrap"}},
+	{BasicMember: api.BasicMember{Name: "remote", Description: "Allows inter-mod communication by way of providing a repository of interfaces that is shared by all mods."}, Type: api.Type{Name: "LuaRemote"}},
+	{BasicMember: api.BasicMember{Name: "commands", Description: "Allows registering custom commands for the in-game console."}, Type: api.Type{Name: "LuaCommandProcessor"}},
+	{BasicMember: api.BasicMember{Name: "settings", Description: "Allows reading the current mod settings."}, Type: api.Type{Name: "LuaSettings"}},
+	{BasicMember: api.BasicMember{Name: "rcon", Description: "Allows printing messages to the calling RCON instance, if any."}, Type: api.Type{Name: "LuaRCON"}},
+	{BasicMember: api.BasicMember{Name: "rendering", Description: "Allows rendering of geometric shapes, text and sprites in the game world."}, Type: api.Type{Name: "LuaRendering"}},
+}
+
+// controlStageGlobals2 are the global objects Factorio 2.0 added, declared like
+// controlStageGlobals, but only for 2.0 APIs: 1.1 has neither them nor their
+// classes.
+var controlStageGlobals2 = []api.GlobalObject{
+	{BasicMember: api.BasicMember{Name: "helpers", Description: "Provides various helper and utility functions."}, Type: api.Type{Name: "LuaHelpers"}},
+	{BasicMember: api.BasicMember{Name: "prototypes", Description: "Allows read-only access to prototypes."}, Type: api.Type{Name: "LuaPrototypes"}},
+}
+
+// runtimeGlobals returns the global objects of the runtime stage: the documented
+// ones, and those of controlStageGlobals (and, for 2.0 APIs, controlStageGlobals2)
+// the API omits.
+func runtimeGlobals(runtimeAPI *api.API) []api.GlobalObject {
+	globals := sortedByOrder(runtimeAPI.GlobalObjects)
+	missing := controlStageGlobals
+	if isFactorio2(runtimeAPI) {
+		missing = append(slices.Clone(missing), controlStageGlobals2...)
+	}
+	for _, global := range missing {
+		if !slices.ContainsFunc(globals, func(o api.GlobalObject) bool { return o.Name == global.Name }) {
+			globals = append(globals, global)
+		}
+	}
+	return globals
+}
+
+// isFactorio2 reports whether an API document is of Factorio 2.0 or later, by its
+// application_version or, without one, its api_version (6 since 2.0). Documents
+// with neither are taken to be of the latest version.
+func isFactorio2(a *api.API) bool {
+	if major, _, ok := strings.Cut(a.ApplicationVersion, "."); ok {
+		if n, err := strconv.Atoi(major); err == nil {
+			return n >= 2
 		}
-		return "any" // Fallback for unknown types or parsing issues
 	}
+	return a.APIVersion == 0 || a.APIVersion >= 6
+}
+
+// contextGlobals declares the runtime globals for a context other than
+// ContextControl, where the globals named in retyped have the class given there
+// instead of their own.
+func (g *Generator) contextGlobals(runtimeAPI *api.API, retyped map[string]string) string {
+	var sb strings.Builder
+	for _, global := range runtimeGlobals(runtimeAPI) {
+		if class, ok := retyped[global.Name]; ok {
+			global.Type = api.Type{Name: class}
+		}
+		sb.WriteString(g.generateGlobalObject(global))
+		sb.WriteString("\n")
+	}
+	sb.WriteString(g.persistentDataGlobals(runtimeAPI))
+	return sb.String()
+}
+
+// persistentDataName returns the name of the table a mod keeps its
+// save-persistent data in: `storage` since Factorio 2.0, and `global` before it.
+func persistentDataName(runtimeAPI *api.API) string {
+	if isFactorio2(runtimeAPI) {
+		return "storage"
+	}
+	return "global"
+}
+
+// persistentDataGlobals declares the table a mod keeps its save-persistent data in
+// (see persistentDataName). Its contents are up to the mod, so it is typed as an
+// empty class, named like the global, that mods extend with their own fields; the
+// comment above it explains how. Fields a mod doesn't declare stay allowed through
+// an indexed field, as in the table it used to be typed as, except in the EmmyLua
+// dialect, which has no indexed fields. For 2.0, `global` is also declared, as
+// deprecated, for the mods still being ported from 1.1.
+func (g *Generator) persistentDataGlobals(runtimeAPI *api.API) string {
+	name := persistentDataName(runtimeAPI)
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("-- The contents of `%s` are up to each mod. Type them by extending its class\n", name))
+	sb.WriteString("-- anywhere in your mod; LuaLS merges the fields of every declaration:\n")
+	sb.WriteString("--\n")
+	sb.WriteString(fmt.Sprintf("--   ---@class %s\n", name))
+	sb.WriteString("--   ---@field players table<integer, PlayerData>\n")
+	sb.WriteString("--   ---@field next_id integer\n")
+	if name == "storage" {
+		sb.WriteString("--\n")
+		sb.WriteString("-- `global`, its name before Factorio 2.0, has the same class.\n")
+	}
+	sb.WriteString("\n")
+	sb.WriteString("---A table whose contents are saved and restored with the save file. Only data\n")
+	sb.WriteString("---(no functions or metatables other than registered ones) may be stored in it.\n")
+	sb.WriteString(fmt.Sprintf("---@class %s\n", name))
+	if g.options.Dialect != DialectEmmyLua {
+		sb.WriteString("---@field [any] any\n")
+	}
+	sb.WriteString(fmt.Sprintf("%s = {}\n", name))
+	if name == "storage" {
+		sb.WriteString("\n")
+		sb.WriteString("---Factorio 1.1's name for the persistent data table, renamed to `storage` in 2.0.\n")
+		sb.WriteString("---@deprecated\n")
+		sb.WriteString("---@type storage\n")
+		sb.WriteString("global = {}\n")
+	}
+	return sb.String()
 }
 
 // generateGlobalObject generates the LuaLS annotation for a global object.
 // Now accepts the GlobalObject struct directly.
 func (g *Generator) generateGlobalObject(global api.GlobalObject) string {
+	g.types.at("global " + global.Name)
 	luaLSType := g.translateFactorioTypeToLuaLS(global.Type)
-	// Global objects are typically defined as global variables with type annotations.
-	return fmt.Sprintf("---@type %s %s\n%s = {}", luaLSType, global.Description, global.Name) // Use global.Name
+	// Global objects are declared as global variables, with the doc comment and the
+	// type in a single block directly above the assignment so LuaLS binds both to
+	// the variable. The value is nil rather than {}: LuaLS would otherwise infer the
+	// variable as the empty table as well as the class instance.
+	var sb strings.Builder
+	g.writeDocComment(&sb, "", global.Description)
+	sb.WriteString(fmt.Sprintf("---@type %s\n%s = nil\n", luaLSType, global.Name))
+	return sb.String()
 }
 
 // generateEventDataClass generates a class for event data payload.
 // Now accepts the Event struct directly.
 func (g *Generator) generateEventDataClass(event api.Event) string {
+	g.types.at("event " + event.Name)
 	var sb strings.Builder
 	// Event data classes are typically named EventData.<event_name> and inherit from a base EventData class.
-	dataTypeName := "EventData." + event.Name                                                     // Use event.Name
-	sb.WriteString(fmt.Sprintf("---@class %s : EventData %s\n", dataTypeName, event.Description)) // Inherit from base EventData
-	sb.WriteString(fmt.Sprintf("%s = {}\n\n", dataTypeName))                                      // Define the class table
-
-	// Add fields for event data parameters
-	for _, param := range event.Data {
+	dataTypeName := "EventData." + event.Name // Use event.Name
+	g.writeDocComment(&sb, "", event.Description)
+	g.writeExamples(&sb, event.Examples)
+	g.writeSeeAlso(&sb, event.Name, event.Description)
+	sb.WriteString(fmt.Sprintf("---@class %s : EventData\n", dataTypeName)) // Inherit from base EventData
+
+	// Add fields for event data parameters. They precede the class table so they attach to the class.
+	for _, param := range sortedByOrder(event.Data) {
 		luaLSType := g.translateFactorioTypeToLuaLS(param.Type)
 		// Handle nullability within the type string for parameters
-		if param.Nullable && !strings.Contains(luaLSType, "| nil") {
-			luaLSType = luaLSType + " | nil"
+		if param.Nullable {
+			luaLSType = withNil(luaLSType)
 		}
-		// Optional parameters in event data are still fields, but their value might be nil
-		// The [opt] tag is more for function parameters.
-		// If param.Optional && !param.Nullable && !strings.Contains(luaLSType, "| nil") {
-		//      luaLSType = luaLSType + " | nil"
-		// }
+		// Optional parameters in event data are still fields, but may be absent.
+		name, luaLSType := g.optionalField(param.Name, luaLSType, param.Optional)
 
-		sb.WriteString(fmt.Sprintf("---@field %s %s %s\n", param.Name, luaLSType, param.Description))
+		sb.WriteString(fieldAnnotation(name, luaLSType, g.inlineDescription(param.Description)) + "\n")
 	}
+	sb.WriteString(fmt.Sprintf("%s = {}\n", dataTypeName)) // Define the class table
 	return sb.String()
 }
 
 // generatePrototypeTypeClass generates a class for a specific prototype type (e.g., ItemPrototype).
-// Now accepts the map of prototypes for this type.
-func (g *Generator) generatePrototypeTypeClass(className string, typeName string, prototypes map[string]api.Prototype) string {
+// Now accepts the prototypes for this type, already in documentation order, and the
+// class it derives from; a root prototype without a parent gets no parent class.
+func (g *Generator) generatePrototypeTypeClass(className string, parent string, typeName string, prototypes []api.Prototype) string {
+	g.types.at("prototype " + className)
 	var sb strings.Builder
 	// Define a class for the prototype type, inheriting from the base Prototype class.
-	sb.WriteString(fmt.Sprintf("---@class %s : Prototype Represents a %s prototype definition.\n", className, typeName))
-	sb.WriteString(fmt.Sprintf("%s = {}\n\n", className)) // Define the class table
+	// The type class is deprecated when every prototype of the type is.
+	deprecated := len(prototypes) > 0
+	for _, prototype := range prototypes {
+		deprecated = deprecated && prototype.Deprecated
+	}
+	if deprecated {
+		sb.WriteString("---@deprecated\n")
+	}
+	if parent != "" {
+		sb.WriteString(fmt.Sprintf("---@class %s : %s Represents a %s prototype definition.\n", className, parent, typeName))
+	} else {
+		sb.WriteString(fmt.Sprintf("---Represents a %s prototype definition.\n", typeName))
+		sb.WriteString(fmt.Sprintf("---@class %s\n", className))
+	}
+	// The literal type discriminates the classes of data.PrototypeUnion.
+	sb.WriteString(fmt.Sprintf("---@field type %s\n", luaString(typeName)))
 
 	// Collect all unique properties across all prototypes of this type.
 	// This is a simplification; ideally, properties might vary per specific prototype.
 	// A more complex approach would be to define unions or intersections of types.
 	// For now, we'll define fields for properties found in at least one prototype of this type.
 	allProperties := make(map[string]api.Property)
-	for _, prototype := range prototypes { // Iterate over the map values
+	for _, prototype := range prototypes {
 		for _, prop := range prototype.Properties {
+			// The literal type field above replaces the documented one.
+			if prop.Name == "type" {
+				continue
+			}
 			// Simple merge: if property exists, use the one encountered last.
 			// A more robust approach would merge types for properties with the same name.
 			allProperties[prop.Name] = prop
 		}
 	}
+	mergedProperties := make([]api.Property, 0, len(allProperties))
+	for _, prop := range allProperties {
+		mergedProperties = append(mergedProperties, prop)
+	}
+
+	g.writePrototypeFields(&sb, mergedProperties)
+	sb.WriteString(fmt.Sprintf("%s = {}\n", className)) // Define the class table, after its fields
+
+	return sb.String()
+}
+
+// generatePrototypeDefinitionClasses generates the classes of a prototype type in
+// PrototypeClassesByDefinition mode: the type class becomes a base without fields,
+// and every prototype definition of the type gets its own class deriving from it,
+// with exactly the properties documented for that definition. A definition named
+// like the type class (FurnacePrototype for "furnace") is the type class itself.
+func (g *Generator) generatePrototypeDefinitionClasses(className string, parent string, typeName string, prototypes []api.Prototype) string {
+	if len(prototypes) == 1 && prototypes[0].Name == className {
+		return g.generatePrototypeTypeClass(className, parent, typeName, prototypes)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(g.generatePrototypeTypeClass(className, parent, typeName, nil))
+	for _, prototype := range prototypes {
+		sb.WriteString("\n")
+		sb.WriteString(g.generatePrototypeClass(prototype.Name, className, prototype))
+	}
+	return sb.String()
+}
+
+// generatePrototypeClass generates the class of a single prototype definition with
+// exactly its own documented properties, such as an abstract base like
+// EntityPrototype. Inherited properties come from the parent class.
+func (g *Generator) generatePrototypeClass(className string, parent string, prototype api.Prototype) string {
+	g.types.at("prototype " + className)
+	var sb strings.Builder
+	if prototype.Deprecated {
+		sb.WriteString("---@deprecated\n")
+	}
+	g.writeDocComment(&sb, "", prototype.Description)
+	if parent != "" {
+		sb.WriteString(fmt.Sprintf("---@class %s : %s\n", className, parent))
+	} else {
+		sb.WriteString(fmt.Sprintf("---@class %s\n", className))
+	}
+	g.writePrototypeFields(&sb, prototype.Properties)
+	sb.WriteString(fmt.Sprintf("%s = {}\n", className))
+	return sb.String()
+}
 
-	// Generate fields for the collected properties.
-	for propName, prop := range allProperties {
+// writePrototypeFields writes the field annotations of prototype properties, in
+// documentation order.
+func (g *Generator) writePrototypeFields(sb *strings.Builder, properties []api.Property) {
+	for _, prop := range sortedByOrder(properties) {
+		propName := prop.Name
 		luaLSType := g.translateFactorioTypeToLuaLS(prop.Type)
 		// Prototype properties are part of the definition data, not runtime objects.
-		// Optional/nullable might be handled differently than runtime properties.
-		// For now, apply nullable annotation if specified.
-		if prop.Nullable && !strings.Contains(luaLSType, "| nil") {
-			luaLSType = luaLSType + " | nil"
-		}
-		// Optional properties might also be nil in the data.raw table.
-		if prop.Optional && !prop.Nullable && !strings.Contains(luaLSType, "| nil") {
-			luaLSType = luaLSType + " | nil"
+		if prop.Nullable {
+			luaLSType = withNil(luaLSType)
 		}
+		// Optional properties may be left out of the data.raw table entirely.
+		propName, luaLSType = g.optionalField(propName, luaLSType, prop.Optional)
 
 		// Indicate read/write status (less relevant for static prototype data, but include description)
 		access := ""
@@ -550,7 +2219,7 @@ func (g *Generator) generatePrototypeTypeClass(className string, typeName string
 			access = "(Write-only)"
 		}
 
-		desc := prop.Description
+		desc := g.inlineDescription(prop.Description)
 		if access != "" {
 			if desc != "" {
 				desc = desc + " " + access
@@ -559,8 +2228,6 @@ func (g *Generator) generatePrototypeTypeClass(className string, typeName string
 			}
 		}
 
-		sb.WriteString(fmt.Sprintf("---@field %s %s %s\n", propName, luaLSType, desc))
+		sb.WriteString(fieldAnnotation(propName, luaLSType, desc) + "\n")
 	}
-
-	return sb.String()
 }
@@ -0,0 +1,103 @@
+package generator
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/luasyntax"
+)
+
+func TestGenerateGlobalObject(t *testing.T) {
+	tests := []struct {
+		global api.GlobalObject
+		want   string
+	}{
+		{
+			api.GlobalObject{BasicMember: api.BasicMember{Name: "game", Description: "The main scripting interface."}, Type: api.Type{Name: "LuaGameScript"}},
+			"---The main scripting interface.\n---@type LuaGameScript\ngame = nil\n",
+		},
+		{
+			api.GlobalObject{BasicMember: api.BasicMember{Name: "rcon"}, Type: api.Type{Name: "LuaRCON"}},
+			"---@type LuaRCON\nrcon = nil\n",
+		},
+	}
+	for _, test := range tests {
+		got := NewGenerator(DefaultOptions()).generateGlobalObject(test.global)
+		if got != test.want {
+			t.Errorf("generateGlobalObject(%s) = %q, want %q", test.global.Name, got, test.want)
+		}
+		if err := luasyntax.Check(got); err != nil {
+			t.Errorf("generateGlobalObject(%s) is not valid Lua: %v", test.global.Name, err)
+		}
+	}
+}
+
+// Every runtime global is declared, those the API omits included, with its type
+// directly above the assignment.
+func TestContextGlobals(t *testing.T) {
+	runtimeAPI := &api.API{GlobalObjects: []api.GlobalObject{
+		{BasicMember: api.BasicMember{Name: "game"}, Type: api.Type{Name: "LuaGameScript"}},
+	}}
+	got := NewGenerator(DefaultOptions()).contextGlobals(runtimeAPI, map[string]string{"script": "LuaScenarioBootstrap"})
+	if err := luasyntax.Check(got); err != nil {
+		t.Fatalf("the globals are not valid Lua: %v\n%s", err, got)
+	}
+	for _, declaration := range []string{
+		"---@type LuaGameScript\ngame = nil\n",
+		"---@type LuaScenarioBootstrap\nscript = nil\n",
+		"---@type LuaRemote\nremote = nil\n",
+		"---@class storage\n",
+		"---@type storage\nglobal = {}\n",
+	} {
+		if !strings.Contains(got, declaration) {
+			t.Errorf("the globals don't declare %q:\n%s", declaration, got)
+		}
+	}
+}
+
+// The globals Factorio 2.0 added are only declared for 2.0 APIs: in 1.1, neither
+// they nor their classes exist.
+func TestRuntimeGlobals(t *testing.T) {
+	documented := []api.GlobalObject{
+		{BasicMember: api.BasicMember{Name: "game"}, Type: api.Type{Name: "LuaGameScript"}},
+		{BasicMember: api.BasicMember{Name: "script"}, Type: api.Type{Name: "LuaBootstrap"}},
+	}
+	tests := []struct {
+		name    string
+		api     *api.API
+		helpers bool // Whether helpers and prototypes are declared
+	}{
+		{"1.1", &api.API{ApplicationVersion: "1.1.110", APIVersion: 5, GlobalObjects: documented}, false},
+		{"1.1 without application_version", &api.API{APIVersion: 5, GlobalObjects: documented}, false},
+		{"2.0", &api.API{ApplicationVersion: "2.0.45", APIVersion: 6, GlobalObjects: documented}, true},
+		{"unversioned", &api.API{GlobalObjects: documented}, true},
+	}
+	for _, test := range tests {
+		var names []string
+		for _, global := range runtimeGlobals(test.api) {
+			names = append(names, global.Name)
+		}
+		if !slices.Contains(names, "game") || !slices.Contains(names, "remote") {
+			t.Errorf("%s: the globals %v lack game or remote", test.name, names)
+		}
+		for _, name := range []string{"helpers", "prototypes"} {
+			if slices.Contains(names, name) != test.helpers {
+				t.Errorf("%s: the globals %v declare %s: %v, want %v", test.name, names, name, !test.helpers, test.helpers)
+			}
+		}
+
+		options := DefaultOptions()
+		options.Formats = []Format{FormatLuaLS, FormatTeal, FormatTypeScript, FormatStubs}
+		definitions, err := NewGenerator(options).GenerateDefinitions(test.api, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, file := range []string{"runtime.lua", "runtime.d.tl", "runtime.d.ts", "stubs/runtime.lua"} {
+			if strings.Contains(definitions[file], "LuaHelpers") != test.helpers {
+				t.Errorf("%s: %s declares helpers: %v, want %v", test.name, file, !test.helpers, test.helpers)
+			}
+		}
+	}
+}
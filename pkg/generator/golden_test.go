@@ -0,0 +1,376 @@
+package generator_test
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/generator"
+)
+
+// The golden tests generate the definitions of every API version recorded in
+// testdata/fixtures with the options of goldenCases, and compare them with the
+// files committed in testdata/golden. A change to the generator that changes the
+// output fails them until the golden files are updated, which puts the change
+// to the output in the diff of the commit for review:
+//
+//	go test ./pkg/generator -run TestGolden -update
+//
+// The fixtures are recorded from the published API documents, trimmed to the
+// definitions of fixtureSelection so the golden files stay reviewable:
+//
+//	go test ./pkg/generator -run TestGolden -record 2.0.45 -update
+//
+// -record takes a game version documented on lua-api.factorio.com, or the URL of
+// a directory holding runtime-api.json and prototype-api.json.
+var (
+	update = flag.Bool("update", false, "rewrite the golden files with the generated output")
+	record = flag.String("record", "", "record a fixture from this API version (e.g. 2.0.45) or directory URL")
+)
+
+// goldenCases are the option sets the fixtures are generated with, by name. Each
+// starts from DefaultOptions.
+var goldenCases = []struct {
+	name    string
+	options func(*generator.Options)
+}{
+	{"luals", func(o *generator.Options) {}},
+	{"split-files", func(o *generator.Options) { o.SplitFiles = true }},
+	{"emmylua", func(o *generator.Options) {
+		o.Dialect = generator.DialectEmmyLua
+		o.OptionalStyle = generator.OptionalNilUnion
+		o.TupleStyle = generator.TuplesTable
+		o.NumberMode = generator.NumbersLoose
+	}},
+	{"type-prefix", func(o *generator.Options) { o.TypePrefix = "Factorio." }},
+	{"luals-addon", func(o *generator.Options) { o.Formats = []generator.Format{generator.FormatLuaLSAddon} }},
+	{"luarocks", func(o *generator.Options) { o.Formats = []generator.Format{generator.FormatLuaRocks} }},
+	{"formats", func(o *generator.Options) {
+		o.Formats = []generator.Format{generator.FormatTeal, generator.FormatTypeScript, generator.FormatIR, generator.FormatMarkdown}
+	}},
+	{"html", func(o *generator.Options) { o.Formats = []generator.Format{generator.FormatHTML} }},
+	{"locale", func(o *generator.Options) {
+		o.DataRawNames = map[string][]string{"item": {"iron-plate"}, "ammo": {"firearm-magazine"}}
+		o.Locale = map[string]map[string]string{
+			"item-name": {"iron-plate": "Iron plate", "firearm-magazine": "Firearm magazine"},
+			"direction": {"north": "North", "east": "East (of __ITEM__iron-plate__)"},
+		}
+	}},
+	{"migrations", func(o *generator.Options) { o.Context = generator.ContextMigrations }},
+	{"scenario", func(o *generator.Options) { o.Context = generator.ContextScenario }},
+	{"base-game", func(o *generator.Options) { o.FeatureFlags = []string{} }},
+	{"stage-globals", func(o *generator.Options) { o.StageGlobals = true }},
+	{"lua-stubs", func(o *generator.Options) { o.Formats = []generator.Format{generator.FormatStubs} }},
+}
+
+// fixtureSelection names the definitions kept in the recorded fixtures, per stage
+// and top-level key of the API documents. They are picked to cover the shapes the
+// generator handles (inheritance, operators, variadic and table parameters,
+// event filters, unions, literals, tables, ...). A name such as
+// LuaEntityPrototype.logistic_parameters keeps a class with only the members
+// named this way. The builtin concepts and types (uint, double, ...) are always
+// kept, for builtin.lua. Names missing from a version are skipped. Keys not
+// listed, such as global_objects, are kept whole.
+var fixtureSelection = map[string]map[string][]string{
+	"runtime": {
+		"classes": {"LuaBootstrap", "LuaRemote", "LuaSettings", "LuaCustomTable", "LuaCommandProcessor", "LuaRCON", "LuaLazyLoadedValue",
+			"LuaControlBehavior", "LuaContainerControlBehavior", "LuaPrototypeBase", "LuaCustomEventPrototype",
+			// Two attributes with the same inline table type
+			"LuaEntityPrototype.logistic_parameters", "LuaEquipmentPrototype.logistic_parameters"},
+		"events": {"on_tick", "on_built_entity", "on_player_created", "on_research_finished", "CustomInputEvent"},
+		"concepts": {"AnyBasic", "BoundingBox", "Color", "ConfigurationChangedData", "CustomCommandData", "EventData", "LocalisedString",
+			"LuaPlayerBuiltEntityEventFilter", "MapPosition", "ModSetting", "NthTickEventData", "Tags", "Vector"},
+		"defines": {"events", "direction"},
+	},
+	"prototype": {
+		"prototypes": {"PrototypeBase", "Prototype", "ItemPrototype", "AmmoItemPrototype", "ToolPrototype", "CustomEventPrototype", "RailSignalPrototype"},
+		"types":      {"Color", "ItemID", "EntityID", "FileName", "ItemPrototypeFlags", "Vector", "Sprite"},
+		"defines":    {"direction"},
+	},
+}
+
+func TestGolden(t *testing.T) {
+	if *record != "" {
+		recordFixture(t, *record)
+	}
+	versions, err := os.ReadDir(filepath.Join("testdata", "fixtures"))
+	if err != nil {
+		t.Fatalf("no fixtures: %v", err)
+	}
+	for _, version := range versions {
+		runtimeAPI := loadFixture(t, version.Name(), "runtime")
+		prototypeAPI := loadFixture(t, version.Name(), "prototype")
+		for _, c := range goldenCases {
+			t.Run(version.Name()+"/"+c.name, func(t *testing.T) {
+				options := generator.DefaultOptions()
+				// The headers name the URLs, which must not depend on the machine.
+				options.RuntimeURL = "fixtures/" + version.Name() + "/runtime-api.json"
+				options.PrototypeURL = "fixtures/" + version.Name() + "/prototype-api.json"
+				c.options(&options)
+				definitions, err := generator.NewGenerator(options).GenerateDefinitions(runtimeAPI, prototypeAPI)
+				if err != nil {
+					t.Fatalf("generating: %v", err)
+				}
+				compareGolden(t, filepath.Join("testdata", "golden", version.Name(), c.name), definitions)
+			})
+		}
+	}
+}
+
+// legacyCases are the goldenCases also generated for a 1.1 API, which differs in
+// what the generator declares by version: the persistent data table is `global`,
+// and the globals 2.0 added aren't declared.
+var legacyCases = []string{"luals", "formats", "lua-stubs"}
+
+// TestGoldenLegacy generates legacyCases from the 2.0.45 fixture reshaped like a
+// 1.1 document, to testdata/golden/1.1: its versions are those of 1.1.110, and it
+// documents neither the global objects 2.0 added nor the EventData concept, which
+// the generator then declares itself. The 1.1 documents differ in much more, but
+// only these change what the generator declares.
+func TestGoldenLegacy(t *testing.T) {
+	runtimeAPI := loadFixture(t, "2.0.45", "runtime")
+	prototypeAPI := loadFixture(t, "2.0.45", "prototype")
+	for _, a := range []*api.API{runtimeAPI, prototypeAPI} {
+		a.ApplicationVersion, a.APIVersion = "1.1.110", 5
+	}
+	runtimeAPI.GlobalObjects = slices.DeleteFunc(runtimeAPI.GlobalObjects, func(g api.GlobalObject) bool {
+		return g.Name == "helpers" || g.Name == "prototypes"
+	})
+	runtimeAPI.Concepts = slices.DeleteFunc(runtimeAPI.Concepts, func(c api.Concept) bool {
+		return c.Name == "EventData"
+	})
+	for _, c := range goldenCases {
+		if !slices.Contains(legacyCases, c.name) {
+			continue
+		}
+		t.Run(c.name, func(t *testing.T) {
+			options := generator.DefaultOptions()
+			options.RuntimeURL = "fixtures/1.1/runtime-api.json"
+			options.PrototypeURL = "fixtures/1.1/prototype-api.json"
+			c.options(&options)
+			definitions, err := generator.NewGenerator(options).GenerateDefinitions(runtimeAPI, prototypeAPI)
+			if err != nil {
+				t.Fatalf("generating: %v", err)
+			}
+			compareGolden(t, filepath.Join("testdata", "golden", "1.1", c.name), definitions)
+		})
+	}
+}
+
+// loadFixture parses the API document of a stage of a recorded version.
+func loadFixture(t *testing.T, version string, stage string) *api.API {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "fixtures", version, stage+"-api.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var a api.API
+	if err := json.Unmarshal(data, &a); err != nil {
+		t.Fatalf("parsing the %s fixture of %s: %v", stage, version, err)
+	}
+	return &a
+}
+
+// compareGolden compares generated files with the golden files in dir, or
+// rewrites them with -update.
+func compareGolden(t *testing.T, dir string, definitions map[string]string) {
+	t.Helper()
+	if *update {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Fatal(err)
+		}
+		for name, content := range definitions {
+			path := filepath.Join(dir, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return
+	}
+
+	var golden []string
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		name, _ := filepath.Rel(dir, path)
+		golden = append(golden, filepath.ToSlash(name))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("no golden files (run with -update to write them): %v", err)
+	}
+	for _, name := range golden {
+		if _, ok := definitions[name]; !ok {
+			t.Errorf("%s: no longer generated", name)
+		}
+	}
+	for _, name := range sortedNames(definitions) {
+		if !slices.Contains(golden, name) {
+			t.Errorf("%s: generated, but has no golden file", name)
+			continue
+		}
+		want, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := firstDifference(string(want), definitions[name]); diff != "" {
+			t.Errorf("%s differs from the golden file (run with -update to accept):\n%s", name, diff)
+		}
+	}
+}
+
+// firstDifference describes the first line where got differs from want, or
+// returns "" if they are equal.
+func firstDifference(want string, got string) string {
+	if want == got {
+		return ""
+	}
+	wantLines, gotLines := strings.Split(want, "\n"), strings.Split(got, "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g || i >= len(wantLines) || i >= len(gotLines) {
+			return fmt.Sprintf("line %d:\n  want: %s\n  got:  %s", i+1, w, g)
+		}
+	}
+	return "line endings differ"
+}
+
+func sortedNames(definitions map[string]string) []string {
+	var names []string
+	for name := range definitions {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// recordFixture downloads the API documents of a version and writes them,
+// trimmed to fixtureSelection, to testdata/fixtures/<application_version>. The
+// documents are trimmed as generic JSON rather than through api.API, so they keep
+// every field of the original, including the ones the parser ignores.
+func recordFixture(t *testing.T, version string) {
+	base := strings.TrimSuffix(version, "/")
+	if !strings.Contains(base, "://") {
+		base = "https://lua-api.factorio.com/" + version
+	}
+	for _, stage := range []string{"runtime", "prototype"} {
+		resp, err := http.Get(base + "/" + stage + "-api.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("recording %s: received status code %d", resp.Request.URL, resp.StatusCode)
+		}
+		var document map[string]any
+		if err := json.Unmarshal(data, &document); err != nil {
+			t.Fatalf("recording %s: %v", resp.Request.URL, err)
+		}
+		trimDocument(document, fixtureSelection[stage])
+
+		dir := filepath.Join("testdata", "fixtures", fmt.Sprint(document["application_version"]))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		trimmed, err := json.MarshalIndent(document, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, stage+"-api.json"), append(trimmed, '\n'), 0644); err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("recorded %s", filepath.Join(dir, stage+"-api.json"))
+	}
+}
+
+// trimDocument keeps the selected definitions of the lists of an API document,
+// and the selected members of those selected by member, besides the builtins.
+// The values of defines.events are trimmed to the selected events too.
+func trimDocument(document map[string]any, selection map[string][]string) {
+	for key, names := range selection {
+		members := make(map[string][]string)
+		for _, name := range names {
+			if definition, member, ok := strings.Cut(name, "."); ok {
+				members[definition] = append(members[definition], member)
+			}
+		}
+		list, _ := document[key].([]any)
+		var kept []any
+		for _, item := range list {
+			definition, _ := item.(map[string]any)
+			name, _ := definition["name"].(string)
+			if selected, ok := members[name]; ok {
+				trimMembers(definition, selected)
+			} else if !slices.Contains(names, name) && !isBuiltin(definition) {
+				continue
+			}
+			kept = append(kept, item)
+		}
+		document[key] = kept
+	}
+	events := selection["events"]
+	defines, _ := document["defines"].([]any)
+	for _, item := range defines {
+		define, _ := item.(map[string]any)
+		if define["name"] != "events" {
+			continue
+		}
+		list, _ := define["values"].([]any)
+		var values []any
+		for _, item := range list {
+			value, _ := item.(map[string]any)
+			if name, _ := value["name"].(string); slices.Contains(events, name) {
+				values = append(values, item)
+			}
+		}
+		define["values"] = values
+	}
+}
+
+// isBuiltin reports whether a concept or type is a builtin: its type is
+// "builtin" in the prototype document and {"complex_type": "builtin"} in the
+// runtime one.
+func isBuiltin(definition map[string]any) bool {
+	if definition["type"] == "builtin" {
+		return true
+	}
+	t, _ := definition["type"].(map[string]any)
+	return t != nil && t["complex_type"] == "builtin"
+}
+
+// trimMembers keeps the named members of a class.
+func trimMembers(class map[string]any, names []string) {
+	for _, key := range []string{"attributes", "methods"} {
+		list, _ := class[key].([]any)
+		kept := []any{}
+		for _, item := range list {
+			member, _ := item.(map[string]any)
+			if name, _ := member["name"].(string); slices.Contains(names, name) {
+				kept = append(kept, item)
+			}
+		}
+		class[key] = kept
+	}
+}
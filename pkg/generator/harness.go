@@ -0,0 +1,299 @@
+package generator
+
+// luaHarness is the mock harness written next to the runtime stubs with
+// FormatStubs. It replaces the stubs of the objects control-stage code talks to
+// with fakes that keep state: script records the handlers a mod registers and the
+// harness dispatches events to them, game holds the tick and the players, and
+// remote calls the interfaces mods add. It is written in the Lua 5.2 subset the
+// game runs, as tests may run on any version.
+const luaHarness = `-- Mock harness for testing control-stage code outside the game, generated by
+-- factorio-api-gen. Load it after the runtime stubs, e.g. in a busted helper:
+--
+--   dofile("path/to/stubs/runtime.lua")
+--   harness = dofile("path/to/stubs/harness.lua")
+--
+-- harness.reset() replaces game, script, settings, remote and storage with fakes
+-- (see its options), after which the mod's control.lua registers its handlers as
+-- in the game, and the harness raises events for it:
+--
+--   before_each(function()
+--     harness.reset({players = {{name = "tester"}}, settings = {startup = {["my-setting"] = true}}})
+--     dofile("control.lua")
+--     harness.init()
+--   end)
+--
+--   it("counts built entities", function()
+--     harness.raise("on_built_entity", {entity = {name = "iron-chest"}, player_index = 1})
+--     harness.tick(60)
+--     assert.are.equal(1, storage.built)
+--   end)
+--
+-- Event filters are accepted but not applied: every raised event reaches its
+-- handler. Everything not faked here is a stub from runtime.lua, which tests can
+-- replace (e.g. game.surfaces, or stub(game, "create_force") with busted).
+
+local harness = {}
+
+local handlers -- Event handlers by event id or custom input name
+local nth_tick_handlers -- on_nth_tick handlers by period
+local lifecycle -- The on_init, on_load and on_configuration_changed handlers
+local interfaces -- Remote interfaces by name
+local next_event_id -- The id the next script.generate_event_name() returns
+
+-- event_id resolves an event given by the name of a defines.events value.
+local function event_id(event)
+  if type(event) == "string" and defines.events[event] ~= nil then
+    return defines.events[event]
+  end
+  return event
+end
+
+-- each calls f with every element of a list, or with the value if it isn't a table.
+local function each(value, f)
+  if type(value) == "table" then
+    for _, element in ipairs(value) do
+      f(element)
+    end
+  else
+    f(value)
+  end
+end
+
+-- copy returns a shallow copy of a table, or an empty table for nil.
+local function copy(t)
+  local result = {}
+  for key, value in pairs(t or {}) do
+    result[key] = value
+  end
+  return result
+end
+
+-- setting_values wraps setting values as the game does: settings.startup["name"].value.
+local function setting_values(values)
+  local result = {}
+  for name, value in pairs(values or {}) do
+    result[name] = {value = value}
+  end
+  return result
+end
+
+-- fake_game builds game with the players of the options, which game.players and
+-- game.get_player find by index and by name.
+local function fake_game(options)
+  local players, by_name, connected = {}, {}, {}
+  for i, player in ipairs(options.players or {}) do
+    player.index = player.index or i
+    player.name = player.name or ("player" .. i)
+    if player.connected == nil then
+      player.connected = true
+    end
+    players[player.index] = player
+    by_name[player.name] = player
+    if player.connected then
+      table.insert(connected, player)
+    end
+  end
+  setmetatable(players, {__index = function(_, key) return by_name[key] end})
+
+  local fake = setmetatable({
+    tick = options.tick or 0,
+    players = players,
+    connected_players = connected,
+  }, {__index = LuaGameScript})
+  function fake.get_player(player)
+    return players[player]
+  end
+  function fake.print(message)
+    table.insert(harness.printed, message)
+  end
+  for key, value in pairs(options.game or {}) do
+    fake[key] = value
+  end
+  return fake
+end
+
+-- fake_script builds script, recording the handlers registered with it.
+local function fake_script(options)
+  local fake = setmetatable({
+    mod_name = options.mod_name or "test-mod",
+    active_mods = options.active_mods or {},
+    level = {level_name = "test", is_simulation = false, is_tutorial = false},
+  }, {__index = LuaBootstrap})
+  function fake.on_init(handler)
+    lifecycle.init = handler
+  end
+  function fake.on_load(handler)
+    lifecycle.load = handler
+  end
+  function fake.on_configuration_changed(handler)
+    lifecycle.configuration_changed = handler
+  end
+  function fake.on_event(event, handler, filters)
+    each(event, function(id) handlers[id] = handler end)
+  end
+  function fake.on_nth_tick(tick, handler)
+    if tick == nil then
+      nth_tick_handlers = {}
+      return
+    end
+    each(tick, function(period) nth_tick_handlers[period] = handler end)
+  end
+  function fake.get_event_handler(event)
+    return handlers[event]
+  end
+  function fake.generate_event_name()
+    next_event_id = next_event_id + 1
+    return next_event_id - 1
+  end
+  function fake.raise_event(event, data)
+    harness.raise(event, data)
+  end
+  return fake
+end
+
+-- fake_remote builds remote, calling the functions of the interfaces added to it.
+local function fake_remote()
+  local fake = setmetatable({interfaces = {}}, {__index = LuaRemote})
+  function fake.add_interface(name, functions)
+    if interfaces[name] then
+      error("Remote interface " .. name .. " already exists.")
+    end
+    interfaces[name] = functions
+    fake.interfaces[name] = {}
+    for function_name in pairs(functions) do
+      fake.interfaces[name][function_name] = true
+    end
+  end
+  function fake.remove_interface(name)
+    local removed = interfaces[name] ~= nil
+    interfaces[name] = nil
+    fake.interfaces[name] = nil
+    return removed
+  end
+  function fake.call(interface, function_, ...)
+    if not interfaces[interface] then
+      error("Unknown interface: " .. tostring(interface))
+    end
+    local f = interfaces[interface][function_]
+    if not f then
+      error("No such function: " .. tostring(interface) .. "." .. tostring(function_))
+    end
+    return f(...)
+  end
+  return fake
+end
+
+---Resets the harness: empties storage, forgets every handler and remote
+---interface, and replaces game, script, settings and remote with new fakes.
+---Options (all optional):
+---  tick: the tick of game.tick, 0 by default
+---  players: the players of game.players, as tables, which get an index, a name
+---    and connected = true unless they have them
+---  game: more fields of game, e.g. surfaces
+---  mod_name, active_mods: the fields of script
+---  settings: the values of the startup, global and player settings by name, e.g.
+---    {startup = {["my-setting"] = 10}}
+---@param options table?
+---@return table harness
+function harness.reset(options)
+  options = options or {}
+  handlers, nth_tick_handlers, lifecycle, interfaces = {}, {}, {}, {}
+  -- Generated event ids follow the ids of the game's events.
+  next_event_id = 0
+  for _, id in pairs(defines.events) do
+    if type(id) == "number" and id >= next_event_id then
+      next_event_id = id + 1
+    end
+  end
+  harness.printed = {} -- The messages of game.print, in order
+  harness.logged = {} -- The messages of log, in order
+
+  storage = {}
+  game = fake_game(options)
+  script = fake_script(options)
+  remote = fake_remote()
+  local values = options.settings or {}
+  settings = setmetatable({
+    startup = setting_values(values.startup),
+    global = setting_values(values.global),
+    player = setting_values(values.player),
+  }, {__index = LuaSettings})
+  function settings.get_player_settings(player)
+    return settings.player
+  end
+  log = function(message) table.insert(harness.logged, message) end
+  return harness
+end
+
+---Runs the on_init handler, as when the mod is added to a new or existing save.
+function harness.init()
+  if lifecycle.init then
+    lifecycle.init()
+  end
+end
+
+---Runs the on_load handler, as when a save with the mod is loaded.
+function harness.load()
+  if lifecycle.load then
+    lifecycle.load()
+  end
+end
+
+---Runs the on_configuration_changed handler with the given data, or data
+---reporting no changes.
+---@param data table?
+function harness.configuration_changed(data)
+  if lifecycle.configuration_changed then
+    lifecycle.configuration_changed(data or {mod_changes = {}, mod_startup_settings_changed = false, migration_applied = false})
+  end
+end
+
+---Raises an event: calls its handler with a copy of data, to which the event id
+---(name) and game.tick (tick) are added, as the game does. The event is an id of
+---defines.events, the name of one (e.g. "on_tick"), a custom input name or an id
+---from script.generate_event_name().
+---@param event integer|string
+---@param data table?
+---@return boolean handled Whether a handler was registered
+function harness.raise(event, data)
+  local id = event_id(event)
+  local handler = handlers[id]
+  if not handler then
+    return false
+  end
+  local payload = copy(data)
+  payload.name = id
+  payload.tick = game.tick
+  if type(id) == "string" then
+    payload.input_name = id
+  end
+  handler(payload)
+  return true
+end
+
+---Advances game.tick by count ticks (1 by default), raising on_tick and running
+---the on_nth_tick handlers due on each.
+---@param count integer?
+function harness.tick(count)
+  for _ = 1, count or 1 do
+    game.tick = game.tick + 1
+    harness.raise(defines.events.on_tick)
+    -- A copy, as handlers may register others.
+    for period, handler in pairs(copy(nth_tick_handlers)) do
+      if game.tick % period == 0 then
+        handler({tick = game.tick, nth_tick = period})
+      end
+    end
+  end
+end
+
+---Returns the handler registered for an event (given as for harness.raise).
+---@param event integer|string
+---@return function?
+function harness.handler(event)
+  return handlers[event_id(event)]
+end
+
+harness.reset()
+return harness
+`
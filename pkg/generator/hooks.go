@@ -0,0 +1,77 @@
+package generator
+
+import (
+	"slices"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+// Hook lets programs embedding the generator patch the API before generation,
+// e.g. to correct a type that is known to be wrong upstream, and the generated
+// files afterwards, e.g. to inject extra definitions. Embed NoopHook to implement
+// only the methods needed.
+type Hook interface {
+	// TransformClass is called for every runtime class before it is generated.
+	TransformClass(class *api.Class)
+	// TransformConcept is called for every concept and prototype type.
+	TransformConcept(concept *api.Concept)
+	// TransformPrototype is called for every prototype definition.
+	TransformPrototype(prototype *api.Prototype)
+	// RewriteOutput is called for every generated file, with its path relative to
+	// the output directory, and returns the content to write instead. Files are
+	// passed through all hooks as soon as they are complete, in the order they are
+	// generated.
+	RewriteOutput(filename string, content string) string
+}
+
+// NoopHook implements Hook without changing anything.
+type NoopHook struct{}
+
+func (NoopHook) TransformClass(*api.Class)         {}
+func (NoopHook) TransformConcept(*api.Concept)     {}
+func (NoopHook) TransformPrototype(*api.Prototype) {}
+
+func (NoopHook) RewriteOutput(_ string, content string) string { return content }
+
+// AddHook registers a hook. Hooks run in the order they were added.
+func (g *Generator) AddHook(hook Hook) {
+	g.hooks = append(g.hooks, hook)
+}
+
+// transformAPI returns a copy of a parsed API with the registered hooks applied
+// to its classes, concepts and prototypes. The hooks work on copies of the
+// entities, but slices nested in them are shared with the original, so a hook
+// should replace rather than modify them in place. A nil API stays nil.
+func (g *Generator) transformAPI(a *api.API) *api.API {
+	if a == nil || len(g.hooks) == 0 {
+		return a
+	}
+	transformed := *a
+	transformed.Classes = slices.Clone(a.Classes)
+	transformed.Concepts = slices.Clone(a.Concepts)
+	transformed.Types = slices.Clone(a.Types)
+	transformed.Prototypes = slices.Clone(a.Prototypes)
+	for _, hook := range g.hooks {
+		for i := range transformed.Classes {
+			hook.TransformClass(&transformed.Classes[i])
+		}
+		for i := range transformed.Concepts {
+			hook.TransformConcept(&transformed.Concepts[i])
+		}
+		for i := range transformed.Types {
+			hook.TransformConcept(&transformed.Types[i])
+		}
+		for i := range transformed.Prototypes {
+			hook.TransformPrototype(&transformed.Prototypes[i])
+		}
+	}
+	return &transformed
+}
+
+// rewriteOutput passes a generated file through the registered hooks.
+func (g *Generator) rewriteOutput(filename string, content string) string {
+	for _, hook := range g.hooks {
+		content = hook.RewriteOutput(filename, content)
+	}
+	return content
+}
@@ -0,0 +1,314 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+// htmlDir is the directory the HTML site is written to, relative to the output directory.
+const htmlDir = "site"
+
+// htmlPageTemplate lays out every page of the HTML site: a sidebar with the search
+// box and the index, and the page content converted from the Markdown reference.
+var htmlPageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} - Factorio API {{.GameVersion}}</title>
+<link rel="stylesheet" href="{{.Root}}style.css">
+</head>
+<body>
+<nav>
+<a class="home" href="{{.Root}}index.html">Factorio API {{.GameVersion}}</a>
+<input id="search" type="search" placeholder="Search" autocomplete="off">
+<ul id="results"></ul>
+</nav>
+<main>
+{{.Content}}
+</main>
+<script>const root = {{.Root}};</script>
+<script src="{{.Root}}search.js"></script>
+</body>
+</html>
+`))
+
+// htmlPageData is passed to htmlPageTemplate.
+type htmlPageData struct {
+	Title       string
+	GameVersion string
+	Root        string // Relative path to the site root, e.g. "../"
+	Content     template.HTML
+}
+
+// htmlStyle is the site's stylesheet.
+const htmlStyle = `body { margin: 0; display: flex; font-family: sans-serif; line-height: 1.5; }
+nav { position: sticky; top: 0; height: 100vh; width: 18em; flex-shrink: 0; overflow-y: auto; padding: 1em; box-sizing: border-box; background: #f4f4f4; }
+nav .home { display: block; font-weight: bold; margin-bottom: 1em; }
+nav input { width: 100%; box-sizing: border-box; }
+nav ul { list-style: none; padding: 0; }
+main { padding: 1em 2em; max-width: 60em; min-width: 0; }
+pre { background: #f4f4f4; padding: 0.5em; overflow-x: auto; }
+code { background: #f4f4f4; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ddd; padding: 0.25em 0.5em; vertical-align: top; text-align: left; }
+`
+
+// htmlSearch filters the search index as the user types. The index is loaded
+// lazily, so pages open without waiting for it.
+const htmlSearch = `(function () {
+  const input = document.getElementById("search");
+  const results = document.getElementById("results");
+  let index = null;
+  input.addEventListener("input", async function () {
+    if (index === null) {
+      index = await (await fetch(root + "search-index.json")).json();
+    }
+    const query = input.value.trim().toLowerCase();
+    results.replaceChildren();
+    if (query === "") {
+      return;
+    }
+    const matches = index.filter((entry) => entry.name.toLowerCase().includes(query));
+    matches.sort((a, b) => a.name.length - b.name.length);
+    for (const entry of matches.slice(0, 50)) {
+      const link = document.createElement("a");
+      link.href = root + entry.url;
+      link.textContent = entry.name;
+      link.title = entry.kind;
+      const item = document.createElement("li");
+      item.append(link);
+      results.append(item);
+    }
+  });
+})();
+`
+
+// htmlSearchEntry is an entry of search-index.json.
+type htmlSearchEntry struct {
+	Name string `json:"name"` // e.g. "LuaSurface.create_entity"
+	Kind string `json:"kind"` // "class", "attribute", "method", "event", "concept", "define", "prototype" or "type"
+	URL  string `json:"url"`  // Relative to the site root
+}
+
+// generateHTML generates a static HTML site from the Markdown reference, for
+// browsing the API offline, with a search index over every documented name. The
+// site is keyed to the game version the definitions were generated from. Either
+// API may be nil when only one stage is generated.
+func (g *Generator) generateHTML(runtimeAPI *api.API, prototypeAPI *api.API) (map[string]string, error) {
+	gameVersion := firstAPI(runtimeAPI, prototypeAPI).ApplicationVersion
+	files := map[string]string{
+		htmlDir + "/style.css": htmlStyle,
+		htmlDir + "/search.js": htmlSearch,
+	}
+	for path, markdown := range g.generateMarkdown(runtimeAPI, prototypeAPI) {
+		page := strings.TrimSuffix(strings.TrimPrefix(path, markdownDir+"/"), ".md")
+		if page == "README" {
+			page = "index"
+		}
+		title, _, _ := strings.Cut(strings.TrimPrefix(markdown, "# "), "\n")
+		var sb strings.Builder
+		err := htmlPageTemplate.Execute(&sb, htmlPageData{
+			Title:       title,
+			GameVersion: gameVersion,
+			Root:        strings.Repeat("../", strings.Count(page, "/")),
+			Content:     template.HTML(markdownToHTML(markdown)),
+		})
+		if err != nil {
+			return nil, err
+		}
+		files[htmlDir+"/"+page+".html"] = sb.String()
+	}
+
+	index, err := json.Marshal(htmlSearchIndex(runtimeAPI, prototypeAPI))
+	if err != nil {
+		return nil, err
+	}
+	files[htmlDir+"/search-index.json"] = string(index) + "\n"
+	return files, nil
+}
+
+// htmlSearchIndex lists every page and member of the reference, with anchors
+// matching the headings of the Markdown pages.
+func htmlSearchIndex(runtimeAPI *api.API, prototypeAPI *api.API) []htmlSearchEntry {
+	entries := []htmlSearchEntry{}
+	if runtimeAPI != nil {
+		for _, class := range sortedByOrder(runtimeAPI.Classes) {
+			page := "classes/" + class.Name + ".html"
+			entries = append(entries, htmlSearchEntry{Name: class.Name, Kind: "class", URL: page})
+			for _, attribute := range sortedByOrder(slices.Concat(class.Attributes, class.Properties)) {
+				entries = append(entries, htmlSearchEntry{Name: class.Name + "." + attribute.Name, Kind: "attribute", URL: page + "#" + htmlAnchor(attribute.Name)})
+			}
+			for _, method := range sortedByOrder(class.Methods) {
+				entries = append(entries, htmlSearchEntry{Name: class.Name + "." + method.Name, Kind: "method", URL: page + "#" + htmlAnchor(method.Name)})
+			}
+		}
+		for _, event := range sortedByOrder(runtimeAPI.Events) {
+			entries = append(entries, htmlSearchEntry{Name: event.Name, Kind: "event", URL: "events.html#" + htmlAnchor(event.Name)})
+		}
+		for _, concept := range sortedByOrder(runtimeAPI.Concepts) {
+			entries = append(entries, htmlSearchEntry{Name: concept.Name, Kind: "concept", URL: "concepts.html#" + htmlAnchor(concept.Name)})
+		}
+		var addDefines func(defines []api.Define, prefix string)
+		addDefines = func(defines []api.Define, prefix string) {
+			for _, define := range sortedByOrder(defines) {
+				name := prefix + "." + define.Name
+				entries = append(entries, htmlSearchEntry{Name: name, Kind: "define", URL: "defines.html#" + htmlAnchor(name)})
+				addDefines(define.Subkeys, name)
+			}
+		}
+		addDefines(runtimeAPI.Defines, "defines")
+	}
+	if prototypeAPI != nil {
+		for _, prototype := range sortedByOrder(prototypeAPI.Prototypes) {
+			entries = append(entries, htmlSearchEntry{Name: prototype.Name, Kind: "prototype", URL: "prototypes/" + prototype.Name + ".html"})
+		}
+		for _, concept := range sortedByOrder(slices.Concat(prototypeAPI.Concepts, prototypeAPI.Types)) {
+			entries = append(entries, htmlSearchEntry{Name: concept.Name, Kind: "type", URL: "types.html#" + htmlAnchor(concept.Name)})
+		}
+	}
+	return entries
+}
+
+// htmlAnchor returns the id of a heading, following GitHub's rules so the links
+// of the Markdown reference (such as concepts.md#eventfilter) work in both.
+func htmlAnchor(heading string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '-':
+			sb.WriteRune(r)
+		case r == ' ':
+			sb.WriteRune('-')
+		}
+	}
+	return sb.String()
+}
+
+var (
+	markdownHeadingPattern   = regexp.MustCompile(`^(#{1,6}) (.*)$`)
+	markdownListItemPattern  = regexp.MustCompile(`^\s*(?:[-*]|\d+\.) (.*)$`)
+	markdownTableRulePattern = regexp.MustCompile(`^\|(?:-+\|)+$`)
+	markdownInlinePattern    = regexp.MustCompile("`[^`]*`|\\[[^\\]]*\\]\\([^)]*\\)|\\*\\*[^*]+\\*\\*")
+	markdownLinkPattern      = regexp.MustCompile(`^\[([^\]]*)\]\(([^)]*)\)$`)
+)
+
+// markdownToHTML converts the Markdown of the reference to HTML. It covers what
+// the reference and the API descriptions use: headings, paragraphs, fenced code,
+// lists and tables, with code spans, links and bold text inline. Links to other
+// pages of the reference are pointed at their HTML version.
+func markdownToHTML(markdown string) string {
+	var sb strings.Builder
+	lines := strings.Split(markdown, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			continue
+
+		case strings.HasPrefix(line, "```"):
+			language := strings.TrimPrefix(line, "```")
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(lines[i], "```"); i++ {
+				code = append(code, lines[i])
+			}
+			class := ""
+			if language != "" {
+				class = fmt.Sprintf(` class="language-%s"`, html.EscapeString(language))
+			}
+			sb.WriteString(fmt.Sprintf("<pre><code%s>%s</code></pre>\n", class, html.EscapeString(strings.Join(code, "\n"))))
+
+		case markdownHeadingPattern.MatchString(line):
+			parts := markdownHeadingPattern.FindStringSubmatch(line)
+			level := len(parts[1])
+			sb.WriteString(fmt.Sprintf("<h%d id=\"%s\">%s</h%d>\n", level, htmlAnchor(parts[2]), markdownInline(parts[2]), level))
+
+		case strings.HasPrefix(line, "|"):
+			sb.WriteString("<table>\n")
+			header := true
+			for ; i < len(lines) && strings.HasPrefix(lines[i], "|"); i++ {
+				if markdownTableRulePattern.MatchString(lines[i]) {
+					continue
+				}
+				cell := "td"
+				if header {
+					cell = "th"
+					header = false
+				}
+				sb.WriteString("<tr>")
+				for _, content := range markdownTableCells(lines[i]) {
+					sb.WriteString(fmt.Sprintf("<%s>%s</%s>", cell, markdownInline(content), cell))
+				}
+				sb.WriteString("</tr>\n")
+			}
+			i--
+			sb.WriteString("</table>\n")
+
+		case markdownListItemPattern.MatchString(line):
+			sb.WriteString("<ul>\n")
+			for ; i < len(lines) && markdownListItemPattern.MatchString(lines[i]); i++ {
+				sb.WriteString("<li>" + markdownInline(markdownListItemPattern.FindStringSubmatch(lines[i])[1]) + "</li>\n")
+			}
+			i--
+			sb.WriteString("</ul>\n")
+
+		default:
+			paragraph := []string{line}
+			for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" && !strings.HasPrefix(lines[i+1], "```") &&
+				!markdownHeadingPattern.MatchString(lines[i+1]) && !markdownListItemPattern.MatchString(lines[i+1]) {
+				i++
+				paragraph = append(paragraph, lines[i])
+			}
+			sb.WriteString("<p>" + markdownInline(strings.Join(paragraph, "\n")) + "</p>\n")
+		}
+	}
+	return sb.String()
+}
+
+// markdownTableCells splits a table row into its cells, keeping escaped pipes.
+func markdownTableCells(row string) []string {
+	row = strings.TrimSuffix(strings.TrimPrefix(row, "|"), "|")
+	cells := strings.Split(strings.ReplaceAll(row, `\|`, "\x00"), "|")
+	for i, cell := range cells {
+		cells[i] = strings.TrimSpace(strings.ReplaceAll(cell, "\x00", "|"))
+	}
+	return cells
+}
+
+// markdownInline converts code spans, links and bold text, escaping everything else.
+func markdownInline(text string) string {
+	var sb strings.Builder
+	last := 0
+	for _, match := range markdownInlinePattern.FindAllStringIndex(text, -1) {
+		sb.WriteString(html.EscapeString(text[last:match[0]]))
+		token := text[match[0]:match[1]]
+		switch {
+		case strings.HasPrefix(token, "`"):
+			sb.WriteString("<code>" + html.EscapeString(strings.Trim(token, "`")) + "</code>")
+		case strings.HasPrefix(token, "**"):
+			sb.WriteString("<strong>" + html.EscapeString(strings.Trim(token, "*")) + "</strong>")
+		default:
+			parts := markdownLinkPattern.FindStringSubmatch(token)
+			url := parts[2]
+			if !strings.Contains(url, "://") {
+				// A page of the reference: "LuaEntity.md" or "concepts.md#eventfilter".
+				page, anchor, hasAnchor := strings.Cut(url, "#")
+				url = strings.TrimSuffix(page, ".md") + ".html"
+				if hasAnchor {
+					url += "#" + anchor
+				}
+			}
+			sb.WriteString(fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(url), html.EscapeString(parts[1])))
+		}
+		last = match[1]
+	}
+	sb.WriteString(html.EscapeString(text[last:]))
+	return sb.String()
+}
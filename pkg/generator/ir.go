@@ -0,0 +1,499 @@
+package generator
+
+import (
+	"cmp"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+// IRSchemaVersion is the version of the ir-json schema. It is bumped whenever a
+// field is removed or changes meaning; new fields may be added without a bump.
+const IRSchemaVersion = 1
+
+// The IR (intermediate representation) is the API as the generator sees it, after
+// filtering and hooks: type wrappers are resolved, references say what they refer
+// to, inheritance is linked into ancestor lists and descriptions are rendered with
+// absolute links. Everything is listed in the documented order. It is written by
+// `--format ir-json` for other tools to consume instead of the raw API format.
+
+// IRDocument is the root of ir.json. A stage left out with --only is omitted.
+type IRDocument struct {
+	SchemaVersion    int               `json:"schema_version"`
+	GameVersion      string            `json:"game_version,omitempty"`
+	GeneratorVersion string            `json:"generator_version"`
+	Runtime          *IRRuntimeStage   `json:"runtime,omitempty"`
+	Prototype        *IRPrototypeStage `json:"prototype,omitempty"`
+}
+
+// IRRuntimeStage holds the runtime API.
+type IRRuntimeStage struct {
+	Classes  []IRClass   `json:"classes"`
+	Events   []IREvent   `json:"events"`
+	Defines  []IRDefine  `json:"defines"`
+	Globals  []IRField   `json:"globals"`
+	Concepts []IRConcept `json:"concepts"`
+}
+
+// IRPrototypeStage holds the prototype API.
+type IRPrototypeStage struct {
+	Prototypes []IRPrototype `json:"prototypes"`
+	Types      []IRConcept   `json:"types"`
+	Defines    []IRDefine    `json:"defines"`
+}
+
+// IRType is a resolved type. Kind is one of "named", "array", "dictionary",
+// "custom_table", "union", "literal", "tuple", "function", "table" (named fields),
+// "builtin" (the type of a builtin concept, which has no structure of its own) or
+// "any"; the other fields are set depending on it.
+type IRType struct {
+	Kind string `json:"kind"`
+	// Name and Ref describe a named type; Ref is what the name refers to:
+	// "builtin", "class", "concept", "prototype" or "define", or empty for the few
+	// names the API uses without documenting them.
+	Name    string      `json:"name,omitempty"`
+	Ref     string      `json:"ref,omitempty"`
+	Element *IRType     `json:"element,omitempty"` // Array element
+	Key     *IRType     `json:"key,omitempty"`     // Dictionary and custom table key
+	Value   *IRType     `json:"value,omitempty"`   // Dictionary and custom table value
+	Members []IRType    `json:"members,omitempty"` // Union options, tuple elements, function parameters
+	Literal interface{} `json:"literal,omitempty"`
+	Fields  []IRField   `json:"fields,omitempty"` // Table fields
+}
+
+// IRField is an attribute, property, parameter, event field or global.
+type IRField struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	Type        IRType  `json:"type"`
+	WriteType   *IRType `json:"write_type,omitempty"` // Set when writing takes another type than reading
+	Optional    bool    `json:"optional,omitempty"`
+	Nullable    bool    `json:"nullable,omitempty"`
+	// Readonly and Writeonly are only set for runtime attributes.
+	Readonly   bool        `json:"readonly,omitempty"`
+	Writeonly  bool        `json:"writeonly,omitempty"`
+	AltName    string      `json:"alt_name,omitempty"`
+	Default    interface{} `json:"default,omitempty"`
+	Deprecated bool        `json:"deprecated,omitempty"`
+	// Variant names the parameter group a variant parameter belongs to.
+	Variant string `json:"variant,omitempty"`
+}
+
+// IRMethod is a method of a runtime class.
+type IRMethod struct {
+	Name          string     `json:"name"`
+	Description   string     `json:"description,omitempty"`
+	Parameters    []IRField  `json:"parameters"`
+	TakesTable    bool       `json:"takes_table,omitempty"`
+	TableOptional bool       `json:"table_optional,omitempty"`
+	Variadic      *IRType    `json:"variadic,omitempty"`
+	Returns       []IRReturn `json:"returns"`
+	Deprecated    bool       `json:"deprecated,omitempty"`
+}
+
+// IRReturn is a return value of a method.
+type IRReturn struct {
+	Description string `json:"description,omitempty"`
+	Type        IRType `json:"type"`
+	Optional    bool   `json:"optional,omitempty"`
+}
+
+// IRClass is a runtime class. Ancestors lists every class it inherits from,
+// nearest first; Fields and Methods only hold its own members.
+type IRClass struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Abstract    bool       `json:"abstract,omitempty"`
+	Parents     []string   `json:"parents,omitempty"`
+	Ancestors   []string   `json:"ancestors,omitempty"`
+	Fields      []IRField  `json:"fields"`
+	Methods     []IRMethod `json:"methods"`
+	Operators   []string   `json:"operators,omitempty"`
+}
+
+// IREvent is a runtime event with the fields of its event data.
+type IREvent struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Filter      string    `json:"filter,omitempty"`
+	Fields      []IRField `json:"fields"`
+}
+
+// IRDefine is a define, named by its full path such as "defines.direction".
+type IRDefine struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Values      []IRDefineValue `json:"values,omitempty"`
+	Subkeys     []IRDefine      `json:"subkeys,omitempty"`
+}
+
+// IRDefineValue is a value of a define. Value is only set where the API gives one.
+type IRDefineValue struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Value       interface{} `json:"value,omitempty"`
+}
+
+// IRConcept is a runtime concept or prototype type. Fields holds the properties of
+// prototype types shaped as structs, which can inherit like prototypes.
+type IRConcept struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Abstract    bool      `json:"abstract,omitempty"`
+	Parents     []string  `json:"parents,omitempty"`
+	Ancestors   []string  `json:"ancestors,omitempty"`
+	Type        IRType    `json:"type"`
+	Fields      []IRField `json:"fields,omitempty"`
+}
+
+// IRPrototype is a prototype definition. TypeName is empty for abstract prototypes.
+type IRPrototype struct {
+	Name        string    `json:"name"`
+	TypeName    string    `json:"typename,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Abstract    bool      `json:"abstract,omitempty"`
+	Parent      string    `json:"parent,omitempty"`
+	Ancestors   []string  `json:"ancestors,omitempty"`
+	Fields      []IRField `json:"fields"`
+}
+
+// irBuilder builds the IR of one stage, knowing what every name refers to.
+type irBuilder struct {
+	g    *Generator
+	refs map[string]string // Name -> "builtin", "class", "concept" or "prototype"
+}
+
+// newIRBuilder resolves names in the stage's own API first and in the other
+// stage's API after that, since a few names (MapSettings) are documented by both.
+func newIRBuilder(g *Generator, stageAPI *api.API, otherAPI *api.API) *irBuilder {
+	b := &irBuilder{g: g, refs: make(map[string]string)}
+	for name := range nativeBuiltins {
+		b.refs[name] = "builtin"
+	}
+	for _, a := range []*api.API{otherAPI, stageAPI} {
+		if a == nil {
+			continue
+		}
+		for _, class := range a.Classes {
+			b.refs[class.Name] = "class"
+		}
+		for _, prototype := range a.Prototypes {
+			b.refs[prototype.Name] = "prototype"
+		}
+		for _, concept := range slices.Concat(a.Concepts, a.Types) {
+			b.refs[concept.Name] = "concept"
+			if isBuiltinConcept(concept) {
+				b.refs[concept.Name] = "builtin"
+			}
+		}
+	}
+	return b
+}
+
+// generateIR returns ir.json for the given APIs; either may be nil.
+func (g *Generator) generateIR(runtimeAPI *api.API, prototypeAPI *api.API) (map[string]string, error) {
+	doc := IRDocument{SchemaVersion: IRSchemaVersion, GeneratorVersion: Version}
+	if runtimeAPI != nil {
+		doc.GameVersion = runtimeAPI.ApplicationVersion
+		doc.Runtime = newIRBuilder(g, runtimeAPI, prototypeAPI).runtimeStage(runtimeAPI)
+	}
+	if prototypeAPI != nil {
+		doc.GameVersion = cmp.Or(doc.GameVersion, prototypeAPI.ApplicationVersion)
+		doc.Prototype = newIRBuilder(g, prototypeAPI, runtimeAPI).prototypeStage(prototypeAPI)
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"ir.json": string(data) + "\n"}, nil
+}
+
+func (b *irBuilder) runtimeStage(a *api.API) *IRRuntimeStage {
+	stage := &IRRuntimeStage{
+		Classes:  []IRClass{},
+		Events:   []IREvent{},
+		Globals:  []IRField{},
+		Defines:  b.defines(a.Defines),
+		Concepts: b.concepts(a.Concepts),
+	}
+	parents := make(map[string][]string)
+	for _, class := range a.Classes {
+		parents[class.Name] = class.Parent
+	}
+	for _, class := range sortedByOrder(a.Classes) {
+		c := IRClass{
+			Name:        class.Name,
+			Description: b.g.renderer.render(class.Description),
+			Abstract:    class.Abstract,
+			Parents:     class.Parent,
+			Ancestors:   ancestors(class.Name, parents),
+			Fields:      b.properties(slices.Concat(class.Attributes, class.Properties)),
+			Methods:     []IRMethod{},
+		}
+		for _, method := range sortedByOrder(class.Methods) {
+			c.Methods = append(c.Methods, b.method(method))
+		}
+		for _, operator := range sortedByOrder(class.Operators) {
+			c.Operators = append(c.Operators, operator.Name)
+		}
+		stage.Classes = append(stage.Classes, c)
+	}
+	for _, event := range sortedByOrder(a.Events) {
+		stage.Events = append(stage.Events, IREvent{
+			Name:        event.Name,
+			Description: b.g.renderer.render(event.Description),
+			Filter:      event.Filter,
+			Fields:      b.parameters(event.Data, ""),
+		})
+	}
+	for _, global := range sortedByOrder(a.GlobalObjects) {
+		stage.Globals = append(stage.Globals, IRField{
+			Name:        global.Name,
+			Description: b.g.renderer.render(global.Description),
+			Type:        b.irType(global.Type),
+		})
+	}
+	return stage
+}
+
+func (b *irBuilder) prototypeStage(a *api.API) *IRPrototypeStage {
+	stage := &IRPrototypeStage{
+		Prototypes: []IRPrototype{},
+		Types:      b.concepts(slices.Concat(a.Concepts, a.Types)),
+		Defines:    b.defines(a.Defines),
+	}
+	parents := make(map[string][]string)
+	for _, prototype := range a.Prototypes {
+		if prototype.Parent != "" {
+			parents[prototype.Name] = []string{prototype.Parent}
+		}
+	}
+	for _, prototype := range sortedByOrder(a.Prototypes) {
+		stage.Prototypes = append(stage.Prototypes, IRPrototype{
+			Name:        prototype.Name,
+			TypeName:    prototype.TypeName,
+			Description: b.g.renderer.render(prototype.Description),
+			Abstract:    prototype.Abstract,
+			Parent:      prototype.Parent,
+			Ancestors:   ancestors(prototype.Name, parents),
+			Fields:      b.properties(prototype.Properties),
+		})
+	}
+	return stage
+}
+
+// ancestors lists the ancestors of name breadth-first, nearest first and each once.
+func ancestors(name string, parents map[string][]string) []string {
+	var result []string
+	seen := map[string]bool{name: true}
+	queue := parents[name]
+	for len(queue) > 0 {
+		parent := queue[0]
+		queue = queue[1:]
+		if seen[parent] {
+			continue
+		}
+		seen[parent] = true
+		result = append(result, parent)
+		queue = append(queue, parents[parent]...)
+	}
+	return result
+}
+
+func (b *irBuilder) concepts(concepts []api.Concept) []IRConcept {
+	parents := make(map[string][]string)
+	for _, concept := range concepts {
+		parents[concept.Name] = concept.Parent
+	}
+	result := []IRConcept{}
+	for _, concept := range sortedByOrder(concepts) {
+		c := IRConcept{
+			Name:        concept.Name,
+			Description: b.g.renderer.render(concept.Description),
+			Abstract:    concept.Abstract,
+			Parents:     concept.Parent,
+			Ancestors:   ancestors(concept.Name, parents),
+			Type:        b.irType(concept.Type),
+		}
+		if len(concept.Properties) > 0 {
+			c.Fields = b.properties(concept.Properties)
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
+func (b *irBuilder) defines(defines []api.Define) []IRDefine {
+	result := []IRDefine{}
+	for _, define := range sortedByOrder(defines) {
+		result = append(result, b.define(define, "defines."+define.Name))
+	}
+	return result
+}
+
+func (b *irBuilder) define(define api.Define, fullName string) IRDefine {
+	d := IRDefine{Name: fullName, Description: b.g.renderer.render(define.Description)}
+	for _, value := range sortedByOrder(define.Values) {
+		d.Values = append(d.Values, IRDefineValue{
+			Name:        value.Name,
+			Description: b.g.renderer.render(value.Description),
+			Value:       value.Value,
+		})
+	}
+	for _, subkey := range sortedByOrder(define.Subkeys) {
+		d.Subkeys = append(d.Subkeys, b.define(subkey, fullName+"."+subkey.Name))
+	}
+	return d
+}
+
+func (b *irBuilder) method(method api.Method) IRMethod {
+	m := IRMethod{
+		Name:          method.Name,
+		Description:   b.g.renderer.render(method.Description),
+		Parameters:    b.parameters(method.Parameters, ""),
+		TakesTable:    method.Format.TakesTable,
+		TableOptional: method.Format.TableOptional,
+		Returns:       []IRReturn{},
+		Deprecated:    method.Deprecated,
+	}
+	for _, group := range sortedByOrder(method.VariantParameterGroups) {
+		m.Parameters = append(m.Parameters, b.parameters(group.Parameters, group.Name)...)
+	}
+	if method.VariadicParameter != nil {
+		variadic := b.irType(method.VariadicParameter.Type)
+		m.Variadic = &variadic
+	}
+	for _, result := range sortedByOrder(method.ReturnValues) {
+		m.Returns = append(m.Returns, IRReturn{
+			Description: b.g.renderer.render(result.Description),
+			Type:        b.irType(result.Type),
+			Optional:    result.Optional || result.Nullable,
+		})
+	}
+	return m
+}
+
+func (b *irBuilder) parameters(params []api.Parameter, variant string) []IRField {
+	result := []IRField{}
+	for _, param := range sortedByOrder(params) {
+		result = append(result, IRField{
+			Name:        param.Name,
+			Description: b.g.renderer.render(param.Description),
+			Type:        b.irType(param.Type),
+			Optional:    param.Optional,
+			Nullable:    param.Nullable,
+			Variant:     variant,
+		})
+	}
+	return result
+}
+
+func (b *irBuilder) properties(properties []api.Property) []IRField {
+	result := []IRField{}
+	for _, property := range sortedByOrder(properties) {
+		f := IRField{
+			Name:        property.Name,
+			Description: b.g.renderer.render(property.Description),
+			Type:        b.irType(property.ValueType()),
+			Optional:    property.Optional,
+			Nullable:    property.Nullable,
+			AltName:     property.AltName,
+			Default:     property.Default,
+			Deprecated:  property.Deprecated,
+		}
+		// Runtime attributes say how they can be accessed; prototype properties
+		// are plain table fields.
+		if property.ReadType != nil || property.WriteType != nil {
+			f.Readonly = !property.IsWritable()
+			f.Writeonly = !property.IsReadable()
+			if property.ReadType != nil && property.WriteType != nil {
+				if write := b.irType(*property.WriteType); !sameIRType(write, f.Type) {
+					f.WriteType = &write
+				}
+			}
+		}
+		result = append(result, f)
+	}
+	return result
+}
+
+// sameIRType reports whether two resolved types are identical.
+func sameIRType(a, b IRType) bool {
+	aJSON, _ := json.Marshal(a)
+	bJSON, _ := json.Marshal(b)
+	return string(aJSON) == string(bJSON)
+}
+
+// irType resolves an API type. Type wrappers are replaced by the type they wrap,
+// and named types are tagged with what they refer to.
+func (b *irBuilder) irType(t api.Type) IRType {
+	if t.ComplexType == "builtin" || (t.IsSimple() && t.Name == "builtin") {
+		return IRType{Kind: "builtin"}
+	}
+	if t.IsSimple() {
+		return b.named(t.Name)
+	}
+	child := func(t *api.Type) *IRType {
+		if t == nil {
+			return &IRType{Kind: "any"}
+		}
+		resolved := b.irType(*t)
+		return &resolved
+	}
+	list := func(types []api.Type) []IRType {
+		var result []IRType
+		for _, t := range types {
+			result = append(result, b.irType(t))
+		}
+		return result
+	}
+
+	switch t.ComplexType {
+	case "array":
+		return IRType{Kind: "array", Element: child(t.Value)}
+	case "dictionary":
+		return IRType{Kind: "dictionary", Key: child(t.Key), Value: child(t.Value)}
+	case "LuaCustomTable":
+		return IRType{Kind: "custom_table", Key: child(t.Key), Value: child(t.Value)}
+	case "union":
+		return IRType{Kind: "union", Members: list(t.Values)}
+	case "literal":
+		return IRType{Kind: "literal", Literal: t.LiteralValue}
+	case "type":
+		return *child(t.Value)
+	case "tuple":
+		return IRType{Kind: "tuple", Members: list(t.Values)}
+	case "function":
+		return IRType{Kind: "function", Members: list(t.Parameters)}
+	case "table":
+		fields := b.parameters(t.Fields, "")
+		for _, group := range sortedByOrder(t.VariantParameterGroups) {
+			fields = append(fields, b.parameters(group.Parameters, group.Name)...)
+		}
+		return IRType{Kind: "table", Fields: fields}
+	case "LuaStruct":
+		return IRType{Kind: "table", Fields: b.properties(t.Attributes)}
+	case "LuaLazyLoadedValue":
+		return b.named("LuaLazyLoadedValue")
+	case "struct":
+		if t.Name != "" {
+			return b.named(t.Name)
+		}
+		return IRType{Kind: "table"}
+	default:
+		if t.Name != "" {
+			return b.named(t.Name)
+		}
+		return IRType{Kind: "any"}
+	}
+}
+
+func (b *irBuilder) named(name string) IRType {
+	ref := b.refs[name]
+	if strings.HasPrefix(name, "defines.") {
+		ref = "define"
+	}
+	return IRType{Kind: "named", Name: name, Ref: ref}
+}
@@ -0,0 +1,99 @@
+package generator
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+// localeReference matches the references to other localised names in a
+// localised string, e.g. __ITEM__iron-plate__ (in [item-name] iron-plate).
+// Parameters such as __1__ don't match.
+var localeReference = regexp.MustCompile(`__([A-Z][A-Z_]*)__(.+?)__`)
+
+// prototypeLocaleSections returns, for each prototype typename, the locale
+// sections that may hold the names of its prototypes, nearest ancestor first: an
+// "ammo" is named in [ammo-item-name] if there were one, and otherwise in
+// [item-name], after ItemPrototype, as the game does.
+func prototypeLocaleSections(prototypeAPI *api.API) map[string][]string {
+	if prototypeAPI == nil {
+		return nil
+	}
+	byName := make(map[string]api.Prototype, len(prototypeAPI.Prototypes))
+	for _, prototype := range prototypeAPI.Prototypes {
+		byName[prototype.Name] = prototype
+	}
+	sections := make(map[string][]string)
+	for _, prototype := range prototypeAPI.Prototypes {
+		if prototype.TypeName == "" {
+			continue
+		}
+		seen := make(map[string]bool)
+		for p, ok := prototype, true; ok && !seen[p.Name]; p, ok = byName[p.Parent] {
+			seen[p.Name] = true
+			if p.Name == "PrototypeBase" || p.Name == "Prototype" {
+				break
+			}
+			sections[prototype.TypeName] = append(sections[prototype.TypeName], localeSectionName(p.Name))
+		}
+	}
+	return sections
+}
+
+// localeSectionName returns the locale section naming the prototypes of a
+// definition: ItemPrototype in [item-name], VirtualSignalPrototype in
+// [virtual-signal-name], ItemGroup in [item-group-name].
+func localeSectionName(definition string) string {
+	base := strings.TrimSuffix(definition, "Prototype")
+	var sb strings.Builder
+	for i, r := range base {
+		if unicode.IsUpper(r) && i > 0 {
+			sb.WriteByte('-')
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return sb.String() + "-name"
+}
+
+// localisedName returns the first string of the locale for key in the given
+// sections, with its references to other names resolved, or "".
+func (g *Generator) localisedName(sections []string, key string) string {
+	for _, section := range sections {
+		if name, ok := g.options.Locale[section][key]; ok {
+			return localeReference.ReplaceAllStringFunc(name, func(reference string) string {
+				m := localeReference.FindStringSubmatch(reference)
+				section := strings.ToLower(strings.ReplaceAll(m[1], "_", "-")) + "-name"
+				if resolved, ok := g.options.Locale[section][m[2]]; ok {
+					return resolved
+				}
+				return reference
+			})
+		}
+	}
+	return ""
+}
+
+// defineLocaleSection returns the locale section that would name the values of a
+// define: [alert-type] for defines.alert_type.
+func defineLocaleSection(fullName string) string {
+	return strings.NewReplacer("_", "-", ".", "-").Replace(strings.TrimPrefix(fullName, "defines."))
+}
+
+// withLocalisedName prepends the localised name of a define value to its
+// description.
+func (g *Generator) withLocalisedName(fullName string, value api.DefineValue) string {
+	sections := []string{defineLocaleSection(fullName)}
+	name := g.localisedName(sections, strings.ReplaceAll(value.Name, "_", "-"))
+	if name == "" {
+		name = g.localisedName(sections, value.Name)
+	}
+	if name == "" {
+		return value.Description
+	}
+	if value.Description == "" {
+		return name
+	}
+	return name + ". " + value.Description
+}
@@ -0,0 +1,62 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+// LuaRocksPackage is the name of the rock of the definitions.
+const LuaRocksPackage = "factorio-api-defs"
+
+// luaRocksVersion matches the game versions LuaRocks accepts as the version of
+// the rock, which a revision number is appended to.
+var luaRocksVersion = regexp.MustCompile(`^\d+(\.\d+)*$`)
+
+// luaRocksRockspec returns the name and content of the rockspec of the rock laying
+// out the LuaLS definitions for LuaRocks. The definitions are in luaLSAddonLibrary,
+// which the rock copies into its directory of the rocks tree rather than
+// installing as modules: they aren't meant to be required, only added to the
+// LuaLS library of the project depending on the rock.
+func (g *Generator) luaRocksRockspec(runtimeAPI *api.API, prototypeAPI *api.API) (string, string) {
+	gameVersion := firstAPI(runtimeAPI, prototypeAPI).ApplicationVersion
+	// A document without a version (or an unusual one) makes a development rock.
+	version := "dev"
+	if luaRocksVersion.MatchString(gameVersion) {
+		version = gameVersion
+	}
+	version += "-1"
+	summary := "LuaLS definitions of the Factorio API"
+	homepage := "https://lua-api.factorio.com/latest/"
+	if gameVersion != "" {
+		summary = fmt.Sprintf("LuaLS definitions of the Factorio %s API", gameVersion)
+		homepage = fmt.Sprintf("https://lua-api.factorio.com/%s/", gameVersion)
+	}
+	// luarocks make builds the rock from the directory of the rockspec whatever the
+	// source; publishing it needs the address of an archive of the output, such as
+	// the asset uploaded by the publish command.
+	source := g.options.RockSourceURL
+	if source == "" {
+		source = "."
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("-- Generated by factorio-api-gen %s\n", Version))
+	sb.WriteString("rockspec_format = \"3.0\"\n")
+	sb.WriteString(fmt.Sprintf("package = %s\n", luaString(LuaRocksPackage)))
+	sb.WriteString(fmt.Sprintf("version = %s\n", luaString(version)))
+	sb.WriteString(fmt.Sprintf("source = {\n  url = %s,\n}\n", luaString(source)))
+	sb.WriteString("description = {\n")
+	sb.WriteString(fmt.Sprintf("  summary = %s,\n", luaString(summary)))
+	sb.WriteString("  detailed = [[\nLuaLS (lua-language-server) annotations of the Factorio runtime and prototype APIs.\n")
+	sb.WriteString("The rock installs them in library/ of its directory in the rocks tree; add that\n")
+	sb.WriteString("directory to Lua.workspace.library.\n]],\n")
+	sb.WriteString(fmt.Sprintf("  homepage = %s,\n", luaString(homepage)))
+	sb.WriteString("  labels = { \"factorio\", \"luals\", \"definitions\" },\n")
+	sb.WriteString("}\n")
+	sb.WriteString("dependencies = {}\n")
+	sb.WriteString(fmt.Sprintf("build = {\n  type = \"none\",\n  copy_directories = { %s },\n}\n", luaString(strings.TrimSuffix(luaLSAddonLibrary, "/"))))
+	return fmt.Sprintf("%s-%s.rockspec", LuaRocksPackage, version), sb.String()
+}
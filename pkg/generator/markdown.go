@@ -0,0 +1,354 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+// markdownDir is the directory the Markdown reference is written to, relative to
+// the output directory.
+const markdownDir = "docs"
+
+// generateMarkdown generates an offline API reference in Markdown from the same
+// model as the definitions, so it documents exactly the version (and filters and
+// hooks) they were generated from. Every class and prototype gets a page of its
+// own; events, concepts, defines and prototype types each get a single page.
+// Signatures use the LuaLS types of the generated definitions. Either API may be
+// nil when only one stage is generated.
+func (g *Generator) generateMarkdown(runtimeAPI *api.API, prototypeAPI *api.API) map[string]string {
+	files := make(map[string]string)
+	var index strings.Builder
+	index.WriteString("# Factorio API reference\n\n")
+	if a := firstAPI(runtimeAPI, prototypeAPI); a.ApplicationVersion != "" {
+		index.WriteString(fmt.Sprintf("Generated for Factorio %s. Links in descriptions lead to the online documentation.\n\n", a.ApplicationVersion))
+	}
+
+	if runtimeAPI != nil {
+		index.WriteString("## Runtime stage\n\n")
+		index.WriteString("- [Events](events.md)\n- [Concepts](concepts.md)\n- [Defines](defines.md)\n\n")
+		index.WriteString("### Classes\n\n")
+		for _, class := range sortedByOrder(runtimeAPI.Classes) {
+			index.WriteString(fmt.Sprintf("- [%s](classes/%s.md)%s\n", class.Name, class.Name, g.markdownSummary(class.Description)))
+			files[markdownDir+"/classes/"+class.Name+".md"] = g.markdownClass(class)
+		}
+		index.WriteString("\n")
+		files[markdownDir+"/events.md"] = g.markdownEvents(runtimeAPI.Events)
+		files[markdownDir+"/concepts.md"] = g.markdownConcepts("Concepts", runtimeAPI.Concepts)
+		files[markdownDir+"/defines.md"] = g.markdownDefines(runtimeAPI.Defines)
+	}
+
+	if prototypeAPI != nil {
+		index.WriteString("## Prototype stage\n\n")
+		index.WriteString("- [Types](types.md)\n\n")
+		index.WriteString("### Prototypes\n\n")
+		for _, prototype := range sortedByOrder(prototypeAPI.Prototypes) {
+			index.WriteString(fmt.Sprintf("- [%s](prototypes/%s.md)%s\n", prototype.Name, prototype.Name, g.markdownSummary(prototype.Description)))
+			files[markdownDir+"/prototypes/"+prototype.Name+".md"] = g.markdownPrototype(prototype)
+		}
+		index.WriteString("\n")
+		files[markdownDir+"/types.md"] = g.markdownConcepts("Types", slices.Concat(prototypeAPI.Concepts, prototypeAPI.Types))
+	}
+
+	files[markdownDir+"/README.md"] = strings.TrimRight(index.String(), "\n") + "\n"
+	return files
+}
+
+// firstAPI returns the first non-nil API, or an empty one.
+func firstAPI(apis ...*api.API) *api.API {
+	for _, a := range apis {
+		if a != nil {
+			return a
+		}
+	}
+	return &api.API{}
+}
+
+// markdownSummary returns the first sentence of a description for index lists,
+// prefixed with a dash, or nothing for undocumented entries.
+func (g *Generator) markdownSummary(description string) string {
+	line := g.inlineDescription(description)
+	if line == "" {
+		return ""
+	}
+	if end := strings.Index(line, ". "); end >= 0 {
+		line = line[:end+1]
+	}
+	return " — " + line
+}
+
+// writeMarkdownDescription writes a rendered description, its extra lists and, if
+// enabled, its examples as paragraphs.
+func (g *Generator) writeMarkdownDescription(sb *strings.Builder, member api.BasicMember) {
+	if member.Deprecated {
+		sb.WriteString("**Deprecated.**\n\n")
+	}
+	if description := strings.TrimSpace(g.renderer.render(member.Description)); description != "" {
+		sb.WriteString(description + "\n\n")
+	}
+	for _, list := range member.Lists {
+		sb.WriteString(strings.TrimSpace(g.renderer.render(list)) + "\n\n")
+	}
+	if !g.options.IncludeExamples {
+		return
+	}
+	for _, example := range member.Examples {
+		example = strings.TrimSpace(example)
+		if strings.HasPrefix(example, "```") {
+			example = "```lua" + strings.TrimPrefix(example, "```")
+		} else {
+			example = "```lua\n" + example + "\n```"
+		}
+		sb.WriteString("Example:\n\n" + example + "\n\n")
+	}
+}
+
+// markdownLinks links each name to its page, e.g. a class's parents.
+func markdownLinks(names []string, dir string) string {
+	var links []string
+	for _, name := range names {
+		links = append(links, fmt.Sprintf("[%s](%s%s.md)", name, dir, name))
+	}
+	return strings.Join(links, ", ")
+}
+
+// markdownCode formats a type for a table cell, escaping the pipes of unions.
+func markdownCode(luaLSType string) string {
+	return "`" + strings.ReplaceAll(luaLSType, "|", "\\|") + "`"
+}
+
+// markdownCell formats a description for a table cell, which must fit on one line.
+func (g *Generator) markdownCell(description string) string {
+	return strings.ReplaceAll(g.inlineDescription(description), "|", "\\|")
+}
+
+func (g *Generator) markdownClass(class api.Class) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# %s\n\n", class.Name))
+	if len(class.Parent) > 0 {
+		sb.WriteString(fmt.Sprintf("Inherits from %s.\n\n", markdownLinks(class.Parent, "")))
+	}
+	if class.Abstract {
+		sb.WriteString("Abstract: only its subclasses exist at runtime.\n\n")
+	}
+	g.writeMarkdownDescription(&sb, class.BasicMember)
+
+	if attributes := slices.Concat(class.Attributes, class.Properties); len(attributes) > 0 {
+		sb.WriteString("## Attributes\n\n")
+		for _, attribute := range sortedByOrder(attributes) {
+			sb.WriteString(fmt.Sprintf("### %s\n\n", attribute.Name))
+			luaLSType := g.translateFactorioTypeToLuaLS(attribute.ValueType())
+			if attribute.Nullable || attribute.Optional {
+				luaLSType = withNil(luaLSType)
+			}
+			access := "read/write"
+			switch {
+			case !attribute.IsWritable():
+				access = "read-only"
+			case !attribute.IsReadable():
+				access = "write-only"
+			}
+			sb.WriteString(fmt.Sprintf("```lua\n%s.%s: %s -- %s\n```\n\n", class.Name, attribute.Name, luaLSType, access))
+			g.writeMarkdownDescription(&sb, attribute.BasicMember)
+		}
+	}
+
+	if len(class.Methods) > 0 {
+		sb.WriteString("## Methods\n\n")
+		for _, method := range sortedByOrder(class.Methods) {
+			sb.WriteString(fmt.Sprintf("### %s\n\n", method.Name))
+			sb.WriteString(fmt.Sprintf("```lua\n%s\n```\n\n", g.markdownSignature(class.Name, method)))
+			g.writeMarkdownDescription(&sb, method.BasicMember)
+			params := slices.Clone(method.Parameters)
+			for _, group := range method.VariantParameterGroups {
+				params = append(params, group.Parameters...)
+			}
+			if len(params) > 0 {
+				heading := "Parameters"
+				if method.Format.TakesTable {
+					heading = "Parameters (passed as a table)"
+				}
+				sb.WriteString(fmt.Sprintf("%s:\n\n", heading))
+				g.writeMarkdownParameters(&sb, params)
+			}
+			if len(method.ReturnValues) > 0 {
+				sb.WriteString("Returns:\n\n")
+				for _, ret := range sortedByOrder(method.ReturnValues) {
+					luaLSType := g.translateFactorioTypeToLuaLS(ret.Type)
+					if ret.Optional || ret.Nullable {
+						luaLSType = withNil(luaLSType)
+					}
+					sb.WriteString(strings.TrimRight(fmt.Sprintf("- `%s` %s", luaLSType, g.inlineDescription(ret.Description)), " ") + "\n")
+				}
+				sb.WriteString("\n")
+			}
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n") + "\n"
+}
+
+// markdownSignature returns a method's signature, e.g.
+// `LuaSurface.create_entity(param: LuaSurface.create_entity_param): LuaEntity | nil`.
+func (g *Generator) markdownSignature(className string, method api.Method) string {
+	var params []string
+	if method.Format.TakesTable {
+		name, luaLSType := g.optionalMember("param", fmt.Sprintf("%s.%s_param", className, method.Name), method.Format.TableOptional)
+		params = append(params, name+": "+luaLSType)
+	} else {
+		for _, param := range sortedByOrder(method.Parameters) {
+			luaLSType := g.translateFactorioTypeToLuaLS(param.Type)
+			if param.Nullable {
+				luaLSType = withNil(luaLSType)
+			}
+			name, luaLSType := g.optionalMember(luaParamName(param.Name), luaLSType, param.Optional)
+			params = append(params, name+": "+luaLSType)
+		}
+	}
+	if method.VariadicParameter != nil {
+		params = append(params, "...: "+g.translateFactorioTypeToLuaLS(method.VariadicParameter.Type))
+	}
+	var results []string
+	for _, ret := range sortedByOrder(method.ReturnValues) {
+		luaLSType := g.translateFactorioTypeToLuaLS(ret.Type)
+		if ret.Optional || ret.Nullable {
+			luaLSType = withNil(luaLSType)
+		}
+		results = append(results, luaLSType)
+	}
+	signature := fmt.Sprintf("%s.%s(%s)", className, method.Name, strings.Join(params, ", "))
+	if len(results) > 0 {
+		signature += ": " + strings.Join(results, ", ")
+	}
+	return signature
+}
+
+// writeMarkdownParameters writes named parameters or fields as a table.
+func (g *Generator) writeMarkdownParameters(sb *strings.Builder, params []api.Parameter) {
+	sb.WriteString("| Name | Type | Optional | Description |\n|---|---|---|---|\n")
+	for _, param := range sortedByOrder(params) {
+		luaLSType := g.translateFactorioTypeToLuaLS(param.Type)
+		if param.Nullable {
+			luaLSType = withNil(luaLSType)
+		}
+		optional := ""
+		if param.Optional {
+			optional = "yes"
+		}
+		sb.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s |\n", param.Name, markdownCode(luaLSType), optional, g.markdownCell(param.Description)))
+	}
+	sb.WriteString("\n")
+}
+
+// writeMarkdownProperties writes prototype properties as a table.
+func (g *Generator) writeMarkdownProperties(sb *strings.Builder, properties []api.Property) {
+	sb.WriteString("| Name | Type | Optional | Default | Description |\n|---|---|---|---|---|\n")
+	for _, property := range sortedByOrder(properties) {
+		optional := ""
+		if property.Optional {
+			optional = "yes"
+		}
+		defaultValue := strings.ReplaceAll(g.markdownDefault(property.Default), "|", "\\|")
+		sb.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s | %s |\n", property.Name, markdownCode(g.translateFactorioTypeToLuaLS(property.ValueType())), optional, defaultValue, g.markdownCell(property.Description)))
+	}
+	sb.WriteString("\n")
+}
+
+// markdownDefault formats a property default. The API gives literal defaults as
+// {"complex_type": "literal", "value": ...} and everything else as a description.
+func (g *Generator) markdownDefault(value interface{}) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if literal, ok := v["value"]; ok {
+			return "`" + defineLiteral(literal) + "`"
+		}
+	case string:
+		return g.inlineDescription(v)
+	}
+	return ""
+}
+
+func (g *Generator) markdownEvents(events []api.Event) string {
+	var sb strings.Builder
+	sb.WriteString("# Events\n\n")
+	for _, event := range sortedByOrder(events) {
+		sb.WriteString(fmt.Sprintf("## %s\n\n", event.Name))
+		g.writeMarkdownDescription(&sb, event.BasicMember)
+		if event.Filter != "" {
+			sb.WriteString(fmt.Sprintf("Filtered with [%s](concepts.md#%s).\n\n", event.Filter, strings.ToLower(event.Filter)))
+		}
+		if len(event.Data) > 0 {
+			g.writeMarkdownParameters(&sb, event.Data)
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n") + "\n"
+}
+
+func (g *Generator) markdownConcepts(title string, concepts []api.Concept) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# %s\n\n", title))
+	for _, concept := range sortedByOrder(concepts) {
+		sb.WriteString(fmt.Sprintf("## %s\n\n", concept.Name))
+		if len(concept.Parent) > 0 {
+			sb.WriteString(fmt.Sprintf("Inherits from %s.\n\n", strings.Join(concept.Parent, ", ")))
+		}
+		switch {
+		case len(concept.Properties) > 0:
+			g.writeMarkdownDescription(&sb, concept.BasicMember)
+			g.writeMarkdownProperties(&sb, concept.Properties)
+		case concept.Type.ComplexType == "table":
+			g.writeMarkdownDescription(&sb, concept.BasicMember)
+			fields := slices.Clone(concept.Type.Fields)
+			for _, group := range concept.Type.VariantParameterGroups {
+				fields = append(fields, group.Parameters...)
+			}
+			g.writeMarkdownParameters(&sb, fields)
+		default:
+			sb.WriteString(fmt.Sprintf("```lua\n%s\n```\n\n", g.translateFactorioTypeToLuaLS(concept.Type)))
+			g.writeMarkdownDescription(&sb, concept.BasicMember)
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n") + "\n"
+}
+
+func (g *Generator) markdownDefines(defines []api.Define) string {
+	var sb strings.Builder
+	sb.WriteString("# Defines\n\n")
+	var write func(define api.Define, fullName string)
+	write = func(define api.Define, fullName string) {
+		sb.WriteString(fmt.Sprintf("## %s\n\n", fullName))
+		g.writeMarkdownDescription(&sb, define.BasicMember)
+		if len(define.Values) > 0 {
+			sb.WriteString("| Name | Description |\n|---|---|\n")
+			for _, value := range sortedByOrder(define.Values) {
+				sb.WriteString(fmt.Sprintf("| `%s` | %s |\n", value.Name, g.markdownCell(value.Description)))
+			}
+			sb.WriteString("\n")
+		}
+		for _, subkey := range sortedByOrder(define.Subkeys) {
+			write(subkey, fullName+"."+subkey.Name)
+		}
+	}
+	for _, define := range sortedByOrder(defines) {
+		write(define, "defines."+define.Name)
+	}
+	return strings.TrimRight(sb.String(), "\n") + "\n"
+}
+
+func (g *Generator) markdownPrototype(prototype api.Prototype) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# %s\n\n", prototype.Name))
+	if prototype.TypeName != "" {
+		sb.WriteString(fmt.Sprintf("Type name: `%s`\n\n", prototype.TypeName))
+	}
+	if prototype.Parent != "" {
+		sb.WriteString(fmt.Sprintf("Inherits from %s.\n\n", markdownLinks([]string{prototype.Parent}, "")))
+	}
+	g.writeMarkdownDescription(&sb, prototype.BasicMember)
+	if len(prototype.Properties) > 0 {
+		sb.WriteString("## Properties\n\n")
+		g.writeMarkdownProperties(&sb, prototype.Properties)
+	}
+	return strings.TrimRight(sb.String(), "\n") + "\n"
+}
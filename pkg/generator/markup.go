@@ -0,0 +1,213 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+// docsBaseURL is the root of the official Factorio API documentation.
+const docsBaseURL = "https://lua-api.factorio.com"
+
+var (
+	// docLinkPattern matches relative documentation links such as
+	// [LuaPlayer](runtime:LuaPlayer) or [Color](prototype:Color).
+	docLinkPattern = regexp.MustCompile(`\[([^\]]*)\]\((runtime|prototype):([^)]*)\)`)
+	// richTextPattern matches in-game rich text tags such as [item=iron-plate].
+	richTextPattern = regexp.MustCompile(`\[([a-z][a-z-]*)=([^\]]+)\]`)
+	// defineReferencePattern matches bare define references such as [defines.events.on_tick].
+	defineReferencePattern = regexp.MustCompile(`\[(defines(?:\.[A-Za-z0-9_]+)+)\]([^(]|$)`)
+)
+
+// auxiliaryPages are runtime link targets that refer to the auxiliary documentation
+// pages rather than to an API member.
+var auxiliaryPages = map[string]bool{
+	"storage":           true,
+	"data-lifecycle":    true,
+	"libraries":         true,
+	"item-weight":       true,
+	"noise-expressions": true,
+	"migrations":        true,
+}
+
+// indexPages are the link targets that refer to the index pages of a stage, such
+// as "events" or "prototypes".
+var indexPages = map[string]bool{
+	"classes":    true,
+	"events":     true,
+	"concepts":   true,
+	"defines":    true,
+	"prototypes": true,
+	"types":      true,
+}
+
+// descriptionRenderer converts the Factorio-specific markup in descriptions into
+// Markdown that renders well in editor hovers: relative doc links become absolute
+// links for the documented game version, and rich text tags become inline code.
+type descriptionRenderer struct {
+	version string
+	// Page paths (relative to the version root) for every documented name, per stage.
+	runtimePages   map[string]string
+	prototypePages map[string]string
+	// Prototype concepts declared under another name, see resolveCollisions.
+	prototypeRenames map[string]string
+}
+
+// newDescriptionRenderer indexes the names documented by both APIs so that links
+// can be resolved to the right documentation page. Either API may be nil.
+func newDescriptionRenderer(runtimeAPI *api.API, prototypeAPI *api.API) *descriptionRenderer {
+	r := &descriptionRenderer{
+		version:        "latest",
+		runtimePages:   make(map[string]string),
+		prototypePages: make(map[string]string),
+	}
+
+	if runtimeAPI != nil {
+		if runtimeAPI.ApplicationVersion != "" {
+			r.version = runtimeAPI.ApplicationVersion
+		}
+		for _, class := range runtimeAPI.Classes {
+			r.runtimePages[class.Name] = "classes/" + class.Name + ".html"
+		}
+		for _, concept := range runtimeAPI.Concepts {
+			r.runtimePages[concept.Name] = "concepts/" + concept.Name + ".html"
+		}
+		for _, event := range runtimeAPI.Events {
+			r.runtimePages[event.Name] = "events.html#" + event.Name
+		}
+		for _, global := range runtimeAPI.GlobalObjects {
+			// Global objects are documented on the page of their class.
+			r.runtimePages[global.Name] = "classes/" + global.Type.Name + ".html"
+		}
+	}
+	if prototypeAPI != nil {
+		if r.version == "latest" && prototypeAPI.ApplicationVersion != "" {
+			r.version = prototypeAPI.ApplicationVersion
+		}
+		for _, prototype := range prototypeAPI.Prototypes {
+			r.prototypePages[prototype.Name] = "prototypes/" + prototype.Name + ".html"
+		}
+		for _, typ := range prototypeAPI.Types {
+			r.prototypePages[typ.Name] = "types/" + typ.Name + ".html"
+		}
+	}
+
+	return r
+}
+
+// DescriptionRenderer returns a function converting the markup of descriptions
+// as the doc comments of the definitions show them: relative links become
+// absolute links for the documented game version, rich text tags inline code.
+// Either API may be nil.
+func DescriptionRenderer(runtimeAPI *api.API, prototypeAPI *api.API) func(description string) string {
+	return newDescriptionRenderer(runtimeAPI, prototypeAPI).render
+}
+
+// render converts the markup in a description. A nil renderer returns the
+// description unchanged.
+func (r *descriptionRenderer) render(description string) string {
+	if r == nil || description == "" {
+		return description
+	}
+
+	description = docLinkPattern.ReplaceAllStringFunc(description, func(match string) string {
+		parts := docLinkPattern.FindStringSubmatch(match)
+		return fmt.Sprintf("[%s](%s)", parts[1], r.linkURL(parts[2], parts[3]))
+	})
+	description = defineReferencePattern.ReplaceAllString(description, "`$1`$2")
+	description = richTextPattern.ReplaceAllString(description, "`$2` ($1)")
+
+	return description
+}
+
+// linkURL resolves a relative documentation link target, such as
+// "LuaGameScript::players" in the runtime stage, to an absolute URL.
+func (r *descriptionRenderer) linkURL(stage string, target string) string {
+	name, member, hasMember := strings.Cut(target, "::")
+	root := fmt.Sprintf("%s/%s/", docsBaseURL, r.version)
+
+	var page string
+	switch {
+	case stage == "runtime" && strings.HasPrefix(name, "defines"):
+		return root + "defines.html#" + name
+	case stage == "runtime" && auxiliaryPages[name]:
+		page = "auxiliary/" + name + ".html"
+	case stage == "runtime" && r.runtimePages[name] != "":
+		page = r.runtimePages[name]
+	case stage == "prototype" && r.prototypePages[name] != "":
+		page = r.prototypePages[name]
+	case indexPages[name]:
+		page = name + ".html"
+	default:
+		// Names the documents don't define, such as the classes left out of a
+		// trimmed document, are resolved by the naming rules of the API.
+		page = guessPage(stage, name)
+	}
+
+	if hasMember {
+		// Members are anchors on their owner's page; events already carry an anchor.
+		if base, _, found := strings.Cut(page, "#"); found {
+			page = base
+		}
+		page += "#" + member
+	}
+	return root + page
+}
+
+// guessPage returns the page of a name the API documents don't define: Lua*
+// names are runtime classes and on_* names events; other names are concepts in
+// the runtime stage, and in the prototype stage prototypes when they end in
+// "Prototype" and types otherwise.
+func guessPage(stage string, name string) string {
+	switch {
+	case stage == "runtime" && strings.HasPrefix(name, "Lua"):
+		return "classes/" + name + ".html"
+	case stage == "runtime" && strings.HasPrefix(name, "on_"):
+		return "events.html#" + name
+	case stage == "runtime":
+		return "concepts/" + name + ".html"
+	case strings.HasSuffix(name, "Prototype"):
+		return "prototypes/" + name + ".html"
+	default:
+		return "types/" + name + ".html"
+	}
+}
+
+// references returns the API members linked from a description, formatted as
+// LuaLS @see targets (e.g. "LuaEntity.set_command"), in order of first appearance.
+// Links to auxiliary or index pages are skipped since they have no definition.
+func (r *descriptionRenderer) references(description string) []string {
+	if r == nil {
+		return nil
+	}
+	var refs []string
+	seen := make(map[string]bool)
+	for _, parts := range docLinkPattern.FindAllStringSubmatch(description, -1) {
+		stage, target := parts[2], parts[3]
+		name, _, _ := strings.Cut(target, "::")
+		known := strings.HasPrefix(name, "defines.")
+		if stage == "runtime" {
+			page, isPage := r.runtimePages[name]
+			known = known || isPage
+			// Events are defined as their payload class.
+			if strings.HasPrefix(page, "events.html#") {
+				target = "EventData." + target
+			}
+		} else {
+			_, isPage := r.prototypePages[name]
+			known = known || isPage
+			if renamed, ok := r.prototypeRenames[name]; ok {
+				target = renamed + target[len(name):]
+			}
+		}
+		ref := strings.ReplaceAll(target, "::", ".")
+		if !known || seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		refs = append(refs, ref)
+	}
+	return refs
+}
@@ -0,0 +1,70 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+func TestLinkURL(t *testing.T) {
+	runtimeAPI := &api.API{
+		ApplicationVersion: "2.0.45",
+		Classes:            []api.Class{{BasicMember: api.BasicMember{Name: "LuaGameScript"}}},
+		Concepts:           []api.Concept{{BasicMember: api.BasicMember{Name: "MapPosition"}}},
+		Events:             []api.Event{{BasicMember: api.BasicMember{Name: "on_tick"}}},
+		GlobalObjects:      []api.GlobalObject{{BasicMember: api.BasicMember{Name: "game"}, Type: api.Type{Name: "LuaGameScript"}}},
+	}
+	prototypeAPI := &api.API{
+		Prototypes: []api.Prototype{{BasicMember: api.BasicMember{Name: "ItemPrototype"}}},
+		Types:      []api.Concept{{BasicMember: api.BasicMember{Name: "Color"}}},
+	}
+	r := newDescriptionRenderer(runtimeAPI, prototypeAPI)
+
+	const root = "https://lua-api.factorio.com/2.0.45/"
+	tests := []struct {
+		stage, target, want string
+	}{
+		{"runtime", "LuaGameScript", "classes/LuaGameScript.html"},
+		{"runtime", "LuaGameScript::players", "classes/LuaGameScript.html#players"},
+		{"runtime", "game", "classes/LuaGameScript.html"},
+		{"runtime", "MapPosition", "concepts/MapPosition.html"},
+		{"runtime", "on_tick", "events.html#on_tick"},
+		{"runtime", "defines.events", "defines.html#defines.events"},
+		{"runtime", "storage", "auxiliary/storage.html"},
+		{"runtime", "events", "events.html"},
+		{"prototype", "ItemPrototype", "prototypes/ItemPrototype.html"},
+		{"prototype", "ItemPrototype::stack_size", "prototypes/ItemPrototype.html#stack_size"},
+		{"prototype", "Color", "types/Color.html"},
+		{"prototype", "prototypes", "prototypes.html"},
+		// Names the documents don't define follow the naming rules.
+		{"runtime", "LuaTrain", "classes/LuaTrain.html"},
+		{"runtime", "LuaTrain::id", "classes/LuaTrain.html#id"},
+		{"runtime", "on_object_destroyed", "events.html#on_object_destroyed"},
+		{"runtime", "RegistrationTarget", "concepts/RegistrationTarget.html"},
+		{"prototype", "TilePrototype", "prototypes/TilePrototype.html"},
+		{"prototype", "AmmoType", "types/AmmoType.html"},
+		{"prototype", "AmmoType::category", "types/AmmoType.html#category"},
+	}
+	for _, test := range tests {
+		if got := r.linkURL(test.stage, test.target); got != root+test.want {
+			t.Errorf("linkURL(%q, %q) = %q, want %q", test.stage, test.target, got, root+test.want)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	r := newDescriptionRenderer(&api.API{ApplicationVersion: "2.0.45"}, nil)
+	tests := []struct {
+		description, want string
+	}{
+		{"See [LuaPlayer](runtime:LuaPlayer).", "See [LuaPlayer](https://lua-api.factorio.com/2.0.45/classes/LuaPlayer.html)."},
+		{"Raises [defines.events.on_tick].", "Raises `defines.events.on_tick`."},
+		{"Costs [item=iron-plate].", "Costs `iron-plate` (item)."},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if got := r.render(test.description); got != test.want {
+			t.Errorf("render(%q) = %q, want %q", test.description, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package generator
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+// MigrationsFile declares the globals of migration scripts with ContextMigrations,
+// in place of the runtime globals.
+const MigrationsFile = "migrations.lua"
+
+// migrationBootstrapClass is the class of script in migration scripts.
+const migrationBootstrapClass = "MigrationBootstrap"
+
+// migrationRegistrations are the LuaBootstrap methods a migration can't use. A
+// migration runs once, while a save is loaded, so the handlers it would register
+// are gone the next time the save is loaded: handlers are registered by
+// control.lua, on every load.
+var migrationRegistrations = []string{"on_init", "on_load", "on_configuration_changed", "on_event", "on_nth_tick", "set_event_filter"}
+
+// migrationsPreamble explains MigrationsFile, after the header.
+const migrationsPreamble = `-- The globals of migration scripts (migrations/*.lua), which run once when a save
+-- made with an older version of the mod is loaded. The runtime definitions don't
+-- declare globals in this context, so this library suits a LuaLS workspace of the
+-- migrations/ directory alone; control.lua uses one generated without --context.
+
+`
+
+// generateMigrationGlobals generates MigrationsFile: the runtime globals, with
+// script typed as a copy of LuaBootstrap without migrationRegistrations.
+func (g *Generator) generateMigrationGlobals(runtimeAPI *api.API) string {
+	var sb strings.Builder
+	sb.WriteString(migrationsPreamble)
+	bootstrap := slices.IndexFunc(runtimeAPI.Classes, func(c api.Class) bool { return c.Name == "LuaBootstrap" })
+	if bootstrap >= 0 {
+		class := runtimeAPI.Classes[bootstrap]
+		class.Name = migrationBootstrapClass
+		class.Description = "The members of [LuaBootstrap](runtime:LuaBootstrap) available to migration scripts, which can't register event handlers."
+		class.Methods = slices.DeleteFunc(slices.Clone(class.Methods), func(m api.Method) bool {
+			return slices.Contains(migrationRegistrations, m.Name)
+		})
+		g.types.known[migrationBootstrapClass] = true
+		sb.WriteString(g.generateClass(class))
+		sb.WriteString("\n")
+	}
+	retyped := make(map[string]string)
+	if bootstrap >= 0 {
+		retyped["script"] = migrationBootstrapClass
+	}
+	sb.WriteString(g.contextGlobals(runtimeAPI, retyped))
+	return sb.String()
+}
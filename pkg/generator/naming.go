@@ -0,0 +1,64 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// classNamer derives class names from the dashed names the API documents use for
+// prototype types, such as "assembling-machine", and keeps them unique. Every
+// class name is claimed by an owner (a typename, or the name of an abstract
+// prototype); two different owners never get the same class.
+type classNamer struct {
+	prefix string            // Prepended to every derived name, e.g. "Factorio"
+	owners map[string]string // Class name -> the owner that claimed it
+}
+
+func newClassNamer(prefix string) *classNamer {
+	return &classNamer{prefix: prefix, owners: make(map[string]string)}
+}
+
+// pascalCase capitalizes the words of a dashed, underscored or spaced name and
+// joins them, so "assembling-machine" becomes "AssemblingMachine". Unlike
+// strings.Title, separators are dropped, so the result is a valid identifier;
+// names starting with a digit, which identifiers can't, get a leading underscore.
+func pascalCase(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '-' || r == '_' || unicode.IsSpace(r)
+	})
+	var sb strings.Builder
+	if first, _ := utf8.DecodeRuneInString(strings.Join(words, "")); unicode.IsDigit(first) {
+		sb.WriteString("_")
+	}
+	for _, word := range words {
+		first, size := utf8.DecodeRuneInString(word)
+		sb.WriteRune(unicode.ToUpper(first))
+		sb.WriteString(word[size:])
+	}
+	return sb.String()
+}
+
+// reserve claims a class name used verbatim, such as a documented prototype
+// definition name, so derived names can't collide with it.
+func (n *classNamer) reserve(className string, owner string) {
+	if _, ok := n.owners[className]; !ok {
+		n.owners[className] = owner
+	}
+}
+
+// name returns the first candidate, with the prefix applied, that no other owner
+// has claimed, and claims it for owner. Candidates are tried in order, so callers
+// pass their preferred name first and fallbacks after it.
+func (n *classNamer) name(owner string, candidates ...string) (string, error) {
+	for _, candidate := range candidates {
+		className := n.prefix + candidate
+		if claimed, ok := n.owners[className]; ok && claimed != owner {
+			continue
+		}
+		n.owners[className] = owner
+		return className, nil
+	}
+	return "", fmt.Errorf("no unique class name for %q: %s taken by %q", owner, n.prefix+candidates[0], n.owners[n.prefix+candidates[0]])
+}
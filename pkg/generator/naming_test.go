@@ -0,0 +1,93 @@
+package generator
+
+import "testing"
+
+func TestPascalCase(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"assembling-machine", "AssemblingMachine"},
+		{"rail_signal", "RailSignal"},
+		{"space platform hub", "SpacePlatformHub"},
+		{"--double--dash--", "DoubleDash"},
+		{"mixed-separators_and spaces", "MixedSeparatorsAndSpaces"},
+		{"item", "Item"},
+		{"AlreadyPascal", "AlreadyPascal"},
+		{"2x2-furnace", "_2x2Furnace"},
+		{"-3d-thing", "_3dThing"},
+		{"élévateur-à-grain", "ÉlévateurÀGrain"},
+		{"über_belt", "ÜberBelt"},
+		{"", ""},
+		{"---", ""},
+	}
+	for _, test := range tests {
+		if got := pascalCase(test.name); got != test.want {
+			t.Errorf("pascalCase(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestClassNamer(t *testing.T) {
+	type call struct {
+		reserve    bool // reserve the first candidate instead of naming
+		owner      string
+		candidates []string
+		want       string // "" for an error
+	}
+	tests := []struct {
+		name   string
+		prefix string
+		calls  []call
+	}{
+		{"distinct names", "", []call{
+			{false, "item", []string{"ItemPrototype"}, "ItemPrototype"},
+			{false, "ammo", []string{"AmmoPrototype"}, "AmmoPrototype"},
+		}},
+		{"same owner again", "", []call{
+			{false, "item", []string{"ItemPrototype"}, "ItemPrototype"},
+			{false, "item", []string{"ItemPrototype"}, "ItemPrototype"},
+		}},
+		{"collision falls back", "", []call{
+			{false, "rail-signal", []string{"RailSignalPrototype"}, "RailSignalPrototype"},
+			{false, "rail_signal", []string{"RailSignalPrototype", "RailSignalPrototype2"}, "RailSignalPrototype2"},
+		}},
+		{"collision without fallback", "", []call{
+			{false, "rail-signal", []string{"RailSignalPrototype"}, "RailSignalPrototype"},
+			{false, "rail_signal", []string{"RailSignalPrototype"}, ""},
+		}},
+		{"reserved name", "", []call{
+			{true, "ItemPrototype", []string{"ItemPrototype"}, ""},
+			{false, "item", []string{"ItemPrototype", "ItemTypePrototype"}, "ItemTypePrototype"},
+			{false, "ItemPrototype", []string{"ItemPrototype"}, "ItemPrototype"},
+		}},
+		{"reserving keeps the first owner", "", []call{
+			{false, "item", []string{"ItemPrototype"}, "ItemPrototype"},
+			{true, "ItemPrototype", []string{"ItemPrototype"}, ""},
+			{false, "item", []string{"ItemPrototype"}, "ItemPrototype"},
+		}},
+		{"prefix", "Factorio.", []call{
+			{false, "item", []string{"ItemPrototype"}, "Factorio.ItemPrototype"},
+			{true, "Factorio.AmmoPrototype", []string{"Factorio.AmmoPrototype"}, ""},
+			{false, "ammo", []string{"AmmoPrototype", "AmmoItemPrototype"}, "Factorio.AmmoItemPrototype"},
+			{false, "item", []string{"ItemPrototype"}, "Factorio.ItemPrototype"},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			namer := newClassNamer(test.prefix)
+			for _, c := range test.calls {
+				if c.reserve {
+					namer.reserve(c.candidates[0], c.owner)
+					continue
+				}
+				got, err := namer.name(c.owner, c.candidates...)
+				switch {
+				case c.want == "" && err == nil:
+					t.Errorf("name(%q, %q) = %q, want an error", c.owner, c.candidates, got)
+				case c.want != "" && (err != nil || got != c.want):
+					t.Errorf("name(%q, %q) = %q, %v, want %q", c.owner, c.candidates, got, err, c.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package generator
+
+import (
+	"cmp"
+	"slices"
+)
+
+// ordered is implemented by every API entity that carries a documented order.
+type ordered interface {
+	SortKey() (int, string)
+}
+
+// sortedByOrder returns a copy of items sorted by their documented order,
+// falling back to the name so output is deterministic between runs even when
+// the JSON contains duplicate or missing order values.
+// The input slice is left untouched, since it belongs to the parsed API.
+func sortedByOrder[T ordered](items []T) []T {
+	sorted := slices.Clone(items)
+	slices.SortStableFunc(sorted, func(a, b T) int {
+		aOrder, aName := a.SortKey()
+		bOrder, bName := b.SortKey()
+		if c := cmp.Compare(aOrder, bOrder); c != 0 {
+			return c
+		}
+		return cmp.Compare(aName, bName)
+	})
+	return sorted
+}
+
+// sortedKeys returns the keys of a map in lexical order, for the few places
+// where generation groups entities in a map before emitting them.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
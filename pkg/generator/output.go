@@ -0,0 +1,217 @@
+package generator
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+// Version is the generator version available to header templates. Release builds
+// set it with -ldflags "-X github.com/bry-guy/factorio-lsp-plugin/pkg/generator.Version=v1.2.3".
+var Version = "dev"
+
+// HeaderData is passed to Options.HeaderTemplate for each generated stage.
+type HeaderData struct {
+	Stage            string // "runtime", "prototype" or "builtin"
+	SourceURL        string // Where the stage's API document was read from; empty for builtin
+	GameVersion      string // Factorio version the API documents, e.g. "2.0.45"
+	GeneratorVersion string // See Version
+}
+
+// defaultHeaders are written at the top of every file generated for a stage when
+// no header template is configured. Runtime and prototype take the source URL.
+var defaultHeaders = map[string]string{
+	"runtime":   "-- Auto-generated Factorio Runtime API definitions\n-- Generated from: %s\n\n",
+	"prototype": "-- Auto-generated Factorio Prototype API definitions\n-- Generated from: %s\n\n",
+	"builtin":   "-- Auto-generated Factorio builtin type aliases\n\n",
+}
+
+// stageHeaders renders the header of every stage, from Options.HeaderTemplate if
+// one is configured. Either API may be nil when only one stage is generated.
+func (g *Generator) stageHeaders(runtimeAPI *api.API, prototypeAPI *api.API) (map[string]string, error) {
+	sources := map[string]string{"runtime": g.options.RuntimeURL, "prototype": g.options.PrototypeURL}
+	gameVersion := ""
+	for _, a := range []*api.API{runtimeAPI, prototypeAPI} {
+		if a != nil && gameVersion == "" {
+			gameVersion = a.ApplicationVersion
+		}
+	}
+
+	headers := make(map[string]string, len(defaultHeaders))
+	for stage, header := range defaultHeaders {
+		if g.options.HeaderTemplate == nil {
+			if sources[stage] != "" {
+				header = fmt.Sprintf(header, sources[stage])
+			}
+			headers[stage] = header
+			continue
+		}
+		var sb strings.Builder
+		data := HeaderData{Stage: stage, SourceURL: sources[stage], GameVersion: gameVersion, GeneratorVersion: Version}
+		if err := g.options.HeaderTemplate.Execute(&sb, data); err != nil {
+			return nil, fmt.Errorf("failed to render the %s file header: %w", stage, err)
+		}
+		// The definitions start on a line of their own, after a blank line.
+		header = strings.TrimRight(sb.String(), "\n")
+		if header != "" {
+			header += "\n\n"
+		}
+		headers[stage] = header
+	}
+	return headers, nil
+}
+
+// DefaultDisabledDiagnostics are the LuaLS diagnostics that the definitions
+// trigger by design: Factorio's globals (data, storage, ...) are lowercase, the
+// API references a few names it doesn't document, and merged prototype classes
+// and event overloads repeat fields and functions.
+var DefaultDisabledDiagnostics = []string{
+	"lowercase-global",
+	"undefined-doc-name",
+	"duplicate-doc-field",
+	"duplicate-set-field",
+}
+
+// metaPreamble returns the first lines of every LuaLS file: the meta marker, so
+// LuaLS treats the file as definitions only, and the disabled diagnostics.
+func (g *Generator) metaPreamble() string {
+	if len(g.options.DisabledDiagnostics) == 0 {
+		return "---@meta\n\n"
+	}
+	return fmt.Sprintf("---@meta\n---@diagnostic disable: %s\n\n", strings.Join(g.options.DisabledDiagnostics, ", "))
+}
+
+// FileCreator creates a generated file for writing, given its path relative to
+// the output (slash-separated, e.g. "runtime/classes/LuaEntity.lua"). GenerateTo
+// closes the file once it is complete.
+type FileCreator func(filename string) (io.WriteCloser, error)
+
+// DirCreator returns a FileCreator writing the files under dir, creating the
+// directories they are nested in.
+func DirCreator(dir string) FileCreator {
+	return func(filename string) (io.WriteCloser, error) {
+		path := filepath.Join(dir, filepath.FromSlash(filename))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, err
+		}
+		return os.Create(path)
+	}
+}
+
+// MapCreator returns a FileCreator collecting the files into files, by path, as
+// they are closed.
+func MapCreator(files map[string]string) FileCreator {
+	return func(filename string) (io.WriteCloser, error) {
+		return &mapFile{name: filename, files: files}, nil
+	}
+}
+
+// mapFile collects a file into a map when it is closed, for GenerateDefinitions.
+type mapFile struct {
+	strings.Builder
+	name  string
+	files map[string]string
+}
+
+func (f *mapFile) Close() error {
+	f.files[f.name] = f.String()
+	return nil
+}
+
+// definitionSet collects the generated LuaLS files and hands each to emit once it
+// is complete. In single-file mode each stage is one file (runtime.lua,
+// prototype.lua) with a comment heading per section, complete when the stage is
+// finished; in split mode every section, and every class, gets its own file in a
+// directory named after the stage, so LuaLS can index them separately and diffs
+// stay readable. Split files are written one after the other, so a file is
+// complete as soon as the next one is started, which keeps at most one of them in
+// memory.
+type definitionSet struct {
+	split     bool
+	preamble  string            // Written before the header of every file, see metaPreamble
+	headers   map[string]string // Header of the files of each stage, see stageHeaders
+	emit      func(name string, content string) error
+	files     map[string]*strings.Builder // The files being written
+	headerLen map[string]int              // Length of each file's header, to drop empty split files
+	sections  map[string]bool             // Section headings already written in single-file mode
+	current   string                      // The split file written last
+	emitted   map[string]bool
+	err       error // First error emitting a file
+}
+
+func newDefinitionSet(split bool, preamble string, headers map[string]string, emit func(name string, content string) error) *definitionSet {
+	return &definitionSet{
+		split:     split,
+		preamble:  preamble,
+		headers:   headers,
+		emit:      emit,
+		files:     make(map[string]*strings.Builder),
+		headerLen: make(map[string]int),
+		sections:  make(map[string]bool),
+		emitted:   make(map[string]bool),
+	}
+}
+
+// section returns the builder that a part of a stage's definitions is written to.
+// title is the section heading used in single-file mode; file is the path of the
+// part within the stage directory in split mode, e.g. "classes/LuaEntity.lua".
+func (d *definitionSet) section(stage string, title string, file string) *strings.Builder {
+	if d.split {
+		name := path.Join(stage, file)
+		if d.current != "" && d.current != name {
+			d.complete(d.current)
+		}
+		d.current = name
+		return d.file(name, d.headers[stage])
+	}
+	sb := d.file(stage+".lua", d.headers[stage])
+	if key := stage + "/" + title; !d.sections[key] {
+		d.sections[key] = true
+		sb.WriteString("-- " + title + "\n\n")
+	}
+	return sb
+}
+
+// file returns the builder of a file, creating it with the preamble and the given
+// header on first use.
+func (d *definitionSet) file(name string, header string) *strings.Builder {
+	sb, ok := d.files[name]
+	if !ok {
+		if d.emitted[name] && d.err == nil {
+			d.err = fmt.Errorf("%s was written to after it was complete", name)
+		}
+		sb = &strings.Builder{}
+		sb.WriteString(d.preamble)
+		sb.WriteString(header)
+		d.files[name] = sb
+		d.headerLen[name] = sb.Len()
+	}
+	return sb
+}
+
+// complete emits a file and releases it. Split files that received no
+// definitions (such as an empty section) are left out.
+func (d *definitionSet) complete(name string) {
+	sb := d.files[name]
+	delete(d.files, name)
+	d.emitted[name] = true
+	if d.err != nil || d.split && sb.Len() == d.headerLen[name] {
+		return
+	}
+	d.err = d.emit(name, sb.String())
+}
+
+// finish emits the files still being written, at the end of a stage, and returns
+// the first error emitting any file.
+func (d *definitionSet) finish() error {
+	for _, name := range sortedKeys(d.files) {
+		d.complete(name)
+	}
+	d.current = ""
+	return d.err
+}
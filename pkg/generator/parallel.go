@@ -0,0 +1,118 @@
+package generator
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// paramClass is the class of a table of named fields: a method's named arguments
+// (see generateMethodStub) or an inline table type (see luaLSSyntax.table).
+// Whether it is declared or aliased to an identical one depends on the classes
+// generated before it, so a worker leaves a marker in its place and the choice
+// is made when the outputs are merged in order.
+type paramClass struct {
+	name  string // e.g. "LuaSurface.find_entities_filtered_param"
+	shape string // Its fields and their types, with the name left out
+	text  string // Its declaration
+}
+
+// newParamClass returns the class named name, declared by text, with the given
+// shape.
+func newParamClass(name string, text string, shape string) paramClass {
+	return paramClass{name: name, shape: strings.ReplaceAll(shape, name, "\x00"), text: text}
+}
+
+// paramMarker is the placeholder a worker writes for its i-th parameter class.
+// Generated text never contains NUL bytes.
+func paramMarker(i int) string {
+	return fmt.Sprintf("\x00param %d\x00", i)
+}
+
+// generateConcurrently generates n independent entities (classes, prototype
+// types) with up to Options.Workers goroutines and returns their outputs in order.
+// Each entity is generated by a worker of its own, a copy of the generator whose
+// records (imprecise types, parameter classes, template errors) are merged back
+// in order, so the result is the same as generating the entities one by one.
+func (g *Generator) generateConcurrently(n int, generate func(w *Generator, i int) string) []string {
+	workers := g.options.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	forks := make([]*Generator, n)
+	outputs := make([]string, n)
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				forks[i] = g.fork()
+				outputs[i] = generate(forks[i], i)
+			}
+		}()
+	}
+	for i := range n {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for i, w := range forks {
+		outputs[i] = g.merge(w, outputs[i])
+	}
+	return outputs
+}
+
+// fork returns a worker generating on its own: the indexes and options are
+// shared, read-only, and the records start empty. Only the generator itself
+// writes the shared maps (paramShapes, when merging; the names of resolveCollisions
+// before any worker starts).
+func (g *Generator) fork() *Generator {
+	w := *g
+	w.types = g.types.fork()
+	w.templateErr = nil
+	w.collisions = nil
+	w.pendingParams = []paramClass{}
+	w.tableName, w.tables = "", nil
+	return &w
+}
+
+// merge adds the records of a worker to the generator and returns its output
+// with the parameter classes resolved. Every record a worker may add while
+// generating is merged here, in order: imprecise types, template errors,
+// collisions and parameter classes.
+func (g *Generator) merge(w *Generator, output string) string {
+	g.types.merge(w.types)
+	if g.templateErr == nil {
+		g.templateErr = w.templateErr
+	}
+	g.collisions = append(g.collisions, w.collisions...)
+	for i, param := range w.pendingParams {
+		output = strings.Replace(output, paramMarker(i), g.declareParamClass(param), 1)
+	}
+	return output
+}
+
+// writeDeclaration writes the declaration of a parameter class, or the marker of
+// a worker in its place, see paramClass.
+func (g *Generator) writeDeclaration(sb *strings.Builder, param paramClass) {
+	if g.pendingParams != nil {
+		sb.WriteString(paramMarker(len(g.pendingParams)))
+		g.pendingParams = append(g.pendingParams, param)
+		return
+	}
+	sb.WriteString(g.declareParamClass(param))
+}
+
+// declareParamClass returns the declaration of a parameter class: the class
+// itself, or an alias of the first identical one.
+func (g *Generator) declareParamClass(param paramClass) string {
+	if shared, ok := g.paramShapes[param.shape]; ok {
+		return fmt.Sprintf("---@alias %s %s\n", param.name, shared)
+	}
+	g.paramShapes[param.shape] = param.name
+	return param.text
+}
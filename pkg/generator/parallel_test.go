@@ -0,0 +1,45 @@
+package generator_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/generator"
+)
+
+// TestWorkers checks that generating with several workers gives the output and
+// the records of generating one definition at a time.
+func TestWorkers(t *testing.T) {
+	runtimeAPI := loadFixture(t, "2.0.45", "runtime")
+	prototypeAPI := loadFixture(t, "2.0.45", "prototype")
+	generate := func(workers int) (*generator.Generator, map[string]string) {
+		options := generator.DefaultOptions()
+		options.Workers = workers
+		options.Formats = []generator.Format{generator.FormatLuaLS, generator.FormatIR}
+		gen := generator.NewGenerator(options)
+		definitions, err := gen.GenerateDefinitions(runtimeAPI, prototypeAPI)
+		if err != nil {
+			t.Fatalf("generating with %d workers: %v", workers, err)
+		}
+		return gen, definitions
+	}
+	sequential, want := generate(1)
+	if len(sequential.TypeReport().Warnings) == 0 || len(sequential.Collisions()) == 0 {
+		t.Fatal("the fixture raises no warnings or collisions to compare")
+	}
+	for range 3 { // The workers finish in a different order each time.
+		parallel, got := generate(8)
+		if !reflect.DeepEqual(got, want) {
+			t.Error("the definitions differ between 1 and 8 workers")
+		}
+		wantReport, _ := json.MarshalIndent(sequential.TypeReport(), "", "  ")
+		gotReport, _ := json.MarshalIndent(parallel.TypeReport(), "", "  ")
+		if diff := firstDifference(string(wantReport), string(gotReport)); diff != "" {
+			t.Errorf("the warnings report differs between 1 and 8 workers:\n%s", diff)
+		}
+		if !reflect.DeepEqual(parallel.Collisions(), sequential.Collisions()) {
+			t.Errorf("collisions with 8 workers = %v, want %v", parallel.Collisions(), sequential.Collisions())
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package generator
+
+// LuaLSPluginFile is the name of the LuaLS plugin written with
+// Options.RequirePlugin, at the root of the output (next to config.json in the
+// addon layout). Lua.runtime.plugin must point at it to enable it.
+const LuaLSPluginFile = "plugin.lua"
+
+// luaLSPlugin rewrites Factorio's mod-relative require paths, which LuaLS can't
+// resolve, as the files are opened. LuaLS resolves a require by matching the end
+// of file paths, so a path that ends like the file's does: the mod's own name is
+// dropped, and other mods' names become a directory, as in Factorio's data
+// directory (base/, core/) or an unzipped mods directory. The plugin runs in
+// LuaLS's own Lua (5.4), not Factorio's.
+const luaLSPlugin = `-- LuaLS plugin resolving Factorio's mod-relative require paths, generated by
+-- factorio-api-gen. Enable it by pointing Lua.runtime.plugin at this file.
+--
+-- Factorio loads require("__mod-name__/path/file") from the mod named mod-name,
+-- which LuaLS can't find. The plugin rewrites these paths for LuaLS only:
+--   * in the mod's own name (read from the nearest info.json), the name is
+--     dropped: require("__my-mod__/scripts/gui") becomes require("scripts/gui");
+--   * other mods keep their name as a directory: require("__core__/lualib/util")
+--     becomes require("core/lualib/util"), which resolves once Factorio's data
+--     directory (or the mods directory) is in Lua.workspace.library.
+
+-- The mod name of every directory looked up, false outside of a mod.
+local modNames = {}
+
+---Returns the name of the mod a file belongs to, from the nearest info.json.
+---@param uri string
+---@return string|false
+local function modName(uri)
+	local path = uri:gsub("^file://", ""):gsub("%%(%x%x)", function(hex)
+		return string.char(tonumber(hex, 16))
+	end)
+	-- Windows URIs (file:///c%3A/...) have a slash before the drive letter.
+	path = path:gsub("^/(%a:)", "%1")
+	local dir = path:match("^(.*)/")
+	local visited = {}
+	local name = false
+	while dir and dir ~= "" do
+		if modNames[dir] ~= nil then
+			name = modNames[dir]
+			break
+		end
+		visited[#visited + 1] = dir
+		local file = io.open(dir .. "/info.json")
+		if file then
+			name = file:read("a"):match('"name"%s*:%s*"([^"]+)"') or false
+			file:close()
+			break
+		end
+		dir = dir:match("^(.*)/")
+	end
+	for _, d in ipairs(visited) do
+		modNames[d] = name
+	end
+	return name
+end
+
+---@param uri string
+---@param text string
+function OnSetText(uri, text)
+	if not text:find("__", 1, true) then
+		return nil
+	end
+	local own = modName(uri)
+	local diffs = {}
+	for start, quote, mod, separator, rest, finish in text:gmatch("require%s*%(?%s*()([\"'])__([^\"'/.]+)__([/.])([^\"']*)[\"']()") do
+		local path = mod .. separator .. rest
+		if mod == own then
+			path = rest
+		end
+		diffs[#diffs + 1] = { start = start, finish = finish - 1, text = quote .. path .. quote }
+	end
+	if #diffs == 0 then
+		return nil
+	end
+	return diffs
+end
+`
@@ -0,0 +1,321 @@
+package generator
+
+import (
+	"regexp"
+	"strings"
+)
+
+// prefixedAnnotation matches the annotation lines whose contents prefixTypeNames
+// rewrites, capturing the tag and the rest of the line.
+var prefixedAnnotation = regexp.MustCompile(`^---(@class|@alias|@field|@param|@return|@type|@overload|@operator|@see|\|)( .*)?$`)
+
+// prefixTypeNames prepends the type prefix to the classes and aliases declared
+// in LuaLS definitions and to every reference to them, so the definitions can be
+// combined with libraries declaring the same names (Color, Prototype, ...).
+//
+// The rewrite is done on the finished output rather than where names are
+// emitted, which covers the hand-written signatures (event handlers, overloads)
+// and template output alike. Only type positions change: the Lua globals the
+// classes describe (LuaSurface = {}, function LuaSurface.foo() end) keep their
+// names, as do the defines, which are a real global table rather than types, and
+// the storage class (global in 1.1), which mods extend under its name (see
+// persistentDataGlobals).
+func prefixTypeNames(definitions map[string]string, prefix string) {
+	declared := make(map[string]bool)
+	for _, content := range definitions {
+		for _, line := range strings.Split(content, "\n") {
+			for _, tag := range []string{"---@class ", "---@alias "} {
+				if rest, ok := strings.CutPrefix(line, tag); ok {
+					name, _, _ := strings.Cut(rest, " ")
+					name, _, _ = strings.Cut(name, "<")
+					if name != "defines" && !strings.HasPrefix(name, "defines.") && name != "storage" && name != "global" {
+						declared[name] = true
+					}
+				}
+			}
+		}
+	}
+
+	for filename, content := range definitions {
+		lines := strings.Split(content, "\n")
+		for i, line := range lines {
+			if match := prefixedAnnotation.FindStringSubmatch(line); match != nil {
+				s := &typeScanner{text: line, pos: len(line) - len(match[2]), declared: declared, prefix: prefix}
+				s.annotation(match[1])
+				lines[i] = s.rewritten()
+			}
+		}
+		definitions[filename] = strings.Join(lines, "\n")
+	}
+}
+
+// typeScanner walks the type expressions of an annotation line, recording the
+// spans of the declared names it meets. Scanning stops at the first text that
+// can't continue a type, which is where the description starts.
+type typeScanner struct {
+	text     string
+	pos      int
+	declared map[string]bool
+	prefix   string
+	names    []int // Start offsets of the names to prefix
+}
+
+// annotation scans the type positions following an annotation tag.
+func (s *typeScanner) annotation(tag string) {
+	s.space()
+	switch tag {
+	case "@class":
+		s.typeName()
+		if s.peek('<') {
+			s.generics()
+		}
+		s.space()
+		if s.peek(':') {
+			s.pos++
+			for {
+				s.space()
+				s.typeName()
+				s.space()
+				if !s.peek(',') {
+					break
+				}
+				s.pos++
+			}
+		}
+	case "@alias":
+		s.typeName()
+		s.space()
+		s.typeExpr()
+	case "@field", "@param":
+		if s.peek('[') {
+			s.pos++
+			s.typeExpr()
+			s.space()
+			if s.peek(']') {
+				s.pos++
+			}
+		} else {
+			s.identifier()
+			if s.peek('?') {
+				s.pos++
+			}
+		}
+		s.space()
+		s.typeExpr()
+	case "@operator":
+		s.identifier()
+		if s.peek('(') {
+			s.pos++
+			s.typeExpr()
+			s.space()
+			if s.peek(')') {
+				s.pos++
+			}
+		}
+		if s.peek(':') {
+			s.pos++
+			s.space()
+			s.typeExpr()
+		}
+	case "@see":
+		// @see names a class or one of its members (LuaHelpers.is_valid_sprite_path),
+		// so the longest declared leading part of the name is what gets prefixed.
+		start := s.pos
+		name := s.identifier()
+		for name != "" {
+			if s.declared[name] {
+				s.names = append(s.names, start)
+				break
+			}
+			dot := strings.LastIndexByte(name, '.')
+			if dot < 0 {
+				break
+			}
+			name = name[:dot]
+		}
+	default: // @return, @type, @overload and alias members (---|)
+		s.typeExpr()
+	}
+}
+
+// typeExpr scans a union of types.
+func (s *typeScanner) typeExpr() {
+	for {
+		s.space()
+		s.primary()
+		for s.peek('?') || strings.HasPrefix(s.text[s.pos:], "[]") {
+			if s.peek('?') {
+				s.pos++
+			} else {
+				s.pos += 2
+			}
+		}
+		// A union continues past the spaces around the |, a description doesn't.
+		next := s.pos
+		for next < len(s.text) && s.text[next] == ' ' {
+			next++
+		}
+		if next >= len(s.text) || s.text[next] != '|' {
+			return
+		}
+		s.pos = next + 1
+	}
+}
+
+// primary scans a type that isn't a union: a name, literal, function signature,
+// table literal, tuple or parenthesized type.
+func (s *typeScanner) primary() {
+	if s.pos >= len(s.text) {
+		return
+	}
+	switch c := s.text[s.pos]; {
+	case c == '"' || c == '\'' || c == '`':
+		end := strings.IndexByte(s.text[s.pos+1:], c)
+		if end < 0 {
+			s.pos = len(s.text)
+		} else {
+			s.pos += end + 2
+		}
+	case c == '(':
+		s.pos++
+		s.typeExpr()
+		s.space()
+		if s.peek(')') {
+			s.pos++
+		}
+	case c == '[':
+		s.pos++
+		s.list(']', s.typeExpr)
+	case c == '{':
+		s.pos++
+		s.list('}', func() {
+			if s.peek('[') {
+				s.pos++
+				s.typeExpr()
+				s.space()
+				if s.peek(']') {
+					s.pos++
+				}
+			} else {
+				s.identifier()
+			}
+			s.space()
+			if s.peek(':') {
+				s.pos++
+				s.typeExpr()
+			}
+		})
+	case strings.HasPrefix(s.text[s.pos:], "fun("):
+		s.pos += len("fun(")
+		s.list(')', func() {
+			if strings.HasPrefix(s.text[s.pos:], "...") {
+				s.pos += len("...")
+			} else {
+				s.identifier()
+			}
+			if s.peek('?') {
+				s.pos++
+			}
+			s.space()
+			if s.peek(':') {
+				s.pos++
+				s.typeExpr()
+			}
+		})
+		if s.peek(':') {
+			s.pos++
+			for {
+				s.space()
+				s.typeExpr()
+				if !s.peek(',') {
+					break
+				}
+				s.pos++
+			}
+		}
+	case c == '-' || c >= '0' && c <= '9':
+		s.pos++
+		for s.pos < len(s.text) && strings.IndexByte("0123456789.", s.text[s.pos]) >= 0 {
+			s.pos++
+		}
+	default:
+		s.typeName()
+		if s.peek('<') {
+			s.generics()
+		}
+	}
+}
+
+// list scans comma-separated elements up to and including the closing character.
+func (s *typeScanner) list(closing byte, element func()) {
+	for {
+		s.space()
+		if s.pos >= len(s.text) || s.peek(closing) {
+			break
+		}
+		start := s.pos
+		element()
+		s.space()
+		if s.peek(',') {
+			s.pos++
+		} else if s.pos == start || !s.peek(closing) {
+			break // Not a type after all, leave the rest alone
+		}
+	}
+	if s.peek(closing) {
+		s.pos++
+	}
+}
+
+// generics scans the type arguments of a generic type, e.g. <string, LuaEntity>.
+func (s *typeScanner) generics() {
+	s.pos++
+	s.list('>', s.typeExpr)
+}
+
+// typeName scans a name in a type position, recording it if it's declared.
+func (s *typeScanner) typeName() {
+	start := s.pos
+	if name := s.identifier(); s.declared[name] {
+		s.names = append(s.names, start)
+	}
+}
+
+// identifier scans a (possibly dotted) identifier.
+func (s *typeScanner) identifier() string {
+	start := s.pos
+	for s.pos < len(s.text) {
+		c := s.text[s.pos]
+		if c != '_' && c != '.' && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			break
+		}
+		s.pos++
+	}
+	return s.text[start:s.pos]
+}
+
+func (s *typeScanner) space() {
+	for s.pos < len(s.text) && s.text[s.pos] == ' ' {
+		s.pos++
+	}
+}
+
+func (s *typeScanner) peek(c byte) bool {
+	return s.pos < len(s.text) && s.text[s.pos] == c
+}
+
+// rewritten returns the line with the prefix inserted before the recorded names.
+func (s *typeScanner) rewritten() string {
+	if len(s.names) == 0 {
+		return s.text
+	}
+	var sb strings.Builder
+	last := 0
+	for _, start := range s.names {
+		sb.WriteString(s.text[last:start])
+		sb.WriteString(s.prefix)
+		last = start
+	}
+	sb.WriteString(s.text[last:])
+	return sb.String()
+}
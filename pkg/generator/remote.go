@@ -0,0 +1,82 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RemoteInterface is a remote interface registered by a mod (see
+// Options.RemoteInterfaces).
+type RemoteInterface struct {
+	Name      string // e.g. "my-mod"
+	Functions []RemoteFunction
+}
+
+// RemoteFunction is a function of a RemoteInterface.
+type RemoteFunction struct {
+	Name       string
+	Parameters []RemoteParameter
+	Returns    []string // LuaLS types; the function returns any if empty
+}
+
+// RemoteParameter is a parameter of a RemoteFunction.
+type RemoteParameter struct {
+	Name     string // "..." for a vararg
+	Type     string // A LuaLS type
+	Optional bool
+}
+
+// remoteFunctionType returns the LuaLS function type of a remote function, with
+// the given parameters before its own.
+func remoteFunctionType(function RemoteFunction, leading ...string) string {
+	params := leading
+	for _, param := range function.Parameters {
+		name := param.Name
+		if param.Optional && name != "..." {
+			name += "?"
+		}
+		params = append(params, fmt.Sprintf("%s: %s", name, param.Type))
+	}
+	returns := "any"
+	if len(function.Returns) > 0 {
+		returns = strings.Join(function.Returns, ", ")
+	}
+	return fmt.Sprintf("fun(%s): %s", strings.Join(params, ", "), returns)
+}
+
+// remoteCallOverloads returns one signature of LuaRemote.call per function of
+// Options.RemoteInterfaces, selected by the literal interface and function names,
+// so the arguments and the result of the call are typed. Calls of other
+// interfaces still match the documented signature.
+func (g *Generator) remoteCallOverloads() []string {
+	var overloads []string
+	for _, remote := range g.options.RemoteInterfaces {
+		for _, function := range remote.Functions {
+			overloads = append(overloads, remoteFunctionType(function, "interface: "+luaString(remote.Name), "function_: "+luaString(function.Name)))
+		}
+	}
+	return overloads
+}
+
+// generateRemoteInterfaceFields generates the fields of the RemoteInterfaces class
+// (see remoteInterfacesClass) declaring Options.RemoteInterfaces, as the tables of
+// functions that remote.interfaces lists.
+func (g *Generator) generateRemoteInterfaceFields() string {
+	var sb strings.Builder
+	for _, remote := range g.options.RemoteInterfaces {
+		var functions []string
+		for _, function := range remote.Functions {
+			functionType := remoteFunctionType(function)
+			if len(function.Returns) > 1 {
+				functionType = "(" + functionType + ")" // The returns' commas would end the field
+			}
+			functions = append(functions, fmt.Sprintf("%s: %s", luaFieldName(function.Name), functionType))
+		}
+		fields := "{}"
+		if len(functions) > 0 {
+			fields = "{ " + strings.Join(functions, ", ") + " }"
+		}
+		sb.WriteString(fmt.Sprintf("---@field %s %s\n", luaFieldName(remote.Name), fields))
+	}
+	return sb.String()
+}
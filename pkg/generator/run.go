@@ -0,0 +1,96 @@
+package generator
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+// Result is what Run generated.
+type Result struct {
+	// The generated files by path, when Options.Output is nil.
+	Files map[string]string
+	// The documents the definitions were generated from; nil for a stage left out.
+	Runtime   *Source
+	Prototype *Source
+	// See Generator.Collisions and Generator.TypeReport.
+	Collisions []Collision
+	TypeReport TypeReport
+}
+
+// Source identifies an API document.
+type Source struct {
+	URL         string
+	GameVersion string // e.g. "2.0.45"
+	APIVersion  int
+	SHA256      string // Of the document as downloaded
+}
+
+// Run downloads the API documents of options.RuntimeURL and options.PrototypeURL
+// and generates their definitions, for programs embedding the generator. It
+// never exits or writes to the standard logger: errors are returned, and the
+// downloads use Options.HTTPClient and log to Options.Logger. It stops, with the
+// context's error, when ctx is canceled.
+//
+// Run is NewGenerator(options).Run(ctx); use Generator.Run to add hooks first.
+func Run(ctx context.Context, options Options) (Result, error) {
+	return NewGenerator(options).Run(ctx)
+}
+
+// Run is the Run function with the generator's hooks.
+//
+// An empty URL leaves its stage out. The files are written through
+// Options.Output, or returned in Result.Files when it is nil.
+func (g *Generator) Run(ctx context.Context) (Result, error) {
+	var result Result
+	if g.options.RuntimeURL == "" && g.options.PrototypeURL == "" {
+		return result, fmt.Errorf("no API document to generate from: set RuntimeURL and/or PrototypeURL")
+	}
+	client := &api.Client{HTTPClient: g.options.HTTPClient, Logger: g.options.Logger, CacheDir: g.options.CacheDir}
+	load := func(url string) (*api.API, *Source, error) {
+		if url == "" {
+			return nil, nil, nil
+		}
+		body, err := client.Fetch(ctx, url)
+		if err != nil {
+			return nil, nil, err
+		}
+		document, err := client.Parse(url, body)
+		if err != nil {
+			return nil, nil, err
+		}
+		return document, &Source{URL: url, GameVersion: document.ApplicationVersion, APIVersion: document.APIVersion, SHA256: fmt.Sprintf("%x", sha256.Sum256(body))}, nil
+	}
+	runtimeAPI, runtimeSource, err := load(g.options.RuntimeURL)
+	if err != nil {
+		return result, err
+	}
+	prototypeAPI, prototypeSource, err := load(g.options.PrototypeURL)
+	if err != nil {
+		return result, err
+	}
+
+	// Generation itself can't be interrupted, but it stops at the next file.
+	create := g.options.Output
+	if create == nil {
+		result.Files = make(map[string]string)
+		create = MapCreator(result.Files)
+	}
+	err = g.GenerateTo(runtimeAPI, prototypeAPI, func(filename string) (io.WriteCloser, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return create(filename)
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	result.Runtime = runtimeSource
+	result.Prototype = prototypeSource
+	result.Collisions = g.Collisions()
+	result.TypeReport = g.TypeReport()
+	return result, nil
+}
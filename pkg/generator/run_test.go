@@ -0,0 +1,42 @@
+package generator_test
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/generator"
+)
+
+func TestRun(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir("testdata/fixtures")))
+	defer server.Close()
+	options := generator.DefaultOptions()
+	options.RuntimeURL = server.URL + "/2.0.45/runtime-api.json"
+	options.PrototypeURL = server.URL + "/2.0.45/prototype-api.json"
+	options.HTTPClient = server.Client()
+
+	result, err := generator.Run(context.Background(), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := generator.NewGenerator(options).GenerateDefinitions(loadFixture(t, "2.0.45", "runtime"), loadFixture(t, "2.0.45", "prototype"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !maps.Equal(result.Files, want) {
+		t.Errorf("Run generated %v, want the files of GenerateDefinitions %v", sortedNames(result.Files), sortedNames(want))
+	}
+	if result.Runtime == nil || result.Runtime.GameVersion != "2.0.45" || len(result.Runtime.SHA256) != 64 {
+		t.Errorf("runtime source = %+v", result.Runtime)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := generator.Run(ctx, options); !errors.Is(err, context.Canceled) {
+		t.Errorf("Run with a canceled context returned %v", err)
+	}
+}
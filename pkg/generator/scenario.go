@@ -0,0 +1,92 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+// ScenarioFile declares the globals of scenario scripts with ContextScenario, in
+// place of the runtime globals.
+const ScenarioFile = "scenario.lua"
+
+// scenarioEvents are the events raised for scenarios and tutorials rather than
+// for mods: the game being created from the scenario, the scenario finishing and
+// its cutscenes.
+var scenarioEvents = []string{
+	"on_game_created_from_scenario",
+	"on_pre_scenario_finished",
+	"on_cutscene_started",
+	"on_cutscene_waypoint_reached",
+	"on_cutscene_finished",
+	"on_cutscene_cancelled",
+}
+
+// scenarioPreamble explains ScenarioFile, after the header.
+const scenarioPreamble = `-- The globals of a scenario's or tutorial's control.lua, and the event_handler
+-- library scenarios such as freeplay are built with. The runtime definitions don't
+-- declare globals in this context, so this library suits a LuaLS workspace of the
+-- scenario's directory; mods use one generated without --context.
+
+`
+
+// generateScenarioGlobals generates ScenarioFile: the classes of the event_handler
+// library, and the runtime globals with game typed as ScenarioGameScript, whose
+// surfaces name the surface every new game starts with.
+func (g *Generator) generateScenarioGlobals(runtimeAPI *api.API) string {
+	var sb strings.Builder
+	sb.WriteString(scenarioPreamble)
+
+	var events []string
+	for _, name := range scenarioEvents {
+		if slices.ContainsFunc(runtimeAPI.Events, func(e api.Event) bool { return e.Name == name }) {
+			events = append(events, fmt.Sprintf("[%s](runtime:%s)", name, name))
+		}
+	}
+	description := "A library of a scenario built with event_handler (see EventHandler): a table of the handlers it registers."
+	if len(events) > 0 {
+		description += " Besides the events of mods, scenarios get " + strings.Join(events, ", ") + "."
+	}
+	g.writeDocComment(&sb, "", description)
+	sb.WriteString("---@class ScenarioLib\n")
+	// The handlers of a library are all optional; event_handler checks for them.
+	for _, field := range []struct{ name, luaLSType, description string }{
+		{"events", "table<defines.events | string, fun(event: EventData)>", "Handlers by event id or custom input name."},
+		{"on_nth_tick", "table<uint, fun(event: NthTickEventData)>", "Handlers by tick period."},
+		{"on_init", "fun()", "Run when the game is created from the scenario."},
+		{"on_load", "fun()", "Run when a save of the scenario is loaded."},
+		{"on_configuration_changed", "fun(data: ConfigurationChangedData)", "Run when the game version or the mods of a save changed."},
+		{"add_remote_interface", "fun()", "Adds the remote interfaces of the library, before on_init and on_load."},
+		{"add_commands", "fun()", "Adds the console commands of the library, before on_init and on_load."},
+	} {
+		name, luaLSType := g.optionalMember(field.name, field.luaLSType, true)
+		sb.WriteString(fieldAnnotation(name, luaLSType, field.description) + "\n")
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString("---The event_handler library of the core mod, returned by require(\"event_handler\"),\n")
+	sb.WriteString("---which registers the handlers of every library added to it. Annotate the\n")
+	sb.WriteString("---result of the require with `---@type EventHandler`.\n")
+	sb.WriteString("---@class EventHandler\n")
+	sb.WriteString("---@field add_lib fun(lib: ScenarioLib) Adds a library, whose handlers run after those of the libraries added before it.\n")
+	sb.WriteString("---@field add_libraries fun(libs: ScenarioLib[]) Adds each library in turn.\n\n")
+
+	g.writeDocComment(&sb, "", "The surfaces of the game, by index or name. Every new game starts with nauvis; the scenario creates the others with [LuaGameScript::create_surface](runtime:LuaGameScript::create_surface).")
+	sb.WriteString("---@class ScenarioSurfaces\n")
+	sb.WriteString("---@field nauvis LuaSurface The surface the game starts on.\n")
+	if g.options.Dialect != DialectEmmyLua {
+		sb.WriteString("---@field [uint | string] LuaSurface\n")
+	}
+	sb.WriteString("\n")
+	g.writeDocComment(&sb, "", "[LuaGameScript](runtime:LuaGameScript), as seen by scenario scripts.")
+	sb.WriteString("---@class ScenarioGameScript : LuaGameScript\n")
+	sb.WriteString("---@field surfaces ScenarioSurfaces The surfaces of the game, by index or name. (Read-only)\n\n")
+	for _, class := range []string{"ScenarioLib", "EventHandler", "ScenarioSurfaces", "ScenarioGameScript"} {
+		g.types.known[class] = true
+	}
+
+	sb.WriteString(g.contextGlobals(runtimeAPI, map[string]string{"game": "ScenarioGameScript"}))
+	return sb.String()
+}
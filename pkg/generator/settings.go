@@ -0,0 +1,195 @@
+package generator
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+// settingField is a field of a mod setting prototype.
+type settingField struct {
+	name        string
+	luaLSType   string
+	optional    bool
+	description string
+}
+
+// settingPrototype describes one kind of mod setting prototype.
+type settingPrototype struct {
+	typeName  string // e.g. "bool-setting"
+	className string
+	fields    []settingField
+}
+
+// modSettingFields are shared by every mod setting prototype.
+var modSettingFields = []settingField{
+	{"name", "string", false, "Internal name of the setting, unique across all mods."},
+	{"setting_type", `"startup" | "runtime-global" | "runtime-per-user"`, false, "Determines whether the setting is read through settings.startup, settings.global or settings.player."},
+	{"localised_name", "LocalisedString", true, ""},
+	{"localised_description", "LocalisedString", true, ""},
+	{"order", "string", true, "Sorting order of the setting in the mod settings GUI."},
+	{"hidden", "boolean", true, "Hides the setting from the mod settings GUI."},
+}
+
+// settingPrototypes are the mod setting prototypes defined in settings.lua. The
+// prototype API doesn't document them, so they are described here.
+var settingPrototypes = []settingPrototype{
+	{"bool-setting", "BoolSettingPrototype", []settingField{
+		{"default_value", "boolean", false, ""},
+		{"forced_value", "boolean", true, "Only loaded when the setting is hidden; the value the setting then always has."},
+	}},
+	{"int-setting", "IntSettingPrototype", []settingField{
+		{"default_value", "int64", false, ""},
+		{"minimum_value", "int64", true, ""},
+		{"maximum_value", "int64", true, ""},
+		{"allowed_values", "int64[]", true, "If given, the setting is a dropdown of these values."},
+	}},
+	{"double-setting", "DoubleSettingPrototype", []settingField{
+		{"default_value", "double", false, ""},
+		{"minimum_value", "double", true, ""},
+		{"maximum_value", "double", true, ""},
+		{"allowed_values", "double[]", true, "If given, the setting is a dropdown of these values."},
+	}},
+	{"string-setting", "StringSettingPrototype", []settingField{
+		{"default_value", "string", false, ""},
+		{"allow_blank", "boolean", true, "Whether the setting may be empty."},
+		{"auto_trim", "boolean", true, "Whether leading and trailing whitespace is removed."},
+		{"allowed_values", "string[]", true, "If given, the setting is a dropdown of these values."},
+	}},
+	{"color-setting", "ColorSettingPrototype", []settingField{
+		{"default_value", "Color", false, ""},
+	}},
+}
+
+// generateSettingPrototypes generates the classes of the mod setting prototypes,
+// which settings.lua passes to data:extend, and returns them together with the
+// `---@field` lines adding their types to data.raw. The values of the settings
+// are read at runtime through the `settings` global (LuaSettings), as ModSetting.
+func (g *Generator) generateSettingPrototypes() (string, string) {
+	var sb, rawFields strings.Builder
+	sb.WriteString("-- Settings stage\n\n")
+
+	sb.WriteString("---Base of the mod setting prototypes, defined in settings.lua.\n")
+	sb.WriteString("---@class ModSettingPrototype : Prototype\n")
+	for _, field := range modSettingFields {
+		sb.WriteString(g.settingFieldAnnotation(field))
+	}
+	sb.WriteString("\n")
+
+	for _, setting := range settingPrototypes {
+		sb.WriteString(fmt.Sprintf("---@class %s : ModSettingPrototype\n", setting.className))
+		sb.WriteString(fmt.Sprintf("---@field type %s\n", luaString(setting.typeName)))
+		for _, field := range setting.fields {
+			sb.WriteString(g.settingFieldAnnotation(field))
+		}
+		sb.WriteString("\n")
+		rawFields.WriteString(fmt.Sprintf("---@field %s table<string, %s> Table of %s prototypes by name.\n", luaFieldName(setting.typeName), setting.className, setting.typeName))
+	}
+	return sb.String(), rawFields.String()
+}
+
+// settingFieldAnnotation generates the field annotation for a setting field.
+func (g *Generator) settingFieldAnnotation(field settingField) string {
+	// The types are those of the prototype stage, which may have been renamed.
+	name, luaLSType := g.optionalField(field.name, cmp.Or(g.renames[field.luaLSType], field.luaLSType), field.optional)
+	return fieldAnnotation(name, luaLSType, field.description) + "\n"
+}
+
+// ModSetting is a setting declared by a mod (see Options.ModSettings), whose
+// entry in the settings global is typed.
+type ModSetting struct {
+	Name          string   // e.g. "my-mod-enabled"
+	Type          string   // The setting prototype type, e.g. "bool-setting"
+	SettingType   string   // "startup", "runtime-global" or "runtime-per-user"
+	AllowedValues []string // Of string settings, if restricted
+}
+
+// modSettingTables are the classes typing the tables of mod settings, by
+// setting type, and the members of the runtime API holding such a table.
+var modSettingTables = []struct {
+	settingType string
+	className   string
+	members     []string // Class.attribute, or Class.method() for its return value
+}{
+	{"startup", "ModSettings.startup", []string{"LuaSettings.startup"}},
+	{"runtime-global", "ModSettings.global", []string{"LuaSettings.global"}},
+	{"runtime-per-user", "ModSettings.player", []string{"LuaSettings.player_default", "LuaSettings.get_player_settings()", "LuaPlayer.mod_settings"}},
+}
+
+// withModSettingTables returns the runtime API with the tables of mod settings
+// typed by the ModSettings classes instead of LuaCustomTable<string, ModSetting>,
+// when Options.ModSettings are given. The API is copied, not modified.
+func (g *Generator) withModSettingTables(runtimeAPI *api.API) *api.API {
+	if len(g.options.ModSettings) == 0 {
+		return runtimeAPI
+	}
+	typed := make(map[string]api.Type)
+	for _, table := range modSettingTables {
+		for _, member := range table.members {
+			typed[member] = api.Type{Name: table.className}
+		}
+	}
+	patched := *runtimeAPI
+	patched.Classes = slices.Clone(runtimeAPI.Classes)
+	for i, class := range patched.Classes {
+		class.Attributes = slices.Clone(class.Attributes)
+		for j, attribute := range class.Attributes {
+			if t, ok := typed[class.Name+"."+attribute.Name]; ok {
+				class.Attributes[j].Type = t
+				if attribute.ReadType != nil {
+					class.Attributes[j].ReadType = &t
+				}
+			}
+		}
+		class.Methods = slices.Clone(class.Methods)
+		for j, method := range class.Methods {
+			if t, ok := typed[class.Name+"."+method.Name+"()"]; ok && len(method.ReturnValues) > 0 {
+				class.Methods[j].ReturnValues = slices.Clone(method.ReturnValues)
+				class.Methods[j].ReturnValues[0].Type = t
+			}
+		}
+		patched.Classes[i] = class
+	}
+	return &patched
+}
+
+// generateModSettingTables generates the ModSettings classes: one field per
+// setting of Options.ModSettings, whose value is typed by the setting's type.
+// Settings missing from them, such as typos, are reported by LuaLS as undefined
+// fields.
+func (g *Generator) generateModSettingTables() string {
+	var sb strings.Builder
+	for _, table := range modSettingTables {
+		sb.WriteString(fmt.Sprintf("---The %s mod settings declared by the mods the definitions were generated for, by name.\n", table.settingType))
+		sb.WriteString(fmt.Sprintf("---@class %s\n", table.className))
+		for _, setting := range g.options.ModSettings {
+			if setting.SettingType != table.settingType {
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("---@field %s {value: %s}\n", luaFieldName(setting.Name), modSettingValueType(setting)))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// modSettingValueType returns the type of the value of a mod setting: that of its
+// prototype's default_value, or the allowed values of a restricted string setting.
+func modSettingValueType(setting ModSetting) string {
+	if setting.Type == "string-setting" && len(setting.AllowedValues) > 0 {
+		var values []string
+		for _, value := range setting.AllowedValues {
+			values = append(values, luaString(value))
+		}
+		return strings.Join(values, " | ")
+	}
+	for _, prototype := range settingPrototypes {
+		if prototype.typeName == setting.Type {
+			return prototype.fields[0].luaLSType
+		}
+	}
+	return "AnyBasic"
+}
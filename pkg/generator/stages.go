@@ -0,0 +1,48 @@
+package generator
+
+import (
+	"strings"
+)
+
+// With Options.StageGlobals, the globals of each stage go in a directory of their
+// own, and every other LuaLS file in StageCommonDir. A workspace (or a directory
+// with its own LuaLS configuration) adds StageCommonDir and the directory of its
+// stage to its library, so only that stage's globals are visible: game doesn't
+// complete in data.lua, nor data in control.lua.
+const (
+	StageCommonDir   = "common"
+	ControlStageDir  = "control"
+	DataStageDir     = "data"
+	SettingsStageDir = "settings"
+)
+
+// stageGlobalsFile is the file of a stage directory declaring its globals.
+const stageGlobalsFile = "globals.lua"
+
+// stagePath returns where a LuaLS file goes with StageGlobals: the files of the
+// stage directories stay there, and the others move to StageCommonDir.
+func stagePath(filename string) string {
+	for _, dir := range []string{ControlStageDir, DataStageDir, SettingsStageDir} {
+		if strings.HasPrefix(filename, dir+"/") {
+			return filename
+		}
+	}
+	return StageCommonDir + "/" + filename
+}
+
+// generateStageGlobals writes the globals of the settings and data stages to
+// their directories, given the globals generateDataStageGlobals separated. Both
+// stages define prototypes with data:extend; the data stage also reads the
+// startup settings, through the runtime stage's LuaSettings when it is generated.
+func (g *Generator) generateStageGlobals(defs *definitionSet, dataGlobals string, withRuntime bool) {
+	header := defs.headers["prototype"]
+	defs.file(SettingsStageDir+"/"+stageGlobalsFile, header).WriteString("-- Settings stage (settings.lua, settings-updates.lua and settings-final-fixes.lua)\n\n" + dataGlobals)
+
+	sb := defs.file(DataStageDir+"/"+stageGlobalsFile, header)
+	sb.WriteString("-- Data stage (data.lua, data-updates.lua and data-final-fixes.lua)\n\n" + dataGlobals)
+	if withRuntime {
+		sb.WriteString("\n---The startup settings of the active mods; the other settings don't exist yet.\n")
+		sb.WriteString("---@type LuaSettings\n")
+		sb.WriteString("settings = {}\n")
+	}
+}
@@ -0,0 +1,148 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+// stubsPreamble explains the stub files, after the header.
+const stubsPreamble = `-- Executable stubs for unit tests outside the game (busted or plain Lua), e.g. in a
+-- busted helper: dofile("path/to/stubs/runtime.lua"). Classes are tables of functions
+-- that do nothing and return nil, which tests replace with spies or their own
+-- implementations; global objects look their functions up in their class.
+-- Attributes aren't stubbed. For control-stage code, harness.lua fakes game, script
+-- and storage and raises events.
+
+`
+
+// stubGlobalFunctions are the functions the game adds to the global environment,
+// which the API documents outside its classes. table_size is implemented, as
+// mod code relies on its result.
+const stubGlobalFunctions = `function log(message) end
+
+function localised_print(message) end
+
+function table_size(t)
+  local size = 0
+  for _ in pairs(t) do
+    size = size + 1
+  end
+  return size
+end
+`
+
+// stubDataExtend implements data:extend as the game does, so that data stage code
+// can be tested by requiring it and inspecting data.raw.
+const stubDataExtend = `function data:extend(prototypes)
+  for _, prototype in ipairs(prototypes) do
+    self.raw[prototype.type] = self.raw[prototype.type] or {}
+    self.raw[prototype.type][prototype.name] = prototype
+  end
+  return self
+end
+`
+
+// generateStubs generates executable Lua stubs of the API (stubs/runtime.lua and
+// stubs/prototype.lua) and the mock harness (stubs/harness.lua) for mod unit
+// tests, rather than annotations. Defines have
+// values: the ones the API documents, or else distinct integers in the order of
+// the documentation, which matches the game for most defines but not all (event
+// ids for instance). Either API may be nil when only one stage is generated.
+func (g *Generator) generateStubs(runtimeAPI *api.API, prototypeAPI *api.API, headers map[string]string) map[string]string {
+	files := make(map[string]string)
+	if runtimeAPI != nil {
+		var sb strings.Builder
+		sb.WriteString(headers["runtime"])
+		sb.WriteString(stubsPreamble)
+		writeStubDefines(&sb, runtimeAPI.Defines)
+
+		classes := sortedByOrder(runtimeAPI.Classes)
+		for _, class := range classes {
+			sb.WriteString(fmt.Sprintf("%s = {}\n", class.Name))
+			for _, method := range sortedByOrder(class.Methods) {
+				sb.WriteString(fmt.Sprintf("function %s.%s(%s) end\n", class.Name, method.Name, strings.Join(stubParameters(method), ", ")))
+			}
+			sb.WriteString("\n")
+		}
+		// Parents are declared by then, whatever the order of the classes.
+		for _, class := range classes {
+			if len(class.Parent) > 0 {
+				sb.WriteString(fmt.Sprintf("setmetatable(%s, {__index = %s})\n", class.Name, class.Parent[0]))
+			}
+		}
+		sb.WriteString("\n")
+
+		for _, global := range runtimeGlobals(runtimeAPI) {
+			if !global.Type.IsSimple() {
+				sb.WriteString(fmt.Sprintf("%s = {}\n", global.Name))
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("%s = setmetatable({}, {__index = %s})\n", global.Name, global.Type.Name))
+		}
+		sb.WriteString(persistentDataName(runtimeAPI) + " = {}\n\n")
+		sb.WriteString(stubGlobalFunctions)
+		files["stubs/runtime.lua"] = sb.String()
+		files["stubs/harness.lua"] = luaHarness
+	}
+
+	if prototypeAPI != nil {
+		var sb strings.Builder
+		sb.WriteString(headers["prototype"])
+		sb.WriteString(stubsPreamble)
+		writeStubDefines(&sb, prototypeAPI.Defines)
+		sb.WriteString("data = {raw = {}, is_demo = false}\n\n")
+		sb.WriteString(stubDataExtend)
+		sb.WriteString("\nmods = {}\n")
+		files["stubs/prototype.lua"] = sb.String()
+	}
+	return files
+}
+
+// stubParameters returns the parameter names of a method stub, as in the
+// annotations: a single table for methods taking named arguments, and ... for
+// variadic ones.
+func stubParameters(method api.Method) []string {
+	var params []string
+	if method.Format.TakesTable {
+		params = append(params, "param")
+	} else {
+		for _, param := range sortedByOrder(method.Parameters) {
+			params = append(params, luaParamName(param.Name))
+		}
+	}
+	if method.VariadicParameter != nil {
+		params = append(params, "...")
+	}
+	return params
+}
+
+// writeStubDefines writes the defines as a global table of nested tables. Names
+// that aren't identifiers (the prototype types of defines.prototypes) are
+// written as ["name"] keys.
+func writeStubDefines(sb *strings.Builder, defines []api.Define) {
+	sb.WriteString("defines = {\n")
+	for _, define := range sortedByOrder(defines) {
+		writeStubDefine(sb, "  ", define, "defines."+define.Name)
+	}
+	sb.WriteString("}\n\n")
+}
+
+func writeStubDefine(sb *strings.Builder, indent string, define api.Define, fullName string) {
+	sb.WriteString(fmt.Sprintf("%s%s = {\n", indent, luaFieldName(define.Name)))
+	for _, value := range sortedByOrder(define.Values) {
+		literal := defineLiteral(value.Value)
+		switch {
+		case strings.HasPrefix(fullName, "defines.prototypes."):
+			literal = "0" // Every prototype subtype maps to 0 in the game
+		case value.Value == nil || literal == "nil":
+			literal = fmt.Sprint(value.Order)
+		}
+		sb.WriteString(fmt.Sprintf("%s  %s = %s,\n", indent, luaFieldName(value.Name), literal))
+	}
+	for _, subDefine := range sortedByOrder(define.Subkeys) {
+		writeStubDefine(sb, indent+"  ", subDefine, fullName+"."+subDefine.Name)
+	}
+	sb.WriteString(indent + "},\n")
+}
@@ -0,0 +1,414 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+// tealSyntax spells types as Teal type expressions. Teal is stricter than LuaLS:
+// every type is nilable, there are no literal types, and a union may hold at most
+// one table-like member, so anything else degrades to the closest valid type.
+type tealSyntax struct{}
+
+func (tealSyntax) named(name string) string {
+	switch {
+	case integerBuiltins[name] || name == "long" || name == "ulong":
+		return "integer"
+	case floatBuiltins[name] || name == "number":
+		return "number"
+	case name == "table":
+		return "{any:any}"
+	case name == "object" || name == "builtin" || builtinAliasTargets[name] != "":
+		return "any"
+	case name == "void":
+		return "nil"
+	case strings.HasPrefix(name, "defines."):
+		return "integer" // Defines are records of integer constants, see writeTealDefine
+	default:
+		return name
+	}
+}
+
+func (tealSyntax) array(element string, _ api.Type) string {
+	return "{" + element + "}"
+}
+
+func (tealSyntax) dictionary(key string, value string) string {
+	return "{" + key + ":" + value + "}"
+}
+
+func (s tealSyntax) customTable(key string, value string) string {
+	return s.dictionary(key, value)
+}
+
+func (s tealSyntax) key(t api.Type) string {
+	return translateType(t, s)
+}
+
+func (tealSyntax) union(members []string) string {
+	var kept []string
+	tableLike := 0
+	for _, member := range splitUnion(joinUnion(members...)) {
+		switch member {
+		case "nil", "":
+			continue // Every Teal type admits nil
+		case "string", "boolean", "integer", "number":
+		default:
+			tableLike++
+		}
+		kept = append(kept, member)
+	}
+	// Teal can't tell two table types apart at runtime, so it rejects such unions.
+	if tableLike > 1 || len(kept) == 0 {
+		return "any"
+	}
+	if slices.Contains(kept, "number") {
+		kept = slices.DeleteFunc(kept, func(member string) bool { return member == "integer" })
+	}
+	return strings.Join(kept, " | ")
+}
+
+func (tealSyntax) literal(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	default:
+		return "any"
+	}
+}
+
+func (tealSyntax) tuple(elements []string) string {
+	return "{" + strings.Join(elements, ", ") + "}"
+}
+
+func (tealSyntax) function(params []string) string {
+	return "function(" + strings.Join(params, ", ") + ")"
+}
+
+// table spells an inline table type as a map: Teal has no anonymous records.
+func (s tealSyntax) table(api.Type) string {
+	return s.named("table")
+}
+
+func (tealSyntax) any() string {
+	return "any"
+}
+
+// tealType translates an API type into a Teal type expression.
+func tealType(t api.Type) string {
+	return translateType(t, tealSyntax{})
+}
+
+// generateTeal generates Teal declaration files (runtime.d.tl and prototype.d.tl)
+// from the same parsed API as the LuaLS output. Teal records can't inherit from
+// one another, so classes and prototypes list their inherited members themselves.
+// Either API may be nil when only one stage is generated.
+func (g *Generator) generateTeal(runtimeAPI *api.API, prototypeAPI *api.API, headers map[string]string) map[string]string {
+	files := make(map[string]string)
+	declared := make(map[string]bool) // Concepts already declared by the runtime file
+	if runtimeAPI != nil {
+		var sb strings.Builder
+		sb.WriteString(headers["runtime"])
+		g.writeTealDefines(&sb, runtimeAPI.Defines)
+		// The EventData concept is declared together with the data of every event.
+		declared["EventData"] = true
+		g.writeTealConcepts(&sb, runtimeAPI.Concepts, declared)
+
+		classes := make(map[string]api.Class)
+		for _, class := range runtimeAPI.Classes {
+			classes[class.Name] = class
+		}
+		for _, class := range sortedByOrder(runtimeAPI.Classes) {
+			g.writeTealClass(&sb, class, classes)
+		}
+
+		sb.WriteString("global record EventData\n")
+		fields, _ := tealConceptFields(documentedEventData(runtimeAPI), nil)
+		for _, field := range fields {
+			sb.WriteString(fmt.Sprintf("   %s: %s\n", luaFieldName(field.name), field.tealType))
+		}
+		for _, event := range sortedByOrder(runtimeAPI.Events) {
+			writeTealRecord(&sb, "   ", "record "+event.Name, parameterFields(event.Data))
+		}
+		sb.WriteString("end\n\n")
+
+		for _, global := range runtimeGlobals(runtimeAPI) {
+			sb.WriteString(fmt.Sprintf("global %s: %s\n", global.Name, tealType(global.Type)))
+		}
+		sb.WriteString(fmt.Sprintf("global %s: {any:any}\n", persistentDataName(runtimeAPI)))
+		files["runtime.d.tl"] = sb.String()
+	}
+
+	if prototypeAPI != nil {
+		var sb strings.Builder
+		sb.WriteString(headers["prototype"])
+		if runtimeAPI == nil {
+			g.writeTealDefines(&sb, prototypeAPI.Defines)
+		}
+		concepts := append(slices.Clone(prototypeAPI.Concepts), prototypeAPI.Types...)
+		g.writeTealConcepts(&sb, concepts, declared)
+
+		prototypes := make(map[string]api.Prototype)
+		for _, prototype := range prototypeAPI.Prototypes {
+			prototypes[prototype.Name] = prototype
+		}
+		for _, prototype := range sortedByOrder(prototypeAPI.Prototypes) {
+			var chain [][]api.Property
+			for p, ok := prototype, true; ok; p, ok = prototypes[p.Parent] {
+				chain = append([][]api.Property{sortedByOrder(p.Properties)}, chain...)
+			}
+			// A few definitions share their name with a runtime concept (MapSettings).
+			name := prototype.Name
+			if declared[name] {
+				name += "Prototype"
+			}
+			writeTealRecord(&sb, "", "global record "+name, propertyFields(slices.Concat(chain...)))
+			sb.WriteString("\n")
+		}
+
+		sb.WriteString("global record data\n")
+		sb.WriteString("   raw: {string:{string:any}}\n")
+		sb.WriteString("   is_demo: boolean\n")
+		sb.WriteString("   extend: function(self: data, prototypes: {any})\n")
+		sb.WriteString("end\n")
+		sb.WriteString("global mods: {string:string}\n")
+		files["prototype.d.tl"] = sb.String()
+	}
+	return files
+}
+
+// tealField is a field of a Teal record.
+type tealField struct {
+	name     string
+	tealType string
+}
+
+// parameterFields returns the record fields of named parameters, in order.
+func parameterFields(params []api.Parameter) []tealField {
+	var fields []tealField
+	for _, param := range sortedByOrder(params) {
+		fields = append(fields, tealField{name: param.Name, tealType: tealType(param.Type)})
+	}
+	return fields
+}
+
+// propertyFields returns the record fields of properties. A property declared
+// again (such as an inherited one overridden by a child) keeps its last type.
+func propertyFields(properties []api.Property) []tealField {
+	var fields []tealField
+	index := make(map[string]int)
+	for _, property := range properties {
+		field := tealField{name: property.Name, tealType: tealType(property.ValueType())}
+		if i, ok := index[property.Name]; ok {
+			fields[i] = field
+			continue
+		}
+		index[property.Name] = len(fields)
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// writeTealRecord writes a record declaration, such as "global record Foo" or a
+// nested "record bar", with the given fields.
+func writeTealRecord(sb *strings.Builder, indent string, declaration string, fields []tealField) {
+	sb.WriteString(indent + declaration + "\n")
+	for _, field := range fields {
+		sb.WriteString(fmt.Sprintf("%s   %s: %s\n", indent, luaFieldName(field.name), field.tealType))
+	}
+	sb.WriteString(indent + "end\n")
+}
+
+// writeTealDefines writes the defines as nested records of integer constants.
+// Teal enums only hold strings, so define values are typed as integer.
+func (g *Generator) writeTealDefines(sb *strings.Builder, defines []api.Define) {
+	sb.WriteString("global record defines\n")
+	for _, define := range sortedByOrder(defines) {
+		writeTealDefine(sb, "   ", define)
+	}
+	sb.WriteString("end\n\n")
+}
+
+func writeTealDefine(sb *strings.Builder, indent string, define api.Define) {
+	sb.WriteString(fmt.Sprintf("%srecord %s\n", indent, define.Name))
+	for _, value := range sortedByOrder(define.Values) {
+		sb.WriteString(fmt.Sprintf("%s   %s: integer\n", indent, luaFieldName(value.Name)))
+	}
+	for _, subDefine := range sortedByOrder(define.Subkeys) {
+		writeTealDefine(sb, indent+"   ", subDefine)
+	}
+	sb.WriteString(indent + "end\n")
+}
+
+// writeTealConcepts writes the concepts not declared yet: string enumerations as
+// enums, struct-shaped concepts as records and everything else as type aliases.
+func (g *Generator) writeTealConcepts(sb *strings.Builder, concepts []api.Concept, declared map[string]bool) {
+	byName := make(map[string]api.Concept)
+	for _, concept := range concepts {
+		byName[concept.Name] = concept
+	}
+	for _, concept := range sortedByOrder(concepts) {
+		if isBuiltinConcept(concept) || declared[concept.Name] {
+			continue
+		}
+		declared[concept.Name] = true
+		if isStringLiteralUnion(concept.Type) {
+			sb.WriteString(fmt.Sprintf("global enum %s\n", concept.Name))
+			for _, option := range concept.Type.Values {
+				sb.WriteString(fmt.Sprintf("   %s\n", luaString(option.LiteralValue.(string))))
+			}
+			sb.WriteString("end\n\n")
+			continue
+		}
+		fields, ok := tealConceptFields(concept, byName)
+		if !ok {
+			sb.WriteString(fmt.Sprintf("global type %s = %s\n\n", concept.Name, tealType(concept.Type)))
+			continue
+		}
+		writeTealRecord(sb, "", "global record "+concept.Name, fields)
+		sb.WriteString("\n")
+	}
+}
+
+// tealConceptFields returns the record fields of a struct-shaped concept. A struct
+// that is one form of a union (MapPosition's {x, y} beside its tuple shorthand)
+// stands for the whole concept. Prototype structs include the properties of their
+// parents, looked up in concepts.
+func tealConceptFields(concept api.Concept, concepts map[string]api.Concept) ([]tealField, bool) {
+	structType, ok := structShape(concept.Type)
+	if !ok {
+		return nil, false
+	}
+	switch structType.ComplexType {
+	case "table":
+		params := slices.Clone(structType.Fields)
+		for _, group := range structType.VariantParameterGroups {
+			params = append(params, group.Parameters...)
+		}
+		return uniqueFields(parameterFields(params)), true
+	case "LuaStruct":
+		return propertyFields(sortedByOrder(structType.Attributes)), true
+	default:
+		var chain [][]api.Property
+		for c, ok := concept, true; ok; {
+			chain = append([][]api.Property{sortedByOrder(c.Properties)}, chain...)
+			if len(c.Parent) == 0 {
+				break
+			}
+			c, ok = concepts[c.Parent[0]]
+		}
+		return propertyFields(slices.Concat(chain...)), true
+	}
+}
+
+// uniqueFields drops repeated fields, such as a parameter documented by several
+// variant groups, keeping the first.
+func uniqueFields(fields []tealField) []tealField {
+	seen := make(map[string]bool)
+	return slices.DeleteFunc(fields, func(field tealField) bool {
+		if seen[field.name] {
+			return true
+		}
+		seen[field.name] = true
+		return false
+	})
+}
+
+// writeTealClass writes a runtime class as a global record with its attributes,
+// including inherited ones, and its methods as function-typed fields. Methods
+// taking a table of named arguments get a nested record for it.
+func (g *Generator) writeTealClass(sb *strings.Builder, class api.Class, classes map[string]api.Class) {
+	var attributes []api.Property
+	var methods []api.Method
+	var collect func(c api.Class)
+	collect = func(c api.Class) {
+		for _, parent := range c.Parent {
+			if parentClass, ok := classes[parent]; ok {
+				collect(parentClass)
+			}
+		}
+		attributes = append(attributes, sortedByOrder(c.Attributes)...)
+		attributes = append(attributes, sortedByOrder(c.Properties)...)
+		methods = append(methods, sortedByOrder(c.Methods)...)
+	}
+	collect(class)
+
+	sb.WriteString(fmt.Sprintf("global record %s\n", class.Name))
+	fields := propertyFields(attributes)
+	seen := make(map[string]bool)
+	for _, field := range fields {
+		seen[field.name] = true
+	}
+	for _, method := range methods {
+		if method.Format.TakesTable {
+			params := slices.Clone(method.Parameters)
+			for _, group := range method.VariantParameterGroups {
+				params = append(params, group.Parameters...)
+			}
+			writeTealRecord(sb, "   ", "record "+method.Name+"_param", uniqueFields(parameterFields(params)))
+		}
+	}
+	// Methods are declared last, so one that is redefined by a subclass keeps
+	// the subclass' signature.
+	signatures := make(map[string]string)
+	var methodNames []string
+	for _, method := range methods {
+		if seen[method.Name] {
+			continue
+		}
+		if _, ok := signatures[method.Name]; !ok {
+			methodNames = append(methodNames, method.Name)
+		}
+		signatures[method.Name] = tealMethodType(class.Name, method)
+	}
+	for _, field := range fields {
+		sb.WriteString(fmt.Sprintf("   %s: %s\n", luaFieldName(field.name), field.tealType))
+	}
+	for _, name := range methodNames {
+		sb.WriteString(fmt.Sprintf("   %s: %s\n", luaFieldName(name), signatures[name]))
+	}
+	sb.WriteString("end\n\n")
+}
+
+// tealMethodType returns the function type of a method. Factorio methods are
+// called with dot syntax, so there is no self parameter.
+func tealMethodType(className string, method api.Method) string {
+	var params []string
+	if method.Format.TakesTable {
+		optional := ""
+		if method.Format.TableOptional {
+			optional = "?"
+		}
+		params = append(params, fmt.Sprintf("params%s: %s.%s_param", optional, className, method.Name))
+	} else {
+		for _, param := range sortedByOrder(method.Parameters) {
+			optional := ""
+			if param.Optional {
+				optional = "?"
+			}
+			params = append(params, fmt.Sprintf("%s%s: %s", luaParamName(param.Name), optional, tealType(param.Type)))
+		}
+	}
+	if method.VariadicParameter != nil {
+		params = append(params, "...: "+tealType(method.VariadicParameter.Type))
+	}
+	var results []string
+	for _, result := range sortedByOrder(method.ReturnValues) {
+		results = append(results, tealType(result.Type))
+	}
+	signature := "function(" + strings.Join(params, ", ") + ")"
+	if len(results) > 0 {
+		signature += ": " + strings.Join(results, ", ")
+	}
+	return signature
+}
@@ -0,0 +1,109 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+// templateNames are the entities whose emission can be overridden by a template
+// named after them, e.g. class.tmpl. A template receives the entity together with
+// the output the generator would emit for it (Default), so it can rewrite the
+// output entirely or only wrap or adjust it.
+var templateNames = []string{"class", "method", "field", "define"}
+
+// ClassTemplateData is passed to class.tmpl for each runtime class. Default holds
+// the class including its fields and methods, which field.tmpl and method.tmpl
+// have already been applied to.
+type ClassTemplateData struct {
+	Class   api.Class
+	Default string
+}
+
+// MethodTemplateData is passed to method.tmpl for each method of a runtime class.
+type MethodTemplateData struct {
+	ClassName string
+	Method    api.Method
+	Default   string
+}
+
+// FieldTemplateData is passed to field.tmpl for each `---@field` annotation of a
+// property, with the LuaLS type the generator resolved for it.
+type FieldTemplateData struct {
+	Name     string
+	Property api.Property
+	Type     string
+	Default  string
+}
+
+// DefineTemplateData is passed to define.tmpl for each define. FullName is the
+// define's path, e.g. "defines.direction"; nested defines are rendered separately.
+type DefineTemplateData struct {
+	FullName string
+	Define   api.Define
+	Default  string
+}
+
+// templateFuncs are the functions available to the templates. The placeholders
+// let templates parse before a Generator binds them (see override).
+var templateFuncs = template.FuncMap{
+	"luaType":    func(api.Type) string { return "" },
+	"docComment": func(string) string { return "" },
+}
+
+// LoadTemplates parses the generation templates in dir (class.tmpl, method.tmpl,
+// field.tmpl and define.tmpl, each optional) for Options.Templates. Other .tmpl
+// files are rejected, so a misspelled name doesn't go unnoticed.
+func LoadTemplates(dir string) (*template.Template, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .tmpl files in %s", dir)
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".tmpl")
+		if !slices.Contains(templateNames, name) {
+			return nil, fmt.Errorf("unknown template %s (expected one of %s.tmpl)", filepath.Base(file), strings.Join(templateNames, ".tmpl, "))
+		}
+	}
+	return template.New(filepath.Base(dir)).Funcs(templateFuncs).ParseFiles(files...)
+}
+
+// override renders the template overriding an entity's emission, if one is
+// configured, and otherwise returns the default output. Trailing newlines follow
+// the default output, so template files may end with a newline or not. The first
+// template error is kept and reported by GenerateDefinitions.
+func (g *Generator) override(name string, data any, defaultOutput string) string {
+	if g.options.Templates == nil {
+		return defaultOutput
+	}
+	tmpl := g.options.Templates.Lookup(name + ".tmpl")
+	if tmpl == nil {
+		return defaultOutput
+	}
+	g.templateMu.Lock()
+	defer g.templateMu.Unlock()
+	tmpl.Funcs(template.FuncMap{
+		"luaType": g.translateFactorioTypeToLuaLS,
+		"docComment": func(text string) string {
+			var sb strings.Builder
+			g.writeDocComment(&sb, "", text)
+			return sb.String()
+		},
+	})
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		if g.templateErr == nil {
+			g.templateErr = fmt.Errorf("failed to render %s.tmpl: %w", name, err)
+		}
+		return defaultOutput
+	}
+	trailing := defaultOutput[len(strings.TrimRight(defaultOutput, "\n")):]
+	return strings.TrimRight(sb.String(), "\n") + trailing
+}
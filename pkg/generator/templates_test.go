@@ -0,0 +1,52 @@
+package generator_test
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/generator"
+)
+
+// Generators given the same templates don't share state, so they can run
+// concurrently (run with -race).
+func TestConcurrentGeneratorsWithTemplates(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "field.tmpl"), []byte("---@field {{.Name}} {{.Type}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	templates, err := generator.LoadTemplates(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runtimeAPI := loadFixture(t, "2.0.45", "runtime")
+	prototypeAPI := loadFixture(t, "2.0.45", "prototype")
+
+	outputs := make([]map[string]string, 4)
+	var wg sync.WaitGroup
+	for i := range outputs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			options := generator.DefaultOptions()
+			options.Templates = templates
+			definitions, err := generator.NewGenerator(options).GenerateDefinitions(runtimeAPI, prototypeAPI)
+			if err != nil {
+				t.Error(err)
+			}
+			outputs[i] = definitions
+		}()
+	}
+	wg.Wait()
+	for _, output := range outputs[1:] {
+		if !maps.Equal(output, outputs[0]) {
+			t.Error("the generators' outputs differ")
+		}
+	}
+	if !strings.Contains(outputs[0]["runtime.lua"], "---@field object_name string\n") {
+		t.Error("the field template wasn't used")
+	}
+}
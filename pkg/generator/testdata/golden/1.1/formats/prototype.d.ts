@@ -0,0 +1,366 @@
+// Auto-generated Factorio Prototype API definitions
+// Generated from: fixtures/1.1/prototype-api.json
+
+/// <reference types="@typescript-to-lua/language-extensions" />
+
+/**
+ * The name of an [EntityPrototype](https://lua-api.factorio.com/1.1.110/prototypes/EntityPrototype.html).
+ */
+type EntityID = string
+
+/**
+ * A slash `"/"` is always used as the directory delimiter. A path always begins with the specification of a root, which can be one of three formats:
+ *
+ * - **core**: A path starting with `__core__` will access the resources in the data/core directory, these resources are always accessible regardless of mod specifications.
+ *
+ * - **base**: A path starting with `__base__` will access the resources in the base mod in data/base directory. These resources are usually available, as long as the base mod isn't removed/deactivated.
+ *
+ * - **mod path**: The format `__<mod-name>__` is placeholder for root of any other mod (mods/<mod-name>), and is accessible as long as the mod is active.
+ */
+type FileName = string
+
+/**
+ * The name of an [ItemPrototype](https://lua-api.factorio.com/1.1.110/prototypes/ItemPrototype.html).
+ */
+type ItemID = string
+
+/**
+ * An array containing the following values.
+ */
+type ItemPrototypeFlags = ("draw-logistic-overlay" | "excluded-from-trash-unrequested" | "always-show" | "hide-from-bonus-gui" | "hide-from-fuel-tooltip" | "not-stackable" | "primary-place-result" | "mod-openable" | "only-in-cursor" | "spawnable" | "spoil-result" | "ignore-spoil-time-modifier")[]
+
+/**
+ * Specifies one picture that can be used in the game.
+ *
+ * When there is more than one sprite or [Animation](https://lua-api.factorio.com/1.1.110/types/Animation.html) frame with the same source file and dimensions/position in the game, they all share the same memory.
+ */
+interface Sprite extends SpriteParameters {
+  /**
+   * If this property is present, all Sprite definitions have to be placed as entries in the array, and they will all be loaded from there. `layers` may not be an empty table. Each definition in the array may also have the `layers` property.
+   *
+   * If this property is present, all other properties, including those inherited from SpriteParameters, are ignored.
+   */
+  layers?: Sprite[]
+  /**
+   * Only loaded, and mandatory if `layers` is not defined.
+   *
+   * The path to the sprite file to use.
+   */
+  filename?: FileName
+  /**
+   * Only loaded if `layers` is not defined.
+   *
+   * Number of slices this is sliced into when using the "optimized atlas packing" option. If you are a modder, you can just ignore this property. Example: If this is 4, the sprite will be sliced into a 4x4 grid.
+   */
+  dice?: SpriteSizeType
+  /**
+   * Only loaded if `layers` is not defined.
+   *
+   * Same as `dice` above, but this specifies only how many slices there are on the x axis.
+   */
+  dice_x?: SpriteSizeType
+  /**
+   * Only loaded if `layers` is not defined.
+   *
+   * Same as `dice` above, but this specifies only how many slices there are on the y axis.
+   */
+  dice_y?: SpriteSizeType
+}
+
+/**
+ * Ammo used for a gun.
+ */
+interface AmmoItemPrototype extends Omit<ItemPrototype, "type"> {
+  type: "ammo"
+  /**
+   * When using a plain [AmmoType](https://lua-api.factorio.com/1.1.110/types/AmmoType.html) (no array), the ammo type applies to everything (`"default"`).
+   *
+   * When using an array of AmmoTypes, they have the additional [AmmoType::source_type](https://lua-api.factorio.com/1.1.110/types/AmmoType.html#source_type) property.
+   */
+  ammo_type: AmmoType | AmmoType[]
+  /**
+   * Number of shots before ammo item is consumed. Must be >= `1`.
+   */
+  magazine_size?: number
+  /**
+   * Amount of extra time (in ticks) it takes to reload the weapon after depleting the magazine. Must be >= `0`.
+   */
+  reload_time?: number
+  ammo_category: AmmoCategoryID
+  shoot_protected?: boolean
+}
+
+/**
+ * Custom events share the same namespace as custom inputs and built-in events for subscribing to and raising them.
+ */
+interface CustomEventPrototype extends Prototype {
+  type: "custom-event"
+}
+
+/**
+ * Possible configuration for all items.
+ */
+interface ItemPrototype extends Prototype {
+  type: "item"
+  /**
+   * Count of items of the same name that can be stored in one inventory slot. Must be 1 when the `"not-stackable"` flag is set.
+   */
+  stack_size: ItemCountType
+  /**
+   * Can't be an empty array.
+   */
+  icons?: IconData[]
+  /**
+   * Path to the icon file.
+   *
+   * Mandatory if `icons` is not defined.
+   */
+  icon?: FileName
+  /**
+   * The size of the square icon, in pixels. E.g. `32` for a 32px by 32px icon. Must be larger than `0`.
+   *
+   * Only loaded if `icons` is not defined.
+   */
+  icon_size?: SpriteSizeType
+  /**
+   * Can't be an empty array.
+   */
+  dark_background_icons?: IconData[]
+  /**
+   * If this is set, it is used to show items in alt-mode instead of the normal item icon. This can be useful to increase the contrast of the icon with the dark alt-mode [icon outline](https://lua-api.factorio.com/1.1.110/types/UtilityConstants.html#item_outline_color).
+   *
+   * Path to the icon file.
+   *
+   * Only loaded if `dark_background_icons` is not defined.
+   */
+  dark_background_icon?: FileName
+  /**
+   * The size of the square icon, in pixels. E.g. `32` for a 32px by 32px icon. Must be larger than `0`.
+   *
+   * Only loaded if `dark_background_icons` is not defined.
+   */
+  dark_background_icon_size?: SpriteSizeType
+  /**
+   * Name of the [EntityPrototype](https://lua-api.factorio.com/1.1.110/prototypes/EntityPrototype.html) that can be built using this item. If this item should be the one that construction bots use to build the specified `place_result`, set the `"primary-place-result"` [item flag](https://lua-api.factorio.com/1.1.110/types/ItemPrototypeFlags.html).
+   *
+   * The localised name of the entity will be used as the in-game item name. This behavior can be overwritten by specifying `localised_name` on this item, it will be used instead.
+   */
+  place_result?: EntityID
+  place_as_equipment_result?: EquipmentID
+  /**
+   * Must exist when a nonzero fuel_value is defined.
+   */
+  fuel_category?: FuelCategoryID
+  /**
+   * The item that is the result when this item gets burned as fuel.
+   */
+  burnt_result?: ItemID
+  spoil_result?: ItemID
+  plant_result?: EntityID
+  place_as_tile?: PlaceAsTile
+  /**
+   * Used to give the item multiple different icons so that they look less uniform on belts. For inventory icons and similar, `icon/icons` will be used. Maximum number of variations is 16.
+   *
+   * When using sprites of size `64` (same as base game icons), the `scale` should be set to 0.5.
+   */
+  pictures?: SpriteVariations
+  /**
+   * Specifies some properties of the item.
+   */
+  flags?: ItemPrototypeFlags
+  spoil_ticks?: number
+  /**
+   * Amount of energy the item gives when used as fuel.
+   *
+   * Mandatory if `fuel_acceleration_multiplier`, `fuel_top_speed_multiplier` or `fuel_emissions_multiplier` or `fuel_glow_color` are used.
+   */
+  fuel_value?: Energy
+  /**
+   * Must be 0 or positive.
+   */
+  fuel_acceleration_multiplier?: number
+  /**
+   * Must be 0 or positive.
+   */
+  fuel_top_speed_multiplier?: number
+  fuel_emissions_multiplier?: number
+  /**
+   * Additional fuel acceleration multiplier per quality level. Defaults to 30% of `fuel_acceleration_multiplier - 1` if `fuel_acceleration_multiplier` is larger than 1. Otherwise defaults to 0.
+   *
+   * Must be 0 or positive.
+   */
+  fuel_acceleration_multiplier_quality_bonus?: number
+  /**
+   * Additional fuel top speed multiplier per quality level. Defaults to 30% of `fuel_top_speed_multiplier - 1` if `fuel_top_speed_multiplier` is larger than 1. Otherwise defaults to 0.
+   *
+   * Must be 0 or positive.
+   */
+  fuel_top_speed_multiplier_quality_bonus?: number
+  /**
+   * The default weight is calculated automatically from recipes and falls back to [UtilityConstants::default_item_weight](https://lua-api.factorio.com/1.1.110/types/UtilityConstants.html#default_item_weight).
+   *
+   * More information on how item weight is determined can be found on its [auxiliary page](https://lua-api.factorio.com/1.1.110/auxiliary/item-weight.html).
+   */
+  weight?: Weight
+  ingredient_to_weight_coefficient?: number
+  /**
+   * Colors the glow of the burner energy source when this fuel is burned. Can also be used to color the glow of reactors burning the fuel, see [ReactorPrototype::use_fuel_glow_color](https://lua-api.factorio.com/1.1.110/prototypes/ReactorPrototype.html#use_fuel_glow_color).
+   */
+  fuel_glow_color?: Color
+  open_sound?: Sound
+  close_sound?: Sound
+  pick_sound?: Sound
+  drop_sound?: Sound
+  inventory_move_sound?: Sound
+  default_import_location?: SpaceLocationID
+  /**
+   * Only used by hidden setting, support may be limited.
+   */
+  color_hint?: ColorHintSpecification
+  has_random_tint?: boolean
+  spoil_to_trigger_result?: SpoilToTriggerResult
+  /**
+   * The effect/trigger that happens when an item is destroyed by being dropped on a [TilePrototype](https://lua-api.factorio.com/1.1.110/prototypes/TilePrototype.html) marked as destroying dropped items.
+   *
+   * This overrides the [TilePrototype::default_destroyed_dropped_item_trigger](https://lua-api.factorio.com/1.1.110/prototypes/TilePrototype.html#default_destroyed_dropped_item_trigger) from the tile.
+   */
+  destroyed_by_dropping_trigger?: Trigger
+  rocket_launch_products?: ItemProductPrototype[]
+  /**
+   * The way this item works when we try to send it to the orbit on its own.
+   *
+   * When "manual" is set, it can only be launched by pressing the launch button in the rocket silo.
+   *
+   * When "automated" is set, it will force the existence of "launch to orbit automatically" checkBox in the rocket silo which will then force the silo to automatically send the item to orbit when present.
+   */
+  send_to_orbit_mode?: SendToOrbitMode
+  /**
+   * Randomly tints item instances on belts and in the world. 0 no tinting. 1 full tint.
+   */
+  random_tint_color?: Color
+  /**
+   * Used by Inserters with spoil priority. Item with higher spoil level is considered more spoiled than item with lower spoil level regardless of progress of spoiling.
+   */
+  spoil_level?: number
+  /**
+   * Whether the item should be included in the self-recycling recipes automatically generated by the quality mod.
+   *
+   * This property is not read by the game engine itself, but the quality mod's data-updates.lua file. This means it is discarded by the game engine after loading finishes.
+   */
+  auto_recycle?: boolean
+}
+
+interface Prototype extends PrototypeBase {
+  /**
+   * The ID type corresponding to the prototype that inherits from this.
+   *
+   * For example, if this is an [EntityPrototype](https://lua-api.factorio.com/1.1.110/prototypes/EntityPrototype.html), this property's type is [EntityID](https://lua-api.factorio.com/1.1.110/types/EntityID.html).
+   */
+  factoriopedia_alternative?: string
+}
+
+/**
+ * The abstract base for prototypes. PrototypeBase defines the common features of prototypes, such as localization and order.
+ */
+interface PrototypeBase {
+  /**
+   * Specifies the kind of prototype this is.
+   *
+   * For a list of all possible types, see the [prototype overview](https://lua-api.factorio.com/1.1.110/prototypes.html).
+   */
+  type: string
+  /**
+   * Unique textual identification of the prototype. May only contain alphanumeric characters, dashes and underscores. May not exceed a length of 200 characters.
+   *
+   * For a list of all names used in vanilla, see [data.raw](https://wiki.factorio.com/Data.raw).
+   */
+  name: string
+  /**
+   * Used to order prototypes in inventory, recipes and GUIs. May not exceed a length of 200 characters.
+   */
+  order?: Order
+  /**
+   * Overwrites the name set in the [locale file](https://wiki.factorio.com/Tutorial:Localisation). Can be used to easily set a procedurally-generated name because the LocalisedString format allows to insert parameters into the name directly from the Lua script.
+   */
+  localised_name?: LocalisedString
+  /**
+   * Overwrites the description set in the [locale file](https://wiki.factorio.com/Tutorial:Localisation). The description is usually shown in the tooltip of the prototype.
+   */
+  localised_description?: LocalisedString
+  /**
+   * Provides additional description used in factoriopedia.
+   */
+  factoriopedia_description?: LocalisedString
+  /**
+   * The name of an [ItemSubGroup](https://lua-api.factorio.com/1.1.110/types/ItemSubGroup.html).
+   */
+  subgroup?: ItemSubGroupID
+  hidden?: boolean
+  hidden_in_factoriopedia?: boolean
+  /**
+   * Whether the prototype is a special type which can be used to parametrize blueprints and doesn't have other function.
+   */
+  parameter?: boolean
+  /**
+   * The simulation shown when looking at this prototype in the Factoriopedia GUI.
+   */
+  factoriopedia_simulation?: SimulationDefinition
+}
+
+/**
+ * A [rail signal](https://wiki.factorio.com/Rail_signal).
+ */
+interface RailSignalPrototype {
+  type: "rail-signal"
+}
+
+/**
+ * Items with a "durability". Used for [science packs](https://wiki.factorio.com/Science_pack).
+ */
+interface ToolPrototype extends Omit<ItemPrototype, "type"> {
+  type: "tool"
+  /**
+   * The durability of this tool. Must be positive. Mandatory if `infinite` is false. Ignored if <code>infinite</code> is true.
+   */
+  durability?: number
+  /**
+   * May not be longer than 200 characters.
+   */
+  durability_description_key?: string
+  /**
+   * May not be longer than 200 characters.
+   *
+   * In-game, the game provides the locale with three [parameters](https://wiki.factorio.com/Tutorial:Localisation#Localising_with_parameters):
+   *
+   * `__1__`: remaining durability
+   *
+   * `__2__`: total durability
+   *
+   * `__3__`: durability as a percentage
+   *
+   * So when a locale key that has the following translation
+   *
+   * `Remaining durability is __1__ out of __2__ which is __3__ %`
+   *
+   * is applied to a tool with 2 remaining durability out of 8 it will be displayed as
+   *
+   * `Remaining durability is 2 out of 8 which is 25 %`
+   */
+  durability_description_value?: string
+  /**
+   * Whether this tool has infinite durability. If this is false, `durability` must be specified.
+   */
+  infinite?: boolean
+}
+
+/** Any prototype definition, discriminated by its `type` field. */
+type PrototypeUnion = AmmoItemPrototype | CustomEventPrototype | ItemPrototype | RailSignalPrototype | ToolPrototype
+
+/** The table prototypes are defined in, available in the data stage. */
+declare const data: {
+  readonly raw: Record<string, Record<string, PrototypeUnion>>
+  readonly is_demo: boolean
+  extend(this: void, prototypes: PrototypeUnion[]): void
+}
+/** The active mods, mapped to their version. */
+declare const mods: Record<string, string>
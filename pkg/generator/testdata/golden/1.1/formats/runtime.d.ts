@@ -0,0 +1,893 @@
+// Auto-generated Factorio Runtime API definitions
+// Generated from: fixtures/1.1/runtime-api.json
+
+/// <reference types="@typescript-to-lua/language-extensions" />
+
+declare namespace defines {
+  enum direction {
+    north,
+    northnortheast,
+    northeast,
+    eastnortheast,
+    east,
+    eastsoutheast,
+    southeast,
+    southsoutheast,
+    south,
+    southsouthwest,
+    southwest,
+    westsouthwest,
+    west,
+    westnorthwest,
+    northwest,
+    northnorthwest,
+  }
+  /**
+   * See the [events page](https://lua-api.factorio.com/1.1.110/events.html) for more info on what events contain and when they get raised.
+   */
+  enum events {
+    on_built_entity,
+    on_player_created,
+    on_research_finished,
+    on_tick,
+  }
+}
+
+/**
+ * Two positions, specifying the top-left and bottom-right corner of the box respectively. Like with [MapPosition](https://lua-api.factorio.com/1.1.110/concepts/MapPosition.html), the names of the members may be omitted. When read from the game, the third member `orientation` is present if it is non-zero.
+ */
+interface BoundingBoxStruct {
+  left_top: MapPosition
+  right_bottom: MapPosition
+  orientation?: RealOrientation
+}
+type BoundingBox = BoundingBoxStruct | [MapPosition, MapPosition]
+
+/**
+ * Localised strings are a way to support translation of in-game text. It is an array where the first element is the key and the remaining elements are parameters that will be substituted for placeholders in the template designated by the key.
+ *
+ * The key identifies the string template. For example, `"gui-alert-tooltip.attack"` (for the template `"__1__ objects are being damaged"`; see the file `data/core/locale/en.cfg`).
+ *
+ * The template can contain placeholders such as `__1__` or `__2__`. These will be replaced by the respective parameter in the LocalisedString. The parameters themselves can be other localised strings, which will be processed recursively in the same fashion. Localised strings can not be recursed deeper than 20 levels and can not have more than 20 parameters.
+ *
+ * There are two special flags for the localised string, indicated by the key being a particular string. First, if the key is the empty string (`""`), then all parameters will be concatenated (after processing, if any are localised strings themselves). Second, if the key is a question mark (`"?"`), then the first valid parameter will be used. A parameter can be invalid if its name doesn't match any string template. If no parameters are valid, the last one is returned. This is useful to implement a fallback for missing locale templates.
+ *
+ * Furthermore, when an API function expects a localised string, it will also accept a regular string (i.e. not a table) which will not be translated, as well as a number, boolean or `nil`, which will be converted to their textual representation.
+ */
+type LocalisedString = string | number | boolean | any | undefined | LocalisedString[]
+
+/**
+ * Coordinates on a surface, for example of an entity. MapPositions may be specified either as a dictionary with `x`, `y` as keys, or simply as an array with two elements.
+ *
+ * The coordinates are saved as a fixed-size 32 bit integer, with 8 bits reserved for decimal precision, meaning the smallest value step is `1/2^8 = 0.00390625` tiles.
+ */
+interface MapPositionStruct {
+  x: number
+  y: number
+}
+type MapPosition = MapPositionStruct | [number, number]
+
+/**
+ * A dictionary of string to the four basic Lua types: `string`, `boolean`, `number`, `table`.
+ *
+ * Note that the API returns tags as a simple table, meaning any modifications to it will not propagate back to the game. Thus, to modify a set of tags, the whole table needs to be written back to the respective property.
+ */
+type Tags = Record<string, AnyBasic>
+
+/**
+ * A vector is a two-element array or dictionary containing the `x` and `y` components. The game will always provide the array format. Positive x goes east, positive y goes south.
+ */
+interface VectorStruct {
+  x: number
+  y: number
+}
+type Vector = VectorStruct | [number, number]
+
+/**
+ * Red, green, blue and alpha values, all in range [0, 1] or all in range [0, 255] if any value is > 1. All values here are optional. Color channels default to `0`, the alpha channel defaults to `1`.
+ *
+ * Similar to [MapPosition](https://lua-api.factorio.com/1.1.110/concepts/MapPosition.html), Color allows the short-hand notation of passing an array of exactly 3 or 4 numbers. The game usually expects colors to be in pre-multiplied form (color channels are pre-multiplied by alpha).
+ */
+interface ColorStruct {
+  r?: number
+  g?: number
+  b?: number
+  a?: number
+}
+type Color = ColorStruct | [number, number, number, number]
+
+interface ModSetting {
+  /**
+   * The value of the mod setting. The type depends on the kind of setting.
+   */
+  value: number | boolean | string | Color
+}
+
+/**
+ * Any basic type (string, number, boolean) or table.
+ */
+type AnyBasic = string | boolean | number | LuaTable
+
+interface NthTickEventData {
+  /**
+   * The tick during which the event happened.
+   */
+  tick: number
+  /**
+   * The nth tick this handler was registered to.
+   */
+  nth_tick: number
+}
+
+interface ConfigurationChangedData {
+  /**
+   * Old version of the map. Present only when loading map version other than the current version.
+   */
+  old_version?: string
+  /**
+   * New version of the map. Present only when loading map version other than the current version.
+   */
+  new_version?: string
+  /**
+   * Dictionary of mod changes. It is indexed by mod name.
+   */
+  mod_changes: Record<string, ModChangeData>
+  /**
+   * `true` when mod startup settings have changed since the last time this save was loaded.
+   */
+  mod_startup_settings_changed: boolean
+  /**
+   * `true` when mod prototype migrations have been applied since the last time this save was loaded.
+   */
+  migration_applied: boolean
+}
+
+interface CustomCommandData {
+  /**
+   * The name of the command.
+   */
+  name: string
+  /**
+   * The tick the command was used in.
+   */
+  tick: number
+  /**
+   * The player who issued the command, or `nil` if it was issued from the server console.
+   */
+  player_index?: number
+  /**
+   * The parameter passed after the command, if there is one.
+   */
+  parameter?: string
+}
+
+interface LuaPlayerBuiltEntityEventFilter {
+  /**
+   * The condition to filter on.
+   */
+  filter: "ghost" | "rail" | "rail-signal" | "rolling-stock" | "robot-with-logistics-interface" | "vehicle" | "turret" | "crafting-machine" | "wall-connectable" | "transport-belt-connectable" | "circuit-network-connectable" | "type" | "name" | "ghost_type" | "ghost_name" | "force"
+  /**
+   * The entity force
+   */
+  force: string
+  /**
+   * The ghost prototype name.
+   */
+  name: string
+  /**
+   * The ghost prototype type.
+   */
+  type: string
+  /**
+   * How to combine this with the previous filter. Defaults to `"or"`. When evaluating the filters, `"and"` has higher precedence than `"or"`.
+   */
+  mode?: "or" | "and"
+  /**
+   * Inverts the condition. Default is `false`.
+   */
+  invert?: boolean
+}
+
+declare namespace LuaBootstrap {
+  interface raise_console_chat_param {
+    /**
+     * The player doing the chatting.
+     */
+    player_index: number
+    /**
+     * The chat message to send.
+     */
+    message: string
+  }
+  interface raise_player_crafted_item_param {
+    /**
+     * The item that has been crafted.
+     */
+    item_stack: LuaItemStack
+    /**
+     * The player doing the crafting.
+     */
+    player_index: number
+    /**
+     * The recipe used to craft this item.
+     */
+    recipe: RecipeID
+  }
+  interface raise_player_fast_transferred_param {
+    /**
+     * The player transferred from or to.
+     */
+    player_index: number
+    /**
+     * The entity transferred from or to.
+     */
+    entity: LuaEntity
+    /**
+     * Whether the transfer was from player to entity. If `false`, the transfer was from entity to player.
+     */
+    from_player: boolean
+    /**
+     * Whether the transfer was a split action (half stack).
+     */
+    is_split: boolean
+  }
+  interface raise_biter_base_built_param {
+    /**
+     * The entity that was built.
+     */
+    entity: LuaEntity
+  }
+  interface raise_market_item_purchased_param {
+    /**
+     * The player who did the purchasing.
+     */
+    player_index: number
+    /**
+     * The market entity.
+     */
+    market: LuaEntity
+    /**
+     * The index of the offer purchased.
+     */
+    offer_index: number
+    /**
+     * The amount of offers purchased.
+     */
+    count: number
+  }
+  interface raise_script_built_param {
+    /**
+     * The entity that has been built.
+     */
+    entity: LuaEntity
+  }
+  interface raise_script_destroy_param {
+    /**
+     * The entity that was destroyed.
+     */
+    entity: LuaEntity
+  }
+  interface raise_script_revive_param {
+    /**
+     * The entity that was revived.
+     */
+    entity: LuaEntity
+    /**
+     * The tags associated with this entity, if any.
+     */
+    tags?: Tags
+  }
+  interface raise_script_teleported_param {
+    /**
+     * The entity that was teleported.
+     */
+    entity: LuaEntity
+    /**
+     * The entity's surface before the teleportation.
+     */
+    old_surface_index: number
+    /**
+     * The entity's position before the teleportation.
+     */
+    old_position: MapPosition
+  }
+  interface raise_script_set_tiles_param {
+    /**
+     * The surface whose tiles have been changed.
+     */
+    surface_index: number
+    /**
+     * The tiles that have been changed.
+     */
+    tiles: Tile[]
+  }
+}
+/**
+ * Entry point for registering event handlers. It is accessible through the global object named `script`.
+ */
+interface LuaBootstrap {
+  /**
+   * The name of the mod from the environment this is used in.
+   */
+  readonly mod_name: string
+  /**
+   * Information about the currently running scenario/campaign/tutorial.
+   */
+  readonly level: { is_simulation?: boolean; is_tutorial?: boolean; campaign_name?: string; level_name: string; mod_name?: string }
+  /**
+   * A dictionary listing the names of all currently active mods and mapping them to their version.
+   */
+  readonly active_mods: Record<string, string>
+  /**
+   * A dictionary of feature flags mapping to whether they are enabled.
+   */
+  readonly feature_flags: { quality: boolean; rail_bridges: boolean; space_travel: boolean; spoiling: boolean; freezing: boolean; segmented_units: boolean; expansion_shaders: boolean }
+  /**
+   * The class name of this object. Available even when `valid` is false. For LuaStruct objects it may also be suffixed with a dotted path to a member of the struct.
+   */
+  readonly object_name: string
+  /**
+   * Register a function to be run on mod initialization.
+   *
+   * This is only called when a new save game is created or when a save file is loaded that previously didn't contain the mod. During it, the mod gets the chance to set up initial values that it will use for its lifetime. It has full access to [LuaGameScript](https://lua-api.factorio.com/1.1.110/classes/LuaGameScript.html) and the [storage](https://lua-api.factorio.com/1.1.110/auxiliary/storage.html) table and can change anything about them that it deems appropriate. No other events will be raised for the mod until it has finished this step.
+   *
+   * For more context, refer to the [Data Lifecycle](https://lua-api.factorio.com/1.1.110/auxiliary/data-lifecycle.html) page.
+   */
+  on_init(this: void, handler: (this: void) => void | undefined): void
+  /**
+   * Register a function to be run on save load. This is only called for mods that have been part of the save previously, or for players connecting to a running multiplayer session.
+   *
+   * It gives the mod the opportunity to rectify potential differences in local state introduced by the save/load cycle. Doing anything other than the following three will lead to desyncs, breaking multiplayer and replay functionality. Access to [LuaGameScript](https://lua-api.factorio.com/1.1.110/classes/LuaGameScript.html) is not available. The [storage](https://lua-api.factorio.com/1.1.110/auxiliary/storage.html) table can be accessed and is safe to read from, but not write to, as doing so will lead to an error.
+   *
+   * The only legitimate uses of this event are these:
+   *
+   * - Re-setup [metatables](https://www.lua.org/pil/13.html) as they are not persisted through the save/load cycle.
+   *
+   * - Re-setup conditional event handlers, meaning subscribing to an event only when some condition is met to save processing time.
+   *
+   * - Create local references to data stored in the [storage](https://lua-api.factorio.com/1.1.110/auxiliary/storage.html) table.
+   *
+   * For all other purposes, [LuaBootstrap::on_init](https://lua-api.factorio.com/1.1.110/classes/LuaBootstrap.html#on_init), [LuaBootstrap::on_configuration_changed](https://lua-api.factorio.com/1.1.110/classes/LuaBootstrap.html#on_configuration_changed) or [migrations](https://lua-api.factorio.com/1.1.110/auxiliary/migrations.html) should be used instead.
+   *
+   * For more context, refer to the [Data Lifecycle](https://lua-api.factorio.com/1.1.110/auxiliary/data-lifecycle.html) page.
+   */
+  on_load(this: void, handler: (this: void) => void | undefined): void
+  /**
+   * Register a function to be run when mod configuration changes.
+   *
+   * This is called when the game version or any mod version changed, when any mod was added or removed, when a startup setting has changed, when any prototypes have been added or removed, or when a migration was applied. It allows the mod to make any changes it deems appropriate to both the data structures in its [storage](https://lua-api.factorio.com/1.1.110/auxiliary/storage.html) table or to the game state through [LuaGameScript](https://lua-api.factorio.com/1.1.110/classes/LuaGameScript.html).
+   *
+   * For more context, refer to the [Data Lifecycle](https://lua-api.factorio.com/1.1.110/auxiliary/data-lifecycle.html) page.
+   */
+  on_configuration_changed(this: void, handler: (this: void, arg1: ConfigurationChangedData) => void | undefined): void
+  /**
+   * Register a handler to run on the specified event(s). Each mod can only register once for every event, as any additional registration will overwrite the previous one. This holds true even if different filters are used for subsequent registrations.
+   */
+  on_event(this: void, event: LuaEventType | LuaEventType[], handler: (this: void, arg1: EventData) => void | undefined, filters?: EventFilter): void
+  /**
+   * Register a handler to run every nth-tick(s). When the game is on tick 0 it will trigger all registered handlers.
+   */
+  on_nth_tick(this: void, tick: number | number[] | undefined, handler: (this: void, arg1: NthTickEventData) => void | undefined): void
+  /**
+   * Registers an object so that after it's destroyed, [on_object_destroyed](https://lua-api.factorio.com/1.1.110/events.html#on_object_destroyed) is called.
+   *
+   * Once an object is registered, it stays registered until it is actually destroyed, even through save/load cycles. The registration is global across all mods, meaning once one mod registers an object, all mods listening to [on_object_destroyed](https://lua-api.factorio.com/1.1.110/events.html#on_object_destroyed) will receive the event when it is destroyed. Registering the same object multiple times will still only fire the destruction event once, and will return the same registration number.
+   *
+   * Depending on when a given object is destroyed, [on_object_destroyed](https://lua-api.factorio.com/1.1.110/events.html#on_object_destroyed) will either be fired at the end of the current tick or at the end of the next tick.
+   */
+  register_on_object_destroyed(this: void, object: RegistrationTarget): LuaMultiReturn<[number, number, defines.target_type]>
+  /**
+   * Register a metatable to have linkage recorded and restored when saving/loading.
+   *
+   * The metatable itself will not be saved. Instead, only the linkage to a registered metatable is saved, and the metatable registered under that name will be used when loading the table.
+   *
+   * `register_metatable()` can not be used in the console, in event listeners or during a `remote.call()`.
+   *
+   * The metatable first needs to be defined in the mod's root scope, then registered using this method. From then on, it will be properly restored for tables in [storage](https://lua-api.factorio.com/1.1.110/auxiliary/storage.html).
+   *
+   * ```
+   * local metatable =
+   * {
+   *   __index = function(key)
+   *     return "no value for key " .. key
+   *   end
+   * }
+   * script.register_metatable("my_metatable", metatable)
+   * ```
+   *
+   * This previously defined `metatable` can then be set on any table as usual:
+   *
+   * ```
+   * local table = {key="value"}
+   * setmetatable(table, metatable)
+   * ```
+   */
+  register_metatable(this: void, name: string, metatable: LuaTable): void
+  /**
+   * Generate a new, unique event ID that can be used to raise custom events with [LuaBootstrap::raise_event](https://lua-api.factorio.com/1.1.110/classes/LuaBootstrap.html#raise_event).
+   */
+  generate_event_name(this: void): defines.events
+  /**
+   * Converts LuaEventType into related value of defines.events. Value will be provided also if event was not given a constant inside of defines.events.
+   */
+  get_event_id(this: void, event: LuaEventType): defines.events
+  /**
+   * Find the event handler for an event.
+   */
+  get_event_handler(this: void, event: LuaEventType): (this: void, arg1: EventData) => void | undefined
+  /**
+   * Gets the mod event order as a string.
+   */
+  get_event_order(this: void): string
+  /**
+   * Sets the filters for the given event. The filters are only retained when set after the actual event registration, because registering for an event with different or no filters will overwrite previously set ones.
+   *
+   * Limit the [on_marked_for_deconstruction](https://lua-api.factorio.com/1.1.110/events.html#on_marked_for_deconstruction) event to only be received when a non-ghost entity is marked for deconstruction.
+   *
+   * ```
+   * script.set_event_filter(defines.events.on_marked_for_deconstruction, {{filter = "ghost", invert = true}})
+   * ```
+   *
+   * Limit the [on_built_entity](https://lua-api.factorio.com/1.1.110/events.html#on_built_entity) event to only be received when either a `unit` or a `unit-spawner` is built.
+   *
+   * ```
+   * script.set_event_filter(defines.events.on_built_entity, {{filter = "type", type = "unit"}, {filter = "type", type = "unit-spawner"}})
+   * ```
+   *
+   * Limit the [on_entity_damaged](https://lua-api.factorio.com/1.1.110/events.html#on_entity_damaged) event to only be received when a `rail` is damaged by an `acid` attack.
+   *
+   * ```
+   * script.set_event_filter(defines.events.on_entity_damaged, {{filter = "rail"}, {filter = "damage-type", type = "acid", mode = "and"}})
+   * ```
+   */
+  set_event_filter(this: void, event: LuaEventType, filters?: EventFilter): void
+  /**
+   * Gets the filters for the given event.
+   */
+  get_event_filter(this: void, event: LuaEventType): EventFilter | undefined
+  /**
+   * Raise an event. Only events generated with [LuaBootstrap::generate_event_name](https://lua-api.factorio.com/1.1.110/classes/LuaBootstrap.html#generate_event_name) and the following can be raised:
+   */
+  raise_event(this: void, event: LuaEventType, data: LuaTable): void
+  raise_console_chat(this: void, params: LuaBootstrap.raise_console_chat_param): void
+  raise_player_crafted_item(this: void, params: LuaBootstrap.raise_player_crafted_item_param): void
+  raise_player_fast_transferred(this: void, params: LuaBootstrap.raise_player_fast_transferred_param): void
+  raise_biter_base_built(this: void, params: LuaBootstrap.raise_biter_base_built_param): void
+  raise_market_item_purchased(this: void, params: LuaBootstrap.raise_market_item_purchased_param): void
+  raise_script_built(this: void, params: LuaBootstrap.raise_script_built_param): void
+  raise_script_destroy(this: void, params: LuaBootstrap.raise_script_destroy_param): void
+  raise_script_revive(this: void, params: LuaBootstrap.raise_script_revive_param): void
+  raise_script_teleported(this: void, params: LuaBootstrap.raise_script_teleported_param): void
+  raise_script_set_tiles(this: void, params: LuaBootstrap.raise_script_set_tiles_param): void
+}
+
+/**
+ * Allows for the registration of custom console commands through the global object named `commands`. Similarly to [event subscriptions](https://lua-api.factorio.com/1.1.110/classes/LuaBootstrap.html#on_event), these don't persist through a save-and-load cycle.
+ */
+interface LuaCommandProcessor {
+  /**
+   * Lists the custom commands registered by scripts through `LuaCommandProcessor`.
+   */
+  readonly commands: Record<string, LocalisedString>
+  /**
+   * Lists the built-in commands of the core game. The [wiki](https://wiki.factorio.com/Console) has an overview of these.
+   */
+  readonly game_commands: Record<string, LocalisedString>
+  /**
+   * The class name of this object. Available even when `valid` is false. For LuaStruct objects it may also be suffixed with a dotted path to a member of the struct.
+   */
+  readonly object_name: string
+  /**
+   * Add a custom console command.
+   *
+   * Trying to add a command with the `name` of a game command or the name of a custom command that is already in use will result in an error.
+   *
+   * This example command will register a custom event called `print_tick` that prints the current tick to either the player issuing the command or to everyone on the server, depending on the command parameter:
+   *
+   * ```
+   * commands.add_command("print_tick", nil, function(command)
+   *   if command.player_index ~= nil and command.parameter == "me" then
+   *     game.get_player(command.player_index).print(command.tick)
+   *   else
+   *     game.print(command.tick)
+   *   end
+   * end)
+   * ```
+   *
+   * This shows the usage of the table that gets passed to any function handling a custom command. This specific example makes use of the `tick` and the optional `player_index` and `parameter` fields. The user is supposed to either call it without any parameter (`"/print_tick"`) or with the `"me"` parameter (`"/print_tick me"`).
+   */
+  add_command(this: void, name: string, help: LocalisedString, function_: (this: void, arg1: CustomCommandData) => void): void
+  /**
+   * Remove a custom console command.
+   */
+  remove_command(this: void, name: string): boolean
+}
+
+/**
+ * Control behavior for container entities.
+ */
+interface LuaContainerControlBehavior extends LuaControlBehavior {
+  /**
+   * `true` if this container is sending its content to a circuit network
+   */
+  read_contents: boolean
+  /**
+   * Is this object valid? This Lua object holds a reference to an object within the game engine. It is possible that the game-engine object is removed whilst a mod still holds the corresponding Lua object. If that happens, the object becomes invalid, i.e. this attribute will be `false`. Mods are advised to check for object validity if any change to the game state might have occurred between the creation of the Lua object and its access.
+   */
+  readonly valid: boolean
+  /**
+   * The class name of this object. Available even when `valid` is false. For LuaStruct objects it may also be suffixed with a dotted path to a member of the struct.
+   */
+  readonly object_name: string
+}
+
+/**
+ * The control behavior for an entity. Inserters have logistic network and circuit network behavior logic, lamps have circuit logic and so on. This is an abstract base class that concrete control behaviors inherit.
+ *
+ * An control reference becomes invalid once the control behavior is removed or the entity (see [LuaEntity](https://lua-api.factorio.com/1.1.110/classes/LuaEntity.html)) it resides in is destroyed.
+ */
+interface LuaControlBehavior {
+  /**
+   * The concrete type of this control behavior.
+   */
+  readonly type: defines.control_behavior.type
+  /**
+   * The entity this control behavior belongs to.
+   */
+  readonly entity: LuaEntity
+  get_circuit_network(this: void, wire_connector_id: defines.wire_connector_id): LuaCircuitNetwork | undefined
+}
+
+/**
+ * Prototype of a custom event.
+ */
+interface LuaCustomEventPrototype extends LuaPrototypeBase {
+  /**
+   * Event identifier associated with this custom event.
+   */
+  readonly event_id: defines.events
+  /**
+   * Is this object valid? This Lua object holds a reference to an object within the game engine. It is possible that the game-engine object is removed whilst a mod still holds the corresponding Lua object. If that happens, the object becomes invalid, i.e. this attribute will be `false`. Mods are advised to check for object validity if any change to the game state might have occurred between the creation of the Lua object and its access.
+   */
+  readonly valid: boolean
+  /**
+   * The class name of this object. Available even when `valid` is false. For LuaStruct objects it may also be suffixed with a dotted path to a member of the struct.
+   */
+  readonly object_name: string
+}
+
+/**
+ * Lazily evaluated table. For performance reasons, we sometimes return a custom table-like type instead of a native Lua table. This custom type lazily constructs the necessary Lua wrappers of the corresponding C++ objects, therefore preventing their unnecessary construction in some cases.
+ *
+ * There are some notable consequences to the usage of a custom table type rather than the native Lua table type: Iterating a custom table is only possible using the `pairs` Lua function; `ipairs` won't work. Another key difference is that custom tables cannot be serialised into a game save file -- if saving the game would require serialisation of a custom table, an error will be displayed and the game will not be saved.
+ *
+ * In previous versions of Factorio, this would create a [LuaPlayer](https://lua-api.factorio.com/1.1.110/classes/LuaPlayer.html) instance for every player in the game, even though only one such wrapper is needed. In the current version, accessing [game.players](https://lua-api.factorio.com/1.1.110/classes/LuaGameScript.html#players) by itself does not create any [LuaPlayer](https://lua-api.factorio.com/1.1.110/classes/LuaPlayer.html) instances; they are created lazily when accessed. Therefore, this example only constructs one [LuaPlayer](https://lua-api.factorio.com/1.1.110/classes/LuaPlayer.html) instance, no matter how many elements there are in `game.players`.
+ *
+ * ```
+ * game.players["Oxyd"].character.die()
+ * ```
+ *
+ * This statement will execute successfully and `storage.p` will be useable as one might expect. However, as soon as the user tries to save the game, a "LuaCustomTable cannot be serialized" error will be shown. The game will remain unsaveable so long as `storage.p` refers to an instance of a custom table.
+ *
+ * ```
+ * storage.p = game.players  -- This has high potential to make the game unsaveable
+ * ```
+ *
+ * The following will produce no output because `ipairs` is not supported with custom tables.
+ *
+ * ```
+ * for _, p in ipairs(game.players) do game.player.print(p.name); end  -- incorrect; use pairs instead
+ * ```
+ */
+interface LuaCustomTable<K extends AnyNotNil, V> extends LuaTable<K, V> {
+  /**
+   * Is this object valid? This Lua object holds a reference to an object within the game engine. It is possible that the game-engine object is removed whilst a mod still holds the corresponding Lua object. If that happens, the object becomes invalid, i.e. this attribute will be `false`. Mods are advised to check for object validity if any change to the game state might have occurred between the creation of the Lua object and its access.
+   */
+  readonly valid: boolean
+  /**
+   * The class name of this object. Available even when `valid` is false. For LuaStruct objects it may also be suffixed with a dotted path to a member of the struct.
+   */
+  readonly object_name: string
+}
+
+/**
+ * Prototype of an entity.
+ */
+interface LuaEntityPrototype extends LuaPrototypeBase {
+  /**
+   * The logistic parameters for this roboport.
+   */
+  readonly logistic_parameters?: { spawn_and_station_height: number; spawn_and_station_shadow_height_offset: number; stationing_render_layer_swap_height: number; charge_approach_distance: number; logistic_radius: number; construction_radius: number; charging_station_count: number; charging_distance: number; charging_station_shift: Vector; charging_energy: number; charging_threshold_distance: number; robot_vertical_acceleration: number; stationing_offset: Vector; robot_limit: number; logistics_connection_distance: number; robots_shrink_when_entering_and_exiting: boolean }
+}
+
+/**
+ * Prototype of a modular equipment.
+ */
+interface LuaEquipmentPrototype extends LuaPrototypeBase {
+  /**
+   * The logistic parameters for this roboport equipment.
+   */
+  readonly logistic_parameters?: { spawn_and_station_height: number; spawn_and_station_shadow_height_offset: number; stationing_render_layer_swap_height: number; charge_approach_distance: number; logistic_radius: number; construction_radius: number; charging_station_count: number; charging_distance: number; charging_station_shift: Vector; charging_energy: number; charging_threshold_distance: number; robot_vertical_acceleration: number; stationing_offset: Vector; robot_limit: number; logistics_connection_distance: number; robots_shrink_when_entering_and_exiting: boolean }
+}
+
+/**
+ * A lazily loaded value. For performance reasons, we sometimes return a custom lazily-loaded value type instead of the native Lua value. This custom type lazily constructs the necessary value when [LuaLazyLoadedValue::get](https://lua-api.factorio.com/1.1.110/classes/LuaLazyLoadedValue.html#get) is called, therefore preventing its unnecessary construction in some cases.
+ *
+ * An instance of LuaLazyLoadedValue is only valid during the event it was created from and cannot be saved.
+ */
+interface LuaLazyLoadedValue {
+  /**
+   * Is this object valid? This Lua object holds a reference to an object within the game engine. It is possible that the game-engine object is removed whilst a mod still holds the corresponding Lua object. If that happens, the object becomes invalid, i.e. this attribute will be `false`. Mods are advised to check for object validity if any change to the game state might have occurred between the creation of the Lua object and its access.
+   */
+  readonly valid: boolean
+  /**
+   * The class name of this object. Available even when `valid` is false. For LuaStruct objects it may also be suffixed with a dotted path to a member of the struct.
+   */
+  readonly object_name: string
+  /**
+   * Gets the value of this lazy loaded value.
+   */
+  get(this: void): Any
+}
+
+/**
+ * Base for all prototype classes.
+ */
+interface LuaPrototypeBase {
+  /**
+   * Type of this prototype.
+   */
+  readonly type: string
+  /**
+   * Name of this prototype.
+   */
+  readonly name: string
+  /**
+   * The string used to alphabetically sort these prototypes. It is a simple string that has no additional semantic meaning.
+   */
+  readonly order: string
+  readonly localised_name: LocalisedString
+  readonly localised_description: LocalisedString
+  /**
+   * Provides additional description used in factoriopedia.
+   */
+  readonly factoriopedia_description: LocalisedString
+  /**
+   * Group of this prototype.
+   */
+  readonly group: LuaGroup
+  /**
+   * Subgroup of this prototype.
+   */
+  readonly subgroup: LuaGroup
+  readonly hidden: boolean
+  readonly hidden_in_factoriopedia: boolean
+  readonly parameter: boolean
+}
+
+/**
+ * An interface to send messages to the calling RCON interface through the global object named `rcon`.
+ */
+interface LuaRCON {
+  /**
+   * The class name of this object. Available even when `valid` is false. For LuaStruct objects it may also be suffixed with a dotted path to a member of the struct.
+   */
+  readonly object_name: string
+  /**
+   * Print text to the calling RCON interface if any.
+   */
+  print(this: void, message: LocalisedString): void
+}
+
+/**
+ * Registry of interfaces between scripts. An interface is simply a dictionary mapping names to functions. A script or mod can then register an interface with [LuaRemote](https://lua-api.factorio.com/1.1.110/classes/LuaRemote.html), after that any script can call the registered functions, provided it knows the interface name and the desired function name. An instance of LuaRemote is available through the global object named `remote`.
+ */
+interface LuaRemote {
+  /**
+   * The class name of this object. Available even when `valid` is false. For LuaStruct objects it may also be suffixed with a dotted path to a member of the struct.
+   */
+  readonly object_name: string
+  /**
+   * List of all registered interfaces. For each interface name, `remote.interfaces[name]` is a dictionary mapping the interface's registered functions to `true`.
+   */
+  readonly interfaces: Record<string, Record<string, true>>
+  /**
+   * Add a remote interface.
+   */
+  add_interface(this: void, name: string, functions: Record<string, (this: void) => void>): void
+  /**
+   * Removes an interface with the given name.
+   */
+  remove_interface(this: void, name: string): boolean
+  /**
+   * Call a function of an interface.
+   *
+   * Providing an unknown interface or function name will result in a script error.
+   */
+  call(this: void, interface_: string, function_: string, ...args: Any[]): Any | undefined
+}
+
+/**
+ * Object containing mod settings of three distinct types: `startup`, `global`, and `player`. An instance of LuaSettings is available through the global object named `settings`.
+ */
+interface LuaSettings {
+  /**
+   * The startup mod settings, indexed by prototype name.
+   */
+  readonly startup: LuaCustomTable<string, ModSetting>
+  /**
+   * The current global mod settings, indexed by prototype name.
+   *
+   * Even though this attribute is marked as read-only, individual settings can be changed by overwriting their [ModSetting](https://lua-api.factorio.com/1.1.110/concepts/ModSetting.html) table. Mods can only change their own settings. Using the in-game console, all player settings can be changed.
+   */
+  readonly global: LuaCustomTable<string, ModSetting>
+  /**
+   * The **default** player mod settings for this map, indexed by prototype name. Changing these settings only affects the default settings for future players joining the game.
+   *
+   * Individual settings can be changed by overwriting their [ModSetting](https://lua-api.factorio.com/1.1.110/concepts/ModSetting.html) table. Mods can only change their own settings. Using the in-game console, all player settings can be changed.
+   */
+  readonly player_default: LuaCustomTable<string, ModSetting>
+  /**
+   * The class name of this object. Available even when `valid` is false. For LuaStruct objects it may also be suffixed with a dotted path to a member of the struct.
+   */
+  readonly object_name: string
+  /**
+   * Gets the current per-player settings for the given player, indexed by prototype name. Returns the same structure as [LuaPlayer::mod_settings](https://lua-api.factorio.com/1.1.110/classes/LuaPlayer.html#mod_settings). This table becomes invalid if its associated player does.
+   *
+   * Even though this attribute is a getter, individual settings can be changed by overwriting their [ModSetting](https://lua-api.factorio.com/1.1.110/concepts/ModSetting.html) table. Mods can only change their own settings. Using the in-game console, all player settings can be changed.
+   */
+  get_player_settings(this: void, player: PlayerIdentification): LuaCustomTable<string, ModSetting>
+}
+
+interface EventData {
+  /**
+   * Identifier of the event
+   */
+  name: defines.events
+  /**
+   * Tick the event was generated.
+   */
+  tick: number
+  /**
+   * The name of the mod that raised the event if it was raised using [LuaBootstrap::raise_event](https://lua-api.factorio.com/1.1.110/classes/LuaBootstrap.html#raise_event).
+   */
+  mod_name?: string
+}
+
+declare namespace EventData {
+  /**
+   * Called when a [CustomInputPrototype](https://lua-api.factorio.com/1.1.110/prototypes/CustomInputPrototype.html) is activated.
+   */
+  interface CustomInputEvent extends EventData {
+    /**
+     * The player that activated the custom input.
+     */
+    player_index: number
+    /**
+     * The prototype name of the custom input that was activated.
+     */
+    input_name: string
+    /**
+     * The mouse cursor position when the custom input was activated.
+     */
+    cursor_position: MapPosition
+    /**
+     * Cursor direction.
+     */
+    cursor_direction?: defines.direction
+    /**
+     * The mouse cursor display location when the custom input was activated.
+     */
+    cursor_display_location: GuiLocation
+    /**
+     * Information about the prototype that is selected when the custom input is used. Needs to be enabled on the custom input's prototype. `nil` if none is selected.
+     */
+    selected_prototype?: SelectedPrototypeData
+    /**
+     * Identifier of the event
+     */
+    name: defines.events
+    /**
+     * Tick the event was generated.
+     */
+    tick: number
+  }
+  /**
+   * Called when player builds something.
+   */
+  interface on_built_entity extends EventData {
+    entity: LuaEntity
+    player_index: number
+    consumed_items: LuaInventory
+    /**
+     * The tags associated with this entity if any.
+     */
+    tags?: Tags
+    /**
+     * Identifier of the event
+     */
+    name: defines.events
+    /**
+     * Tick the event was generated.
+     */
+    tick: number
+  }
+  /**
+   * Called after the player was created.
+   */
+  interface on_player_created extends EventData {
+    player_index: number
+    /**
+     * Identifier of the event
+     */
+    name: defines.events
+    /**
+     * Tick the event was generated.
+     */
+    tick: number
+  }
+  /**
+   * Called when a research finishes.
+   */
+  interface on_research_finished extends EventData {
+    /**
+     * The researched technology
+     */
+    research: LuaTechnology
+    /**
+     * If the technology was researched by script.
+     */
+    by_script: boolean
+    /**
+     * Identifier of the event
+     */
+    name: defines.events
+    /**
+     * Tick the event was generated.
+     */
+    tick: number
+  }
+  /**
+   * It is fired once every tick. Since this event is fired every tick, its handler shouldn't include performance heavy code.
+   */
+  interface on_tick extends EventData {
+    /**
+     * Identifier of the event
+     */
+    name: defines.events
+    /**
+     * Tick the event was generated.
+     */
+    tick: number
+  }
+}
+
+/**
+ * The main scripting interface through which most of the API is accessed.
+ */
+declare const game: LuaGameScript
+/**
+ * Provides an interface for registering game event handlers.
+ */
+declare const script: LuaBootstrap
+/**
+ * Allows registration of custom commands for the in-game console.
+ */
+declare const commands: LuaCommandProcessor
+/**
+ * Allows printing messages to the calling RCON instance, if any.
+ */
+declare const rcon: LuaRCON
+/**
+ * Allows registration and use of functions to communicate between mods.
+ */
+declare const remote: LuaRemote
+/**
+ * Allows rendering of geometric shapes, text and sprites in the game world.
+ */
+declare const rendering: LuaRendering
+/**
+ * Provides access to the current mod settings.
+ */
+declare const settings: LuaSettings
+declare const global: Record<string, any>
@@ -0,0 +1,42 @@
+-- Auto-generated Factorio Prototype API definitions
+-- Generated from: fixtures/1.1/prototype-api.json
+
+-- Executable stubs for unit tests outside the game (busted or plain Lua), e.g. in a
+-- busted helper: dofile("path/to/stubs/runtime.lua"). Classes are tables of functions
+-- that do nothing and return nil, which tests replace with spies or their own
+-- implementations; global objects look their functions up in their class.
+-- Attributes aren't stubbed. For control-stage code, harness.lua fakes game, script
+-- and storage and raises events.
+
+defines = {
+  direction = {
+    north = 0,
+    northnortheast = 1,
+    northeast = 2,
+    eastnortheast = 3,
+    east = 4,
+    eastsoutheast = 5,
+    southeast = 6,
+    southsoutheast = 7,
+    south = 8,
+    southsouthwest = 9,
+    southwest = 10,
+    westsouthwest = 11,
+    west = 12,
+    westnorthwest = 13,
+    northwest = 14,
+    northnorthwest = 15,
+  },
+}
+
+data = {raw = {}, is_demo = false}
+
+function data:extend(prototypes)
+  for _, prototype in ipairs(prototypes) do
+    self.raw[prototype.type] = self.raw[prototype.type] or {}
+    self.raw[prototype.type][prototype.name] = prototype
+  end
+  return self
+end
+
+mods = {}
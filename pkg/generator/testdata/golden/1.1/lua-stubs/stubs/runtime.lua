@@ -0,0 +1,121 @@
+-- Auto-generated Factorio Runtime API definitions
+-- Generated from: fixtures/1.1/runtime-api.json
+
+-- Executable stubs for unit tests outside the game (busted or plain Lua), e.g. in a
+-- busted helper: dofile("path/to/stubs/runtime.lua"). Classes are tables of functions
+-- that do nothing and return nil, which tests replace with spies or their own
+-- implementations; global objects look their functions up in their class.
+-- Attributes aren't stubbed. For control-stage code, harness.lua fakes game, script
+-- and storage and raises events.
+
+defines = {
+  direction = {
+    north = 0,
+    northnortheast = 1,
+    northeast = 2,
+    eastnortheast = 3,
+    east = 4,
+    eastsoutheast = 5,
+    southeast = 6,
+    southsoutheast = 7,
+    south = 8,
+    southsouthwest = 9,
+    southwest = 10,
+    westsouthwest = 11,
+    west = 12,
+    westnorthwest = 13,
+    northwest = 14,
+    northnorthwest = 15,
+  },
+  events = {
+    on_built_entity = 6,
+    on_player_created = 86,
+    on_research_finished = 150,
+    on_tick = 187,
+  },
+}
+
+LuaBootstrap = {}
+function LuaBootstrap.on_init(handler) end
+function LuaBootstrap.on_load(handler) end
+function LuaBootstrap.on_configuration_changed(handler) end
+function LuaBootstrap.on_event(event, handler, filters) end
+function LuaBootstrap.on_nth_tick(tick, handler) end
+function LuaBootstrap.register_on_object_destroyed(object) end
+function LuaBootstrap.register_metatable(name, metatable) end
+function LuaBootstrap.generate_event_name() end
+function LuaBootstrap.get_event_id(event) end
+function LuaBootstrap.get_event_handler(event) end
+function LuaBootstrap.get_event_order() end
+function LuaBootstrap.set_event_filter(event, filters) end
+function LuaBootstrap.get_event_filter(event) end
+function LuaBootstrap.raise_event(event, data) end
+function LuaBootstrap.raise_console_chat(param) end
+function LuaBootstrap.raise_player_crafted_item(param) end
+function LuaBootstrap.raise_player_fast_transferred(param) end
+function LuaBootstrap.raise_biter_base_built(param) end
+function LuaBootstrap.raise_market_item_purchased(param) end
+function LuaBootstrap.raise_script_built(param) end
+function LuaBootstrap.raise_script_destroy(param) end
+function LuaBootstrap.raise_script_revive(param) end
+function LuaBootstrap.raise_script_teleported(param) end
+function LuaBootstrap.raise_script_set_tiles(param) end
+
+LuaCommandProcessor = {}
+function LuaCommandProcessor.add_command(name, help, function_) end
+function LuaCommandProcessor.remove_command(name) end
+
+LuaContainerControlBehavior = {}
+
+LuaControlBehavior = {}
+function LuaControlBehavior.get_circuit_network(wire_connector_id) end
+
+LuaCustomEventPrototype = {}
+
+LuaCustomTable = {}
+
+LuaEntityPrototype = {}
+
+LuaEquipmentPrototype = {}
+
+LuaLazyLoadedValue = {}
+function LuaLazyLoadedValue.get() end
+
+LuaPrototypeBase = {}
+
+LuaRCON = {}
+function LuaRCON.print(message) end
+
+LuaRemote = {}
+function LuaRemote.add_interface(name, functions) end
+function LuaRemote.remove_interface(name) end
+function LuaRemote.call(interface, function_, ...) end
+
+LuaSettings = {}
+function LuaSettings.get_player_settings(player) end
+
+setmetatable(LuaContainerControlBehavior, {__index = LuaControlBehavior})
+setmetatable(LuaCustomEventPrototype, {__index = LuaPrototypeBase})
+setmetatable(LuaEntityPrototype, {__index = LuaPrototypeBase})
+setmetatable(LuaEquipmentPrototype, {__index = LuaPrototypeBase})
+
+game = setmetatable({}, {__index = LuaGameScript})
+script = setmetatable({}, {__index = LuaBootstrap})
+commands = setmetatable({}, {__index = LuaCommandProcessor})
+rcon = setmetatable({}, {__index = LuaRCON})
+remote = setmetatable({}, {__index = LuaRemote})
+rendering = setmetatable({}, {__index = LuaRendering})
+settings = setmetatable({}, {__index = LuaSettings})
+global = {}
+
+function log(message) end
+
+function localised_print(message) end
+
+function table_size(t)
+  local size = 0
+  for _ in pairs(t) do
+    size = size + 1
+  end
+  return size
+end
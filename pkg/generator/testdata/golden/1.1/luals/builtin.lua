@@ -0,0 +1,66 @@
+---@meta
+
+-- Auto-generated Factorio builtin type aliases
+
+---A floating-point number. This is a single-precision floating point number. Whilst Lua only uses double-precision numbers, when a function takes a float, the game engine will immediately convert the double-precision number to single-precision.
+---@alias float number
+
+---A double-precision floating-point number. This is the same data type as all Lua numbers use.
+---@alias double number
+
+---32-bit signed integer. Possible values are `-2 147 483 648` to `2 147 483 647`.
+---
+---Since Lua 5.2 only uses doubles, any API that asks for `int` will floor the given double.
+---@alias int integer
+
+---8-bit signed integer. Possible values are `-128` to `127`.
+---
+---Since Lua 5.2 only uses doubles, any API that asks for `int8` will floor the given double.
+---@alias int8 integer
+
+---32-bit unsigned integer. Possible values are `0` to `4 294 967 295`.
+---
+---Since Lua 5.2 only uses doubles, any API that asks for `uint` will floor the given double.
+---@alias uint integer
+
+---8-bit unsigned integer. Possible values are `0` to `255`.
+---
+---Since Lua 5.2 only uses doubles, any API that asks for `uint8` will floor the given double.
+---@alias uint8 integer
+
+---16-bit unsigned integer. Possible values are `0` to `65 535`.
+---
+---Since Lua 5.2 only uses doubles, any API that asks for `uint16` will floor the given double.
+---@alias uint16 integer
+
+---64-bit unsigned integer. Possible values are `0` to `18 446 744 073 709 551 615`.
+---
+---Since Lua 5.2 only uses doubles, any API that asks for `uint64` will floor the given double.
+---@alias uint64 integer
+
+---Any LuaObject listed on the [Classes](https://lua-api.factorio.com/1.1.110/classes.html) page.
+---@alias LuaObject any
+
+---The data.extend method. It's the primary way to add prototypes to the data table.
+---
+---The method has two positional function parameters:
+---
+---- `self` :: [Data](https://lua-api.factorio.com/1.1.110/types/Data.html)?: Usually provided by calling `data:extend(otherdata)`, which is syntax sugar for `data.extend(data, otherdata)`.
+---
+---- `otherdata` :: array[[AnyPrototype](https://lua-api.factorio.com/1.1.110/prototypes/AnyPrototype.html)]: A continuous array of non-abstract prototypes.
+---
+---The data.extend method can also be called with only the `otherdata` argument by calling it directly on data: `data.extend(otherdata)`.
+---@alias DataExtendMethod function
+
+---16 bit signed integer. Ranges from `-32 768` to `32 767`, or `[-2^15, 2^15-1]`.
+---@alias int16 integer
+
+---32 bit signed integer. Ranges from `-2 147 483 648` to `2 147 483 647`, or `[-2^31, 2^31-1]`.
+---@alias int32 integer
+
+---64 bit signed integer.
+---@alias int64 integer
+
+---32 bit unsigned integer. Ranges from `0` to `4 294 967 295`, or `[0, 2^32-1]`.
+---@alias uint32 integer
+
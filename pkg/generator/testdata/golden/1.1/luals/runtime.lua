@@ -0,0 +1,829 @@
+---@meta
+
+-- Auto-generated Factorio Runtime API definitions
+-- Generated from: fixtures/1.1/runtime-api.json
+
+-- Defines
+
+---Constants used throughout the API, such as `defines.direction.north`.
+---@class defines
+defines = {}
+
+---@class defines.direction
+---@field north defines.direction
+---@field northnortheast defines.direction
+---@field northeast defines.direction
+---@field eastnortheast defines.direction
+---@field east defines.direction
+---@field eastsoutheast defines.direction
+---@field southeast defines.direction
+---@field southsoutheast defines.direction
+---@field south defines.direction
+---@field southsouthwest defines.direction
+---@field southwest defines.direction
+---@field westsouthwest defines.direction
+---@field west defines.direction
+---@field westnorthwest defines.direction
+---@field northwest defines.direction
+---@field northnorthwest defines.direction
+defines.direction = {}
+
+---See the [events page](https://lua-api.factorio.com/1.1.110/events.html) for more info on what events contain and when they get raised.
+---@class defines.events
+---@field on_built_entity defines.events.on_built_entity
+---@field on_player_created defines.events.on_player_created
+---@field on_research_finished defines.events.on_research_finished
+---@field on_tick defines.events.on_tick
+defines.events = {}
+---@class defines.events.on_built_entity : defines.events
+---@class defines.events.on_player_created : defines.events
+---@class defines.events.on_research_finished : defines.events
+---@class defines.events.on_tick : defines.events
+
+-- Concepts (Runtime)
+
+---@class BoundingBox.struct
+---@field left_top MapPosition
+---@field right_bottom MapPosition
+---@field orientation? RealOrientation
+
+---Two positions, specifying the top-left and bottom-right corner of the box respectively. Like with [MapPosition](https://lua-api.factorio.com/1.1.110/concepts/MapPosition.html), the names of the members may be omitted. When read from the game, the third member `orientation` is present if it is non-zero.
+---
+---```lua
+----- Explicit definition
+---{left_top = {x = -2, y = -3}, right_bottom = {x = 5, y = 8}}
+---```
+---
+---```lua
+----- Shorthand
+---{{-2, -3}, {5, 8}}
+---```
+---@see MapPosition
+---@alias BoundingBox BoundingBox.struct | [MapPosition, MapPosition]
+
+---Localised strings are a way to support translation of in-game text. It is an array where the first element is the key and the remaining elements are parameters that will be substituted for placeholders in the template designated by the key.
+---
+---The key identifies the string template. For example, `"gui-alert-tooltip.attack"` (for the template `"__1__ objects are being damaged"`; see the file `data/core/locale/en.cfg`).
+---
+---The template can contain placeholders such as `__1__` or `__2__`. These will be replaced by the respective parameter in the LocalisedString. The parameters themselves can be other localised strings, which will be processed recursively in the same fashion. Localised strings can not be recursed deeper than 20 levels and can not have more than 20 parameters.
+---
+---There are two special flags for the localised string, indicated by the key being a particular string. First, if the key is the empty string (`""`), then all parameters will be concatenated (after processing, if any are localised strings themselves). Second, if the key is a question mark (`"?"`), then the first valid parameter will be used. A parameter can be invalid if its name doesn't match any string template. If no parameters are valid, the last one is returned. This is useful to implement a fallback for missing locale templates.
+---
+---Furthermore, when an API function expects a localised string, it will also accept a regular string (i.e. not a table) which will not be translated, as well as a number, boolean or `nil`, which will be converted to their textual representation.
+---
+---```lua
+----- In the English translation, this will print "No ammo"; in the Czech translation, it will print "Bez munice":
+---game.player.print({"description.no-ammo"})
+----- The 'description.no-ammo' template contains no placeholders, so no further parameters are necessary.
+---```
+---
+---```lua
+----- In the English translation, this will print "Durability: 5/9"; in the Japanese one, it will print "耐久度: 5/9":
+---game.player.print({"description.durability", 5, 9})
+---```
+---
+---```lua
+----- This will print "hello" in all translations:
+---game.player.print({"", "hello"})
+---```
+---
+---```lua
+----- This will print "Iron plate: 60" in the English translation and "Eisenplatte: 60" in the German translation.
+---game.print({"", {"item-name.iron-plate"}, ": ", 60})
+---```
+---
+---```lua
+----- As an example of a localised string with fallback, consider this:
+---{"?", {"", {"entity-description.furnace"}, "\n"}, {"item-description.furnace"}, "optional fallback"}
+----- If 'entity-description.furnace' exists, it is concatenated with "\n" and returned. Otherwise, if 'item-description.furnace'
+-----  exists, it is returned as-is. Otherwise, "optional fallback" is returned. If this value wasn't specified, the
+-----  translation result would be "Unknown key: 'item-description.furnace'".
+---```
+---@alias LocalisedString string | number | boolean | LuaObject | LocalisedString[] | nil
+
+---@class MapPosition.struct
+---@field x double
+---@field y double
+
+---Coordinates on a surface, for example of an entity. MapPositions may be specified either as a dictionary with `x`, `y` as keys, or simply as an array with two elements.
+---
+---The coordinates are saved as a fixed-size 32 bit integer, with 8 bits reserved for decimal precision, meaning the smallest value step is `1/2^8 = 0.00390625` tiles.
+---
+---```lua
+----- Explicit definition
+---{x = 5.5, y = 2}
+---{y = 2.25, x = 5.125}
+---```
+---
+---```lua
+----- Shorthand
+---{1.625, 2.375}
+---```
+---@alias MapPosition MapPosition.struct | [double, double]
+
+---A dictionary of string to the four basic Lua types: `string`, `boolean`, `number`, `table`.
+---
+---Note that the API returns tags as a simple table, meaning any modifications to it will not propagate back to the game. Thus, to modify a set of tags, the whole table needs to be written back to the respective property.
+---
+---```lua
+---{a = 1, b = true, c = "three", d = {e = "f"}}
+---```
+---@alias Tags table<string, AnyBasic>
+
+---@class Vector.struct
+---@field x float
+---@field y float
+
+---A vector is a two-element array or dictionary containing the `x` and `y` components. The game will always provide the array format. Positive x goes east, positive y goes south.
+---
+---```lua
+---right = {1.0, 0.0}
+---```
+---@alias Vector Vector.struct | [float, float]
+
+---@class Color.struct
+---@field r? float
+---@field g? float
+---@field b? float
+---@field a? float
+
+---Red, green, blue and alpha values, all in range [0, 1] or all in range [0, 255] if any value is > 1. All values here are optional. Color channels default to `0`, the alpha channel defaults to `1`.
+---
+---Similar to [MapPosition](https://lua-api.factorio.com/1.1.110/concepts/MapPosition.html), Color allows the short-hand notation of passing an array of exactly 3 or 4 numbers. The game usually expects colors to be in pre-multiplied form (color channels are pre-multiplied by alpha).
+---
+---```lua
+---red1 = {r = 0.5, g = 0, b = 0, a = 0.5}  -- Half-opacity red
+---red2 = {r = 0.5, a = 0.5}                -- Same color as red1
+---black = {}                               -- All channels omitted: black
+---red1_short = {0.5, 0, 0, 0.5}            -- Same color as red1 in short-hand notation
+---```
+---@see MapPosition
+---@alias Color Color.struct | [float, float, float, float]
+
+---@class ModSetting
+---@field value int | double | boolean | string | Color The value of the mod setting. The type depends on the kind of setting.
+
+---Any basic type (string, number, boolean) or table.
+---@alias AnyBasic string | boolean | number | table
+
+---@class NthTickEventData
+---@field tick uint The tick during which the event happened.
+---@field nth_tick uint The nth tick this handler was registered to.
+
+---@class ConfigurationChangedData
+---@field old_version? string Old version of the map. Present only when loading map version other than the current version.
+---@field new_version? string New version of the map. Present only when loading map version other than the current version.
+---@field mod_changes table<string, ModChangeData> Dictionary of mod changes. It is indexed by mod name.
+---@field mod_startup_settings_changed boolean `true` when mod startup settings have changed since the last time this save was loaded.
+---@field migration_applied boolean `true` when mod prototype migrations have been applied since the last time this save was loaded.
+
+---@class CustomCommandData
+---@field name string The name of the command.
+---@field tick uint The tick the command was used in.
+---@field player_index? uint The player who issued the command, or `nil` if it was issued from the server console.
+---@field parameter? string The parameter passed after the command, if there is one.
+
+---@class LuaPlayerBuiltEntityEventFilter_base
+---@field filter "ghost" | "rail" | "rail-signal" | "rolling-stock" | "robot-with-logistics-interface" | "vehicle" | "turret" | "crafting-machine" | "wall-connectable" | "transport-belt-connectable" | "circuit-network-connectable" | "type" | "name" | "ghost_type" | "ghost_name" | "force" The condition to filter on.
+---@field mode? "or" | "and" How to combine this with the previous filter. Defaults to `"or"`. When evaluating the filters, `"and"` has higher precedence than `"or"`.
+---@field invert? boolean Inverts the condition. Default is `false`.
+
+---@class LuaPlayerBuiltEntityEventFilter.type : LuaPlayerBuiltEntityEventFilter_base
+---@field filter "type"
+---@field type string The prototype type.
+
+---@class LuaPlayerBuiltEntityEventFilter.name : LuaPlayerBuiltEntityEventFilter_base
+---@field filter "name"
+---@field name string The prototype name.
+
+---@class LuaPlayerBuiltEntityEventFilter.ghost_type : LuaPlayerBuiltEntityEventFilter_base
+---@field filter "ghost_type"
+---@field type string The ghost prototype type.
+
+---@class LuaPlayerBuiltEntityEventFilter.ghost_name : LuaPlayerBuiltEntityEventFilter_base
+---@field filter "ghost_name"
+---@field name string The ghost prototype name.
+
+---@class LuaPlayerBuiltEntityEventFilter.force : LuaPlayerBuiltEntityEventFilter_base
+---@field filter "force"
+---@field force string The entity force
+
+---@alias LuaPlayerBuiltEntityEventFilter LuaPlayerBuiltEntityEventFilter.type | LuaPlayerBuiltEntityEventFilter.name | LuaPlayerBuiltEntityEventFilter.ghost_type | LuaPlayerBuiltEntityEventFilter.ghost_name | LuaPlayerBuiltEntityEventFilter.force
+
+-- Classes
+
+---Entry point for registering event handlers. It is accessible through the global object named `script`.
+---@class LuaBootstrap
+---@field mod_name string The name of the mod from the environment this is used in. (Read-only)
+---@field level LuaBootstrap.level_table Information about the currently running scenario/campaign/tutorial. (Read-only)
+---@field active_mods table<string, string> A dictionary listing the names of all currently active mods and mapping them to their version. (Read-only)
+---@field feature_flags LuaBootstrap.feature_flags_table A dictionary of feature flags mapping to whether they are enabled. (Read-only)
+---@field object_name string The class name of this object. Available even when `valid` is false. For LuaStruct objects it may also be suffixed with a dotted path to a member of the struct. (Read-only)
+LuaBootstrap = {}
+
+---@class LuaBootstrap.level_table
+---@field is_simulation? boolean Is this level a simulation? (The main menu and 'Tips and tricks' use simulations)
+---@field is_tutorial? boolean Is this level a tutorial?
+---@field campaign_name? string The campaign name if any.
+---@field level_name string The level name.
+---@field mod_name? string The mod name if any.
+
+---@class LuaBootstrap.feature_flags_table
+---@field quality boolean
+---@field rail_bridges boolean
+---@field space_travel boolean
+---@field spoiling boolean
+---@field freezing boolean
+---@field segmented_units boolean
+---@field expansion_shaders boolean
+
+---Register a function to be run on mod initialization.
+---
+---This is only called when a new save game is created or when a save file is loaded that previously didn't contain the mod. During it, the mod gets the chance to set up initial values that it will use for its lifetime. It has full access to [LuaGameScript](https://lua-api.factorio.com/1.1.110/classes/LuaGameScript.html) and the [storage](https://lua-api.factorio.com/1.1.110/auxiliary/storage.html) table and can change anything about them that it deems appropriate. No other events will be raised for the mod until it has finished this step.
+---
+---For more context, refer to the [Data Lifecycle](https://lua-api.factorio.com/1.1.110/auxiliary/data-lifecycle.html) page.
+---
+---```lua
+----- Initialize a `players` table in `storage` for later use
+---script.on_init(function()
+---  storage.players = {}
+---end)
+---```
+---@param handler fun() The handler for this event. Passing `nil` will unregister it.
+---@overload fun(handler: nil)
+function LuaBootstrap.on_init(handler) end
+
+---Register a function to be run on save load. This is only called for mods that have been part of the save previously, or for players connecting to a running multiplayer session.
+---
+---It gives the mod the opportunity to rectify potential differences in local state introduced by the save/load cycle. Doing anything other than the following three will lead to desyncs, breaking multiplayer and replay functionality. Access to [LuaGameScript](https://lua-api.factorio.com/1.1.110/classes/LuaGameScript.html) is not available. The [storage](https://lua-api.factorio.com/1.1.110/auxiliary/storage.html) table can be accessed and is safe to read from, but not write to, as doing so will lead to an error.
+---
+---The only legitimate uses of this event are these:
+---
+---- Re-setup [metatables](https://www.lua.org/pil/13.html) as they are not persisted through the save/load cycle.
+---
+---- Re-setup conditional event handlers, meaning subscribing to an event only when some condition is met to save processing time.
+---
+---- Create local references to data stored in the [storage](https://lua-api.factorio.com/1.1.110/auxiliary/storage.html) table.
+---
+---For all other purposes, [LuaBootstrap::on_init](https://lua-api.factorio.com/1.1.110/classes/LuaBootstrap.html#on_init), [LuaBootstrap::on_configuration_changed](https://lua-api.factorio.com/1.1.110/classes/LuaBootstrap.html#on_configuration_changed) or [migrations](https://lua-api.factorio.com/1.1.110/auxiliary/migrations.html) should be used instead.
+---
+---For more context, refer to the [Data Lifecycle](https://lua-api.factorio.com/1.1.110/auxiliary/data-lifecycle.html) page.
+---@param handler fun() The handler for this event. Passing `nil` will unregister it.
+---@overload fun(handler: nil)
+---@see LuaBootstrap.on_init
+---@see LuaBootstrap.on_configuration_changed
+function LuaBootstrap.on_load(handler) end
+
+---Register a function to be run when mod configuration changes.
+---
+---This is called when the game version or any mod version changed, when any mod was added or removed, when a startup setting has changed, when any prototypes have been added or removed, or when a migration was applied. It allows the mod to make any changes it deems appropriate to both the data structures in its [storage](https://lua-api.factorio.com/1.1.110/auxiliary/storage.html) table or to the game state through [LuaGameScript](https://lua-api.factorio.com/1.1.110/classes/LuaGameScript.html).
+---
+---For more context, refer to the [Data Lifecycle](https://lua-api.factorio.com/1.1.110/auxiliary/data-lifecycle.html) page.
+---@param handler fun(data: ConfigurationChangedData) The handler for this event. Passing `nil` will unregister it.
+---@overload fun(handler: nil)
+function LuaBootstrap.on_configuration_changed(handler) end
+
+---Register a handler to run on the specified event(s). Each mod can only register once for every event, as any additional registration will overwrite the previous one. This holds true even if different filters are used for subsequent registrations.
+---
+---```lua
+----- Register for the on_tick event to print the current tick to console each tick
+---script.on_event(defines.events.on_tick,
+---function(event) game.print(event.tick) end)
+---```
+---
+---```lua
+----- Register for the on_built_entity event, limiting it to only be received when a `"fast-inserter"` is built
+---script.on_event(defines.events.on_built_entity,
+---function(event) game.print("Gotta go fast!") end,
+---{{filter = "name", name = "fast-inserter"}})
+---```
+---@param event LuaEventType | LuaEventType[] The event(s) or custom-input to invoke the handler on.
+---@param handler fun(arg1: EventData) | nil The handler for this event. Passing `nil` will unregister it.
+---@param filters? EventFilter The filters for this event. Can only be used when registering for individual events.
+---@overload fun(event: defines.events.on_built_entity, handler: fun(event: EventData.on_built_entity) | nil, filters?: LuaPlayerBuiltEntityEventFilter[])
+---@overload fun(event: defines.events.on_player_created, handler: fun(event: EventData.on_player_created) | nil)
+---@overload fun(event: defines.events.on_research_finished, handler: fun(event: EventData.on_research_finished) | nil)
+---@overload fun(event: defines.events.on_tick, handler: fun(event: EventData.on_tick) | nil)
+---@overload fun(event: string | LuaCustomInputPrototype, handler: fun(event: EventData.CustomInputEvent) | nil)
+function LuaBootstrap.on_event(event, handler, filters) end
+
+---Register a handler to run every nth-tick(s). When the game is on tick 0 it will trigger all registered handlers.
+---@param tick uint | uint[] The nth-tick(s) to invoke the handler on. Passing `nil` as the only parameter will unregister all nth-tick handlers.
+---@param handler fun(event: NthTickEventData) The handler to run. Passing `nil` will unregister it for the provided nth-tick(s).
+---@overload fun(tick: uint | uint[], handler: nil)
+---@overload fun(tick: nil)
+function LuaBootstrap.on_nth_tick(tick, handler) end
+
+---Registers an object so that after it's destroyed, [on_object_destroyed](https://lua-api.factorio.com/1.1.110/events.html#on_object_destroyed) is called.
+---
+---Once an object is registered, it stays registered until it is actually destroyed, even through save/load cycles. The registration is global across all mods, meaning once one mod registers an object, all mods listening to [on_object_destroyed](https://lua-api.factorio.com/1.1.110/events.html#on_object_destroyed) will receive the event when it is destroyed. Registering the same object multiple times will still only fire the destruction event once, and will return the same registration number.
+---
+---Depending on when a given object is destroyed, [on_object_destroyed](https://lua-api.factorio.com/1.1.110/events.html#on_object_destroyed) will either be fired at the end of the current tick or at the end of the next tick.
+---@param object RegistrationTarget The object to register.
+---@return uint64 The registration number. It is used to identify the object in the [on_object_destroyed](https://lua-api.factorio.com/1.1.110/events.html#on_object_destroyed) event.
+---@return uint64 The [useful identifier](https://lua-api.factorio.com/1.1.110/concepts/RegistrationTarget.html) of the object if it has one. This identifier is specific to the object type, for example for trains it is the value [LuaTrain::id](https://lua-api.factorio.com/1.1.110/classes/LuaTrain.html#id).
+---@return defines.target_type Type of the target object.
+function LuaBootstrap.register_on_object_destroyed(object) end
+
+---Register a metatable to have linkage recorded and restored when saving/loading.
+---
+---The metatable itself will not be saved. Instead, only the linkage to a registered metatable is saved, and the metatable registered under that name will be used when loading the table.
+---
+---`register_metatable()` can not be used in the console, in event listeners or during a `remote.call()`.
+---
+---The metatable first needs to be defined in the mod's root scope, then registered using this method. From then on, it will be properly restored for tables in [storage](https://lua-api.factorio.com/1.1.110/auxiliary/storage.html).
+---
+---```
+---local metatable =
+---{
+---  __index = function(key)
+---    return "no value for key " .. key
+---  end
+---}
+---script.register_metatable("my_metatable", metatable)
+---```
+---
+---This previously defined `metatable` can then be set on any table as usual:
+---
+---```
+---local table = {key="value"}
+---setmetatable(table, metatable)
+---```
+---@param name string The name of this metatable. Names must be unique per mod.
+---@param metatable table The metatable to register.
+function LuaBootstrap.register_metatable(name, metatable) end
+
+---Generate a new, unique event ID that can be used to raise custom events with [LuaBootstrap::raise_event](https://lua-api.factorio.com/1.1.110/classes/LuaBootstrap.html#raise_event).
+---@return defines.events The newly generated event ID. This will be a new value that does not correspond to any named entry in defines.events.
+---@see LuaBootstrap.raise_event
+function LuaBootstrap.generate_event_name() end
+
+---Converts LuaEventType into related value of defines.events. Value will be provided also if event was not given a constant inside of defines.events.
+---@param event LuaEventType 
+---@return defines.events 
+function LuaBootstrap.get_event_id(event) end
+
+---Find the event handler for an event.
+---@param event LuaEventType The event identifier to get a handler for.
+---@return fun(arg1: EventData) | nil Reference to the function currently registered as the handler, if it was found.
+function LuaBootstrap.get_event_handler(event) end
+
+---Gets the mod event order as a string.
+---@return string 
+function LuaBootstrap.get_event_order() end
+
+---Sets the filters for the given event. The filters are only retained when set after the actual event registration, because registering for an event with different or no filters will overwrite previously set ones.
+---
+---Limit the [on_marked_for_deconstruction](https://lua-api.factorio.com/1.1.110/events.html#on_marked_for_deconstruction) event to only be received when a non-ghost entity is marked for deconstruction.
+---
+---```
+---script.set_event_filter(defines.events.on_marked_for_deconstruction, {{filter = "ghost", invert = true}})
+---```
+---
+---Limit the [on_built_entity](https://lua-api.factorio.com/1.1.110/events.html#on_built_entity) event to only be received when either a `unit` or a `unit-spawner` is built.
+---
+---```
+---script.set_event_filter(defines.events.on_built_entity, {{filter = "type", type = "unit"}, {filter = "type", type = "unit-spawner"}})
+---```
+---
+---Limit the [on_entity_damaged](https://lua-api.factorio.com/1.1.110/events.html#on_entity_damaged) event to only be received when a `rail` is damaged by an `acid` attack.
+---
+---```
+---script.set_event_filter(defines.events.on_entity_damaged, {{filter = "rail"}, {filter = "damage-type", type = "acid", mode = "and"}})
+---```
+---@param event LuaEventType ID of the event to filter.
+---@param filters? EventFilter The filters or `nil` to clear them.
+---@see EventData.on_built_entity
+function LuaBootstrap.set_event_filter(event, filters) end
+
+---Gets the filters for the given event.
+---@param event LuaEventType ID of the event to get.
+---@return EventFilter | nil The filters or `nil` if none are defined.
+function LuaBootstrap.get_event_filter(event) end
+
+---Raise an event. Only events generated with [LuaBootstrap::generate_event_name](https://lua-api.factorio.com/1.1.110/classes/LuaBootstrap.html#generate_event_name) and the following can be raised:
+---
+---```lua
+----- Raise the on_console_chat event with the desired message 'from' the first player
+---local data = {player_index = 1, message = "Hello friends!"}
+---script.raise_event(defines.events.on_console_chat, data)
+---```
+---@param event LuaEventType ID or name of the event to raise.
+---@param data table Table with extra data that will be passed to the event handler. Any invalid LuaObjects will silently stop the event from being raised.
+---@see LuaBootstrap.generate_event_name
+function LuaBootstrap.raise_event(event, data) end
+
+---@class LuaBootstrap.raise_console_chat_param
+---@field player_index uint The player doing the chatting.
+---@field message string The chat message to send.
+
+---@param param LuaBootstrap.raise_console_chat_param
+function LuaBootstrap.raise_console_chat(param) end
+
+---@class LuaBootstrap.raise_player_crafted_item_param
+---@field item_stack LuaItemStack The item that has been crafted.
+---@field player_index uint The player doing the crafting.
+---@field recipe RecipeID The recipe used to craft this item.
+
+---@param param LuaBootstrap.raise_player_crafted_item_param
+function LuaBootstrap.raise_player_crafted_item(param) end
+
+---@class LuaBootstrap.raise_player_fast_transferred_param
+---@field player_index uint The player transferred from or to.
+---@field entity LuaEntity The entity transferred from or to.
+---@field from_player boolean Whether the transfer was from player to entity. If `false`, the transfer was from entity to player.
+---@field is_split boolean Whether the transfer was a split action (half stack).
+
+---@param param LuaBootstrap.raise_player_fast_transferred_param
+function LuaBootstrap.raise_player_fast_transferred(param) end
+
+---@class LuaBootstrap.raise_biter_base_built_param
+---@field entity LuaEntity The entity that was built.
+
+---@param param LuaBootstrap.raise_biter_base_built_param
+function LuaBootstrap.raise_biter_base_built(param) end
+
+---@class LuaBootstrap.raise_market_item_purchased_param
+---@field player_index uint The player who did the purchasing.
+---@field market LuaEntity The market entity.
+---@field offer_index uint The index of the offer purchased.
+---@field count uint The amount of offers purchased.
+
+---@param param LuaBootstrap.raise_market_item_purchased_param
+function LuaBootstrap.raise_market_item_purchased(param) end
+
+---@alias LuaBootstrap.raise_script_built_param LuaBootstrap.raise_biter_base_built_param
+
+---@param param LuaBootstrap.raise_script_built_param
+function LuaBootstrap.raise_script_built(param) end
+
+---@alias LuaBootstrap.raise_script_destroy_param LuaBootstrap.raise_biter_base_built_param
+
+---@param param LuaBootstrap.raise_script_destroy_param
+function LuaBootstrap.raise_script_destroy(param) end
+
+---@class LuaBootstrap.raise_script_revive_param
+---@field entity LuaEntity The entity that was revived.
+---@field tags? Tags The tags associated with this entity, if any.
+
+---@param param LuaBootstrap.raise_script_revive_param
+function LuaBootstrap.raise_script_revive(param) end
+
+---@class LuaBootstrap.raise_script_teleported_param
+---@field entity LuaEntity The entity that was teleported.
+---@field old_surface_index uint8 The entity's surface before the teleportation.
+---@field old_position MapPosition The entity's position before the teleportation.
+
+---@param param LuaBootstrap.raise_script_teleported_param
+function LuaBootstrap.raise_script_teleported(param) end
+
+---@class LuaBootstrap.raise_script_set_tiles_param
+---@field surface_index uint The surface whose tiles have been changed.
+---@field tiles Tile[] The tiles that have been changed.
+
+---@param param LuaBootstrap.raise_script_set_tiles_param
+function LuaBootstrap.raise_script_set_tiles(param) end
+
+
+---Allows for the registration of custom console commands through the global object named `commands`. Similarly to [event subscriptions](https://lua-api.factorio.com/1.1.110/classes/LuaBootstrap.html#on_event), these don't persist through a save-and-load cycle.
+---@see LuaBootstrap.on_event
+---@class LuaCommandProcessor
+---@field commands table<string, LocalisedString> Lists the custom commands registered by scripts through `LuaCommandProcessor`. (Read-only)
+---@field game_commands table<string, LocalisedString> Lists the built-in commands of the core game. The [wiki](https://wiki.factorio.com/Console) has an overview of these. (Read-only)
+---@field object_name string The class name of this object. Available even when `valid` is false. For LuaStruct objects it may also be suffixed with a dotted path to a member of the struct. (Read-only)
+LuaCommandProcessor = {}
+
+---Add a custom console command.
+---
+---Trying to add a command with the `name` of a game command or the name of a custom command that is already in use will result in an error.
+---
+---This example command will register a custom event called `print_tick` that prints the current tick to either the player issuing the command or to everyone on the server, depending on the command parameter:
+---
+---```
+---commands.add_command("print_tick", nil, function(command)
+---  if command.player_index ~= nil and command.parameter == "me" then
+---    game.get_player(command.player_index).print(command.tick)
+---  else
+---    game.print(command.tick)
+---  end
+---end)
+---```
+---
+---This shows the usage of the table that gets passed to any function handling a custom command. This specific example makes use of the `tick` and the optional `player_index` and `parameter` fields. The user is supposed to either call it without any parameter (`"/print_tick"`) or with the `"me"` parameter (`"/print_tick me"`).
+---@param name string The desired name of the command (case sensitive).
+---@param help LocalisedString The localised help message. It will be shown to players using the `/help` command.
+---@param function_ fun(arg1: CustomCommandData) The function that will be called when this command is invoked.
+function LuaCommandProcessor.add_command(name, help, function_) end
+
+---Remove a custom console command.
+---@param name string The name of the command to remove (case sensitive).
+---@return boolean Whether the command was successfully removed. Returns `false` if the command didn't exist.
+function LuaCommandProcessor.remove_command(name) end
+
+
+---Control behavior for container entities.
+---@class LuaContainerControlBehavior : LuaControlBehavior
+---@field read_contents boolean `true` if this container is sending its content to a circuit network (Read/Write)
+---@field valid boolean Is this object valid? This Lua object holds a reference to an object within the game engine. It is possible that the game-engine object is removed whilst a mod still holds the corresponding Lua object. If that happens, the object becomes invalid, i.e. this attribute will be `false`. Mods are advised to check for object validity if any change to the game state might have occurred between the creation of the Lua object and its access. (Read-only)
+---@field object_name string The class name of this object. Available even when `valid` is false. For LuaStruct objects it may also be suffixed with a dotted path to a member of the struct. (Read-only)
+LuaContainerControlBehavior = {}
+
+
+---The control behavior for an entity. Inserters have logistic network and circuit network behavior logic, lamps have circuit logic and so on. This is an abstract base class that concrete control behaviors inherit.
+---
+---An control reference becomes invalid once the control behavior is removed or the entity (see [LuaEntity](https://lua-api.factorio.com/1.1.110/classes/LuaEntity.html)) it resides in is destroyed.
+---@class LuaControlBehavior
+---@field type defines.control_behavior.type The concrete type of this control behavior. (Read-only)
+---@field entity LuaEntity The entity this control behavior belongs to. (Read-only)
+LuaControlBehavior = {}
+
+---@param wire_connector_id defines.wire_connector_id Wire connector to get circuit network for.
+---@return LuaCircuitNetwork | nil The circuit network or nil.
+function LuaControlBehavior.get_circuit_network(wire_connector_id) end
+
+
+---Prototype of a custom event.
+---@class LuaCustomEventPrototype : LuaPrototypeBase
+---@field event_id defines.events Event identifier associated with this custom event. (Read-only)
+---@field valid boolean Is this object valid? This Lua object holds a reference to an object within the game engine. It is possible that the game-engine object is removed whilst a mod still holds the corresponding Lua object. If that happens, the object becomes invalid, i.e. this attribute will be `false`. Mods are advised to check for object validity if any change to the game state might have occurred between the creation of the Lua object and its access. (Read-only)
+---@field object_name string The class name of this object. Available even when `valid` is false. For LuaStruct objects it may also be suffixed with a dotted path to a member of the struct. (Read-only)
+LuaCustomEventPrototype = {}
+
+
+---Lazily evaluated table. For performance reasons, we sometimes return a custom table-like type instead of a native Lua table. This custom type lazily constructs the necessary Lua wrappers of the corresponding C++ objects, therefore preventing their unnecessary construction in some cases.
+---
+---There are some notable consequences to the usage of a custom table type rather than the native Lua table type: Iterating a custom table is only possible using the `pairs` Lua function; `ipairs` won't work. Another key difference is that custom tables cannot be serialised into a game save file -- if saving the game would require serialisation of a custom table, an error will be displayed and the game will not be saved.
+---
+---In previous versions of Factorio, this would create a [LuaPlayer](https://lua-api.factorio.com/1.1.110/classes/LuaPlayer.html) instance for every player in the game, even though only one such wrapper is needed. In the current version, accessing [game.players](https://lua-api.factorio.com/1.1.110/classes/LuaGameScript.html#players) by itself does not create any [LuaPlayer](https://lua-api.factorio.com/1.1.110/classes/LuaPlayer.html) instances; they are created lazily when accessed. Therefore, this example only constructs one [LuaPlayer](https://lua-api.factorio.com/1.1.110/classes/LuaPlayer.html) instance, no matter how many elements there are in `game.players`.
+---
+---```
+---game.players["Oxyd"].character.die()
+---```
+---
+---This statement will execute successfully and `storage.p` will be useable as one might expect. However, as soon as the user tries to save the game, a "LuaCustomTable cannot be serialized" error will be shown. The game will remain unsaveable so long as `storage.p` refers to an instance of a custom table.
+---
+---```
+---storage.p = game.players  -- This has high potential to make the game unsaveable
+---```
+---
+---The following will produce no output because `ipairs` is not supported with custom tables.
+---
+---```
+---for _, p in ipairs(game.players) do game.player.print(p.name); end  -- incorrect; use pairs instead
+---```
+---
+---```lua
+----- Custom tables may be iterated using `pairs`.
+---for _, p in pairs(game.players) do game.player.print(p.name); end
+---```
+---@class LuaCustomTable<K, V>
+---@field [K] V
+---@operator len: uint
+---@field valid boolean Is this object valid? This Lua object holds a reference to an object within the game engine. It is possible that the game-engine object is removed whilst a mod still holds the corresponding Lua object. If that happens, the object becomes invalid, i.e. this attribute will be `false`. Mods are advised to check for object validity if any change to the game state might have occurred between the creation of the Lua object and its access. (Read-only)
+---@field object_name string The class name of this object. Available even when `valid` is false. For LuaStruct objects it may also be suffixed with a dotted path to a member of the struct. (Read-only)
+LuaCustomTable = {}
+
+
+---Prototype of an entity.
+---@class LuaEntityPrototype : LuaPrototypeBase
+---@field logistic_parameters? LuaEntityPrototype.logistic_parameters_table The logistic parameters for this roboport. (Read-only)
+LuaEntityPrototype = {}
+
+---@class LuaEntityPrototype.logistic_parameters_table
+---@field spawn_and_station_height float
+---@field spawn_and_station_shadow_height_offset float
+---@field stationing_render_layer_swap_height float
+---@field charge_approach_distance float
+---@field logistic_radius float
+---@field construction_radius float
+---@field charging_station_count uint
+---@field charging_distance float
+---@field charging_station_shift Vector
+---@field charging_energy double
+---@field charging_threshold_distance float
+---@field robot_vertical_acceleration float
+---@field stationing_offset Vector
+---@field robot_limit uint
+---@field logistics_connection_distance float
+---@field robots_shrink_when_entering_and_exiting boolean
+
+
+---Prototype of a modular equipment.
+---@class LuaEquipmentPrototype : LuaPrototypeBase
+---@field logistic_parameters? LuaEquipmentPrototype.logistic_parameters_table The logistic parameters for this roboport equipment. (Read-only)
+LuaEquipmentPrototype = {}
+
+---@alias LuaEquipmentPrototype.logistic_parameters_table LuaEntityPrototype.logistic_parameters_table
+
+
+---A lazily loaded value. For performance reasons, we sometimes return a custom lazily-loaded value type instead of the native Lua value. This custom type lazily constructs the necessary value when [LuaLazyLoadedValue::get](https://lua-api.factorio.com/1.1.110/classes/LuaLazyLoadedValue.html#get) is called, therefore preventing its unnecessary construction in some cases.
+---
+---An instance of LuaLazyLoadedValue is only valid during the event it was created from and cannot be saved.
+---@see LuaLazyLoadedValue.get
+---@class LuaLazyLoadedValue
+---@field valid boolean Is this object valid? This Lua object holds a reference to an object within the game engine. It is possible that the game-engine object is removed whilst a mod still holds the corresponding Lua object. If that happens, the object becomes invalid, i.e. this attribute will be `false`. Mods are advised to check for object validity if any change to the game state might have occurred between the creation of the Lua object and its access. (Read-only)
+---@field object_name string The class name of this object. Available even when `valid` is false. For LuaStruct objects it may also be suffixed with a dotted path to a member of the struct. (Read-only)
+LuaLazyLoadedValue = {}
+
+---Gets the value of this lazy loaded value.
+---@return Any 
+function LuaLazyLoadedValue.get() end
+
+
+---Base for all prototype classes.
+---@class LuaPrototypeBase
+---@field type string Type of this prototype. (Read-only)
+---@field name string Name of this prototype. (Read-only)
+---@field order string The string used to alphabetically sort these prototypes. It is a simple string that has no additional semantic meaning. (Read-only)
+---@field localised_name LocalisedString (Read-only)
+---@field localised_description LocalisedString (Read-only)
+---@field factoriopedia_description LocalisedString Provides additional description used in factoriopedia. (Read-only)
+---@field group LuaGroup Group of this prototype. (Read-only)
+---@field subgroup LuaGroup Subgroup of this prototype. (Read-only)
+---@field hidden boolean (Read-only)
+---@field hidden_in_factoriopedia boolean (Read-only)
+---@field parameter boolean (Read-only)
+LuaPrototypeBase = {}
+
+
+---An interface to send messages to the calling RCON interface through the global object named `rcon`.
+---@class LuaRCON
+---@field object_name string The class name of this object. Available even when `valid` is false. For LuaStruct objects it may also be suffixed with a dotted path to a member of the struct. (Read-only)
+LuaRCON = {}
+
+---Print text to the calling RCON interface if any.
+---@param message LocalisedString 
+function LuaRCON.print(message) end
+
+
+---Registry of interfaces between scripts. An interface is simply a dictionary mapping names to functions. A script or mod can then register an interface with [LuaRemote](https://lua-api.factorio.com/1.1.110/classes/LuaRemote.html), after that any script can call the registered functions, provided it knows the interface name and the desired function name. An instance of LuaRemote is available through the global object named `remote`.
+---
+---```lua
+----- Will register a remote interface containing two functions. Later, it will call these functions through `remote`.
+---remote.add_interface("human interactor",
+---  {
+---    hello = function() game.player.print("Hi!") end,
+---    bye = function(name) game.player.print("Bye " .. name) end
+---  })
+----- Some time later, possibly in a different mod...
+---remote.call("human interactor", "hello")
+---remote.call("human interactor", "bye", "dear reader")
+---```
+---@class LuaRemote
+---@field object_name string The class name of this object. Available even when `valid` is false. For LuaStruct objects it may also be suffixed with a dotted path to a member of the struct. (Read-only)
+---@field interfaces table<string, table<string, true>> List of all registered interfaces. For each interface name, `remote.interfaces[name]` is a dictionary mapping the interface's registered functions to `true`. (Read-only)
+LuaRemote = {}
+
+---Add a remote interface.
+---@param name string Name of the interface. If the name matches any existing interface, an error is thrown.
+---@param functions table<string, function> List of functions that are members of the new interface.
+function LuaRemote.add_interface(name, functions) end
+
+---Removes an interface with the given name.
+---@param name string Name of the interface.
+---@return boolean Whether the interface was removed. `false` if the interface didn't exist.
+function LuaRemote.remove_interface(name) end
+
+---Call a function of an interface.
+---
+---Providing an unknown interface or function name will result in a script error.
+---@param interface string Interface to look up `function` in.
+---@param function_ string Function name that belongs to the `interface`.
+---@param ... Any Arguments to pass to the called function. Note that any arguments passed through the interface are a copy of the original, not a reference. Metatables are not retained, while references to LuaObjects stay intact.
+---@return Any | nil 
+function LuaRemote.call(interface, function_, ...) end
+
+
+---The remote interfaces known to this workspace, by interface name. Extend it to type
+---the interfaces your mod calls:
+---
+---```lua
+------@class RemoteInterfaces
+------@field my_mod { get_value: fun(name: string): number }
+---```
+---@class RemoteInterfaces
+---@field [string] table<string, function>
+
+---Object containing mod settings of three distinct types: `startup`, `global`, and `player`. An instance of LuaSettings is available through the global object named `settings`.
+---@class LuaSettings
+---@field startup LuaCustomTable<string, ModSetting> The startup mod settings, indexed by prototype name. (Read-only)
+---@field global LuaCustomTable<string, ModSetting> The current global mod settings, indexed by prototype name. Even though this attribute is marked as read-only, individual settings can be changed by overwriting their [ModSetting](https://lua-api.factorio.com/1.1.110/concepts/ModSetting.html) table. Mods can only change their own settings. Using the in-game console, all player settings can be changed. (Read-only)
+---@field player_default LuaCustomTable<string, ModSetting> The **default** player mod settings for this map, indexed by prototype name. Changing these settings only affects the default settings for future players joining the game. Individual settings can be changed by overwriting their [ModSetting](https://lua-api.factorio.com/1.1.110/concepts/ModSetting.html) table. Mods can only change their own settings. Using the in-game console, all player settings can be changed. (Read-only)
+---@field object_name string The class name of this object. Available even when `valid` is false. For LuaStruct objects it may also be suffixed with a dotted path to a member of the struct. (Read-only)
+LuaSettings = {}
+
+---Gets the current per-player settings for the given player, indexed by prototype name. Returns the same structure as [LuaPlayer::mod_settings](https://lua-api.factorio.com/1.1.110/classes/LuaPlayer.html#mod_settings). This table becomes invalid if its associated player does.
+---
+---Even though this attribute is a getter, individual settings can be changed by overwriting their [ModSetting](https://lua-api.factorio.com/1.1.110/concepts/ModSetting.html) table. Mods can only change their own settings. Using the in-game console, all player settings can be changed.
+---
+---```lua
+----- Change the value of the "active_lifestyle" setting
+---settings.get_player_settings(player_index)["active_lifestyle"] = {value = true}
+---```
+---@param player PlayerIdentification 
+---@return LuaCustomTable<string, ModSetting> 
+---@see ModSetting
+function LuaSettings.get_player_settings(player) end
+
+
+-- Global Objects
+
+---The main scripting interface through which most of the API is accessed.
+---@type LuaGameScript
+game = nil
+
+---Provides an interface for registering game event handlers.
+---@type LuaBootstrap
+script = nil
+
+---Allows registration of custom commands for the in-game console.
+---@type LuaCommandProcessor
+commands = nil
+
+---Allows printing messages to the calling RCON instance, if any.
+---@type LuaRCON
+rcon = nil
+
+---Allows registration and use of functions to communicate between mods.
+---@type LuaRemote
+remote = nil
+
+---Allows rendering of geometric shapes, text and sprites in the game world.
+---@type LuaRendering
+rendering = nil
+
+---Provides access to the current mod settings.
+---@type LuaSettings
+settings = nil
+
+-- The contents of `global` are up to each mod. Type them by extending its class
+-- anywhere in your mod; LuaLS merges the fields of every declaration:
+--
+--   ---@class global
+--   ---@field players table<integer, PlayerData>
+--   ---@field next_id integer
+
+---A table whose contents are saved and restored with the save file. Only data
+---(no functions or metatables other than registered ones) may be stored in it.
+---@class global
+---@field [any] any
+global = {}
+
+-- Events
+
+---@class EventData
+---@field name defines.events Identifier of the event
+---@field tick integer Tick the event was generated.
+---@field mod_name? string The name of the mod that raised the event if it was raised using [LuaBootstrap::raise_event](https://lua-api.factorio.com/1.1.110/classes/LuaBootstrap.html#raise_event).
+EventData = {}
+
+---Called when a [CustomInputPrototype](https://lua-api.factorio.com/1.1.110/prototypes/CustomInputPrototype.html) is activated.
+---
+---```lua
+----- This will be raised when a custom input with the name "my-potato-control" and action "lua" is pressed
+---script.on_event("my-potato-control", function(event)
+---  game.print("Keyboard shortcut pressed on tick: " ..tostring(event.tick))
+---end)
+---```
+---@class EventData.CustomInputEvent : EventData
+---@field player_index uint The player that activated the custom input.
+---@field input_name string The prototype name of the custom input that was activated.
+---@field cursor_position MapPosition The mouse cursor position when the custom input was activated.
+---@field cursor_direction? defines.direction Cursor direction.
+---@field cursor_display_location GuiLocation The mouse cursor display location when the custom input was activated.
+---@field selected_prototype? SelectedPrototypeData Information about the prototype that is selected when the custom input is used. Needs to be enabled on the custom input's prototype. `nil` if none is selected.
+---@field name defines.events Identifier of the event
+---@field tick uint Tick the event was generated.
+EventData.CustomInputEvent = {}
+
+---Called when player builds something.
+---@class EventData.on_built_entity : EventData
+---@field entity LuaEntity
+---@field player_index uint
+---@field consumed_items LuaInventory
+---@field tags? Tags The tags associated with this entity if any.
+---@field name defines.events Identifier of the event
+---@field tick uint Tick the event was generated.
+EventData.on_built_entity = {}
+
+---Called after the player was created.
+---@class EventData.on_player_created : EventData
+---@field player_index uint
+---@field name defines.events Identifier of the event
+---@field tick uint Tick the event was generated.
+EventData.on_player_created = {}
+
+---Called when a research finishes.
+---@class EventData.on_research_finished : EventData
+---@field research LuaTechnology The researched technology
+---@field by_script boolean If the technology was researched by script.
+---@field name defines.events Identifier of the event
+---@field tick uint Tick the event was generated.
+EventData.on_research_finished = {}
+
+---It is fired once every tick. Since this event is fired every tick, its handler shouldn't include performance heavy code.
+---@class EventData.on_tick : EventData
+---@field name defines.events Identifier of the event
+---@field tick uint Tick the event was generated.
+EventData.on_tick = {}
+
@@ -0,0 +1,324 @@
+---@meta
+
+-- Auto-generated Factorio Prototype API definitions
+-- Generated from: fixtures/2.0.45/prototype-api.json
+
+-- Defines (Prototype)
+
+-- Concepts (Prototype)
+
+---@class data.Color.struct
+---@field r? float red value
+---@field g? float green value
+---@field b? float blue value
+---@field a? float alpha value (opacity)
+
+---Table of red, green, blue, and alpha float values between 0 and 1. Alternatively, values can be from 0-255, they are interpreted as such if at least one value is `> 1`.
+---
+---Color allows the short-hand notation of passing an array of exactly 3 or 4 numbers. The array items are r, g, b and optionally a, in that order.
+---
+---The game usually expects colors to be in pre-multiplied form (color channels are pre-multiplied by alpha).
+---
+---```lua
+---color = {r=1, g=0, b=0, a=1} -- red, full opacity
+---color = {r=1} -- the same red, omitting default values
+---color = {1, 0, 0, 1} -- also the same red
+---color = {0, 0, 1} -- blue
+---color = {r=0, g=0.5, b=0, a=0.5} -- half transparency green
+---color = {} -- full opacity black
+---```
+---@alias data.Color data.Color.struct | [float, float, float] | [float, float, float, float]
+
+---The name of an [EntityPrototype](https://lua-api.factorio.com/2.0.45/prototypes/EntityPrototype.html).
+---
+---```lua
+---"stone-furnace"
+---```
+---
+---```lua
+---"bulk-inserter"
+---```
+---@alias EntityID string
+
+---A slash `"/"` is always used as the directory delimiter. A path always begins with the specification of a root, which can be one of three formats:
+---
+---- **core**: A path starting with `__core__` will access the resources in the data/core directory, these resources are always accessible regardless of mod specifications.
+---
+---- **base**: A path starting with `__base__` will access the resources in the base mod in data/base directory. These resources are usually available, as long as the base mod isn't removed/deactivated.
+---
+---- **mod path**: The format `__<mod-name>__` is placeholder for root of any other mod (mods/<mod-name>), and is accessible as long as the mod is active.
+---
+---```lua
+---filename = "__base__/graphics/entity/accumulator/accumulator.png"
+---```
+---
+---```lua
+---filename = "__a-mod__/animations/assembler.png"
+---```
+---@alias FileName string
+
+---The name of an [ItemPrototype](https://lua-api.factorio.com/2.0.45/prototypes/ItemPrototype.html).
+---
+---```lua
+---"iron-plate"
+---```
+---
+---```lua
+---"blueprint-book"
+---```
+---@see ItemPrototype
+---@alias ItemID string
+
+---An array containing the following values.
+---@alias ItemPrototypeFlags ("draw-logistic-overlay" | "excluded-from-trash-unrequested" | "always-show" | "hide-from-bonus-gui" | "hide-from-fuel-tooltip" | "not-stackable" | "primary-place-result" | "mod-openable" | "only-in-cursor" | "spawnable" | "spoil-result" | "ignore-spoil-time-modifier")[]
+
+---Specifies one picture that can be used in the game.
+---
+---When there is more than one sprite or [Animation](https://lua-api.factorio.com/2.0.45/types/Animation.html) frame with the same source file and dimensions/position in the game, they all share the same memory.
+---
+---```lua
+----- simple sprite
+---picture_set_enemy =
+---{
+---  filename = "__base__/graphics/entity/land-mine/land-mine-set-enemy.png",
+---  priority = "medium",
+---  width = 32,
+---  height = 32
+---}
+---```
+---
+---```lua
+----- sprite with layers
+---picture =
+---{
+---  layers =
+---  {
+---    {
+---      filename = "__base__/graphics/entity/wooden-chest/wooden-chest.png",
+---      priority = "extra-high",
+---      width = 62,
+---      height = 72,
+---      shift = util.by_pixel(0.5, -2),
+---      scale = 0.5
+---    },
+---    {
+---      filename = "__base__/graphics/entity/wooden-chest/wooden-chest-shadow.png",
+---      priority = "extra-high",
+---      width = 104,
+---      height = 40,
+---      shift = util.by_pixel(10, 6.5),
+---      draw_as_shadow = true,
+---      scale = 0.5
+---    }
+---  }
+---}
+---```
+---@class Sprite : SpriteParameters
+---@field layers? Sprite[] If this property is present, all Sprite definitions have to be placed as entries in the array, and they will all be loaded from there. `layers` may not be an empty table. Each definition in the array may also have the `layers` property. If this property is present, all other properties, including those inherited from SpriteParameters, are ignored.
+---@field filename? FileName Only loaded, and mandatory if `layers` is not defined. The path to the sprite file to use.
+---@field dice? SpriteSizeType Only loaded if `layers` is not defined. Number of slices this is sliced into when using the "optimized atlas packing" option. If you are a modder, you can just ignore this property. Example: If this is 4, the sprite will be sliced into a 4x4 grid.
+---@field dice_x? SpriteSizeType Only loaded if `layers` is not defined. Same as `dice` above, but this specifies only how many slices there are on the x axis.
+---@field dice_y? SpriteSizeType Only loaded if `layers` is not defined. Same as `dice` above, but this specifies only how many slices there are on the y axis.
+
+---@class data.Vector.struct
+---@field x double
+---@field y double
+
+---A vector is a two-element array or dictionary containing the x and y components. Positive x goes east, positive y goes south.
+---
+---```lua
+---shift = {0, 12}
+---```
+---
+---```lua
+---right = {1.0, 0.5}
+---```
+---
+---```lua
+---vector = {x = 2.3, y = 3.4}
+---```
+---@alias data.Vector data.Vector.struct | [double, double]
+
+-- Prototypes
+
+---@class Prototype : PrototypeBase
+---@field factoriopedia_alternative? string The ID type corresponding to the prototype that inherits from this. For example, if this is an [EntityPrototype](https://lua-api.factorio.com/2.0.45/prototypes/EntityPrototype.html), this property's type is [EntityID](https://lua-api.factorio.com/2.0.45/types/EntityID.html).
+Prototype = {}
+
+---The abstract base for prototypes. PrototypeBase defines the common features of prototypes, such as localization and order.
+---@class PrototypeBase
+---@field type string Specifies the kind of prototype this is. For a list of all possible types, see the [prototype overview](https://lua-api.factorio.com/2.0.45/prototypes.html).
+---@field name string Unique textual identification of the prototype. May only contain alphanumeric characters, dashes and underscores. May not exceed a length of 200 characters. For a list of all names used in vanilla, see [data.raw](https://wiki.factorio.com/Data.raw).
+---@field order? Order Used to order prototypes in inventory, recipes and GUIs. May not exceed a length of 200 characters.
+---@field localised_name? LocalisedString Overwrites the name set in the [locale file](https://wiki.factorio.com/Tutorial:Localisation). Can be used to easily set a procedurally-generated name because the LocalisedString format allows to insert parameters into the name directly from the Lua script.
+---@field localised_description? LocalisedString Overwrites the description set in the [locale file](https://wiki.factorio.com/Tutorial:Localisation). The description is usually shown in the tooltip of the prototype.
+---@field factoriopedia_description? LocalisedString Provides additional description used in factoriopedia.
+---@field subgroup? ItemSubGroupID The name of an [ItemSubGroup](https://lua-api.factorio.com/2.0.45/types/ItemSubGroup.html).
+---@field hidden? boolean
+---@field hidden_in_factoriopedia? boolean
+---@field parameter? boolean Whether the prototype is a special type which can be used to parametrize blueprints and doesn't have other function.
+---@field factoriopedia_simulation? SimulationDefinition The simulation shown when looking at this prototype in the Factoriopedia GUI.
+PrototypeBase = {}
+
+---@class AmmoPrototype : ItemPrototype Represents a ammo prototype definition.
+---@field type "ammo"
+---@field ammo_type AmmoType | AmmoType[] When using a plain [AmmoType](https://lua-api.factorio.com/2.0.45/types/AmmoType.html) (no array), the ammo type applies to everything (`"default"`). When using an array of AmmoTypes, they have the additional [AmmoType::source_type](https://lua-api.factorio.com/2.0.45/types/AmmoType.html#source_type) property.
+---@field magazine_size? float Number of shots before ammo item is consumed. Must be >= `1`.
+---@field reload_time? float Amount of extra time (in ticks) it takes to reload the weapon after depleting the magazine. Must be >= `0`.
+---@field ammo_category AmmoCategoryID
+---@field shoot_protected? boolean
+AmmoPrototype = {}
+
+---@class CustomEventPrototype : Prototype Represents a custom-event prototype definition.
+---@field type "custom-event"
+CustomEventPrototype = {}
+
+---@class ItemPrototype : Prototype Represents a item prototype definition.
+---@field type "item"
+---@field stack_size ItemCountType Count of items of the same name that can be stored in one inventory slot. Must be 1 when the `"not-stackable"` flag is set.
+---@field icons? IconData[] Can't be an empty array.
+---@field icon? FileName Path to the icon file. Mandatory if `icons` is not defined.
+---@field icon_size? SpriteSizeType The size of the square icon, in pixels. E.g. `32` for a 32px by 32px icon. Must be larger than `0`. Only loaded if `icons` is not defined.
+---@field dark_background_icons? IconData[] Can't be an empty array.
+---@field dark_background_icon? FileName If this is set, it is used to show items in alt-mode instead of the normal item icon. This can be useful to increase the contrast of the icon with the dark alt-mode [icon outline](https://lua-api.factorio.com/2.0.45/types/UtilityConstants.html#item_outline_color). Path to the icon file. Only loaded if `dark_background_icons` is not defined.
+---@field dark_background_icon_size? SpriteSizeType The size of the square icon, in pixels. E.g. `32` for a 32px by 32px icon. Must be larger than `0`. Only loaded if `dark_background_icons` is not defined.
+---@field place_result? EntityID Name of the [EntityPrototype](https://lua-api.factorio.com/2.0.45/prototypes/EntityPrototype.html) that can be built using this item. If this item should be the one that construction bots use to build the specified `place_result`, set the `"primary-place-result"` [item flag](https://lua-api.factorio.com/2.0.45/types/ItemPrototypeFlags.html). The localised name of the entity will be used as the in-game item name. This behavior can be overwritten by specifying `localised_name` on this item, it will be used instead.
+---@field place_as_equipment_result? EquipmentID
+---@field fuel_category? FuelCategoryID Must exist when a nonzero fuel_value is defined.
+---@field burnt_result? ItemID The item that is the result when this item gets burned as fuel.
+---@field plant_result? EntityID
+---@field place_as_tile? PlaceAsTile
+---@field pictures? SpriteVariations Used to give the item multiple different icons so that they look less uniform on belts. For inventory icons and similar, `icon/icons` will be used. Maximum number of variations is 16. When using sprites of size `64` (same as base game icons), the `scale` should be set to 0.5.
+---@field flags? ItemPrototypeFlags Specifies some properties of the item.
+---@field fuel_value? Energy Amount of energy the item gives when used as fuel. Mandatory if `fuel_acceleration_multiplier`, `fuel_top_speed_multiplier` or `fuel_emissions_multiplier` or `fuel_glow_color` are used.
+---@field fuel_acceleration_multiplier? double Must be 0 or positive.
+---@field fuel_top_speed_multiplier? double Must be 0 or positive.
+---@field fuel_emissions_multiplier? double
+---@field fuel_acceleration_multiplier_quality_bonus? double Additional fuel acceleration multiplier per quality level. Defaults to 30% of `fuel_acceleration_multiplier - 1` if `fuel_acceleration_multiplier` is larger than 1. Otherwise defaults to 0. Must be 0 or positive.
+---@field fuel_top_speed_multiplier_quality_bonus? double Additional fuel top speed multiplier per quality level. Defaults to 30% of `fuel_top_speed_multiplier - 1` if `fuel_top_speed_multiplier` is larger than 1. Otherwise defaults to 0. Must be 0 or positive.
+---@field weight? Weight The default weight is calculated automatically from recipes and falls back to [UtilityConstants::default_item_weight](https://lua-api.factorio.com/2.0.45/types/UtilityConstants.html#default_item_weight). More information on how item weight is determined can be found on its [auxiliary page](https://lua-api.factorio.com/2.0.45/auxiliary/item-weight.html).
+---@field ingredient_to_weight_coefficient? double
+---@field fuel_glow_color? data.Color Colors the glow of the burner energy source when this fuel is burned. Can also be used to color the glow of reactors burning the fuel, see [ReactorPrototype::use_fuel_glow_color](https://lua-api.factorio.com/2.0.45/prototypes/ReactorPrototype.html#use_fuel_glow_color).
+---@field open_sound? Sound
+---@field close_sound? Sound
+---@field pick_sound? Sound
+---@field drop_sound? Sound
+---@field inventory_move_sound? Sound
+---@field default_import_location? SpaceLocationID
+---@field color_hint? ColorHintSpecification Only used by hidden setting, support may be limited.
+---@field has_random_tint? boolean
+---@field destroyed_by_dropping_trigger? Trigger The effect/trigger that happens when an item is destroyed by being dropped on a [TilePrototype](https://lua-api.factorio.com/2.0.45/prototypes/TilePrototype.html) marked as destroying dropped items. This overrides the [TilePrototype::default_destroyed_dropped_item_trigger](https://lua-api.factorio.com/2.0.45/prototypes/TilePrototype.html#default_destroyed_dropped_item_trigger) from the tile.
+---@field rocket_launch_products? ItemProductPrototype[]
+---@field send_to_orbit_mode? SendToOrbitMode The way this item works when we try to send it to the orbit on its own. When "manual" is set, it can only be launched by pressing the launch button in the rocket silo. When "automated" is set, it will force the existence of "launch to orbit automatically" checkBox in the rocket silo which will then force the silo to automatically send the item to orbit when present.
+---@field random_tint_color? data.Color Randomly tints item instances on belts and in the world. 0 no tinting. 1 full tint.
+---@field spoil_level? uint8 Used by Inserters with spoil priority. Item with higher spoil level is considered more spoiled than item with lower spoil level regardless of progress of spoiling.
+---@field auto_recycle? boolean Whether the item should be included in the self-recycling recipes automatically generated by the quality mod. This property is not read by the game engine itself, but the quality mod's data-updates.lua file. This means it is discarded by the game engine after loading finishes.
+ItemPrototype = {}
+
+---@class RailSignalPrototype : RailSignalBasePrototype Represents a rail-signal prototype definition.
+---@field type "rail-signal"
+RailSignalPrototype = {}
+
+---@class ToolPrototype : ItemPrototype Represents a tool prototype definition.
+---@field type "tool"
+---@field durability? double The durability of this tool. Must be positive. Mandatory if `infinite` is false. Ignored if <code>infinite</code> is true.
+---@field durability_description_key? string May not be longer than 200 characters.
+---@field durability_description_value? string May not be longer than 200 characters. In-game, the game provides the locale with three [parameters](https://wiki.factorio.com/Tutorial:Localisation#Localising_with_parameters): `__1__`: remaining durability `__2__`: total durability `__3__`: durability as a percentage So when a locale key that has the following translation `Remaining durability is __1__ out of __2__ which is __3__ %` is applied to a tool with 2 remaining durability out of 8 it will be displayed as `Remaining durability is 2 out of 8 which is 25 %`
+---@field infinite? boolean Whether this tool has infinite durability. If this is false, `durability` must be specified.
+ToolPrototype = {}
+
+-- Settings stage
+
+---Base of the mod setting prototypes, defined in settings.lua.
+---@class ModSettingPrototype : Prototype
+---@field name string Internal name of the setting, unique across all mods.
+---@field setting_type "startup" | "runtime-global" | "runtime-per-user" Determines whether the setting is read through settings.startup, settings.global or settings.player.
+---@field localised_name? LocalisedString
+---@field localised_description? LocalisedString
+---@field order? string Sorting order of the setting in the mod settings GUI.
+---@field hidden? boolean Hides the setting from the mod settings GUI.
+
+---@class BoolSettingPrototype : ModSettingPrototype
+---@field type "bool-setting"
+---@field default_value boolean
+---@field forced_value? boolean Only loaded when the setting is hidden; the value the setting then always has.
+
+---@class IntSettingPrototype : ModSettingPrototype
+---@field type "int-setting"
+---@field default_value int64
+---@field minimum_value? int64
+---@field maximum_value? int64
+---@field allowed_values? int64[] If given, the setting is a dropdown of these values.
+
+---@class DoubleSettingPrototype : ModSettingPrototype
+---@field type "double-setting"
+---@field default_value double
+---@field minimum_value? double
+---@field maximum_value? double
+---@field allowed_values? double[] If given, the setting is a dropdown of these values.
+
+---@class StringSettingPrototype : ModSettingPrototype
+---@field type "string-setting"
+---@field default_value string
+---@field allow_blank? boolean Whether the setting may be empty.
+---@field auto_trim? boolean Whether leading and trailing whitespace is removed.
+---@field allowed_values? string[] If given, the setting is a dropdown of these values.
+
+---@class ColorSettingPrototype : ModSettingPrototype
+---@field type "color-setting"
+---@field default_value data.Color
+
+-- Data stage
+
+---Every prototype defined so far, indexed by type and then by name.
+---@class data.raw
+---@field ammo table<string, AmmoPrototype> Table of ammo prototypes by name.
+---@field ["custom-event"] table<string, CustomEventPrototype> Table of custom-event prototypes by name.
+---@field item table<string, ItemPrototype> Table of item prototypes by name.
+---@field ["rail-signal"] table<string, RailSignalPrototype> Table of rail-signal prototypes by name.
+---@field tool table<string, ToolPrototype> Table of tool prototypes by name.
+---@field ["bool-setting"] table<string, BoolSettingPrototype> Table of bool-setting prototypes by name.
+---@field ["int-setting"] table<string, IntSettingPrototype> Table of int-setting prototypes by name.
+---@field ["double-setting"] table<string, DoubleSettingPrototype> Table of double-setting prototypes by name.
+---@field ["string-setting"] table<string, StringSettingPrototype> Table of string-setting prototypes by name.
+---@field ["color-setting"] table<string, ColorSettingPrototype> Table of color-setting prototypes by name.
+
+---The table prototypes are defined in, available in the data stage (data.lua, data-updates.lua and data-final-fixes.lua).
+---@class data
+---@field raw data.raw Every prototype defined so far, indexed by type and then by name.
+---@field is_demo boolean Whether the game is the demo version.
+data = {}
+
+---Any prototype definition, discriminated by its `type` field.
+---@alias data.PrototypeUnion
+---| AmmoPrototype
+---| CustomEventPrototype
+---| ItemPrototype
+---| RailSignalPrototype
+---| ToolPrototype
+---| BoolSettingPrototype
+---| IntSettingPrototype
+---| DoubleSettingPrototype
+---| StringSettingPrototype
+---| ColorSettingPrototype
+
+---Adds the given prototypes to data.raw, replacing any prototype of the same type and name.
+---@param prototypes data.PrototypeUnion[]
+function data:extend(prototypes) end
+
+---The active mods, mapped to their version.
+---@type table<string, string>
+mods = {}
+
+---@class FeatureFlags
+---@field quality boolean
+---@field rail_bridges boolean
+---@field space_travel boolean
+---@field spoiling boolean
+---@field freezing boolean
+---@field segmented_units boolean
+---@field expansion_shaders boolean
+
+---The feature flags enabled by the active mods.
+---@type FeatureFlags
+feature_flags = {}
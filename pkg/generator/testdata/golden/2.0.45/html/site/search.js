@@ -0,0 +1,26 @@
+(function () {
+  const input = document.getElementById("search");
+  const results = document.getElementById("results");
+  let index = null;
+  input.addEventListener("input", async function () {
+    if (index === null) {
+      index = await (await fetch(root + "search-index.json")).json();
+    }
+    const query = input.value.trim().toLowerCase();
+    results.replaceChildren();
+    if (query === "") {
+      return;
+    }
+    const matches = index.filter((entry) => entry.name.toLowerCase().includes(query));
+    matches.sort((a, b) => a.name.length - b.name.length);
+    for (const entry of matches.slice(0, 50)) {
+      const link = document.createElement("a");
+      link.href = root + entry.url;
+      link.textContent = entry.name;
+      link.title = entry.kind;
+      const item = document.createElement("li");
+      item.append(link);
+      results.append(item);
+    }
+  });
+})();
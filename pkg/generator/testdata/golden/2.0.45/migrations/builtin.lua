@@ -0,0 +1,43 @@
+---@meta
+
+-- Auto-generated Factorio builtin type aliases
+
+---A floating-point number. This is a single-precision floating point number. Whilst Lua only uses double-precision numbers, when a function takes a float, the game engine will immediately convert the double-precision number to single-precision.
+---@alias float number
+
+---A double-precision floating-point number. This is the same data type as all Lua numbers use.
+---@alias double number
+
+---32-bit signed integer. Possible values are `-2 147 483 648` to `2 147 483 647`.
+---
+---Since Lua 5.2 only uses doubles, any API that asks for `int` will floor the given double.
+---@alias int integer
+
+---8-bit signed integer. Possible values are `-128` to `127`.
+---
+---Since Lua 5.2 only uses doubles, any API that asks for `int8` will floor the given double.
+---@alias int8 integer
+
+---32-bit unsigned integer. Possible values are `0` to `4 294 967 295`.
+---
+---Since Lua 5.2 only uses doubles, any API that asks for `uint` will floor the given double.
+---@alias uint integer
+
+---8-bit unsigned integer. Possible values are `0` to `255`.
+---
+---Since Lua 5.2 only uses doubles, any API that asks for `uint8` will floor the given double.
+---@alias uint8 integer
+
+---16-bit unsigned integer. Possible values are `0` to `65 535`.
+---
+---Since Lua 5.2 only uses doubles, any API that asks for `uint16` will floor the given double.
+---@alias uint16 integer
+
+---64-bit unsigned integer. Possible values are `0` to `18 446 744 073 709 551 615`.
+---
+---Since Lua 5.2 only uses doubles, any API that asks for `uint64` will floor the given double.
+---@alias uint64 integer
+
+---Any LuaObject listed on the [Classes](https://lua-api.factorio.com/2.0.45/classes.html) page.
+---@alias LuaObject any
+
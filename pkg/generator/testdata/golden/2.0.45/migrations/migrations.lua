@@ -0,0 +1,205 @@
+---@meta
+
+-- Auto-generated Factorio Runtime API definitions
+-- Generated from: fixtures/2.0.45/runtime-api.json
+
+-- The globals of migration scripts (migrations/*.lua), which run once when a save
+-- made with an older version of the mod is loaded. The runtime definitions don't
+-- declare globals in this context, so this library suits a LuaLS workspace of the
+-- migrations/ directory alone; control.lua uses one generated without --context.
+
+---The members of [LuaBootstrap](https://lua-api.factorio.com/2.0.45/classes/LuaBootstrap.html) available to migration scripts, which can't register event handlers.
+---@see LuaBootstrap
+---@class MigrationBootstrap
+---@field mod_name string The name of the mod from the environment this is used in. (Read-only)
+---@field level MigrationBootstrap.level_table Information about the currently running scenario/campaign/tutorial. (Read-only)
+---@field active_mods table<string, string> A dictionary listing the names of all currently active mods and mapping them to their version. (Read-only)
+---@field feature_flags MigrationBootstrap.feature_flags_table A dictionary of feature flags mapping to whether they are enabled. (Read-only)
+---@field object_name string The class name of this object. Available even when `valid` is false. For LuaStruct objects it may also be suffixed with a dotted path to a member of the struct. (Read-only)
+MigrationBootstrap = {}
+
+---@alias MigrationBootstrap.level_table LuaBootstrap.level_table
+
+---@alias MigrationBootstrap.feature_flags_table LuaBootstrap.feature_flags_table
+
+---Registers an object so that after it's destroyed, [on_object_destroyed](https://lua-api.factorio.com/2.0.45/events.html#on_object_destroyed) is called.
+---
+---Once an object is registered, it stays registered until it is actually destroyed, even through save/load cycles. The registration is global across all mods, meaning once one mod registers an object, all mods listening to [on_object_destroyed](https://lua-api.factorio.com/2.0.45/events.html#on_object_destroyed) will receive the event when it is destroyed. Registering the same object multiple times will still only fire the destruction event once, and will return the same registration number.
+---
+---Depending on when a given object is destroyed, [on_object_destroyed](https://lua-api.factorio.com/2.0.45/events.html#on_object_destroyed) will either be fired at the end of the current tick or at the end of the next tick.
+---@param object RegistrationTarget The object to register.
+---@return uint64 The registration number. It is used to identify the object in the [on_object_destroyed](https://lua-api.factorio.com/2.0.45/events.html#on_object_destroyed) event.
+---@return uint64 The [useful identifier](https://lua-api.factorio.com/2.0.45/concepts/RegistrationTarget.html) of the object if it has one. This identifier is specific to the object type, for example for trains it is the value [LuaTrain::id](https://lua-api.factorio.com/2.0.45/classes/LuaTrain.html#id).
+---@return defines.target_type Type of the target object.
+function MigrationBootstrap.register_on_object_destroyed(object) end
+
+---Register a metatable to have linkage recorded and restored when saving/loading.
+---
+---The metatable itself will not be saved. Instead, only the linkage to a registered metatable is saved, and the metatable registered under that name will be used when loading the table.
+---
+---`register_metatable()` can not be used in the console, in event listeners or during a `remote.call()`.
+---
+---The metatable first needs to be defined in the mod's root scope, then registered using this method. From then on, it will be properly restored for tables in [storage](https://lua-api.factorio.com/2.0.45/auxiliary/storage.html).
+---
+---```
+---local metatable =
+---{
+---  __index = function(key)
+---    return "no value for key " .. key
+---  end
+---}
+---script.register_metatable("my_metatable", metatable)
+---```
+---
+---This previously defined `metatable` can then be set on any table as usual:
+---
+---```
+---local table = {key="value"}
+---setmetatable(table, metatable)
+---```
+---@param name string The name of this metatable. Names must be unique per mod.
+---@param metatable table The metatable to register.
+function MigrationBootstrap.register_metatable(name, metatable) end
+
+---Generate a new, unique event ID that can be used to raise custom events with [LuaBootstrap::raise_event](https://lua-api.factorio.com/2.0.45/classes/LuaBootstrap.html#raise_event).
+---@return defines.events The newly generated event ID. This will be a new value that does not correspond to any named entry in defines.events.
+---@see LuaBootstrap.raise_event
+function MigrationBootstrap.generate_event_name() end
+
+---Converts LuaEventType into related value of defines.events. Value will be provided also if event was not given a constant inside of defines.events.
+---@param event LuaEventType 
+---@return defines.events 
+function MigrationBootstrap.get_event_id(event) end
+
+---Find the event handler for an event.
+---@param event LuaEventType The event identifier to get a handler for.
+---@return fun(arg1: EventData) | nil Reference to the function currently registered as the handler, if it was found.
+function MigrationBootstrap.get_event_handler(event) end
+
+---Gets the mod event order as a string.
+---@return string 
+function MigrationBootstrap.get_event_order() end
+
+---Gets the filters for the given event.
+---@param event LuaEventType ID of the event to get.
+---@return EventFilter | nil The filters or `nil` if none are defined.
+function MigrationBootstrap.get_event_filter(event) end
+
+---Raise an event. Only events generated with [LuaBootstrap::generate_event_name](https://lua-api.factorio.com/2.0.45/classes/LuaBootstrap.html#generate_event_name) and the following can be raised:
+---
+---```lua
+----- Raise the on_console_chat event with the desired message 'from' the first player
+---local data = {player_index = 1, message = "Hello friends!"}
+---script.raise_event(defines.events.on_console_chat, data)
+---```
+---@param event LuaEventType ID or name of the event to raise.
+---@param data table Table with extra data that will be passed to the event handler. Any invalid LuaObjects will silently stop the event from being raised.
+---@see LuaBootstrap.generate_event_name
+function MigrationBootstrap.raise_event(event, data) end
+
+---@alias MigrationBootstrap.raise_console_chat_param LuaBootstrap.raise_console_chat_param
+
+---@param param MigrationBootstrap.raise_console_chat_param
+function MigrationBootstrap.raise_console_chat(param) end
+
+---@alias MigrationBootstrap.raise_player_crafted_item_param LuaBootstrap.raise_player_crafted_item_param
+
+---@param param MigrationBootstrap.raise_player_crafted_item_param
+function MigrationBootstrap.raise_player_crafted_item(param) end
+
+---@alias MigrationBootstrap.raise_player_fast_transferred_param LuaBootstrap.raise_player_fast_transferred_param
+
+---@param param MigrationBootstrap.raise_player_fast_transferred_param
+function MigrationBootstrap.raise_player_fast_transferred(param) end
+
+---@alias MigrationBootstrap.raise_biter_base_built_param LuaBootstrap.raise_biter_base_built_param
+
+---@param param MigrationBootstrap.raise_biter_base_built_param
+function MigrationBootstrap.raise_biter_base_built(param) end
+
+---@alias MigrationBootstrap.raise_market_item_purchased_param LuaBootstrap.raise_market_item_purchased_param
+
+---@param param MigrationBootstrap.raise_market_item_purchased_param
+function MigrationBootstrap.raise_market_item_purchased(param) end
+
+---@alias MigrationBootstrap.raise_script_built_param LuaBootstrap.raise_biter_base_built_param
+
+---@param param MigrationBootstrap.raise_script_built_param
+function MigrationBootstrap.raise_script_built(param) end
+
+---@alias MigrationBootstrap.raise_script_destroy_param LuaBootstrap.raise_biter_base_built_param
+
+---@param param MigrationBootstrap.raise_script_destroy_param
+function MigrationBootstrap.raise_script_destroy(param) end
+
+---@alias MigrationBootstrap.raise_script_revive_param LuaBootstrap.raise_script_revive_param
+
+---@param param MigrationBootstrap.raise_script_revive_param
+function MigrationBootstrap.raise_script_revive(param) end
+
+---@alias MigrationBootstrap.raise_script_teleported_param LuaBootstrap.raise_script_teleported_param
+
+---@param param MigrationBootstrap.raise_script_teleported_param
+function MigrationBootstrap.raise_script_teleported(param) end
+
+---@alias MigrationBootstrap.raise_script_set_tiles_param LuaBootstrap.raise_script_set_tiles_param
+
+---@param param MigrationBootstrap.raise_script_set_tiles_param
+function MigrationBootstrap.raise_script_set_tiles(param) end
+
+
+---The main scripting interface through which most of the API is accessed.
+---@type LuaGameScript
+game = nil
+
+---Provides an interface for registering game event handlers.
+---@type MigrationBootstrap
+script = nil
+
+---Allows registration of custom commands for the in-game console.
+---@type LuaCommandProcessor
+commands = nil
+
+---Provides access to various helper and utility functions.
+---@type LuaHelpers
+helpers = nil
+
+---Allows read-only access to prototypes.
+---@type LuaPrototypes
+prototypes = nil
+
+---Allows printing messages to the calling RCON instance, if any.
+---@type LuaRCON
+rcon = nil
+
+---Allows registration and use of functions to communicate between mods.
+---@type LuaRemote
+remote = nil
+
+---Allows rendering of geometric shapes, text and sprites in the game world.
+---@type LuaRendering
+rendering = nil
+
+---Provides access to the current mod settings.
+---@type LuaSettings
+settings = nil
+
+-- The contents of `storage` are up to each mod. Type them by extending its class
+-- anywhere in your mod; LuaLS merges the fields of every declaration:
+--
+--   ---@class storage
+--   ---@field players table<integer, PlayerData>
+--   ---@field next_id integer
+--
+-- `global`, its name before Factorio 2.0, has the same class.
+
+---A table whose contents are saved and restored with the save file. Only data
+---(no functions or metatables other than registered ones) may be stored in it.
+---@class storage
+---@field [any] any
+storage = {}
+
+---Factorio 1.1's name for the persistent data table, renamed to `storage` in 2.0.
+---@deprecated
+---@type storage
+global = {}
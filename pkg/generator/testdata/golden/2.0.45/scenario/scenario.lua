@@ -0,0 +1,91 @@
+---@meta
+
+-- Auto-generated Factorio Runtime API definitions
+-- Generated from: fixtures/2.0.45/runtime-api.json
+
+-- The globals of a scenario's or tutorial's control.lua, and the event_handler
+-- library scenarios such as freeplay are built with. The runtime definitions don't
+-- declare globals in this context, so this library suits a LuaLS workspace of the
+-- scenario's directory; mods use one generated without --context.
+
+---A library of a scenario built with event_handler (see EventHandler): a table of the handlers it registers.
+---@class ScenarioLib
+---@field events? table<defines.events | string, fun(event: EventData)> Handlers by event id or custom input name.
+---@field on_nth_tick? table<uint, fun(event: NthTickEventData)> Handlers by tick period.
+---@field on_init? fun() Run when the game is created from the scenario.
+---@field on_load? fun() Run when a save of the scenario is loaded.
+---@field on_configuration_changed? fun(data: ConfigurationChangedData) Run when the game version or the mods of a save changed.
+---@field add_remote_interface? fun() Adds the remote interfaces of the library, before on_init and on_load.
+---@field add_commands? fun() Adds the console commands of the library, before on_init and on_load.
+
+---The event_handler library of the core mod, returned by require("event_handler"),
+---which registers the handlers of every library added to it. Annotate the
+---result of the require with `---@type EventHandler`.
+---@class EventHandler
+---@field add_lib fun(lib: ScenarioLib) Adds a library, whose handlers run after those of the libraries added before it.
+---@field add_libraries fun(libs: ScenarioLib[]) Adds each library in turn.
+
+---The surfaces of the game, by index or name. Every new game starts with nauvis; the scenario creates the others with [LuaGameScript::create_surface](https://lua-api.factorio.com/2.0.45/classes/LuaGameScript.html#create_surface).
+---@class ScenarioSurfaces
+---@field nauvis LuaSurface The surface the game starts on.
+---@field [uint | string] LuaSurface
+
+---[LuaGameScript](https://lua-api.factorio.com/2.0.45/classes/LuaGameScript.html), as seen by scenario scripts.
+---@class ScenarioGameScript : LuaGameScript
+---@field surfaces ScenarioSurfaces The surfaces of the game, by index or name. (Read-only)
+
+---The main scripting interface through which most of the API is accessed.
+---@type ScenarioGameScript
+game = nil
+
+---Provides an interface for registering game event handlers.
+---@type LuaBootstrap
+script = nil
+
+---Allows registration of custom commands for the in-game console.
+---@type LuaCommandProcessor
+commands = nil
+
+---Provides access to various helper and utility functions.
+---@type LuaHelpers
+helpers = nil
+
+---Allows read-only access to prototypes.
+---@type LuaPrototypes
+prototypes = nil
+
+---Allows printing messages to the calling RCON instance, if any.
+---@type LuaRCON
+rcon = nil
+
+---Allows registration and use of functions to communicate between mods.
+---@type LuaRemote
+remote = nil
+
+---Allows rendering of geometric shapes, text and sprites in the game world.
+---@type LuaRendering
+rendering = nil
+
+---Provides access to the current mod settings.
+---@type LuaSettings
+settings = nil
+
+-- The contents of `storage` are up to each mod. Type them by extending its class
+-- anywhere in your mod; LuaLS merges the fields of every declaration:
+--
+--   ---@class storage
+--   ---@field players table<integer, PlayerData>
+--   ---@field next_id integer
+--
+-- `global`, its name before Factorio 2.0, has the same class.
+
+---A table whose contents are saved and restored with the save file. Only data
+---(no functions or metatables other than registered ones) may be stored in it.
+---@class storage
+---@field [any] any
+storage = {}
+
+---Factorio 1.1's name for the persistent data table, renamed to `storage` in 2.0.
+---@deprecated
+---@type storage
+global = {}
@@ -0,0 +1,524 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+// tsSyntax spells types as TypeScript type expressions for TypeScriptToLua (TSTL).
+// Lua tables with non-string keys use TSTL's LuaTable, nil becomes undefined, and
+// Factorio's numeric types all become number.
+type tsSyntax struct{}
+
+func (tsSyntax) named(name string) string {
+	switch {
+	case integerBuiltins[name] || floatBuiltins[name] || name == "long" || name == "ulong":
+		return "number"
+	case name == "table":
+		return "LuaTable"
+	case name == "nil":
+		return "undefined"
+	case name == "object" || name == "builtin" || builtinAliasTargets[name] != "":
+		return "any"
+	default:
+		return name
+	}
+}
+
+func (tsSyntax) array(element string, elementType api.Type) string {
+	if elementType.IsUnion() || elementType.IsFunction() {
+		element = "(" + element + ")"
+	}
+	return element + "[]"
+}
+
+func (tsSyntax) dictionary(key string, value string) string {
+	// Record only takes string and number keys; anything else needs a LuaTable.
+	for _, member := range splitUnion(key) {
+		if member != "string" && member != "number" && !strings.HasPrefix(member, `"`) {
+			return fmt.Sprintf("LuaTable<%s, %s>", key, value)
+		}
+	}
+	return fmt.Sprintf("Record<%s, %s>", key, value)
+}
+
+func (tsSyntax) customTable(key string, value string) string {
+	return fmt.Sprintf("LuaCustomTable<%s, %s>", key, value)
+}
+
+func (s tsSyntax) key(t api.Type) string {
+	return translateType(t, s)
+}
+
+func (tsSyntax) union(members []string) string {
+	var kept []string
+	for _, member := range splitUnion(joinUnion(members...)) {
+		if member == "nil" {
+			member = "undefined"
+		}
+		kept = append(kept, member)
+	}
+	return strings.Join(kept, " | ")
+}
+
+func (tsSyntax) literal(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return jsString(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return "any"
+	}
+}
+
+func (tsSyntax) tuple(elements []string) string {
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+func (tsSyntax) function(params []string) string {
+	// Callbacks are called from Lua without a self argument.
+	named := []string{"this: void"}
+	for i, param := range params {
+		named = append(named, fmt.Sprintf("arg%d: %s", i+1, param))
+	}
+	return "(" + strings.Join(named, ", ") + ") => void"
+}
+
+// table spells an inline table type as an object type literal.
+func (tsSyntax) table(t api.Type) string {
+	params := slices.Clone(t.Fields)
+	for _, group := range t.VariantParameterGroups {
+		params = append(params, group.Parameters...)
+	}
+	var members []string
+	for _, member := range parameterMembers(params) {
+		name := tsPropertyName(member.name)
+		if member.optional {
+			name += "?"
+		}
+		members = append(members, name+": "+member.tsType)
+	}
+	return "{ " + strings.Join(members, "; ") + " }"
+}
+
+func (tsSyntax) any() string {
+	return "any"
+}
+
+// tsType translates an API type into a TypeScript type expression.
+func tsType(t api.Type) string {
+	return translateType(t, tsSyntax{})
+}
+
+// tsReservedWords can't be used as parameter names in TypeScript.
+var tsReservedWords = map[string]bool{
+	"break": true, "case": true, "catch": true, "class": true, "const": true, "continue": true,
+	"debugger": true, "default": true, "delete": true, "do": true, "else": true, "enum": true,
+	"export": true, "extends": true, "false": true, "finally": true, "for": true, "function": true,
+	"if": true, "import": true, "in": true, "instanceof": true, "new": true, "null": true,
+	"return": true, "super": true, "switch": true, "this": true, "throw": true, "true": true,
+	"try": true, "typeof": true, "var": true, "void": true, "while": true, "with": true,
+	"implements": true, "interface": true, "let": true, "package": true, "private": true,
+	"protected": true, "public": true, "static": true, "yield": true,
+}
+
+// tsParamName returns a parameter name that is valid in TypeScript.
+func tsParamName(name string) string {
+	if tsReservedWords[name] {
+		return name + "_"
+	}
+	return name
+}
+
+// tsPropertyName quotes property names that aren't identifiers, e.g. "assembling-machine".
+func tsPropertyName(name string) string {
+	if luaIdentifierPattern.MatchString(name) {
+		return name
+	}
+	return jsString(name)
+}
+
+// tsHeader turns a stage header of Lua comments into TypeScript comments.
+func tsHeader(header string) string {
+	var sb strings.Builder
+	for _, line := range strings.SplitAfter(header, "\n") {
+		if rest, ok := strings.CutPrefix(line, "--"); ok {
+			line = "//" + rest
+		}
+		sb.WriteString(line)
+	}
+	return sb.String()
+}
+
+// generateTypeScript generates TypeScript declaration files (runtime.d.ts and
+// prototype.d.ts) for mods written with TypeScriptToLua. They declare global
+// interfaces, so no imports are needed, and use TSTL's language extensions
+// (LuaTable, LuaMultiReturn). Either API may be nil when only one stage is generated.
+func (g *Generator) generateTypeScript(runtimeAPI *api.API, prototypeAPI *api.API, headers map[string]string) map[string]string {
+	const preamble = "/// <reference types=\"@typescript-to-lua/language-extensions\" />\n\n"
+	files := make(map[string]string)
+	declared := make(map[string]bool) // Concepts already declared by the runtime file
+	if runtimeAPI != nil {
+		var sb strings.Builder
+		sb.WriteString(tsHeader(headers["runtime"]))
+		sb.WriteString(preamble)
+		g.writeTSDefines(&sb, runtimeAPI.Defines)
+		g.writeTSConcepts(&sb, runtimeAPI.Concepts, declared)
+		for _, class := range sortedByOrder(runtimeAPI.Classes) {
+			g.writeTSClass(&sb, class)
+		}
+
+		// Event data derives from the EventData concept and is named after the event,
+		// e.g. EventData.on_tick, as in the LuaLS output. APIs that don't document
+		// the concept get the fallback.
+		g.writeTSConcepts(&sb, []api.Concept{eventDataConcept}, declared)
+		sb.WriteString("declare namespace EventData {\n")
+		for _, event := range sortedByOrder(runtimeAPI.Events) {
+			g.writeJSDoc(&sb, "  ", event.Description)
+			g.writeTSInterface(&sb, "  ", "interface "+event.Name+" extends EventData", parameterMembers(event.Data))
+		}
+		sb.WriteString("}\n\n")
+
+		for _, global := range runtimeGlobals(runtimeAPI) {
+			g.writeJSDoc(&sb, "", global.Description)
+			sb.WriteString(fmt.Sprintf("declare const %s: %s\n", global.Name, tsType(global.Type)))
+		}
+		sb.WriteString(fmt.Sprintf("declare const %s: Record<string, any>\n", persistentDataName(runtimeAPI)))
+		files["runtime.d.ts"] = sb.String()
+	}
+
+	if prototypeAPI != nil {
+		var sb strings.Builder
+		sb.WriteString(tsHeader(headers["prototype"]))
+		sb.WriteString(preamble)
+		if runtimeAPI == nil {
+			g.writeTSDefines(&sb, prototypeAPI.Defines)
+		}
+		concepts := append(slices.Clone(prototypeAPI.Concepts), prototypeAPI.Types...)
+		g.writeTSConcepts(&sb, concepts, declared)
+
+		// A few definitions share their name with a runtime concept (MapSettings);
+		// interfaces of the same name would merge, so they get a suffix.
+		names := make(map[string]string)
+		byName := make(map[string]api.Prototype)
+		for _, prototype := range prototypeAPI.Prototypes {
+			byName[prototype.Name] = prototype
+			names[prototype.Name] = prototype.Name
+			if declared[prototype.Name] {
+				names[prototype.Name] += "Prototype"
+			}
+		}
+		var concrete []string
+		for _, prototype := range sortedByOrder(prototypeAPI.Prototypes) {
+			declaration := "interface " + names[prototype.Name]
+			if parent, ok := names[prototype.Parent]; ok {
+				// The literal type of a concrete prototype conflicts with that of a
+				// concrete ancestor (AmmoItemPrototype's "ammo" with "item"), so it's
+				// left out of the inherited members.
+				if prototype.TypeName != "" && hasConcreteAncestor(byName, prototype) {
+					parent = fmt.Sprintf("Omit<%s, \"type\">", parent)
+				}
+				declaration += " extends " + parent
+			}
+			members := propertyMembers(prototype.Properties, true)
+			if prototype.TypeName != "" {
+				// The literal type discriminates the members of PrototypeUnion.
+				members = slices.DeleteFunc(members, func(m tsMember) bool { return m.name == "type" })
+				members = append([]tsMember{{name: "type", tsType: jsString(prototype.TypeName)}}, members...)
+				concrete = append(concrete, names[prototype.Name])
+			}
+			g.writeJSDoc(&sb, "", prototype.Description)
+			g.writeTSInterface(&sb, "", declaration, members)
+			sb.WriteString("\n")
+		}
+
+		sb.WriteString("/** Any prototype definition, discriminated by its `type` field. */\n")
+		sb.WriteString(fmt.Sprintf("type PrototypeUnion = %s\n\n", strings.Join(concrete, " | ")))
+		sb.WriteString("/** The table prototypes are defined in, available in the data stage. */\n")
+		sb.WriteString("declare const data: {\n")
+		sb.WriteString("  readonly raw: Record<string, Record<string, PrototypeUnion>>\n")
+		sb.WriteString("  readonly is_demo: boolean\n")
+		sb.WriteString("  extend(this: void, prototypes: PrototypeUnion[]): void\n")
+		sb.WriteString("}\n")
+		sb.WriteString("/** The active mods, mapped to their version. */\n")
+		sb.WriteString("declare const mods: Record<string, string>\n")
+		files["prototype.d.ts"] = sb.String()
+	}
+	return files
+}
+
+// hasConcreteAncestor reports whether any ancestor of a prototype has a typename.
+func hasConcreteAncestor(byName map[string]api.Prototype, prototype api.Prototype) bool {
+	for parent, ok := byName[prototype.Parent]; ok; parent, ok = byName[parent.Parent] {
+		if parent.TypeName != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// tsMember is a property of a TypeScript interface.
+type tsMember struct {
+	name        string
+	tsType      string
+	optional    bool
+	readonly    bool
+	description string
+}
+
+// parameterMembers returns the interface members of named parameters, in order.
+func parameterMembers(params []api.Parameter) []tsMember {
+	var members []tsMember
+	seen := make(map[string]bool)
+	for _, param := range sortedByOrder(params) {
+		if seen[param.Name] {
+			continue // Repeated by several variant groups
+		}
+		seen[param.Name] = true
+		tsType := tsType(param.Type)
+		if param.Nullable {
+			tsType = joinUnion(tsType, "undefined")
+		}
+		members = append(members, tsMember{name: param.Name, tsType: tsType, optional: param.Optional, description: param.Description})
+	}
+	return members
+}
+
+// propertyMembers returns the interface members of properties. Runtime attributes
+// that can't be written are readonly; prototype properties are always writable.
+func propertyMembers(properties []api.Property, writable bool) []tsMember {
+	var members []tsMember
+	for _, property := range sortedByOrder(properties) {
+		tsType := tsType(property.ValueType())
+		if property.Nullable {
+			tsType = joinUnion(tsType, "undefined")
+		}
+		members = append(members, tsMember{
+			name:        property.Name,
+			tsType:      tsType,
+			optional:    property.Optional,
+			readonly:    !writable && !property.IsWritable(),
+			description: property.Description,
+		})
+	}
+	return members
+}
+
+// writeTSInterface writes an interface declaration with the given members. The
+// declaration is everything before the opening brace, e.g. "interface A extends B".
+func (g *Generator) writeTSInterface(sb *strings.Builder, indent string, declaration string, members []tsMember) {
+	sb.WriteString(indent + declaration + " {\n")
+	g.writeTSMembers(sb, indent+"  ", members)
+	sb.WriteString(indent + "}\n")
+}
+
+func (g *Generator) writeTSMembers(sb *strings.Builder, indent string, members []tsMember) {
+	for _, member := range members {
+		g.writeJSDoc(sb, indent, member.description)
+		prefix := ""
+		if member.readonly {
+			prefix = "readonly "
+		}
+		optional := ""
+		if member.optional {
+			optional = "?"
+		}
+		sb.WriteString(fmt.Sprintf("%s%s%s%s: %s\n", indent, prefix, tsPropertyName(member.name), optional, member.tsType))
+	}
+}
+
+// writeJSDoc writes a description as a JSDoc comment, so editors show it on hover.
+func (g *Generator) writeJSDoc(sb *strings.Builder, indent string, description string) {
+	if description == "" {
+		return
+	}
+	sb.WriteString(indent + "/**\n")
+	for _, line := range strings.Split(g.renderer.render(description), "\n") {
+		line = strings.ReplaceAll(line, "*/", "*\\/")
+		sb.WriteString(strings.TrimRight(indent+" * "+line, " ") + "\n")
+	}
+	sb.WriteString(indent + " */\n")
+}
+
+// writeTSDefines writes the defines as enums in a `defines` namespace, with nested
+// namespaces for defines that have subkeys.
+func (g *Generator) writeTSDefines(sb *strings.Builder, defines []api.Define) {
+	sb.WriteString("declare namespace defines {\n")
+	for _, define := range sortedByOrder(defines) {
+		g.writeTSDefine(sb, "  ", define)
+	}
+	sb.WriteString("}\n\n")
+}
+
+func (g *Generator) writeTSDefine(sb *strings.Builder, indent string, define api.Define) {
+	g.writeJSDoc(sb, indent, define.Description)
+	if len(define.Values) > 0 || len(define.Subkeys) == 0 {
+		sb.WriteString(fmt.Sprintf("%senum %s {\n", indent, define.Name))
+		for _, value := range sortedByOrder(define.Values) {
+			g.writeJSDoc(sb, indent+"  ", value.Description)
+			switch value.Value.(type) {
+			case float64, string:
+				sb.WriteString(fmt.Sprintf("%s  %s = %s,\n", indent, tsPropertyName(value.Name), tsSyntax{}.literal(value.Value)))
+			default:
+				sb.WriteString(fmt.Sprintf("%s  %s,\n", indent, tsPropertyName(value.Name)))
+			}
+		}
+		sb.WriteString(indent + "}\n")
+	}
+	if len(define.Subkeys) > 0 {
+		// Namespaces merge with an enum of the same name.
+		sb.WriteString(fmt.Sprintf("%snamespace %s {\n", indent, define.Name))
+		for _, subDefine := range sortedByOrder(define.Subkeys) {
+			g.writeTSDefine(sb, indent+"  ", subDefine)
+		}
+		sb.WriteString(indent + "}\n")
+	}
+}
+
+// writeTSConcepts writes the concepts not declared yet: struct-shaped concepts as
+// interfaces and everything else as type aliases.
+func (g *Generator) writeTSConcepts(sb *strings.Builder, concepts []api.Concept, declared map[string]bool) {
+	for _, concept := range sortedByOrder(concepts) {
+		if isBuiltinConcept(concept) || declared[concept.Name] {
+			continue
+		}
+		declared[concept.Name] = true
+		g.writeJSDoc(sb, "", concept.Description)
+		structType, ok := structShape(concept.Type)
+		if !ok || isStringLiteralUnion(concept.Type) {
+			sb.WriteString(fmt.Sprintf("type %s = %s\n\n", concept.Name, tsType(concept.Type)))
+			continue
+		}
+		var members []tsMember
+		switch structType.ComplexType {
+		case "table":
+			params := slices.Clone(structType.Fields)
+			for _, group := range structType.VariantParameterGroups {
+				params = append(params, group.Parameters...)
+			}
+			members = parameterMembers(params)
+		case "LuaStruct":
+			members = propertyMembers(structType.Attributes, true)
+		default:
+			members = propertyMembers(concept.Properties, true)
+		}
+		// A struct that is one form of a union (MapPosition's {x, y} beside its
+		// tuple shorthand) gets its own interface, and the concept aliases the union.
+		interfaceName := concept.Name
+		if concept.Type.IsUnion() {
+			interfaceName += "Struct"
+		}
+		declaration := "interface " + interfaceName
+		if len(concept.Parent) > 0 {
+			declaration += " extends " + strings.Join(concept.Parent, ", ")
+		}
+		g.writeTSInterface(sb, "", declaration, members)
+		if concept.Type.IsUnion() {
+			var options []string
+			for _, option := range concept.Type.Values {
+				if isStructType(option) {
+					options = append(options, interfaceName)
+				} else {
+					options = append(options, tsType(option))
+				}
+			}
+			sb.WriteString(fmt.Sprintf("type %s = %s\n", concept.Name, tsSyntax{}.union(options)))
+		}
+		sb.WriteString("\n")
+	}
+}
+
+// writeTSClass writes a runtime class as an interface extending its parents, with
+// its attributes as properties and its methods as `this: void` methods, since
+// Factorio methods are called with dot syntax. Table-taking methods get an
+// interface for their argument in a namespace named after the class.
+func (g *Generator) writeTSClass(sb *strings.Builder, class api.Class) {
+	var paramInterfaces strings.Builder
+	for _, method := range sortedByOrder(class.Methods) {
+		if method.Format.TakesTable {
+			params := slices.Clone(method.Parameters)
+			for _, group := range method.VariantParameterGroups {
+				params = append(params, group.Parameters...)
+			}
+			g.writeTSInterface(&paramInterfaces, "  ", "interface "+method.Name+"_param", parameterMembers(params))
+		}
+	}
+	if paramInterfaces.Len() > 0 {
+		sb.WriteString(fmt.Sprintf("declare namespace %s {\n%s}\n", class.Name, paramInterfaces.String()))
+	}
+
+	g.writeJSDoc(sb, "", class.Description)
+	declaration := "interface " + class.Name
+	if _, isGeneric := genericClasses[class.Name]; isGeneric {
+		declaration += "<K extends AnyNotNil, V> extends LuaTable<K, V>"
+	} else if len(class.Parent) > 0 {
+		declaration += " extends " + strings.Join(class.Parent, ", ")
+	}
+	sb.WriteString(declaration + " {\n")
+	g.writeTSMembers(sb, "  ", propertyMembers(append(slices.Clone(class.Attributes), class.Properties...), false))
+	for _, method := range sortedByOrder(class.Methods) {
+		g.writeJSDoc(sb, "  ", method.Description)
+		sb.WriteString(fmt.Sprintf("  %s(%s): %s\n", tsPropertyName(method.Name), strings.Join(tsMethodParams(class.Name, method), ", "), tsMethodResult(method)))
+	}
+	sb.WriteString("}\n\n")
+}
+
+// tsMethodParams returns the parameter list of a method, starting with `this: void`.
+func tsMethodParams(className string, method api.Method) []string {
+	params := []string{"this: void"}
+	if method.Format.TakesTable {
+		optional := ""
+		if method.Format.TableOptional {
+			optional = "?"
+		}
+		params = append(params, fmt.Sprintf("params%s: %s.%s_param", optional, className, method.Name))
+	} else {
+		for _, param := range sortedByOrder(method.Parameters) {
+			optional := ""
+			if param.Optional {
+				optional = "?"
+			}
+			paramType := tsType(param.Type)
+			if param.Nullable {
+				paramType = joinUnion(paramType, "undefined")
+			}
+			params = append(params, fmt.Sprintf("%s%s: %s", tsParamName(param.Name), optional, paramType))
+		}
+	}
+	if method.VariadicParameter != nil {
+		element := tsType(method.VariadicParameter.Type)
+		if len(splitUnion(element)) > 1 {
+			element = "(" + element + ")"
+		}
+		params = append(params, "...args: "+element+"[]")
+	}
+	return params
+}
+
+// tsMethodResult returns the return type of a method; several return values use
+// TSTL's LuaMultiReturn.
+func tsMethodResult(method api.Method) string {
+	var results []string
+	for _, result := range sortedByOrder(method.ReturnValues) {
+		resultType := tsType(result.Type)
+		if result.Optional || result.Nullable {
+			resultType = joinUnion(resultType, "undefined")
+		}
+		results = append(results, resultType)
+	}
+	switch len(results) {
+	case 0:
+		return "void"
+	case 1:
+		return results[0]
+	default:
+		return "LuaMultiReturn<[" + strings.Join(results, ", ") + "]>"
+	}
+}
@@ -0,0 +1,119 @@
+package generator
+
+import "github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+
+// typeSyntax spells the type expressions of an output format. translateType walks
+// the API's types and hands every construct to the syntax, so all formats resolve
+// the types the same way and only differ in how they are written.
+type typeSyntax interface {
+	named(name string) string                          // A builtin, class or concept, by its API name
+	array(element string, elementType api.Type) string // elementType is the untranslated element
+	dictionary(key string, value string) string
+	customTable(key string, value string) string // LuaCustomTable, a dictionary-like class
+	key(t api.Type) string                       // The key type of a dictionary or custom table
+	union(members []string) string
+	literal(value interface{}) string
+	tuple(elements []string) string
+	function(params []string) string
+	table(t api.Type) string // An inline table of named fields, untranslated
+	any() string
+}
+
+// translateType translates an API type into the given syntax.
+func translateType(t api.Type, syntax typeSyntax) string {
+	// Handle simple types
+	if t.IsSimple() {
+		return syntax.named(t.Name)
+	}
+
+	// Handle complex types based on ComplexType field
+	switch t.ComplexType {
+	case "array":
+		if t.Value != nil {
+			return syntax.array(translateType(*t.Value, syntax), *t.Value)
+		}
+		return downgrade(syntax, t, syntax.named("table"), "array without an element type")
+
+	case "dictionary":
+		if t.Key != nil && t.Value != nil {
+			return syntax.dictionary(syntax.key(*t.Key), translateType(*t.Value, syntax))
+		}
+		return downgrade(syntax, t, syntax.named("table"), "dictionary without key or value type")
+
+	case "LuaCustomTable":
+		if t.Key != nil && t.Value != nil {
+			return syntax.customTable(syntax.key(*t.Key), translateType(*t.Value, syntax))
+		}
+		return downgrade(syntax, t, syntax.customTable(syntax.any(), syntax.any()), "LuaCustomTable without key or value type")
+
+	case "union":
+		if len(t.Values) > 0 {
+			var members []string
+			for _, optionType := range t.Values {
+				members = append(members, translateType(optionType, syntax))
+			}
+			return syntax.union(members)
+		}
+		return downgrade(syntax, t, syntax.any(), "union without options")
+
+	case "literal":
+		if t.LiteralValue != nil {
+			return syntax.literal(t.LiteralValue)
+		}
+		return downgrade(syntax, t, syntax.any(), "literal without a value")
+
+	case "type":
+		// This seems to be a wrapper around another type, possibly with a description.
+		// Just return the translation of the wrapped type.
+		if t.Value != nil {
+			return translateType(*t.Value, syntax)
+		}
+		return downgrade(syntax, t, syntax.any(), "type wrapper without a type")
+
+	case "LuaLazyLoadedValue":
+		// The LuaLazyLoadedValue class isn't generic, so the type its get() returns
+		// (under "value") can't be expressed.
+		return syntax.named("LuaLazyLoadedValue")
+
+	case "struct":
+		// 'struct' often appears as a complex_type for named concepts or types that
+		// are essentially tables/structs. If t.Name is present, it's likely a
+		// reference to a defined concept/type.
+		if t.Name != "" {
+			return syntax.named(t.Name)
+		}
+		return downgrade(syntax, t, syntax.named("table"), "anonymous struct")
+
+	case "table":
+		return syntax.table(t)
+
+	case "tuple":
+		if len(t.Values) > 0 {
+			var elements []string
+			for _, elementType := range t.Values {
+				elements = append(elements, translateType(elementType, syntax))
+			}
+			return syntax.tuple(elements)
+		}
+		return downgrade(syntax, t, syntax.named("table"), "tuple without elements")
+
+	case "function":
+		var params []string
+		for _, paramType := range t.Parameters {
+			params = append(params, translateType(paramType, syntax))
+		}
+		return syntax.function(params)
+
+	case "builtin":
+		// The {"complex_type":"builtin"} marker carries no name; the actual builtin
+		// types (like "boolean") are handled by the IsSimple() case.
+		return downgrade(syntax, t, syntax.any(), "builtin marker without a name")
+
+	default:
+		// If ComplexType is empty or unknown, it might be a simple type with just a Name.
+		if t.Name != "" {
+			return syntax.named(t.Name) // Assume it's a reference to a defined type/concept
+		}
+		return downgrade(syntax, t, syntax.any(), "untranslated complex type "+t.ComplexType) // Parsing issues or a newer format
+	}
+}
@@ -0,0 +1,59 @@
+package generator
+
+import "strings"
+
+// splitUnion splits a LuaLS type into its top-level union members, so that
+// "A | (B | C)[] | nil" yields "A", "(B | C)[]" and "nil". Separators nested in
+// brackets, generics, function signatures or string literals are left alone.
+func splitUnion(luaLSType string) []string {
+	var members []string
+	depth := 0
+	inString := false
+	start := 0
+	for i := 0; i < len(luaLSType); i++ {
+		switch c := luaLSType[i]; {
+		case inString:
+			if c == '\\' {
+				i++ // Skip the escaped character
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '(' || c == '[' || c == '{' || c == '<':
+			depth++
+		case c == ')' || c == ']' || c == '}' || c == '>':
+			depth--
+		case c == '|' && depth == 0:
+			members = append(members, strings.TrimSpace(luaLSType[start:i]))
+			start = i + 1
+		}
+	}
+	return append(members, strings.TrimSpace(luaLSType[start:]))
+}
+
+// joinUnion joins LuaLS types into a single normalized union: nested unions are
+// flattened, duplicate members dropped (keeping the first occurrence), and nil,
+// if present, is moved to the end, where readers and LuaLS hovers expect it.
+func joinUnion(types ...string) string {
+	var members []string
+	seen := make(map[string]bool)
+	hasNil := false
+	for _, luaLSType := range types {
+		for _, member := range splitUnion(luaLSType) {
+			if member == "" || seen[member] {
+				continue
+			}
+			seen[member] = true
+			if member == "nil" {
+				hasNil = true
+				continue
+			}
+			members = append(members, member)
+		}
+	}
+	if hasNil {
+		members = append(members, "nil")
+	}
+	return strings.Join(members, " | ")
+}
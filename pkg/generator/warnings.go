@@ -0,0 +1,153 @@
+package generator
+
+import (
+	"encoding/json"
+	"slices"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+// TypeWarningKind classifies a TypeWarning.
+type TypeWarningKind string
+
+const (
+	// TypeUnresolved is a reference to a name no definition declares, such as an
+	// undocumented type or one left out with --exclude.
+	TypeUnresolved TypeWarningKind = "unresolved"
+	// TypeDowngraded is a type the definitions can't express, written as any or
+	// table instead.
+	TypeDowngraded TypeWarningKind = "downgraded"
+)
+
+// TypeWarning is a type of the LuaLS definitions that is less precise than the
+// API documents it.
+type TypeWarning struct {
+	Kind   TypeWarningKind `json:"kind"`
+	Where  string          `json:"where"`  // The definition being generated, e.g. "class LuaSurface"
+	Type   json.RawMessage `json:"type"`   // The API type, as documented
+	Output string          `json:"output"` // What it was written as
+	Reason string          `json:"reason"`
+}
+
+// TypeReport summarizes the types of the LuaLS definitions generated by the last
+// GenerateDefinitions call, so the share of imprecise types can be measured.
+type TypeReport struct {
+	Types      int           `json:"types"`      // Types translated, counting each annotation once
+	Unresolved int           `json:"unresolved"` // Warnings of kind TypeUnresolved
+	Downgraded int           `json:"downgraded"` // Warnings of kind TypeDowngraded
+	AnyRate    float64       `json:"any_rate"`   // Downgraded types per translated type
+	Warnings   []TypeWarning `json:"warnings"`
+}
+
+// typeRecorder is implemented by the type syntaxes recording the types they
+// can't write precisely; the LuaLS syntax reports them in the TypeReport.
+type typeRecorder interface {
+	downgraded(t api.Type, output string, reason string)
+}
+
+// downgrade returns the output a type falls back to, recording it when the
+// syntax keeps a record.
+func downgrade(syntax typeSyntax, t api.Type, output string, reason string) string {
+	if recorder, ok := syntax.(typeRecorder); ok {
+		recorder.downgraded(t, output, reason)
+	}
+	return output
+}
+
+// typeLog collects the warnings of a GenerateDefinitions call.
+type typeLog struct {
+	known    map[string]bool // Every name a definition is generated for
+	where    string          // Set as each top-level definition is generated
+	types    int
+	warnings []TypeWarning
+}
+
+// newTypeLog indexes the names documented by both APIs, either of which may be nil.
+func newTypeLog(runtimeAPI *api.API, prototypeAPI *api.API) *typeLog {
+	l := &typeLog{known: make(map[string]bool)}
+	for name := range nativeBuiltins {
+		l.known[name] = true
+	}
+	for _, a := range []*api.API{runtimeAPI, prototypeAPI} {
+		if a == nil {
+			continue
+		}
+		for _, class := range a.Classes {
+			l.known[class.Name] = true
+		}
+		for _, concept := range append(slices.Clone(a.Concepts), a.Types...) {
+			l.known[concept.Name] = true
+		}
+		for _, builtin := range a.BuiltinTypes {
+			l.known[builtin.Name] = true
+		}
+		for _, prototype := range a.Prototypes {
+			l.known[prototype.Name] = true
+		}
+		l.addDefines("defines.", a.Defines)
+	}
+	return l
+}
+
+func (l *typeLog) addDefines(prefix string, defines []api.Define) {
+	for _, define := range defines {
+		l.known[prefix+define.Name] = true
+		l.addDefines(prefix+define.Name+".", define.Subkeys)
+	}
+}
+
+// at sets the definition the following warnings belong to.
+func (l *typeLog) at(where string) {
+	if l != nil {
+		l.where = where
+	}
+}
+
+func (l *typeLog) add(kind TypeWarningKind, t api.Type, output string, reason string) {
+	if l == nil {
+		return
+	}
+	documented, _ := json.Marshal(t)
+	l.warnings = append(l.warnings, TypeWarning{Kind: kind, Where: l.where, Type: documented, Output: output, Reason: reason})
+}
+
+// fork returns an empty log for a worker, see generateConcurrently.
+func (l *typeLog) fork() *typeLog {
+	if l == nil {
+		return nil
+	}
+	return &typeLog{known: l.known}
+}
+
+// merge appends the records of a worker's log. The worker's definition stays
+// current, as if it had been generated by this log.
+func (l *typeLog) merge(other *typeLog) {
+	if l == nil || other == nil {
+		return
+	}
+	l.where = other.where
+	l.types += other.types
+	l.warnings = append(l.warnings, other.warnings...)
+}
+
+// report summarizes the log.
+func (l *typeLog) report() TypeReport {
+	report := TypeReport{Types: l.types, Warnings: append([]TypeWarning{}, l.warnings...)}
+	for _, warning := range report.Warnings {
+		if warning.Kind == TypeUnresolved {
+			report.Unresolved++
+		} else {
+			report.Downgraded++
+		}
+	}
+	if report.Types > 0 {
+		report.AnyRate = float64(report.Downgraded) / float64(report.Types)
+	}
+	return report
+}
+
+// TypeReport returns the imprecise types of the LuaLS definitions generated by
+// the last GenerateDefinitions call. It is empty when no LuaLS output was requested.
+func (g *Generator) TypeReport() TypeReport {
+	return g.typeReport
+}
@@ -0,0 +1,119 @@
+package lsp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// codeMask returns text with its comments and string literals blanked out,
+// keeping the offsets and newlines, so what is searched in it is code.
+func codeMask(text string) []byte {
+	mask := []byte(text)
+	blank := func(from, to int) {
+		for i := from; i < to; i++ {
+			if mask[i] != '\n' {
+				mask[i] = ' '
+			}
+		}
+	}
+	for i := 0; i < len(text); {
+		switch {
+		case strings.HasPrefix(text[i:], "--"):
+			end := longBracketEnd(text, i+2)
+			if end < 0 {
+				end = strings.IndexByte(text[i:], '\n')
+				if end < 0 {
+					end = len(text)
+				} else {
+					end += i
+				}
+			}
+			blank(i, end)
+			i = end
+		case text[i] == '"' || text[i] == '\'':
+			end := i + 1
+			for end < len(text) && text[end] != text[i] && text[end] != '\n' {
+				if text[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(text))
+			blank(i, end)
+			i = end
+		case text[i] == '[':
+			end := longBracketEnd(text, i)
+			if end < 0 {
+				i++
+				continue
+			}
+			blank(i, end)
+			i = end
+		default:
+			i++
+		}
+	}
+	return mask
+}
+
+// longBracketEnd returns the offset after the long bracket opening at i, e.g.
+// [==[ ... ]==], the end of text if it isn't closed, or -1 if none opens there.
+func longBracketEnd(text string, i int) int {
+	if i >= len(text) || text[i] != '[' {
+		return -1
+	}
+	level := 0
+	for i+1+level < len(text) && text[i+1+level] == '=' {
+		level++
+	}
+	if i+1+level >= len(text) || text[i+1+level] != '[' {
+		return -1
+	}
+	closing := "]" + strings.Repeat("=", level) + "]"
+	end := strings.Index(text[i+2+level:], closing)
+	if end < 0 {
+		return len(text)
+	}
+	return i + 2 + level + end + len(closing)
+}
+
+// readOnlyAssignments reports the assignments to read-only attributes of runtime
+// classes, such as `game.player.name = "x"`, which fail when the mod runs. Like
+// the other requests, only what the API tells is known: `entity.name = "x"` of a
+// local entity isn't reported, nor are the targets of a multiple assignment
+// before its last.
+func (x *index) readOnlyAssignments(text string) []diagnostic {
+	mask := codeMask(text)
+	var diagnostics []diagnostic
+	for i, c := range mask {
+		// A single '=', not part of ==, ~=, <= or >=.
+		if c != '=' || i+1 < len(mask) && mask[i+1] == '=' || i > 0 && strings.IndexByte("=~<>", mask[i-1]) >= 0 {
+			continue
+		}
+		end := i
+		for end > 0 && (mask[end-1] == ' ' || mask[end-1] == '\t') {
+			end--
+		}
+		chain := parseChain(text, end)
+		if len(chain) < 2 {
+			continue
+		}
+		_, e := x.resolve(chain)
+		if e == nil || e.readOnly == "" {
+			continue
+		}
+		// The attribute's name, or its index, e.g. ["name"].
+		start := end - len(chain[len(chain)-1].name)
+		if chain[len(chain)-1].kind == '[' {
+			start = matchingOpen(text, end-1)
+		}
+		diagnostics = append(diagnostics, diagnostic{
+			Range:    lspRange{Start: positionAt(text, start), End: positionAt(text, end)},
+			Severity: severityError,
+			Code:     "read-only",
+			Source:   "factorio-api-gen",
+			Message:  fmt.Sprintf("%s is read-only", e.readOnly),
+		})
+	}
+	return diagnostics
+}
@@ -0,0 +1,65 @@
+package lsp
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+func TestCodeMask(t *testing.T) {
+	tests := []struct{ text, want string }{
+		{`a = "b = c"`, `a =        `},
+		{`a = 'it\'s'`, `a =        `},
+		{"a -- b = c\nd", "a         \nd"},
+		{"--[[ a = b\nc = d ]] e", "          \n         e"},
+		{"a = [==[ ]] = ]==] b", "a =                b"},
+		{"t[i] = x", "t[i] = x"},
+	}
+	for _, test := range tests {
+		if got := string(codeMask(test.text)); got != test.want {
+			t.Errorf("codeMask(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestReadOnlyAssignments(t *testing.T) {
+	str := api.Type{Name: "string"}
+	runtimeAPI := &api.API{
+		Classes: []api.Class{{BasicMember: api.BasicMember{Name: "LuaPlayer"}, Attributes: []api.Property{
+			{BasicMember: api.BasicMember{Name: "name"}, ReadType: &str},
+			{BasicMember: api.BasicMember{Name: "tag"}, ReadType: &str, WriteType: &str},
+		}}},
+		GlobalObjects: []api.GlobalObject{{BasicMember: api.BasicMember{Name: "player"}, Type: api.Type{Name: "LuaPlayer"}}},
+	}
+	x := newIndex(runtimeAPI, nil)
+
+	tests := []struct {
+		text string
+		want []string // The ranges reported, as "line:character-line:character"
+	}{
+		{`player.name = "x"`, []string{"0:7-0:11"}},
+		{`player["name"]="x"`, []string{"0:6-0:14"}},
+		{"local 𝔰 = 1\n  player.name\t= 𝔰", []string{"1:9-1:13"}},
+		{`player.tag = "x"`, nil},   // Writable
+		{`player.name == "x"`, nil}, // A comparison
+		{`if player.name ~= "x" then end`, nil},
+		{`local name = player.name`, nil},
+		{`-- player.name = "x"`, nil}, // A comment
+		{`print("player.name = x")`, nil},
+		{`entity.name = "x"`, nil}, // Unknown
+	}
+	for _, test := range tests {
+		var got []string
+		for _, d := range x.readOnlyAssignments(test.text) {
+			got = append(got, fmt.Sprintf("%d:%d-%d:%d", d.Range.Start.Line, d.Range.Start.Character, d.Range.End.Line, d.Range.End.Character))
+			if d.Message != "LuaPlayer.name is read-only" {
+				t.Errorf("readOnlyAssignments(%q) message = %q", test.text, d.Message)
+			}
+		}
+		if strings.Join(got, " ") != strings.Join(test.want, " ") {
+			t.Errorf("readOnlyAssignments(%q) = %v, want %v", test.text, got, test.want)
+		}
+	}
+}
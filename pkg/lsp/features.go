@@ -0,0 +1,259 @@
+package lsp
+
+import (
+	"regexp"
+	"strings"
+)
+
+// segment is a step of an expression such as game.players[1].surface: a name
+// accessed with '.' or ':', an index ('[', with name set for a string key) or a
+// call ('('). The first segment is a name, with kind 0.
+type segment struct {
+	kind byte
+	name string
+}
+
+func isIdentifierByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// identifierAt returns the bounds of the identifier at or just before offset.
+func identifierAt(text string, offset int) (int, int) {
+	start, end := offset, offset
+	for start > 0 && isIdentifierByte(text[start-1]) {
+		start--
+	}
+	for end < len(text) && isIdentifierByte(text[end]) {
+		end++
+	}
+	return start, end
+}
+
+// parseChain parses the expression ending at end, scanning backwards over names,
+// separators and balanced brackets. It returns nil if no expression ends there.
+func parseChain(text string, end int) []segment {
+	var chain []segment
+	i := end
+	for {
+		if i > 0 && (text[i-1] == ']' || text[i-1] == ')') {
+			open := matchingOpen(text, i-1)
+			if open < 0 {
+				return nil
+			}
+			seg := segment{kind: text[open]}
+			if seg.kind == '[' {
+				seg.name = stringKey(text[open+1 : i-1])
+			}
+			chain = append(chain, seg)
+			i = open
+			continue
+		}
+		start := i
+		for start > 0 && isIdentifierByte(text[start-1]) {
+			start--
+		}
+		if start == i || text[start] >= '0' && text[start] <= '9' {
+			return nil
+		}
+		chain = append(chain, segment{name: text[start:i]})
+		i = start
+		// A separator continues the expression, but ".." concatenates.
+		if i > 0 && (text[i-1] == '.' || text[i-1] == ':') && (i < 2 || text[i-2] != '.') {
+			chain[len(chain)-1].kind = text[i-1]
+			i--
+			continue
+		}
+		break
+	}
+	if chain[len(chain)-1].kind != 0 {
+		return nil // Started with an index or a call
+	}
+	for l, r := 0, len(chain)-1; l < r; l, r = l+1, r-1 {
+		chain[l], chain[r] = chain[r], chain[l]
+	}
+	return chain
+}
+
+// matchingOpen returns the offset of the bracket opening the one at close, or -1.
+func matchingOpen(text string, close int) int {
+	depth := 0
+	// Don't scan whole files for a stray bracket.
+	for i := close; i >= 0 && i >= close-4096; i-- {
+		switch text[i] {
+		case ')', ']', '}':
+			depth++
+		case '(', '[', '{':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// stringKey returns the string a key is, if it is a string literal.
+func stringKey(key string) string {
+	key = strings.TrimSpace(key)
+	if len(key) >= 2 && (key[0] == '"' || key[0] == '\'') && key[len(key)-1] == key[0] {
+		return key[1 : len(key)-1]
+	}
+	return ""
+}
+
+// hover returns the hover of the name at offset.
+func (x *index) hover(text string, offset int) *hover {
+	start, end := identifierAt(text, offset)
+	if start == end {
+		return nil
+	}
+	_, e := x.resolve(parseChain(text, end))
+	if e == nil {
+		return nil
+	}
+	return &hover{Contents: *markdown(e.hover())}
+}
+
+// completion completes the name being typed at offset: a member after '.' or
+// ':', a type name in an annotation, or a global name.
+func (x *index) completion(text string, offset int) *completionList {
+	start, _ := identifierAt(text, offset)
+	var entries []entry
+	switch {
+	case start > 0 && (text[start-1] == '.' || text[start-1] == ':') && (start < 2 || text[start-2] != '.'):
+		v, _ := x.resolve(parseChain(text, start-1))
+		entries = x.entries(v)
+	case inAnnotation(text, start):
+		entries = x.typeEntries()
+	default:
+		entries = x.globalEntries()
+	}
+	list := &completionList{Items: []completionItem{}}
+	for _, e := range entries {
+		list.Items = append(list.Items, completionItem{
+			Label:         e.name,
+			Kind:          e.kind,
+			Detail:        e.detail,
+			Documentation: markdown(e.doc),
+			Deprecated:    e.deprecated,
+		})
+	}
+	return list
+}
+
+// inAnnotation reports whether offset is in a "---@" annotation comment.
+func inAnnotation(text string, offset int) bool {
+	line := text[strings.LastIndexByte(text[:offset], '\n')+1 : offset]
+	return strings.HasPrefix(strings.TrimSpace(line), "---@")
+}
+
+// fieldName matches the name of a named argument being given, e.g. "force = ".
+var fieldName = regexp.MustCompile(`^\s*([A-Za-z_][A-Za-z0-9_]*)\s*(=)?`)
+
+// signatureHelp returns the signature of the method called around offset, with
+// the parameter being given highlighted. For methods taking a table of named
+// arguments, it is the one whose name is typed.
+func (x *index) signatureHelp(text string, offset int) *signatureHelp {
+	callee, commas, field, inTable := openCall(text, offset)
+	if callee < 0 {
+		return nil
+	}
+	v, _ := x.resolve(parseChain(text, callee))
+	if v.kind != valueMethod {
+		return nil
+	}
+	method := v.method
+	labels := x.parameters(method)
+	info := signatureInformation{
+		Label:         x.signature(v.name, method),
+		Documentation: markdown(x.describe(method.Description)),
+		Parameters:    []parameterInformation{},
+	}
+	params := sortedByOrder(method.Parameters)
+	for i, label := range labels {
+		param := parameterInformation{Label: label}
+		if i < len(params) {
+			param.Documentation = markdown(x.describe(params[i].Description))
+		} else if method.VariadicParameter != nil {
+			param.Documentation = markdown(x.describe(method.VariadicParameter.Description))
+		}
+		info.Parameters = append(info.Parameters, param)
+	}
+
+	active := 0
+	if method.Format.TakesTable {
+		// The named argument typed, or the first one its name starts. Out of
+		// range highlights none.
+		if m := fieldName.FindStringSubmatch(field); inTable {
+			active = len(labels)
+			for i, param := range params {
+				if m != nil && (param.Name == m[1] || active == len(labels) && m[2] == "" && strings.HasPrefix(param.Name, m[1])) {
+					active = i
+				}
+			}
+		}
+	} else {
+		active = commas
+		if active >= len(labels) && method.VariadicParameter != nil {
+			active = len(labels) - 1
+		}
+	}
+	return &signatureHelp{Signatures: []signatureInformation{info}, ActiveParameter: active}
+}
+
+// openCall finds the call around offset. It returns the offset where the callee
+// ends, or -1 outside of calls, and the number of arguments before the one being
+// given. Calls with a table of named arguments, f{...} or f({...}), are reported
+// with inTable set and the text of the current field instead.
+func openCall(text string, offset int) (callee int, commas int, field string, inTable bool) {
+	depth := 0
+	fieldStart := offset
+	for i := offset - 1; i >= 0 && i >= offset-4096; i-- {
+		switch text[i] {
+		case ')', ']', '}':
+			depth++
+		case ',':
+			if depth == 0 {
+				if !inTable && commas == 0 {
+					fieldStart = i + 1
+				}
+				commas++
+			}
+		case '[':
+			if depth == 0 {
+				return -1, 0, "", false
+			}
+			depth--
+		case '{':
+			if depth > 0 {
+				depth--
+				continue
+			}
+			before := strings.TrimRight(text[:i], " \t\r\n")
+			if inTable || before == "" {
+				return -1, 0, "", false
+			}
+			if commas == 0 {
+				fieldStart = i + 1
+			}
+			field, inTable, commas = text[fieldStart:offset], true, 0
+			if last := before[len(before)-1]; last != '(' {
+				if !isIdentifierByte(last) {
+					return -1, 0, "", false
+				}
+				return len(before), 0, field, true // f{...}
+			}
+			i = len(before) // Continue with the parenthesis
+		case '(':
+			if depth > 0 {
+				depth--
+				continue
+			}
+			if inTable && commas > 0 {
+				return -1, 0, "", false // The table isn't the first argument
+			}
+			return len(strings.TrimRight(text[:i], " \t")), commas, field, inTable
+		}
+	}
+	return -1, 0, "", false
+}
@@ -0,0 +1,529 @@
+package lsp
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/generator"
+)
+
+// index looks up the API by name for the requests of the server. Both stages are
+// indexed; names documented by both resolve to the runtime definition.
+type index struct {
+	classes    map[string]api.Class
+	concepts   map[string]api.Concept
+	globals    []api.GlobalObject
+	events     map[string]api.Event
+	defines    map[string]api.Define    // By full name, e.g. "defines.events"
+	prototypes map[string]api.Prototype // By name, e.g. "ItemPrototype"
+	typeNames  map[string]api.Prototype // By typename, e.g. "item"
+	dataStage  bool                     // Whether the prototype API was loaded
+	types      *generator.Generator     // Spells types as the definitions do
+	describe   func(description string) string
+}
+
+func newIndex(runtimeAPI *api.API, prototypeAPI *api.API) *index {
+	x := &index{
+		classes:    make(map[string]api.Class),
+		concepts:   make(map[string]api.Concept),
+		events:     make(map[string]api.Event),
+		defines:    make(map[string]api.Define),
+		prototypes: make(map[string]api.Prototype),
+		typeNames:  make(map[string]api.Prototype),
+		dataStage:  prototypeAPI != nil,
+		types:      generator.NewGenerator(generator.DefaultOptions()),
+		describe:   generator.DescriptionRenderer(runtimeAPI, prototypeAPI),
+	}
+	root := api.Define{BasicMember: api.BasicMember{Name: "defines", Description: "Constants used throughout the API, such as `defines.direction.north`."}}
+	// The prototype stage goes first, so the runtime stage wins shared names.
+	for _, a := range []*api.API{prototypeAPI, runtimeAPI} {
+		if a == nil {
+			continue
+		}
+		for _, class := range a.Classes {
+			x.classes[class.Name] = class
+		}
+		for _, concept := range append(slices.Clone(a.Concepts), a.Types...) {
+			x.concepts[concept.Name] = concept
+		}
+		for _, event := range a.Events {
+			x.events[event.Name] = event
+		}
+		for _, prototype := range a.Prototypes {
+			x.prototypes[prototype.Name] = prototype
+			if prototype.TypeName != "" {
+				x.typeNames[prototype.TypeName] = prototype
+			}
+		}
+		if len(a.Defines) > 0 {
+			root.Subkeys = a.Defines
+		}
+	}
+	x.defines["defines"] = root
+	x.addDefines("defines", root.Subkeys)
+	if runtimeAPI != nil {
+		x.globals = runtimeAPI.GlobalObjects
+	}
+	return x
+}
+
+func (x *index) addDefines(prefix string, defines []api.Define) {
+	for _, define := range defines {
+		x.defines[prefix+"."+define.Name] = define
+		x.addDefines(prefix+"."+define.Name, define.Subkeys)
+	}
+}
+
+// valueKind classifies values.
+type valueKind int
+
+const (
+	valueUnknown   valueKind = iota
+	valueInstance            // An instance of the runtime class in name
+	valuePrototype           // The data of the prototype in name, e.g. "ItemPrototype"
+	valueConcept             // A table of the concept in name
+	valueDefine              // The defines table in name, e.g. "defines.events"
+	valueMethod              // The method in method, of the class in name
+	valueData                // The data stage's `data` global
+	valueRaw                 // data.raw
+	valueRawType             // The data.raw table of the typename in name
+	valueOther               // A value of type typ
+)
+
+// value is what an expression evaluates to, as far as the API tells.
+type value struct {
+	kind   valueKind
+	name   string
+	method api.Method
+	typ    api.Type
+}
+
+// entry is a name an expression can continue with, e.g. a member of a class, with
+// what hovers and completion show for it.
+type entry struct {
+	name       string
+	kind       int    // Completion item kind
+	signature  string // Shown as code, e.g. "(field) LuaEntity.name: string"
+	detail     string // The type or signature, shown next to completion items
+	doc        string // Markdown
+	deprecated bool
+	readOnly   string // For an attribute that can't be assigned, its name, e.g. "LuaEntity.name"
+	value      value  // What the name evaluates to
+}
+
+// hover returns the Markdown hover of an entry.
+func (e entry) hover() string {
+	text := "```lua\n" + e.signature + "\n```"
+	if e.doc != "" {
+		text += "\n\n" + e.doc
+	}
+	return text
+}
+
+// resolve evaluates an expression. The last segment's entry is returned with
+// it, or nil when the expression has a single segment that isn't a known name.
+func (x *index) resolve(chain []segment) (value, *entry) {
+	if len(chain) == 0 {
+		return value{}, nil
+	}
+	e := x.find(x.globalEntries(), chain[0].name)
+	if e == nil {
+		// Type names, e.g. in annotations.
+		e = x.find(x.typeEntries(), chain[0].name)
+	}
+	if e == nil {
+		return value{}, nil
+	}
+	v := e.value
+	for _, seg := range chain[1:] {
+		switch seg.kind {
+		case '[':
+			// A string key names a member, e.g. data.raw["item"].
+			if member := x.find(x.entries(v), seg.name); seg.name != "" && member != nil {
+				v, e = member.value, member
+				continue
+			}
+			v, e = x.index(v), nil
+		case '(':
+			v, e = x.call(v), nil
+		default:
+			e = x.find(x.entries(v), seg.name)
+			if e == nil {
+				// t.name is t["name"], e.g. game.surfaces.nauvis.
+				v = x.index(v)
+				if v.kind == valueUnknown {
+					return value{}, nil
+				}
+				continue
+			}
+			v = e.value
+		}
+	}
+	return v, e
+}
+
+func (x *index) find(entries []entry, name string) *entry {
+	for i := range entries {
+		if entries[i].name == name {
+			return &entries[i]
+		}
+	}
+	return nil
+}
+
+// globalEntries are the names available everywhere: the global objects, defines
+// and, with the prototype API, data.
+func (x *index) globalEntries() []entry {
+	var entries []entry
+	for _, global := range x.globals {
+		luaType := x.types.LuaLSType(global.Type)
+		entries = append(entries, entry{
+			name:      global.Name,
+			kind:      kindVariable,
+			signature: fmt.Sprintf("(global) %s: %s", global.Name, luaType),
+			detail:    luaType,
+			doc:       x.describe(global.Description),
+			value:     x.resolveType(global.Type),
+		})
+	}
+	root := x.defines["defines"]
+	entries = append(entries, entry{name: "defines", kind: kindModule, signature: "(global) defines", doc: x.describe(root.Description), value: value{kind: valueDefine, name: "defines"}})
+	if x.dataStage {
+		entries = append(entries, entry{
+			name:      "data",
+			kind:      kindVariable,
+			signature: "(global) data",
+			doc:       "The data stage's prototype definitions: `data.raw` holds every prototype by type and name, and `data:extend` adds new ones.",
+			value:     value{kind: valueData},
+		})
+	}
+	return entries
+}
+
+// typeEntries are the names of the classes, concepts and prototypes, as used
+// in annotations.
+func (x *index) typeEntries() []entry {
+	var entries []entry
+	for _, name := range sortedKeys(x.classes) {
+		class := x.classes[name]
+		entries = append(entries, entry{name: name, kind: kindClass, signature: "(class) " + name, doc: x.describe(class.Description), deprecated: class.Deprecated, value: value{kind: valueInstance, name: name}})
+	}
+	for _, name := range sortedKeys(x.concepts) {
+		concept := x.concepts[name]
+		if _, isClass := x.classes[name]; isClass {
+			continue
+		}
+		entries = append(entries, entry{name: name, kind: kindClass, signature: "(concept) " + name, doc: x.describe(concept.Description), deprecated: concept.Deprecated, value: x.resolveType(api.Type{Name: name})})
+	}
+	for _, name := range sortedKeys(x.prototypes) {
+		prototype := x.prototypes[name]
+		if _, isClass := x.classes[name]; isClass {
+			continue
+		}
+		entries = append(entries, entry{name: name, kind: kindClass, signature: "(prototype) " + name, doc: x.describe(prototype.Description), deprecated: prototype.Deprecated, value: value{kind: valuePrototype, name: name}})
+	}
+	return entries
+}
+
+// entries returns the names a value can be indexed with by name: the members of
+// a class, the fields of a table, the contents of a defines table, ...
+func (x *index) entries(v value) []entry {
+	var entries []entry
+	switch v.kind {
+	case valueInstance:
+		seen := make(map[string]bool)
+		for _, class := range x.classChain(v.name) {
+			for _, attribute := range sortedByOrder(class.Attributes) {
+				if !seen[attribute.Name] {
+					seen[attribute.Name] = true
+					entries = append(entries, x.propertyEntry(class.Name, attribute))
+				}
+			}
+			for _, method := range sortedByOrder(class.Methods) {
+				if !seen[method.Name] {
+					seen[method.Name] = true
+					entries = append(entries, x.methodEntry(class.Name, method))
+				}
+			}
+		}
+	case valuePrototype:
+		seen := make(map[string]bool)
+		for prototype, ok := x.prototypes[v.name]; ok; prototype, ok = x.prototypes[prototype.Parent] {
+			for _, property := range sortedByOrder(prototype.Properties) {
+				if !seen[property.Name] {
+					seen[property.Name] = true
+					entries = append(entries, x.propertyEntry(prototype.Name, property))
+				}
+			}
+		}
+	case valueConcept:
+		concept := x.concepts[v.name]
+		for _, property := range sortedByOrder(concept.Properties) {
+			entries = append(entries, x.propertyEntry(concept.Name, property))
+		}
+		for _, field := range sortedByOrder(concept.Type.Fields) {
+			entries = append(entries, x.parameterEntry(concept.Name, field))
+		}
+	case valueDefine:
+		define := x.defines[v.name]
+		for _, subkey := range sortedByOrder(define.Subkeys) {
+			fullName := v.name + "." + subkey.Name
+			entries = append(entries, entry{name: subkey.Name, kind: kindEnum, signature: "(define) " + fullName, doc: x.describe(subkey.Description), deprecated: subkey.Deprecated, value: value{kind: valueDefine, name: fullName}})
+		}
+		for _, defineValue := range sortedByOrder(define.Values) {
+			entries = append(entries, x.defineValueEntry(v.name, defineValue))
+		}
+	case valueData:
+		entries = append(entries, entry{name: "raw", kind: kindField, signature: "(field) data.raw", doc: "Every prototype, by type and name, e.g. `data.raw.item[\"iron-plate\"]`.", value: value{kind: valueRaw}})
+	case valueRaw:
+		for _, typeName := range sortedKeys(x.typeNames) {
+			prototype := x.typeNames[typeName]
+			entries = append(entries, entry{
+				name:       typeName,
+				kind:       kindField,
+				signature:  fmt.Sprintf("(field) data.raw[%q]: table<string, %s>", typeName, prototype.Name),
+				detail:     prototype.Name,
+				doc:        x.describe(prototype.Description),
+				deprecated: prototype.Deprecated,
+				value:      value{kind: valueRawType, name: typeName},
+			})
+		}
+	}
+	return entries
+}
+
+// classChain returns a class followed by its ancestors.
+func (x *index) classChain(name string) []api.Class {
+	var chain []api.Class
+	queue := []string{name}
+	seen := make(map[string]bool)
+	for len(queue) > 0 {
+		name, queue = queue[0], queue[1:]
+		class, ok := x.classes[name]
+		if !ok || seen[name] {
+			continue
+		}
+		seen[name] = true
+		chain = append(chain, class)
+		queue = append(queue, class.Parent...)
+	}
+	return chain
+}
+
+func (x *index) propertyEntry(owner string, property api.Property) entry {
+	luaType := x.types.LuaLSType(property.ValueType())
+	optional := ""
+	if property.Optional {
+		optional = "?"
+	}
+	doc := x.describe(property.Description)
+	readOnly := ""
+	if property.ReadType != nil || property.WriteType != nil {
+		access := "Read/Write"
+		if !property.IsWritable() {
+			access = "Read-only"
+			readOnly = owner + "." + property.Name
+		} else if !property.IsReadable() {
+			access = "Write-only"
+		}
+		doc = strings.TrimSpace(doc + "\n\n*" + access + "*")
+	}
+	return entry{
+		name:       property.Name,
+		kind:       kindField,
+		signature:  fmt.Sprintf("(field) %s.%s%s: %s", owner, property.Name, optional, luaType),
+		detail:     luaType,
+		doc:        doc,
+		deprecated: property.Deprecated,
+		readOnly:   readOnly,
+		value:      x.resolveType(property.ValueType()),
+	}
+}
+
+func (x *index) parameterEntry(owner string, param api.Parameter) entry {
+	luaType := x.types.LuaLSType(param.Type)
+	optional := ""
+	if param.Optional {
+		optional = "?"
+	}
+	return entry{
+		name:      param.Name,
+		kind:      kindField,
+		signature: fmt.Sprintf("(field) %s.%s%s: %s", owner, param.Name, optional, luaType),
+		detail:    luaType,
+		doc:       x.describe(param.Description),
+		value:     x.resolveType(param.Type),
+	}
+}
+
+func (x *index) methodEntry(owner string, method api.Method) entry {
+	signature := x.signature(owner, method)
+	return entry{
+		name:       method.Name,
+		kind:       kindMethod,
+		signature:  "(method) " + signature,
+		detail:     signature,
+		doc:        x.describe(method.Description),
+		deprecated: method.Deprecated,
+		value:      value{kind: valueMethod, name: owner, method: method},
+	}
+}
+
+// defineValueEntry describes a define value. Event ids also describe the event
+// and the fields of its payload.
+func (x *index) defineValueEntry(define string, defineValue api.DefineValue) entry {
+	e := entry{
+		name:       defineValue.Name,
+		kind:       kindEnumMember,
+		signature:  fmt.Sprintf("(define) %s.%s", define, defineValue.Name),
+		doc:        x.describe(defineValue.Description),
+		deprecated: defineValue.Deprecated,
+	}
+	if event, ok := x.events[defineValue.Name]; ok && define == "defines.events" {
+		e.kind = kindEvent
+		var sb strings.Builder
+		sb.WriteString(x.describe(event.Description))
+		sb.WriteString("\n\nEvent data:\n")
+		for _, param := range sortedByOrder(event.Data) {
+			optional := ""
+			if param.Optional {
+				optional = "?"
+			}
+			sb.WriteString(fmt.Sprintf("- `%s%s: %s` %s\n", param.Name, optional, x.types.LuaLSType(param.Type), x.describe(param.Description)))
+		}
+		e.doc = strings.TrimSpace(sb.String())
+	}
+	return e
+}
+
+// signature spells a method as the definitions declare it, e.g.
+// "LuaSurface.find_entities(area?: BoundingBox): LuaEntity[]". Methods taking a
+// table of named arguments list them in braces.
+func (x *index) signature(owner string, method api.Method) string {
+	params := x.parameters(method)
+	list := strings.Join(params, ", ")
+	if method.Format.TakesTable {
+		list = "{ " + list + " }"
+		if method.Format.TableOptional {
+			list += "?"
+		}
+	}
+	var results []string
+	for _, ret := range sortedByOrder(method.ReturnValues) {
+		results = append(results, x.types.LuaLSType(ret.Type))
+	}
+	signature := fmt.Sprintf("%s.%s(%s)", owner, method.Name, list)
+	if len(results) > 0 {
+		signature += ": " + strings.Join(results, ", ")
+	}
+	return signature
+}
+
+// parameters spells the parameters of a method, e.g. "area?: BoundingBox".
+func (x *index) parameters(method api.Method) []string {
+	var params []string
+	for _, param := range sortedByOrder(method.Parameters) {
+		optional := ""
+		if param.Optional {
+			optional = "?"
+		}
+		params = append(params, fmt.Sprintf("%s%s: %s", param.Name, optional, x.types.LuaLSType(param.Type)))
+	}
+	if method.VariadicParameter != nil {
+		params = append(params, "...: "+x.types.LuaLSType(method.VariadicParameter.Type))
+	}
+	return params
+}
+
+// resolveType returns the value a type describes. Aliases are followed, and a
+// union with a single option besides nil resolves to that option.
+func (x *index) resolveType(t api.Type) value {
+	for range 8 { // Concepts may alias each other, but not endlessly
+		switch {
+		case t.ComplexType == "type" && t.Value != nil:
+			t = *t.Value
+			continue
+		case t.ComplexType == "union":
+			var options []api.Type
+			for _, option := range t.Values {
+				if option.Name != "nil" {
+					options = append(options, option)
+				}
+			}
+			if len(options) != 1 {
+				return value{kind: valueOther, typ: t}
+			}
+			t = options[0]
+			continue
+		case t.ComplexType != "" || t.Name == "":
+			return value{kind: valueOther, typ: t}
+		}
+		if _, ok := x.classes[t.Name]; ok {
+			return value{kind: valueInstance, name: t.Name}
+		}
+		if _, ok := x.prototypes[t.Name]; ok {
+			return value{kind: valuePrototype, name: t.Name}
+		}
+		concept, ok := x.concepts[t.Name]
+		if !ok {
+			return value{kind: valueOther, typ: t}
+		}
+		if len(concept.Properties) > 0 || len(concept.Type.Fields) > 0 {
+			return value{kind: valueConcept, name: t.Name}
+		}
+		t = concept.Type
+	}
+	return value{kind: valueOther, typ: t}
+}
+
+// index returns the value of v[key]: an element of an array, dictionary or
+// custom table, of a class with an index operator, or a prototype of data.raw.
+func (x *index) index(v value) value {
+	switch v.kind {
+	case valueInstance:
+		for _, class := range x.classChain(v.name) {
+			for _, operator := range class.Operators {
+				if operator.Name == "index" && operator.ReadType != nil {
+					return x.resolveType(*operator.ReadType)
+				}
+			}
+		}
+	case valueRawType:
+		return value{kind: valuePrototype, name: x.typeNames[v.name].Name}
+	case valueOther:
+		if v.typ.Value != nil && (v.typ.ComplexType == "array" || v.typ.ComplexType == "dictionary" || v.typ.ComplexType == "LuaCustomTable") {
+			return x.resolveType(*v.typ.Value)
+		}
+	}
+	return value{}
+}
+
+// call returns the value a method call returns, its first return value.
+func (x *index) call(v value) value {
+	if v.kind != valueMethod || len(v.method.ReturnValues) == 0 {
+		return value{}
+	}
+	return x.resolveType(sortedByOrder(v.method.ReturnValues)[0].Type)
+}
+
+func sortedByOrder[T interface{ SortKey() (int, string) }](items []T) []T {
+	return slices.SortedStableFunc(slices.Values(items), func(a, b T) int {
+		orderA, nameA := a.SortKey()
+		orderB, nameB := b.SortKey()
+		if orderA != orderB {
+			return orderA - orderB
+		}
+		return strings.Compare(nameA, nameB)
+	})
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
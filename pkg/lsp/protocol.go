@@ -0,0 +1,233 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// message is a JSON-RPC 2.0 message: a request (ID and Method set), a
+// notification (Method only) or a response (ID with Result or Error).
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  any             `json:"result,omitempty"`
+	Error   *responseError  `json:"error,omitempty"`
+}
+
+// responseError is the error of a failed request.
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// JSON-RPC and LSP error codes.
+const (
+	codeInvalidParams        = -32602
+	codeMethodNotFound       = -32601
+	codeServerNotInitialized = -32002
+)
+
+// readMessage reads a message framed with a Content-Length header, as LSP sends
+// them over stdio.
+func readMessage(r *bufio.Reader) (*message, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length header %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	var msg message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, fmt.Errorf("invalid message: %w", err)
+	}
+	return &msg, nil
+}
+
+// writeMessage writes a message with its Content-Length header.
+func writeMessage(w io.Writer, msg *message) error {
+	msg.JSONRPC = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+// position is a position in a document: a zero-based line, and a character
+// offset within it counted in UTF-16 code units, as LSP does by default.
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type textDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type textDocumentPositionParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Position     position               `json:"position"`
+}
+
+type didOpenParams struct {
+	TextDocument struct {
+		URI  string `json:"uri"`
+		Text string `json:"text"`
+	} `json:"textDocument"`
+}
+
+type didChangeParams struct {
+	TextDocument   textDocumentIdentifier `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+type didCloseParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+type lspRange struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+// Diagnostic severities used by the server.
+const severityError = 1
+
+// diagnostic is a problem found in a document, published to the client with
+// textDocument/publishDiagnostics.
+type diagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Code     string   `json:"code"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type publishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []diagnostic `json:"diagnostics"`
+}
+
+// markupContent is Markdown shown by the client, e.g. in a hover.
+type markupContent struct {
+	Kind  string `json:"kind"` // Always "markdown"
+	Value string `json:"value"`
+}
+
+type hover struct {
+	Contents markupContent `json:"contents"`
+}
+
+// Completion item kinds used by the server.
+const (
+	kindMethod     = 2
+	kindField      = 5
+	kindVariable   = 6
+	kindClass      = 7
+	kindModule     = 9
+	kindEnum       = 13
+	kindEnumMember = 20
+	kindEvent      = 23
+)
+
+type completionItem struct {
+	Label         string         `json:"label"`
+	Kind          int            `json:"kind"`
+	Detail        string         `json:"detail,omitempty"`
+	Documentation *markupContent `json:"documentation,omitempty"`
+	Deprecated    bool           `json:"deprecated,omitempty"`
+	SortText      string         `json:"sortText,omitempty"`
+}
+
+type completionList struct {
+	IsIncomplete bool             `json:"isIncomplete"`
+	Items        []completionItem `json:"items"`
+}
+
+type parameterInformation struct {
+	Label         string         `json:"label"`
+	Documentation *markupContent `json:"documentation,omitempty"`
+}
+
+type signatureInformation struct {
+	Label         string                 `json:"label"`
+	Documentation *markupContent         `json:"documentation,omitempty"`
+	Parameters    []parameterInformation `json:"parameters"`
+}
+
+type signatureHelp struct {
+	Signatures      []signatureInformation `json:"signatures"`
+	ActiveSignature int                    `json:"activeSignature"`
+	ActiveParameter int                    `json:"activeParameter"`
+}
+
+// offset converts a position to a byte offset in text. Positions past the end
+// of a line or of the text are clamped.
+func offset(text string, pos position) int {
+	start := 0
+	for line := 0; line < pos.Line; line++ {
+		next := strings.IndexByte(text[start:], '\n')
+		if next < 0 {
+			return len(text)
+		}
+		start += next + 1
+	}
+	end := strings.IndexByte(text[start:], '\n')
+	if end < 0 {
+		end = len(text)
+	} else {
+		end += start
+	}
+	units := 0
+	for i, r := range text[start:end] {
+		if units >= pos.Character {
+			return start + i
+		}
+		units++
+		if r >= 0x10000 {
+			units++ // Encoded as a surrogate pair
+		}
+	}
+	return end
+}
+
+// positionAt converts a byte offset in text to a position, the inverse of offset.
+func positionAt(text string, offset int) position {
+	line := strings.Count(text[:offset], "\n")
+	start := strings.LastIndexByte(text[:offset], '\n') + 1
+	character := 0
+	for _, r := range text[start:offset] {
+		character++
+		if r >= 0x10000 {
+			character++ // Encoded as a surrogate pair
+		}
+	}
+	return position{Line: line, Character: character}
+}
+
+// markdown wraps text as the content of a hover or a documentation popup.
+func markdown(text string) *markupContent {
+	if text == "" {
+		return nil
+	}
+	return &markupContent{Kind: "markdown", Value: text}
+}
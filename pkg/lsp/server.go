@@ -0,0 +1,161 @@
+// Package lsp implements a language server answering hovers, completion and
+// signature help for the Factorio API straight from the parsed API model, without
+// generated definitions. It only knows the API: it doesn't analyze the code
+// beyond the expression at the cursor (locals aren't typed, so `local p =
+// game.player` then `p.` completes nothing), and is meant to run standalone in
+// editors without LuaLS, or alongside it for the documentation of the API.
+//
+// With ReadOnlyDiagnostics, it also reports the assignments to read-only runtime
+// attributes, which LuaLS has no way to check.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+// Server is a language server for the Factorio API, speaking LSP over a stream.
+type Server struct {
+	// ReadOnlyDiagnostics publishes an error for each assignment to a read-only
+	// attribute of a runtime class in the open documents, e.g. to LuaEntity.name.
+	ReadOnlyDiagnostics bool
+
+	index         *index
+	documents     map[string]string // Open documents by URI
+	notifications []*message        // Sent after the message being handled
+	initialized   bool
+	shutdown      bool
+}
+
+// NewServer returns a server for the given APIs. Either may be nil: the runtime
+// API provides the classes, events, defines and global objects, the prototype API
+// the prototypes and data.raw.
+func NewServer(runtimeAPI *api.API, prototypeAPI *api.API) *Server {
+	return &Server{
+		index:     newIndex(runtimeAPI, prototypeAPI),
+		documents: make(map[string]string),
+	}
+}
+
+// Serve answers the requests read from r on w until the client exits or r ends.
+// An exit without a shutdown request first is reported as an error, as LSP
+// expects the server to exit with a failure then.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	reader := bufio.NewReader(r)
+	for {
+		msg, err := readMessage(reader)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("exit without shutdown")
+			}
+			return nil
+		}
+		result, rpcErr := s.handle(msg)
+		for _, notification := range s.notifications {
+			if err := writeMessage(w, notification); err != nil {
+				return err
+			}
+		}
+		s.notifications = nil
+		if msg.ID == nil {
+			continue // Notifications get no response
+		}
+		if err := writeMessage(w, &message{ID: msg.ID, Result: result, Error: rpcErr}); err != nil {
+			return err
+		}
+	}
+}
+
+// handle answers a message. Unknown notifications are ignored.
+func (s *Server) handle(msg *message) (any, *responseError) {
+	if !s.initialized && msg.Method != "initialize" {
+		return nil, &responseError{Code: codeServerNotInitialized, Message: "the server is not initialized"}
+	}
+	switch msg.Method {
+	case "initialize":
+		s.initialized = true
+		return map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync":      1, // Full documents
+				"hoverProvider":         true,
+				"completionProvider":    map[string]any{"triggerCharacters": []string{".", ":"}},
+				"signatureHelpProvider": map[string]any{"triggerCharacters": []string{"(", ",", "{"}},
+			},
+			"serverInfo": map[string]any{"name": "factorio-api-gen"},
+		}, nil
+	case "shutdown":
+		s.shutdown = true
+		return json.RawMessage("null"), nil
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := json.Unmarshal(msg.Params, &params); err == nil {
+			s.documents[params.TextDocument.URI] = params.TextDocument.Text
+			s.publishDiagnostics(params.TextDocument.URI)
+		}
+		return nil, nil
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := json.Unmarshal(msg.Params, &params); err == nil && len(params.ContentChanges) > 0 {
+			s.documents[params.TextDocument.URI] = params.ContentChanges[len(params.ContentChanges)-1].Text
+			s.publishDiagnostics(params.TextDocument.URI)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := json.Unmarshal(msg.Params, &params); err == nil {
+			delete(s.documents, params.TextDocument.URI)
+			// Clears the document's diagnostics.
+			s.publishDiagnostics(params.TextDocument.URI)
+		}
+		return nil, nil
+	case "textDocument/hover", "textDocument/completion", "textDocument/signatureHelp":
+		var params textDocumentPositionParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &responseError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		text := s.documents[params.TextDocument.URI]
+		at := offset(text, params.Position)
+		// Nothing to show is a null result, which omitempty would drop.
+		switch msg.Method {
+		case "textDocument/hover":
+			if h := s.index.hover(text, at); h != nil {
+				return h, nil
+			}
+		case "textDocument/completion":
+			return s.index.completion(text, at), nil
+		default:
+			if help := s.index.signatureHelp(text, at); help != nil {
+				return help, nil
+			}
+		}
+		return json.RawMessage("null"), nil
+	}
+	if msg.ID != nil {
+		return nil, &responseError{Code: codeMethodNotFound, Message: "unsupported method " + msg.Method}
+	}
+	return nil, nil
+}
+
+// publishDiagnostics queues the diagnostics of a document, none if it was
+// closed, when they are enabled.
+func (s *Server) publishDiagnostics(uri string) {
+	if !s.ReadOnlyDiagnostics {
+		return
+	}
+	diagnostics := []diagnostic{}
+	if text, ok := s.documents[uri]; ok {
+		diagnostics = append(diagnostics, s.index.readOnlyAssignments(text)...)
+	}
+	params, _ := json.Marshal(publishDiagnosticsParams{URI: uri, Diagnostics: diagnostics})
+	s.notifications = append(s.notifications, &message{Method: "textDocument/publishDiagnostics", Params: params})
+}
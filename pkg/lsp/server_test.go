@@ -0,0 +1,219 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+// loadFixture parses an API document of the generator's 2.0.45 fixtures.
+func loadFixture(t *testing.T, stage string) *api.API {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "generator", "testdata", "fixtures", "2.0.45", stage+"-api.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var a api.API
+	if err := json.Unmarshal(data, &a); err != nil {
+		t.Fatal(err)
+	}
+	return &a
+}
+
+// request frames a JSON-RPC message; a nil id makes it a notification.
+func request(id any, method string, params any) string {
+	msg := map[string]any{"jsonrpc": "2.0", "method": method}
+	if id != nil {
+		msg["id"] = id
+	}
+	if params != nil {
+		msg["params"] = params
+	}
+	body, _ := json.Marshal(msg)
+	return fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+// at is the params of a request about the position of marker in text.
+func at(uri string, text string, marker string) map[string]any {
+	before := text[:strings.Index(text, marker)+len(marker)]
+	line := strings.Count(before, "\n")
+	character := len(before) - strings.LastIndexByte(before, '\n') - 1
+	return map[string]any{"textDocument": map[string]any{"uri": uri}, "position": map[string]any{"line": line, "character": character}}
+}
+
+func TestServe(t *testing.T) {
+	const uri = "file:///mod/control.lua"
+	text := "script.on_event(defines.events.on_tick, function(event)\n  local tick = event.tick\n  script.\nend)\n"
+	var input strings.Builder
+	input.WriteString(request(0, "textDocument/hover", at(uri, text, "script")))
+	input.WriteString(request(1, "initialize", map[string]any{}))
+	input.WriteString(request(nil, "initialized", map[string]any{}))
+	input.WriteString(request(nil, "textDocument/didOpen", map[string]any{"textDocument": map[string]any{"uri": uri, "text": text}}))
+	input.WriteString(request(2, "textDocument/hover", at(uri, text, "script.on_ev")))
+	input.WriteString(request(3, "textDocument/completion", at(uri, text, "  script.")))
+	input.WriteString(request(4, "textDocument/signatureHelp", at(uri, text, "defines.events.on_tick, ")))
+	input.WriteString(request(5, "textDocument/hover", at(uri, text, "local")))
+	input.WriteString(request(6, "workspace/symbol", map[string]any{}))
+	input.WriteString(request(7, "shutdown", nil))
+	input.WriteString(request(nil, "exit", nil))
+
+	var output bytes.Buffer
+	server := NewServer(loadFixture(t, "runtime"), loadFixture(t, "prototype"))
+	if err := server.Serve(strings.NewReader(input.String()), &output); err != nil {
+		t.Fatal(err)
+	}
+
+	responses := make(map[int]map[string]any)
+	reader := bufio.NewReader(&output)
+	for {
+		msg, err := readMessage(reader)
+		if err != nil {
+			break
+		}
+		var id int
+		var response map[string]any
+		data, _ := json.Marshal(msg)
+		if err := json.Unmarshal(msg.ID, &id); err != nil {
+			t.Fatalf("a response without an id: %s", data)
+		}
+		if err := json.Unmarshal(data, &response); err != nil {
+			t.Fatal(err)
+		}
+		responses[id] = response
+	}
+	if len(responses) != 8 {
+		t.Fatalf("got %d responses, want 8 (notifications get none): %v", len(responses), responses)
+	}
+	encoded := func(id int) string {
+		data, _ := json.Marshal(responses[id])
+		return string(data)
+	}
+
+	if !strings.Contains(encoded(0), `"code":-32002`) {
+		t.Errorf("a request before initialize = %s, want a not initialized error", encoded(0))
+	}
+	if !strings.Contains(encoded(1), `"hoverProvider":true`) {
+		t.Errorf("initialize = %s", encoded(1))
+	}
+	if !strings.Contains(encoded(2), "on_event") {
+		t.Errorf("hover of script.on_event = %s", encoded(2))
+	}
+	if !strings.Contains(encoded(3), `"label":"raise_event"`) || !strings.Contains(encoded(3), `"label":"on_event"`) {
+		t.Errorf("completion of script. = %s", encoded(3))
+	}
+	if !strings.Contains(encoded(4), `"activeParameter":1`) {
+		t.Errorf("signature help of the handler of script.on_event = %s", encoded(4))
+	}
+	if responses[5]["result"] != nil || responses[5]["error"] != nil {
+		t.Errorf("hover of a keyword = %s, want no result", encoded(5))
+	}
+	if !strings.Contains(encoded(6), `"code":-32601`) {
+		t.Errorf("an unsupported request = %s, want a method not found error", encoded(6))
+	}
+}
+
+func TestServeExitWithoutShutdown(t *testing.T) {
+	input := request(1, "initialize", map[string]any{}) + request(nil, "exit", nil)
+	if err := NewServer(nil, nil).Serve(strings.NewReader(input), &bytes.Buffer{}); err == nil {
+		t.Error("an exit without shutdown isn't an error")
+	}
+}
+
+func TestOffset(t *testing.T) {
+	text := "local a = 1\nlocal 𝔰 = \"é\"\n"
+	tests := []struct {
+		line, character, want int
+	}{
+		{0, 0, 0},
+		{0, 6, 6},
+		{0, 99, 11}, // Clamped to the end of the line
+		{1, 6, 18},
+		{1, 8, 22},  // 𝔰 is two UTF-16 code units and four bytes
+		{1, 13, 28}, // é is one code unit and two bytes
+		{5, 0, len(text)},
+	}
+	for _, test := range tests {
+		if got := offset(text, position{Line: test.line, Character: test.character}); got != test.want {
+			t.Errorf("offset(%d:%d) = %d, want %d", test.line, test.character, got, test.want)
+		}
+	}
+}
+
+func TestParseChain(t *testing.T) {
+	tests := []struct {
+		text string
+		want string // The segments, kind and name
+	}{
+		{"game", " game"},
+		{"game.players[1].surface", " game .players [ .surface"},
+		{`data.raw["item"]`, ` data .raw [item`},
+		{"game.get_player(1).force:get_item_launched", " game .get_player ( .force :get_item_launched"},
+		{`"a" .. game`, " game"},
+		{"local x = y.z", " y .z"},
+		{"(f).x", ""},
+		{"[1].x", ""},
+		{"1abc", ""},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, seg := range parseChain(test.text, len(test.text)) {
+			kind := " "
+			if seg.kind != 0 {
+				kind = string(seg.kind)
+			}
+			got = append(got, kind+seg.name)
+		}
+		if strings.Join(got, " ") != test.want {
+			t.Errorf("parseChain(%q) = %q, want %q", test.text, strings.Join(got, " "), test.want)
+		}
+	}
+}
+
+func TestServeReadOnlyDiagnostics(t *testing.T) {
+	const uri = "file:///mod/control.lua"
+	input := request(1, "initialize", map[string]any{}) +
+		request(nil, "textDocument/didOpen", map[string]any{"textDocument": map[string]any{"uri": uri, "text": "script.mod_name = 'x'\n"}}) +
+		request(nil, "textDocument/didChange", map[string]any{"textDocument": map[string]any{"uri": uri}, "contentChanges": []map[string]any{{"text": "local name = script.mod_name\n"}}}) +
+		request(nil, "textDocument/didClose", map[string]any{"textDocument": map[string]any{"uri": uri}})
+
+	for _, enabled := range []bool{false, true} {
+		server := NewServer(loadFixture(t, "runtime"), nil)
+		server.ReadOnlyDiagnostics = enabled
+		var output bytes.Buffer
+		if err := server.Serve(strings.NewReader(input), &output); err != nil {
+			t.Fatal(err)
+		}
+		var published []string
+		reader := bufio.NewReader(&output)
+		for {
+			msg, err := readMessage(reader)
+			if err != nil {
+				break
+			}
+			if msg.Method == "textDocument/publishDiagnostics" {
+				published = append(published, string(msg.Params))
+			}
+		}
+		if !enabled {
+			if len(published) != 0 {
+				t.Errorf("diagnostics published when disabled: %v", published)
+			}
+			continue
+		}
+		want := []string{
+			`{"uri":"file:///mod/control.lua","diagnostics":[{"range":{"start":{"line":0,"character":7},"end":{"line":0,"character":15}},"severity":1,"code":"read-only","source":"factorio-api-gen","message":"LuaBootstrap.mod_name is read-only"}]}`,
+			`{"uri":"file:///mod/control.lua","diagnostics":[]}`,
+			`{"uri":"file:///mod/control.lua","diagnostics":[]}`,
+		}
+		if strings.Join(published, "\n") != strings.Join(want, "\n") {
+			t.Errorf("published\n%s\nwant\n%s", strings.Join(published, "\n"), strings.Join(want, "\n"))
+		}
+	}
+}
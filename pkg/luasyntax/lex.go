@@ -0,0 +1,297 @@
+// Package luasyntax checks that Lua source is syntactically valid, following the
+// grammar of the Lua 5.4 reference manual (which accepts the Lua 5.2 of the game
+// too). It parses without building a syntax tree, and stops at the first error,
+// like the Lua compiler does. It doesn't resolve goto labels.
+package luasyntax
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SyntaxError is the first syntax error of a source.
+type SyntaxError struct {
+	Line    int
+	Message string // e.g. "'end' expected near 'foo'"
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// Token kinds. Keywords and operators are their own text.
+const (
+	tokenEOF    = "<eof>"
+	tokenName   = "<name>"
+	tokenNumber = "<number>"
+	tokenString = "<string>"
+)
+
+var keywords = map[string]bool{
+	"and": true, "break": true, "do": true, "else": true, "elseif": true, "end": true,
+	"false": true, "for": true, "function": true, "goto": true, "if": true, "in": true,
+	"local": true, "nil": true, "not": true, "or": true, "repeat": true, "return": true,
+	"then": true, "true": true, "until": true, "while": true,
+}
+
+// operators are the operators and punctuation, longest first so that a prefix
+// doesn't shadow a longer operator.
+var operators = []string{
+	"...", "..", "//", "<<", ">>", "==", "~=", "<=", ">=", "::",
+	"+", "-", "*", "/", "%", "^", "#", "&", "~", "|", "<", ">", "=",
+	"(", ")", "{", "}", "[", "]", ";", ":", ",", ".",
+}
+
+// Numerals: decimal or hexadecimal, with an optional fraction and exponent.
+var (
+	decimalNumeral = regexp.MustCompile(`^([0-9]+\.?[0-9]*|\.[0-9]+)([eE][+-]?[0-9]+)?$`)
+	hexNumeral     = regexp.MustCompile(`^0[xX]([0-9a-fA-F]+\.?[0-9a-fA-F]*|\.[0-9a-fA-F]+)([pP][+-]?[0-9]+)?$`)
+)
+
+type token struct {
+	kind string // One of the token kinds, a keyword or an operator
+	text string
+	line int
+}
+
+// lexer splits a source into tokens, skipping whitespace and comments.
+type lexer struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (l *lexer) errorf(format string, args ...any) error {
+	return &SyntaxError{Line: l.line, Message: fmt.Sprintf(format, args...)}
+}
+
+// next returns the next token, or a token of kind tokenEOF at the end.
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\n' || c == '\r':
+			l.newline()
+		case c == ' ' || c == '\t' || c == '\f' || c == '\v':
+			l.pos++
+		case strings.HasPrefix(l.src[l.pos:], "--"):
+			l.pos += 2
+			if level, ok := l.longBracket(); ok {
+				if _, err := l.longString(level, "comment"); err != nil {
+					return token{}, err
+				}
+				continue
+			}
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' && l.src[l.pos] != '\r' {
+				l.pos++
+			}
+		default:
+			return l.token()
+		}
+	}
+	return token{kind: tokenEOF, text: tokenEOF, line: l.line}, nil
+}
+
+// newline skips a line break: \n, \r, \r\n or \n\r.
+func (l *lexer) newline() {
+	c := l.src[l.pos]
+	l.pos++
+	if l.pos < len(l.src) && (l.src[l.pos] == '\n' || l.src[l.pos] == '\r') && l.src[l.pos] != c {
+		l.pos++
+	}
+	l.line++
+}
+
+func (l *lexer) token() (token, error) {
+	start, line := l.pos, l.line
+	c := l.src[l.pos]
+	switch {
+	case isLetter(c):
+		for l.pos < len(l.src) && (isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		text := l.src[start:l.pos]
+		if keywords[text] {
+			return token{kind: text, text: text, line: line}, nil
+		}
+		return token{kind: tokenName, text: text, line: line}, nil
+
+	case isDigit(c) || (c == '.' && l.pos+1 < len(l.src) && isDigit(l.src[l.pos+1])):
+		return l.number()
+
+	case c == '"' || c == '\'':
+		return l.shortString(c)
+
+	case c == '[':
+		if level, ok := l.longBracket(); ok {
+			if _, err := l.longString(level, "string"); err != nil {
+				return token{}, err
+			}
+			return token{kind: tokenString, text: l.src[start:l.pos], line: line}, nil
+		}
+	}
+	for _, op := range operators {
+		if strings.HasPrefix(l.src[l.pos:], op) {
+			l.pos += len(op)
+			return token{kind: op, text: op, line: line}, nil
+		}
+	}
+	return token{}, l.errorf("unexpected symbol near '%c'", c)
+}
+
+// number reads a numeral the way Lua does: as far as it could continue, after
+// which the whole of it must be valid.
+func (l *lexer) number() (token, error) {
+	start := l.pos
+	exponent := "Ee"
+	if strings.HasPrefix(l.src[l.pos:], "0x") || strings.HasPrefix(l.src[l.pos:], "0X") {
+		exponent = "Pp"
+		l.pos += 2
+	}
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if strings.IndexByte(exponent, c) >= 0 && l.pos+1 < len(l.src) && (l.src[l.pos+1] == '+' || l.src[l.pos+1] == '-') {
+			l.pos += 2
+			continue
+		}
+		if !isDigit(c) && !isLetter(c) && c != '.' {
+			break
+		}
+		l.pos++
+	}
+	text := l.src[start:l.pos]
+	if !decimalNumeral.MatchString(text) && !hexNumeral.MatchString(text) {
+		return token{}, l.errorf("malformed number near '%s'", text)
+	}
+	return token{kind: tokenNumber, text: text, line: l.line}, nil
+}
+
+// shortString reads a quoted string, checking its escape sequences. A line break
+// may only be escaped.
+func (l *lexer) shortString(quote byte) (token, error) {
+	start, line := l.pos, l.line
+	l.pos++
+	for {
+		if l.pos >= len(l.src) {
+			return token{}, l.errorf("unfinished string near <eof>")
+		}
+		c := l.src[l.pos]
+		switch c {
+		case quote:
+			l.pos++
+			return token{kind: tokenString, text: l.src[start:l.pos], line: line}, nil
+		case '\n', '\r':
+			return token{}, l.errorf("unfinished string near '%s'", l.src[start:l.pos])
+		case '\\':
+			if err := l.escape(); err != nil {
+				return token{}, err
+			}
+		default:
+			l.pos++
+		}
+	}
+}
+
+// escape checks the escape sequence at the position, and skips it.
+func (l *lexer) escape() error {
+	l.pos++ // The backslash
+	if l.pos >= len(l.src) {
+		return l.errorf("unfinished string near <eof>")
+	}
+	c := l.src[l.pos]
+	switch {
+	case strings.IndexByte(`abfnrtv\"'`, c) >= 0:
+		l.pos++
+	case c == '\n' || c == '\r':
+		l.newline()
+	case c == 'z':
+		// Skips the following whitespace, line breaks included.
+		l.pos++
+		for l.pos < len(l.src) && strings.IndexByte(" \t\f\v\n\r", l.src[l.pos]) >= 0 {
+			if l.src[l.pos] == '\n' || l.src[l.pos] == '\r' {
+				l.newline()
+			} else {
+				l.pos++
+			}
+		}
+	case c == 'x':
+		if l.pos+2 >= len(l.src) || !isHexDigit(l.src[l.pos+1]) || !isHexDigit(l.src[l.pos+2]) {
+			return l.errorf("hexadecimal digit expected in escape sequence")
+		}
+		l.pos += 3
+	case c == 'u':
+		end := strings.IndexByte(l.src[l.pos:], '}')
+		if !strings.HasPrefix(l.src[l.pos:], "u{") || end < 0 {
+			return l.errorf("missing '{' or '}' in \\u{xxxx} escape sequence")
+		}
+		code, err := strconv.ParseUint(l.src[l.pos+2:l.pos+end], 16, 32)
+		if err != nil || code >= 1<<31 {
+			return l.errorf("UTF-8 value too large or malformed in escape sequence")
+		}
+		l.pos += end + 1
+	case isDigit(c):
+		end := l.pos
+		for end < len(l.src) && end < l.pos+3 && isDigit(l.src[end]) {
+			end++
+		}
+		if code, _ := strconv.Atoi(l.src[l.pos:end]); code > 255 {
+			return l.errorf("decimal escape too large near '\\%s'", l.src[l.pos:end])
+		}
+		l.pos = end
+	default:
+		return l.errorf("invalid escape sequence '\\%c'", c)
+	}
+	return nil
+}
+
+// longBracket reports whether an opening long bracket ([[, [=[, ...) is at the
+// position, and its level (the number of =).
+func (l *lexer) longBracket() (int, bool) {
+	rest := l.src[l.pos:]
+	if !strings.HasPrefix(rest, "[") {
+		return 0, false
+	}
+	level := 1
+	for level < len(rest) && rest[level] == '=' {
+		level++
+	}
+	if level < len(rest) && rest[level] == '[' {
+		return level - 1, true
+	}
+	return 0, false
+}
+
+// longString skips a long string or comment of the given level, from its opening
+// bracket to its closing one.
+func (l *lexer) longString(level int, what string) (string, error) {
+	line := l.line
+	l.pos += level + 2
+	closing := "]" + strings.Repeat("=", level) + "]"
+	start := l.pos
+	for l.pos < len(l.src) {
+		if strings.HasPrefix(l.src[l.pos:], closing) {
+			l.pos += len(closing)
+			return l.src[start : l.pos-len(closing)], nil
+		}
+		if l.src[l.pos] == '\n' || l.src[l.pos] == '\r' {
+			l.newline()
+		} else {
+			l.pos++
+		}
+	}
+	return "", &SyntaxError{Line: line, Message: fmt.Sprintf("unfinished long %s", what)}
+}
+
+func isLetter(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
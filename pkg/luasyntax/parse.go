@@ -0,0 +1,669 @@
+package luasyntax
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Check parses source as a Lua chunk, and returns its first syntax error as a
+// *SyntaxError, or nil.
+func Check(source string) error {
+	p := &parser{lexer: lexer{src: source, line: 1}}
+	// A chunk is the body of a vararg function.
+	p.functions = []function{{vararg: true}}
+	if err := p.advance(); err != nil {
+		return err
+	}
+	if err := p.block(); err != nil {
+		return err
+	}
+	if p.tok.kind != tokenEOF {
+		return p.errorf("'<eof>' expected")
+	}
+	return nil
+}
+
+// function is the state of a function body being parsed.
+type function struct {
+	vararg bool
+	loops  int // Loops enclosing the position, for break
+}
+
+// parser is a recursive descent parser of the grammar of the Lua manual, which
+// reads one token ahead.
+type parser struct {
+	lexer
+	tok       token
+	functions []function // The innermost last
+}
+
+func (p *parser) advance() error {
+	tok, err := p.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+// errorf reports an error near the current token.
+func (p *parser) errorf(format string, args ...any) error {
+	near := p.tok.text
+	if p.tok.kind != tokenEOF {
+		near = "'" + near + "'"
+	}
+	return &SyntaxError{Line: p.tok.line, Message: fmt.Sprintf(format, args...) + " near " + near}
+}
+
+// accept skips the current token if it is of the given kind.
+func (p *parser) accept(kind string) (bool, error) {
+	if p.tok.kind != kind {
+		return false, nil
+	}
+	return true, p.advance()
+}
+
+// expect skips the current token, which must be of the given kind.
+func (p *parser) expect(kind string) error {
+	if p.tok.kind != kind {
+		return p.errorf("'%s' expected", kind)
+	}
+	return p.advance()
+}
+
+// expectClosing is expect for the token closing a construct opened on line, which
+// is named in the error if it is on another line, as Lua does.
+func (p *parser) expectClosing(kind string, opening string, line int) error {
+	if p.tok.kind == kind {
+		return p.advance()
+	}
+	if line == p.tok.line {
+		return p.errorf("'%s' expected", kind)
+	}
+	return p.errorf("'%s' expected (to close '%s' at line %d)", kind, opening, line)
+}
+
+func (p *parser) name() error {
+	if p.tok.kind != tokenName {
+		return p.errorf("<name> expected")
+	}
+	return p.advance()
+}
+
+// blockEnds reports whether the current token ends a block.
+func (p *parser) blockEnds() bool {
+	switch p.tok.kind {
+	case tokenEOF, "end", "else", "elseif", "until":
+		return true
+	}
+	return false
+}
+
+// block parses statements up to the end of a block. A return statement must be
+// the last one.
+func (p *parser) block() error {
+	for !p.blockEnds() {
+		if p.tok.kind == "return" {
+			if err := p.advance(); err != nil {
+				return err
+			}
+			if !p.blockEnds() && p.tok.kind != ";" {
+				if err := p.expressionList(); err != nil {
+					return err
+				}
+			}
+			if _, err := p.accept(";"); err != nil {
+				return err
+			}
+			if !p.blockEnds() {
+				return p.errorf("'<eof>' expected")
+			}
+			return nil
+		}
+		if err := p.statement(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *parser) statement() error {
+	line := p.tok.line
+	switch p.tok.kind {
+	case ";":
+		return p.advance()
+
+	case "::":
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if err := p.name(); err != nil {
+			return err
+		}
+		return p.expect("::")
+
+	case "break":
+		if p.functions[len(p.functions)-1].loops == 0 {
+			return p.errorf("break outside a loop")
+		}
+		return p.advance()
+
+	case "goto":
+		if err := p.advance(); err != nil {
+			return err
+		}
+		return p.name()
+
+	case "do":
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if err := p.block(); err != nil {
+			return err
+		}
+		return p.expectClosing("end", "do", line)
+
+	case "while":
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if err := p.expression(); err != nil {
+			return err
+		}
+		if err := p.expect("do"); err != nil {
+			return err
+		}
+		if err := p.loopBody(); err != nil {
+			return err
+		}
+		return p.expectClosing("end", "while", line)
+
+	case "repeat":
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if err := p.loopBody(); err != nil {
+			return err
+		}
+		if err := p.expectClosing("until", "repeat", line); err != nil {
+			return err
+		}
+		return p.expression()
+
+	case "if":
+		return p.ifStatement(line)
+
+	case "for":
+		return p.forStatement(line)
+
+	case "function":
+		if err := p.advance(); err != nil {
+			return err
+		}
+		// funcname: Name {'.' Name} [':' Name]
+		if err := p.name(); err != nil {
+			return err
+		}
+		for p.tok.kind == "." {
+			if err := p.advance(); err != nil {
+				return err
+			}
+			if err := p.name(); err != nil {
+				return err
+			}
+		}
+		if ok, err := p.accept(":"); err != nil {
+			return err
+		} else if ok {
+			if err := p.name(); err != nil {
+				return err
+			}
+		}
+		return p.functionBody(line)
+
+	case "local":
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if ok, err := p.accept("function"); err != nil {
+			return err
+		} else if ok {
+			if err := p.name(); err != nil {
+				return err
+			}
+			return p.functionBody(line)
+		}
+		// attnamelist: Name attrib {',' Name attrib}, where attrib is ['<' Name '>']
+		for {
+			if err := p.name(); err != nil {
+				return err
+			}
+			if ok, err := p.accept("<"); err != nil {
+				return err
+			} else if ok {
+				if p.tok.kind != tokenName || (p.tok.text != "const" && p.tok.text != "close") {
+					return p.errorf("unknown attribute '%s'", p.tok.text)
+				}
+				if err := p.advance(); err != nil {
+					return err
+				}
+				if err := p.expect(">"); err != nil {
+					return err
+				}
+			}
+			if ok, err := p.accept(","); err != nil {
+				return err
+			} else if !ok {
+				break
+			}
+		}
+		if ok, err := p.accept("="); err != nil || !ok {
+			return err
+		}
+		return p.expressionList()
+
+	default:
+		return p.expressionStatement()
+	}
+}
+
+// loopBody parses the block of a loop, in which break is allowed.
+func (p *parser) loopBody() error {
+	// Indexed rather than held, as nested functions may move the slice.
+	i := len(p.functions) - 1
+	p.functions[i].loops++
+	err := p.block()
+	p.functions[i].loops--
+	return err
+}
+
+func (p *parser) ifStatement(line int) error {
+	for {
+		// The "if" or "elseif"
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if err := p.expression(); err != nil {
+			return err
+		}
+		if err := p.expect("then"); err != nil {
+			return err
+		}
+		if err := p.block(); err != nil {
+			return err
+		}
+		if p.tok.kind != "elseif" {
+			break
+		}
+	}
+	if ok, err := p.accept("else"); err != nil {
+		return err
+	} else if ok {
+		if err := p.block(); err != nil {
+			return err
+		}
+	}
+	return p.expectClosing("end", "if", line)
+}
+
+func (p *parser) forStatement(line int) error {
+	if err := p.advance(); err != nil {
+		return err
+	}
+	if err := p.name(); err != nil {
+		return err
+	}
+	switch p.tok.kind {
+	case "=":
+		// for Name '=' exp ',' exp [',' exp] do block end
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if err := p.expression(); err != nil {
+			return err
+		}
+		if err := p.expect(","); err != nil {
+			return err
+		}
+		if err := p.expression(); err != nil {
+			return err
+		}
+		if ok, err := p.accept(","); err != nil {
+			return err
+		} else if ok {
+			if err := p.expression(); err != nil {
+				return err
+			}
+		}
+	case ",", "in":
+		// for namelist in explist do block end
+		for p.tok.kind == "," {
+			if err := p.advance(); err != nil {
+				return err
+			}
+			if err := p.name(); err != nil {
+				return err
+			}
+		}
+		if err := p.expect("in"); err != nil {
+			return err
+		}
+		if err := p.expressionList(); err != nil {
+			return err
+		}
+	default:
+		return p.errorf("'=' or 'in' expected")
+	}
+	if err := p.expect("do"); err != nil {
+		return err
+	}
+	if err := p.loopBody(); err != nil {
+		return err
+	}
+	return p.expectClosing("end", "for", line)
+}
+
+// expressionStatement parses an assignment or a function call, which both start
+// with a suffixed expression.
+func (p *parser) expressionStatement() error {
+	kind, err := p.suffixedExpression()
+	if err != nil {
+		return err
+	}
+	if p.tok.kind != "=" && p.tok.kind != "," {
+		if kind != expressionCall {
+			return p.errorf("syntax error")
+		}
+		return nil
+	}
+	for {
+		if kind != expressionVariable {
+			return p.errorf("syntax error")
+		}
+		if ok, err := p.accept(","); err != nil {
+			return err
+		} else if !ok {
+			break
+		}
+		if kind, err = p.suffixedExpression(); err != nil {
+			return err
+		}
+	}
+	if err := p.expect("="); err != nil {
+		return err
+	}
+	return p.expressionList()
+}
+
+// The kinds of suffixed expressions, which decide what statement they can start.
+const (
+	expressionValue    = iota // e.g. (a)
+	expressionVariable        // a, a.b, a[b]: assignable
+	expressionCall            // a(), a:b(): a statement on its own
+)
+
+// suffixedExpression parses a name or parenthesized expression followed by
+// fields, indexes and calls.
+func (p *parser) suffixedExpression() (int, error) {
+	var kind int
+	switch p.tok.kind {
+	case tokenName:
+		kind = expressionVariable
+		if err := p.advance(); err != nil {
+			return 0, err
+		}
+	case "(":
+		line := p.tok.line
+		kind = expressionValue
+		if err := p.advance(); err != nil {
+			return 0, err
+		}
+		if err := p.expression(); err != nil {
+			return 0, err
+		}
+		if err := p.expectClosing(")", "(", line); err != nil {
+			return 0, err
+		}
+	default:
+		return 0, p.errorf("unexpected symbol")
+	}
+
+	for {
+		switch p.tok.kind {
+		case ".":
+			if err := p.advance(); err != nil {
+				return 0, err
+			}
+			if err := p.name(); err != nil {
+				return 0, err
+			}
+			kind = expressionVariable
+		case "[":
+			if err := p.advance(); err != nil {
+				return 0, err
+			}
+			if err := p.expression(); err != nil {
+				return 0, err
+			}
+			if err := p.expect("]"); err != nil {
+				return 0, err
+			}
+			kind = expressionVariable
+		case ":":
+			if err := p.advance(); err != nil {
+				return 0, err
+			}
+			if err := p.name(); err != nil {
+				return 0, err
+			}
+			if err := p.arguments(); err != nil {
+				return 0, err
+			}
+			kind = expressionCall
+		case "(", "{", tokenString:
+			if err := p.arguments(); err != nil {
+				return 0, err
+			}
+			kind = expressionCall
+		default:
+			return kind, nil
+		}
+	}
+}
+
+// arguments parses the arguments of a call: a list in parentheses, a table
+// constructor or a string.
+func (p *parser) arguments() error {
+	switch p.tok.kind {
+	case tokenString:
+		return p.advance()
+	case "{":
+		return p.table()
+	case "(":
+		line := p.tok.line
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if p.tok.kind != ")" {
+			if err := p.expressionList(); err != nil {
+				return err
+			}
+		}
+		return p.expectClosing(")", "(", line)
+	}
+	return p.errorf("function arguments expected")
+}
+
+func (p *parser) expressionList() error {
+	for {
+		if err := p.expression(); err != nil {
+			return err
+		}
+		if ok, err := p.accept(","); err != nil || !ok {
+			return err
+		}
+	}
+}
+
+// binaryPriority are the left and right priorities of the binary operators, as in
+// the Lua compiler. A right priority below the left one makes the operator right
+// associative.
+var binaryPriority = map[string][2]int{
+	"or": {1, 1}, "and": {2, 2},
+	"<": {3, 3}, ">": {3, 3}, "<=": {3, 3}, ">=": {3, 3}, "~=": {3, 3}, "==": {3, 3},
+	"|": {4, 4}, "~": {5, 5}, "&": {6, 6}, "<<": {7, 7}, ">>": {7, 7},
+	"..": {9, 8},
+	"+":  {10, 10}, "-": {10, 10},
+	"*": {11, 11}, "/": {11, 11}, "//": {11, 11}, "%": {11, 11},
+	"^": {14, 13},
+}
+
+// unaryPriority binds unary operators tighter than the binary ones but ^.
+const unaryPriority = 12
+
+var unaryOperators = []string{"not", "-", "#", "~"}
+
+func (p *parser) expression() error {
+	return p.subexpression(0)
+}
+
+// subexpression parses an expression whose binary operators bind tighter than
+// limit.
+func (p *parser) subexpression(limit int) error {
+	if slices.Contains(unaryOperators, p.tok.kind) {
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if err := p.subexpression(unaryPriority); err != nil {
+			return err
+		}
+	} else if err := p.simpleExpression(); err != nil {
+		return err
+	}
+	for {
+		priority, ok := binaryPriority[p.tok.kind]
+		if !ok || priority[0] <= limit {
+			return nil
+		}
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if err := p.subexpression(priority[1]); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *parser) simpleExpression() error {
+	switch p.tok.kind {
+	case tokenNumber, tokenString, "nil", "true", "false":
+		return p.advance()
+	case "...":
+		if !p.functions[len(p.functions)-1].vararg {
+			return p.errorf("cannot use '...' outside a vararg function")
+		}
+		return p.advance()
+	case "{":
+		return p.table()
+	case "function":
+		line := p.tok.line
+		if err := p.advance(); err != nil {
+			return err
+		}
+		return p.functionBody(line)
+	}
+	_, err := p.suffixedExpression()
+	return err
+}
+
+// table parses a table constructor: fields separated by ',' or ';', each a
+// [key] = value, a name = value or a value.
+func (p *parser) table() error {
+	line := p.tok.line
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for p.tok.kind != "}" {
+		switch {
+		case p.tok.kind == "[":
+			if err := p.advance(); err != nil {
+				return err
+			}
+			if err := p.expression(); err != nil {
+				return err
+			}
+			if err := p.expect("]"); err != nil {
+				return err
+			}
+			if err := p.expect("="); err != nil {
+				return err
+			}
+		case p.tok.kind == tokenName && p.peekAssignment():
+			if err := p.advance(); err != nil {
+				return err
+			}
+			if err := p.advance(); err != nil { // The '='
+				return err
+			}
+		}
+		if err := p.expression(); err != nil {
+			return err
+		}
+		if ok, err := p.accept(","); err != nil {
+			return err
+		} else if !ok {
+			if ok, err := p.accept(";"); err != nil {
+				return err
+			} else if !ok {
+				break
+			}
+		}
+	}
+	return p.expectClosing("}", "{", line)
+}
+
+// peekAssignment reports whether the token after the current one is a '=' (and
+// not a '=='), without consuming anything.
+func (p *parser) peekAssignment() bool {
+	saved := p.lexer
+	next, err := p.next()
+	p.lexer = saved
+	return err == nil && next.kind == "="
+}
+
+// functionBody parses the parameters and body of a function, from the '('.
+func (p *parser) functionBody(line int) error {
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	fn := function{}
+	if p.tok.kind != ")" {
+		for {
+			if p.tok.kind == "..." {
+				fn.vararg = true
+				if err := p.advance(); err != nil {
+					return err
+				}
+				break
+			}
+			if err := p.name(); err != nil {
+				return err
+			}
+			if ok, err := p.accept(","); err != nil {
+				return err
+			} else if !ok {
+				break
+			}
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return err
+	}
+	p.functions = append(p.functions, fn)
+	err := p.block()
+	p.functions = p.functions[:len(p.functions)-1]
+	if err != nil {
+		return err
+	}
+	return p.expectClosing("end", "function", line)
+}
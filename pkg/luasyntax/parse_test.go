@@ -0,0 +1,72 @@
+package luasyntax
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckValid(t *testing.T) {
+	for _, source := range []string{
+		"",
+		"---@meta\n\n---@class LuaEntity\nLuaEntity = {}\n",
+		"function LuaBootstrap.on_event(event, handler, filters) end\nfunction LuaGuiElement:add(args) end\n",
+		"function LuaRemote.call(interface, function_, ...) end",
+		"defines.events.on_tick = 0\ndefines.direction = {north = 0, [\"east\"] = 4; south = 8,}\n",
+		"local a <const>, b = 1, 2 return a",
+		"return",
+		"return;",
+		"x = function(...) return select('#', ...) end",
+		"for i = 1, 10, 2 do if i > 3 then break elseif i then goto next else end ::next:: end",
+		"for k, v in pairs(t) do while true do break end repeat until k end",
+		"local s = 'a\\'b\\n\\x41\\065\\u{48}\\z\n   c' .. \"d\\\ne\" .. [==[\n]]\n]==]",
+		"--[[ long\ncomment ]] x = 1 --[=[ ]] ]=] y = 0x1p4 + 1e-3 + .5 + 0xA.8 + 3 // 2 ~ 1 << 2 & ~5 | 1",
+		"a.b[c]:d 'e' {f} (g)",
+		"x = -2 ^ -3 .. not true and #t or nil == false",
+		"local function f() end; f()",
+	} {
+		if err := Check(source); err != nil {
+			t.Errorf("%q: %v", source, err)
+		}
+	}
+}
+
+func TestCheckInvalid(t *testing.T) {
+	for _, c := range []struct {
+		source string
+		line   int
+	}{
+		// A description line that lost its comment prefix
+		{"---The entity.\nthe entity's position\nLuaEntity = {}", 2},
+		// An unescaped line break in a string
+		{"x = \"first\nsecond\"", 1},
+		{"x = 'a\\qb'", 1},
+		{"x = '\\300'", 1},
+		{"x = \"unfinished", 1},
+		{"--[[ unfinished\n\n", 1},
+		{"x = [==[ unfinished ]=]", 1},
+		{"function LuaEntity.f(a, b)\n\nx = 1", 3},
+		{"function LuaEntity.f(a,) end", 1},
+		{"function f() return ... end", 1},
+		{"return 1\nx = 2", 2},
+		{"break", 1},
+		{"f() = 1", 1},
+		{"(a) = 1", 1},
+		{"a.b", 1},
+		{"x = 1 +", 1},
+		{"x = 3x", 1},
+		{"x = {a = }", 1},
+		{"local a <mutable> = 1", 1},
+		{"if x then else elseif y then end", 1},
+		{"x = 1 $", 1},
+	} {
+		err := Check(c.source)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("%q: got %v, want a syntax error", c.source, err)
+			continue
+		}
+		if syntaxErr.Line != c.line {
+			t.Errorf("%q: error %q on line %d, want line %d", c.source, syntaxErr.Message, syntaxErr.Line, c.line)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package release
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultGitHubAPIURL is the address of GitHub's REST API.
+const DefaultGitHubAPIURL = "https://api.github.com"
+
+// GitHub publishes releases to a repository with a token allowed to write its
+// contents (a fine-grained token with "Contents: write", or GITHUB_TOKEN in
+// GitHub Actions).
+type GitHub struct {
+	APIURL string // DefaultGitHubAPIURL, or that of a GitHub Enterprise server
+	Repo   string // owner/name
+	Token  string
+}
+
+// Release is a release to publish: its tag, created on the default branch if it
+// doesn't exist, title and notes.
+type Release struct {
+	Tag   string
+	Name  string
+	Notes string
+}
+
+// githubRelease is a release, as returned by the API.
+type githubRelease struct {
+	ID        int64  `json:"id"`
+	HTMLURL   string `json:"html_url"`
+	UploadURL string `json:"upload_url"` // A URI template: .../assets{?name,label}
+	Assets    []struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	} `json:"assets"`
+}
+
+// Publish creates the release, or updates the existing release of the tag, and
+// uploads an asset to it, replacing the asset of the same name if any. It
+// returns the address of the release page.
+func (g *GitHub) Publish(release Release, assetName string, contentType string, asset []byte) (string, error) {
+	created, err := g.upsertRelease(release)
+	if err != nil {
+		return "", err
+	}
+	for _, existing := range created.Assets {
+		if existing.Name == assetName {
+			if err := g.request(http.MethodDelete, fmt.Sprintf("%s/repos/%s/releases/assets/%d", g.APIURL, g.Repo, existing.ID), "", nil, nil); err != nil {
+				return "", fmt.Errorf("failed to replace the asset %s: %w", assetName, err)
+			}
+		}
+	}
+	uploadURL, _, _ := strings.Cut(created.UploadURL, "{")
+	uploadURL += "?" + url.Values{"name": {assetName}}.Encode()
+	if err := g.request(http.MethodPost, uploadURL, contentType, asset, nil); err != nil {
+		return "", fmt.Errorf("failed to upload %s: %w", assetName, err)
+	}
+	return created.HTMLURL, nil
+}
+
+// upsertRelease returns the release of the tag, updated with the name and notes,
+// or creates it.
+func (g *GitHub) upsertRelease(release Release) (*githubRelease, error) {
+	fields, err := json.Marshal(map[string]string{"tag_name": release.Tag, "name": release.Name, "body": release.Notes})
+	if err != nil {
+		return nil, err
+	}
+	var existing githubRelease
+	err = g.request(http.MethodGet, fmt.Sprintf("%s/repos/%s/releases/tags/%s", g.APIURL, g.Repo, url.PathEscape(release.Tag)), "", nil, &existing)
+	var status *statusError
+	switch {
+	case err == nil:
+		var updated githubRelease
+		if err := g.request(http.MethodPatch, fmt.Sprintf("%s/repos/%s/releases/%d", g.APIURL, g.Repo, existing.ID), "application/json", fields, &updated); err != nil {
+			return nil, fmt.Errorf("failed to update the release %s: %w", release.Tag, err)
+		}
+		return &updated, nil
+	case asStatusError(err, &status) && status.Code == http.StatusNotFound:
+		var created githubRelease
+		if err := g.request(http.MethodPost, fmt.Sprintf("%s/repos/%s/releases", g.APIURL, g.Repo), "application/json", fields, &created); err != nil {
+			return nil, fmt.Errorf("failed to create the release %s: %w", release.Tag, err)
+		}
+		return &created, nil
+	default:
+		return nil, fmt.Errorf("failed to look up the release %s: %w", release.Tag, err)
+	}
+}
+
+// statusError is a response of the API with an unexpected status code.
+type statusError struct {
+	Code    int
+	Message string // The message of the API's error document, if any
+}
+
+func (e *statusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("received status code %d", e.Code)
+	}
+	return fmt.Sprintf("received status code %d: %s", e.Code, e.Message)
+}
+
+func asStatusError(err error, target **statusError) bool {
+	e, ok := err.(*statusError)
+	if ok {
+		*target = e
+	}
+	return ok
+}
+
+// request sends an authenticated request to the API, decoding the JSON response
+// into result unless it is nil.
+func (g *GitHub) request(method string, address string, contentType string, body []byte, result any) error {
+	req, err := http.NewRequest(method, address, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+g.Token)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiError struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(data, &apiError)
+		return &statusError{Code: resp.StatusCode, Message: apiError.Message}
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(data, result)
+}
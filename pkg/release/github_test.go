@@ -0,0 +1,58 @@
+package release
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPublishCreatesReleaseAndReplacesAsset(t *testing.T) {
+	var requests []string
+	var uploaded string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("%s %s: missing token", r.Method, r.URL.Path)
+		}
+		release := map[string]any{
+			"id":         1,
+			"html_url":   "https://github.com/owner/defs/releases/tag/factorio-2.0.45",
+			"upload_url": server.URL + "/uploads/1/assets{?name,label}",
+			"assets":     []map[string]any{{"id": 7, "name": "defs.zip"}},
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /repos/owner/defs/releases/tags/factorio-2.0.45":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Not Found"}`))
+		case "POST /repos/owner/defs/releases":
+			json.NewEncoder(w).Encode(release)
+		case "DELETE /repos/owner/defs/releases/assets/7":
+			w.WriteHeader(http.StatusNoContent)
+		case "POST /uploads/1/assets":
+			body, _ := io.ReadAll(r.Body)
+			uploaded = r.URL.Query().Get("name") + ":" + string(body)
+			w.Write([]byte(`{}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	github := &GitHub{APIURL: server.URL, Repo: "owner/defs", Token: "token"}
+	page, err := github.Publish(Release{Tag: "factorio-2.0.45", Name: "factorio-2.0.45"}, "defs.zip", "application/zip", []byte("zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page != "https://github.com/owner/defs/releases/tag/factorio-2.0.45" {
+		t.Errorf("page = %q", page)
+	}
+	if uploaded != "defs.zip:zip" {
+		t.Errorf("uploaded %q", uploaded)
+	}
+	if len(requests) != 4 {
+		t.Errorf("requests = %v", requests)
+	}
+}
@@ -0,0 +1,86 @@
+// Package release packages generated definitions for redistribution and
+// publishes them as GitHub releases.
+package release
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ManifestFile is the name of the manifest at the root of a package.
+const ManifestFile = "manifest.json"
+
+// Manifest describes a package of definitions, so that consumers can tell which
+// game versions it is for and check its files.
+type Manifest struct {
+	Name             string   `json:"name"`
+	GameVersions     []string `json:"game_versions"`     // The versions the definitions document, e.g. "2.0.28"
+	GeneratorVersion string   `json:"generator_version"` // See generator.Version
+	Files            []File   `json:"files"`             // Every file of the package but the manifest
+}
+
+// File is a file of a package.
+type File struct {
+	Path   string `json:"path"` // Slash-separated, relative to the package root
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Package writes a zip of the files under dir to w, with the manifest (whose
+// Files it fills in) at its root. The paths in the zip are relative to dir, in
+// the order of filepath.WalkDir, so that the same files make the same zip.
+func Package(dir string, manifest *Manifest, w io.Writer) error {
+	archive := zip.NewWriter(w)
+	manifest.Files = nil
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if name == ManifestFile {
+			return fmt.Errorf("%s is reserved for the manifest of the package", path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		f, err := archive.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(data); err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, File{Path: name, Size: int64(len(data)), SHA256: fmt.Sprintf("%x", sha256.Sum256(data))})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(manifest.Files) == 0 {
+		return fmt.Errorf("no files to package in %s", dir)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := archive.Create(ManifestFile)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return archive.Close()
+}
@@ -0,0 +1,222 @@
+package workspace
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// LibrarySetting is the library of a workspace as one of its configuration
+// files sets it.
+type LibrarySetting struct {
+	File      string   // .luarc.json or .vscode/settings.json
+	Libraries []string // Absolute, or as written when they can't be resolved
+}
+
+// ReadLibraries returns the library of the workspace in dir, from each of its
+// configuration files that sets one: .luarc.json (with dotted or nested keys,
+// see UpdateLuarc) and .vscode/settings.json. Relative entries and those using
+// ${workspaceFolder} are resolved against dir.
+func ReadLibraries(dir string) ([]LibrarySetting, error) {
+	var settings []LibrarySetting
+	for _, file := range []struct {
+		path, key string
+		jsonc     bool
+	}{
+		{filepath.Join(dir, ".luarc.json"), "workspace.library", false},
+		{filepath.Join(dir, ".vscode", "settings.json"), "Lua.workspace.library", true},
+	} {
+		data, err := os.ReadFile(file.path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		text := string(data)
+		if file.jsonc {
+			text = stripJSONC(text)
+		}
+		var config map[string]any
+		if err := json.Unmarshal([]byte(text), &config); err != nil {
+			return nil, err
+		}
+		parent, name := settingParent(config, file.key)
+		list, ok := parent[name].([]any)
+		if !ok {
+			continue
+		}
+		setting := LibrarySetting{File: file.path}
+		for _, entry := range list {
+			library, ok := entry.(string)
+			if !ok {
+				continue
+			}
+			library = strings.ReplaceAll(library, "${workspaceFolder}", dir)
+			if !filepath.IsAbs(library) {
+				library = filepath.Join(dir, library)
+			}
+			if abs, err := filepath.Abs(library); err == nil {
+				library = abs
+			}
+			setting.Libraries = append(setting.Libraries, library)
+		}
+		settings = append(settings, setting)
+	}
+	return settings, nil
+}
+
+// factorioClass matches the declaration of a class of the Factorio API in LuaLS
+// annotations, by which other definitions of the API are recognized.
+var factorioClass = regexp.MustCompile(`^---\s*@class\s+(\w+\.)?LuaEntity\b`)
+
+// DeclaresFactorioAPI reports whether a library (a directory or a file) holds
+// definitions of the Factorio API, such as those of another generator or of an
+// editor extension: whether one of its Lua files declares LuaEntity.
+func DeclaresFactorioAPI(library string) bool {
+	found := errors.New("found")
+	err := filepath.WalkDir(library, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !strings.HasSuffix(path, ".lua") {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return nil
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(nil, 1<<20)
+		for scanner.Scan() {
+			if factorioClass.MatchString(scanner.Text()) {
+				return found
+			}
+		}
+		return nil
+	})
+	return err == found
+}
+
+// gameVersionLine matches the line of factorio-current.log naming the version of
+// the game, e.g. "   0.000 2024-10-21 12:00:00; Factorio 2.0.45 (build 80000, linux64, full)".
+var gameVersionLine = regexp.MustCompile(`Factorio (\d+\.\d+\.\d+) \(build`)
+
+// InstalledGameVersion returns the version of the game that last ran with the
+// user data directory, from its factorio-current.log.
+func InstalledGameVersion(userDataDir string) (string, error) {
+	f, err := os.Open(filepath.Join(userDataDir, "factorio-current.log"))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for n := 0; n < 20 && scanner.Scan(); n++ {
+		if m := gameVersionLine.FindStringSubmatch(scanner.Text()); m != nil {
+			return m[1], nil
+		}
+	}
+	return "", errors.New("factorio-current.log doesn't name the game version")
+}
+
+// LuaLSInstall is an installation of the Lua language server.
+type LuaLSInstall struct {
+	Where   string // The executable, or the directory of the VS Code extension
+	Version string // e.g. "3.13.5"
+}
+
+// OlderLuaLS reports whether a version of LuaLS is older than the given one.
+func OlderLuaLS(version string, than string) bool {
+	return compareVersions(version, than) < 0
+}
+
+// LuaLSRequirement is a syntax of the definitions that older versions of LuaLS
+// don't read.
+type LuaLSRequirement struct {
+	Syntax  string // e.g. "tuple types"
+	Version string // The first version of LuaLS reading it
+	Where   string // The file and line of its first use
+	Fix     string // The generation option avoiding it
+}
+
+// luaLSSyntaxes are the syntaxes RequiredLuaLS looks for: those the generator
+// emits by default and has an option to avoid. Tuples are looked for in aliases,
+// whose lines have no description to mistake for a type.
+var luaLSSyntaxes = []struct {
+	pattern *regexp.Regexp
+	LuaLSRequirement
+}{
+	{regexp.MustCompile(`^---@alias \S+ .*\[[\w.]+(\[\])?, `), LuaLSRequirement{Syntax: "tuple types", Version: "3.6.0", Fix: "--tuple-style table"}},
+	{regexp.MustCompile(`^---@field \S+\? `), LuaLSRequirement{Syntax: "optional fields", Version: "3.0.0", Fix: "--optional-style nil-union"}},
+}
+
+// RequiredLuaLS returns the syntaxes of the LuaLS definitions in library (a
+// directory or a file) that older versions of LuaLS don't read, with their first
+// use of each.
+func RequiredLuaLS(library string) []LuaLSRequirement {
+	var requirements []LuaLSRequirement
+	seen := make([]bool, len(luaLSSyntaxes))
+	filepath.WalkDir(library, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !strings.HasSuffix(path, ".lua") {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return nil
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(nil, 1<<20)
+		for line := 1; scanner.Scan(); line++ {
+			for i, syntax := range luaLSSyntaxes {
+				if !seen[i] && syntax.pattern.MatchString(scanner.Text()) {
+					seen[i] = true
+					requirement := syntax.LuaLSRequirement
+					requirement.Where = fmt.Sprintf("%s:%d", path, line)
+					requirements = append(requirements, requirement)
+				}
+			}
+		}
+		return nil
+	})
+	return requirements
+}
+
+// luaLSExtension matches the directories of the LuaLS VS Code extension, named
+// after its version and, for platform builds, the platform.
+var luaLSExtension = regexp.MustCompile(`^sumneko\.lua-(\d+\.\d+\.\d+)`)
+
+// FindLuaLS returns the installations of LuaLS found: lua-language-server on the
+// PATH, and the VS Code extension in the extension directories of VS Code,
+// VS Code Server and VSCodium.
+func FindLuaLS() []LuaLSInstall {
+	var installs []LuaLSInstall
+	if path, err := exec.LookPath("lua-language-server"); err == nil {
+		if out, err := exec.Command(path, "--version").Output(); err == nil {
+			if version := regexp.MustCompile(`\d+\.\d+\.\d+`).FindString(string(out)); version != "" {
+				installs = append(installs, LuaLSInstall{Where: path, Version: version})
+			}
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return installs
+	}
+	for _, dir := range []string{".vscode", ".vscode-server", ".vscode-oss"} {
+		entries, err := os.ReadDir(filepath.Join(home, dir, "extensions"))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if m := luaLSExtension.FindStringSubmatch(entry.Name()); m != nil && entry.IsDir() {
+				installs = append(installs, LuaLSInstall{Where: filepath.Join(home, dir, "extensions", entry.Name()), Version: m[1]})
+			}
+		}
+	}
+	return installs
+}
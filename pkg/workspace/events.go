@@ -0,0 +1,231 @@
+package workspace
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// CustomEventDefinition is a custom event of a mod: an id from
+// script.generate_event_name(), and the payload fields given to
+// script.raise_event with it.
+type CustomEventDefinition struct {
+	Name   string // The variable or field holding the id, e.g. "on_thing_done"
+	File   string // The file generating the id
+	Fields []CustomEventField
+}
+
+// CustomEventField is a field of the payload of a custom event, with the Lua type
+// of the values it is given.
+type CustomEventField struct {
+	Name     string
+	Type     string // e.g. "string", "integer | string"; "any" if not a literal
+	Optional bool   // Left out by some of the raise_event calls
+}
+
+// ScanCustomEvents finds the custom events of the mod in dir by scanning its Lua
+// files, without running them. An event is found where its id is assigned, as in
+// `local on_thing_done = script.generate_event_name()` or
+// `M.events.on_thing_done = script.generate_event_name()`, and named after the
+// variable or field. Its payload fields come from the table constructors passed
+// to script.raise_event with an expression ending in that name; the type of a
+// field is the Lua type of the literals it is given, "any" for other
+// expressions. Fields not given by every call are optional.
+func ScanCustomEvents(dir string) ([]CustomEventDefinition, error) {
+	files, err := luaFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(map[string]*CustomEventDefinition)
+	var order []string
+	var raises []eventRaise
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		tokens, err := tokenizeLua(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for i := range tokens {
+			if name, ok := generatedEventName(tokens, i); ok && events[name] == nil {
+				events[name] = &CustomEventDefinition{Name: name, File: path}
+				order = append(order, name)
+			}
+			if raise, ok := raisedEvent(tokens, i); ok {
+				raises = append(raises, raise)
+			}
+		}
+	}
+
+	var definitions []CustomEventDefinition
+	for _, name := range order {
+		event := events[name]
+		event.Fields = mergePayloads(raises, name)
+		definitions = append(definitions, *event)
+	}
+	return definitions, nil
+}
+
+// luaFiles lists the Lua files under dir, leaving out hidden directories.
+func luaFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() && path != dir && strings.HasPrefix(entry.Name(), ".") {
+			return filepath.SkipDir // .git, .vscode, ...
+		}
+		if !entry.IsDir() && strings.HasSuffix(path, ".lua") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// isToken reports whether tokens[i] is the given punctuation or name.
+func isToken(tokens []luaToken, i int, text string) bool {
+	return i >= 0 && i < len(tokens) && tokens[i].kind != 's' && tokens[i].text == text
+}
+
+// generatedEventName returns the name an event id is assigned to, if tokens[i]
+// starts `name = script.generate_event_name()`.
+func generatedEventName(tokens []luaToken, i int) (string, bool) {
+	if tokens[i].kind != 'n' || !isToken(tokens, i+1, "=") || !isToken(tokens, i+2, "script") ||
+		!isToken(tokens, i+3, ".") || !isToken(tokens, i+4, "generate_event_name") || !isToken(tokens, i+5, "(") {
+		return "", false
+	}
+	return tokens[i].text, true
+}
+
+// eventRaise is a call of script.raise_event with a table constructor.
+type eventRaise struct {
+	event  string            // The last name of the event expression
+	fields map[string]string // Field types
+}
+
+// raisedEvent reads the call of script.raise_event starting at tokens[i], if
+// its payload is a table constructor.
+func raisedEvent(tokens []luaToken, i int) (eventRaise, bool) {
+	if !isToken(tokens, i, "script") || !isToken(tokens, i+1, ".") || !isToken(tokens, i+2, "raise_event") || !isToken(tokens, i+3, "(") {
+		return eventRaise{}, false
+	}
+	// The event expression runs up to the first top-level comma.
+	event := ""
+	depth := 0
+	j := i + 4
+	for ; j < len(tokens) && !(depth == 0 && isToken(tokens, j, ",")); j++ {
+		switch {
+		case tokens[j].kind == 'p' && strings.Contains("([{", tokens[j].text):
+			depth++
+		case tokens[j].kind == 'p' && strings.Contains(")]}", tokens[j].text):
+			if depth == 0 {
+				return eventRaise{}, false // No payload
+			}
+			depth--
+		case tokens[j].kind == 'n' && depth == 0:
+			event = tokens[j].text
+		case tokens[j].kind == 's' && depth == 1 && isToken(tokens, j-1, "["):
+			event = tokens[j].text // events["on_thing_done"]
+		}
+	}
+	if event == "" || !isToken(tokens, j+1, "{") {
+		return eventRaise{}, false
+	}
+	return eventRaise{event: event, fields: fieldTypes(tokens, j+1)}, true
+}
+
+// fieldTypes returns the Lua types of the top-level fields of the table
+// constructor opening at tokens[open].
+func fieldTypes(tokens []luaToken, open int) map[string]string {
+	fields := make(map[string]string)
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		if tokens[i].kind == 'p' {
+			switch tokens[i].text {
+			case "{", "(", "[":
+				depth++
+			case "}", ")", "]":
+				depth--
+			}
+		}
+		if depth == 0 {
+			break
+		}
+		if depth != 1 || tokens[i].kind != 'n' || !isToken(tokens, i+1, "=") || isToken(tokens, i-1, ".") || i+2 >= len(tokens) {
+			continue
+		}
+		value := tokens[i+2]
+		luaType := "any"
+		switch {
+		case value.kind == 'p' && value.text == "{":
+			luaType = "table"
+		case value.kind == 'n' && value.text == "function":
+			luaType = "function"
+		case !endsField(tokens, i+3):
+			// An expression, e.g. player.index
+		case value.kind == 's':
+			luaType = "string"
+		case value.kind == '0' && strings.ContainsAny(value.text, ".eEpP") && !strings.HasPrefix(value.text, "0x"):
+			luaType = "number"
+		case value.kind == '0':
+			luaType = "integer"
+		case value.text == "true" || value.text == "false":
+			luaType = "boolean"
+		case value.text == "nil":
+			continue
+		}
+		fields[tokens[i].text] = luaType
+	}
+	return fields
+}
+
+// mergePayloads merges the fields of the payloads an event is raised with.
+func mergePayloads(raises []eventRaise, event string) []CustomEventField {
+	types := make(map[string][]string)
+	count := make(map[string]int)
+	calls := 0
+	for _, raise := range raises {
+		if raise.event != event {
+			continue
+		}
+		calls++
+		for name, luaType := range raise.fields {
+			count[name]++
+			if !slices.Contains(types[name], luaType) {
+				types[name] = append(types[name], luaType)
+			}
+		}
+	}
+	var fields []CustomEventField
+	for _, name := range sortedKeys(types) {
+		if slices.Contains(types[name], "number") {
+			types[name] = slices.DeleteFunc(types[name], func(t string) bool { return t == "integer" })
+		}
+		luaType := strings.Join(types[name], " | ")
+		if slices.Contains(types[name], "any") {
+			luaType = "any"
+		}
+		fields = append(fields, CustomEventField{Name: name, Type: luaType, Optional: count[name] < calls})
+	}
+	return fields
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
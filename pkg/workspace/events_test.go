@@ -0,0 +1,60 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestScanCustomEvents(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"control.lua": `local events = require("scripts.events")
+local on_thing_done = script.generate_event_name()
+
+script.on_event(defines.events.on_tick, function(event)
+  script.raise_event(on_thing_done, {player_index = event.player_index, name = "thing", count = 3})
+  script.raise_event(on_thing_done, {name = "other", count = 1.5, silent = true, extra = nil})
+  script.raise_event(events.on_area_cleared, {area = {left_top = {0, 0}}, callback = function() end})
+end)
+`,
+		"scripts/events.lua": `local M = {}
+M.on_area_cleared = script.generate_event_name()
+M.on_unraised = script.generate_event_name()
+return M
+`,
+		".git/hooks/unused.lua": `local on_hidden = script.generate_event_name()`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	events, err := ScanCustomEvents(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	control, scripts := filepath.Join(dir, "control.lua"), filepath.Join(dir, "scripts", "events.lua")
+	want := []CustomEventDefinition{
+		{Name: "on_thing_done", File: control, Fields: []CustomEventField{
+			{Name: "count", Type: "number"},
+			{Name: "name", Type: "string"},
+			{Name: "player_index", Type: "any", Optional: true},
+			{Name: "silent", Type: "boolean", Optional: true},
+		}},
+		{Name: "on_area_cleared", File: scripts, Fields: []CustomEventField{
+			{Name: "area", Type: "table"},
+			{Name: "callback", Type: "function"},
+		}},
+		{Name: "on_unraised", File: scripts},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("ScanCustomEvents =\n%+v\nwant\n%+v", events, want)
+	}
+}
@@ -0,0 +1,314 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// jsoncDocument edits the top-level members of a JSON object written as JSONC,
+// the JSON with comments and trailing commas that VS Code uses for its settings.
+// Edits are made to the text, so comments, formatting and the order of members
+// survive; only the edited values are rewritten.
+type jsoncDocument struct {
+	text   string
+	indent string // One level of indentation, as used by the document
+}
+
+// jsoncMember is a top-level member of the document: its key and the offsets of
+// its value, and of its line's indentation.
+type jsoncMember struct {
+	key        string
+	lineStart  int
+	valueStart int
+	valueEnd   int
+}
+
+func newJSONCDocument(text string) (*jsoncDocument, error) {
+	if strings.TrimSpace(text) == "" {
+		text = "{\n}\n"
+	}
+	doc := &jsoncDocument{text: text, indent: "    "}
+	members, _, err := doc.members()
+	if err != nil {
+		return nil, err
+	}
+	if len(members) > 0 {
+		if indent := leadingSpace(text[members[0].lineStart:]); indent != "" {
+			doc.indent = indent
+		}
+	}
+	return doc, nil
+}
+
+// leadingSpace returns the spaces and tabs a line starts with.
+func leadingSpace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// skipSpace returns the offset of the next token after i, skipping whitespace and comments.
+func (d *jsoncDocument) skipSpace(i int) int {
+	return skipJSONCSpace(d.text, i)
+}
+
+func skipJSONCSpace(text string, i int) int {
+	for i < len(text) {
+		switch {
+		case strings.ContainsRune(" \t\r\n", rune(text[i])):
+			i++
+		case strings.HasPrefix(text[i:], "//"):
+			end := strings.IndexByte(text[i:], '\n')
+			if end < 0 {
+				return len(text)
+			}
+			i += end + 1
+		case strings.HasPrefix(text[i:], "/*"):
+			end := strings.Index(text[i+2:], "*/")
+			if end < 0 {
+				return len(text)
+			}
+			i += 2 + end + 2
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// skipValue returns the offset just past the value starting at i.
+func (d *jsoncDocument) skipValue(i int) (int, error) {
+	if i >= len(d.text) {
+		return 0, fmt.Errorf("unexpected end of document")
+	}
+	switch d.text[i] {
+	case '"':
+		for j := i + 1; j < len(d.text); j++ {
+			switch d.text[j] {
+			case '\\':
+				j++
+			case '"':
+				return j + 1, nil
+			}
+		}
+		return 0, fmt.Errorf("unterminated string at offset %d", i)
+	case '{', '[':
+		closing := byte('}')
+		if d.text[i] == '[' {
+			closing = ']'
+		}
+		j := d.skipSpace(i + 1)
+		for j < len(d.text) && d.text[j] != closing {
+			end, err := d.skipValue(j)
+			if err != nil {
+				return 0, err
+			}
+			j = d.skipSpace(end)
+			if j < len(d.text) && (d.text[j] == ',' || d.text[j] == ':') {
+				j = d.skipSpace(j + 1)
+			}
+		}
+		if j >= len(d.text) {
+			return 0, fmt.Errorf("unterminated %c at offset %d", d.text[i], i)
+		}
+		return j + 1, nil
+	default:
+		j := i
+		for j < len(d.text) && !strings.ContainsRune(" \t\r\n,:]}/", rune(d.text[j])) {
+			j++
+		}
+		if j == i {
+			return 0, fmt.Errorf("unexpected %q at offset %d", d.text[i], i)
+		}
+		return j, nil
+	}
+}
+
+// members returns the top-level members and the offset of the closing brace.
+func (d *jsoncDocument) members() ([]jsoncMember, int, error) {
+	i := d.skipSpace(0)
+	if i >= len(d.text) || d.text[i] != '{' {
+		return nil, 0, fmt.Errorf("the document is not a JSON object")
+	}
+	var members []jsoncMember
+	i = d.skipSpace(i + 1)
+	for i < len(d.text) && d.text[i] != '}' {
+		keyEnd, err := d.skipValue(i)
+		if err != nil {
+			return nil, 0, err
+		}
+		var key string
+		if err := json.Unmarshal([]byte(d.text[i:keyEnd]), &key); err != nil {
+			return nil, 0, fmt.Errorf("invalid key at offset %d", i)
+		}
+		colon := d.skipSpace(keyEnd)
+		if colon >= len(d.text) || d.text[colon] != ':' {
+			return nil, 0, fmt.Errorf("expected ':' after %q", key)
+		}
+		valueStart := d.skipSpace(colon + 1)
+		valueEnd, err := d.skipValue(valueStart)
+		if err != nil {
+			return nil, 0, err
+		}
+		members = append(members, jsoncMember{
+			key:        key,
+			lineStart:  strings.LastIndexByte(d.text[:i], '\n') + 1,
+			valueStart: valueStart,
+			valueEnd:   valueEnd,
+		})
+		i = d.skipSpace(valueEnd)
+		if i < len(d.text) && d.text[i] == ',' {
+			i = d.skipSpace(i + 1)
+		}
+	}
+	if i >= len(d.text) {
+		return nil, 0, fmt.Errorf("unterminated object")
+	}
+	return members, i, nil
+}
+
+// member returns the top-level member with the given key, if any.
+func (d *jsoncDocument) member(key string) (jsoncMember, bool, error) {
+	members, _, err := d.members()
+	if err != nil {
+		return jsoncMember{}, false, err
+	}
+	for _, member := range members {
+		if member.key == key {
+			return member, true, nil
+		}
+	}
+	return jsoncMember{}, false, nil
+}
+
+// set sets a top-level member, replacing its value or adding it at the end.
+func (d *jsoncDocument) set(key string, value any) error {
+	encoded, err := json.MarshalIndent(value, d.indent, d.indent)
+	if err != nil {
+		return err
+	}
+	member, ok, err := d.member(key)
+	if err != nil {
+		return err
+	}
+	if ok {
+		d.text = d.text[:member.valueStart] + string(encoded) + d.text[member.valueEnd:]
+		return nil
+	}
+
+	members, closing, err := d.members()
+	if err != nil {
+		return err
+	}
+	encodedKey, _ := json.Marshal(key)
+	entry := fmt.Sprintf("%s%s: %s", d.indent, encodedKey, encoded)
+	// The member goes last, after any comments following the current last member,
+	// which gets the comma it lacks (unless it has a trailing one).
+	body := strings.TrimRight(d.text[:closing], " \t\r\n")
+	if len(members) > 0 {
+		last := members[len(members)-1]
+		if d.text[d.skipSpace(last.valueEnd)] != ',' {
+			body = body[:last.valueEnd] + "," + body[last.valueEnd:]
+		}
+	}
+	d.text = body + "\n" + entry + "\n" + d.text[closing:]
+	return nil
+}
+
+// appendList adds the values missing from a top-level list member, creating it if
+// needed. Existing elements and comments between them are kept.
+func (d *jsoncDocument) appendList(key string, values ...string) error {
+	member, ok, err := d.member(key)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return d.set(key, values)
+	}
+	var existing []any
+	if err := json.Unmarshal([]byte(stripJSONC(d.text[member.valueStart:member.valueEnd])), &existing); err != nil {
+		return fmt.Errorf("setting %s is not a list", key)
+	}
+	var missing []string
+	for _, value := range values {
+		found := false
+		for _, element := range existing {
+			found = found || element == value
+		}
+		if !found {
+			missing = append(missing, value)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	// The values go last, after any comments following the current last element,
+	// which gets the comma it lacks (unless it has a trailing one).
+	memberIndent := leadingSpace(d.text[member.lineStart:])
+	closing := member.valueEnd - 1
+	lastEnd := -1
+	for i := d.skipSpace(member.valueStart + 1); i < closing; {
+		end, err := d.skipValue(i)
+		if err != nil {
+			return err
+		}
+		lastEnd = end
+		i = d.skipSpace(end)
+		if d.text[i] == ',' {
+			i = d.skipSpace(i + 1)
+		}
+	}
+	body := strings.TrimRight(d.text[:closing], " \t\r\n")
+	if lastEnd >= 0 && d.text[d.skipSpace(lastEnd)] != ',' {
+		body = body[:lastEnd] + "," + body[lastEnd:]
+	}
+	var sb strings.Builder
+	sb.WriteString(body)
+	for n, value := range missing {
+		if n > 0 {
+			sb.WriteString(",")
+		}
+		encoded, _ := json.Marshal(value)
+		sb.WriteString("\n" + memberIndent + d.indent + string(encoded))
+	}
+	sb.WriteString("\n" + memberIndent)
+	d.text = sb.String() + d.text[closing:]
+	return nil
+}
+
+// stripJSONC removes the comments and trailing commas from a JSONC value, so it
+// can be decoded as JSON.
+func stripJSONC(text string) string {
+	var sb strings.Builder
+	for i := 0; i < len(text); {
+		next := skipJSONCSpace(text, i)
+		if next > i {
+			sb.WriteByte(' ')
+			i = next
+			continue
+		}
+		switch text[i] {
+		case '"':
+			j := i + 1
+			for ; j < len(text) && text[j] != '"'; j++ {
+				if text[j] == '\\' {
+					j++
+				}
+			}
+			end := min(j+1, len(text))
+			sb.WriteString(text[i:end])
+			i = end
+		case ',':
+			if after := skipJSONCSpace(text, i+1); after < len(text) && (text[after] == ']' || text[after] == '}') {
+				i = after
+				continue
+			}
+			sb.WriteByte(',')
+			i++
+		default:
+			sb.WriteByte(text[i])
+			i++
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,168 @@
+package workspace
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJSONCSet(t *testing.T) {
+	tests := []struct {
+		name, text string
+		key        string
+		value      any
+		want       string
+	}{
+		{"empty", "", "Lua.runtime.version", "Lua 5.2",
+			"{\n    \"Lua.runtime.version\": \"Lua 5.2\"\n}\n"},
+		{"added last", "{\n  \"editor.tabSize\": 2\n}\n", "Lua.runtime.version", "Lua 5.2",
+			"{\n  \"editor.tabSize\": 2,\n  \"Lua.runtime.version\": \"Lua 5.2\"\n}\n"},
+		{"after a trailing comma", "{\n  \"editor.tabSize\": 2,\n}\n", "Lua.runtime.version", "Lua 5.2",
+			"{\n  \"editor.tabSize\": 2,\n  \"Lua.runtime.version\": \"Lua 5.2\"\n}\n"},
+		{"after comments", "{\n  // Editor\n  \"editor.tabSize\": 2 // two\n  /* end */\n}\n", "Lua.runtime.version", "Lua 5.2",
+			"{\n  // Editor\n  \"editor.tabSize\": 2, // two\n  /* end */\n  \"Lua.runtime.version\": \"Lua 5.2\"\n}\n"},
+		{"replaced", "{\n\t// Which Lua\n\t\"Lua.runtime.version\": \"Lua 5.4\", // old\n\t\"editor.tabSize\": 2\n}", "Lua.runtime.version", "Lua 5.2",
+			"{\n\t// Which Lua\n\t\"Lua.runtime.version\": \"Lua 5.2\", // old\n\t\"editor.tabSize\": 2\n}"},
+		{"indented like the document", "{\n\t\"a\": 1\n}\n", "b", []string{"x"},
+			"{\n\t\"a\": 1,\n\t\"b\": [\n\t\t\"x\"\n\t]\n}\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc, err := newJSONCDocument(test.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := doc.set(test.key, test.value); err != nil {
+				t.Fatal(err)
+			}
+			if doc.text != test.want {
+				t.Errorf("set(%q) =\n%s\nwant\n%s", test.key, doc.text, test.want)
+			}
+			if !json.Valid([]byte(stripJSONC(doc.text))) {
+				t.Errorf("set(%q) isn't valid JSONC:\n%s", test.key, doc.text)
+			}
+		})
+	}
+}
+
+func TestJSONCAppendList(t *testing.T) {
+	tests := []struct {
+		name, text string
+		values     []string
+		want       string
+	}{
+		{"created", "{\n  \"a\": 1\n}\n", []string{"x", "y"},
+			"{\n  \"a\": 1,\n  \"list\": [\n    \"x\",\n    \"y\"\n  ]\n}\n"},
+		{"missing values", "{\n  \"list\": [\n    \"x\" // mine\n  ]\n}\n", []string{"x", "y"},
+			"{\n  \"list\": [\n    \"x\", // mine\n    \"y\"\n  ]\n}\n"},
+		{"after a trailing comma", "{\n  \"list\": [\n    \"x\",\n  ],\n}\n", []string{"y"},
+			"{\n  \"list\": [\n    \"x\",\n    \"y\"\n  ],\n}\n"},
+		{"empty list", "{\n  \"list\": []\n}\n", []string{"x"},
+			"{\n  \"list\": [\n    \"x\"\n  ]\n}\n"},
+		{"nothing missing", "{\n  \"list\": [\"y\", /* x */ \"x\",],\n}\n", []string{"x", "y"},
+			"{\n  \"list\": [\"y\", /* x */ \"x\",],\n}\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc, err := newJSONCDocument(test.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := doc.appendList("list", test.values...); err != nil {
+				t.Fatal(err)
+			}
+			if doc.text != test.want {
+				t.Errorf("appendList =\n%s\nwant\n%s", doc.text, test.want)
+			}
+			// Appending again changes nothing.
+			if err := doc.appendList("list", test.values...); err != nil {
+				t.Fatal(err)
+			}
+			if doc.text != test.want {
+				t.Errorf("appendList again =\n%s", doc.text)
+			}
+		})
+	}
+
+	doc, _ := newJSONCDocument(`{"list": "not a list"}`)
+	if err := doc.appendList("list", "x"); err == nil {
+		t.Error("appendList to a string succeeded")
+	}
+}
+
+func TestJSONCInvalid(t *testing.T) {
+	for _, text := range []string{"[]", "{\"a\": 1", "{\"a\" 1}", "{\"a\": \"open}", "// only a comment"} {
+		if _, err := newJSONCDocument(text); err == nil {
+			t.Errorf("newJSONCDocument(%q) succeeded", text)
+		}
+	}
+}
+
+func TestStripJSONC(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{`[1, 2,]`, `[1, 2]`},
+		{`{"a": 1, /* c */ }`, `{"a": 1}`},
+		{"[\"//not a comment\", // a comment\n 2]", `["//not a comment", 2]`},
+		{`["a,]", "\"b\""]`, `["a,]", "\"b\""]`},
+	}
+	for _, test := range tests {
+		if got := stripJSONC(test.text); got != test.want {
+			t.Errorf("stripJSONC(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+// Merging the settings keeps the user's and doesn't change them on later runs.
+func TestInstallVSCode(t *testing.T) {
+	dir := t.TempDir()
+	settings := "{\n  // Mine\n  \"editor.tabSize\": 2,\n  \"Lua.workspace.library\": [\n    \"other/library\", // also mine\n  ],\n}\n"
+	path := filepath.Join(dir, ".vscode", "settings.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(settings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var first string
+	for run := range 2 {
+		if _, err := InstallVSCode(dir, []string{"/defs/factorio"}, "/defs/plugin.lua"); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if run == 0 {
+			first = string(data)
+		} else if string(data) != first {
+			t.Errorf("the second merge changed the settings:\n%s\nto\n%s", first, data)
+		}
+	}
+
+	var merged struct {
+		TabSize int      `json:"editor.tabSize"`
+		Library []string `json:"Lua.workspace.library"`
+		Version string   `json:"Lua.runtime.version"`
+		Plugin  string   `json:"Lua.runtime.plugin"`
+		Globals []string `json:"Lua.diagnostics.globals"`
+	}
+	if err := json.Unmarshal([]byte(stripJSONC(first)), &merged); err != nil {
+		t.Fatalf("the merged settings aren't valid JSONC: %v\n%s", err, first)
+	}
+	if merged.TabSize != 2 || len(merged.Library) != 2 || merged.Library[0] != "other/library" || merged.Library[1] != "/defs/factorio" {
+		t.Errorf("merged settings = %+v", merged)
+	}
+	if merged.Version != LuaVersion || merged.Plugin != "/defs/plugin.lua" || len(merged.Globals) != len(FactorioGlobals) {
+		t.Errorf("merged settings = %+v", merged)
+	}
+	for _, comment := range []string{"// Mine", "// also mine"} {
+		if !strings.Contains(first, comment) {
+			t.Errorf("the merge lost %q:\n%s", comment, first)
+		}
+	}
+}
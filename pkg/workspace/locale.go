@@ -0,0 +1,87 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Locale holds the localised strings of a language by section and key, as in
+// Factorio's locale files: [item-name] iron-plate=Iron plate.
+type Locale map[string]map[string]string
+
+// ReadLocale reads the locale files (*.cfg) of a language, e.g. "en", from dir:
+// a mod directory, whose files are in locale/<language>/, or the game's data
+// directory, in which case the locales of its builtin mods (base, core, ...) are
+// read in turn. Strings read later replace the earlier ones of the same key.
+func ReadLocale(dir string, language string) (Locale, error) {
+	locale := make(Locale)
+	dirs := []string{dir}
+	if _, err := os.Stat(filepath.Join(dir, "locale")); errors.Is(err, fs.ErrNotExist) {
+		dirs = nil
+		for _, mod := range BuiltinMods {
+			if _, err := os.Stat(filepath.Join(dir, mod, "locale")); err == nil {
+				dirs = append(dirs, filepath.Join(dir, mod))
+			}
+		}
+		if len(dirs) == 0 {
+			return nil, fmt.Errorf("%s has no locale directory, and isn't the game's data directory", dir)
+		}
+	}
+	for _, modDir := range dirs {
+		files, err := filepath.Glob(filepath.Join(modDir, "locale", language, "*.cfg"))
+		if err != nil {
+			return nil, err
+		}
+		slices.Sort(files)
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			locale.parse(string(data))
+		}
+	}
+	return locale, nil
+}
+
+// Merge copies the strings of other into the locale, replacing those of the same
+// key.
+func (l Locale) Merge(other Locale) {
+	for section, values := range other {
+		if l[section] == nil {
+			l[section] = make(map[string]string, len(values))
+		}
+		for key, value := range values {
+			l[section][key] = value
+		}
+	}
+}
+
+// parse adds the strings of a locale file. Lines are a [section] header, a
+// key=value pair or a comment (# or ;); pairs before the first header are in the
+// "" section. The \n escape in values stands for a line break.
+func (l Locale) parse(content string) {
+	section := ""
+	for _, line := range strings.Split(strings.TrimPrefix(content, "\ufeff"), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			section = strings.TrimSpace(line[1 : len(line)-1])
+		default:
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				continue
+			}
+			if l[section] == nil {
+				l[section] = make(map[string]string)
+			}
+			l[section][strings.TrimSpace(key)] = strings.ReplaceAll(value, `\n`, "\n")
+		}
+	}
+}
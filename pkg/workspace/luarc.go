@@ -0,0 +1,99 @@
+// Package workspace sets up a mod's workspace to use the generated definitions,
+// so editors pick them up without manual configuration.
+package workspace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// LuaVersion is the Lua version Factorio runs mods with.
+const LuaVersion = "Lua 5.2"
+
+// FactorioGlobals are the globals Factorio provides outside the documented API
+// (log, serpent, ...) and those of the popular debugger mod, which LuaLS would
+// otherwise report as undefined.
+var FactorioGlobals = []string{"log", "localised_print", "table_size", "serpent", "__DebugAdapter", "__Profiler"}
+
+// UpdateLuarc writes the .luarc.json of a workspace, or merges into an existing
+// one: the library directories (the definitions, and the sources of the mods the
+// workspace depends on, see ResolveDependencies) are added to workspace.library,
+// runtime.version is set to Factorio's Lua version and FactorioGlobals are added
+// to diagnostics.globals. When plugin isn't empty, runtime.plugin is set to it
+// (see generator.LuaLSPluginFile). Other settings are kept. Settings may be
+// written with dotted keys ("workspace.library") or nested objects; an existing
+// nested object is updated in place. It returns the path of the file.
+func UpdateLuarc(dir string, libraries []string, plugin string) (string, error) {
+	path := filepath.Join(dir, ".luarc.json")
+	config := make(map[string]any)
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		config["$schema"] = "https://raw.githubusercontent.com/LuaLS/vscode-lua/master/setting/schema.json"
+	case err != nil:
+		return "", err
+	default:
+		if err := json.Unmarshal(data, &config); err != nil {
+			return "", fmt.Errorf("can't merge into %s, it isn't valid JSON: %w", path, err)
+		}
+	}
+
+	if err := appendSetting(config, "workspace.library", libraries...); err != nil {
+		return "", err
+	}
+	setSetting(config, "runtime.version", LuaVersion)
+	if plugin != "" {
+		setSetting(config, "runtime.plugin", plugin)
+	}
+	if err := appendSetting(config, "diagnostics.globals", FactorioGlobals...); err != nil {
+		return "", err
+	}
+
+	data, err = json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// settingParent returns the object holding a dotted setting and the key within
+// it: the nested object if the config already has one ({"workspace": {...}}),
+// otherwise the config itself with the dotted key.
+func settingParent(config map[string]any, key string) (map[string]any, string) {
+	section, name, _ := strings.Cut(key, ".")
+	if nested, ok := config[section].(map[string]any); ok {
+		return nested, name
+	}
+	return config, key
+}
+
+// setSetting sets a setting, replacing its value.
+func setSetting(config map[string]any, key string, value any) {
+	parent, name := settingParent(config, key)
+	parent[name] = value
+}
+
+// appendSetting adds values missing from a list setting.
+func appendSetting(config map[string]any, key string, values ...string) error {
+	parent, name := settingParent(config, key)
+	var list []any
+	switch existing := parent[name].(type) {
+	case nil:
+	case []any:
+		list = existing
+	default:
+		return fmt.Errorf("setting %s is not a list", key)
+	}
+	for _, value := range values {
+		if !slices.Contains(list, any(value)) {
+			list = append(list, value)
+		}
+	}
+	parent[name] = list
+	return nil
+}
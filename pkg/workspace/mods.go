@@ -0,0 +1,264 @@
+package workspace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ModInfo is the part of a mod's info.json the workspace setup reads.
+type ModInfo struct {
+	Name            string   `json:"name"`
+	Version         string   `json:"version"`
+	FactorioVersion string   `json:"factorio_version"` // e.g. "2.0"
+	Dependencies    []string `json:"dependencies"`
+}
+
+// ReadModInfo reads the info.json of the mod in dir.
+func ReadModInfo(dir string) (*ModInfo, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "info.json"))
+	if err != nil {
+		return nil, err
+	}
+	var info ModInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", filepath.Join(dir, "info.json"), err)
+	}
+	if info.Name == "" {
+		return nil, fmt.Errorf("%s has no mod name", filepath.Join(dir, "info.json"))
+	}
+	return &info, nil
+}
+
+// Dependency is a parsed entry of the dependencies of an info.json, e.g.
+// "? some-mod >= 1.2.0".
+type Dependency struct {
+	Name         string
+	Optional     bool   // "?" or "(?)": loaded first if present
+	Incompatible bool   // "!": must not be present
+	Operator     string // Version constraint, e.g. ">=", empty for any version
+	Version      string
+}
+
+// dependencyPattern matches a dependency: a prefix, the name (which may contain
+// spaces) and an optional version constraint.
+var dependencyPattern = regexp.MustCompile(`^\s*(!|\?|\(\?\)|~)?\s*(\S.*?)\s*(?:(<=|>=|<|>|=)\s*(\S+))?\s*$`)
+
+// ParseDependency parses an entry of the dependencies of an info.json.
+func ParseDependency(entry string) (Dependency, error) {
+	m := dependencyPattern.FindStringSubmatch(entry)
+	if m == nil {
+		return Dependency{}, fmt.Errorf("invalid dependency %q", entry)
+	}
+	return Dependency{
+		Name:         m[2],
+		Optional:     m[1] == "?" || m[1] == "(?)",
+		Incompatible: m[1] == "!",
+		Operator:     m[3],
+		Version:      m[4],
+	}, nil
+}
+
+// BuiltinMods are the mods shipped in Factorio's data directory rather than the
+// mods directory: the base game and the official expansion.
+var BuiltinMods = []string{"base", "core", "elevated-rails", "quality", "space-age"}
+
+// DefaultUserDataDir returns the user data directory of a default Factorio
+// installation on this platform, which holds mods/ and script-output/.
+func DefaultUserDataDir() string {
+	home, _ := os.UserHomeDir()
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(os.Getenv("APPDATA"), "Factorio")
+	case "darwin":
+		return filepath.Join(home, "Library", "Application Support", "factorio")
+	default:
+		return filepath.Join(home, ".factorio")
+	}
+}
+
+// DefaultModsDir returns the mods directory of a default Factorio installation
+// on this platform.
+func DefaultModsDir() string {
+	return filepath.Join(DefaultUserDataDir(), "mods")
+}
+
+// DefaultDataRawDump returns where `factorio --dump-data` writes the data-raw
+// dump in a default Factorio installation on this platform.
+func DefaultDataRawDump() string {
+	return filepath.Join(DefaultUserDataDir(), "script-output", "data-raw-dump.json")
+}
+
+// ModSource is a mod found in the mods directory, or downloaded by a ModPortal.
+type ModSource struct {
+	Name    string
+	Version string
+	Dir     string
+}
+
+// ResolveDependencies finds the mods the mod in dir depends on in modsDir, with
+// the mods they require in turn. Only unzipped mods (directories named
+// "name" or "name_version") can be used, since LuaLS doesn't read zip files; of
+// several versions, the newest satisfying the constraint is used. Incompatible
+// and builtin mods are skipped. With a portal, required dependencies that aren't
+// installed are downloaded from the mod portal. The dependencies that couldn't be
+// found are returned too, with the reason; missing optional ones are left out of
+// both.
+func ResolveDependencies(dir string, modsDir string, portal *ModPortal) ([]ModSource, []MissingDependency, error) {
+	info, err := ReadModInfo(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	installed, err := scanMods(modsDir)
+	if errors.Is(err, os.ErrNotExist) && portal != nil {
+		installed = nil // Everything comes from the portal then
+	} else if err != nil {
+		return nil, nil, err
+	}
+	if portal != nil && portal.FactorioVersion == "" {
+		portal.FactorioVersion = info.FactorioVersion
+	}
+
+	var resolved []ModSource
+	var missing []MissingDependency
+	seen := map[string]bool{info.Name: true}
+	queue := []ModInfo{*info}
+	for len(queue) > 0 {
+		mod := queue[0]
+		queue = queue[1:]
+		for _, entry := range mod.Dependencies {
+			dep, err := ParseDependency(entry)
+			if err != nil {
+				return nil, nil, fmt.Errorf("mod %s: %w", mod.Name, err)
+			}
+			if dep.Incompatible || seen[dep.Name] || slices.Contains(BuiltinMods, dep.Name) {
+				continue
+			}
+			// Optional dependencies of dependencies don't affect the workspace.
+			if dep.Optional && mod.Name != info.Name {
+				continue
+			}
+			source, depInfo, ok := newestMatching(installed[dep.Name], dep)
+			if !ok && dep.Optional {
+				continue
+			}
+			if !ok && portal == nil {
+				missing = append(missing, MissingDependency{Dependency: dep, Err: fmt.Errorf("not found unzipped in %s", modsDir)})
+				continue
+			}
+			if !ok {
+				source, depInfo, err = portal.Fetch(dep)
+				if err != nil {
+					missing = append(missing, MissingDependency{Dependency: dep, Err: err})
+					continue
+				}
+			}
+			seen[dep.Name] = true
+			resolved = append(resolved, source)
+			queue = append(queue, depInfo)
+		}
+	}
+	return resolved, missing, nil
+}
+
+// MissingDependency is a dependency that couldn't be found, and why.
+type MissingDependency struct {
+	Dependency
+	Err error
+}
+
+// installedMod is an unzipped mod of the mods directory.
+type installedMod struct {
+	source ModSource
+	info   ModInfo
+}
+
+// scanMods lists the unzipped mods of a mods directory by name.
+func scanMods(modsDir string) (map[string][]installedMod, error) {
+	entries, err := os.ReadDir(modsDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no mods directory at %s: %w", modsDir, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	mods := make(map[string][]installedMod)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue // Zipped mods, mod-list.json, mod-settings.dat, ...
+		}
+		dir := filepath.Join(modsDir, entry.Name())
+		info, err := ReadModInfo(dir)
+		if err != nil {
+			continue // Not a mod
+		}
+		mods[info.Name] = append(mods[info.Name], installedMod{
+			source: ModSource{Name: info.Name, Version: info.Version, Dir: dir},
+			info:   *info,
+		})
+	}
+	return mods, nil
+}
+
+// newestMatching returns the newest of the installed versions of a mod that
+// satisfies the version constraint of a dependency.
+func newestMatching(versions []installedMod, dep Dependency) (ModSource, ModInfo, bool) {
+	var best *installedMod
+	for i, mod := range versions {
+		if !satisfies(mod.info.Version, dep.Operator, dep.Version) {
+			continue
+		}
+		if best == nil || compareVersions(mod.info.Version, best.info.Version) > 0 {
+			best = &versions[i]
+		}
+	}
+	if best == nil {
+		return ModSource{}, ModInfo{}, false
+	}
+	return best.source, best.info, true
+}
+
+// satisfies reports whether a version satisfies a constraint such as ">= 1.2.0".
+func satisfies(version string, operator string, constraint string) bool {
+	c := compareVersions(version, constraint)
+	switch operator {
+	case "":
+		return true
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case "=":
+		return c == 0
+	case ">=":
+		return c >= 0
+	default: // ">"
+		return c > 0
+	}
+}
+
+// compareVersions compares Factorio versions ("major.minor.sub") part by part,
+// numerically; missing or invalid parts count as 0.
+func compareVersions(a string, b string) int {
+	partsA, partsB := strings.Split(a, "."), strings.Split(b, ".")
+	for i := range max(len(partsA), len(partsB)) {
+		var x, y int
+		if i < len(partsA) {
+			x, _ = strconv.Atoi(partsA[i])
+		}
+		if i < len(partsB) {
+			y, _ = strconv.Atoi(partsB[i])
+		}
+		if x != y {
+			return x - y
+		}
+	}
+	return 0
+}
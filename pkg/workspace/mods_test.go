@@ -0,0 +1,114 @@
+package workspace
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseDependency(t *testing.T) {
+	tests := []struct {
+		entry string
+		want  Dependency
+	}{
+		{"flib", Dependency{Name: "flib"}},
+		{"base >= 2.0.0", Dependency{Name: "base", Operator: ">=", Version: "2.0.0"}},
+		{"? space-age", Dependency{Name: "space-age", Optional: true}},
+		{"(?) quality > 1.0", Dependency{Name: "quality", Optional: true, Operator: ">", Version: "1.0"}},
+		{"! bobs-mod", Dependency{Name: "bobs-mod", Incompatible: true}},
+		{"~ stdlib = 1.4.8", Dependency{Name: "stdlib", Operator: "=", Version: "1.4.8"}},
+		{"?Krastorio 2<0.9", Dependency{Name: "Krastorio 2", Optional: true, Operator: "<", Version: "0.9"}},
+		{"  mod with spaces <= 3.1.4  ", Dependency{Name: "mod with spaces", Operator: "<=", Version: "3.1.4"}},
+	}
+	for _, test := range tests {
+		got, err := ParseDependency(test.entry)
+		if err != nil || got != test.want {
+			t.Errorf("ParseDependency(%q) = %+v, %v, want %+v", test.entry, got, err, test.want)
+		}
+	}
+	if _, err := ParseDependency("   "); err == nil {
+		t.Error("ParseDependency of a blank entry succeeded")
+	}
+}
+
+func TestSatisfies(t *testing.T) {
+	tests := []struct {
+		version, operator, constraint string
+		want                          bool
+	}{
+		{"1.2.3", "", "", true},
+		{"1.2.3", ">=", "1.2.3", true},
+		{"1.10.0", ">", "1.9.0", true},
+		{"1.2", "=", "1.2.0", true},
+		{"0.9.9", ">=", "1.0.0", false},
+		{"2.0.0", "<", "2.0.0", false},
+		{"1.0.0", "<=", "1.0.1", true},
+	}
+	for _, test := range tests {
+		if got := satisfies(test.version, test.operator, test.constraint); got != test.want {
+			t.Errorf("satisfies(%q, %q, %q) = %v, want %v", test.version, test.operator, test.constraint, got, test.want)
+		}
+	}
+}
+
+// writeMod writes the info.json of a mod in dir.
+func writeMod(t *testing.T, dir string, info ModInfo) {
+	t.Helper()
+	data, err := json.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "info.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolveDependencies(t *testing.T) {
+	root := t.TempDir()
+	modDir := filepath.Join(root, "my-mod")
+	modsDir := filepath.Join(root, "mods")
+	writeMod(t, modDir, ModInfo{Name: "my-mod", Version: "1.0.0", FactorioVersion: "2.0", Dependencies: []string{
+		"base >= 2.0",
+		"flib >= 0.15",
+		"? optional-lib",
+		"? absent-optional",
+		"! incompatible",
+		"absent",
+	}})
+	writeMod(t, filepath.Join(modsDir, "flib_0.14.0"), ModInfo{Name: "flib", Version: "0.14.0"})
+	writeMod(t, filepath.Join(modsDir, "flib_0.16.2"), ModInfo{Name: "flib", Version: "0.16.2", Dependencies: []string{"base", "stdlib", "? flib-optional"}})
+	writeMod(t, filepath.Join(modsDir, "flib_0.15.0"), ModInfo{Name: "flib", Version: "0.15.0"})
+	writeMod(t, filepath.Join(modsDir, "stdlib"), ModInfo{Name: "stdlib", Version: "1.4.8", Dependencies: []string{"flib"}})
+	writeMod(t, filepath.Join(modsDir, "optional-lib_1.0.0"), ModInfo{Name: "optional-lib", Version: "1.0.0"})
+	writeMod(t, filepath.Join(modsDir, "flib-optional"), ModInfo{Name: "flib-optional", Version: "1.0.0"})
+	writeMod(t, filepath.Join(modsDir, "incompatible"), ModInfo{Name: "incompatible", Version: "1.0.0"})
+	// Zipped mods and other files are ignored.
+	if err := os.WriteFile(filepath.Join(modsDir, "absent_1.0.0.zip"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	resolved, missing, err := ResolveDependencies(modDir, modsDir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, mod := range resolved {
+		got = append(got, mod.Name+" "+mod.Version+" "+filepath.Base(mod.Dir))
+	}
+	want := []string{"flib 0.16.2 flib_0.16.2", "optional-lib 1.0.0 optional-lib_1.0.0", "stdlib 1.4.8 stdlib"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("resolved:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if len(missing) != 1 || missing[0].Name != "absent" || missing[0].Err == nil {
+		t.Errorf("missing = %+v, want absent", missing)
+	}
+
+	if _, _, err := ResolveDependencies(modDir, filepath.Join(root, "no-mods"), nil); err == nil {
+		t.Error("resolving without a mods directory succeeded")
+	}
+}
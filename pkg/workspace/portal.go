@@ -0,0 +1,259 @@
+package workspace
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultModPortalURL is the address of the Factorio mod portal.
+const DefaultModPortalURL = "https://mods.factorio.com"
+
+// ModPortal downloads the dependencies missing from the mods directory from the
+// Factorio mod portal, and unzips them into a cache so LuaLS can read them.
+// Downloads need a factorio.com account: its username and token, as found in
+// the player-data.json of a logged in game (see PortalCredentials).
+type ModPortal struct {
+	URL             string // DefaultModPortalURL unless testing
+	CacheDir        string // Mods are unzipped in CacheDir/name_version/name
+	Username        string
+	Token           string
+	FactorioVersion string // e.g. "2.0": only releases for it are used; empty for any
+}
+
+// DefaultModCacheDir returns where downloaded mods are cached by default.
+func DefaultModCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "factorio-api-gen", "mods"), nil
+}
+
+// PortalCredentials returns the mod portal username and token from the
+// FACTORIO_USERNAME and FACTORIO_TOKEN environment variables or, if unset, from
+// the player-data.json next to the mods directory, where the game stores them
+// once logged in.
+func PortalCredentials(modsDir string) (string, string, error) {
+	username, token := os.Getenv("FACTORIO_USERNAME"), os.Getenv("FACTORIO_TOKEN")
+	if username != "" && token != "" {
+		return username, token, nil
+	}
+	path := filepath.Join(filepath.Dir(filepath.Clean(modsDir)), "player-data.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", fmt.Errorf("no mod portal credentials: set FACTORIO_USERNAME and FACTORIO_TOKEN, or log in to the game (%v)", err)
+	}
+	var playerData struct {
+		Username string `json:"service-username"`
+		Token    string `json:"service-token"`
+	}
+	if err := json.Unmarshal(data, &playerData); err != nil {
+		return "", "", fmt.Errorf("invalid %s: %w", path, err)
+	}
+	if playerData.Username == "" || playerData.Token == "" {
+		return "", "", fmt.Errorf("no mod portal credentials in %s: log in to the game first", path)
+	}
+	return playerData.Username, playerData.Token, nil
+}
+
+// portalRelease is a release of a mod, as listed by the portal's full mod API.
+type portalRelease struct {
+	DownloadURL string  `json:"download_url"`
+	Version     string  `json:"version"`
+	SHA1        string  `json:"sha1"`
+	InfoJSON    ModInfo `json:"info_json"`
+}
+
+// Fetch returns the newest release of a mod satisfying the version constraint of
+// a dependency, downloading and unzipping it. A matching release in the cache is
+// used without asking the portal, so newer releases are only downloaded once
+// the cached one is deleted.
+func (p *ModPortal) Fetch(dep Dependency) (ModSource, ModInfo, error) {
+	if source, info, ok := p.cached(dep); ok {
+		return source, info, nil
+	}
+	release, err := p.release(dep)
+	if err != nil {
+		return ModSource{}, ModInfo{}, err
+	}
+	dir := filepath.Join(p.CacheDir, dep.Name+"_"+release.Version, dep.Name)
+	if info, err := ReadModInfo(dir); err == nil {
+		return ModSource{Name: info.Name, Version: info.Version, Dir: dir}, *info, nil
+	}
+	if err := p.download(release, dir); err != nil {
+		return ModSource{}, ModInfo{}, fmt.Errorf("downloading %s %s: %w", dep.Name, release.Version, err)
+	}
+	info, err := ReadModInfo(dir)
+	if err != nil {
+		return ModSource{}, ModInfo{}, err
+	}
+	return ModSource{Name: info.Name, Version: info.Version, Dir: dir}, *info, nil
+}
+
+// cached returns the newest cached release of a mod matching a dependency.
+func (p *ModPortal) cached(dep Dependency) (ModSource, ModInfo, bool) {
+	dirs, _ := filepath.Glob(filepath.Join(p.CacheDir, dep.Name+"_*", dep.Name))
+	var versions []installedMod
+	for _, dir := range dirs {
+		info, err := ReadModInfo(dir)
+		if err != nil || p.FactorioVersion != "" && info.FactorioVersion != p.FactorioVersion {
+			continue
+		}
+		versions = append(versions, installedMod{
+			source: ModSource{Name: info.Name, Version: info.Version, Dir: dir},
+			info:   *info,
+		})
+	}
+	return newestMatching(versions, dep)
+}
+
+// release looks up the newest release of a mod matching a dependency.
+func (p *ModPortal) release(dep Dependency) (*portalRelease, error) {
+	resp, err := http.Get(p.URL + "/api/mods/" + url.PathEscape(dep.Name) + "/full")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("mod %q is not on the mod portal", dep.Name)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("looking up mod %q: received status code %d", dep.Name, resp.StatusCode)
+	}
+	var mod struct {
+		Releases []portalRelease `json:"releases"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&mod); err != nil {
+		return nil, fmt.Errorf("looking up mod %q: %w", dep.Name, err)
+	}
+
+	var best *portalRelease
+	for i, release := range mod.Releases {
+		if p.FactorioVersion != "" && release.InfoJSON.FactorioVersion != p.FactorioVersion {
+			continue
+		}
+		if !satisfies(release.Version, dep.Operator, dep.Version) {
+			continue
+		}
+		if best == nil || compareVersions(release.Version, best.Version) > 0 {
+			best = &mod.Releases[i]
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("mod %q has no release matching %s %s for Factorio %s", dep.Name, dep.Operator, dep.Version, p.FactorioVersion)
+	}
+	return best, nil
+}
+
+// download downloads a release and unzips it into dir. The zip is checked
+// against the release's checksum, and unzipped next to dir first, so an
+// interrupted download doesn't leave a partial mod in the cache.
+func (p *ModPortal) download(release *portalRelease, dir string) error {
+	query := url.Values{"username": {p.Username}, "token": {p.Token}}
+	resp, err := http.Get(p.URL + release.DownloadURL + "?" + query.Encode())
+	if err != nil {
+		return withoutQuery(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("received status code %d", resp.StatusCode)
+	}
+	// The portal redirects to its login page when the credentials are wrong.
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		return errors.New("the mod portal rejected the credentials")
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if sum := sha1.Sum(data); release.SHA1 != "" && hex.EncodeToString(sum[:]) != release.SHA1 {
+		return errors.New("checksum mismatch")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), ".download-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	if err := unzipMod(data, tmp); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dir)
+}
+
+// withoutQuery strips the query from the URL of a request error, which carries
+// the credentials of downloads and would end up in the logs.
+func withoutQuery(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+	redacted := *urlErr
+	if u, parseErr := url.Parse(urlErr.URL); parseErr == nil {
+		u.RawQuery = ""
+		redacted.URL = u.String()
+	} else {
+		redacted.URL, _, _ = strings.Cut(urlErr.URL, "?")
+	}
+	return &redacted
+}
+
+// unzipMod extracts a mod zip into dir. The files of a mod zip are in a single
+// top-level directory (name_version), which is left out.
+func unzipMod(data []byte, dir string) error {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+	for _, file := range archive.File {
+		_, name, ok := strings.Cut(file.Name, "/")
+		if !ok || name == "" || strings.HasSuffix(name, "/") {
+			continue
+		}
+		// Refuse paths escaping the directory.
+		name = path.Clean(name)
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("invalid path %q in the zip", file.Name)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := extractFile(file, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractFile(file *zip.File, target string) error {
+	r, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	w, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,35 @@
+package workspace
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestModPortalDownloadErrorHidesCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/mods/flib/full" {
+			w.Write([]byte(`{"releases": [{"download_url": "/download/flib/1", "version": "0.16.2", "info_json": {"factorio_version": "2.0"}}]}`))
+			return
+		}
+		// Drop the download's connection, failing the request itself.
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}))
+	defer server.Close()
+
+	portal := &ModPortal{URL: server.URL, CacheDir: t.TempDir(), Username: "engineer", Token: "secret-token"}
+	_, _, err := portal.Fetch(Dependency{Name: "flib"})
+	if err == nil {
+		t.Fatal("Fetch succeeded")
+	}
+	if strings.Contains(err.Error(), "secret-token") || strings.Contains(err.Error(), "engineer") {
+		t.Errorf("error shows the credentials: %v", err)
+	}
+	if !strings.Contains(err.Error(), "/download/flib/1") {
+		t.Errorf("error doesn't show the download: %v", err)
+	}
+}
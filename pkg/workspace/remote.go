@@ -0,0 +1,284 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// RemoteInterface is a remote interface a mod registers with
+// remote.add_interface, callable by other mods through remote.call.
+type RemoteInterface struct {
+	Name      string // The interface name, e.g. "my-mod"
+	File      string // The file registering it
+	Functions []RemoteFunction
+}
+
+// RemoteFunction is a function of a remote interface, with the types its
+// `---@param` and `---@return` annotations give, if any.
+type RemoteFunction struct {
+	Name       string
+	Parameters []RemoteParameter
+	Returns    []string // LuaLS types; empty if not annotated
+}
+
+// RemoteParameter is a parameter of a RemoteFunction.
+type RemoteParameter struct {
+	Name     string // "..." for a vararg
+	Type     string // A LuaLS type; "any" if not annotated
+	Optional bool
+}
+
+// ScanRemoteInterfaces finds the remote interfaces the mod in dir registers by
+// scanning its Lua files, without running them. An interface is found where
+// remote.add_interface is called with a literal name, and a table constructor
+// or a variable set to one in the same file. Its functions are the fields of the
+// table set to a function, either inline or by the name of a function defined in
+// the same file, and the functions added to the variable later (function
+// M.foo() ... end). Their parameters are typed by the annotations preceding the
+// function, as written for LuaLS. The interfaces are in the order they're found.
+func ScanRemoteInterfaces(dir string) ([]RemoteInterface, error) {
+	files, err := luaFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	var interfaces []RemoteInterface
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		src := string(data)
+		tokens, err := tokenizeLua(src)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		lines := strings.Split(src, "\n")
+		for i := range tokens {
+			if remote, ok := addedInterface(tokens, lines, i); ok {
+				remote.File = path
+				interfaces = append(interfaces, remote)
+			}
+		}
+	}
+	return interfaces, nil
+}
+
+// addedInterface reads the call of remote.add_interface starting at tokens[i].
+func addedInterface(tokens []luaToken, lines []string, i int) (RemoteInterface, bool) {
+	if !isToken(tokens, i, "remote") || !isToken(tokens, i+1, ".") || !isToken(tokens, i+2, "add_interface") || !isToken(tokens, i+3, "(") ||
+		i+6 >= len(tokens) || tokens[i+4].kind != 's' || !isToken(tokens, i+5, ",") {
+		return RemoteInterface{}, false
+	}
+	remote := RemoteInterface{Name: tokens[i+4].text}
+
+	// The functions: a table constructor, or a variable holding one.
+	open, variable := i+6, ""
+	if tokens[open].kind == 'n' && (isToken(tokens, open+1, ")") || isToken(tokens, open+1, ",")) {
+		variable = tokens[open].text
+		open = -1
+		for k := range tokens {
+			if tokens[k].text == variable && tokens[k].kind == 'n' && !isToken(tokens, k-1, ".") && isToken(tokens, k+1, "=") && isToken(tokens, k+2, "{") {
+				// The last one before the call, or else the first after it.
+				if k > i && open >= 0 {
+					break
+				}
+				open = k + 2
+			}
+		}
+	}
+	seen := make(map[string]bool)
+	if open >= 0 && isToken(tokens, open, "{") {
+		for _, field := range functionFields(tokens, open) {
+			function, ok := field.definition, field.definition >= 0
+			if field.reference != "" {
+				function, ok = functionDefinition(tokens, field.reference)
+			}
+			if ok && !seen[field.name] {
+				seen[field.name] = true
+				remote.Functions = append(remote.Functions, remoteFunction(tokens, lines, field.name, function))
+			}
+		}
+	}
+	if variable == "" {
+		return remote, true
+	}
+	// Functions added to the variable: function M.foo(...) and M.foo = function(...).
+	for k := range tokens {
+		var name string
+		function := -1
+		switch {
+		case isToken(tokens, k, "function") && isToken(tokens, k+1, variable) && isToken(tokens, k+2, ".") && k+4 < len(tokens) && isToken(tokens, k+4, "("):
+			name, function = tokens[k+3].text, k
+		case isToken(tokens, k, variable) && !isToken(tokens, k-1, ".") && isToken(tokens, k+1, ".") && k+4 < len(tokens) &&
+			isToken(tokens, k+3, "=") && isToken(tokens, k+4, "function"):
+			name, function = tokens[k+2].text, k+4
+		default:
+			continue
+		}
+		if !seen[name] {
+			seen[name] = true
+			remote.Functions = append(remote.Functions, remoteFunction(tokens, lines, name, function))
+		}
+	}
+	return remote, true
+}
+
+// functionField is a field of a table constructor whose value is a function:
+// either defined inline (the index of its function keyword) or referenced by
+// name.
+type functionField struct {
+	name       string
+	definition int // -1 for a reference
+	reference  string
+}
+
+// functionFields returns the fields of the table constructor opening at
+// tokens[open] that are set to a function, or to a name that may be one.
+func functionFields(tokens []luaToken, open int) []functionField {
+	var fields []functionField
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		if tokens[i].kind == 'p' {
+			switch tokens[i].text {
+			case "{", "(", "[":
+				depth++
+			case "}", ")", "]":
+				depth--
+			}
+		}
+		if depth == 0 {
+			break
+		}
+		// name = value, or ["name"] = value.
+		var name string
+		value := -1
+		switch {
+		case depth == 1 && tokens[i].kind == 'n' && !isToken(tokens, i-1, ".") && isToken(tokens, i+1, "="):
+			name, value = tokens[i].text, i+2
+		case depth == 2 && tokens[i].kind == 's' && isToken(tokens, i-1, "[") && isToken(tokens, i+1, "]") && isToken(tokens, i+2, "="):
+			name, value = tokens[i].text, i+3
+		default:
+			continue
+		}
+		if value >= len(tokens) {
+			break
+		}
+		if isToken(tokens, value, "function") {
+			fields = append(fields, functionField{name: name, definition: value})
+			continue
+		}
+		// A name, maybe qualified (M.foo), ending the field.
+		end := value
+		for end+2 < len(tokens) && tokens[end].kind == 'n' && (isToken(tokens, end+1, ".") || isToken(tokens, end+1, ":")) {
+			end += 2
+		}
+		if tokens[end].kind == 'n' && endsField(tokens, end+1) {
+			fields = append(fields, functionField{name: name, definition: -1, reference: tokens[end].text})
+		}
+	}
+	return fields
+}
+
+// functionDefinition returns the index of the function keyword defining the
+// function of the given name in the file: `function name(`, `function M.name(`,
+// or `name = function(`.
+func functionDefinition(tokens []luaToken, name string) (int, bool) {
+	for k := range tokens {
+		if !isToken(tokens, k, name) {
+			continue
+		}
+		if isToken(tokens, k+1, "(") {
+			// function name( or function M.name( or function M:name(
+			j := k
+			for isToken(tokens, j-1, ".") || isToken(tokens, j-1, ":") {
+				j -= 2
+			}
+			if isToken(tokens, j-1, "function") {
+				return j - 1, true
+			}
+		}
+		if isToken(tokens, k+1, "=") && isToken(tokens, k+2, "function") {
+			return k + 2, true
+		}
+	}
+	return -1, false
+}
+
+// remoteFunction reads the signature of the function defined at tokens[function].
+func remoteFunction(tokens []luaToken, lines []string, name string, function int) RemoteFunction {
+	remote := RemoteFunction{Name: name}
+	k := function + 1
+	for k < len(tokens) && !isToken(tokens, k, "(") {
+		k++ // The function's name
+	}
+	params, returns := docAnnotations(lines, tokens[function].line)
+	for k++; k < len(tokens) && !isToken(tokens, k, ")"); k++ {
+		param := tokens[k].text
+		switch {
+		case param == ".." && isToken(tokens, k+1, "."):
+			param = "..."
+			k++
+		case tokens[k].kind != 'n':
+			continue
+		}
+		annotated, ok := params[param]
+		if !ok {
+			annotated = RemoteParameter{Name: param, Type: "any"}
+		}
+		remote.Parameters = append(remote.Parameters, annotated)
+	}
+	remote.Returns = returns
+	return remote
+}
+
+// docAnnotations reads the `---@param` and `---@return` annotations of the doc
+// comment ending on the line before the given (1-based) line.
+func docAnnotations(lines []string, line int) (map[string]RemoteParameter, []string) {
+	params := make(map[string]RemoteParameter)
+	var returns []string
+	for i := line - 2; i >= 0 && i < len(lines); i-- {
+		comment, ok := strings.CutPrefix(strings.TrimSpace(lines[i]), "---")
+		if !ok {
+			break
+		}
+		tag, rest, _ := strings.Cut(strings.TrimSpace(comment), " ")
+		rest = strings.TrimSpace(rest)
+		switch tag {
+		case "@param":
+			name, luaType, _ := strings.Cut(rest, " ")
+			name, optional := strings.CutSuffix(name, "?")
+			if t := annotationType(luaType); name != "" && t != "" {
+				params[name] = RemoteParameter{Name: name, Type: t, Optional: optional}
+			}
+		case "@return":
+			if t := annotationType(rest); t != "" {
+				returns = append([]string{t}, returns...) // Read bottom up
+			}
+		}
+	}
+	return params, returns
+}
+
+// annotationType returns the type at the start of the rest of an annotation,
+// leaving out the description after it. Types may contain spaces, as in
+// "string | nil" or "fun(a: string): number".
+func annotationType(s string) string {
+	s = strings.TrimSpace(s)
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(', '<', '{', '[':
+			depth++
+		case ')', '>', '}', ']':
+			depth--
+		case ' ':
+			before, after := strings.TrimSpace(s[:i]), strings.TrimSpace(s[i:])
+			if depth > 0 || strings.HasSuffix(before, "|") || strings.HasPrefix(after, "|") || strings.HasSuffix(before, ":") || strings.HasSuffix(before, ",") {
+				continue
+			}
+			return before
+		}
+	}
+	return s
+}
@@ -0,0 +1,96 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestScanRemoteInterfaces(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"control.lua": `---Counts the things of a force.
+---@param force_name string The force
+---@param surface? LuaSurface | string Where, everywhere if nil
+---@return integer count
+local function count_things(force_name, surface)
+  return 0
+end
+
+remote.add_interface("my-mod", {
+  count_things = count_things,
+  ---@param enabled boolean
+  set_enabled = function(enabled) end,
+  ["get-version"] = function() return "1.0.0" end,
+  version = "1.0.0",
+})
+`,
+		"scripts/api.lua": `local M = {}
+
+---@param callback fun(event: EventData, data: table): boolean
+---@param ... string Filters
+---@return table<string, integer>|nil ids By filter
+function M.subscribe(callback, ...)
+end
+
+M.unsubscribe = function(id) end
+
+remote.add_interface("my-mod-api", M)
+`,
+		"computed.lua": `remote.add_interface(script.mod_name, {})`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	interfaces, err := ScanRemoteInterfaces(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []RemoteInterface{
+		{Name: "my-mod", File: filepath.Join(dir, "control.lua"), Functions: []RemoteFunction{
+			{Name: "count_things", Parameters: []RemoteParameter{
+				{Name: "force_name", Type: "string"},
+				{Name: "surface", Type: "LuaSurface | string", Optional: true},
+			}, Returns: []string{"integer"}},
+			{Name: "set_enabled", Parameters: []RemoteParameter{{Name: "enabled", Type: "boolean"}}},
+			{Name: "get-version"},
+		}},
+		{Name: "my-mod-api", File: filepath.Join(dir, "scripts", "api.lua"), Functions: []RemoteFunction{
+			{Name: "subscribe", Parameters: []RemoteParameter{
+				{Name: "callback", Type: "fun(event: EventData, data: table): boolean"},
+				{Name: "...", Type: "string"},
+			}, Returns: []string{"table<string, integer>|nil"}},
+			{Name: "unsubscribe", Parameters: []RemoteParameter{{Name: "id", Type: "any"}}},
+		}},
+	}
+	if !reflect.DeepEqual(interfaces, want) {
+		t.Errorf("ScanRemoteInterfaces =\n%+v\nwant\n%+v", interfaces, want)
+	}
+}
+
+func TestAnnotationType(t *testing.T) {
+	tests := []struct {
+		s, want string
+	}{
+		{"string The name", "string"},
+		{"string | nil The name", "string | nil"},
+		{"fun(a: string, b: integer): boolean Called back", "fun(a: string, b: integer): boolean"},
+		{"table<string, LuaEntity> By name", "table<string, LuaEntity>"},
+		{"{ x: number, y: number } A position", "{ x: number, y: number }"},
+		{"integer", "integer"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if got := annotationType(test.s); got != test.want {
+			t.Errorf("annotationType(%q) = %q, want %q", test.s, got, test.want)
+		}
+	}
+}
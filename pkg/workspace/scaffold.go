@@ -0,0 +1,135 @@
+package workspace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// modNamePattern matches the mod names ScaffoldMod accepts: those the mod portal
+// accepts, without the spaces it tolerates, since the name is a directory too.
+var modNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,100}$`)
+
+// scaffoldControl is the control.lua of a new mod. It takes the name of the mod
+// and that of the table the mod keeps its save-persistent data in.
+const scaffoldControl = `-- The control stage of %[1]s: event handlers, which run in the game.
+
+script.on_init(function()
+  -- Runs once, when the mod is added to a save.
+  %[2]s.players = {}
+end)
+
+script.on_event(defines.events.on_player_created, function(event)
+  local player = game.get_player(event.player_index)
+  if not player then return end
+  %[2]s.players[player.index] = { created_tick = event.tick }
+end)
+`
+
+// scaffoldData is the data.lua of a new mod.
+const scaffoldData = `-- The data stage of %[1]s: the prototypes it adds, with data:extend.
+
+data:extend({
+})
+`
+
+// scaffoldSettings is the settings.lua of a new mod.
+const scaffoldSettings = `-- The settings stage of %[1]s: the mod settings it adds, with data:extend.
+
+data:extend({
+  {
+    type = "bool-setting",
+    name = "%[1]s-enabled",
+    setting_type = "runtime-global",
+    default_value = true,
+  },
+})
+`
+
+// ScaffoldMod creates the skeleton of a mod in dir: an info.json for the game
+// version (e.g. "2.0.45", empty for the latest), and a control.lua, data.lua and
+// settings.lua to start from. Existing files are left as they are. It returns the
+// paths of the files it created.
+func ScaffoldMod(dir string, name string, gameVersion string) ([]string, error) {
+	if err := CheckModName(name); err != nil {
+		return nil, err
+	}
+	// info.json names the major and minor version; the save data table was renamed
+	// from global to storage in 2.0.
+	factorioVersion := "2.0"
+	persistentData := "storage"
+	if parts := strings.Split(gameVersion, "."); len(parts) >= 2 {
+		factorioVersion = parts[0] + "." + parts[1]
+		if parts[0] == "0" || parts[0] == "1" {
+			persistentData = "global"
+		}
+	}
+	var info strings.Builder
+	encoder := json.NewEncoder(&info)
+	encoder.SetEscapeHTML(false) // The dependencies have version constraints
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(struct {
+		Name            string   `json:"name"`
+		Version         string   `json:"version"`
+		Title           string   `json:"title"`
+		Author          string   `json:"author"`
+		FactorioVersion string   `json:"factorio_version"`
+		Dependencies    []string `json:"dependencies"`
+		Description     string   `json:"description"`
+	}{name, "0.1.0", modTitle(name), "", factorioVersion, []string{"base >= " + factorioVersion}, ""})
+	if err != nil {
+		return nil, err
+	}
+	files := []struct{ name, content string }{
+		{"info.json", info.String()},
+		{"settings.lua", fmt.Sprintf(scaffoldSettings, name)},
+		{"data.lua", fmt.Sprintf(scaffoldData, name)},
+		{"control.lua", fmt.Sprintf(scaffoldControl, name, persistentData)},
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	var created []string
+	for _, file := range files {
+		path := filepath.Join(dir, file.name)
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return created, err
+		}
+		_, err = f.WriteString(file.content)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return created, err
+		}
+		created = append(created, path)
+	}
+	return created, nil
+}
+
+// CheckModName returns an error if ScaffoldMod doesn't accept a mod name.
+func CheckModName(name string) error {
+	if !modNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid mod name %q: use letters, digits, - and _", name)
+	}
+	return nil
+}
+
+// modTitle derives the title of a mod from its name: "my-cool_mod" gives
+// "My Cool Mod".
+func modTitle(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool { return r == '-' || r == '_' })
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,270 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SettingsFiles are the files of a mod declaring its settings, in load order.
+var SettingsFiles = []string{"settings.lua", "settings-updates.lua", "settings-final-fixes.lua"}
+
+// SettingDefinition is a mod setting declared in a settings file.
+type SettingDefinition struct {
+	Name          string   // e.g. "my-mod-enabled"
+	Type          string   // e.g. "bool-setting"
+	SettingType   string   // "startup", "runtime-global" or "runtime-per-user"
+	AllowedValues []string // Of string settings, if restricted
+	File          string   // The file declaring it
+}
+
+// ScanSettings finds the settings the mod in dir declares in its settings files.
+// The files aren't run: they are scanned for table constructors with a type
+// ending in "-setting" and a literal name and setting_type, as passed to
+// data:extend. Settings whose names are computed (e.g. prefix .. "-enabled")
+// can't be found so; the tables skipped for that reason are returned as warnings.
+func ScanSettings(dir string) ([]SettingDefinition, []string, error) {
+	var settings []SettingDefinition
+	var warnings []string
+	for _, name := range SettingsFiles {
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		tokens, err := tokenizeLua(string(data))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for _, table := range luaTables(tokens) {
+			settingType, ok := table.fields["type"]
+			if !ok || !strings.HasSuffix(settingType, "-setting") {
+				continue
+			}
+			name, hasName := table.fields["name"]
+			scope, hasScope := table.fields["setting_type"]
+			if !hasName || !hasScope {
+				warnings = append(warnings, fmt.Sprintf("%s:%d: skipped a %s whose name or setting_type isn't a string literal", path, table.line, settingType))
+				continue
+			}
+			settings = append(settings, SettingDefinition{
+				Name:          name,
+				Type:          settingType,
+				SettingType:   scope,
+				AllowedValues: table.lists["allowed_values"],
+				File:          path,
+			})
+		}
+	}
+	return settings, warnings, nil
+}
+
+// luaToken is a token of Lua source: a name, a string (its value), a number or
+// a symbol.
+type luaToken struct {
+	kind byte // 'n'ame, 's'tring, '0' number or 'p'unctuation
+	text string
+	line int
+}
+
+// tokenizeLua splits Lua source into tokens, leaving out comments.
+func tokenizeLua(src string) ([]luaToken, error) {
+	var tokens []luaToken
+	line := 1
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "--"):
+			if level, ok := longBracket(src[i+2:]); ok {
+				end, err := skipLongBracket(src, i+2, level)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: unterminated comment", line)
+				}
+				line += strings.Count(src[i:end], "\n")
+				i = end
+				continue
+			}
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '"' || c == '\'':
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(src) && src[j] != c; j++ {
+				if src[j] == '\n' {
+					return nil, fmt.Errorf("line %d: unterminated string", line)
+				}
+				if src[j] == '\\' && j+1 < len(src) {
+					j++
+					switch src[j] {
+					case 'n':
+						sb.WriteByte('\n')
+					case 't':
+						sb.WriteByte('\t')
+					default:
+						sb.WriteByte(src[j]) // Good enough for names
+					}
+					continue
+				}
+				sb.WriteByte(src[j])
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("line %d: unterminated string", line)
+			}
+			tokens = append(tokens, luaToken{kind: 's', text: sb.String(), line: line})
+			i = j + 1
+		case c == '[':
+			if level, ok := longBracket(src[i:]); ok {
+				end, err := skipLongBracket(src, i, level)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: unterminated string", line)
+				}
+				text := src[i+level+2 : end-level-2]
+				tokens = append(tokens, luaToken{kind: 's', text: strings.TrimPrefix(text, "\n"), line: line})
+				line += strings.Count(src[i:end], "\n")
+				i = end
+				continue
+			}
+			tokens = append(tokens, luaToken{kind: 'p', text: "[", line: line})
+			i++
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			j := i
+			for j < len(src) && (src[j] == '_' || src[j] >= 'a' && src[j] <= 'z' || src[j] >= 'A' && src[j] <= 'Z' || src[j] >= '0' && src[j] <= '9') {
+				j++
+			}
+			tokens = append(tokens, luaToken{kind: 'n', text: src[i:j], line: line})
+			i = j
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			j := i + 1
+			for j < len(src) && (strings.IndexByte("0123456789abcdefABCDEFxX.pP", src[j]) >= 0 || (src[j] == '-' || src[j] == '+') && strings.IndexByte("eEpP", src[j-1]) >= 0) {
+				j++
+			}
+			tokens = append(tokens, luaToken{kind: '0', text: src[i:j], line: line})
+			i = j
+		default:
+			// Multi-character operators only matter for "..", which makes a
+			// concatenation of what looks like a literal.
+			text := src[i : i+1]
+			if strings.HasPrefix(src[i:], "..") {
+				text = ".."
+			}
+			tokens = append(tokens, luaToken{kind: 'p', text: text, line: line})
+			i += len(text)
+		}
+	}
+	return tokens, nil
+}
+
+// longBracket reports whether s starts with a long bracket, [[ or [=*[, and its level.
+func longBracket(s string) (int, bool) {
+	if !strings.HasPrefix(s, "[") {
+		return 0, false
+	}
+	level := 0
+	for level+1 < len(s) && s[level+1] == '=' {
+		level++
+	}
+	return level, level+1 < len(s) && s[level+1] == '['
+}
+
+// skipLongBracket returns the offset past the long bracket of the given level
+// starting at i.
+func skipLongBracket(src string, i int, level int) (int, error) {
+	closing := "]" + strings.Repeat("=", level) + "]"
+	end := strings.Index(src[i+level+2:], closing)
+	if end < 0 {
+		return 0, errors.New("unterminated long bracket")
+	}
+	return i + level + 2 + end + len(closing), nil
+}
+
+// luaTable is a table constructor of the source: the fields set to a single
+// string literal (name = "x") and to a list of them (allowed_values = {"a", "b"}).
+type luaTable struct {
+	line   int
+	fields map[string]string
+	lists  map[string][]string
+}
+
+// luaTables returns every table constructor of the tokens, nested ones included.
+func luaTables(tokens []luaToken) []luaTable {
+	var tables []luaTable
+	for i, token := range tokens {
+		if token.kind == 'p' && token.text == "{" {
+			tables = append(tables, parseLuaTable(tokens, i))
+		}
+	}
+	return tables
+}
+
+// parseLuaTable reads the top-level fields of the table constructor opening at
+// tokens[open].
+func parseLuaTable(tokens []luaToken, open int) luaTable {
+	table := luaTable{line: tokens[open].line, fields: make(map[string]string), lists: make(map[string][]string)}
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		token := tokens[i]
+		if token.kind == 'p' {
+			switch token.text {
+			case "{", "(", "[":
+				depth++
+			case "}", ")", "]":
+				depth--
+			}
+		}
+		if depth == 0 {
+			break
+		}
+		// A field of this table: name = value, the value ending the field.
+		if depth != 1 || token.kind != 'n' || i+2 >= len(tokens) || tokens[i+1].text != "=" || tokens[i+1].kind != 'p' {
+			continue
+		}
+		if i > 0 && tokens[i-1].kind == 'p' && tokens[i-1].text == "." {
+			continue // e.g. a.b = ... in a function body
+		}
+		value := tokens[i+2]
+		if value.kind == 's' && endsField(tokens, i+3) {
+			table.fields[token.text] = value.text
+		} else if value.kind == 'p' && value.text == "{" {
+			if list, ok := stringList(tokens, i+3); ok {
+				table.lists[token.text] = list
+			}
+		}
+	}
+	return table
+}
+
+// stringList reads a list of string literals starting at tokens[i], after its
+// opening brace. It fails if the list has anything else.
+func stringList(tokens []luaToken, i int) ([]string, bool) {
+	list := []string{}
+	for ; i < len(tokens); i++ {
+		token := tokens[i]
+		switch {
+		case token.kind == 'p' && token.text == "}":
+			return list, true
+		case token.kind == 's' && endsField(tokens, i+1):
+			list = append(list, token.text)
+		case token.kind == 'p' && (token.text == "," || token.text == ";"):
+		default:
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// endsField reports whether the token at i ends a field: the value before it is
+// complete, rather than e.g. the start of a concatenation.
+func endsField(tokens []luaToken, i int) bool {
+	return i < len(tokens) && tokens[i].kind == 'p' && (tokens[i].text == "," || tokens[i].text == ";" || tokens[i].text == "}")
+}
@@ -0,0 +1,89 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScanSettings(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"settings.lua": `local prefix = "my-mod"
+--[[ A commented out setting:
+{ type = "bool-setting", name = "old", setting_type = "startup" },
+]]
+data:extend({
+  {
+    type = "bool-setting",
+    name = "my-mod-enabled",
+    setting_type = "startup",
+    default_value = true, -- { type = "int-setting" }
+  },
+  {
+    type = 'string-setting',
+    name = "my-mod-mode",
+    setting_type = "runtime-global",
+    default_value = "fast",
+    allowed_values = { "fast", "slow"; [[safe]] },
+  },
+  {
+    type = "int-setting",
+    name = prefix .. "-radius",
+    setting_type = "runtime-per-user",
+    default_value = 3,
+  },
+  {
+    type = "double-setting",
+    name = "my-mod-" .. "ratio",
+    setting_type = "runtime-global",
+    default_value = 0.5,
+  },
+})
+`,
+		"settings-updates.lua": `data.raw["bool-setting"]["my-mod-enabled"].default_value = false
+data:extend{{type = "color-setting", name = "my-mod-color", setting_type = "runtime-per-user", default_value = {r = 1, g = 0, b = 0}}}
+`,
+		"data.lua": `data:extend{{type = "bool-setting", name = "not-a-settings-file", setting_type = "startup"}}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	settings, warnings, err := ScanSettings(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settingsFile, updatesFile := filepath.Join(dir, "settings.lua"), filepath.Join(dir, "settings-updates.lua")
+	want := []SettingDefinition{
+		{Name: "my-mod-enabled", Type: "bool-setting", SettingType: "startup", File: settingsFile},
+		{Name: "my-mod-mode", Type: "string-setting", SettingType: "runtime-global", AllowedValues: []string{"fast", "slow", "safe"}, File: settingsFile},
+		{Name: "my-mod-color", Type: "color-setting", SettingType: "runtime-per-user", File: updatesFile},
+	}
+	if !reflect.DeepEqual(settings, want) {
+		t.Errorf("ScanSettings =\n%+v\nwant\n%+v", settings, want)
+	}
+	if len(warnings) != 2 || !strings.HasPrefix(warnings[0], settingsFile+":19: skipped a int-setting") || !strings.HasPrefix(warnings[1], settingsFile+":25: skipped a double-setting") {
+		t.Errorf("warnings = %q", warnings)
+	}
+}
+
+func TestScanSettingsErrors(t *testing.T) {
+	for _, content := range []string{`data:extend{{name = "open}}`, "--[==[ unterminated ]]", "x = [[unterminated"} {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "settings.lua"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := ScanSettings(dir); err == nil {
+			t.Errorf("ScanSettings of %q succeeded", content)
+		}
+	}
+	// A mod without settings has none.
+	if settings, warnings, err := ScanSettings(t.TempDir()); err != nil || len(settings) != 0 || len(warnings) != 0 {
+		t.Errorf("ScanSettings of a mod without settings = %v, %v, %v", settings, warnings, err)
+	}
+}
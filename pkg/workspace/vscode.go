@@ -0,0 +1,139 @@
+package workspace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// InstallVSCode merges the settings for the generated definitions into a
+// workspace's .vscode/settings.json, creating it if needed: the library
+// directories are added to Lua.workspace.library, Lua.runtime.version is set to Factorio's Lua
+// version, FactorioGlobals are added to Lua.diagnostics.globals and, when plugin
+// isn't empty, Lua.runtime.plugin is set to it. The file is
+// edited in place, so other settings and comments are kept. It returns the path
+// of the file.
+func InstallVSCode(dir string, libraries []string, plugin string) (string, error) {
+	path := filepath.Join(dir, ".vscode", "settings.json")
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	doc, err := newJSONCDocument(string(data))
+	if err != nil {
+		return "", fmt.Errorf("can't merge into %s: %w", path, err)
+	}
+	if err := doc.appendList("Lua.workspace.library", libraries...); err != nil {
+		return "", err
+	}
+	if err := doc.set("Lua.runtime.version", LuaVersion); err != nil {
+		return "", err
+	}
+	if err := doc.appendList("Lua.diagnostics.globals", FactorioGlobals...); err != nil {
+		return "", err
+	}
+	if plugin != "" {
+		if err := doc.set("Lua.runtime.plugin", plugin); err != nil {
+			return "", err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, []byte(doc.text), 0644)
+}
+
+// LaunchConfigName is the name of the debug configuration InstallLaunch writes,
+// which it replaces on the next run.
+const LaunchConfigName = "Factorio Mod Debug"
+
+// LaunchConfig is a launch configuration of the Factorio Mod Debug extension
+// (FMTK, justarandomgeek.factoriomod-debug), which runs Factorio with its debug
+// adapter hooked into a mod.
+type LaunchConfig struct {
+	Mod          string // The mod debugged, by name
+	ModsDir      string // The mods directory Factorio runs with, holding the mod
+	FactorioPath string // The Factorio executable; empty uses the extension's active version
+}
+
+// launchConfiguration is the configuration of a LaunchConfig in launch.json.
+type launchConfiguration struct {
+	Type         string          `json:"type"`
+	Request      string          `json:"request"`
+	Name         string          `json:"name"`
+	FactorioPath string          `json:"factorioPath,omitempty"`
+	ModsPath     string          `json:"modsPath"`
+	HookControl  []string        `json:"hookControl"`
+	AdjustMods   map[string]bool `json:"adjustMods"`
+}
+
+// InstallLaunch merges a debug configuration for the mod into a workspace's
+// .vscode/launch.json, creating it if needed. The configuration named
+// LaunchConfigName is replaced, and the others kept; comments outside the
+// configurations are kept too. The configuration hooks the debug adapter into
+// the mod's control stage and enables the mod when Factorio starts. It returns
+// the path of the file.
+func InstallLaunch(dir string, launch LaunchConfig) (string, error) {
+	path := filepath.Join(dir, ".vscode", "launch.json")
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	doc, err := newJSONCDocument(string(data))
+	if err != nil {
+		return "", fmt.Errorf("can't merge into %s: %w", path, err)
+	}
+	if _, ok, err := doc.member("version"); err != nil {
+		return "", err
+	} else if !ok {
+		if err := doc.set("version", "0.2.0"); err != nil {
+			return "", err
+		}
+	}
+
+	// The other configurations are kept as they are, key order included.
+	var configurations []json.RawMessage
+	if member, ok, err := doc.member("configurations"); err != nil {
+		return "", err
+	} else if ok {
+		if err := json.Unmarshal([]byte(stripJSONC(doc.text[member.valueStart:member.valueEnd])), &configurations); err != nil {
+			return "", fmt.Errorf("configurations of %s is not a list", path)
+		}
+	}
+	configuration, err := json.Marshal(launchConfiguration{
+		Type:         "factoriomod",
+		Request:      "launch",
+		Name:         LaunchConfigName,
+		FactorioPath: launch.FactorioPath,
+		ModsPath:     launch.ModsDir,
+		HookControl:  []string{launch.Mod},
+		AdjustMods:   map[string]bool{launch.Mod: true},
+	})
+	if err != nil {
+		return "", err
+	}
+	replaced := false
+	for i, existing := range configurations {
+		var named struct {
+			Name string `json:"name"`
+		}
+		if json.Unmarshal(existing, &named) == nil && named.Name == LaunchConfigName {
+			configurations[i] = configuration
+			replaced = true
+		}
+	}
+	if !replaced {
+		configurations = append(configurations, configuration)
+	}
+	if err := doc.set("configurations", configurations); err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, []byte(doc.text), 0644)
+}
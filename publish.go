@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/generator"
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/release"
+	"github.com/spf13/cobra"
+)
+
+var (
+	publishRepo    string
+	publishTag     string
+	publishArchive string
+	publishAPIURL  string
+	publishDryRun  bool
+)
+
+var publishCmd = &cobra.Command{
+	Use:   "publish",
+	Short: "Package previously generated definitions and upload them to a GitHub release",
+	Long: `Zips the --output directory with a manifest.json listing the game versions (from
+the lockfile), the generator version and the checksum of every file, and uploads
+the zip to the release of --tag in --repo, creating the release if needed. The tag
+defaults to factorio-<game version>. The token is read from GITHUB_TOKEN (or
+GH_TOKEN) and needs write access to the repository's contents. With --dry-run,
+only the zip is written.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		log.SetOutput(os.Stdout)
+		log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
+
+		token := os.Getenv("GITHUB_TOKEN")
+		if token == "" {
+			token = os.Getenv("GH_TOKEN")
+		}
+		if !publishDryRun {
+			if owner, name, ok := strings.Cut(publishRepo, "/"); !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+				log.Fatalf("Fatal error: --repo must be owner/name, got %q", publishRepo)
+			}
+			if token == "" {
+				log.Fatalf("Fatal error: set GITHUB_TOKEN to a token allowed to create releases in %s", publishRepo)
+			}
+		}
+		if info, err := os.Stat(outputDir); err != nil || !info.IsDir() {
+			log.Fatalf("Fatal error: no definitions in %s, generate them first", outputDir)
+		}
+
+		// The game versions come from the lockfile every generation writes.
+		path := lockPath
+		if path == "" {
+			path = filepath.Join(outputDir, lockFileName)
+		}
+		var gameVersions []string
+		if lock, err := readLockFile(path); err == nil {
+			for _, t := range lock.Targets {
+				for _, document := range []*lockDocument{t.Runtime, t.Prototype} {
+					if document != nil && document.GameVersion != "" && !slices.Contains(gameVersions, document.GameVersion) {
+						gameVersions = append(gameVersions, document.GameVersion)
+					}
+				}
+			}
+		} else if !os.IsNotExist(err) {
+			log.Fatalf("Fatal error reading the lockfile: %v", err)
+		}
+		tag := publishTag
+		if tag == "" {
+			if len(gameVersions) != 1 {
+				log.Fatalf("Fatal error: %s doesn't record a single game version, set --tag", path)
+			}
+			tag = "factorio-" + gameVersions[0]
+		}
+
+		manifest := &release.Manifest{Name: "factorio-api-definitions", GameVersions: gameVersions, GeneratorVersion: generator.Version}
+		var archive bytes.Buffer
+		if err := release.Package(outputDir, manifest, &archive); err != nil {
+			log.Fatalf("Fatal error packaging %s: %v", outputDir, err)
+		}
+		assetName := fmt.Sprintf("factorio-definitions-%s.zip", strings.TrimPrefix(tag, "factorio-"))
+		archivePath := publishArchive
+		if archivePath == "" {
+			archivePath = assetName
+		}
+		if err := os.WriteFile(archivePath, archive.Bytes(), 0644); err != nil {
+			log.Fatalf("Fatal error writing %s: %v", archivePath, err)
+		}
+		log.Printf("Packaged %d files into %s.", len(manifest.Files), archivePath)
+		if publishDryRun {
+			return
+		}
+
+		notes := fmt.Sprintf("Lua definitions of the Factorio API, generated by factorio-api-gen %s.", generator.Version)
+		if len(gameVersions) > 0 {
+			notes = fmt.Sprintf("Lua definitions of the Factorio %s API, generated by factorio-api-gen %s.", strings.Join(gameVersions, ", "), generator.Version)
+		}
+		github := &release.GitHub{APIURL: strings.TrimSuffix(publishAPIURL, "/"), Repo: publishRepo, Token: token}
+		page, err := github.Publish(release.Release{Tag: tag, Name: tag, Notes: notes}, filepath.Base(archivePath), "application/zip", archive.Bytes())
+		if err != nil {
+			log.Fatalf("Fatal error publishing to %s: %v", publishRepo, err)
+		}
+		log.Printf("Published %s to %s.", filepath.Base(archivePath), page)
+	},
+}
+
+func init() {
+	publishCmd.Flags().StringVar(&publishRepo, "repo", "", "GitHub repository to publish to, as owner/name")
+	publishCmd.Flags().StringVar(&publishTag, "tag", "", "Tag of the release (default: factorio-<game version> from the lockfile)")
+	publishCmd.Flags().StringVar(&publishArchive, "archive", "", "Path of the zip, also the name of the release asset (default: factorio-definitions-<version>.zip in the current directory)")
+	publishCmd.Flags().StringVar(&publishAPIURL, "github-api", release.DefaultGitHubAPIURL, "GitHub REST API address, for GitHub Enterprise servers")
+	publishCmd.Flags().BoolVar(&publishDryRun, "dry-run", false, "Only write the zip, without uploading it")
+	rootCmd.AddCommand(publishCmd)
+}
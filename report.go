@@ -0,0 +1,133 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/generator"
+)
+
+// runReport is the --report of a generation, for the programs running the
+// generator (e.g. bots regenerating definitions nightly): what it was generated
+// from, with which options, and what it wrote.
+type runReport struct {
+	GeneratorVersion string         `json:"generator_version"`
+	Started          time.Time      `json:"started"`
+	Finished         time.Time      `json:"finished"`
+	Output           string         `json:"output"` // The output directory, or archive
+	OutputFormat     string         `json:"output_format"`
+	Formats          []string       `json:"formats"`
+	Lockfile         string         `json:"lockfile,omitempty"`
+	Targets          []reportTarget `json:"targets"`
+}
+
+// reportTarget is the part of the report about one target, i.e. one version
+// of --factorio-version.
+type reportTarget struct {
+	Output    string        `json:"output"` // Like the lockfile's
+	Runtime   *lockDocument `json:"runtime,omitempty"`
+	Prototype *lockDocument `json:"prototype,omitempty"`
+	// The files written, in the order they were written; their paths are relative
+	// to the target's directory or, in an archive, to the target's place in it.
+	Files      []reportFile         `json:"files"`
+	Statistics reportStatistics     `json:"statistics"`
+	Collisions []string             `json:"collisions"`
+	Warnings   generator.TypeReport `json:"warnings"` // As written by --warnings-report
+}
+
+// reportFile is a file written by a generation.
+type reportFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// reportStatistics counts what the definitions of a target were generated from
+// and into.
+type reportStatistics struct {
+	Classes        int   `json:"classes"`
+	Events         int   `json:"events"`
+	Concepts       int   `json:"concepts"`
+	Defines        int   `json:"defines"`
+	GlobalObjects  int   `json:"global_objects"`
+	Prototypes     int   `json:"prototypes"`
+	PrototypeTypes int   `json:"prototype_types"`
+	Files          int   `json:"files"`
+	Bytes          int64 `json:"bytes"`
+}
+
+// generationReport is the report of the generation in progress, with --report.
+var generationReport *runReport
+
+// addStatistics counts the definitions of the API documents of a target.
+func (s *reportStatistics) addStatistics(runtimeAPI *api.API, prototypeAPI *api.API) {
+	if runtimeAPI != nil {
+		s.Classes = len(runtimeAPI.Classes)
+		s.Events = len(runtimeAPI.Events)
+		s.Concepts = len(runtimeAPI.Concepts)
+		s.Defines = len(runtimeAPI.Defines)
+		s.GlobalObjects = len(runtimeAPI.GlobalObjects)
+	}
+	if prototypeAPI != nil {
+		s.Prototypes = len(prototypeAPI.Prototypes)
+		s.PrototypeTypes = len(prototypeAPI.Types)
+	}
+}
+
+// reportCreator wraps a FileCreator to record the files written in the target
+// report t.
+func reportCreator(create generator.FileCreator, t *reportTarget) generator.FileCreator {
+	return func(filename string) (io.WriteCloser, error) {
+		w, err := create(filename)
+		if err != nil {
+			return nil, err
+		}
+		return &reportedFile{WriteCloser: w, hash: sha256.New(), path: filename, target: t}, nil
+	}
+}
+
+// reportedFile hashes a file as it is written, and adds it to the report once
+// it is closed.
+type reportedFile struct {
+	io.WriteCloser
+	hash   hash.Hash
+	size   int64
+	path   string
+	target *reportTarget
+}
+
+func (f *reportedFile) Write(p []byte) (int, error) {
+	n, err := f.WriteCloser.Write(p)
+	f.hash.Write(p[:n])
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *reportedFile) Close() error {
+	if err := f.WriteCloser.Close(); err != nil {
+		return err
+	}
+	f.target.Files = append(f.target.Files, reportFile{Path: f.path, Size: f.size, SHA256: fmt.Sprintf("%x", f.hash.Sum(nil))})
+	f.target.Statistics.Files++
+	f.target.Statistics.Bytes += f.size
+	return nil
+}
+
+// writeRunReport writes the report, indented like the lockfile.
+func writeRunReport(path string, report *runReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
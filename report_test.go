@@ -0,0 +1,87 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "factorio")
+	reportPath := filepath.Join(root, "report", "generation.json")
+	args := slices.Concat(fixtureFlags(t), []string{"--output", dir, "--report", reportPath})
+	if code, output := runCommand(t, args...); code != 0 {
+		t.Fatalf("generating exited with %d:\n%s", code, output)
+	}
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The shape programs reading the report rely on.
+	var document map[string]any
+	if err := json.Unmarshal(data, &document); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"generator_version", "started", "finished", "output", "output_format", "formats", "targets"} {
+		if _, ok := document[key]; !ok {
+			t.Errorf("the report has no %q: %s", key, data)
+		}
+	}
+	targets, _ := document["targets"].([]any)
+	if len(targets) != 1 {
+		t.Fatalf("the report has %d targets, want 1", len(targets))
+	}
+	target, _ := targets[0].(map[string]any)
+	for _, key := range []string{"output", "runtime", "prototype", "files", "statistics", "collisions", "warnings"} {
+		if _, ok := target[key]; !ok {
+			t.Errorf("the report's target has no %q", key)
+		}
+	}
+
+	var report runReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Output != dir || report.OutputFormat != outputToDir {
+		t.Errorf("report output = %s (%s), want %s (%s)", report.Output, report.OutputFormat, dir, outputToDir)
+	}
+	if report.Finished.Before(report.Started) {
+		t.Errorf("report finished at %v, before it started at %v", report.Finished, report.Started)
+	}
+	reported := report.Targets[0]
+	if reported.Runtime == nil || reported.Prototype == nil {
+		t.Error("the report's target doesn't name its API documents")
+	}
+	if reported.Statistics.Classes == 0 || reported.Statistics.Prototypes == 0 {
+		t.Errorf("report statistics = %+v", reported.Statistics)
+	}
+	if reported.Statistics.Files != len(reported.Files) {
+		t.Errorf("report statistics count %d files, and lists %d", reported.Statistics.Files, len(reported.Files))
+	}
+
+	// The files listed are those written, as they were written.
+	var bytes int64
+	for _, file := range reported.Files {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file.Path)))
+		if err != nil {
+			t.Errorf("reported file: %v", err)
+			continue
+		}
+		if file.Size != int64(len(content)) || file.SHA256 != fmt.Sprintf("%x", sha256.Sum256(content)) {
+			t.Errorf("reported %s: %d bytes, sha256 %s; written: %d bytes, sha256 %x", file.Path, file.Size, file.SHA256, len(content), sha256.Sum256(content))
+		}
+		bytes += file.Size
+	}
+	if reported.Statistics.Bytes != bytes {
+		t.Errorf("report statistics count %d bytes, the files %d", reported.Statistics.Bytes, bytes)
+	}
+	if !slices.ContainsFunc(reported.Files, func(file reportFile) bool { return file.Path == "runtime.lua" }) {
+		t.Errorf("runtime.lua isn't reported: %+v", reported.Files)
+	}
+}
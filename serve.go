@@ -0,0 +1,59 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/lsp"
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run a language server for the Factorio API over stdio",
+	Long: `Downloads the runtime and prototype APIs (--runtime-url, --prototype-url; --only
+limits it to one stage) and answers LSP requests on stdin/stdout: hovers, completion
+and signature help for the global objects, classes, defines, events and data.raw,
+straight from the API. Run it standalone, or next to LuaLS for the API documentation.
+With --read-only-diagnostics, assignments to read-only runtime attributes are
+reported as errors. Logs go to stderr.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Stdout carries the protocol.
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
+
+		if only != "" && only != "runtime" && only != "prototype" {
+			log.Fatalf("Fatal error: unknown --only %q (expected %q or %q)", only, "runtime", "prototype")
+		}
+		var runtimeAPI *api.API
+		if only != "prototype" {
+			runtimeAPI = &api.API{}
+			if err := api.DownloadAndParseAPI(runtimeURL, runtimeAPI); err != nil {
+				log.Fatalf("Fatal error downloading/parsing runtime API from %s: %v", runtimeURL, err)
+			}
+		}
+		var prototypeAPI *api.API
+		if only != "runtime" {
+			prototypeAPI = &api.API{}
+			if err := api.DownloadAndParseAPI(prototypeURL, prototypeAPI); err != nil {
+				log.Fatalf("Fatal error downloading/parsing prototype API from %s: %v", prototypeURL, err)
+			}
+		}
+
+		log.Println("Serving the Factorio API over stdio.")
+		server := lsp.NewServer(runtimeAPI, prototypeAPI)
+		server.ReadOnlyDiagnostics = readOnlyDiagnostics
+		if err := server.Serve(os.Stdin, os.Stdout); err != nil {
+			log.Fatalf("Fatal error serving: %v", err)
+		}
+	},
+}
+
+var readOnlyDiagnostics bool
+
+func init() {
+	serveCmd.Flags().BoolVar(&readOnlyDiagnostics, "read-only-diagnostics", false, "Report assignments to read-only runtime attributes (e.g. game.player.name = ...) in the open documents as errors")
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/defserver"
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/generator"
+	"github.com/spf13/cobra"
+)
+
+var (
+	httpAddress string
+	httpPort    int
+)
+
+// servedVersion matches the versions serve-http generates when --factorio-version
+// doesn't restrict them.
+var servedVersion = regexp.MustCompile(`^(latest|\d+\.\d+\.\d+)$`)
+
+var serveHTTPCmd = &cobra.Command{
+	Use:   "serve-http",
+	Short: "Serve the generated definitions and documentation of any game version over HTTP",
+	Long: `Serves the LuaLS definitions, the JSON IR (ir.json), the Markdown reference
+(docs/) and the HTML site (site/) of game versions at /<version>/, e.g.
+/2.0.45/runtime.lua or /latest/site/index.html. A version is generated on its first
+request, from the documents of --runtime-url and --prototype-url with /latest/
+replaced by the version, and kept in memory; the documents are cached like with
+--factorio-version. --factorio-version restricts the versions served. /<version>/
+lists the files of a version and / the versions generated so far, as JSON.
+The definitions are generated with the default options; --only limits them to one
+stage.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		log.SetOutput(os.Stdout)
+		log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
+
+		if only != "" && only != "runtime" && only != "prototype" {
+			log.Fatalf("Fatal error: unknown --only %q (expected %q or %q)", only, "runtime", "prototype")
+		}
+		for _, version := range versions {
+			if !servedVersion.MatchString(version) {
+				log.Fatalf("Fatal error: invalid --factorio-version %q (expected e.g. 2.0.28)", version)
+			}
+		}
+		allowed := func(version string) bool {
+			if len(versions) > 0 {
+				return slices.Contains(versions, version)
+			}
+			return servedVersion.MatchString(version)
+		}
+
+		cacheDir := ""
+		if cache := apiCache(); cache != nil {
+			cacheDir = cache.Dir
+		}
+		server := defserver.NewServer(func(version string) (map[string]string, error) {
+			log.Printf("Generating the definitions of version %s...", version)
+			options := generator.DefaultOptions()
+			options.Formats = []generator.Format{generator.FormatLuaLS, generator.FormatIR, generator.FormatMarkdown, generator.FormatHTML}
+			if only != "prototype" {
+				options.RuntimeURL = strings.Replace(runtimeURL, "/latest/", "/"+version+"/", 1)
+			}
+			if only != "runtime" {
+				options.PrototypeURL = strings.Replace(prototypeURL, "/latest/", "/"+version+"/", 1)
+			}
+			options.CacheDir = cacheDir
+			options.Logger = log.Default()
+			result, err := generator.Run(context.Background(), options)
+			if err != nil {
+				log.Printf("Failed to generate version %s: %v", version, err)
+				return nil, err
+			}
+			log.Printf("Generated %d files for version %s.", len(result.Files), version)
+			return result.Files, nil
+		}, allowed)
+
+		address := fmt.Sprintf("%s:%d", httpAddress, httpPort)
+		log.Printf("Serving the definitions on http://%s/", address)
+		if err := http.ListenAndServe(address, server); err != nil {
+			log.Fatalf("Fatal error serving: %v", err)
+		}
+	},
+}
+
+func init() {
+	serveHTTPCmd.Flags().StringVar(&httpAddress, "address", "", "Address to listen on (default: every interface)")
+	serveHTTPCmd.Flags().IntVar(&httpPort, "port", 8080, "Port to listen on")
+	rootCmd.AddCommand(serveHTTPCmd)
+}
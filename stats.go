@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/apistats"
+	"github.com/spf13/cobra"
+)
+
+var statsJSON bool
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Summarize the API documents without generating definitions",
+	Long: `Downloads the documents of --runtime-url and --prototype-url (--only limits it to
+one stage) and prints, for each, how many classes, methods, attributes, events,
+defines, concepts, prototypes and properties it documents and how many of them are
+deprecated, how its types are distributed by depth (named types have depth 0, an
+array of them 1, ...), the complex_types it uses, and those the generator doesn't
+parse, with where they are. The documents are read as plain JSON, so a document
+the generator can't parse is summarized too, with the parse error; run it when a
+game version is released to gauge how the schema changed.
+
+--json prints the summaries as a JSON array instead.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Stdout carries the summaries.
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
+
+		if only != "" && only != "runtime" && only != "prototype" {
+			log.Fatalf("Fatal error: unknown --only %q (expected %q or %q)", only, "runtime", "prototype")
+		}
+		var urls []string
+		if only != "prototype" {
+			urls = append(urls, runtimeURL)
+		}
+		if only != "runtime" {
+			urls = append(urls, prototypeURL)
+		}
+
+		cache := apiCache()
+		var summaries []*apistats.Stats
+		for _, url := range urls {
+			body, err := cache.Fetch(url)
+			if err != nil {
+				log.Fatalf("Fatal error downloading %s: %v", url, err)
+			}
+			stats, err := apistats.Analyze(body)
+			if err != nil {
+				log.Fatalf("Fatal error reading %s: %v", url, err)
+			}
+			summaries = append(summaries, stats)
+		}
+
+		if statsJSON {
+			data, err := json.MarshalIndent(summaries, "", "  ")
+			if err != nil {
+				log.Fatalf("Fatal error encoding the summaries: %v", err)
+			}
+			fmt.Println(string(data))
+			return
+		}
+		for i, stats := range summaries {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Print(stats.Text())
+		}
+	},
+}
+
+func init() {
+	statsCmd.Flags().BoolVar(&statsJSON, "json", false, "Print the summaries as JSON")
+	rootCmd.AddCommand(statsCmd)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/api"
+)
+
+// watchReleases polls the API every --watch-interval and, when it documents
+// another game version than the definitions were generated from, regenerates
+// them and runs --post-hook. It runs until the process is stopped. A failed poll
+// or regeneration is retried at the next interval, so the API being down or a
+// full disk doesn't stop the watch.
+func watchReleases(lock *lockFile, regenerate func() (*lockFile, error)) {
+	current := lockedGameVersion(lock)
+	// The runtime document is polled unless --only prototype left it out.
+	url := runtimeURL
+	if only == "prototype" {
+		url = prototypeURL
+	}
+	log.Printf("Watching %s for a new game version every %s (generated: %s).", url, watchInterval, current)
+	client := &api.Client{}
+	poll := func() (string, error) {
+		document, err := client.Load(context.Background(), url)
+		if err != nil {
+			return "", err
+		}
+		return document.ApplicationVersion, nil
+	}
+	for {
+		time.Sleep(watchInterval)
+		current = checkRelease(current, poll, regenerate)
+	}
+}
+
+// checkRelease polls the API once and regenerates the definitions if it
+// documents another game version than current, returning the version the
+// definitions are generated from afterwards. Failures are logged and leave it
+// current, so the next poll tries again.
+func checkRelease(current string, poll func() (string, error), regenerate func() (*lockFile, error)) string {
+	version, err := poll()
+	if err != nil {
+		log.Printf("Warning: failed to poll the API: %v", err)
+		return current
+	}
+	if version == current {
+		log.Printf("No new game version (still %s).", current)
+		return current
+	}
+	log.Printf("Game version %s was released (generated: %s), regenerating the definitions...", version, current)
+	lock, err := regenerate()
+	if err != nil {
+		log.Printf("Warning: failed to regenerate the definitions, retrying at the next poll: %v", err)
+		return current
+	}
+	current = lockedGameVersion(lock)
+	if postHook != "" {
+		runPostHook(current)
+	}
+	return current
+}
+
+// lockedGameVersion returns the game version the definitions of a lockfile were
+// generated from.
+func lockedGameVersion(lock *lockFile) string {
+	for _, t := range lock.Targets {
+		for _, document := range []*lockDocument{t.Runtime, t.Prototype} {
+			if document != nil && document.GameVersion != "" {
+				return document.GameVersion
+			}
+		}
+	}
+	return ""
+}
+
+// runPostHook runs --post-hook with the shell, telling it the version and where
+// the definitions are. A failing hook is logged: the next release runs it again.
+func runPostHook(version string) {
+	log.Printf("Running the post-hook: %s", postHook)
+	cmd := exec.Command("sh", "-c", postHook)
+	cmd.Env = append(os.Environ(), "FACTORIO_VERSION="+version, "FACTORIO_API_OUTPUT="+outputDir)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		log.Printf("Warning: the post-hook failed: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bry-guy/factorio-lsp-plugin/pkg/generator"
+)
+
+// A failed regeneration keeps the watch going: the version stays the one
+// generated, so the next poll tries again.
+func TestCheckReleaseRetries(t *testing.T) {
+	polls := []struct {
+		version       string
+		pollErr       error
+		regenerateErr error
+		want          string
+		regenerated   bool
+	}{
+		{pollErr: errors.New("503 Service Unavailable"), want: "2.0.45"},
+		{version: "2.0.45", want: "2.0.45"},
+		{version: "2.0.46", regenerateErr: errors.New("no space left on device"), want: "2.0.45", regenerated: true},
+		{version: "2.0.46", want: "2.0.46", regenerated: true},
+		{version: "2.0.46", want: "2.0.46"},
+	}
+	current := "2.0.45"
+	for i, p := range polls {
+		regenerated := false
+		poll := func() (string, error) { return p.version, p.pollErr }
+		regenerate := func() (*lockFile, error) {
+			regenerated = true
+			if p.regenerateErr != nil {
+				return nil, p.regenerateErr
+			}
+			return &lockFile{Targets: []lockTarget{{Runtime: &lockDocument{GameVersion: p.version}}}}, nil
+		}
+		current = checkRelease(current, poll, regenerate)
+		if current != p.want || regenerated != p.regenerated {
+			t.Errorf("poll %d: version %s, regenerated %v; want %s, %v", i, current, regenerated, p.want, p.regenerated)
+		}
+	}
+}
+
+// The generation reports a failed download instead of exiting, for --watch.
+func TestGenerateTargetsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	targets := []target{{runtimeURL: server.URL + "/runtime-api.json", prototypeURL: server.URL + "/prototype-api.json", dir: dir}}
+	lock, err := generateTargets(generator.DefaultOptions(), targets, nil, dir)
+	if err == nil || lock != nil {
+		t.Fatalf("generateTargets = %v, %v; want an error", lock, err)
+	}
+	if !strings.Contains(err.Error(), "runtime-api.json") {
+		t.Errorf("error doesn't name the document: %v", err)
+	}
+}